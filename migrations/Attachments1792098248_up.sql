@@ -0,0 +1,14 @@
+CREATE TABLE attachments
+(
+    id           UUID PRIMARY KEY,
+    owner_type   VARCHAR(255)  NOT NULL,
+    owner_id     UUID          NOT NULL,
+    name         VARCHAR(255)  NOT NULL,
+    content_type VARCHAR(255)  NOT NULL,
+    size         BIGINT        NOT NULL,
+    storage_key  VARCHAR(1024) NOT NULL,
+    created_by   UUID          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT current_timestamp,
+    tenant_id    VARCHAR(255)  NOT NULL DEFAULT 'default'
+);
+CREATE INDEX attachments_tenant_id_owner_idx ON attachments (tenant_id, owner_type, owner_id);
@@ -0,0 +1,11 @@
+CREATE TABLE audit_log
+(
+    id              UUID PRIMARY KEY,
+    actor_id        UUID         NOT NULL,
+    impersonated_id UUID,
+    action          VARCHAR(255) NOT NULL,
+    details         JSONB        NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    tenant_id       VARCHAR(255) NOT NULL DEFAULT 'default'
+);
+CREATE INDEX audit_log_tenant_id_created_at_idx ON audit_log (tenant_id, created_at DESC);
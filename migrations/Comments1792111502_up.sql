@@ -0,0 +1,12 @@
+CREATE TABLE comments
+(
+    id          UUID PRIMARY KEY,
+    target_type VARCHAR(255) NOT NULL,
+    target_id   UUID         NOT NULL,
+    parent_id   UUID REFERENCES comments (id) ON DELETE CASCADE,
+    body        TEXT         NOT NULL,
+    created_by  UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    tenant_id   VARCHAR(255) NOT NULL DEFAULT 'default'
+);
+CREATE INDEX comments_tenant_id_target_idx ON comments (tenant_id, target_type, target_id, created_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS event_outbox_dead_at_idx;
+DROP INDEX IF EXISTS event_outbox_pending_idx;
+CREATE INDEX event_outbox_pending_idx ON event_outbox (created_at) WHERE published_at IS NULL;
+ALTER TABLE event_outbox
+    DROP COLUMN IF EXISTS dead_at;
+ALTER TABLE event_outbox
+    DROP COLUMN IF EXISTS next_attempt_at;
\ No newline at end of file
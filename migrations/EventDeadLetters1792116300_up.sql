@@ -0,0 +1,7 @@
+ALTER TABLE event_outbox
+    ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp;
+ALTER TABLE event_outbox
+    ADD COLUMN dead_at TIMESTAMPTZ;
+DROP INDEX IF EXISTS event_outbox_pending_idx;
+CREATE INDEX event_outbox_pending_idx ON event_outbox (next_attempt_at) WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX event_outbox_dead_at_idx ON event_outbox (dead_at) WHERE dead_at IS NOT NULL;
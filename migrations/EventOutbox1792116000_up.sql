@@ -0,0 +1,13 @@
+CREATE TABLE event_outbox
+(
+    id           UUID PRIMARY KEY,
+    event_id     VARCHAR(255) NOT NULL,
+    payload      JSONB        NOT NULL,
+    dedup_key    VARCHAR(255) NOT NULL UNIQUE,
+    attempts     INT          NOT NULL DEFAULT 0,
+    last_error   TEXT,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    published_at TIMESTAMPTZ,
+    tenant_id    VARCHAR(255) NOT NULL DEFAULT 'default'
+);
+CREATE INDEX event_outbox_pending_idx ON event_outbox (created_at) WHERE published_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS gallery_flags;
+DROP TABLE IF EXISTS gallery_entries;
\ No newline at end of file
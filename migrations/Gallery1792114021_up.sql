@@ -0,0 +1,26 @@
+CREATE TABLE gallery_entries
+(
+    id              UUID PRIMARY KEY,
+    template_set_id UUID          REFERENCES template_sets (id) ON DELETE SET NULL,
+    name            VARCHAR(255)  NOT NULL,
+    version         VARCHAR(255)  NOT NULL,
+    description     TEXT          NOT NULL DEFAULT '',
+    license         VARCHAR(100)  NOT NULL,
+    templates       JSONB         NOT NULL,
+    installs        INTEGER       NOT NULL DEFAULT 0,
+    hidden          BOOLEAN       NOT NULL DEFAULT FALSE,
+    published_by    UUID          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    published_at    TIMESTAMPTZ   NOT NULL DEFAULT current_timestamp,
+    tenant_id       VARCHAR(255)  NOT NULL DEFAULT 'default',
+    UNIQUE (tenant_id, published_by, name, version)
+);
+CREATE INDEX gallery_entries_tenant_id_name_idx ON gallery_entries (tenant_id, lower(name));
+
+CREATE TABLE gallery_flags
+(
+    entry_id   UUID         NOT NULL REFERENCES gallery_entries (id) ON DELETE CASCADE,
+    user_id    UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason     TEXT         NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (entry_id, user_id)
+);
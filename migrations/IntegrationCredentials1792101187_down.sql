@@ -0,0 +1 @@
+DROP TABLE IF EXISTS integration_credentials;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE integration_credentials
+(
+    id          UUID PRIMARY KEY,
+    user_id     UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    integration VARCHAR(255) NOT NULL,
+    name        VARCHAR(255) NOT NULL,
+    secret      TEXT         NOT NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at  TIMESTAMPTZ,
+    tenant_id   VARCHAR(255) NOT NULL DEFAULT 'default',
+    CONSTRAINT integration_credentials_tenant_id_user_id_integration_name_key UNIQUE (tenant_id, user_id, integration, name)
+);
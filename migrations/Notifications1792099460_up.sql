@@ -0,0 +1,11 @@
+CREATE TABLE notifications
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type       VARCHAR(255) NOT NULL,
+    payload    JSONB        NOT NULL DEFAULT '{}',
+    read_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    tenant_id  VARCHAR(255) NOT NULL DEFAULT 'default'
+);
+CREATE INDEX notifications_tenant_id_user_id_idx ON notifications (tenant_id, user_id, created_at DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE requirements
+    DROP COLUMN IF EXISTS project_id;
+DROP TABLE IF EXISTS project_template_sets;
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
\ No newline at end of file
@@ -0,0 +1,31 @@
+CREATE TABLE projects
+(
+    id          UUID PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    created_by  UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at  TIMESTAMPTZ,
+    tenant_id   VARCHAR(255) NOT NULL DEFAULT 'default'
+);
+
+CREATE TABLE project_members
+(
+    project_id UUID         NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    user_id    UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (project_id, user_id)
+);
+CREATE INDEX project_members_user_id_idx ON project_members (user_id);
+
+CREATE TABLE project_template_sets
+(
+    project_id   UUID NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    template_set UUID NOT NULL REFERENCES template_sets (id) ON DELETE CASCADE,
+    PRIMARY KEY (project_id, template_set)
+);
+
+ALTER TABLE requirements
+    ADD COLUMN project_id UUID REFERENCES projects (id) ON DELETE SET NULL;
+CREATE INDEX requirements_tenant_id_project_id_idx ON requirements (tenant_id, project_id, created_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS requirement_sequences;
+ALTER TABLE requirements
+    DROP COLUMN IF EXISTS identifier;
\ No newline at end of file
@@ -0,0 +1,26 @@
+ALTER TABLE requirements
+    ADD COLUMN identifier VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE TABLE requirement_sequences
+(
+    tenant_id VARCHAR(255) NOT NULL,
+    scope     UUID         NOT NULL,
+    last      BIGINT       NOT NULL,
+    PRIMARY KEY (tenant_id, scope)
+);
+
+-- existing requirements are numbered in the order of their creation per project, requirements without a project per user
+WITH numbered AS (SELECT id,
+                         ROW_NUMBER() OVER (PARTITION BY tenant_id, COALESCE(project_id, created_by) ORDER BY created_at, id) AS number
+                  FROM requirements)
+UPDATE requirements r
+SET identifier = 'REQ-' || LPAD(n.number::TEXT, GREATEST(4, LENGTH(n.number::TEXT)), '0')
+FROM numbered n
+WHERE n.id = r.id;
+
+INSERT INTO requirement_sequences (tenant_id, scope, last)
+SELECT tenant_id, COALESCE(project_id, created_by), COUNT(*)
+FROM requirements
+GROUP BY tenant_id, COALESCE(project_id, created_by);
+
+CREATE INDEX requirements_tenant_id_identifier_idx ON requirements (tenant_id, identifier);
@@ -0,0 +1,2 @@
+ALTER TABLE requirements
+    DROP COLUMN IF EXISTS output;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE requirements
+    ADD COLUMN output TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS requirement_reviews;
+ALTER TABLE requirements
+    DROP COLUMN IF EXISTS reviewer;
+ALTER TABLE requirements
+    DROP COLUMN IF EXISTS state;
\ No newline at end of file
@@ -0,0 +1,17 @@
+ALTER TABLE requirements
+    ADD COLUMN state VARCHAR(255) NOT NULL DEFAULT 'draft';
+ALTER TABLE requirements
+    ADD COLUMN reviewer UUID REFERENCES users (id) ON DELETE SET NULL;
+CREATE INDEX requirements_tenant_id_reviewer_state_idx ON requirements (tenant_id, reviewer, state);
+
+CREATE TABLE requirement_reviews
+(
+    id             UUID PRIMARY KEY,
+    requirement_id UUID         NOT NULL REFERENCES requirements (id) ON DELETE CASCADE,
+    from_state     VARCHAR(255) NOT NULL,
+    to_state       VARCHAR(255) NOT NULL,
+    comment        TEXT         NOT NULL DEFAULT '',
+    created_by     UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp
+);
+CREATE INDEX requirement_reviews_requirement_id_idx ON requirement_reviews (requirement_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS requirement_tags;
+DROP TABLE IF EXISTS requirements;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE requirements
+(
+    id          UUID PRIMARY KEY,
+    template_id UUID         NOT NULL,
+    template    VARCHAR(255) NOT NULL,
+    variant     VARCHAR(255) NOT NULL,
+    text        TEXT         NOT NULL,
+    segments    JSONB        NOT NULL DEFAULT '[]',
+    created_by  UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    updated_at  TIMESTAMPTZ,
+    tenant_id   VARCHAR(255) NOT NULL DEFAULT 'default'
+);
+CREATE INDEX requirements_tenant_id_created_by_idx ON requirements (tenant_id, created_by, created_at);
+
+CREATE TABLE requirement_tags
+(
+    requirement_id UUID         NOT NULL REFERENCES requirements (id) ON DELETE CASCADE,
+    tag            VARCHAR(255) NOT NULL,
+    automatic      BOOLEAN      NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (requirement_id, tag)
+);
+CREATE INDEX requirement_tags_tag_idx ON requirement_tags (tag);
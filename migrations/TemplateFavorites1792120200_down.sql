@@ -0,0 +1 @@
+DROP TABLE IF EXISTS template_favorites;
\ No newline at end of file
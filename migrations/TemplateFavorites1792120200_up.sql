@@ -0,0 +1,10 @@
+CREATE TABLE template_favorites
+(
+    user_id     UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    template_id UUID         NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+    tenant_id   VARCHAR(255) NOT NULL DEFAULT 'default',
+    pinned      BOOLEAN      NOT NULL DEFAULT FALSE,
+    used_at     TIMESTAMPTZ,
+    PRIMARY KEY (tenant_id, user_id, template_id)
+);
+CREATE INDEX template_favorites_template_id_idx ON template_favorites (template_id);
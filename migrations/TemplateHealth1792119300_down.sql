@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS template_rule_stats;
+DROP TABLE IF EXISTS template_parse_stats;
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE template_parse_stats
+(
+    template_id UUID         NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+    tenant_id   VARCHAR(255) NOT NULL DEFAULT 'default',
+    parses      BIGINT       NOT NULL DEFAULT 0,
+    failed      BIGINT       NOT NULL DEFAULT 0,
+    since       TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (tenant_id, template_id)
+);
+
+CREATE TABLE template_rule_stats
+(
+    template_id    UUID         NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+    tenant_id      VARCHAR(255) NOT NULL DEFAULT 'default',
+    rule           VARCHAR(255) NOT NULL,
+    errors         BIGINT       NOT NULL DEFAULT 0,
+    warnings       BIGINT       NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (tenant_id, template_id, rule)
+);
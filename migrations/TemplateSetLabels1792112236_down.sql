@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS template_set_label_assignments;
+DROP TABLE IF EXISTS template_set_labels;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE template_set_labels
+(
+    id         UUID PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    created_by UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    tenant_id  VARCHAR(255) NOT NULL DEFAULT 'default',
+    UNIQUE (tenant_id, created_by, name)
+);
+
+CREATE TABLE template_set_label_assignments
+(
+    template_set_id UUID NOT NULL REFERENCES template_sets (id) ON DELETE CASCADE,
+    label_id        UUID NOT NULL REFERENCES template_set_labels (id) ON DELETE CASCADE,
+    PRIMARY KEY (template_set_id, label_id)
+);
+CREATE INDEX template_set_label_assignments_label_id_idx ON template_set_label_assignments (label_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS template_set_sources;
\ No newline at end of file
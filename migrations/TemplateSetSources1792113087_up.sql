@@ -0,0 +1,11 @@
+CREATE TABLE template_set_sources
+(
+    template_set_id UUID PRIMARY KEY REFERENCES template_sets (id) ON DELETE CASCADE,
+    kind            VARCHAR(16)   NOT NULL,
+    url             VARCHAR(2048) NOT NULL,
+    ref             VARCHAR(255)  NOT NULL DEFAULT '',
+    path            VARCHAR(1024) NOT NULL DEFAULT '',
+    revision        VARCHAR(64)   NOT NULL,
+    synced_at       TIMESTAMPTZ   NOT NULL DEFAULT current_timestamp,
+    tenant_id       VARCHAR(255)  NOT NULL DEFAULT 'default'
+);
@@ -0,0 +1,2 @@
+ALTER TABLE template_set_sources
+    DROP COLUMN IF EXISTS base;
\ No newline at end of file
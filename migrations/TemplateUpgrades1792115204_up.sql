@@ -0,0 +1,2 @@
+ALTER TABLE template_set_sources
+    ADD COLUMN base JSONB NOT NULL DEFAULT '{}';
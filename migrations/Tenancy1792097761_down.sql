@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS templates_tenant_id_template_set_idx;
+ALTER TABLE templates
+    DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS template_sets_tenant_id_created_by_idx;
+ALTER TABLE template_sets
+    DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_tenant_id_email_key;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users
+    ADD CONSTRAINT users_email_key UNIQUE (email);
@@ -0,0 +1,17 @@
+ALTER TABLE users
+    ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE users
+    DROP CONSTRAINT users_email_key;
+ALTER TABLE users
+    ADD CONSTRAINT users_tenant_id_email_key UNIQUE (tenant_id, email);
+
+ALTER TABLE sessions
+    ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+
+ALTER TABLE template_sets
+    ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+CREATE INDEX template_sets_tenant_id_created_by_idx ON template_sets (tenant_id, created_by);
+
+ALTER TABLE templates
+    ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+CREATE INDEX templates_tenant_id_template_set_idx ON templates (tenant_id, template_set);
@@ -0,0 +1,9 @@
+CREATE TABLE user_preferences
+(
+    user_id    UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    key        VARCHAR(255) NOT NULL,
+    value      JSONB        NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT current_timestamp,
+    tenant_id  VARCHAR(255) NOT NULL DEFAULT 'default',
+    PRIMARY KEY (user_id, key)
+);
@@ -0,0 +1 @@
+DROP INDEX sessions_tenant_id_user_idx;
\ No newline at end of file
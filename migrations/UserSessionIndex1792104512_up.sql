@@ -0,0 +1 @@
+CREATE INDEX sessions_tenant_id_user_idx ON sessions (tenant_id, type, (payload ->> 'ID'));
@@ -13,6 +13,15 @@ document.addEventListener('htmx:afterSettle', autoResizeInput);
 document.addEventListener('DOMContentLoaded', registerOutputEmptyBtn);
 document.addEventListener('htmx:afterSettle', registerOutputEmptyBtn);
 
+document.addEventListener('DOMContentLoaded', registerRequirementsExport);
+document.addEventListener('htmx:afterSettle', registerRequirementsExport);
+
+document.addEventListener('DOMContentLoaded', registerTemplateEvents);
+document.addEventListener('htmx:afterSettle', registerTemplateEvents);
+
+document.addEventListener('DOMContentLoaded', initGuidedMode);
+document.addEventListener('htmx:afterSettle', initGuidedMode);
+
 document.addEventListener('htmx:afterRequest', requirementParsed);
 document.addEventListener('newRequirementEvent', newRequirement);
 document.addEventListener('emptyRequirementsEvent', emptyRequirements);
@@ -32,6 +41,8 @@ function registerFocuses() {
         // focus search input if the search modal was loaded or the search results were loaded
         // unfortunately, chromium takes the focus from the input when the results are loaded, so we need to refocus
         if (event.detail.elt.id !== 'eiffelTemplateSearch' && event.detail.elt.id !== 'eiffelTemplateSearchResults') return;
+        // results loaded by changing a filter keep the focus on the filter
+        if (event.detail.elt.id === 'eiffelTemplateSearchResults' && document.activeElement && document.activeElement.closest('.eiffel-template-search-filters')) return;
         focusSearchInput();
     })
 
@@ -75,15 +86,18 @@ function registerShortcuts() {
     });
 
     // alt + enter to submit elicitation form - we might as well keep the alt when we already use it everywhere else
+    // the key can be configured per template and user, it is read from the form (see template.UISettings)
     document.addEventListener('keydown', function (event) {
-        if (event.key === 'Enter' && event.altKey) {
-            event.preventDefault();
+        if (!event.altKey) return;
 
-            const elicitationForm = document.getElementById('eiffelElicitationForm');
-            if (!elicitationForm) return;
+        const elicitationForm = document.getElementById('eiffelElicitationForm');
+        if (!elicitationForm) return;
 
-            elicitationForm.querySelector('button[type="submit"]').click(); // to trigger the hx-post on the button
-        }
+        const hotkey = elicitationForm.dataset.eiffelParseHotkey || 'Enter';
+        if (event.key.toLowerCase() !== hotkey.toLowerCase()) return;
+
+        event.preventDefault();
+        elicitationForm.querySelector('button[type="submit"]').click(); // to trigger the hx-post on the button
     });
 
     // alt + -> next variant - strg + -> would collide with selecting text in the inputs
@@ -223,6 +237,32 @@ function registerOutputEmptyBtn() {
     outputEmptyBtn.dataset.eiffelStatus = 'setup';
 }
 
+function registerRequirementsExport() {
+    const exportForm = document.getElementById('eiffelRequirementsExportForm');
+    if (!exportForm || exportForm.dataset.eiffelStatus === 'setup') return;
+
+    // the recently captured requirements are only stored on the device, they are sent along for the export
+    exportForm.addEventListener('submit', function () {
+        const itemNodes = document.querySelectorAll('#eiffelRequirementsListWrapper li.eiffel-requirements-list-item');
+        const requirements = [];
+        itemNodes.forEach(itemNode => {
+            const key = itemNode.dataset.eiffelRequirementKey;
+            if (!key) return;
+
+            const exported = localStorage.getItem(exportKey(key));
+            requirements.unshift(exported ? JSON.parse(exported) : {requirement: itemNode.innerText});
+        });
+
+        exportForm.querySelector('input[name="requirements"]').value = JSON.stringify(requirements);
+    });
+
+    exportForm.dataset.eiffelStatus = 'setup';
+}
+
+function exportKey(requirementKey) {
+    return requirementKey.replace('eiffel-requirement-', 'eiffel-export-');
+}
+
 function copyOutputToClipboard(event) {
     const target = event.target;
     if (!target) return;
@@ -239,7 +279,8 @@ function clearElicitationForm() {
     const elicitationForm = document.getElementById('eiffelElicitationForm');
     if (!elicitationForm) return;
 
-    const inputs = elicitationForm.querySelectorAll('input:not([type="hidden"]):not([disabled]), textarea:not([disabled])');
+    // the tags are kept for the next requirement
+    const inputs = elicitationForm.querySelectorAll('input:not([type="hidden"]):not([disabled]):not([name="requirement-tags"]), textarea:not([disabled])');
     inputs.forEach(input => {
         input.value = '';
         input.dispatchEvent(new Event('change'));
@@ -283,13 +324,32 @@ function requirementParsed(event) {
     const parsingSuccessEvent = event.parsingSuccessEvent;
     if (!parsingSuccessEvent) return;
 
-    const requirement = parsingSuccessEvent.requirement;
+    let requirement = parsingSuccessEvent.requirement;
     if (!requirement) return;
 
+    // requirements are listed in the output format of their template and with their identifier like they are copied to the clipboard
+    if (event.export && event.export.output) {
+        requirement = event.export.output;
+    }
+    if (event.export && event.export.identifier) {
+        requirement = `${event.export.identifier}: ${requirement}`;
+    }
+
+    // files attached to the requirement are listed below the requirement
+    const attachments = event.attachments || [];
+    if (attachments.length > 0) {
+        requirement += '\n' + attachments.map(attachment => `${attachment.name}: ${attachment.url}`).join('\n');
+    }
+
     const timestamp = Date.now();
     let key = `eiffel-requirement-${timestamp}`;
     localStorage.setItem(key, requirement);
 
+    // the requirement's template, variant and segments are kept separately for exports
+    if (event.export) {
+        localStorage.setItem(exportKey(key), JSON.stringify(event.export));
+    }
+
     document.dispatchEvent(new CustomEvent('newRequirementEvent', {
         detail: {
             requirement: requirement,
@@ -380,6 +440,7 @@ function cleanRequirementsList(items, max) {
     const keysToDelete = keys.slice(0, keys.length - max);
     keysToDelete.forEach(key => {
         localStorage.removeItem(key);
+        localStorage.removeItem(exportKey(key));
     });
     console.info(`Removed ${keysToDelete.length} requirements from local storage.`);
 
@@ -466,4 +527,83 @@ function updateRequirementCount(reset = false) {
     if (newCount > EiffelMaxRequirementsInLocalStorage) {
         currentCountElem.classList.add('text-danger');
     }
-}
\ No newline at end of file
+}
+
+let eiffelTemplateEventSource = null;
+
+// subscribes to the server-sent events of the current template and shows a notice if the template changed
+function registerTemplateEvents() {
+    const notice = document.getElementById('eiffelTemplateEvents');
+    const url = notice ? notice.dataset.eiffelEventsUrl : null;
+
+    if (eiffelTemplateEventSource && eiffelTemplateEventSource.url.endsWith(url)) return;
+
+    if (eiffelTemplateEventSource) {
+        eiffelTemplateEventSource.close();
+        eiffelTemplateEventSource = null;
+    }
+
+    if (!url || !window.EventSource) return;
+
+    eiffelTemplateEventSource = new EventSource(url);
+
+    const show = (selector) => {
+        const current = document.getElementById('eiffelTemplateEvents');
+        if (!current) return;
+
+        current.querySelectorAll('.eiffel-template-updated, .eiffel-template-deleted').forEach(el => el.classList.add('d-none'));
+        current.querySelector(selector).classList.remove('d-none');
+        current.classList.remove('d-none');
+    };
+
+    eiffelTemplateEventSource.addEventListener('template-updated', () => show('.eiffel-template-updated'));
+    eiffelTemplateEventSource.addEventListener('template-deleted', () => show('.eiffel-template-deleted'));
+}
+
+// in guided mode the rules of the elicitation form are displayed one at a time
+// the form is submitted on the last rule, each rule is validated on input through the segment endpoint
+function initGuidedMode() {
+    const form = document.querySelector('#eiffelElicitationForm[data-eiffel-guided]');
+    if (!form || form.dataset.eiffelStatus === 'setup') return;
+
+    const steps = Array.from(form.querySelectorAll('[data-eiffel-guided-step]'));
+    if (steps.length === 0) return;
+
+    const prevBtn = form.querySelector('.eiffel-guided-prev');
+    const nextBtn = form.querySelector('.eiffel-guided-next');
+    const currentElem = form.querySelector('.eiffel-guided-current');
+    const submit = form.querySelector('.eiffel-guided-submit');
+
+    // start at the first rule with violations after the form was submitted
+    let current = steps.findIndex(step => step.querySelector('.is-invalid'));
+    if (current < 0) current = 0;
+
+    const show = function (index) {
+        current = Math.min(Math.max(index, 0), steps.length - 1);
+        steps.forEach((step, i) => step.classList.toggle('d-none', i !== current));
+
+        if (currentElem) currentElem.innerText = (current + 1).toString();
+        if (prevBtn) prevBtn.disabled = current === 0;
+        if (nextBtn) nextBtn.classList.toggle('d-none', current === steps.length - 1);
+        if (submit) submit.classList.toggle('d-none', current !== steps.length - 1);
+
+        const input = steps[current].querySelector('input:not([type="hidden"]):not([disabled]), textarea:not([disabled])');
+        if (input) input.focus();
+    };
+
+    if (prevBtn) prevBtn.addEventListener('click', () => show(current - 1));
+    if (nextBtn) nextBtn.addEventListener('click', () => show(current + 1));
+
+    // enter moves on to the next rule instead of submitting the form - except for textareas and on the last rule
+    form.addEventListener('keydown', function (event) {
+        if (event.key !== 'Enter' || event.altKey || event.target.tagName === 'TEXTAREA') return;
+        if (current === steps.length - 1) return;
+
+        event.preventDefault();
+        show(current + 1);
+    });
+
+    show(current);
+
+    form.dataset.eiffelStatus = 'setup';
+}
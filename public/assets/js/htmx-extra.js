@@ -1,13 +1,41 @@
-// redirect to login page if session expired
-document.addEventListener('htmx:afterRequest', function(event) {
-    if (event.detail.isError) return;
-
+// swap error pages rendered by the server (X-Harmony-Error header) although they are sent with an error status code
+document.addEventListener('htmx:beforeSwap', function(event) {
     const request = event.detail.xhr;
-    if (!request) return;
+    if (!request || request.getResponseHeader('X-Harmony-Error') !== 'true') return;
+
+    event.detail.shouldSwap = true;
+    event.detail.isError = false;
+});
+
+// display toasts triggered by the server (HX-Trigger header with the harmony:toast event)
+document.body.addEventListener('harmony:toast', function(event) {
+    let container = document.querySelector('.harmony-toasts');
+    if (!container) {
+        container = document.createElement('div');
+        container.className = 'harmony-toasts toast-container position-fixed bottom-0 end-0 p-3';
+        document.body.appendChild(container);
+    }
+
+    const toast = document.createElement('div');
+    toast.className = 'toast align-items-center border-0 text-bg-' + (event.detail.level || 'danger');
+    toast.setAttribute('role', 'alert');
+    toast.setAttribute('aria-live', 'assertive');
+    toast.setAttribute('aria-atomic', 'true');
+
+    const wrapper = document.createElement('div');
+    wrapper.className = 'd-flex';
+    const body = document.createElement('div');
+    body.className = 'toast-body';
+    body.textContent = event.detail.message;
+    const close = document.createElement('button');
+    close.type = 'button';
+    close.className = 'btn-close btn-close-white me-2 m-auto';
+    close.setAttribute('data-bs-dismiss', 'toast');
 
-    const isLoginPage = request.responseURL.includes('/auth/login');
-    if (!isLoginPage) return;
+    wrapper.append(body, close);
+    toast.appendChild(wrapper);
+    container.appendChild(toast);
 
-    // TODO would a toast be better?
-    window.location.href = '/auth/login';
-})
\ No newline at end of file
+    toast.addEventListener('hidden.bs.toast', function() { toast.remove(); });
+    bootstrap.Toast.getOrCreateInstance(toast).show();
+});
\ No newline at end of file
@@ -0,0 +1,19 @@
+// applies the color theme before the page is rendered to prevent flashing the wrong theme
+// the server sets data-harmony-theme on the html element to either light, dark or system
+(function () {
+    const root = document.documentElement;
+    const media = window.matchMedia('(prefers-color-scheme: dark)');
+
+    function applyTheme() {
+        const theme = root.dataset.harmonyTheme || 'system';
+        if (theme !== 'system') {
+            root.dataset.bsTheme = theme;
+            return;
+        }
+
+        root.dataset.bsTheme = media.matches ? 'dark' : 'light';
+    }
+
+    applyTheme();
+    media.addEventListener('change', applyTheme);
+})();
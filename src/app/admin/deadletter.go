@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/outbox"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+)
+
+// MaxDeadLetters is the maximum number of dead letters listed at once, the most recently dead-lettered first.
+const MaxDeadLetters = 100
+
+// DeadLetter is a dead-lettered event of the outbox listed in the administration. PayloadJSON is the indented payload.
+// Events without a registered outbox.Decoder are not Redeliverable.
+type DeadLetter struct {
+	*outbox.Message
+	PayloadJSON   string
+	Redeliverable bool
+}
+
+func deadLetterListController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		if _, _, err := adminFlags(io); err != nil {
+			return io.Error(err)
+		}
+
+		deadLetters, err := findDeadLetters(io)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		return io.Render(
+			web.NewFormData(deadLetters, nil),
+			"admin.dead-letters.page",
+			"admin/dead-letters-page.go.html",
+			"admin/_dead-letters.go.html",
+		)
+	})
+}
+
+func deadLetterRedeliverController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		// errors are rendered into the messages instead of replacing the whole list
+		errorTarget := web.ErrorInto(".admin-dead-letters-messages")
+
+		_, subject, err := adminFlags(io)
+		if err != nil {
+			return io.InlineErrorTo(errorTarget, err)
+		}
+
+		id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+		if err != nil {
+			return io.InlineErrorTo(errorTarget, web.NotFound(err))
+		}
+
+		repository, err := web.Repository[outbox.Repository](io, outbox.RepositoryName)
+		if err != nil {
+			return io.InlineErrorTo(errorTarget, web.ErrInternal, err)
+		}
+
+		err = repository.Redeliver(io.Context(), id)
+		if errors.Is(err, persistence.ErrNotFound) {
+			return io.InlineErrorTo(errorTarget, web.NotFound(err))
+		}
+		if err != nil {
+			return io.InlineErrorTo(errorTarget, web.ErrInternal, err)
+		}
+
+		appCtx.Info(Pkg, "dead letter re-delivered", "messageID", id, "by", subject.Email)
+
+		deadLetters, err := findDeadLetters(io)
+		if err != nil {
+			return io.InlineErrorTo(errorTarget, web.ErrInternal, err)
+		}
+
+		return io.Render(
+			web.NewFormData(deadLetters, []string{"admin.dead-letters.redelivered"}),
+			"admin.dead-letters",
+			"admin/_dead-letters.go.html",
+		)
+	})
+}
+
+// findDeadLetters finds the most recent dead letters of all tenants, see MaxDeadLetters.
+func findDeadLetters(io web.IO) ([]*DeadLetter, error) {
+	repository, err := web.Repository[outbox.Repository](io, outbox.RepositoryName)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := repository.FindDead(io.Context(), MaxDeadLetters)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]*DeadLetter, 0, len(messages))
+	for _, m := range messages {
+		payload := &bytes.Buffer{}
+		if json.Indent(payload, m.Payload, "", "  ") != nil {
+			payload.Reset()
+			payload.Write(m.Payload)
+		}
+
+		deadLetters = append(deadLetters, &DeadLetter{
+			Message:       m,
+			PayloadJSON:   payload.String(),
+			Redeliverable: outbox.Registered(m.EventID),
+		})
+	}
+
+	return deadLetters, nil
+}
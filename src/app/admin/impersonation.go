@@ -0,0 +1,190 @@
+package admin
+
+import (
+	"errors"
+	"github.com/org-harmony/harmony/src/app/audit"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/auth"
+	"github.com/org-harmony/harmony/src/core/feature"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"strings"
+)
+
+// MaxAuditEntries is the maximum number of audit log entries listed on the impersonation page, the newest first.
+const MaxAuditEntries = 50
+
+var (
+	// ErrImpersonationUnknownUser is displayed if no user with the email exists.
+	ErrImpersonationUnknownUser = errors.New("admin.impersonation.error.unknown-user")
+	// ErrImpersonationSelf is displayed if administrators try to impersonate themselves.
+	ErrImpersonationSelf = errors.New("admin.impersonation.error.self")
+	// ErrImpersonationAdmin is displayed if the user to impersonate is an administrator (feature.RoleAdmin) as well.
+	ErrImpersonationAdmin = errors.New("admin.impersonation.error.admin")
+)
+
+// ImpersonationPage is the data of the impersonation page. Email is the email entered into the form.
+type ImpersonationPage struct {
+	Email   string
+	Entries []*audit.Entry
+}
+
+func impersonationPageController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		if _, _, err := adminFlags(io); err != nil {
+			return io.Error(err)
+		}
+
+		return renderImpersonationPage(io, "")
+	})
+}
+
+// impersonationStartController starts impersonating the user of the form value email. The impersonation session is
+// a new session of the user replacing the session cookie, the administrator's session is rotated, kept and restored
+// when the impersonation is stopped (see user.Impersonate and impersonationStopController). Administrators can not impersonate other administrators.
+func impersonationStartController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	sessionStore := user.SessionStore(appCtx)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		flags, subject, err := adminFlags(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		request := io.Request()
+		email := strings.TrimSpace(request.FormValue("email"))
+		admin := user.MustFromIO(io)
+
+		adminSession, err := user.SessionFromRequest(request, sessionStore)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		userRepository, err := web.Repository[user.Repository](io, user.RepositoryName)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		impersonated, err := userRepository.FindByEmail(io.Context(), email)
+		if errors.Is(err, persistence.ErrNotFound) {
+			return renderImpersonationPage(io, email, ErrImpersonationUnknownUser)
+		}
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		if impersonated.ID == admin.ID {
+			return renderImpersonationPage(io, email, ErrImpersonationSelf)
+		}
+		if flags.HasRole(feature.Subject{ID: impersonated.ID.String(), Email: impersonated.Email, Tenant: tenant.ID(io.Context())}, feature.RoleAdmin) {
+			return renderImpersonationPage(io, email, ErrImpersonationAdmin)
+		}
+
+		session, err := user.Impersonate(
+			io.Context(),
+			adminSession,
+			impersonated,
+			sessionStore,
+			user.WithDeviceName(user.DeviceName(request.UserAgent())),
+		)
+		// the administrator's session was rotated, the previous session cookie is rejected
+		if err != nil {
+			adminSession.SetCookie(io.Response())
+			return io.Error(web.ErrInternal, err)
+		}
+
+		err = recordImpersonation(io, audit.ActionImpersonationStart, admin, impersonated)
+		if err != nil {
+			_ = sessionStore.Delete(io.Context(), session.ID)
+			adminSession.SetCookie(io.Response())
+			return io.Error(web.ErrInternal, err)
+		}
+
+		appCtx.Info(Pkg, "impersonation started", "user", impersonated.Email, "by", subject.Email)
+
+		session.SetCookie(io.Response())
+
+		return io.Redirect("/", http.StatusSeeOther)
+	})
+}
+
+// impersonationStopController stops the impersonation of the request's session. The impersonation session is deleted
+// and the administrator's session restored under a rotated id (see user.StopImpersonation). If the administrator's
+// session expired meanwhile, the administrator is logged out.
+func impersonationStopController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	sessionStore := user.SessionStore(appCtx)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		impersonator, ok := user.CtxImpersonator(io.Context())
+		if !ok {
+			return io.Error(web.Forbidden(nil))
+		}
+
+		impersonated := user.MustFromIO(io)
+
+		session, err := user.SessionFromRequest(io.Request(), sessionStore)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		adminSession, stopErr := user.StopImpersonation(io.Context(), session, sessionStore)
+		if stopErr != nil && !errors.Is(stopErr, user.ErrHardSessionExpiry) && !errors.Is(stopErr, persistence.ErrNotFound) {
+			return io.Error(web.ErrInternal, stopErr)
+		}
+
+		err = recordImpersonation(io, audit.ActionImpersonationStop, &user.User{ID: impersonator.UserID, Email: impersonator.Email}, impersonated)
+		if err != nil {
+			appCtx.Error(Pkg, "failed to record stopped impersonation in audit log", err, "user", impersonated.Email, "by", impersonator.Email)
+		}
+
+		appCtx.Info(Pkg, "impersonation stopped", "user", impersonated.Email, "by", impersonator.Email)
+
+		if stopErr != nil {
+			auth.ClearSession(io.Response(), user.SessionCookieName)
+			return io.Redirect("/auth/login", http.StatusSeeOther)
+		}
+
+		adminSession.SetCookie(io.Response())
+
+		return io.Redirect("/admin/impersonation", http.StatusSeeOther)
+	})
+}
+
+// recordImpersonation records the start or stop of an impersonation in the audit log with the emails of both users.
+func recordImpersonation(io web.IO, action string, admin *user.User, impersonated *user.User) error {
+	repository, err := web.Repository[audit.Repository](io, audit.RepositoryName)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.Create(io.Context(), &audit.Entry{
+		ActorID:        admin.ID,
+		ImpersonatedID: &impersonated.ID,
+		Action:         action,
+		Details:        map[string]string{"admin": admin.Email, "user": impersonated.Email},
+	})
+
+	return err
+}
+
+// renderImpersonationPage renders the impersonation page with the recent audit log entries and the errors as violations.
+func renderImpersonationPage(io web.IO, email string, errs ...error) error {
+	repository, err := web.Repository[audit.Repository](io, audit.RepositoryName)
+	if err != nil {
+		return io.Error(web.ErrInternal, err)
+	}
+
+	entries, err := repository.FindRecent(io.Context(), MaxAuditEntries)
+	if err != nil {
+		return io.Error(web.ErrInternal, err)
+	}
+
+	return io.Render(
+		web.NewFormData(&ImpersonationPage{Email: email, Entries: entries}, nil, errs...),
+		"admin.impersonation.page",
+		"admin/impersonation-page.go.html",
+	)
+}
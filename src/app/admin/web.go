@@ -0,0 +1,184 @@
+// Package admin provides the administration pages of HARMONY. They are restricted to users with the feature.RoleAdmin role.
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/feature"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+)
+
+// Pkg is the package name used for logging.
+const Pkg = "app.admin"
+
+// ErrNoFeatureFlags is displayed if the request context contains no feature flags, see feature.Middleware.
+var ErrNoFeatureFlags = errors.New("admin.features.error.no-flags")
+
+// RegisterController registers the administration pages and the navigation's permission checker.
+// It registers the following routes, all of them require the feature.RoleAdmin role (see adminFlags):
+//   - GET /admin/features For listing the feature flags and their states.
+//   - POST /admin/features/{name} For toggling a feature flag at runtime (form value state: on, off or reset).
+//   - GET /admin/translations For reporting the missing translation keys per locale since the application started.
+//   - GET /admin/dead-letters For listing the events dead-lettered in the outbox (see outbox.DeadLetter).
+//   - POST /admin/dead-letters/{id}/redeliver For re-delivering a dead letter through the outbox.Relay.
+//   - GET /admin/impersonation For starting an impersonation and listing the audit log.
+//   - POST /admin/impersonation For impersonating the user of the form value email in a separate session.
+//
+// POST /admin/impersonation/stop only requires an impersonation session (see user.CtxImpersonator) as the impersonated user is no administrator.
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx, translators trans.TranslatorProvider) {
+	registerNavigation(webCtx)
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/admin/features", featureListController(appCtx, webCtx).ServeHTTP)
+	router.Post("/admin/features/{name}", featureToggleController(appCtx, webCtx).ServeHTTP)
+	router.Get("/admin/translations", translationReportController(appCtx, webCtx, translators).ServeHTTP)
+	router.Get("/admin/dead-letters", deadLetterListController(appCtx, webCtx).ServeHTTP)
+	router.Post("/admin/dead-letters/{id}/redeliver", deadLetterRedeliverController(appCtx, webCtx).ServeHTTP)
+	router.Get("/admin/impersonation", impersonationPageController(appCtx, webCtx).ServeHTTP)
+	router.Post("/admin/impersonation", impersonationStartController(appCtx, webCtx).ServeHTTP)
+	router.Post("/admin/impersonation/stop", impersonationStopController(appCtx, webCtx).ServeHTTP)
+}
+
+// registerNavigation adds the administration to the navigation. NavItem.Permission is checked as a role of the request's subject.
+func registerNavigation(webCtx *web.Ctx) {
+	webCtx.Navigation.SetPermissionChecker(func(io web.IO, permission string) (bool, error) {
+		flags, subject, ok := feature.FromContext(io.Context())
+		return ok && flags.HasRole(subject, permission), nil
+	})
+
+	webCtx.Navigation.Add("admin.features", web.NavItem{
+		URL:        "/admin/features",
+		Name:       "harmony.menu.admin",
+		Permission: feature.RoleAdmin,
+		Display: func(io web.IO) (bool, error) {
+			return true, nil
+		},
+		Position: 1050,
+	})
+
+	webCtx.Navigation.Add("admin.translations", web.NavItem{
+		URL:        "/admin/translations",
+		Name:       "harmony.menu.admin-translations",
+		Permission: feature.RoleAdmin,
+		Display: func(io web.IO) (bool, error) {
+			return true, nil
+		},
+		Position: 1051,
+	})
+
+	webCtx.Navigation.Add("admin.dead-letters", web.NavItem{
+		URL:        "/admin/dead-letters",
+		Name:       "harmony.menu.admin-dead-letters",
+		Permission: feature.RoleAdmin,
+		Display: func(io web.IO) (bool, error) {
+			return true, nil
+		},
+		Position: 1052,
+	})
+
+	webCtx.Navigation.Add("admin.impersonation", web.NavItem{
+		URL:        "/admin/impersonation",
+		Name:       "harmony.menu.admin-impersonation",
+		Permission: feature.RoleAdmin,
+		Display: func(io web.IO) (bool, error) {
+			return true, nil
+		},
+		Position: 1053,
+	})
+}
+
+func featureListController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		flags, subject, err := adminFlags(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		return io.Render(
+			web.NewFormData(flags.States(subject), nil),
+			"admin.features.page",
+			"admin/features-page.go.html",
+		)
+	})
+}
+
+func featureToggleController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		// errors are rendered into the messages instead of replacing the whole list
+		errorTarget := web.ErrorInto(".admin-features-messages")
+
+		flags, subject, err := adminFlags(io)
+		if err != nil {
+			return io.InlineErrorTo(errorTarget, err)
+		}
+
+		name := web.URLParam(io.Request(), "name")
+		state := io.Request().FormValue("state")
+
+		switch state {
+		case "on", "off":
+			err = flags.Set(subject.Tenant, name, state == "on")
+		case "reset":
+			err = flags.Reset(subject.Tenant, name)
+		default:
+			return io.InlineErrorTo(errorTarget, web.NewHTTPError(http.StatusBadRequest, nil, fmt.Errorf("invalid feature flag state %q", state)))
+		}
+		if errors.Is(err, feature.ErrUnknownFlag) {
+			return io.InlineErrorTo(errorTarget, web.NotFound(err))
+		}
+		if err != nil {
+			return io.InlineErrorTo(errorTarget, web.ErrInternal, err)
+		}
+
+		appCtx.Info(Pkg, "feature flag toggled", "flag", name, "state", state, "tenant", subject.Tenant, "by", subject.Email)
+
+		return io.RenderBlock(
+			web.NewFormData(flags.States(subject), []string{"admin.features.toggled"}),
+			web.PartialTemplateName,
+			"admin.features",
+			"admin/features-page.go.html",
+		)
+	})
+}
+
+func translationReportController(appCtx *hctx.AppCtx, webCtx *web.Ctx, translators trans.TranslatorProvider) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		if _, _, err := adminFlags(io); err != nil {
+			return io.Error(err)
+		}
+
+		return io.Render(
+			web.NewFormData(trans.Reports(translators), nil),
+			"admin.translations.page",
+			"admin/translations-page.go.html",
+		)
+	})
+}
+
+// IsAdmin returns a function reporting whether the user has the feature.RoleAdmin role in the request's tenant.
+// It is passed to user.RotateOnPrivilegeChange to rotate the sessions of users gaining or losing the role.
+func IsAdmin(flags *feature.Flags) func(r *http.Request, u *user.User) bool {
+	return func(r *http.Request, u *user.User) bool {
+		return flags.HasRole(feature.Subject{ID: u.ID.String(), Email: u.Email, Tenant: tenant.ID(r.Context())}, feature.RoleAdmin)
+	}
+}
+
+// adminFlags returns the feature flags and the subject of the request. It returns a forbidden error (see web.Forbidden)
+// unless the subject has the feature.RoleAdmin role and ErrNoFeatureFlags if the request context contains no feature flags.
+func adminFlags(io web.IO) (*feature.Flags, feature.Subject, error) {
+	flags, subject, ok := feature.FromContext(io.Context())
+	if !ok {
+		return nil, subject, ErrNoFeatureFlags
+	}
+
+	if !flags.HasRole(subject, feature.RoleAdmin) {
+		return nil, subject, web.Forbidden(nil)
+	}
+
+	return flags, subject, nil
+}
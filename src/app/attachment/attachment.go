@@ -0,0 +1,206 @@
+// Package attachment allows users to attach files, e.g. diagrams or stakeholder documents, to templates and elicited requirements.
+// The files are stored in a storage.Store, the metadata of the attachments is stored in the database.
+package attachment
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/storage"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"time"
+)
+
+// TODO delete attachments (and their files) of deleted templates - events do not carry the tenant of the request yet
+
+const (
+	// RepositoryName is the name of the attachment repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "AttachmentRepository"
+	// Pkg is the package name for logging.
+	Pkg = "app.attachment"
+	// OwnerTemplate is the owner type of attachments of a template. The owner ID is the template's ID.
+	OwnerTemplate = "template"
+	// OwnerRequirement is the owner type of attachments of an elicited requirement. The owner ID is the requirement's ID,
+	// it is generated when the elicitation form is rendered, files can therefore be attached before the requirement is saved.
+	OwnerRequirement = "requirement"
+	// attachmentColumns is the column list of the attachments table in the order scanned by scanAttachment.
+	attachmentColumns = "id, owner_type, owner_id, name, content_type, size, storage_key, created_by, created_at"
+)
+
+var (
+	// ErrTooLarge is returned if an uploaded file exceeds Cfg.MaxSize.
+	ErrTooLarge = errors.New("attachment.error.too-large")
+	// ErrTypeNotAllowed is returned if the content type of an uploaded file is not one of Cfg.AllowedTypes.
+	ErrTypeNotAllowed = errors.New("attachment.error.type-not-allowed")
+	// ErrInvalidOwner is returned if the owner type is unknown or the owner does not exist.
+	ErrInvalidOwner = errors.New("attachment.error.invalid-owner")
+)
+
+// Cfg is the attachment package's configuration.
+type Cfg struct {
+	// MaxSize is the maximum size of an uploaded file in bytes.
+	MaxSize int64 `toml:"max_size" hvalidate:"required"`
+	// AllowedTypes are the allowed content types of uploaded files, e.g. "application/pdf" or "image/*".
+	// The content type is detected from the file's content, the content type sent by the client is ignored.
+	AllowedTypes []string `toml:"allowed_types" hvalidate:"required"`
+	// URLSecret is the secret download URLs are signed with. A random secret is used if it is empty,
+	// download URLs then become invalid when the application is restarted.
+	URLSecret string `toml:"url_secret" env:"ATTACHMENT_URL_SECRET"`
+	// URLTTL is the duration in seconds a signed download URL is valid for.
+	URLTTL  int          `toml:"url_ttl" hvalidate:"required"`
+	Storage *storage.Cfg `toml:"storage" hvalidate:"required"`
+}
+
+// Attachment is a file attached to an owner, e.g. a template. The file itself is stored under the StorageKey.
+type Attachment struct {
+	ID          uuid.UUID
+	OwnerType   string
+	OwnerID     uuid.UUID
+	Name        string
+	ContentType string
+	Size        int64
+	StorageKey  string
+	CreatedBy   uuid.UUID
+	CreatedAt   time.Time
+}
+
+// ToCreate is the attachment entity that is used to create a new attachment.
+type ToCreate struct {
+	OwnerType   string    `hvalidate:"required"`
+	OwnerID     uuid.UUID `hvalidate:"required"`
+	Name        string    `hvalidate:"required"`
+	ContentType string    `hvalidate:"required"`
+	Size        int64
+	StorageKey  string    `hvalidate:"required"`
+	CreatedBy   uuid.UUID `hvalidate:"required"`
+}
+
+// Repository is the attachment repository. It contains all methods to interact with the attachments table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID).
+// Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// FindByID finds an attachment by its id.
+	// It returns persistence.ErrNotFound if the attachment could not be found and persistence.ErrReadRow for any other error.
+	FindByID(ctx context.Context, id uuid.UUID) (*Attachment, error)
+	// FindByOwner finds all attachments of an owner ordered by their creation.
+	// It returns persistence.ErrNotFound if no attachments could be found and persistence.ErrReadRow for any other error.
+	FindByOwner(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]*Attachment, error)
+	// Create creates a new attachment and returns it. It returns persistence.ErrInsert if the attachment could not be inserted.
+	Create(ctx context.Context, toCreate *ToCreate) (*Attachment, error)
+	// Delete deletes an attachment by its id. It returns persistence.ErrDelete if the attachment could not be deleted.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// PGRepository is the attachment repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &PGRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// FindByID finds an attachment by its id.
+// It returns persistence.ErrNotFound if the attachment could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByID(ctx context.Context, id uuid.UUID) (*Attachment, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+attachmentColumns+" FROM attachments WHERE id = $1 AND tenant_id = $2",
+		id, tenant.ID(ctx),
+	), scanAttachment)
+}
+
+// FindByOwner finds all attachments of an owner ordered by their creation.
+// It returns persistence.ErrNotFound if no attachments could be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByOwner(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]*Attachment, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		"SELECT "+attachmentColumns+" FROM attachments WHERE owner_type = $1 AND owner_id = $2 AND tenant_id = $3 ORDER BY created_at",
+		ownerType, ownerID, tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, scanAttachment)
+}
+
+// Create creates a new attachment and returns it. It returns persistence.ErrInsert if the attachment could not be inserted.
+func (r *PGRepository) Create(ctx context.Context, toCreate *ToCreate) (*Attachment, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	newAttachment := &Attachment{
+		ID:          uuid.New(),
+		OwnerType:   toCreate.OwnerType,
+		OwnerID:     toCreate.OwnerID,
+		Name:        toCreate.Name,
+		ContentType: toCreate.ContentType,
+		Size:        toCreate.Size,
+		StorageKey:  toCreate.StorageKey,
+		CreatedBy:   toCreate.CreatedBy,
+		CreatedAt:   time.Now(),
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		"INSERT INTO attachments ("+attachmentColumns+", tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		newAttachment.ID,
+		newAttachment.OwnerType,
+		newAttachment.OwnerID,
+		newAttachment.Name,
+		newAttachment.ContentType,
+		newAttachment.Size,
+		newAttachment.StorageKey,
+		newAttachment.CreatedBy,
+		newAttachment.CreatedAt,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return newAttachment, nil
+}
+
+// Delete deletes an attachment by its id. It returns persistence.ErrDelete if the attachment could not be deleted.
+func (r *PGRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM attachments WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// DownloadPath returns the path the attachment is downloaded from.
+// Without a signature (see storage.Signer) only the creator of the attachment may download it.
+func DownloadPath(id uuid.UUID) string {
+	return "/attachment/" + id.String() + "/download"
+}
+
+// scanAttachment scans a row containing the attachmentColumns into a new Attachment.
+func scanAttachment(row pgx.Row) (*Attachment, error) {
+	a := &Attachment{}
+	err := row.Scan(&a.ID, &a.OwnerType, &a.OwnerID, &a.Name, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedBy, &a.CreatedAt)
+
+	return a, err
+}
@@ -0,0 +1,137 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/storage"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffLen is the number of bytes read to detect the content type of a file, see http.DetectContentType.
+const sniffLen = 512
+
+// maxNameLen is the maximum length of an attachment's name in characters. Longer names are truncated.
+const maxNameLen = 255
+
+// Upload is a file uploaded by a user to be attached to an owner.
+type Upload struct {
+	OwnerType string
+	OwnerID   uuid.UUID
+	// Name is the file name sent by the client. Only the base name is used.
+	Name      string
+	Size      int64
+	File      io.ReadSeeker
+	CreatedBy uuid.UUID
+}
+
+// Attach validates the size and content type of the uploaded file, stores the file in the store and creates the attachment.
+// The file is stored under the key <tenant>/<owner type>/<owner id>/<random uuid>.
+// It returns ErrTooLarge if the file exceeds Cfg.MaxSize and ErrTypeNotAllowed if its content type is not allowed.
+func Attach(ctx context.Context, cfg *Cfg, store storage.Store, repo Repository, upload *Upload) (*Attachment, error) {
+	if upload.Size > cfg.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType, err := DetectContentType(upload.File)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.Allowed(contentType) {
+		return nil, ErrTypeNotAllowed
+	}
+
+	key, err := storage.Key(tenant.ID(ctx), upload.OwnerType, upload.OwnerID.String(), uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Put(ctx, key, upload.File, upload.Size, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := repo.Create(ctx, &ToCreate{
+		OwnerType:   upload.OwnerType,
+		OwnerID:     upload.OwnerID,
+		Name:        Name(upload.Name),
+		ContentType: contentType,
+		Size:        upload.Size,
+		StorageKey:  key,
+		CreatedBy:   upload.CreatedBy,
+	})
+	if err != nil {
+		return nil, errors.Join(err, store.Delete(ctx, key))
+	}
+
+	return attachment, nil
+}
+
+// Remove deletes the attachment and its file.
+func Remove(ctx context.Context, store storage.Store, repo Repository, attachment *Attachment) error {
+	err := repo.Delete(ctx, attachment.ID)
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(ctx, attachment.StorageKey)
+}
+
+// DetectContentType detects the content type of the file from its first bytes without parameters (e.g. charset)
+// and rewinds the file afterward.
+func DetectContentType(file io.ReadSeeker) (string, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil {
+		return "", err
+	}
+
+	return mediaType, nil
+}
+
+// Allowed returns true if the content type matches one of the allowed types.
+// An allowed type may match all subtypes of a type, e.g. "image/*".
+func (c *Cfg) Allowed(contentType string) bool {
+	for _, allowed := range c.AllowedTypes {
+		if allowed == contentType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Name returns the base name of a file name sent by a client truncated to 255 characters.
+// Client file names may contain the path of the file on the client, depending on the browser.
+func Name(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+
+	if utf8.RuneCountInString(name) > maxNameLen {
+		name = string([]rune(name)[:maxNameLen])
+	}
+
+	return name
+}
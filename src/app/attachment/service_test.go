@@ -0,0 +1,166 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memRepository is an in-memory Repository for tests.
+type memRepository struct {
+	attachments map[uuid.UUID]*Attachment
+	createErr   error
+}
+
+func (r *memRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+func (r *memRepository) FindByID(ctx context.Context, id uuid.UUID) (*Attachment, error) {
+	a, ok := r.attachments[id]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+
+	return a, nil
+}
+
+func (r *memRepository) FindByOwner(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]*Attachment, error) {
+	var attachments []*Attachment
+	for _, a := range r.attachments {
+		if a.OwnerType == ownerType && a.OwnerID == ownerID {
+			attachments = append(attachments, a)
+		}
+	}
+
+	return attachments, nil
+}
+
+func (r *memRepository) Create(ctx context.Context, toCreate *ToCreate) (*Attachment, error) {
+	if r.createErr != nil {
+		return nil, r.createErr
+	}
+
+	a := &Attachment{
+		ID:          uuid.New(),
+		OwnerType:   toCreate.OwnerType,
+		OwnerID:     toCreate.OwnerID,
+		Name:        toCreate.Name,
+		ContentType: toCreate.ContentType,
+		Size:        toCreate.Size,
+		StorageKey:  toCreate.StorageKey,
+		CreatedBy:   toCreate.CreatedBy,
+		CreatedAt:   time.Now(),
+	}
+	r.attachments[a.ID] = a
+
+	return a, nil
+}
+
+func (r *memRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.attachments, id)
+	return nil
+}
+
+func TestAttach(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Cfg{MaxSize: 1024, AllowedTypes: []string{"text/plain"}}
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	repo := &memRepository{attachments: map[uuid.UUID]*Attachment{}}
+
+	upload := func(content string) *Upload {
+		return &Upload{
+			OwnerType: OwnerTemplate,
+			OwnerID:   uuid.New(),
+			Name:      "C:\\Users\\foo\\notes.txt",
+			Size:      int64(len(content)),
+			File:      strings.NewReader(content),
+			CreatedBy: uuid.New(),
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		a, err := Attach(ctx, cfg, store, repo, upload("some notes"))
+		require.NoError(t, err)
+		assert.Equal(t, "notes.txt", a.Name)
+		assert.Equal(t, "text/plain", a.ContentType)
+		assert.True(t, strings.HasPrefix(a.StorageKey, "default/template/"+a.OwnerID.String()+"/"))
+
+		r, err := store.Get(ctx, a.StorageKey)
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		assert.Equal(t, "some notes", string(content))
+
+		require.NoError(t, Remove(ctx, store, repo, a))
+		_, err = store.Get(ctx, a.StorageKey)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.Empty(t, repo.attachments)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := Attach(ctx, cfg, store, repo, upload(strings.Repeat("a", 1025)))
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+
+	t.Run("type not allowed", func(t *testing.T) {
+		u := upload("")
+		u.File = bytes.NewReader([]byte("%PDF-1.7"))
+		u.Size = 8
+
+		_, err := Attach(ctx, cfg, store, repo, u)
+		assert.ErrorIs(t, err, ErrTypeNotAllowed)
+	})
+
+	t.Run("file deleted on repository error", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := storage.NewLocalStore(dir)
+		require.NoError(t, err)
+		failing := &memRepository{attachments: map[uuid.UUID]*Attachment{}, createErr: errors.New("insert failed")}
+
+		u := upload("some notes")
+		_, err = Attach(ctx, cfg, store, failing, u)
+		require.Error(t, err)
+
+		var files []string
+		require.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, path)
+			}
+			return err
+		}))
+		assert.Empty(t, files)
+	})
+}
+
+func TestAllowed(t *testing.T) {
+	cfg := &Cfg{AllowedTypes: []string{"application/pdf", "image/*"}}
+
+	assert.True(t, cfg.Allowed("application/pdf"))
+	assert.True(t, cfg.Allowed("image/png"))
+	assert.False(t, cfg.Allowed("image"))
+	assert.False(t, cfg.Allowed("text/html"))
+	assert.False(t, cfg.Allowed("application/pdfx"))
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "foo.pdf", Name("foo.pdf"))
+	assert.Equal(t, "foo.pdf", Name("/home/user/foo.pdf"))
+	assert.Equal(t, "foo.pdf", Name("C:\\Users\\user\\foo.pdf"))
+	assert.Equal(t, "attachment", Name(""))
+	assert.Equal(t, "attachment", Name("/"))
+	assert.Len(t, []rune(Name(strings.Repeat("ä", 300))), 255)
+}
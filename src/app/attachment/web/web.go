@@ -0,0 +1,303 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/attachment"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/config"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/storage"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// multipartOverhead is the size in bytes allowed for the multipart encoding of an upload in addition to Cfg.MaxSize.
+const multipartOverhead = 1 << 20
+
+// ErrNoFile is displayed to the user if an upload does not contain a file.
+var ErrNoFile = errors.New("attachment.error.no-file")
+
+// ListData is the data of the attachment list of an owner. It contains the attachments' signed download URLs.
+type ListData struct {
+	OwnerType   string
+	OwnerID     uuid.UUID
+	Attachments []Link
+	// MaxSize is the maximum size of an uploaded file in megabytes.
+	MaxSize int64
+}
+
+// Link is an attachment with its signed download URL.
+type Link struct {
+	*attachment.Attachment
+	URL string
+}
+
+// controllerDeps are the dependencies shared by the attachment controllers.
+type controllerDeps struct {
+	cfg          *attachment.Cfg
+	store        storage.Store
+	signer       *storage.Signer
+	attachments  attachment.Repository
+	templates    template.Repository
+	requirements requirement.Repository
+}
+
+// RegisterController registers the controllers of the attachment module:
+//   - GET /attachment/owner/{ownerType}/{ownerID} Renders the attachment list and upload form of an owner.
+//   - POST /attachment/owner/{ownerType}/{ownerID} Uploads a file and attaches it to the owner.
+//   - DELETE /attachment/{id} Deletes an attachment.
+//   - GET /attachment/{id}/download Downloads an attachment through a signed URL or as its creator.
+//
+// The attachment list is meant to be embedded into other pages, e.g. using hx-get with the load trigger.
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	registerErrors(webCtx)
+
+	cfg := &attachment.Cfg{}
+	util.Ok(config.C(cfg, config.From("attachment"), config.Validate(appCtx.Validator)))
+
+	deps := &controllerDeps{
+		cfg:          cfg,
+		store:        util.Unwrap(storage.NewStore(cfg.Storage)),
+		signer:       util.Unwrap(storage.NewSigner(cfg.URLSecret)),
+		attachments:  util.UnwrapType[attachment.Repository](appCtx.Repository(attachment.RepositoryName)),
+		templates:    util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName)),
+		requirements: util.UnwrapType[requirement.Repository](appCtx.Repository(requirement.RepositoryName)),
+	}
+
+	if cfg.URLSecret == "" {
+		appCtx.Warn(attachment.Pkg, "no attachment url secret configured, download urls become invalid on restart")
+	}
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/attachment/owner/{ownerType}/{ownerID}", listController(appCtx, webCtx, deps).ServeHTTP)
+	router.With(web.LimitBody(cfg.MaxSize+multipartOverhead)).Post("/attachment/owner/{ownerType}/{ownerID}", uploadController(appCtx, webCtx, deps).ServeHTTP)
+	router.Delete("/attachment/{id}", deleteController(appCtx, webCtx, deps).ServeHTTP)
+
+	webCtx.Router.Get("/attachment/{id}/download", downloadController(appCtx, webCtx, deps).ServeHTTP)
+}
+
+// registerErrors maps the errors of the attachment controllers to their HTTP status codes.
+func registerErrors(webCtx *web.Ctx) {
+	webCtx.Errors.Map(attachment.ErrInvalidOwner, http.StatusNotFound, web.ErrNotFound)
+	webCtx.Errors.Map(storage.ErrNotFound, http.StatusNotFound, web.ErrNotFound)
+	webCtx.Errors.Map(storage.ErrInvalidSignature, http.StatusForbidden, web.ErrForbidden)
+	webCtx.Errors.Map(storage.ErrURLExpired, http.StatusForbidden, web.ErrForbidden)
+}
+
+func listController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ownerType, ownerID, err := deps.permittedOwner(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		return deps.renderList(io, ownerType, ownerID, nil)
+	})
+}
+
+func uploadController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ownerType, ownerID, err := deps.permittedOwner(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		file, header, err := io.Request().FormFile("file")
+		if web.IsBodyTooLarge(err) {
+			return deps.renderList(io, ownerType, ownerID, nil, attachment.ErrTooLarge)
+		}
+		if errors.Is(err, http.ErrMissingFile) {
+			return deps.renderList(io, ownerType, ownerID, nil, ErrNoFile)
+		}
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		defer file.Close()
+
+		_, err = attachment.Attach(io.Context(), deps.cfg, deps.store, deps.attachments, &attachment.Upload{
+			OwnerType: ownerType,
+			OwnerID:   ownerID,
+			Name:      header.Filename,
+			Size:      header.Size,
+			File:      file,
+			CreatedBy: user.MustCtxUser(io.Context()).ID,
+		})
+		if errors.Is(err, attachment.ErrTooLarge) || errors.Is(err, attachment.ErrTypeNotAllowed) {
+			return deps.renderList(io, ownerType, ownerID, nil, err)
+		}
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return deps.renderList(io, ownerType, ownerID, []string{"attachment.uploaded"})
+	})
+}
+
+func deleteController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		a, err := deps.attachmentFromParams(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		if a.CreatedBy != user.MustCtxUser(io.Context()).ID {
+			return io.InlineError(web.Forbidden(nil))
+		}
+
+		err = attachment.Remove(io.Context(), deps.store, deps.attachments, a)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return deps.renderList(io, a.OwnerType, a.OwnerID, []string{"attachment.deleted"})
+	})
+}
+
+// downloadController serves the file of an attachment. The request has to be signed (see storage.Signer)
+// or the logged-in user has to be the creator of the attachment. Files are always served as downloads
+// to prevent uploaded HTML or scripts from being executed in the context of the application.
+func downloadController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+
+		a, err := deps.attachmentFromParams(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		err = deps.signer.Verify(request.URL.Path, request.URL.Query())
+		if err != nil {
+			u, userErr := user.CtxUser(io.Context())
+			if userErr != nil || u.ID != a.CreatedBy {
+				return io.Error(err)
+			}
+		}
+
+		file, err := deps.store.Get(io.Context(), a.StorageKey)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+		defer file.Close()
+
+		header := io.Response().Header()
+		header.Set("Content-Type", a.ContentType)
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+		header.Set("Content-Length", fmt.Sprintf("%d", a.Size))
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Cache-Control", "private")
+
+		return copyFile(io.Response(), file)
+	})
+}
+
+// copyFile writes the content of the file to the response.
+func copyFile(w http.ResponseWriter, file io.Reader) error {
+	_, err := io.Copy(w, file)
+	return err
+}
+
+// permittedOwner returns the owner of the request's URL parameters ownerType and ownerID
+// if the logged-in user is permitted to access the owner's attachments, see checkOwner.
+func (d *controllerDeps) permittedOwner(io web.IO) (string, uuid.UUID, error) {
+	request := io.Request()
+	ownerType := web.URLParam(request, "ownerType")
+
+	ownerID, err := uuid.Parse(web.URLParam(request, "ownerID"))
+	if err != nil {
+		return "", uuid.Nil, errors.Join(attachment.ErrInvalidOwner, err)
+	}
+
+	err = d.checkOwner(io.Context(), user.MustCtxUser(io.Context()).ID, ownerType, ownerID)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+
+	return ownerType, ownerID, nil
+}
+
+// checkOwner returns a forbidden error (see web.Forbidden) if the user is not permitted to access the owner's attachments.
+// Templates are only accessible by their creator, requirements by their participants (see requirement.Repository.IsParticipant).
+// Files are attached to requirements while they are elicited, before they are saved. Requirements not saved yet are
+// therefore accessible, their ids are generated when the elicitation form is rendered.
+func (d *controllerDeps) checkOwner(ctx context.Context, userID uuid.UUID, ownerType string, ownerID uuid.UUID) error {
+	switch ownerType {
+	case attachment.OwnerTemplate:
+		tmpl, err := d.templates.FindByID(ctx, ownerID)
+		if err != nil {
+			return errors.Join(attachment.ErrInvalidOwner, err)
+		}
+
+		if tmpl.CreatedBy != userID {
+			return web.Forbidden(nil)
+		}
+	case attachment.OwnerRequirement:
+		participant, err := d.requirements.IsParticipant(ctx, userID, ownerID)
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return web.NewHTTPError(http.StatusInternalServerError, web.ErrInternal, err)
+		}
+
+		if !participant {
+			return web.Forbidden(nil)
+		}
+	default:
+		return attachment.ErrInvalidOwner
+	}
+
+	return nil
+}
+
+// attachmentFromParams returns the attachment of the request's URL parameter id.
+func (d *controllerDeps) attachmentFromParams(io web.IO) (*attachment.Attachment, error) {
+	id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+	if err != nil {
+		return nil, web.NotFound(err)
+	}
+
+	a, err := d.attachments.FindByID(io.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// renderList renders the attachment list of the owner with signed download URLs.
+// Only the logged-in user's attachments are listed.
+func (d *controllerDeps) renderList(io web.IO, ownerType string, ownerID uuid.UUID, success []string, errs ...error) error {
+	attachments, err := d.attachments.FindByOwner(io.Context(), ownerType, ownerID)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return io.InlineError(web.ErrInternal, err)
+	}
+
+	usr := user.MustCtxUser(io.Context())
+	ttl := time.Duration(d.cfg.URLTTL) * time.Second
+
+	links := make([]Link, 0, len(attachments))
+	for _, a := range attachments {
+		if a.CreatedBy != usr.ID {
+			continue
+		}
+
+		links = append(links, Link{Attachment: a, URL: d.signer.Sign(attachment.DownloadPath(a.ID), ttl)})
+	}
+
+	return io.Render(web.NewFormData(ListData{
+		OwnerType:   ownerType,
+		OwnerID:     ownerID,
+		Attachments: links,
+		MaxSize:     d.cfg.MaxSize >> 20,
+	}, success, errs...), "attachment.list", "attachment/_list.go.html")
+}
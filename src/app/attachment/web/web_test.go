@@ -0,0 +1,84 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/attachment"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/web"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestCheckOwner(t *testing.T) {
+	creator, participant, stranger := uuid.New(), uuid.New(), uuid.New()
+	templateID, requirementID, unsavedID := uuid.New(), uuid.New(), uuid.New()
+
+	deps := &controllerDeps{
+		templates: &templateRepository{templates: map[uuid.UUID]*template.Template{
+			templateID: {ID: templateID, CreatedBy: creator},
+		}},
+		requirements: &requirementRepository{participants: map[uuid.UUID][]uuid.UUID{
+			requirementID: {creator, participant},
+		}},
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, deps.checkOwner(ctx, creator, attachment.OwnerTemplate, templateID))
+	assertForbidden(t, deps.checkOwner(ctx, participant, attachment.OwnerTemplate, templateID))
+	assert.ErrorIs(t, deps.checkOwner(ctx, creator, attachment.OwnerTemplate, uuid.New()), attachment.ErrInvalidOwner)
+
+	assert.NoError(t, deps.checkOwner(ctx, creator, attachment.OwnerRequirement, requirementID))
+	assert.NoError(t, deps.checkOwner(ctx, participant, attachment.OwnerRequirement, requirementID))
+	assertForbidden(t, deps.checkOwner(ctx, stranger, attachment.OwnerRequirement, requirementID))
+	assert.NoError(t, deps.checkOwner(ctx, stranger, attachment.OwnerRequirement, unsavedID), "requirements not saved yet are accessible")
+
+	assert.ErrorIs(t, deps.checkOwner(ctx, creator, "unknown", templateID), attachment.ErrInvalidOwner)
+}
+
+func assertForbidden(t *testing.T, err error) {
+	var httpErr *web.HTTPError
+	if assert.True(t, errors.As(err, &httpErr)) {
+		assert.Equal(t, http.StatusForbidden, httpErr.Status)
+	}
+}
+
+// templateRepository is a template.Repository finding the templates by their id.
+type templateRepository struct {
+	template.Repository
+	templates map[uuid.UUID]*template.Template
+}
+
+func (r *templateRepository) FindByID(ctx context.Context, id uuid.UUID) (*template.Template, error) {
+	tmpl, ok := r.templates[id]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+
+	return tmpl, nil
+}
+
+// requirementRepository is a requirement.Repository knowing the participants of the requirements by their id.
+type requirementRepository struct {
+	requirement.Repository
+	participants map[uuid.UUID][]uuid.UUID
+}
+
+func (r *requirementRepository) IsParticipant(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error) {
+	participants, ok := r.participants[id]
+	if !ok {
+		return false, persistence.ErrNotFound
+	}
+
+	for _, participant := range participants {
+		if participant == userID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,125 @@
+// Package audit records security relevant actions in the audit log, e.g. the actions of administrators impersonating users.
+// Entries are only appended, they are never changed or deleted by the application.
+package audit
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"time"
+)
+
+const (
+	// RepositoryName is the name of the audit repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "AuditRepository"
+	// Pkg is the package name for logging.
+	Pkg = "app.audit"
+	// ActionImpersonationStart is recorded when an administrator starts impersonating a user.
+	ActionImpersonationStart = "impersonation.start"
+	// ActionImpersonationStop is recorded when an administrator stops impersonating a user.
+	ActionImpersonationStop = "impersonation.stop"
+	// ActionImpersonatedRequest is recorded once for each request while impersonating.
+	ActionImpersonatedRequest = "impersonation.request"
+	// entryColumns is the column list of the audit_log table in the order scanned by scanEntry.
+	entryColumns = "id, actor_id, impersonated_id, action, details, created_at"
+)
+
+// Entry is an action recorded in the audit log. The ActorID is the user performing the action.
+// While impersonating, the actor is the administrator and ImpersonatedID the user the administrator acts as.
+type Entry struct {
+	ID             uuid.UUID
+	ActorID        uuid.UUID
+	ImpersonatedID *uuid.UUID
+	// Action describes the recorded action, e.g. ActionImpersonationStart.
+	Action string
+	// Details describe the action further, e.g. the method and path of a request.
+	Details   map[string]string
+	CreatedAt time.Time
+}
+
+// Repository is the audit repository. It contains all methods to interact with the audit_log table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID). Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// Create appends a new entry to the audit log and returns it. The id and creation time are set by Create.
+	// It returns persistence.ErrInsert if the entry could not be inserted.
+	Create(ctx context.Context, entry *Entry) (*Entry, error)
+	// FindRecent finds the latest entries up to the limit, the newest first.
+	// It returns an empty slice if there are no entries and persistence.ErrReadRow for any other error.
+	FindRecent(ctx context.Context, limit int) ([]*Entry, error)
+}
+
+// PGRepository is the audit repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &PGRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// Create appends a new entry to the audit log and returns it. The id and creation time are set by Create.
+// It returns persistence.ErrInsert if the entry could not be inserted.
+func (r *PGRepository) Create(ctx context.Context, entry *Entry) (*Entry, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	created := *entry
+	created.ID = uuid.New()
+	created.CreatedAt = time.Now()
+	if created.Details == nil {
+		created.Details = make(map[string]string)
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		"INSERT INTO audit_log ("+entryColumns+", tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		created.ID,
+		created.ActorID,
+		created.ImpersonatedID,
+		created.Action,
+		created.Details,
+		created.CreatedAt,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return &created, nil
+}
+
+// FindRecent finds the latest entries up to the limit, the newest first.
+// It returns an empty slice if there are no entries and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindRecent(ctx context.Context, limit int) ([]*Entry, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		"SELECT "+entryColumns+" FROM audit_log WHERE tenant_id = $1 ORDER BY created_at DESC LIMIT $2",
+		tenant.ID(ctx), limit,
+	)
+
+	return persistence.PGCollectRows(rows, err, scanEntry)
+}
+
+// scanEntry scans a row containing the entryColumns into a new Entry.
+func scanEntry(row pgx.Row) (*Entry, error) {
+	e := &Entry{}
+	err := row.Scan(&e.ID, &e.ActorID, &e.ImpersonatedID, &e.Action, &e.Details, &e.CreatedAt)
+
+	return e, err
+}
@@ -0,0 +1,284 @@
+// Package checklist implements checklist templates: a list of items answered with yes or no and optional notes,
+// e.g. the checklist of a requirements review. Checklist templates are a template type of their own (TemplateType)
+// next to the EIFFEL basic templates, they are validated through the template.ValidateTemplateConfigEvent (see SubscribeTemplateValidation).
+package checklist
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"strings"
+)
+
+const (
+	// TemplateType is the type name of checklist templates used to identify the corresponding parser for a template.
+	TemplateType = "checklist"
+	// Pkg is the package name for logging.
+	Pkg = "app.checklist"
+	// KindYesNo is the kind of items answered with yes or no. It is the default kind of an item.
+	KindYesNo = "yesNo"
+	// KindNotes is the kind of items answered with free-form notes only.
+	KindNotes = "notes"
+	// NotesOptional allows adding notes to a yes/no answer. It is the default of an item.
+	NotesOptional = "optional"
+	// NotesNone does not allow adding notes to a yes/no answer.
+	NotesNone = "none"
+	// NotesRequired requires notes for each yes/no answer.
+	NotesRequired = "required"
+	// NotesOnNo requires notes for answers with no, e.g. to explain a failed check.
+	NotesOnNo = "onNo"
+	// AnswerYes is the answer value of a checked item.
+	AnswerYes = "yes"
+	// AnswerNo is the answer value of an item that is not checked.
+	AnswerNo = "no"
+)
+
+const (
+	// DisplayYesNo will display the item as a yes/no choice without notes.
+	DisplayYesNo DisplayType = "yes-no"
+	// DisplayYesNoNotes will display the item as a yes/no choice with a notes input.
+	DisplayYesNoNotes DisplayType = "yes-no-notes"
+	// DisplayNotes will display the item as a notes textarea.
+	DisplayNotes DisplayType = "notes"
+)
+
+var (
+	// ErrNoItems is returned if a checklist template has no items.
+	ErrNoItems = errors.New("checklist.error.no-items")
+	// ErrInvalidConfig is returned if the config of a checklist template is no valid JSON.
+	ErrInvalidConfig = errors.New("checklist.error.invalid-config")
+)
+
+// DisplayType specifies how an item should be displayed in the UI.
+type DisplayType string
+
+// Template is a checklist template. Its items are answered in the order they are defined.
+type Template struct {
+	// ID is the unique identifier of the template.
+	ID string `json:"id" hvalidate:"required"`
+	// Name is the name of the template.
+	Name string `json:"name" hvalidate:"required"`
+	// Version is the version of the template. It must be a semantic version.
+	Version string `json:"version" hvalidate:"required,semVer"`
+	// Authors is a list of authors of the template.
+	Authors []string `json:"authors"`
+	// License is the license of the template.
+	License string `json:"license"`
+	// Description is a description of the template.
+	Description string `json:"description"`
+	// Items are the items of the checklist.
+	Items []Item `json:"items"`
+}
+
+// Item is a single item of a checklist template. The key identifies the item, it must be unique within the template.
+type Item struct {
+	Key string `json:"key" hvalidate:"required"`
+	// Question is the question answered by the item, e.g. "Is each requirement testable?".
+	Question string `json:"question" hvalidate:"required"`
+	// Hint is an optional hint displayed with the question.
+	Hint string `json:"hint"`
+	// Kind is the kind of answer: KindYesNo (default) or KindNotes.
+	Kind string `json:"kind"`
+	// Notes specifies if notes may or must be added to yes/no answers: NotesOptional (default), NotesNone, NotesRequired or NotesOnNo.
+	// It is ignored for items of the KindNotes.
+	Notes string `json:"notes"`
+	// Optional items may be left unanswered.
+	Optional bool `json:"optional"`
+}
+
+// Answer is the answer to an item. Value is AnswerYes, AnswerNo or empty for items of the KindNotes and unanswered items.
+type Answer struct {
+	Value string
+	Notes string
+}
+
+// ItemError is a validation error of an item of a checklist template.
+type ItemError struct {
+	Item *Item
+	// Msg is the error message. It is translated using the parameters: "item" (item's key) and "question" (item's question).
+	Msg string
+}
+
+// FromConfig parses the config of a checklist template. It returns ErrInvalidConfig if the config is no valid JSON.
+// The template is not validated, use Validate for that.
+func FromConfig(config string) (*Template, error) {
+	t := &Template{}
+	if err := json.Unmarshal([]byte(config), t); err != nil {
+		return nil, errors.Join(ErrInvalidConfig, err)
+	}
+
+	return t, nil
+}
+
+// IntoTemplate parses the template's config into a checklist Template and validates it.
+// It returns template.ErrInvalidTemplate if the config is invalid.
+func IntoTemplate(tmpl *template.Template, validator validation.V) (*Template, error) {
+	t, err := FromConfig(tmpl.Config)
+	if err != nil {
+		return nil, template.ErrInvalidTemplate
+	}
+
+	if errs := t.Validate(validator); len(errs) > 0 {
+		return nil, template.ErrInvalidTemplate
+	}
+
+	return t, nil
+}
+
+// Validate validates the template and its items. The item's keys must be unique, their kind and notes must be known.
+// It returns a slice of errors that are safe to display to the user, template.ErrInvalidTemplate is always part of a non-empty slice.
+func (t *Template) Validate(v validation.V) []error {
+	err, errs := v.ValidateStruct(t)
+	if err != nil {
+		return []error{template.ErrInvalidTemplate, err}
+	}
+
+	if len(t.Items) == 0 {
+		errs = append(errs, ErrNoItems)
+	}
+
+	keys := make(map[string]bool, len(t.Items))
+	for i := range t.Items {
+		item := &t.Items[i]
+
+		// validation is not capable of validating slices of structs, see eiffel.BasicTemplate.Validate
+		err, itemErrs := v.ValidateStruct(*item)
+		if err != nil {
+			return []error{template.ErrInvalidTemplate, err}
+		}
+		errs = append(errs, itemErrs...)
+
+		if keys[item.Key] {
+			errs = append(errs, ItemError{Item: item, Msg: "checklist.error.duplicate-item"})
+		}
+		keys[item.Key] = true
+
+		switch item.Kind {
+		case "", KindYesNo, KindNotes:
+		default:
+			errs = append(errs, ItemError{Item: item, Msg: "checklist.error.invalid-kind"})
+		}
+
+		switch item.Notes {
+		case "", NotesOptional, NotesNone, NotesRequired, NotesOnNo:
+		default:
+			errs = append(errs, ItemError{Item: item, Msg: "checklist.error.invalid-notes"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return append(errs, template.ErrInvalidTemplate)
+	}
+
+	return nil
+}
+
+// DisplayTypes returns the display types of the template's items keyed by the items' keys.
+func (t *Template) DisplayTypes() map[string]DisplayType {
+	displayTypes := make(map[string]DisplayType, len(t.Items))
+	for _, item := range t.Items {
+		displayTypes[item.Key] = item.DisplayType()
+	}
+
+	return displayTypes
+}
+
+// DisplayType returns how the item is displayed: items of the KindNotes as notes, yes/no items with or without notes.
+func (i Item) DisplayType() DisplayType {
+	if i.Kind == KindNotes {
+		return DisplayNotes
+	}
+	if i.Notes == NotesNone {
+		return DisplayYesNo
+	}
+
+	return DisplayYesNoNotes
+}
+
+// Parse checks the answers of the checklist keyed by the items' keys. Unanswered items, invalid answers and missing notes
+// are errors unless the item is optional. Items answered with no are warnings, the checklist is still valid.
+// If the checklist is valid, the result's requirement is the answered checklist in Markdown task list notation, e.g.
+// "- [x] Is each requirement testable?". Answers to unknown items are ignored.
+func (t *Template) Parse(answers map[string]Answer) parser.ParsingResult {
+	result := parser.ParsingResult{
+		TemplateID:      t.ID,
+		TemplateType:    TemplateType,
+		TemplateVersion: t.Version,
+		TemplateName:    t.Name,
+	}
+
+	lines := make([]string, 0, len(t.Items))
+	for _, item := range t.Items {
+		answer := answers[item.Key]
+		answer.Value = strings.ToLower(strings.TrimSpace(answer.Value))
+		answer.Notes = strings.TrimSpace(answer.Notes)
+
+		segment := &parser.ParsingSegment{Name: item.Key, Value: answer.Value}
+		log := func(level parser.ParsingLogLevel, msg string) parser.ParsingLog {
+			return parser.ParsingLog{Segment: segment, Level: level, Message: msg, TranslationArgs: []string{"question", item.Question}}
+		}
+
+		if item.Kind == KindNotes {
+			segment.Value = answer.Notes
+			if answer.Notes == "" && !item.Optional {
+				result.Errors = append(result.Errors, log(parser.ParsingLogLevelError, "checklist.parser.missing-notes"))
+			}
+			if answer.Notes != "" {
+				lines = append(lines, "- "+item.Question+": "+answer.Notes)
+			}
+			continue
+		}
+
+		switch answer.Value {
+		case "":
+			if !item.Optional {
+				result.Errors = append(result.Errors, log(parser.ParsingLogLevelError, "checklist.parser.missing-answer"))
+			}
+			continue
+		case AnswerYes, AnswerNo:
+		default:
+			result.Errors = append(result.Errors, log(parser.ParsingLogLevelError, "checklist.parser.invalid-answer"))
+			continue
+		}
+
+		if answer.Value == AnswerNo {
+			result.Warnings = append(result.Warnings, log(parser.ParsingLogLevelWarning, "checklist.parser.answered-no"))
+		}
+
+		notesRequired := item.Notes == NotesRequired || (item.Notes == NotesOnNo && answer.Value == AnswerNo)
+		if notesRequired && answer.Notes == "" {
+			result.Errors = append(result.Errors, log(parser.ParsingLogLevelError, "checklist.parser.missing-notes"))
+		}
+		if item.Notes == NotesNone {
+			answer.Notes = ""
+		}
+
+		line := "- [ ] " + item.Question
+		if answer.Value == AnswerYes {
+			line = "- [x] " + item.Question
+		}
+		if answer.Notes != "" {
+			line += " (" + answer.Notes + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	if result.Ok() {
+		result.Requirement = strings.Join(lines, "\n")
+	}
+
+	return result
+}
+
+// Error returns the translation key of the error.
+func (e ItemError) Error() string {
+	return e.Msg
+}
+
+// Translate on ItemError translates the error using the given translator. Item key and question are passed in.
+func (e ItemError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "item", e.Item.Key, "question", e.Item.Question)
+}
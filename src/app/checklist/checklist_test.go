@@ -0,0 +1,116 @@
+package checklist
+
+import (
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testConfig = `{
+	"id": "review",
+	"type": "checklist",
+	"name": "Review",
+	"version": "1.0.0",
+	"items": [
+		{"key": "testable", "question": "Is each requirement testable?", "notes": "onNo"},
+		{"key": "source", "question": "Is the source named?", "notes": "none"},
+		{"key": "remarks", "question": "Remarks", "kind": "notes", "optional": true}
+	]
+}`
+
+func TestFromConfig(t *testing.T) {
+	tmpl, err := FromConfig(testConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "Review", tmpl.Name)
+	require.Len(t, tmpl.Items, 3)
+	assert.Equal(t, NotesOnNo, tmpl.Items[0].Notes)
+	assert.True(t, tmpl.Items[2].Optional)
+	assert.Empty(t, tmpl.Validate(validation.New()))
+
+	_, err = FromConfig(`{"items": [`)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestValidate(t *testing.T) {
+	v := validation.New()
+
+	tmpl := &Template{ID: "empty", Name: "Empty", Version: "1.0.0"}
+	errs := tmpl.Validate(v)
+	assert.Contains(t, errs, ErrNoItems)
+	assert.Contains(t, errs, template.ErrInvalidTemplate)
+
+	tmpl = &Template{ID: "invalid", Name: "Invalid", Version: "1.0.0", Items: []Item{
+		{Key: "a", Question: "A?", Kind: "maybe"},
+		{Key: "a", Question: "Again A?", Notes: "sometimes"},
+	}}
+	errs = tmpl.Validate(v)
+	assert.Contains(t, errs, ItemError{Item: &tmpl.Items[0], Msg: "checklist.error.invalid-kind"})
+	assert.Contains(t, errs, ItemError{Item: &tmpl.Items[1], Msg: "checklist.error.duplicate-item"})
+	assert.Contains(t, errs, ItemError{Item: &tmpl.Items[1], Msg: "checklist.error.invalid-notes"})
+	assert.Contains(t, errs, template.ErrInvalidTemplate)
+
+	tmpl = &Template{ID: "missing", Name: "Missing", Version: "1.0.0", Items: []Item{{Key: "a"}}}
+	errs = tmpl.Validate(v)
+	assert.Len(t, errs, 2)
+	assert.Contains(t, errs, template.ErrInvalidTemplate)
+}
+
+func TestDisplayTypes(t *testing.T) {
+	tmpl, err := FromConfig(testConfig)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]DisplayType{
+		"testable": DisplayYesNoNotes,
+		"source":   DisplayYesNo,
+		"remarks":  DisplayNotes,
+	}, tmpl.DisplayTypes())
+}
+
+func TestParse(t *testing.T) {
+	tmpl, err := FromConfig(testConfig)
+	require.NoError(t, err)
+
+	result := tmpl.Parse(map[string]Answer{
+		"testable": {Value: " Yes "},
+		"source":   {Value: "no", Notes: "ignored"},
+		"unknown":  {Value: "yes"},
+	})
+	assert.True(t, result.Ok())
+	assert.Equal(t, TemplateType, result.TemplateType)
+	assert.Equal(t, "review", result.TemplateID)
+	require.Len(t, result.Warnings, 1)
+	assert.Equal(t, "checklist.parser.answered-no", result.Warnings[0].Message)
+	assert.Equal(t, []string{"question", "Is the source named?"}, result.Warnings[0].TranslationArgs)
+	assert.Equal(t, "- [x] Is each requirement testable?\n- [ ] Is the source named?", result.Requirement)
+
+	result = tmpl.Parse(map[string]Answer{
+		"testable": {Value: "no", Notes: "Two are vague"},
+		"source":   {Value: "yes"},
+		"remarks":  {Notes: "Done"},
+	})
+	assert.True(t, result.Ok())
+	assert.Equal(t, "- [ ] Is each requirement testable? (Two are vague)\n- [x] Is the source named?\n- Remarks: Done", result.Requirement)
+
+	result = tmpl.Parse(map[string]Answer{
+		"testable": {Value: "no"},
+		"source":   {Value: "maybe"},
+	})
+	assert.False(t, result.Ok())
+	assert.Empty(t, result.Requirement)
+	assert.Equal(t, []parser.ParsingLog{{
+		Segment:         &parser.ParsingSegment{Name: "testable", Value: "no"},
+		Level:           parser.ParsingLogLevelError,
+		Message:         "checklist.parser.missing-notes",
+		TranslationArgs: []string{"question", "Is each requirement testable?"},
+	}}, result.ViolationsForRule("testable"))
+	require.Len(t, result.ViolationsForRule("source"), 1)
+	assert.Equal(t, "checklist.parser.invalid-answer", result.ViolationsForRule("source")[0].Message)
+
+	result = tmpl.Parse(nil)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, "checklist.parser.missing-answer", result.Errors[0].Message)
+	assert.Equal(t, "checklist.parser.missing-answer", result.Errors[1].Message)
+}
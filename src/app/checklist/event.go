@@ -0,0 +1,39 @@
+package checklist
+
+import (
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"strings"
+)
+
+// SubscribeTemplateValidation subscribes to the template.ValidateTemplateConfigEvent and validates checklist templates.
+// Subscribing registers TemplateType as a template type: configs of types nobody validates are rejected (see template.ErrDidNotValidate).
+// The subscriber is safe for concurrent use (see template.ValidationPipeline).
+func SubscribeTemplateValidation(em event.Manager, validator validation.V) {
+	em.Subscribe("template.config.validate", func(event event.Event, args *event.PublishArgs) error {
+		validateEvent, ok := event.Payload().(*template.ValidateTemplateConfigEvent)
+		if !ok {
+			return nil
+		}
+		if strings.ToLower(validateEvent.TemplateType) != TemplateType {
+			return nil
+		}
+		if validateEvent.DidValidate {
+			return nil
+		}
+		validateEvent.DidValidate = true
+
+		t, err := FromConfig(validateEvent.Config)
+		if err != nil {
+			validateEvent.AddErrors(ErrInvalidConfig, template.ErrInvalidTemplate)
+			return nil
+		}
+
+		if errs := t.Validate(validator); len(errs) > 0 {
+			validateEvent.AddErrors(errs...)
+		}
+
+		return nil
+	}, event.DefaultPriority)
+}
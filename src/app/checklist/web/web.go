@@ -0,0 +1,138 @@
+package web
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/checklist"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+)
+
+// ErrTemplateNotFound is displayed to the user if the checklist template could not be found or belongs to another user.
+var ErrTemplateNotFound = errors.New("checklist.error.template-not-found")
+
+// FormData is the data of the checklist page and its form answering a checklist.
+type FormData struct {
+	// Templates are the user's checklist templates to choose from. They are only filled on the checklist page.
+	Templates []*template.Template
+	// Template is the chosen checklist template. It is nil if no template was chosen.
+	Template *checklist.Template
+	// TemplateID is the ID of the template that is answered.
+	TemplateID   uuid.UUID
+	DisplayTypes map[string]checklist.DisplayType
+	// Answers are the answers of the last submit keyed by the items' keys.
+	Answers map[string]checklist.Answer
+	// ParsingResult is the result of checking the answers. It is nil if the checklist was not submitted yet.
+	ParsingResult *parser.ParsingResult
+}
+
+// RegisterController registers the controllers of checklist templates and subscribes to the validation of their configs:
+//   - GET /checklist Renders the checklist page listing the user's checklist templates.
+//   - GET /checklist/{templateID} Renders the checklist page with the form answering the template.
+//   - POST /checklist/{templateID} Checks the answers (answer-<item>, notes-<item>) and renders the form with the result.
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	// TODO remove this with module manager
+	checklist.SubscribeTemplateValidation(appCtx.EventManager, appCtx.Validator)
+
+	registerNavigation(webCtx)
+
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/checklist", checklistPage(appCtx, webCtx, templateRepository).ServeHTTP)
+	router.Get("/checklist/{templateID}", checklistPage(appCtx, webCtx, templateRepository).ServeHTTP)
+	router.Post("/checklist/{templateID}", checkAnswers(appCtx, webCtx, templateRepository).ServeHTTP)
+}
+
+func registerNavigation(webCtx *web.Ctx) {
+	webCtx.Navigation.Add("checklist", web.NavItem{
+		URL:  "/checklist",
+		Name: "harmony.menu.checklist",
+		Display: func(io web.IO) (bool, error) {
+			return true, nil
+		},
+		Position:       105,
+		ActivePrefixes: []string{"/checklist/"},
+	})
+}
+
+func checklistPage(appCtx *hctx.AppCtx, webCtx *web.Ctx, templateRepository template.Repository) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		templates, err := templateRepository.FindByQueryForTypeAndUser(io.Context(), template.SearchFilter{}, checklist.TemplateType, user.MustFromIO(io))
+		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		form := &FormData{}
+		var formErr error
+		if templateID := web.URLParam(io.Request(), "templateID"); templateID != "" {
+			form, formErr = formFromRequest(io, templateRepository, appCtx.Validator, templateID)
+			if formErr != nil {
+				form = &FormData{}
+			}
+		}
+		form.Templates = templates
+
+		return io.Render(web.NewFormData(form, nil, formErr), "checklist.page", "checklist/page.go.html", "checklist/_form.go.html")
+	})
+}
+
+// checkAnswers checks the answers of the checklist (see checklist.Template.Parse) and renders the form with the result.
+func checkAnswers(appCtx *hctx.AppCtx, webCtx *web.Ctx, templateRepository template.Repository) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		form, err := formFromRequest(io, templateRepository, appCtx.Validator, web.URLParam(io.Request(), "templateID"))
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		request := io.Request()
+		for _, item := range form.Template.Items {
+			form.Answers[item.Key] = checklist.Answer{
+				Value: request.FormValue("answer-" + item.Key),
+				Notes: request.FormValue("notes-" + item.Key),
+			}
+		}
+
+		result := form.Template.Parse(form.Answers)
+		form.ParsingResult = &result
+
+		var success []string
+		if result.Ok() {
+			success = []string{"checklist.form.success"}
+		}
+
+		return io.Render(web.NewFormData(form, success), "checklist.form", "checklist/_form.go.html")
+	})
+}
+
+// formFromRequest returns the form of the user's checklist template with the ID. Returned errors are safe to display to the user.
+func formFromRequest(io web.IO, templateRepository template.Repository, validator validation.V, templateID string) (*FormData, error) {
+	id, err := uuid.Parse(templateID)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	tmpl, err := templateRepository.FindByID(io.Context(), id)
+	if err != nil || tmpl.CreatedBy != user.MustFromIO(io).ID || tmpl.Type != checklist.TemplateType {
+		return nil, ErrTemplateNotFound
+	}
+
+	t, err := checklist.IntoTemplate(tmpl, validator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FormData{
+		Template:     t,
+		TemplateID:   tmpl.ID,
+		DisplayTypes: t.DisplayTypes(),
+		Answers:      make(map[string]checklist.Answer),
+	}, nil
+}
@@ -0,0 +1,191 @@
+// Package comment allows users to discuss templates and requirements in comment threads.
+// Comments are attached to a target, e.g. a template, and can be replied to. Users are mentioned by their email (@jane@example.com)
+// and notified about it (see MentionedEvent).
+package comment
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"time"
+)
+
+const (
+	// RepositoryName is the name of the comment repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "CommentRepository"
+	// Pkg is the package name for logging.
+	Pkg = "app.comment"
+	// TargetTemplate is the target type of comments on a template. The target ID is the template's ID.
+	TargetTemplate = "template"
+	// TargetRequirement is the target type of comments on a stored requirement. The target ID is the requirement's ID.
+	TargetRequirement = "requirement"
+	// MentionedEventID is the id of the MentionedEvent.
+	MentionedEventID = "comment.mentioned"
+	// MaxBodyLength is the maximum length of a comment in characters.
+	MaxBodyLength = 5000
+	// commentColumns is the column list of the comments table in the order scanned by scanComment.
+	commentColumns = "c.id, c.target_type, c.target_id, c.parent_id, c.body, c.created_by, u.email, c.created_at"
+)
+
+var (
+	// ErrInvalidTarget is returned if the target type is unknown or the target does not exist.
+	ErrInvalidTarget = errors.New("comment.error.invalid-target")
+	// ErrInvalidParent is returned if the replied to comment does not exist or belongs to another target.
+	ErrInvalidParent = errors.New("comment.error.invalid-parent")
+	// ErrEmpty is returned if the comment is empty.
+	ErrEmpty = errors.New("comment.error.empty")
+	// ErrTooLong is returned if the comment is longer than MaxBodyLength.
+	ErrTooLong = errors.New("comment.error.too-long")
+)
+
+// Comment is a comment on a target, e.g. a template. Comments without a parent start a thread, replies reference their parent.
+type Comment struct {
+	ID         uuid.UUID
+	TargetType string
+	TargetID   uuid.UUID
+	// ParentID is the id of the replied to comment, nil if the comment starts a thread.
+	ParentID  *uuid.UUID
+	Body      string
+	CreatedBy uuid.UUID
+	// CreatedByEmail is the email of the comment's author joined onto the comment.
+	CreatedByEmail string
+	CreatedAt      time.Time
+	// Replies are the replies to the comment ordered by their creation. They are only filled by Threads.
+	Replies []*Comment
+}
+
+// ToCreate is the comment entity that is used to create a new comment.
+type ToCreate struct {
+	TargetType string    `hvalidate:"required"`
+	TargetID   uuid.UUID `hvalidate:"required"`
+	ParentID   *uuid.UUID
+	Body       string    `hvalidate:"required"`
+	CreatedBy  uuid.UUID `hvalidate:"required"`
+}
+
+// Repository is the comment repository. It contains all methods to interact with the comments table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID). Callers are responsible for checking the user
+// may access the comments' target. Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// FindByID finds a comment by its id.
+	// It returns persistence.ErrNotFound if the comment could not be found and persistence.ErrReadRow for any other error.
+	FindByID(ctx context.Context, id uuid.UUID) (*Comment, error)
+	// FindByTarget finds all comments of a target ordered by their creation, the oldest first.
+	// It returns an empty slice if the target has no comments and persistence.ErrReadRow for any other error.
+	FindByTarget(ctx context.Context, targetType string, targetID uuid.UUID) ([]*Comment, error)
+	// Create creates a new comment and returns it with the email of its author.
+	// It returns persistence.ErrInsert if the comment could not be inserted.
+	Create(ctx context.Context, toCreate *ToCreate) (*Comment, error)
+	// Delete deletes a comment by its id including all replies to it.
+	// It returns persistence.ErrDelete if the comment could not be deleted.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// PGRepository is the comment repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &PGRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// FindByID finds a comment by its id.
+// It returns persistence.ErrNotFound if the comment could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByID(ctx context.Context, id uuid.UUID) (*Comment, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+commentColumns+" FROM comments c JOIN users u ON u.id = c.created_by WHERE c.id = $1 AND c.tenant_id = $2",
+		id, tenant.ID(ctx),
+	), scanComment)
+}
+
+// FindByTarget finds all comments of a target ordered by their creation, the oldest first.
+// It returns an empty slice if the target has no comments and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByTarget(ctx context.Context, targetType string, targetID uuid.UUID) ([]*Comment, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT `+commentColumns+` FROM comments c JOIN users u ON u.id = c.created_by
+		WHERE c.target_type = $1 AND c.target_id = $2 AND c.tenant_id = $3 ORDER BY c.created_at`,
+		targetType, targetID, tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, scanComment)
+}
+
+// Create creates a new comment and returns it with the email of its author.
+// It returns persistence.ErrInsert if the comment could not be inserted.
+func (r *PGRepository) Create(ctx context.Context, toCreate *ToCreate) (*Comment, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	newComment := &Comment{
+		ID:         uuid.New(),
+		TargetType: toCreate.TargetType,
+		TargetID:   toCreate.TargetID,
+		ParentID:   toCreate.ParentID,
+		Body:       toCreate.Body,
+		CreatedBy:  toCreate.CreatedBy,
+		CreatedAt:  time.Now(),
+	}
+
+	err := r.db.QueryRow(
+		ctx,
+		`INSERT INTO comments (id, target_type, target_id, parent_id, body, created_by, created_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING (SELECT email FROM users WHERE id = $6)`,
+		newComment.ID,
+		newComment.TargetType,
+		newComment.TargetID,
+		newComment.ParentID,
+		newComment.Body,
+		newComment.CreatedBy,
+		newComment.CreatedAt,
+		tenant.ID(ctx),
+	).Scan(&newComment.CreatedByEmail)
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return newComment, nil
+}
+
+// Delete deletes a comment by its id including all replies to it.
+// It returns persistence.ErrDelete if the comment could not be deleted.
+func (r *PGRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM comments WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// scanComment scans a row containing the commentColumns into a new Comment.
+func scanComment(row pgx.Row) (*Comment, error) {
+	c := &Comment{}
+	err := row.Scan(&c.ID, &c.TargetType, &c.TargetID, &c.ParentID, &c.Body, &c.CreatedBy, &c.CreatedByEmail, &c.CreatedAt)
+
+	return c, err
+}
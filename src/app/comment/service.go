@@ -0,0 +1,158 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMentions is the maximum number of users mentioned in a comment. Further mentions are ignored.
+const MaxMentions = 10
+
+// mentionPattern matches a mention of a user by their email, e.g. @jane@example.com. The mention has to start a word.
+var mentionPattern = regexp.MustCompile(`(?:^|[\s(])@([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+// MentionResolver resolves the email of a mentioned user to the user's id. It returns false if no user with the email exists
+// or the user may not access the comment's target. Mentions of such users are ignored.
+type MentionResolver func(ctx context.Context, email string) (uuid.UUID, bool)
+
+// MentionedEvent is published after a comment mentioning users was created, e.g. to notify the mentioned users.
+// Events are handled outside the request, the event therefore carries the tenant of the request the comment was created in.
+// The event is published without waiting for subscribers.
+type MentionedEvent struct {
+	Comment *Comment
+	// Mentioned are the ids of the mentioned users. The comment's author is never mentioned.
+	Mentioned []uuid.UUID
+	// Tenant is the tenant the comment was created in. It is nil if the application is not multi-tenant.
+	Tenant *tenant.Tenant
+}
+
+// Post validates and creates the comment and publishes a MentionedEvent if it mentions users (see Mentions).
+// Replies have to belong to the same target as their parent, ErrInvalidParent is returned otherwise.
+// The body is trimmed, ErrEmpty is returned for empty comments and ErrTooLong for comments longer than MaxBodyLength.
+func Post(ctx context.Context, repository Repository, em event.Manager, toCreate *ToCreate, resolve MentionResolver) (*Comment, error) {
+	toCreate.Body = strings.TrimSpace(toCreate.Body)
+	if toCreate.Body == "" {
+		return nil, ErrEmpty
+	}
+	if utf8.RuneCountInString(toCreate.Body) > MaxBodyLength {
+		return nil, ErrTooLong
+	}
+
+	if toCreate.ParentID != nil {
+		parent, err := repository.FindByID(ctx, *toCreate.ParentID)
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, ErrInvalidParent
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.TargetType != toCreate.TargetType || parent.TargetID != toCreate.TargetID {
+			return nil, ErrInvalidParent
+		}
+	}
+
+	c, err := repository.Create(ctx, toCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	var mentioned []uuid.UUID
+	for _, email := range Mentions(c.Body) {
+		userID, ok := resolve(ctx, email)
+		if ok && userID != c.CreatedBy && !slices.Contains(mentioned, userID) {
+			mentioned = append(mentioned, userID)
+		}
+	}
+
+	if len(mentioned) > 0 {
+		t, _ := tenant.FromCtx(ctx)
+		em.Publish(&MentionedEvent{Comment: c, Mentioned: mentioned, Tenant: t}, nil)
+	}
+
+	return c, nil
+}
+
+// Mentions returns the lowercased emails of the users mentioned in the comment's body in the order of their first mention.
+// At most MaxMentions emails are returned.
+func Mentions(body string) []string {
+	var emails []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(match[1])
+		if slices.Contains(emails, email) {
+			continue
+		}
+
+		emails = append(emails, email)
+		if len(emails) == MaxMentions {
+			break
+		}
+	}
+
+	return emails
+}
+
+// Threads arranges the comments into threads: the comments without a parent with their replies (see Comment.Replies).
+// The comments are expected to be ordered by their creation, the order is kept within each thread.
+// Replies to comments that are not part of the passed in comments are treated as starting a thread.
+func Threads(comments []*Comment) []*Comment {
+	byID := make(map[uuid.UUID]*Comment, len(comments))
+	for _, c := range comments {
+		c.Replies = nil
+		byID[c.ID] = c
+	}
+
+	var threads []*Comment
+	for _, c := range comments {
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok && parent != c {
+				parent.Replies = append(parent.Replies, c)
+				continue
+			}
+		}
+
+		threads = append(threads, c)
+	}
+
+	return threads
+}
+
+// TargetURL returns the path of the page showing the target's comments, e.g. to link to it from a notification.
+func TargetURL(targetType string, targetID uuid.UUID) string {
+	switch targetType {
+	case TargetTemplate:
+		return fmt.Sprintf("/template/%s/edit", targetID)
+	case TargetRequirement:
+		return "/requirement"
+	default:
+		return "/"
+	}
+}
+
+// ID returns the event id.
+func (e *MentionedEvent) ID() string {
+	return MentionedEventID
+}
+
+// Payload returns the event payload. It is the event itself as a pointer.
+func (e *MentionedEvent) Payload() any {
+	return e
+}
+
+// Ctx returns a new context containing the tenant the comment was created in (see tenant.WithTenant).
+func (e *MentionedEvent) Ctx() context.Context {
+	ctx := context.Background()
+	if e.Tenant != nil {
+		ctx = tenant.WithTenant(ctx, e.Tenant)
+	}
+
+	return ctx
+}
@@ -0,0 +1,136 @@
+package comment
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+type repositoryMock struct {
+	Repository
+	comments []*Comment
+}
+
+func TestMentions(t *testing.T) {
+	assert.Equal(t,
+		[]string{"jane@example.com", "max.mustermann@example.de"},
+		Mentions("@Jane@example.com please check this with (@max.mustermann@example.de). Thanks @jane@example.com."),
+	)
+	assert.Empty(t, Mentions("mail jane@example.com or @jane"), "mentions start a word and contain an email")
+
+	many := make([]string, 0, MaxMentions+2)
+	for i := 0; i < MaxMentions+2; i++ {
+		many = append(many, "@user"+strings.Repeat("x", i)+"@example.com")
+	}
+	assert.Len(t, Mentions(strings.Join(many, " ")), MaxMentions)
+}
+
+func TestThreads(t *testing.T) {
+	root, reply, nested, other := &Comment{ID: uuid.New()}, &Comment{ID: uuid.New()}, &Comment{ID: uuid.New()}, &Comment{ID: uuid.New()}
+	reply.ParentID = &root.ID
+	nested.ParentID = &reply.ID
+	missing := uuid.New()
+	orphan := &Comment{ID: uuid.New(), ParentID: &missing}
+
+	threads := Threads([]*Comment{root, other, reply, nested, orphan})
+	assert.Equal(t, []*Comment{root, other, orphan}, threads)
+	assert.Equal(t, []*Comment{reply}, root.Replies)
+	assert.Equal(t, []*Comment{nested}, reply.Replies)
+	assert.Empty(t, other.Replies)
+}
+
+func TestPost(t *testing.T) {
+	ctx := context.Background()
+	em := event.NewManager(trace.NewLogger())
+	repository := &repositoryMock{}
+	author, jane := uuid.New(), uuid.New()
+	target := uuid.New()
+	resolve := func(ctx context.Context, email string) (uuid.UUID, bool) {
+		switch email {
+		case "jane@example.com":
+			return jane, true
+		case "author@example.com":
+			return author, true
+		default:
+			return uuid.Nil, false
+		}
+	}
+
+	published := make(chan *MentionedEvent, 1)
+	em.Subscribe(MentionedEventID, func(e event.Event, args *event.PublishArgs) error {
+		published <- e.Payload().(*MentionedEvent)
+		return nil
+	}, event.DefaultPriority)
+
+	_, err := Post(ctx, repository, em, &ToCreate{TargetType: TargetTemplate, TargetID: target, Body: "  ", CreatedBy: author}, resolve)
+	assert.ErrorIs(t, err, ErrEmpty)
+	_, err = Post(ctx, repository, em, &ToCreate{TargetType: TargetTemplate, TargetID: target, Body: strings.Repeat("x", MaxBodyLength+1), CreatedBy: author}, resolve)
+	assert.ErrorIs(t, err, ErrTooLong)
+
+	c, err := Post(ctx, repository, em, &ToCreate{
+		TargetType: TargetTemplate,
+		TargetID:   target,
+		Body:       " @jane@example.com @author@example.com @unknown@example.com have a look ",
+		CreatedBy:  author,
+	}, resolve)
+	require.NoError(t, err)
+	assert.Equal(t, "@jane@example.com @author@example.com @unknown@example.com have a look", c.Body)
+
+	select {
+	case e := <-published:
+		assert.Equal(t, c, e.Comment)
+		assert.Equal(t, []uuid.UUID{jane}, e.Mentioned, "unknown users and the author are not mentioned")
+	case <-time.After(time.Second):
+		t.Fatal("mention was not published")
+	}
+
+	_, err = Post(ctx, repository, em, &ToCreate{TargetType: TargetTemplate, TargetID: target, ParentID: &c.ID, Body: "reply", CreatedBy: jane}, resolve)
+	require.NoError(t, err)
+
+	otherTarget := uuid.New()
+	_, err = Post(ctx, repository, em, &ToCreate{TargetType: TargetTemplate, TargetID: otherTarget, ParentID: &c.ID, Body: "reply", CreatedBy: jane}, resolve)
+	assert.ErrorIs(t, err, ErrInvalidParent, "replies belong to the target of their parent")
+	missing := uuid.New()
+	_, err = Post(ctx, repository, em, &ToCreate{TargetType: TargetTemplate, TargetID: target, ParentID: &missing, Body: "reply", CreatedBy: jane}, resolve)
+	assert.ErrorIs(t, err, ErrInvalidParent)
+
+	assert.Len(t, repository.comments, 2)
+}
+
+func TestTargetURL(t *testing.T) {
+	id := uuid.New()
+	assert.Equal(t, "/template/"+id.String()+"/edit", TargetURL(TargetTemplate, id))
+	assert.Equal(t, "/requirement", TargetURL(TargetRequirement, id))
+}
+
+func (r *repositoryMock) FindByID(ctx context.Context, id uuid.UUID) (*Comment, error) {
+	for _, c := range r.comments {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+
+	return nil, persistence.ErrNotFound
+}
+
+func (r *repositoryMock) Create(ctx context.Context, toCreate *ToCreate) (*Comment, error) {
+	c := &Comment{
+		ID:         uuid.New(),
+		TargetType: toCreate.TargetType,
+		TargetID:   toCreate.TargetID,
+		ParentID:   toCreate.ParentID,
+		Body:       toCreate.Body,
+		CreatedBy:  toCreate.CreatedBy,
+		CreatedAt:  time.Now(),
+	}
+	r.comments = append(r.comments, c)
+
+	return c, nil
+}
@@ -0,0 +1,240 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/comment"
+	"github.com/org-harmony/harmony/src/app/project"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+)
+
+// ThreadData is the data of the comment threads of a target.
+type ThreadData struct {
+	TargetType string
+	TargetID   uuid.UUID
+	Threads    []*comment.Comment
+	// UserID is the logged-in user. Users only delete their own comments.
+	UserID uuid.UUID
+}
+
+// ItemData is the data of a comment rendered within the threads of its target.
+type ItemData struct {
+	*comment.Comment
+	Thread *ThreadData
+}
+
+// controllerDeps are the dependencies shared by the comment controllers.
+type controllerDeps struct {
+	comments     comment.Repository
+	templates    template.Repository
+	requirements requirement.Repository
+	projects     project.Repository
+	users        user.Repository
+}
+
+// RegisterController registers the controllers of the comment module:
+//   - GET /comment/target/{targetType}/{targetID} Renders the comment threads and the comment form of a target.
+//   - POST /comment/target/{targetType}/{targetID} Creates a comment (body) on the target, optionally replying to another comment (parent).
+//   - DELETE /comment/{id} Deletes a comment including all replies to it.
+//
+// The threads are meant to be embedded into other pages, e.g. using hx-get with the load trigger.
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	registerErrors(webCtx)
+
+	deps := &controllerDeps{
+		comments:     util.UnwrapType[comment.Repository](appCtx.Repository(comment.RepositoryName)),
+		templates:    util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName)),
+		requirements: util.UnwrapType[requirement.Repository](appCtx.Repository(requirement.RepositoryName)),
+		projects:     util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName)),
+		users:        util.UnwrapType[user.Repository](appCtx.Repository(user.RepositoryName)),
+	}
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/comment/target/{targetType}/{targetID}", threadsController(appCtx, webCtx, deps).ServeHTTP)
+	router.Post("/comment/target/{targetType}/{targetID}", postController(appCtx, webCtx, deps).ServeHTTP)
+	router.Delete("/comment/{id}", deleteController(appCtx, webCtx, deps).ServeHTTP)
+}
+
+// Item returns the data of the comment rendered within the threads.
+func (d *ThreadData) Item(c *comment.Comment) ItemData {
+	return ItemData{Comment: c, Thread: d}
+}
+
+// Item returns the data of the reply rendered within the threads.
+func (d ItemData) Item(c *comment.Comment) ItemData {
+	return d.Thread.Item(c)
+}
+
+// IsAuthor returns true if the logged-in user wrote the comment.
+func (d ItemData) IsAuthor() bool {
+	return d.CreatedBy == d.Thread.UserID
+}
+
+// registerErrors maps the errors of the comment controllers to their HTTP status codes.
+func registerErrors(webCtx *web.Ctx) {
+	webCtx.Errors.Map(comment.ErrInvalidTarget, http.StatusNotFound, web.ErrNotFound)
+	for _, err := range []error{comment.ErrInvalidParent, comment.ErrEmpty, comment.ErrTooLong} {
+		webCtx.Errors.Map(err, http.StatusUnprocessableEntity, err)
+	}
+}
+
+func threadsController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		targetType, targetID, err := deps.permittedTarget(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		return deps.renderThreads(io, targetType, targetID, nil)
+	})
+}
+
+func postController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		targetType, targetID, err := deps.permittedTarget(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		toCreate := &comment.ToCreate{
+			TargetType: targetType,
+			TargetID:   targetID,
+			Body:       io.Request().FormValue("body"),
+			CreatedBy:  user.MustCtxUser(io.Context()).ID,
+		}
+		if parent := io.Request().FormValue("parent"); parent != "" {
+			parentID, err := uuid.Parse(parent)
+			if err != nil {
+				return deps.renderThreads(io, targetType, targetID, nil, comment.ErrInvalidParent)
+			}
+
+			toCreate.ParentID = &parentID
+		}
+
+		_, err = comment.Post(io.Context(), deps.comments, appCtx.EventManager, toCreate, deps.mentionResolver(targetType, targetID))
+		if errors.Is(err, comment.ErrEmpty) || errors.Is(err, comment.ErrTooLong) || errors.Is(err, comment.ErrInvalidParent) {
+			return deps.renderThreads(io, targetType, targetID, nil, err)
+		}
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return deps.renderThreads(io, targetType, targetID, []string{"comment.posted"})
+	})
+}
+
+func deleteController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+		if err != nil {
+			return io.InlineError(web.NotFound(err))
+		}
+
+		c, err := deps.comments.FindByID(io.Context(), id)
+		if err != nil && errors.Is(err, persistence.ErrNotFound) {
+			return io.InlineError(web.ErrNotFound, err)
+		} else if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		if c.CreatedBy != user.MustCtxUser(io.Context()).ID {
+			return io.InlineError(web.Forbidden(nil))
+		}
+
+		if err := deps.comments.Delete(io.Context(), c.ID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return deps.renderThreads(io, c.TargetType, c.TargetID, []string{"comment.deleted"})
+	})
+}
+
+// permittedTarget returns the target of the request's URL parameters targetType and targetID
+// if the logged-in user may access the target's comments, see controllerDeps.access.
+func (d *controllerDeps) permittedTarget(io web.IO) (string, uuid.UUID, error) {
+	request := io.Request()
+	targetType := web.URLParam(request, "targetType")
+
+	targetID, err := uuid.Parse(web.URLParam(request, "targetID"))
+	if err != nil {
+		return "", uuid.Nil, errors.Join(comment.ErrInvalidTarget, err)
+	}
+
+	if err := d.access(io.Context(), targetType, targetID, user.MustCtxUser(io.Context()).ID); err != nil {
+		return "", uuid.Nil, err
+	}
+
+	return targetType, targetID, nil
+}
+
+// access returns nil if the user may access the comments of the target. Templates are accessible by their creator
+// and the members of the projects their template set belongs to. Requirements are accessible by their author and reviewer.
+// It returns comment.ErrInvalidTarget if the target does not exist and web.ErrForbidden if the user may not access it.
+func (d *controllerDeps) access(ctx context.Context, targetType string, targetID uuid.UUID, userID uuid.UUID) error {
+	switch targetType {
+	case comment.TargetTemplate:
+		tmpl, err := d.templates.FindByID(ctx, targetID)
+		if err != nil {
+			return errors.Join(comment.ErrInvalidTarget, err)
+		}
+		if tmpl.CreatedBy == userID {
+			return nil
+		}
+
+		projects, err := d.projects.FindByMember(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, p := range projects {
+			if p.HasTemplateSet(tmpl.TemplateSet) {
+				return nil
+			}
+		}
+
+		return web.Forbidden(nil)
+	case comment.TargetRequirement:
+		_, err := d.requirements.FindByIDAsParticipant(ctx, userID, targetID)
+		if err != nil {
+			return errors.Join(comment.ErrInvalidTarget, err)
+		}
+
+		return nil
+	default:
+		return comment.ErrInvalidTarget
+	}
+}
+
+// mentionResolver resolves mentioned users by their email. Users that may not access the target are not mentioned.
+func (d *controllerDeps) mentionResolver(targetType string, targetID uuid.UUID) comment.MentionResolver {
+	return func(ctx context.Context, email string) (uuid.UUID, bool) {
+		u, err := d.users.FindByEmail(ctx, email)
+		if err != nil {
+			return uuid.Nil, false
+		}
+
+		return u.ID, d.access(ctx, targetType, targetID, u.ID) == nil
+	}
+}
+
+// renderThreads renders the comment threads of the target with the comment form.
+func (d *controllerDeps) renderThreads(io web.IO, targetType string, targetID uuid.UUID, success []string, errs ...error) error {
+	comments, err := d.comments.FindByTarget(io.Context(), targetType, targetID)
+	if err != nil {
+		return io.InlineError(web.ErrInternal, err)
+	}
+
+	return io.Render(web.NewFormData(&ThreadData{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Threads:    comment.Threads(comments),
+		UserID:     user.MustCtxUser(io.Context()).ID,
+	}, success, errs...), "comment.threads", "comment/_threads.go.html")
+}
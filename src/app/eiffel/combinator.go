@@ -0,0 +1,318 @@
+package eiffel
+
+import (
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"strings"
+)
+
+// maxCombinatorDepth limits the nesting of combinator rules during parsing. It protects against cyclic references
+// in templates that were not validated before parsing.
+const maxCombinatorDepth = 10
+
+// ruleResolverContextKey is the context key under which the ruleResolver is stored during BasicTemplate.Parse.
+const ruleResolverContextKey = "eiffel.ruleResolver"
+
+// ErrCombinatorDepthExceeded is returned if combinator rules are nested too deep, this usually means the references are cyclic.
+var ErrCombinatorDepthExceeded = errors.New("eiffel.parser.error.combinator-depth-exceeded")
+
+// AllOfRuleParser is a rule parser for the rule type 'allOf'. It expects the rule's value to be a slice of rule names
+// defined in the same template. Each referenced rule is parsed against the same segment and all logs are aggregated.
+// The segment is valid if it is valid for all referenced rules.
+type AllOfRuleParser struct{}
+
+// AnyOfRuleParser is a rule parser for the rule type 'anyOf'. It expects the rule's value to be a slice of rule names
+// defined in the same template. The segment is valid if it is valid (without errors) for any of the referenced rules.
+// In that case, the warnings and notices of the first valid rule are reported.
+type AnyOfRuleParser struct{}
+
+// NotRuleParser is a rule parser for the rule type 'not'. It expects the rule's value to be a slice of rule names
+// defined in the same template. The segment is valid if it is invalid for each of the referenced rules.
+type NotRuleParser struct{}
+
+// CombinatorReferenceError is returned if a combinator rule references a rule that is not defined in the template
+// or if the references of combinator rules are cyclic. It is returned by the CombinatorReferencesValidator.
+type CombinatorReferenceError struct {
+	Rule      string
+	Reference string
+	Template  string
+	// Cyclic is true if the reference is part of a cycle. Otherwise, the referenced rule is not defined.
+	Cyclic bool
+}
+
+// ruleResolver gives combinator rule parsers access to the template's rules and the rule parsers.
+type ruleResolver struct {
+	rules   map[string]BasicRule
+	parsers *RuleParserProvider
+	depth   int
+}
+
+// IsCombinator returns true if the rule type is a combinator rule type referencing other rules.
+func IsCombinator(ruleType string) bool {
+	return ruleType == "allOf" || ruleType == "anyOf" || ruleType == "not"
+}
+
+// Parse implements the RuleParser interface for the AllOfRuleParser. It is used to parse rules of the type 'allOf'.
+func (p AllOfRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+	results, err := parseReferences(ctx, rule, segment)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []parser.ParsingLog
+	for _, result := range results {
+		logs = append(logs, result...)
+	}
+
+	return logs, nil
+}
+
+// Validate implements the RuleParser interface for the AllOfRuleParser. It is used to validate rules of the type 'allOf'.
+// The allOf rule expects a slice of rule names as value. The references are validated by the CombinatorReferencesValidator.
+func (p AllOfRuleParser) Validate(v validation.V, rule BasicRule) []error {
+	return validateReferences(rule)
+}
+
+// DisplayType implements the RuleParser interface for the AllOfRuleParser. Combinator rules are displayed like placeholders.
+func (p AllOfRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
+	return PlaceholderRuleParser{}.DisplayType(rule)
+}
+
+// Parse implements the RuleParser interface for the AnyOfRuleParser. It is used to parse rules of the type 'anyOf'.
+func (p AnyOfRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+	results, err := parseReferences(ctx, rule, segment)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if !hasErrors(result) {
+			return result, nil
+		}
+	}
+
+	return []parser.ParsingLog{{
+		Segment:         &segment,
+		Level:           parser.ParsingLogLevelError,
+		Message:         "eiffel.parser.any-of.error",
+		TranslationArgs: []string{"rules", referencedRuleNames(ctx, rule), "actual", segment.Value},
+		Ranges:          []parser.ParsingRange{segmentRange(segment, parser.ParsingLogLevelError)},
+	}}, nil
+}
+
+// Validate implements the RuleParser interface for the AnyOfRuleParser. It is used to validate rules of the type 'anyOf'.
+// The anyOf rule expects a slice of rule names as value. The references are validated by the CombinatorReferencesValidator.
+func (p AnyOfRuleParser) Validate(v validation.V, rule BasicRule) []error {
+	return validateReferences(rule)
+}
+
+// DisplayType implements the RuleParser interface for the AnyOfRuleParser. Combinator rules are displayed like placeholders.
+func (p AnyOfRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
+	return PlaceholderRuleParser{}.DisplayType(rule)
+}
+
+// Parse implements the RuleParser interface for the NotRuleParser. It is used to parse rules of the type 'not'.
+func (p NotRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+	results, err := parseReferences(ctx, rule, segment)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if hasErrors(result) {
+			continue
+		}
+
+		return []parser.ParsingLog{{
+			Segment:         &segment,
+			Level:           parser.ParsingLogLevelError,
+			Message:         "eiffel.parser.not.error",
+			TranslationArgs: []string{"rules", referencedRuleNames(ctx, rule), "actual", segment.Value},
+			Ranges:          []parser.ParsingRange{segmentRange(segment, parser.ParsingLogLevelError)},
+		}}, nil
+	}
+
+	return nil, nil
+}
+
+// Validate implements the RuleParser interface for the NotRuleParser. It is used to validate rules of the type 'not'.
+// The not rule expects a slice of rule names as value. The references are validated by the CombinatorReferencesValidator.
+func (p NotRuleParser) Validate(v validation.V, rule BasicRule) []error {
+	return validateReferences(rule)
+}
+
+// DisplayType implements the RuleParser interface for the NotRuleParser. Combinator rules are displayed like placeholders.
+func (p NotRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
+	return PlaceholderRuleParser{}.DisplayType(rule)
+}
+
+// CombinatorReferencesValidator validates that each rule referenced by a combinator rule is defined in the template's
+// 'rules' section and that the references of combinator rules are not cyclic.
+func CombinatorReferencesValidator(basicTemplate any) error {
+	bt, ok := basicTemplate.(*BasicTemplate)
+	if !ok {
+		return nil
+	}
+
+	for name, rule := range bt.Rules {
+		if !IsCombinator(rule.Type) {
+			continue
+		}
+
+		references, err := toStringSlice(rule.Value)
+		if err != nil {
+			continue // the value itself is validated by the combinator's rule parser
+		}
+
+		for _, reference := range references {
+			if _, ok := bt.Rules[reference]; !ok {
+				return CombinatorReferenceError{Rule: name, Reference: reference, Template: bt.Name}
+			}
+		}
+
+		if reference, ok := findCycle(bt.Rules, name, map[string]bool{}); ok {
+			return CombinatorReferenceError{Rule: name, Reference: reference, Template: bt.Name, Cyclic: true}
+		}
+	}
+
+	return nil
+}
+
+// Error on CombinatorReferenceError returns the error code of the error.
+func (e CombinatorReferenceError) Error() string {
+	if e.Cyclic {
+		return "eiffel.parser.error.cyclic-rule-reference"
+	}
+
+	return "eiffel.parser.error.invalid-rule-reference"
+}
+
+// UnwrapTransparent on CombinatorReferenceError returns the error itself, implementing the validation.TransparentError interface.
+func (e CombinatorReferenceError) UnwrapTransparent(err validation.Error) error {
+	return e
+}
+
+// Translate on CombinatorReferenceError translates the error using the given translator.
+func (e CombinatorReferenceError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "template", e.Template, "rule", e.Rule, "reference", e.Reference)
+}
+
+// withRuleResolver adds a ruleResolver for the template's rules to the context.
+// It is called by BasicTemplate.Parse to allow combinator rules to parse their referenced rules.
+func withRuleResolver(ctx context.Context, bt *BasicTemplate, ruleParsers *RuleParserProvider) context.Context {
+	return context.WithValue(ctx, ruleResolverContextKey, &ruleResolver{rules: bt.Rules, parsers: ruleParsers})
+}
+
+// parseReferences parses all rules referenced by the combinator rule against the segment.
+// It returns the parsing logs per referenced rule in the order of the references.
+func parseReferences(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([][]parser.ParsingLog, error) {
+	resolver, ok := util.CtxValue[*ruleResolver](ctx, ruleResolverContextKey)
+	if !ok {
+		return nil, RuleInvalidValueError{Rule: &rule, Msg: "eiffel.parser.error.invalid-rule-reference"}
+	}
+
+	if resolver.depth >= maxCombinatorDepth {
+		return nil, ErrCombinatorDepthExceeded
+	}
+
+	references, err := toStringSlice(rule.Value)
+	if err != nil {
+		return nil, RuleInvalidValueError{Rule: &rule, Msg: err.Error()}
+	}
+
+	nested := &ruleResolver{rules: resolver.rules, parsers: resolver.parsers, depth: resolver.depth + 1}
+	nestedCtx := context.WithValue(ctx, ruleResolverContextKey, nested)
+
+	results := make([][]parser.ParsingLog, 0, len(references))
+	for _, reference := range references {
+		referencedRule, ok := resolver.rules[reference]
+		if !ok {
+			return nil, CombinatorReferenceError{Reference: reference, Rule: rule.Name}
+		}
+
+		logs, err := parse(nestedCtx, resolver.parsers, reference, referencedRule, segment)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, logs)
+	}
+
+	return results, nil
+}
+
+// referencedRuleNames returns the display names of the rules referenced by the combinator rule joined by a comma.
+func referencedRuleNames(ctx context.Context, rule BasicRule) string {
+	references, _ := toStringSlice(rule.Value)
+	resolver, ok := util.CtxValue[*ruleResolver](ctx, ruleResolverContextKey)
+
+	names := make([]string, 0, len(references))
+	for _, reference := range references {
+		name := reference
+		if ok {
+			if r, rOk := resolver.rules[reference]; rOk {
+				name = r.Name
+			}
+		}
+
+		names = append(names, "\""+name+"\"")
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func validateReferences(rule BasicRule) []error {
+	references, err := toStringSlice(rule.Value)
+	if err != nil {
+		return []error{RuleInvalidValueError{Rule: &rule, Msg: err.Error()}}
+	}
+
+	if len(references) == 0 {
+		return []error{RuleInvalidValueError{Rule: &rule, Msg: "eiffel.parser.error.empty-rule-references"}}
+	}
+
+	return nil
+}
+
+// findCycle returns a rule name that is referenced in a cycle starting at the rule with the given name.
+// visiting contains the rule names on the current path.
+func findCycle(rules map[string]BasicRule, name string, visiting map[string]bool) (string, bool) {
+	rule, ok := rules[name]
+	if !ok || !IsCombinator(rule.Type) {
+		return "", false
+	}
+
+	references, err := toStringSlice(rule.Value)
+	if err != nil {
+		return "", false
+	}
+
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	for _, reference := range references {
+		if visiting[reference] {
+			return reference, true
+		}
+
+		if cyclic, ok := findCycle(rules, reference, visiting); ok {
+			return cyclic, true
+		}
+	}
+
+	return "", false
+}
+
+func hasErrors(logs []parser.ParsingLog) bool {
+	for _, log := range logs {
+		if log.Level == parser.ParsingLogLevelError {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCombinatorRules(t *testing.T) {
+	bt := combinatorTemplate()
+	rp := RuleParsers()
+
+	t.Run("combinator template is valid", func(t *testing.T) {
+		require.Len(t, bt.Validate(validation.New(), rp), 0)
+	})
+
+	t.Run("allOf aggregates logs of all referenced rules", func(t *testing.T) {
+		result, err := bt.Parse(context.Background(), rp, "allOf", parser.ParsingSegment{Name: "allOfRule", Value: "maybe the system"})
+		require.NoError(t, err)
+		assert.True(t, result.Ok())
+		assert.Len(t, result.Warnings, 1)
+
+		result, err = bt.Parse(context.Background(), rp, "allOf", parser.ParsingSegment{Name: "allOfRule", Value: "maybe"})
+		require.NoError(t, err)
+		assert.Len(t, result.Errors, 1)
+		assert.Len(t, result.Warnings, 1)
+	})
+
+	t.Run("anyOf is valid if any referenced rule is valid", func(t *testing.T) {
+		result, err := bt.Parse(context.Background(), rp, "anyOf", parser.ParsingSegment{Name: "anyOfRule", Value: "should"})
+		require.NoError(t, err)
+		assert.True(t, result.Flawless())
+
+		result, err = bt.Parse(context.Background(), rp, "anyOf", parser.ParsingSegment{Name: "anyOfRule", Value: "could"})
+		require.NoError(t, err)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "eiffel.parser.any-of.error", result.Errors[0].Message)
+	})
+
+	t.Run("not is valid if no referenced rule is valid", func(t *testing.T) {
+		result, err := bt.Parse(context.Background(), rp, "not", parser.ParsingSegment{Name: "notRule", Value: "could"})
+		require.NoError(t, err)
+		assert.True(t, result.Flawless())
+
+		result, err = bt.Parse(context.Background(), rp, "not", parser.ParsingSegment{Name: "notRule", Value: "shall"})
+		require.NoError(t, err)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "eiffel.parser.not.error", result.Errors[0].Message)
+	})
+
+	t.Run("undefined reference is invalid", func(t *testing.T) {
+		bt := combinatorTemplate()
+		bt.Rules["anyOfRule"] = BasicRule{Name: "Any Of", Type: "anyOf", Value: []any{"shallRule", "undefinedRule"}}
+
+		errs := bt.Validate(validation.New(), rp)
+		require.Len(t, errs, 2)
+		assert.ErrorAs(t, errs[0], &CombinatorReferenceError{})
+		assert.Equal(t, "eiffel.parser.error.invalid-rule-reference", errs[0].Error())
+	})
+
+	t.Run("cyclic reference is invalid", func(t *testing.T) {
+		bt := combinatorTemplate()
+		bt.Rules["anyOfRule"] = BasicRule{Name: "Any Of", Type: "anyOf", Value: []any{"shallRule", "notRule"}}
+		bt.Rules["notRule"] = BasicRule{Name: "Not", Type: "not", Value: []any{"anyOfRule"}}
+
+		errs := bt.Validate(validation.New(), rp)
+		require.Len(t, errs, 2)
+		assert.Equal(t, "eiffel.parser.error.cyclic-rule-reference", errs[0].Error())
+
+		_, err := bt.Parse(context.Background(), rp, "not", parser.ParsingSegment{Name: "notRule", Value: "could"})
+		assert.ErrorIs(t, err, ErrCombinatorDepthExceeded)
+	})
+}
+
+func combinatorTemplate() *BasicTemplate {
+	return &BasicTemplate{
+		ID:      "combinator-template",
+		Name:    "Combinator Template",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"shallRule":    {Name: "Shall", Type: "equals", Value: "shall"},
+			"shouldRule":   {Name: "Should", Type: "equals", Value: "should"},
+			"systemRule":   {Name: "System", Type: "equalsAny", Value: []any{"maybe the system", "the system"}},
+			"weakWordRule": {Name: "Weak Words", Type: "forbids", Value: []any{"maybe"}},
+			"allOfRule":    {Name: "All Of", Type: "allOf", Value: []any{"systemRule", "weakWordRule"}},
+			"anyOfRule":    {Name: "Any Of", Type: "anyOf", Value: []any{"shallRule", "shouldRule"}},
+			"notRule":      {Name: "Not", Type: "not", Value: []any{"shallRule", "shouldRule"}},
+		},
+		Variants: map[string]BasicVariant{
+			"allOf": {Name: "All Of", Rules: []string{"allOfRule"}},
+			"anyOf": {Name: "Any Of", Rules: []string{"anyOfRule"}},
+			"not":   {Name: "Not", Rules: []string{"notRule"}},
+		},
+	}
+}
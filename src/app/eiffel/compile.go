@@ -0,0 +1,239 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/expr"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxCompiledTemplates is the maximum number of templates whose compiled rules are cached, see CompiledRulesFor.
+const MaxCompiledTemplates = 1000
+
+// RuleCompiler is implemented by rule parsers precompiling rule values, e.g. normalizing values or compiling expressions.
+// The value of the passed in rule is resolved to a single locale. The compiled value is passed to the parser
+// through the rule while parsing, see CompiledValue. If compiling fails, the rule is parsed from its plain value.
+type RuleCompiler interface {
+	Compile(rule BasicRule) (any, error)
+}
+
+// CompiledRule is the precompiled value of a rule by locale path. Plain (non-localized) values are compiled under the empty path.
+type CompiledRule struct {
+	values map[string]any
+	// fallback is the first locale path (sorted) used like BasicRule.ValueFor for locales without a value.
+	fallback string
+}
+
+// CompiledRules are the compiled rules of a template by the rule's technical name, see BasicTemplate.Compile.
+type CompiledRules map[string]*CompiledRule
+
+// compiledEquals is the compiled value of an equals rule.
+type compiledEquals struct {
+	lower string
+}
+
+// compiledEqualsAny is the compiled value of an equalsAny rule: the original values and the set of lowercase values.
+type compiledEqualsAny struct {
+	values []string
+	set    map[string]struct{}
+}
+
+// compiledForbids is the compiled value of a forbids rule: the forbidden phrases and their runes.
+type compiledForbids struct {
+	phrases []string
+	runes   [][]rune
+}
+
+// compiledRulesCache caches the compiled rules of templates by their id, see CompiledRulesFor.
+var compiledRulesCache = struct {
+	entries map[uuid.UUID]compiledRulesEntry
+	mu      sync.Mutex
+}{entries: map[uuid.UUID]compiledRulesEntry{}}
+
+type compiledRulesEntry struct {
+	rules   CompiledRules
+	updated time.Time
+}
+
+// Compile compiles the values of all rules whose parser is a RuleCompiler and attaches them to the rules of the template.
+// Parsing the template (see BasicTemplate.Parse) uses the compiled values, e.g. the lowercase values of equalsAny rules,
+// instead of normalizing the values on each parse. The template must be compiled after resolving the templates it extends
+// and must not be modified afterward. Localizing the template (see BasicTemplate.Localize) keeps the compiled values.
+func (bt *BasicTemplate) Compile(ruleParsers *RuleParserProvider) CompiledRules {
+	compiled := make(CompiledRules, len(bt.Rules))
+	for name, rule := range bt.Rules {
+		ruleParser, err := ruleParsers.Parser(rule.Type)
+		if err != nil {
+			continue
+		}
+
+		compiler, ok := ruleParser.(RuleCompiler)
+		if !ok {
+			continue
+		}
+
+		compiledRule := compileRule(compiler, rule)
+		if compiledRule != nil {
+			compiled[name] = compiledRule
+		}
+	}
+
+	bt.AttachCompiled(compiled)
+
+	return compiled
+}
+
+// AttachCompiled attaches the compiled rules, e.g. cached by CompiledRulesFor, to the rules of the template.
+func (bt *BasicTemplate) AttachCompiled(compiled CompiledRules) {
+	for name, rule := range bt.Rules {
+		rule.compiled = compiled[name]
+		bt.Rules[name] = rule
+	}
+}
+
+// CompiledRulesFor compiles the rules of the template (see BasicTemplate.Compile) unless they are cached for the template
+// and its last update. The template is expected to be the resolved BasicTemplate of the template entity t.
+// The cache is cleared on each template update (see SubscribeCompiledRulesInvalidation) as a template's compiled rules
+// also depend on the templates it extends.
+func CompiledRulesFor(t *template.Template, bt *BasicTemplate, ruleParsers *RuleParserProvider) CompiledRules {
+	updated := t.CreatedAt
+	if t.UpdatedAt != nil {
+		updated = *t.UpdatedAt
+	}
+
+	compiledRulesCache.mu.Lock()
+	entry, ok := compiledRulesCache.entries[t.ID]
+	compiledRulesCache.mu.Unlock()
+
+	if ok && entry.updated.Equal(updated) {
+		bt.AttachCompiled(entry.rules)
+		return entry.rules
+	}
+
+	compiled := bt.Compile(ruleParsers)
+
+	compiledRulesCache.mu.Lock()
+	defer compiledRulesCache.mu.Unlock()
+
+	if len(compiledRulesCache.entries) >= MaxCompiledTemplates {
+		compiledRulesCache.entries = map[uuid.UUID]compiledRulesEntry{}
+	}
+	compiledRulesCache.entries[t.ID] = compiledRulesEntry{rules: compiled, updated: updated}
+
+	return compiled
+}
+
+// SubscribeCompiledRulesInvalidation subscribes to the template.TemplateUpdatedEvent and clears the cache of CompiledRulesFor.
+func SubscribeCompiledRulesInvalidation(em event.Manager) {
+	em.Subscribe(template.TemplateUpdatedEventID, func(event event.Event, args *event.PublishArgs) error {
+		compiledRulesCache.mu.Lock()
+		defer compiledRulesCache.mu.Unlock()
+
+		compiledRulesCache.entries = map[uuid.UUID]compiledRulesEntry{}
+
+		return nil
+	}, event.DefaultPriority)
+}
+
+// CompiledValue returns the compiled value of the rule for the user's active locale read from the context (see LocalizedValue).
+// It returns false if the rule was not compiled, rule parsers must then parse the rule's plain value.
+func CompiledValue(ctx context.Context, rule BasicRule) (any, bool) {
+	if rule.compiled == nil {
+		return nil, false
+	}
+
+	if value, ok := rule.compiled.values[""]; ok {
+		return value, true
+	}
+
+	if value, ok := rule.compiled.values[ctxLocale(ctx)]; ok {
+		return value, true
+	}
+
+	value, ok := rule.compiled.values[rule.compiled.fallback]
+	return value, ok
+}
+
+// Compile implements the RuleCompiler interface for the EqualsRuleParser by lowercasing the rule's value.
+func (p EqualsRuleParser) Compile(rule BasicRule) (any, error) {
+	rv, ok := rule.Value.(string)
+	if !ok {
+		return nil, RuleInvalidValueError{Rule: &rule}
+	}
+
+	return compiledEquals{lower: strings.ToLower(rv)}, nil
+}
+
+// Compile implements the RuleCompiler interface for the EqualsAnyRuleParser by building the set of lowercase values.
+func (p EqualsAnyRuleParser) Compile(rule BasicRule) (any, error) {
+	rv, err := toStringSlice(rule.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]struct{}, len(rv))
+	for _, v := range rv {
+		set[strings.ToLower(v)] = struct{}{}
+	}
+
+	return compiledEqualsAny{values: rv, set: set}, nil
+}
+
+// Compile implements the RuleCompiler interface for the ForbidsRuleParser by converting the phrases into runes.
+func (p ForbidsRuleParser) Compile(rule BasicRule) (any, error) {
+	phrases, err := toStringSlice(rule.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := make([][]rune, len(phrases))
+	for i, phrase := range phrases {
+		runes[i] = []rune(phrase)
+	}
+
+	return compiledForbids{phrases: phrases, runes: runes}, nil
+}
+
+// Compile implements the RuleCompiler interface for the ScriptRuleParser by compiling the expression.
+// Regular expressions of the expression are compiled on first use and cached by the program.
+func (p ScriptRuleParser) Compile(rule BasicRule) (any, error) {
+	source, ok := rule.Value.(string)
+	if !ok {
+		return nil, RuleInvalidValueError{Rule: &rule}
+	}
+
+	return expr.Compile(source)
+}
+
+// compileRule compiles the rule's value for each of its locales. It returns nil if the value of any locale can not be compiled.
+func compileRule(compiler RuleCompiler, rule BasicRule) *CompiledRule {
+	if !rule.IsLocalized() {
+		value, err := compiler.Compile(rule)
+		if err != nil {
+			return nil
+		}
+
+		return &CompiledRule{values: map[string]any{"": value}}
+	}
+
+	locales := rule.Locales()
+	if len(locales) == 0 {
+		return nil
+	}
+
+	compiled := &CompiledRule{values: make(map[string]any, len(locales)), fallback: locales[0]}
+	for i, localizedRule := range rule.LocaleVariants() {
+		value, err := compiler.Compile(localizedRule)
+		if err != nil {
+			return nil
+		}
+
+		compiled.values[locales[i]] = value
+	}
+
+	return compiled
+}
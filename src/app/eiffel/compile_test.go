@@ -0,0 +1,153 @@
+package eiffel
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBasicTemplate_Compile(t *testing.T) {
+	segments := []parser.ParsingSegment{
+		{Name: "pre", Value: "This"},
+		{Name: "stateVerbRule", Value: "IS"},
+		{Name: "mid", Value: "a"},
+		{Name: "fooRule", Value: "bar"},
+		{Name: "fooPostfixRule", Value: "example"},
+		{Name: "end", Value: "."},
+	}
+
+	expected, err := basicTemplate().Parse(context.Background(), ruleParsers(), "basicVariant", segments...)
+	require.NoError(t, err)
+
+	bt := basicTemplate()
+	compiled := bt.Compile(ruleParsers())
+	assert.Contains(t, compiled, "stateVerbRule")
+	assert.NotContains(t, compiled, "mid", "placeholders are not compiled")
+
+	actual, err := bt.Parse(context.Background(), ruleParsers(), "basicVariant", segments...)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestBasicTemplate_CompileLocalized(t *testing.T) {
+	bt := &BasicTemplate{
+		Rules: map[string]BasicRule{
+			"modal": {Name: "Modal", Type: "equalsAny", Value: map[string]any{
+				"de": []any{"muss", "soll"},
+				"en": []any{"shall", "should"},
+			}},
+			"forbidden": {Name: "Forbidden", Type: "forbids", Value: []any{"maybe"}},
+			"script":    {Name: "Script", Type: "script", Value: `value != "never"`},
+		},
+	}
+	bt.Compile(RuleParsers())
+
+	enCtx := context.WithValue(context.Background(), trans.TranslatorContextKey, trans.NewTranslator(trans.ForLocale(&trans.Locale{Path: "en"})))
+	frCtx := context.WithValue(context.Background(), trans.TranslatorContextKey, trans.NewTranslator(trans.ForLocale(&trans.Locale{Path: "fr"})))
+
+	logs, err := EqualsAnyRuleParser{}.Parse(enCtx, bt.Rules["modal"], parser.ParsingSegment{Value: "Shall"})
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+
+	logs, err = EqualsAnyRuleParser{}.Parse(frCtx, bt.Rules["modal"], parser.ParsingSegment{Value: "muss"})
+	require.NoError(t, err)
+	assert.Empty(t, logs, "locales without a value fall back to the first locale")
+
+	bt.Localize(enCtx)
+	logs, err = EqualsAnyRuleParser{}.Parse(enCtx, bt.Rules["modal"], parser.ParsingSegment{Value: "muss"})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, []string{"expected", `"shall", "should"`, "actual", "muss"}, logs[0].TranslationArgs)
+
+	logs, err = ForbidsRuleParser{}.Parse(enCtx, bt.Rules["forbidden"], parser.ParsingSegment{Value: "It maybe works"})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, 3, logs[0].Ranges[0].Start)
+
+	logs, err = ScriptRuleParser{}.Parse(enCtx, bt.Rules["script"], parser.ParsingSegment{Value: "never"})
+	require.NoError(t, err)
+	assert.Len(t, logs, 1)
+}
+
+func TestCompiledRulesFor(t *testing.T) {
+	em := event.NewManager(trace.NewTestLogger(t))
+	SubscribeCompiledRulesInvalidation(em)
+
+	tmpl := &template.Template{ID: uuid.New(), CreatedAt: time.Now()}
+	first := CompiledRulesFor(tmpl, basicTemplate(), ruleParsers())
+
+	bt := basicTemplate()
+	cached := CompiledRulesFor(tmpl, bt, ruleParsers())
+	assert.Same(t, first["stateVerbRule"], cached["stateVerbRule"])
+	assert.Same(t, first["stateVerbRule"], bt.Rules["stateVerbRule"].compiled)
+
+	updated := time.Now().Add(time.Second)
+	tmpl.UpdatedAt = &updated
+	recompiled := CompiledRulesFor(tmpl, basicTemplate(), ruleParsers())
+	assert.NotSame(t, first["stateVerbRule"], recompiled["stateVerbRule"])
+
+	done := make(chan []error)
+	em.Publish(&template.TemplateUpdatedEvent{Template: uuid.New()}, done)
+	<-done
+
+	invalidated := CompiledRulesFor(tmpl, basicTemplate(), ruleParsers())
+	assert.NotSame(t, recompiled["stateVerbRule"], invalidated["stateVerbRule"])
+}
+
+func BenchmarkBasicTemplate_Parse(b *testing.B) {
+	for _, compiled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compiled=%t", compiled), func(b *testing.B) {
+			bt, segments := largeTemplate(50, 1000)
+			if compiled {
+				bt.Compile(RuleParsers())
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := bt.Parse(context.Background(), RuleParsers(), "default", segments...)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// largeTemplate returns a template with a variant of the number of equalsAny rules each having the number of values
+// and segments matching the last value of each rule.
+func largeTemplate(rules int, values int) (*BasicTemplate, []parser.ParsingSegment) {
+	bt := &BasicTemplate{
+		ID:       "large",
+		Name:     "Large",
+		Version:  "1.0.0",
+		Rules:    make(map[string]BasicRule, rules),
+		Variants: map[string]BasicVariant{"default": {Name: "Default"}},
+	}
+
+	var segments []parser.ParsingSegment
+	variant := bt.Variants["default"]
+	for i := 0; i < rules; i++ {
+		name := fmt.Sprintf("rule%d", i)
+		ruleValues := make([]any, values)
+		for j := range ruleValues {
+			ruleValues[j] = fmt.Sprintf("Value %d of Rule %d", j, i)
+		}
+
+		bt.Rules[name] = BasicRule{Name: name, Type: "equalsAny", Value: ruleValues}
+		variant.Rules = append(variant.Rules, name)
+		segments = append(segments, parser.ParsingSegment{Name: name, Value: fmt.Sprintf("value %d of rule %d", values-1, i)})
+	}
+	bt.Variants["default"] = variant
+
+	return bt, segments
+}
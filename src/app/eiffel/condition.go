@@ -0,0 +1,139 @@
+package eiffel
+
+import (
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"slices"
+	"strings"
+)
+
+// BasicCondition activates a rule of a variant depending on the value of a segment parsed before the rule,
+// e.g. the rule "condition" only applies if the segment "priority" equals "shall". An active rule is required
+// even if it is optional, an inactive rule is skipped: its segment is neither parsed nor part of the requirement.
+//
+// Example:
+//
+//	"conditions": {"condition": {"segment": "priority", "equals": ["shall"]}}
+type BasicCondition struct {
+	// Segment is the technical name of the rule whose segment activates the rule. It must precede the rule in the variant.
+	Segment string `json:"segment" hvalidate:"required"`
+	// Equals are the values activating the rule, they are compared case-insensitively.
+	// If it is empty, any non-empty value activates the rule.
+	Equals []string `json:"equals"`
+}
+
+// ConditionError is returned if a condition of a variant is invalid. It is returned by BasicTemplate.Validate.
+type ConditionError struct {
+	Rule    string
+	Segment string
+	Variant string
+	// Msg is the error message, it is translated using the parameters "rule", "segment" and "variant".
+	Msg string
+}
+
+// Active returns true if the value of the condition's segment activates the rule.
+func (c BasicCondition) Active(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false
+	}
+	if len(c.Equals) == 0 {
+		return true
+	}
+
+	for _, equals := range c.Equals {
+		if strings.EqualFold(strings.TrimSpace(equals), value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateConditions validates that the conditions of each variant reference rules of the variant, that the segment
+// of a condition precedes the conditional rule and that neither of them is repeated (see BasicGroup).
+func (bt *BasicTemplate) validateConditions(v validation.V) []error {
+	var errs []error
+	for _, variant := range bt.Variants {
+		for ruleName, condition := range variant.Conditions {
+			if err, conditionErrs := v.ValidateStruct(condition); err == nil {
+				errs = append(errs, conditionErrs...)
+			}
+
+			conditionErr := ConditionError{Rule: ruleName, Segment: condition.Segment, Variant: variant.Name}
+			ruleIndex := slices.Index(variant.Rules, ruleName)
+			segmentIndex := slices.Index(variant.Rules, condition.Segment)
+
+			switch {
+			case ruleIndex < 0:
+				conditionErr.Msg = "eiffel.parser.error.condition-unknown-rule"
+			case condition.Segment != "" && segmentIndex < 0:
+				conditionErr.Msg = "eiffel.parser.error.condition-unknown-segment"
+			case condition.Segment != "" && segmentIndex >= ruleIndex:
+				conditionErr.Msg = "eiffel.parser.error.condition-order"
+			}
+			if _, grouped := variant.GroupOf(ruleName); grouped && conditionErr.Msg == "" {
+				conditionErr.Msg = "eiffel.parser.error.condition-grouped"
+			}
+			if _, grouped := variant.GroupOf(condition.Segment); grouped && conditionErr.Msg == "" {
+				conditionErr.Msg = "eiffel.parser.error.condition-grouped"
+			}
+
+			if conditionErr.Msg != "" {
+				errs = append(errs, conditionErr)
+			}
+		}
+	}
+
+	return errs
+}
+
+// conditionalRule applies the variant's condition of the rule to the rule. Active is false if the rule is inactive,
+// the rule must be skipped then. A notice is returned if the segment of an inactive rule is not empty.
+// The rule of an active condition is required.
+func conditionalRule(variant BasicVariant, ruleName string, rule BasicRule, segments map[string]parser.ParsingSegment) (BasicRule, bool, []parser.ParsingLog) {
+	condition, ok := variant.Conditions[ruleName]
+	if !ok {
+		return rule, true, nil
+	}
+
+	if condition.Active(segments[condition.Segment].Value) {
+		rule.Optional = false
+		return rule, true, nil
+	}
+
+	segment := segments[ruleName]
+	if segment.Value == "" {
+		return rule, false, nil
+	}
+
+	return rule, false, []parser.ParsingLog{{
+		Segment: &parser.ParsingSegment{Name: ruleName, Value: segment.Value},
+		Level:   parser.ParsingLogLevelNotice,
+		Message: "eiffel.parser.condition.inactive",
+		TranslationArgs: []string{
+			"name",
+			rule.Name,
+			"technicalName",
+			ruleName,
+			"segment",
+			condition.Segment,
+		},
+	}}
+}
+
+// Error on ConditionError returns the error code of the error.
+func (e ConditionError) Error() string {
+	return e.Msg
+}
+
+// UnwrapTransparent on ConditionError returns the error itself, implementing the validation.TransparentError interface.
+func (e ConditionError) UnwrapTransparent(err validation.Error) error {
+	return e
+}
+
+// Translate on ConditionError translates the error using the given translator.
+func (e ConditionError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "rule", e.Rule, "segment", e.Segment, "variant", e.Variant)
+}
@@ -0,0 +1,132 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBasicCondition_Active(t *testing.T) {
+	condition := BasicCondition{Segment: "priority", Equals: []string{"shall", " must "}}
+	assert.True(t, condition.Active("Shall"))
+	assert.True(t, condition.Active(" must"))
+	assert.False(t, condition.Active("should"))
+	assert.False(t, condition.Active(""))
+
+	condition = BasicCondition{Segment: "priority"}
+	assert.True(t, condition.Active("anything"))
+	assert.False(t, condition.Active(" "))
+}
+
+func TestBasicTemplate_ValidateConditions(t *testing.T) {
+	v := validation.New()
+	bt := conditionTestTemplate()
+	require.Empty(t, bt.Validate(v, RuleParsers()))
+
+	variant := bt.Variants["default"]
+	variant.Conditions = map[string]BasicCondition{
+		"unknown":   {Segment: "priority"},
+		"priority":  {Segment: "condition"},
+		"system":    {Segment: "unknown"},
+		"process":   {Segment: "condition"},
+		"condition": {},
+	}
+	bt.Variants["default"] = variant
+
+	errs := bt.Validate(v, RuleParsers())
+	assert.Contains(t, errs, ConditionError{Rule: "unknown", Segment: "priority", Variant: "Default", Msg: "eiffel.parser.error.condition-unknown-rule"})
+	assert.Contains(t, errs, ConditionError{Rule: "priority", Segment: "condition", Variant: "Default", Msg: "eiffel.parser.error.condition-order"})
+	assert.Contains(t, errs, ConditionError{Rule: "system", Segment: "unknown", Variant: "Default", Msg: "eiffel.parser.error.condition-unknown-segment"})
+	assert.Contains(t, errs, ConditionError{Rule: "process", Segment: "condition", Variant: "Default", Msg: "eiffel.parser.error.condition-order"})
+	assert.Contains(t, errs, template.ErrInvalidTemplate)
+
+	bt = conditionTestTemplate()
+	variant = bt.Variants["default"]
+	variant.Groups = map[string]BasicGroup{"conditions": {Name: "Conditions", Rules: []string{"condition"}}}
+	bt.Variants["default"] = variant
+	assert.Contains(t, bt.Validate(v, RuleParsers()), ConditionError{Rule: "condition", Segment: "priority", Variant: "Default", Msg: "eiffel.parser.error.condition-grouped"})
+}
+
+func TestBasicTemplate_ParseConditions(t *testing.T) {
+	ctx := context.Background()
+	bt := conditionTestTemplate()
+
+	result, err := bt.Parse(
+		ctx,
+		RuleParsers(),
+		"default",
+		parser.ParsingSegment{Name: "system", Value: "The system"},
+		parser.ParsingSegment{Name: "priority", Value: "shall"},
+		parser.ParsingSegment{Name: "process", Value: "log every access"},
+	)
+	require.NoError(t, err)
+	assert.False(t, result.Ok())
+	require.Len(t, result.ViolationsForRule("condition"), 1)
+	assert.Equal(t, "eiffel.parser.error.missing-segment", result.ViolationsForRule("condition")[0].Message)
+	assert.False(t, result.ViolationsForRule("condition")[0].Downgrade)
+
+	result, err = bt.Parse(
+		ctx,
+		RuleParsers(),
+		"default",
+		parser.ParsingSegment{Name: "system", Value: "The system"},
+		parser.ParsingSegment{Name: "priority", Value: "shall"},
+		parser.ParsingSegment{Name: "process", Value: "log every access"},
+		parser.ParsingSegment{Name: "condition", Value: "if logging is enabled"},
+	)
+	require.NoError(t, err)
+	assert.True(t, result.Flawless())
+	assert.Equal(t, "The system shall log every access if logging is enabled", result.Requirement)
+
+	result, err = bt.Parse(
+		ctx,
+		RuleParsers(),
+		"default",
+		parser.ParsingSegment{Name: "system", Value: "The system"},
+		parser.ParsingSegment{Name: "priority", Value: "should"},
+		parser.ParsingSegment{Name: "process", Value: "log every access"},
+		parser.ParsingSegment{Name: "condition", Value: "if logging is enabled"},
+	)
+	require.NoError(t, err)
+	assert.True(t, result.Ok())
+	assert.Equal(t, "The system should log every access", result.Requirement)
+	require.Len(t, result.Notices, 1)
+	assert.Equal(t, "eiffel.parser.condition.inactive", result.Notices[0].Message)
+
+	result, err = bt.Parse(
+		ctx,
+		RuleParsers(),
+		"default",
+		parser.ParsingSegment{Name: "system", Value: "The system"},
+		parser.ParsingSegment{Name: "priority", Value: "should"},
+		parser.ParsingSegment{Name: "process", Value: "log every access"},
+	)
+	require.NoError(t, err)
+	assert.True(t, result.Flawless())
+	assert.Empty(t, result.Notices)
+}
+
+func conditionTestTemplate() *BasicTemplate {
+	return &BasicTemplate{
+		ID:      "conditions",
+		Name:    "Conditions",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"system":    {Name: "System", Type: "placeholder"},
+			"priority":  {Name: "Priority", Type: "equalsAny", Value: []any{"shall", "should"}},
+			"process":   {Name: "Process", Type: "placeholder"},
+			"condition": {Name: "Condition", Type: "placeholder", Optional: true},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {
+				Name:       "Default",
+				Rules:      []string{"system", "priority", "process", "condition"},
+				Conditions: map[string]BasicCondition{"condition": {Segment: "priority", Equals: []string{"shall"}}},
+			},
+		},
+	}
+}
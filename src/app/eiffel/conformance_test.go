@@ -0,0 +1,135 @@
+package eiffel_test
+
+import (
+	"github.com/org-harmony/harmony/src/app/eiffel"
+	"github.com/org-harmony/harmony/src/app/eiffel/parsertest"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// combinatorRules are the rules referenced by the combinators in their conformance suites.
+var combinatorRules = map[string]eiffel.BasicRule{
+	"shall":  {Name: "Shall", Type: "equals", Value: "shall"},
+	"should": {Name: "Should", Type: "equals", Value: "should"},
+	"weak":   {Name: "Weak", Type: "forbids", Value: []any{"maybe"}},
+}
+
+func TestBuiltinRuleParsersConformance(t *testing.T) {
+	modal := eiffel.BasicRule{Value: []any{"shall", "should"}}
+	combined := eiffel.BasicRule{Value: []any{"shall", "should"}}
+
+	suites := []parsertest.Suite{
+		{
+			Type:         "equals",
+			Parser:       eiffel.EqualsRuleParser{},
+			ValidRules:   []eiffel.BasicRule{{Value: "shall"}, {Value: ""}},
+			InvalidRules: []eiffel.BasicRule{{Value: 42}, {Value: []any{"shall"}}},
+			Cases: []parsertest.Case{
+				{Name: "equal", Rule: eiffel.BasicRule{Value: "shall"}, Segment: "SHALL", Valid: true},
+				{Name: "not equal", Rule: eiffel.BasicRule{Value: "shall"}, Segment: "should", Messages: []string{"eiffel.parser.equals.error"}},
+			},
+		},
+		{
+			Type:         "equalsAny",
+			Parser:       eiffel.EqualsAnyRuleParser{},
+			ValidRules:   []eiffel.BasicRule{modal, {Value: []any{"shall"}, Extra: map[string]any{"allowOthers": true}}},
+			InvalidRules: []eiffel.BasicRule{{Value: "shall"}, {Value: []any{42}}},
+			Cases: []parsertest.Case{
+				{Name: "any", Rule: modal, Segment: "Should", Valid: true},
+				{Name: "none", Rule: modal, Segment: "could"},
+				{Name: "others", Rule: eiffel.BasicRule{Value: []any{"shall"}, Extra: map[string]any{"allowOthers": true}}, Segment: "could", Valid: true},
+			},
+		},
+		{
+			Type:         "placeholder",
+			Parser:       eiffel.PlaceholderRuleParser{},
+			ValidRules:   []eiffel.BasicRule{{}, {Size: "full"}},
+			InvalidRules: []eiffel.BasicRule{{Extra: map[string]any{"languageCheck": "yes"}}},
+			Cases: []parsertest.Case{
+				{Name: "text", Segment: "log every access", Valid: true},
+			},
+		},
+		{
+			Type:         "forbids",
+			Parser:       eiffel.ForbidsRuleParser{},
+			ValidRules:   []eiffel.BasicRule{{Value: []any{"maybe", "as fast as possible"}}},
+			InvalidRules: []eiffel.BasicRule{{Value: 42}},
+			Cases: []parsertest.Case{
+				{Name: "clean", Rule: eiffel.BasicRule{Value: []any{"maybe"}}, Segment: "log every access", Valid: true},
+				{Name: "weak word", Rule: eiffel.BasicRule{Value: []any{"maybe"}}, Segment: "maybe log", Valid: true, Messages: []string{"eiffel.parser.forbids.warning"}},
+			},
+		},
+		{
+			Type:       "allOf",
+			Parser:     eiffel.AllOfRuleParser{},
+			Rules:      combinatorRules,
+			ValidRules: []eiffel.BasicRule{{Value: []any{"shall", "weak"}}},
+			Cases: []parsertest.Case{
+				{Name: "all", Rule: eiffel.BasicRule{Value: []any{"shall", "weak"}}, Segment: "shall", Valid: true},
+				{Name: "one fails", Rule: eiffel.BasicRule{Value: []any{"shall", "weak"}}, Segment: "maybe"},
+			},
+		},
+		{
+			Type:       "anyOf",
+			Parser:     eiffel.AnyOfRuleParser{},
+			Rules:      combinatorRules,
+			ValidRules: []eiffel.BasicRule{combined},
+			Cases: []parsertest.Case{
+				{Name: "any", Rule: combined, Segment: "should", Valid: true},
+				{Name: "none", Rule: combined, Segment: "could", Messages: []string{"eiffel.parser.any-of.error"}},
+			},
+		},
+		{
+			Type:       "not",
+			Parser:     eiffel.NotRuleParser{},
+			Rules:      combinatorRules,
+			ValidRules: []eiffel.BasicRule{combined},
+			Cases: []parsertest.Case{
+				{Name: "none", Rule: combined, Segment: "could", Valid: true},
+				{Name: "any", Rule: combined, Segment: "shall", Messages: []string{"eiffel.parser.not.error"}},
+			},
+		},
+		{
+			Type:         "script",
+			Parser:       eiffel.ScriptRuleParser{},
+			ValidRules:   []eiffel.BasicRule{{Value: `len(value) > 3`}, {Value: `true`, Extra: map[string]any{"level": "warning"}}},
+			InvalidRules: []eiffel.BasicRule{{Value: 42}, {Value: `value ==`}},
+			Cases: []parsertest.Case{
+				{Name: "true", Rule: eiffel.BasicRule{Value: `len(value) > 3`}, Segment: "log every access", Valid: true},
+				{Name: "false", Rule: eiffel.BasicRule{Value: `len(value) > 3`}, Segment: "log"},
+			},
+		},
+	}
+
+	for _, suite := range suites {
+		t.Run(suite.Type, func(t *testing.T) {
+			parsertest.Run(t, suite)
+		})
+	}
+}
+
+func TestPluginRuleParserConformance(t *testing.T) {
+	id := eiffel.BasicRule{Value: "^REQ-[0-9]+$"}
+	suite := parsertest.Suite{
+		Type:         "regex",
+		ValidRules:   []eiffel.BasicRule{id},
+		InvalidRules: []eiffel.BasicRule{{Value: "("}},
+		Cases: []parsertest.Case{
+			{Name: "match", Rule: id, Segment: "REQ-1", Valid: true},
+			{Name: "mismatch", Rule: id, Segment: "ABC", Messages: []string{"eiffel.parser.plugin.message"}},
+		},
+	}
+
+	// the regex plugin is implemented by TestHelperPlugin
+	var err error
+	suite.Parser, err = eiffel.NewPluginRuleParser(eiffel.PluginCfg{
+		Type:    "regex",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperPlugin$"},
+		Env:     []string{"HARMONY_TEST_PLUGIN=regex"},
+	})
+	require.NoError(t, err)
+
+	parsertest.Run(t, suite)
+}
@@ -0,0 +1,156 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/expr"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"slices"
+)
+
+// BasicConstraint is a template-level constraint spanning multiple segments of a requirement, e.g. that a condition
+// is required if the priority is "must". Constraints are evaluated after all segments were parsed (see BasicTemplate.Parse).
+//
+// The expression (see package expr) must evaluate to true for the requirement to be valid. It can access:
+//   - segments: the values of all segments by their rule's technical name, missing segments are empty strings
+//   - valid: whether each segment was parsed without errors by its rule's technical name
+//   - variant: the technical name (key) of the parsed variant
+//   - requirement: the requirement built from the segments
+//   - errors, warnings, notices: the number of logs of the segments per level
+//
+// Example:
+//
+//	{"name": "Condition for must", "expression": "segments.priority != 'must' || segments.condition != ''", "message": {"en": "Requirements that must be met require a condition."}}
+type BasicConstraint struct {
+	// Name is the display name of the constraint.
+	Name string `json:"name" hvalidate:"required"`
+	// Expression is the expression that must evaluate to true.
+	Expression string `json:"expression" hvalidate:"required"`
+	// Message is reported if the expression evaluates to false. It is optional and may be localized like rule values.
+	Message any `json:"message"`
+	// Level is the level of the report: "error" (default), "warning" or "notice".
+	Level string `json:"level"`
+	// Variants restricts the constraint to the variants with these technical names. It applies to all variants if it is empty.
+	Variants []string `json:"variants"`
+}
+
+// ConstraintError is returned if a constraint of a template is invalid. It is returned by BasicTemplate.Validate.
+type ConstraintError struct {
+	Constraint string
+	Template   string
+	// Msg is the error message, it is translated using the parameters "constraint" and "template".
+	Msg string
+}
+
+// parseConstraints evaluates the template's constraints applying to the variant against the parsed segments and the result.
+// It returns the logs of all constraints that are not satisfied. Constraints which can not be evaluated
+// (e.g. because a segment is not a number) are reported with the constraint's level as well.
+func (bt *BasicTemplate) parseConstraints(ctx context.Context, variantKey string, segments map[string]parser.ParsingSegment, result parser.ParsingResult) []parser.ParsingLog {
+	if len(bt.Constraints) == 0 {
+		return nil
+	}
+
+	values := make(map[string]any, len(bt.Rules))
+	valid := make(map[string]any, len(bt.Rules))
+	for name := range bt.Rules {
+		values[name] = segments[name].Value
+		valid[name] = len(result.ViolationsForRule(name)) == 0
+	}
+
+	vars := map[string]any{
+		"segments":    values,
+		"valid":       valid,
+		"variant":     variantKey,
+		"requirement": result.Requirement,
+		"errors":      len(result.Errors),
+		"warnings":    len(result.Warnings),
+		"notices":     len(result.Notices),
+	}
+
+	var logs []parser.ParsingLog
+	for _, constraint := range bt.Constraints {
+		if len(constraint.Variants) > 0 && !slices.Contains(constraint.Variants, variantKey) {
+			continue
+		}
+
+		satisfied := false
+		program, err := expr.Compile(constraint.Expression)
+		if err == nil {
+			satisfied, err = program.EvalBool(vars)
+		}
+		if satisfied {
+			continue
+		}
+
+		level, ok := parsingLogLevel(constraint.Level)
+		if !ok {
+			level = parser.ParsingLogLevelError
+		}
+
+		log := parser.ParsingLog{
+			Level:           level,
+			Message:         "eiffel.parser.constraint.error",
+			TranslationArgs: []string{"name", constraint.Name},
+			Constraint:      constraint.Name,
+		}
+
+		message, _ := LocalizedValue(ctx, BasicRule{Value: constraint.Message}).(string)
+		if err != nil {
+			log.Message = "eiffel.parser.constraint.failed"
+		} else if message != "" {
+			// the author's message is passed as an argument because the message itself would be parsed as a translation template
+			log.Message = "eiffel.parser.constraint.message"
+			log.TranslationArgs = []string{"message", message}
+		}
+
+		logs = append(logs, log)
+	}
+
+	return logs
+}
+
+// validateConstraints validates the expressions, messages, levels and variants of the template's constraints.
+func (bt *BasicTemplate) validateConstraints() []error {
+	var errs []error
+	for _, constraint := range bt.Constraints {
+		if _, err := expr.Compile(constraint.Expression); err != nil {
+			errs = append(errs, ConstraintError{Constraint: constraint.Name, Template: bt.Name, Msg: "eiffel.parser.constraint.invalid"})
+		}
+
+		for _, localized := range (BasicRule{Value: constraint.Message}).LocaleVariants() {
+			if _, ok := localized.Value.(string); !ok && localized.Value != nil {
+				errs = append(errs, ConstraintError{Constraint: constraint.Name, Template: bt.Name, Msg: "eiffel.parser.constraint.invalid-message"})
+				break
+			}
+		}
+
+		if _, ok := parsingLogLevel(constraint.Level); !ok && constraint.Level != "" {
+			errs = append(errs, ConstraintError{Constraint: constraint.Name, Template: bt.Name, Msg: "eiffel.parser.constraint.invalid-level"})
+		}
+
+		for _, variant := range constraint.Variants {
+			if _, ok := bt.Variants[variant]; !ok {
+				errs = append(errs, ConstraintError{Constraint: constraint.Name, Template: bt.Name, Msg: "eiffel.parser.constraint.invalid-variant"})
+				break
+			}
+		}
+	}
+
+	return errs
+}
+
+// Error on ConstraintError returns the error code of the error.
+func (e ConstraintError) Error() string {
+	return e.Msg
+}
+
+// UnwrapTransparent on ConstraintError returns the error itself, implementing the validation.TransparentError interface.
+func (e ConstraintError) UnwrapTransparent(err validation.Error) error {
+	return e
+}
+
+// Translate on ConstraintError translates the error using the given translator.
+func (e ConstraintError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "constraint", e.Constraint, "template", e.Template)
+}
@@ -0,0 +1,131 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBasicTemplate_Constraints(t *testing.T) {
+	bt := constraintTemplate()
+	rp := RuleParsers()
+	require.Empty(t, bt.Validate(validation.New(), rp))
+
+	result, err := bt.Parse(context.Background(), rp, "default",
+		parser.ParsingSegment{Name: "priority", Value: "must"},
+		parser.ParsingSegment{Name: "condition", Value: "if logged in"},
+		parser.ParsingSegment{Name: "x", Value: "40"},
+		parser.ParsingSegment{Name: "y", Value: "60"},
+	)
+	require.NoError(t, err)
+	assert.True(t, result.Flawless())
+	assert.Empty(t, result.ConstraintLogs())
+
+	result, err = bt.Parse(context.Background(), rp, "default",
+		parser.ParsingSegment{Name: "priority", Value: "must"},
+		parser.ParsingSegment{Name: "x", Value: "50"},
+		parser.ParsingSegment{Name: "y", Value: "60"},
+	)
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "eiffel.parser.constraint.message", result.Errors[0].Message)
+	assert.Equal(t, []string{"message", "A condition is required."}, result.Errors[0].TranslationArgs)
+	assert.Equal(t, "Condition", result.Errors[0].Constraint)
+	assert.Nil(t, result.Errors[0].Segment)
+	require.Len(t, result.Warnings, 1)
+	assert.Equal(t, "eiffel.parser.constraint.error", result.Warnings[0].Message)
+	assert.Len(t, result.ConstraintLogs(), 2)
+	assert.Empty(t, result.ViolationsForRule("condition"))
+
+	result, err = bt.Parse(context.Background(), rp, "default",
+		parser.ParsingSegment{Name: "priority", Value: "may"},
+		parser.ParsingSegment{Name: "x", Value: "many"},
+		parser.ParsingSegment{Name: "y", Value: "1"},
+	)
+	require.NoError(t, err)
+	require.Len(t, result.Warnings, 1)
+	assert.Equal(t, "eiffel.parser.constraint.failed", result.Warnings[0].Message)
+
+	result, err = bt.Parse(context.Background(), rp, "short",
+		parser.ParsingSegment{Name: "priority", Value: "may"},
+		parser.ParsingSegment{Name: "x", Value: "many"},
+	)
+	require.NoError(t, err)
+	assert.True(t, result.Flawless(), "constraints restricted to other variants are not evaluated")
+}
+
+func TestBasicTemplate_ValidateConstraints(t *testing.T) {
+	bt := constraintTemplate()
+	bt.Constraints = append(bt.Constraints,
+		BasicConstraint{Name: "Syntax", Expression: "segments.x =="},
+		BasicConstraint{Name: "Message", Expression: "true", Message: 42},
+		BasicConstraint{Name: "Level", Expression: "true", Level: "fatal"},
+		BasicConstraint{Name: "Variant", Expression: "true", Variants: []string{"unknown"}},
+		BasicConstraint{Name: "Missing"},
+	)
+
+	errs := bt.Validate(validation.New(), RuleParsers())
+	assert.ErrorIs(t, errs[len(errs)-1], template.ErrInvalidTemplate)
+
+	var messages []string
+	for _, err := range errs {
+		if constraintErr, ok := err.(ConstraintError); ok {
+			messages = append(messages, constraintErr.Msg)
+		}
+	}
+	assert.Equal(t, []string{
+		"eiffel.parser.constraint.invalid",
+		"eiffel.parser.constraint.invalid-message",
+		"eiffel.parser.constraint.invalid-level",
+		"eiffel.parser.constraint.invalid-variant",
+		"eiffel.parser.constraint.invalid",
+	}, messages)
+	assert.Len(t, errs, 7, "the constraint missing its expression is a validation error")
+}
+
+func TestMergeBasicTemplates_Constraints(t *testing.T) {
+	parent := &BasicTemplate{Constraints: []BasicConstraint{{Name: "a", Expression: "true"}, {Name: "b", Expression: "true"}}}
+	child := &BasicTemplate{Constraints: []BasicConstraint{{Name: "b", Expression: "false"}, {Name: "c", Expression: "true"}}}
+
+	merged := mergeBasicTemplates(parent, child)
+	assert.Equal(t, []BasicConstraint{
+		{Name: "a", Expression: "true"},
+		{Name: "b", Expression: "false"},
+		{Name: "c", Expression: "true"},
+	}, merged.Constraints)
+}
+
+func constraintTemplate() *BasicTemplate {
+	return &BasicTemplate{
+		ID:      "constraints",
+		Name:    "Constraints",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"priority":  {Name: "Priority", Type: "equalsAny", Value: []any{"must", "should", "may"}},
+			"condition": {Name: "Condition", Type: "placeholder", Optional: true, IgnoreMissingWhenOptional: true},
+			"x":         {Name: "X", Type: "placeholder", Optional: true, IgnoreMissingWhenOptional: true},
+			"y":         {Name: "Y", Type: "placeholder", Optional: true, IgnoreMissingWhenOptional: true},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {Name: "Default", Rules: []string{"priority", "condition", "x", "y"}},
+			"short":   {Name: "Short", Rules: []string{"priority"}},
+		},
+		Constraints: []BasicConstraint{
+			{
+				Name:       "Condition",
+				Expression: `segments.priority != "must" || segments.condition != ""`,
+				Message:    map[string]any{"en": "A condition is required."},
+			},
+			{
+				Name:       "Sum",
+				Expression: `number(segments.x) + number(segments.y) <= 100`,
+				Level:      "warning",
+				Variants:   []string{"default"},
+			},
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package eiffel
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/user"
+	"strings"
+)
+
+// Draft is the segment map currently entered in the elicitation form of a template's variant keyed by the rules' names.
+// It is stored in the session's settings whenever the form changes (see DraftFromSession and Draft.AddToSession),
+// the elicitation page restores the form from it on reloads, back and forward navigation and bookmarks.
+type Draft map[string]string
+
+// DraftFromSession reads the draft of the template's variant from the session. It returns false if the session
+// does not contain a (valid) draft or the draft is empty, see Draft.Empty.
+func DraftFromSession(session *user.Session, templateID string, variant string) (Draft, bool) {
+	value, err := session.Setting(draftSettingKey(templateID, variant))
+	if err != nil {
+		return nil, false
+	}
+
+	var draft Draft
+	if err := json.Unmarshal([]byte(value), &draft); err != nil || draft.Empty() {
+		return nil, false
+	}
+
+	return draft, true
+}
+
+// AddToSession stores the draft of the template's variant in the session. The session is not written to the store.
+func (d Draft) AddToSession(session *user.Session, templateID string, variant string) error {
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	session.AddSetting(draftSettingKey(templateID, variant), string(value))
+
+	return nil
+}
+
+// Empty returns true if no segment of the draft contains more than whitespace, e.g. after the form was cleared.
+func (d Draft) Empty() bool {
+	for _, value := range d {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func draftSettingKey(templateID string, variant string) string {
+	return fmt.Sprintf("eiffel.Draft.%s.%s", templateID, variant)
+}
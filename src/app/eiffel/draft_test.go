@@ -0,0 +1,30 @@
+package eiffel
+
+import (
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDraft_Session(t *testing.T) {
+	session := &user.Session{}
+	_, ok := DraftFromSession(session, "template", "default")
+	assert.False(t, ok)
+
+	require.NoError(t, Draft{"system": "The system", "action": ""}.AddToSession(session, "template", "default"))
+
+	draft, ok := DraftFromSession(session, "template", "default")
+	require.True(t, ok)
+	assert.Equal(t, Draft{"system": "The system", "action": ""}, draft)
+	_, ok = DraftFromSession(session, "template", "other")
+	assert.False(t, ok, "drafts are kept per variant")
+
+	require.NoError(t, Draft{"system": " ", "action": ""}.AddToSession(session, "template", "default"))
+	_, ok = DraftFromSession(session, "template", "default")
+	assert.False(t, ok, "cleared forms are not restored")
+
+	session.AddSetting(draftSettingKey("template", "default"), "invalid")
+	_, ok = DraftFromSession(session, "template", "default")
+	assert.False(t, ok)
+}
@@ -1,9 +1,41 @@
 // Package eiffel contains necessary functionality for the Elicitation Interface for eFFective Language (EIFFEL).
 package eiffel
 
+import (
+	"github.com/org-harmony/harmony/src/core/config"
+	"github.com/org-harmony/harmony/src/core/validation"
+)
+
 // Cfg is EIFFEL's configuration struct. This can be used to unmarshal a TOML configuration file into.
 type Cfg struct {
 	NeglectOptional bool `toml:"neglect_optional" env:"EIFFEL_NEGLECT_OPTIONAL"`
+	// RuleTimeout is the maximum duration in milliseconds of parsing a single rule. Defaults to DefaultRuleTimeout.
+	RuleTimeout int `toml:"rule_timeout" env:"EIFFEL_RULE_TIMEOUT"`
+	// LanguageTool configures the optional spelling and grammar check of placeholder segments.
+	LanguageTool LanguageToolCfg `toml:"language_tool"`
+	// PDF configures the optional rendering of requirement reports as PDF.
+	PDF PDFCfg `toml:"pdf"`
+	// Suggestions configures the suggestions for rules rendered as single-select, see BasicTemplate.Suggest.
+	Suggestions SuggestionsCfg `toml:"suggestions"`
+	// Lint configures the checks reported when linting templates, see BasicTemplate.Lint.
+	Lint LintCfg `toml:"lint"`
+	// Limits configures the size and complexity limits of templates, see LimitsCfg.
+	Limits LimitsCfg `toml:"limits"`
+	// Plugins configures external rule parsers, see PluginRuleParser.
+	Plugins []PluginCfg `toml:"plugin"`
+}
+
+// LoadCfg loads the EIFFEL config and registers its plugins (see RegisterPlugins). The web controller and the command line
+// tools load the config through LoadCfg, so all of them accept templates using plugin rule types.
+// The options are applied after the defaults, e.g. config.FromDir changes the config directory.
+func LoadCfg(v validation.V, opts ...config.Option) (Cfg, error) {
+	cfg := Cfg{}
+	opts = append([]config.Option{config.From("eiffel"), config.Validate(v)}, opts...)
+	if err := config.C(&cfg, opts...); err != nil {
+		return cfg, err
+	}
+
+	return cfg, RegisterPlugins(cfg.Plugins)
 }
 
 // TODO add tests for service, web and output
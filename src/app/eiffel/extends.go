@@ -0,0 +1,187 @@
+package eiffel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"slices"
+	"strings"
+)
+
+// ExtendsError is returned if a template extends a template that is not part of the same template set
+// or if the templates extend each other cyclically.
+type ExtendsError struct {
+	Template string
+	Extends  string
+	// Cyclic is true if the templates extend each other cyclically. Otherwise, the extended template was not found.
+	Cyclic bool
+}
+
+// BasicTemplatesOfSet loads all EIFFEL basic templates of the template set keyed by their ID (the template config's "id").
+// Templates with an invalid config are skipped as they can not be extended anyway.
+// An empty map is returned if the template set does not contain any templates.
+func BasicTemplatesOfSet(ctx context.Context, templateRepository template.Repository, templateSetID uuid.UUID) (map[string]*BasicTemplate, error) {
+	templates, err := templateRepository.FindByTemplateSetID(ctx, templateSetID)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, err
+	}
+
+	set := make(map[string]*BasicTemplate, len(templates))
+	for _, t := range templates {
+		if strings.ToLower(t.Type) != BasicTemplateType {
+			continue
+		}
+
+		bt := &BasicTemplate{}
+		if err := json.Unmarshal([]byte(t.Config), bt); err != nil {
+			continue
+		}
+
+		set[bt.ID] = bt
+	}
+
+	return set, nil
+}
+
+// ResolveExtends merges the template with the templates it extends. The extended templates are looked up by their ID
+// in the passed in set, usually loaded by BasicTemplatesOfSet. Templates may extend templates which again extend other templates.
+// Rules and variants of the extending template override the ones of the extended template with the same key,
+// constraints override the ones with the same name.
+// The template's metadata (ID, name, version...) is kept, only empty optional metadata is inherited.
+//
+// An ExtendsError is returned if an extended template is not found or if the templates extend each other cyclically.
+// The passed in templates are not modified. The merged result should be validated afterward.
+func ResolveExtends(bt *BasicTemplate, set map[string]*BasicTemplate) (*BasicTemplate, error) {
+	return resolveExtends(bt, set, map[string]bool{})
+}
+
+func resolveExtends(bt *BasicTemplate, set map[string]*BasicTemplate, visited map[string]bool) (*BasicTemplate, error) {
+	if bt.Extends == "" {
+		return bt, nil
+	}
+
+	visited[bt.ID] = true
+	if visited[bt.Extends] {
+		return nil, ExtendsError{Template: bt.Name, Extends: bt.Extends, Cyclic: true}
+	}
+
+	parent, ok := set[bt.Extends]
+	if !ok {
+		return nil, ExtendsError{Template: bt.Name, Extends: bt.Extends}
+	}
+
+	parent, err := resolveExtends(parent, set, visited)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeBasicTemplates(parent, bt), nil
+}
+
+// mergeBasicTemplates returns a new template with the rules and variants of the parent overridden by the child's.
+// Constraints are merged by their name, UI settings per setting and extra properties per key.
+func mergeBasicTemplates(parent *BasicTemplate, child *BasicTemplate) *BasicTemplate {
+	merged := *child
+
+	if len(merged.Authors) == 0 {
+		merged.Authors = parent.Authors
+	}
+	if merged.License == "" {
+		merged.License = parent.License
+	}
+	if merged.Description == "" {
+		merged.Description = parent.Description
+	}
+	if merged.Output == "" {
+		merged.Output = parent.Output
+	}
+
+	merged.Rules = make(map[string]BasicRule, len(parent.Rules)+len(child.Rules))
+	for name, rule := range parent.Rules {
+		merged.Rules[name] = rule
+	}
+	for name, rule := range child.Rules {
+		merged.Rules[name] = rule
+	}
+
+	merged.Variants = make(map[string]BasicVariant, len(parent.Variants)+len(child.Variants))
+	for name, variant := range parent.Variants {
+		merged.Variants[name] = variant
+	}
+	for name, variant := range child.Variants {
+		merged.Variants[name] = variant
+	}
+
+	merged.Constraints = make([]BasicConstraint, 0, len(parent.Constraints)+len(child.Constraints))
+	for _, constraint := range parent.Constraints {
+		if !slices.ContainsFunc(child.Constraints, func(c BasicConstraint) bool { return c.Name == constraint.Name }) {
+			merged.Constraints = append(merged.Constraints, constraint)
+		}
+	}
+	merged.Constraints = append(merged.Constraints, child.Constraints...)
+	merged.UI = parent.UI.Merge(child.UI)
+
+	merged.Extra = make(map[string]any, len(parent.Extra)+len(child.Extra))
+	for key, value := range parent.Extra {
+		merged.Extra[key] = value
+	}
+	for key, value := range child.Extra {
+		merged.Extra[key] = value
+	}
+
+	return &merged
+}
+
+// ResolvedTemplateIntoBasicTemplate parses a template's config into a BasicTemplate, resolves the templates it extends
+// from the same template set and validates the merged result. Use TemplateIntoBasicTemplate if extending is not relevant.
+func ResolvedTemplateIntoBasicTemplate(
+	ctx context.Context,
+	t *template.Template,
+	templateRepository template.Repository,
+	validator validation.V,
+	ruleParsers *RuleParserProvider,
+) (*BasicTemplate, error) {
+	bt := &BasicTemplate{}
+	err := json.Unmarshal([]byte(t.Config), bt)
+	if err != nil {
+		return nil, err
+	}
+
+	if bt.Extends != "" {
+		set, err := BasicTemplatesOfSet(ctx, templateRepository, t.TemplateSet)
+		if err != nil {
+			return nil, err
+		}
+
+		bt, err = ResolveExtends(bt, set)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	errs := bt.Validate(validator, ruleParsers)
+	if len(errs) > 0 {
+		return nil, template.ErrInvalidTemplate
+	}
+
+	return bt, nil
+}
+
+// Error on ExtendsError returns the error code of the error.
+func (e ExtendsError) Error() string {
+	if e.Cyclic {
+		return "eiffel.parser.error.cyclic-extends"
+	}
+
+	return "eiffel.parser.error.extends-not-found"
+}
+
+// Translate on ExtendsError translates the error using the given translator.
+func (e ExtendsError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "template", e.Template, "extends", e.Extends)
+}
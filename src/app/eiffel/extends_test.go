@@ -0,0 +1,70 @@
+package eiffel
+
+import (
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestResolveExtends(t *testing.T) {
+	base := basicTemplate()
+	child := &BasicTemplate{
+		ID:      "child-template",
+		Name:    "Child Template",
+		Version: "1.0.0",
+		Extends: base.ID,
+		Rules: map[string]BasicRule{
+			"fooRule": {Name: "Bar Rule", Type: "equals", Value: "bar"},
+		},
+		Variants: map[string]BasicVariant{
+			"childVariant": {Name: "Child Variant", Rules: []string{"stateVerbRule", "fooRule"}},
+		},
+	}
+	set := map[string]*BasicTemplate{base.ID: base, child.ID: child}
+
+	t.Run("rules and variants are merged and overridden", func(t *testing.T) {
+		merged, err := ResolveExtends(child, set)
+		require.NoError(t, err)
+
+		assert.Equal(t, "child-template", merged.ID)
+		assert.Equal(t, base.License, merged.License)
+		assert.Equal(t, "bar", merged.Rules["fooRule"].Value)
+		assert.Contains(t, merged.Rules, "stateVerbRule")
+		assert.Contains(t, merged.Variants, "basicVariant")
+		assert.Contains(t, merged.Variants, "childVariant")
+		assert.Equal(t, "foo", base.Rules["fooRule"].Value, "extended template must not be modified")
+
+		require.Len(t, merged.Validate(validation.New(), RuleParsers()), 0)
+	})
+
+	t.Run("templates can be extended transitively", func(t *testing.T) {
+		grandchild := &BasicTemplate{ID: "grandchild", Name: "Grandchild", Version: "1.0.0", Extends: child.ID}
+		merged, err := ResolveExtends(grandchild, map[string]*BasicTemplate{base.ID: base, child.ID: child, grandchild.ID: grandchild})
+		require.NoError(t, err)
+		assert.Len(t, merged.Variants, 2)
+		assert.Equal(t, "bar", merged.Rules["fooRule"].Value)
+	})
+
+	t.Run("missing extended template", func(t *testing.T) {
+		_, err := ResolveExtends(child, map[string]*BasicTemplate{child.ID: child})
+		assert.Equal(t, ExtendsError{Template: child.Name, Extends: base.ID}, err)
+	})
+
+	t.Run("cyclic extends", func(t *testing.T) {
+		cyclicBase := basicTemplate()
+		cyclicBase.Extends = child.ID
+
+		_, err := ResolveExtends(child, map[string]*BasicTemplate{cyclicBase.ID: cyclicBase, child.ID: child})
+		require.Error(t, err)
+		assert.Equal(t, "eiffel.parser.error.cyclic-extends", err.Error())
+	})
+}
+
+func TestMergeBasicTemplates_UI(t *testing.T) {
+	parent := &BasicTemplate{UI: template.UISettings{DefaultVariant: "short", TextareaMinRows: 3}}
+	child := &BasicTemplate{UI: template.UISettings{DefaultVariant: "long"}}
+
+	assert.Equal(t, template.UISettings{DefaultVariant: "long", TextareaMinRows: 3}, mergeBasicTemplates(parent, child).UI)
+}
@@ -0,0 +1,91 @@
+package eiffel
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"slices"
+)
+
+// TemplateWorkingSet are the templates a user works with: the pinned templates and the templates used recently (see template.FavoriteRepository).
+// It is displayed at the top of the template search and on the elicitation page before a template is selected.
+type TemplateWorkingSet struct {
+	Pinned []*template.Template
+	Recent []*template.Template
+}
+
+// TemplatePinData is passed to the template rendering the button pinning a template.
+type TemplatePinData struct {
+	TemplateID uuid.UUID
+	Pinned     bool
+}
+
+// WorkingSetFor reads the user's working set of EIFFEL basic templates.
+func WorkingSetFor(ctx context.Context, favorites template.FavoriteRepository, userID uuid.UUID) (*TemplateWorkingSet, error) {
+	pinned, err := favorites.FindPinned(ctx, userID, BasicTemplateType)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := favorites.FindRecent(ctx, userID, BasicTemplateType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TemplateWorkingSet{Pinned: pinned, Recent: recent}, nil
+}
+
+// Empty returns true if the user neither pinned nor recently used a template.
+func (w *TemplateWorkingSet) Empty() bool {
+	return w == nil || len(w.Pinned) == 0 && len(w.Recent) == 0
+}
+
+// IsPinned returns true if the template is one of the pinned templates.
+func (w *TemplateWorkingSet) IsPinned(templateID uuid.UUID) bool {
+	if w == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(w.Pinned, func(t *template.Template) bool { return t.ID == templateID })
+}
+
+// PinData returns the data rendering the button pinning the template, see TemplatePinData.
+func (w *TemplateWorkingSet) PinData(templateID uuid.UUID) TemplatePinData {
+	return TemplatePinData{TemplateID: templateID, Pinned: w.IsPinned(templateID)}
+}
+
+// toggleTemplatePin pins the template for the user or unpins it if it is already pinned and renders the toggled pin button.
+func toggleTemplatePin(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	favorites := util.UnwrapType[template.FavoriteRepository](appCtx.Repository(template.FavoriteRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		templateID, err := uuid.Parse(web.URLParam(io.Request(), "templateID"))
+		if err != nil {
+			return io.InlineError(ErrTemplateNotFound, err)
+		}
+
+		pinned, err := favorites.Toggle(io.Context(), user.MustFromIO(io).ID, templateID)
+		if err != nil && errors.Is(err, persistence.ErrNotFound) {
+			return io.InlineError(ErrTemplateNotFound, err)
+		} else if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return io.Render(TemplatePinData{TemplateID: templateID, Pinned: pinned}, "eiffel.template.pin", "eiffel/_template-working-set.go.html")
+	})
+}
+
+// recordTemplateUse records that the user selected the template to elicit requirements (see template.FavoriteRepository.Use).
+// The recently used templates are a convenience, failing to record the use is logged and does not fail the request.
+func recordTemplateUse(io web.IO, appCtx *hctx.AppCtx, favorites template.FavoriteRepository, templateID uuid.UUID) {
+	if err := favorites.Use(io.Context(), user.MustFromIO(io).ID, templateID); err != nil {
+		appCtx.Logger.Error(template.Pkg, "failed to record template use", err, "templateID", templateID)
+	}
+}
@@ -0,0 +1,25 @@
+package eiffel
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTemplateWorkingSet(t *testing.T) {
+	pinned := &template.Template{ID: uuid.New()}
+	recent := &template.Template{ID: uuid.New()}
+
+	var missing *TemplateWorkingSet
+	assert.True(t, missing.Empty())
+	assert.False(t, missing.IsPinned(pinned.ID))
+	assert.True(t, (&TemplateWorkingSet{}).Empty())
+
+	workingSet := &TemplateWorkingSet{Pinned: []*template.Template{pinned}, Recent: []*template.Template{recent}}
+	assert.False(t, workingSet.Empty())
+	assert.True(t, workingSet.IsPinned(pinned.ID))
+	assert.False(t, workingSet.IsPinned(recent.ID))
+	assert.Equal(t, TemplatePinData{TemplateID: pinned.ID, Pinned: true}, workingSet.PinData(pinned.ID))
+	assert.Equal(t, TemplatePinData{TemplateID: recent.ID}, workingSet.PinData(recent.ID))
+}
@@ -0,0 +1,257 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// The fuzz tests run their seed corpus as part of go test. Fuzz a single target with e.g.:
+//
+//	go test ./app/eiffel -run '^$' -fuzz FuzzEqualsAnyRuleParser -fuzztime 30s
+
+func FuzzPrepareSegments(f *testing.F) {
+	f.Add("rule", " value ", "rule", "other")
+	f.Add("", "", "", "\t\n")
+	f.Add("a", " x ", "b", "\xff")
+
+	f.Fuzz(func(t *testing.T, firstName, firstValue, secondName, secondValue string) {
+		segments := []parser.ParsingSegment{{Name: firstName, Value: firstValue}, {Name: secondName, Value: secondValue}}
+		indexed := prepareSegments(segments)
+
+		last := map[string]string{firstName: firstValue}
+		last[secondName] = secondValue
+		if len(indexed) != len(last) {
+			t.Fatalf("expected %d indexed segments, got %d", len(last), len(indexed))
+		}
+
+		for name, value := range last {
+			segment, ok := indexed[name]
+			if !ok {
+				t.Fatalf("segment %q is not indexed", name)
+			}
+			if segment.Name != name || segment.Value != strings.TrimSpace(value) {
+				t.Fatalf("expected segment %q to be trimmed to %q, got %q", name, strings.TrimSpace(value), segment.Value)
+			}
+		}
+	})
+}
+
+func FuzzEqualsAnyRuleParser(f *testing.F) {
+	f.Add("shall|should|may", "Should")
+	f.Add("", "")
+	f.Add("İ|ß|ǅ", "i̇")
+	f.Add("a||b", "\xff")
+
+	f.Fuzz(func(t *testing.T, values string, segmentValue string) {
+		var ruleValues []any
+		for _, value := range strings.Split(values, "|") {
+			ruleValues = append(ruleValues, value)
+		}
+		rule := BasicRule{Name: "Fuzz", Type: "equalsAny", Value: ruleValues}
+		segment := parser.ParsingSegment{Name: "fuzz", Value: segmentValue}
+
+		if errs := (EqualsAnyRuleParser{}).Validate(validation.New(), rule); len(errs) > 0 {
+			t.Fatalf("expected valid rule, got %v", errs)
+		}
+
+		logs, err := EqualsAnyRuleParser{}.Parse(context.Background(), rule, segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertErrorLogsOnly(t, logs)
+
+		matches := false
+		for _, value := range ruleValues {
+			matches = matches || strings.ToLower(value.(string)) == strings.ToLower(segmentValue)
+		}
+		if matches != (len(logs) == 0) {
+			t.Fatalf("expected match %t for %q in %q, got logs %v", matches, segmentValue, values, logs)
+		}
+
+		compiled := &BasicTemplate{Rules: map[string]BasicRule{"fuzz": rule}}
+		compiled.Compile(RuleParsers())
+		compiledLogs, err := EqualsAnyRuleParser{}.Parse(context.Background(), compiled.Rules["fuzz"], segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(compiledLogs) != len(logs) {
+			t.Fatalf("expected compiled rule to parse like the plain rule, got %v and %v", compiledLogs, logs)
+		}
+	})
+}
+
+func FuzzForbidsRuleParser(f *testing.F) {
+	f.Add("maybe|as fast as possible", "It maybe works as fast as possible.")
+	f.Add("", "")
+	f.Add("ß|İ", "Straße İstanbul")
+	f.Add("x", "\xffx\xff")
+
+	f.Fuzz(func(t *testing.T, phrases string, segmentValue string) {
+		var ruleValues []any
+		for _, phrase := range strings.Split(phrases, "|") {
+			ruleValues = append(ruleValues, phrase)
+		}
+		rule := BasicRule{Name: "Fuzz", Type: "forbids", Value: ruleValues}
+
+		logs, err := ForbidsRuleParser{}.Parse(context.Background(), rule, parser.ParsingSegment{Name: "fuzz", Value: segmentValue})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		length := utf8.RuneCountInString(segmentValue)
+		for _, log := range logs {
+			for _, r := range log.Ranges {
+				if r.Start < 0 || r.End > length || r.Start >= r.End {
+					t.Fatalf("range %d-%d is out of bounds of %q", r.Start, r.End, segmentValue)
+				}
+			}
+		}
+	})
+}
+
+func FuzzScriptRuleParser(f *testing.F) {
+	f.Add(`len(value) > 3 && value != "never"`, "value")
+	f.Add(`value matches "^[A-Z]+-[0-9]+$"`, "REQ-1")
+	f.Add(`number(value) / 0 > 1`, "12")
+	f.Add(`segments["other"] == (`, "")
+	f.Add(`value matches "("`, "\xff")
+
+	f.Fuzz(func(t *testing.T, source string, segmentValue string) {
+		rule := BasicRule{Name: "Fuzz", Type: "script", Value: source}
+		segment := parser.ParsingSegment{Name: "fuzz", Value: segmentValue}
+
+		invalid := len((ScriptRuleParser{}).Validate(validation.New(), rule)) > 0
+		logs, err := ScriptRuleParser{}.Parse(context.Background(), rule, segment)
+		if invalid {
+			return
+		}
+		if err != nil {
+			t.Fatalf("expected valid script %q to parse, got %v", source, err)
+		}
+		if len(logs) > 1 {
+			t.Fatalf("expected at most one log, got %v", logs)
+		}
+	})
+}
+
+func FuzzBasicTemplate_Parse(f *testing.F) {
+	f.Add(`{"id": "fuzz", "name": "Fuzz", "version": "1.0.0", "rules": {"modal": {"name": "Modal", "type": "equalsAny", "value": ["shall"]}},
+		"variants": {"default": {"name": "Default", "rules": ["modal"]}}}`, "default", "shall")
+	f.Add(`{"rules": {"a": {"type": "allOf", "value": ["a"]}}, "variants": {"v": {"rules": ["a"]}}}`, "v", "x")
+	f.Add(`{"rules": {"a": {"type": "not", "value": ["b"]}, "b": {"type": "equals", "value": {"en": "x"}}},
+		"constraints": [{"name": "c", "expression": "len(requirement) >"}], "variants": {"v": {"rules": ["a", "b"]}}}`, "v", "x")
+	f.Add(`{"rules": {"a": {"type": "script", "value": 1}}, "variants": {"v": {"rules": ["a", "missing"]}}}`, "v", "")
+
+	f.Fuzz(func(t *testing.T, config string, variant string, segmentValue string) {
+		bt, err := TemplateIntoBasicTemplate(&template.Template{Config: config}, validation.New(), RuleParsers())
+		if err != nil {
+			return
+		}
+
+		segments := make([]parser.ParsingSegment, 0, len(bt.Rules))
+		for name := range bt.Rules {
+			segments = append(segments, parser.ParsingSegment{Name: name, Value: segmentValue})
+		}
+
+		result, err := bt.Parse(context.Background(), RuleParsers(), variant, segments...)
+		if err != nil {
+			return
+		}
+		assertDowngradedLogs(t, result)
+
+		bt.Compile(RuleParsers())
+		compiled, err := bt.Parse(context.Background(), RuleParsers(), variant, segments...)
+		if err != nil {
+			t.Fatalf("expected compiled template to parse, got %v", err)
+		}
+		if len(compiled.Errors) != len(result.Errors) || compiled.Requirement != result.Requirement {
+			t.Fatalf("expected compiled template to parse like the plain template, got %v and %v", compiled, result)
+		}
+	})
+}
+
+// FuzzBasicTemplate_ParseOptional checks the properties of optional rules: they never cause parsing errors
+// and their downgraded logs are always marked as downgraded.
+func FuzzBasicTemplate_ParseOptional(f *testing.F) {
+	f.Add("foo", "is", "maybe", `len(value) > 100`)
+	f.Add("", "", "", `value ==`)
+	f.Add("FOO", "\xff", "as soon as possible", `segments["verb"] == "is"`)
+
+	f.Fuzz(func(t *testing.T, foo string, verb string, text string, script string) {
+		bt := basicTemplate()
+		bt.Rules["weakWords"] = BasicRule{Name: "Weak Words", Type: "forbids", Value: []any{"maybe", "as soon as possible"}, Optional: true}
+		bt.Rules["script"] = BasicRule{Name: "Script", Type: "script", Value: script, Optional: true}
+		variant := bt.Variants["basicVariant"]
+		variant.Rules = append(variant.Rules, "weakWords", "script")
+		bt.Variants["basicVariant"] = variant
+
+		for name, rule := range bt.Rules {
+			rule.Optional = true
+			bt.Rules[name] = rule
+		}
+
+		result, err := bt.Parse(context.Background(), RuleParsers(), "basicVariant",
+			parser.ParsingSegment{Name: "stateVerbRule", Value: verb},
+			parser.ParsingSegment{Name: "fooRule", Value: foo},
+			parser.ParsingSegment{Name: "fooPostfixRule", Value: text},
+			parser.ParsingSegment{Name: "optionalErrorTestRule", Value: foo},
+			parser.ParsingSegment{Name: "weakWords", Value: text},
+			parser.ParsingSegment{Name: "script", Value: text},
+		)
+		if err != nil {
+			if strings.Contains(err.Error(), "script") {
+				return // invalid scripts are rejected by the template validation
+			}
+			t.Fatal(err)
+		}
+
+		if len(result.Errors) > 0 {
+			t.Fatalf("expected optional rules to never cause errors, got %v", result.Errors)
+		}
+		assertDowngradedLogs(t, result)
+	})
+}
+
+// assertErrorLogsOnly fails the test if any of the logs is not an error.
+func assertErrorLogsOnly(t *testing.T, logs []parser.ParsingLog) {
+	t.Helper()
+
+	for _, log := range logs {
+		if log.Level != parser.ParsingLogLevelError {
+			t.Fatalf("expected error log, got %v", log)
+		}
+	}
+}
+
+// assertDowngradedLogs fails the test if a downgraded log is not a notice or any of its ranges is above the log's level.
+func assertDowngradedLogs(t *testing.T, result parser.ParsingResult) {
+	t.Helper()
+
+	for _, logs := range [][]parser.ParsingLog{result.Errors, result.Warnings, result.Notices} {
+		for _, log := range logs {
+			if !log.Downgrade {
+				continue
+			}
+
+			if log.Level != parser.ParsingLogLevelNotice {
+				t.Fatalf("expected downgraded log to be a notice, got %v", log)
+			}
+			for _, r := range log.Ranges {
+				if r.Level < log.Level {
+					t.Fatalf("expected range of downgraded log to be downgraded, got %v", r)
+				}
+			}
+		}
+	}
+
+	for _, log := range result.Notices {
+		if log.Message == "eiffel.parser.error.missing-segment" && !log.Downgrade {
+			t.Fatalf("expected missing optional segment to be marked as downgraded, got %v", log)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"slices"
+)
+
+// BasicGroup is a repeatable group of rules of a variant, e.g. the stakeholders or the conditions of a requirement.
+// The group's rules occur between Min and Max times (see parser.Multiplicity), each occurrence is parsed by the rules.
+// The segments of an occurrence are indexed by the occurrence, e.g. "stakeholder[0]" and "stakeholder[1]" (see parser.IndexedSegmentName).
+//
+// Example:
+//
+//	"groups": {"stakeholders": {"name": "Stakeholders", "rules": ["stakeholder"], "min": 1, "max": 3}}
+type BasicGroup struct {
+	// Name is the display name of the group.
+	Name string `json:"name" hvalidate:"required"`
+	// Rules are the technical names of the variant's rules that are repeated together.
+	Rules []string `json:"rules" hvalidate:"required"`
+	// Min is the minimum number of occurrences. The group is optional by default.
+	Min int `json:"min"`
+	// Max is the maximum number of occurrences. It defaults to parser.MaxOccurrences.
+	Max int `json:"max"`
+}
+
+// GroupError is returned if a group of a variant is invalid. It is returned by BasicTemplate.Validate.
+type GroupError struct {
+	Group   string
+	Variant string
+	Rule    string
+	// Msg is the error message, it is translated using the parameters "group", "variant" and "rule".
+	Msg string
+}
+
+// Multiplicity returns the number of occurrences allowed of the group.
+func (g BasicGroup) Multiplicity() parser.Multiplicity {
+	return parser.Multiplicity{Min: g.Min, Max: g.Max}
+}
+
+// GroupOf returns the technical name of the variant's group containing the rule. Ok is false if the rule is not repeated.
+func (v BasicVariant) GroupOf(rule string) (string, bool) {
+	for key, group := range v.Groups {
+		if slices.Contains(group.Rules, rule) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// validateGroups validates that the groups of each variant only contain rules of the variant, that each rule is part
+// of one group at most and that the number of occurrences is valid.
+func (bt *BasicTemplate) validateGroups(v validation.V) []error {
+	var errs []error
+	for _, variant := range bt.Variants {
+		grouped := make(map[string]bool)
+		for _, group := range variant.Groups {
+			if err, groupErrs := v.ValidateStruct(group); err == nil {
+				errs = append(errs, groupErrs...)
+			}
+
+			for _, rule := range group.Rules {
+				if !slices.Contains(variant.Rules, rule) {
+					errs = append(errs, GroupError{Group: group.Name, Variant: variant.Name, Rule: rule, Msg: "eiffel.parser.error.group-unknown-rule"})
+				}
+				if grouped[rule] {
+					errs = append(errs, GroupError{Group: group.Name, Variant: variant.Name, Rule: rule, Msg: "eiffel.parser.error.group-duplicate-rule"})
+				}
+				grouped[rule] = true
+			}
+
+			if !group.Multiplicity().Valid() {
+				errs = append(errs, GroupError{Group: group.Name, Variant: variant.Name, Msg: "eiffel.parser.error.group-invalid-occurrences"})
+			}
+		}
+	}
+
+	return errs
+}
+
+// parseGroup parses each non-empty occurrence of the group's rules and checks the number of occurrences.
+// Segments of the group's rules which are not indexed are the first occurrence unless it is indexed as well,
+// e.g. if the elicitation form renders a single occurrence. The logs of each parsed segment are emitted like rules.
+// The logs of the number of occurrences are emitted with the group's technical name as rule. Stop is true if
+// parsing should be stopped at the first error (see StopAtFirstError).
+func (bt *BasicTemplate) parseGroup(
+	ctx context.Context,
+	ruleParsers *RuleParserProvider,
+	group BasicGroup,
+	groupKey string,
+	segments []parser.ParsingSegment,
+	result *parser.ParsingResult,
+	emit func(ParsedRule) error,
+	options *parseOptions,
+) (stop bool, err error) {
+	indexed := make(map[string]bool)
+	for _, segment := range segments {
+		indexed[segment.Name] = true
+	}
+
+	groupSegments := make([]parser.ParsingSegment, 0, len(segments))
+	for _, segment := range segments {
+		if slices.Contains(group.Rules, segment.Name) && !indexed[parser.IndexedSegmentName(segment.Name, 0)] {
+			segment.Name = parser.IndexedSegmentName(segment.Name, 0)
+		}
+		groupSegments = append(groupSegments, segment)
+	}
+
+	occurrences := 0
+	for i, row := range parser.GroupSegments(group.Rules, groupSegments...) {
+		if row.Empty() {
+			continue
+		}
+		occurrences++
+
+		for _, ruleName := range group.Rules {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+
+			rule, ok := bt.Rules[ruleName]
+			if !ok {
+				return false, RuleMissingError{Rule: ruleName, Template: bt.Name, Variant: group.Name}
+			}
+			segment := parser.ParsingSegment{Name: parser.IndexedSegmentName(ruleName, i), Value: row[ruleName]}
+
+			parsingLogs, err := parseSegment(ctx, ruleParsers, segment.Name, rule, segment)
+			if err != nil {
+				return false, err
+			}
+
+			if segment.Value != "" {
+				buildRequirementIncrementally(rule, segment, result)
+			}
+
+			errorsBefore := len(result.Errors)
+			addParsingLogs(result, parsingLogs)
+			if err := emit(ParsedRule{Rule: segment.Name, Logs: parsingLogs}); err != nil {
+				return false, err
+			}
+
+			if options.stopAtFirstError && len(result.Errors) > errorsBefore {
+				return true, nil
+			}
+		}
+	}
+
+	logs := group.Multiplicity().Check(group.Name, occurrences)
+	if len(logs) == 0 {
+		return false, nil
+	}
+
+	addParsingLogs(result, logs)
+	if err := emit(ParsedRule{Rule: groupKey, Logs: logs}); err != nil {
+		return false, err
+	}
+
+	return options.stopAtFirstError, nil
+}
+
+// Error on GroupError returns the error code of the error.
+func (e GroupError) Error() string {
+	return e.Msg
+}
+
+// UnwrapTransparent on GroupError returns the error itself, implementing the validation.TransparentError interface.
+func (e GroupError) UnwrapTransparent(err validation.Error) error {
+	return e
+}
+
+// Translate on GroupError translates the error using the given translator.
+func (e GroupError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "group", e.Group, "variant", e.Variant, "rule", e.Rule)
+}
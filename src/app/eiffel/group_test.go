@@ -0,0 +1,161 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBasicTemplate_ValidateGroups(t *testing.T) {
+	v := validation.New()
+	bt := groupTestTemplate()
+	require.Empty(t, bt.Validate(v, RuleParsers()))
+
+	variant := bt.Variants["default"]
+	variant.Groups["invalid"] = BasicGroup{Name: "Invalid", Rules: []string{"stakeholder", "unknown"}, Min: 3, Max: 2}
+	bt.Variants["default"] = variant
+
+	errs := bt.Validate(v, RuleParsers())
+	assert.Contains(t, errs, GroupError{Group: "Invalid", Variant: "Default", Rule: "unknown", Msg: "eiffel.parser.error.group-unknown-rule"})
+	assert.Contains(t, errs, GroupError{Group: "Invalid", Variant: "Default", Msg: "eiffel.parser.error.group-invalid-occurrences"})
+	assert.Contains(t, errs, template.ErrInvalidTemplate)
+
+	var duplicates int
+	for _, err := range errs {
+		if groupErr, ok := err.(GroupError); ok && groupErr.Msg == "eiffel.parser.error.group-duplicate-rule" {
+			duplicates++
+		}
+	}
+	assert.Equal(t, 1, duplicates)
+}
+
+func TestBasicTemplate_ParseGroups(t *testing.T) {
+	ctx := context.Background()
+	bt := groupTestTemplate()
+
+	result, err := bt.Parse(
+		ctx,
+		RuleParsers(),
+		"default",
+		parser.ParsingSegment{Name: "system", Value: "The system"},
+		parser.ParsingSegment{Name: "modal", Value: "shall"},
+		parser.ParsingSegment{Name: "process", Value: "notify"},
+		parser.ParsingSegment{Name: "stakeholder[0]", Value: "the admin"},
+		parser.ParsingSegment{Name: "stakeholder[2]", Value: ""},
+		parser.ParsingSegment{Name: "stakeholder[3]", Value: "the user"},
+	)
+	require.NoError(t, err)
+	assert.True(t, result.Ok())
+	assert.Equal(t, "The system shall notify the admin the user", result.Requirement)
+
+	result, err = bt.Parse(
+		ctx,
+		RuleParsers(),
+		"default",
+		parser.ParsingSegment{Name: "system", Value: "The system"},
+		parser.ParsingSegment{Name: "modal", Value: "could"},
+		parser.ParsingSegment{Name: "process", Value: "notify"},
+	)
+	require.NoError(t, err)
+	assert.False(t, result.Ok())
+	assert.Len(t, result.ViolationsForRule("modal"), 1)
+	require.Len(t, result.ConstraintLogs(), 1)
+	assert.Equal(t, "template.parser.too-few-occurrences", result.ConstraintLogs()[0].Message)
+	assert.Equal(t, "Stakeholders", result.ConstraintLogs()[0].Constraint)
+
+	result, err = bt.Parse(
+		ctx,
+		RuleParsers(),
+		"default",
+		parser.ParsingSegment{Name: "system", Value: "The system"},
+		parser.ParsingSegment{Name: "modal", Value: "shall"},
+		parser.ParsingSegment{Name: "process", Value: "notify"},
+		parser.ParsingSegment{Name: "stakeholder", Value: "the admin"},
+		parser.ParsingSegment{Name: "stakeholder[1]", Value: "the user"},
+		parser.ParsingSegment{Name: "stakeholder[2]", Value: "the guest"},
+	)
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, "The system shall notify the admin the user the guest", result.Requirement)
+
+	result, err = bt.Parse(
+		ctx,
+		RuleParsers(),
+		"default",
+		parser.ParsingSegment{Name: "system", Value: "The system"},
+		parser.ParsingSegment{Name: "modal", Value: "shall"},
+		parser.ParsingSegment{Name: "process", Value: "notify"},
+		parser.ParsingSegment{Name: "stakeholder[0]", Value: "a"},
+		parser.ParsingSegment{Name: "stakeholder[1]", Value: "b"},
+		parser.ParsingSegment{Name: "stakeholder[2]", Value: "c"},
+		parser.ParsingSegment{Name: "stakeholder[3]", Value: "d"},
+	)
+	require.NoError(t, err)
+	require.Len(t, result.ConstraintLogs(), 1)
+	assert.Equal(t, "template.parser.too-many-occurrences", result.ConstraintLogs()[0].Message)
+}
+
+func TestBasicTemplate_ParseGroupLogsByOccurrence(t *testing.T) {
+	bt := groupTestTemplate()
+	bt.Rules["stakeholder"] = BasicRule{Name: "Stakeholder", Type: "equalsAny", Value: []any{"the admin", "the user"}}
+
+	var emitted []string
+	result, err := bt.ParseStream(
+		context.Background(),
+		RuleParsers(),
+		"default",
+		[]parser.ParsingSegment{
+			{Name: "system", Value: "The system"},
+			{Name: "modal", Value: "shall"},
+			{Name: "process", Value: "notify"},
+			{Name: "stakeholder[0]", Value: "the admin"},
+			{Name: "stakeholder[1]", Value: "the cat"},
+		},
+		func(parsed ParsedRule) error {
+			emitted = append(emitted, parsed.Rule)
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"system", "modal", "process", "stakeholder[0]", "stakeholder[1]"}, emitted)
+
+	logs := result.LogsByOccurrence("stakeholder")
+	assert.Empty(t, logs[0])
+	require.Len(t, logs[1], 1)
+	assert.Equal(t, parser.ParsingLogLevelError, logs[1][0].Level)
+	assert.Len(t, result.ViolationsForRule("stakeholder[1]"), 1)
+
+	logs1, err := bt.ParseSegment(context.Background(), RuleParsers(), "default", parser.ParsingSegment{Name: "stakeholder[1]", Value: "the cat"})
+	require.NoError(t, err)
+	assert.Len(t, logs1, 1)
+
+	_, err = bt.ParseSegment(context.Background(), RuleParsers(), "default", parser.ParsingSegment{Name: "system[1]", Value: "The system"})
+	assert.ErrorAs(t, err, &RuleMissingError{})
+}
+
+func groupTestTemplate() *BasicTemplate {
+	return &BasicTemplate{
+		ID:      "groups",
+		Name:    "Groups",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"system":      {Name: "System", Type: "placeholder"},
+			"modal":       {Name: "Modal", Type: "equalsAny", Value: []any{"shall", "should"}},
+			"process":     {Name: "Process", Type: "placeholder"},
+			"stakeholder": {Name: "Stakeholder", Type: "placeholder"},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {
+				Name:  "Default",
+				Rules: []string{"system", "modal", "process", "stakeholder"},
+				Groups: map[string]BasicGroup{
+					"stakeholders": {Name: "Stakeholders", Rules: []string{"stakeholder"}, Min: 1, Max: 3},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package eiffel
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/app/user"
+	"maps"
+	"time"
+)
+
+// MaxHistoryEntries is the maximum number of parse attempts kept in the history of a template's variant.
+// The oldest entries are dropped once the history is full.
+const MaxHistoryEntries = 20
+
+// History is the history of parse attempts of a template's variant within a user's session. It allows the user
+// to step back and forth through earlier phrasings of a requirement. The history is stored in the session's settings,
+// see HistoryFromSession and History.AddToSession.
+type History struct {
+	Entries []HistoryEntry `json:"entries"`
+	// Position is the index of the entry currently displayed in the elicitation form. It is -1 for an empty history.
+	Position int `json:"position"`
+}
+
+// HistoryEntry is a single parse attempt: the segments the user entered and a summary of the parsing result.
+type HistoryEntry struct {
+	SegmentMap  map[string]string `json:"segments"`
+	Requirement string            `json:"requirement"`
+	Errors      int               `json:"errors"`
+	Warnings    int               `json:"warnings"`
+	Notices     int               `json:"notices"`
+	ParsedAt    time.Time         `json:"parsedAt"`
+}
+
+// NewHistoryEntry summarizes the parse attempt of the segments as an entry of the history.
+func NewHistoryEntry(segmentMap map[string]string, result parser.ParsingResult) HistoryEntry {
+	return HistoryEntry{
+		SegmentMap:  maps.Clone(segmentMap),
+		Requirement: result.Requirement,
+		Errors:      len(result.Errors),
+		Warnings:    len(result.Warnings),
+		Notices:     len(result.Notices),
+		ParsedAt:    time.Now(),
+	}
+}
+
+// HistoryFromSession reads the history of the template's variant from the session. An empty history is returned
+// if the session does not contain a (valid) history.
+func HistoryFromSession(session *user.Session, templateID string, variant string) History {
+	history := History{Position: -1}
+
+	value, err := session.Setting(historySettingKey(templateID, variant))
+	if err != nil {
+		return history
+	}
+
+	if err := json.Unmarshal([]byte(value), &history); err != nil || history.Position >= len(history.Entries) {
+		return History{Position: -1}
+	}
+
+	return history
+}
+
+// AddToSession stores the history of the template's variant in the session. The session is not written to the store.
+func (h History) AddToSession(session *user.Session, templateID string, variant string) error {
+	value, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	session.AddSetting(historySettingKey(templateID, variant), string(value))
+
+	return nil
+}
+
+// Push appends the entry to the history and makes it the current entry. An entry with the same segments as the
+// last entry replaces the last entry. Entries beyond MaxHistoryEntries are dropped starting with the oldest.
+func (h *History) Push(entry HistoryEntry) {
+	if last := len(h.Entries) - 1; last >= 0 && maps.Equal(h.Entries[last].SegmentMap, entry.SegmentMap) {
+		h.Entries = h.Entries[:last]
+	}
+
+	h.Entries = append(h.Entries, entry)
+	if len(h.Entries) > MaxHistoryEntries {
+		h.Entries = h.Entries[len(h.Entries)-MaxHistoryEntries:]
+	}
+	h.Position = len(h.Entries) - 1
+}
+
+// Step moves the position to the entry at the index and returns the entry. It returns false if there is no entry at the index.
+func (h *History) Step(index int) (HistoryEntry, bool) {
+	if index < 0 || index >= len(h.Entries) {
+		return HistoryEntry{}, false
+	}
+
+	h.Position = index
+
+	return h.Entries[index], true
+}
+
+// CanBack returns true if there is an entry before the current entry.
+func (h History) CanBack() bool {
+	return h.Position > 0
+}
+
+// CanForward returns true if there is an entry after the current entry.
+func (h History) CanForward() bool {
+	return h.Position >= 0 && h.Position < len(h.Entries)-1
+}
+
+func historySettingKey(templateID string, variant string) string {
+	return fmt.Sprintf("eiffel.History.%s.%s", templateID, variant)
+}
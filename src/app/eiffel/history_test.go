@@ -0,0 +1,60 @@
+package eiffel
+
+import (
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	history := History{Position: -1}
+	assert.False(t, history.CanBack())
+	assert.False(t, history.CanForward())
+
+	history.Push(NewHistoryEntry(map[string]string{"system": "A"}, parser.ParsingResult{Requirement: "A"}))
+	history.Push(NewHistoryEntry(map[string]string{"system": "B"}, parser.ParsingResult{Requirement: "B", Errors: []parser.ParsingLog{{}}}))
+	history.Push(NewHistoryEntry(map[string]string{"system": "B"}, parser.ParsingResult{Requirement: "B"}))
+	require.Len(t, history.Entries, 2, "an entry with the same segments replaces the last entry")
+	assert.Equal(t, 1, history.Position)
+	assert.Equal(t, 0, history.Entries[1].Errors)
+	assert.True(t, history.CanBack())
+	assert.False(t, history.CanForward())
+
+	entry, ok := history.Step(history.Position - 1)
+	require.True(t, ok)
+	assert.Equal(t, "A", entry.SegmentMap["system"])
+	assert.True(t, history.CanForward())
+
+	_, ok = history.Step(history.Position - 1)
+	assert.False(t, ok)
+	assert.Equal(t, 0, history.Position)
+
+	history.Push(NewHistoryEntry(map[string]string{"system": "C"}, parser.ParsingResult{}))
+	assert.Equal(t, 2, history.Position, "pushing keeps earlier entries and makes the new entry the current one")
+
+	for i := 0; i < MaxHistoryEntries; i++ {
+		history.Push(NewHistoryEntry(map[string]string{"system": string(rune('a' + i))}, parser.ParsingResult{}))
+	}
+	assert.Len(t, history.Entries, MaxHistoryEntries)
+	assert.Equal(t, "a", history.Entries[0].SegmentMap["system"])
+}
+
+func TestHistory_Session(t *testing.T) {
+	session := &user.Session{}
+	assert.Equal(t, History{Position: -1}, HistoryFromSession(session, "template", "default"))
+
+	history := History{Position: -1}
+	history.Push(NewHistoryEntry(map[string]string{"system": "A"}, parser.ParsingResult{Requirement: "A"}))
+	require.NoError(t, history.AddToSession(session, "template", "default"))
+
+	restored := HistoryFromSession(session, "template", "default")
+	require.Len(t, restored.Entries, 1)
+	assert.Equal(t, "A", restored.Entries[0].Requirement)
+	assert.Equal(t, 0, restored.Position)
+	assert.Equal(t, History{Position: -1}, HistoryFromSession(session, "template", "other"))
+
+	session.AddSetting(historySettingKey("template", "default"), "invalid")
+	assert.Equal(t, History{Position: -1}, HistoryFromSession(session, "template", "default"))
+}
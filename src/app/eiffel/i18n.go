@@ -0,0 +1,174 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"regexp"
+	"strings"
+)
+
+// localePathRegex matches locale paths like de, en or en-US.
+var localePathRegex = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4})?$`)
+
+// TemplateTranslation overrides the user-facing texts of a template for a locale. Empty fields are not overridden.
+//
+// Example:
+//
+//	{"name": "User Story", "translations": {"de": {"name": "Nutzergeschichte", "description": "..."}}}
+type TemplateTranslation struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// RuleTranslation overrides the user-facing texts of a rule for a locale. Empty fields are not overridden.
+// The rule's value is localized separately, see LocalizedValue.
+type RuleTranslation struct {
+	Name        string `json:"name"`
+	Hint        string `json:"hint"`
+	Explanation string `json:"explanation"`
+}
+
+// VariantTranslation overrides the user-facing texts of a variant for a locale. Empty fields are not overridden.
+type VariantTranslation struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Format      string `json:"format"`
+	Example     string `json:"example"`
+}
+
+// TranslationError is returned if a translation of a template is invalid: its locale is no locale path or it overrides nothing.
+// It is returned by BasicTemplate.Validate.
+type TranslationError struct {
+	Template string
+	// Element is the translated element, e.g. rules.role or variants.default. It is empty for the template itself.
+	Element string
+	Locale  string
+}
+
+// LocalizeContent overrides the names, descriptions, hints, explanations, formats and examples of the template, its rules and variants
+// with their translations for the user's active locale read from the context. Locales like en-US fall back to their language (en).
+// Texts without a translation are kept. This is useful before displaying the template, e.g. in the elicitation form.
+// The template is modified in place.
+func (bt *BasicTemplate) LocalizeContent(ctx context.Context) {
+	locale := ctxLocale(ctx)
+	if locale == "" {
+		return
+	}
+
+	if translation, ok := translationFor(bt.Translations, locale); ok {
+		override(&bt.Name, translation.Name)
+		override(&bt.Description, translation.Description)
+	}
+
+	for name, rule := range bt.Rules {
+		translation, ok := translationFor(rule.Translations, locale)
+		if !ok {
+			continue
+		}
+
+		override(&rule.Name, translation.Name)
+		override(&rule.Hint, translation.Hint)
+		override(&rule.Explanation, translation.Explanation)
+		bt.Rules[name] = rule
+	}
+
+	for name, variant := range bt.Variants {
+		translation, ok := translationFor(variant.Translations, locale)
+		if !ok {
+			continue
+		}
+
+		override(&variant.Name, translation.Name)
+		override(&variant.Description, translation.Description)
+		override(&variant.Format, translation.Format)
+		override(&variant.Example, translation.Example)
+		bt.Variants[name] = variant
+	}
+}
+
+// Localize resolves the localized rule values (see LocalizeRules) and the translated texts (see LocalizeContent)
+// of the template to the user's active locale read from the context. The template is modified in place.
+func (bt *BasicTemplate) Localize(ctx context.Context) {
+	bt.LocalizeRules(ctx)
+	bt.LocalizeContent(ctx)
+}
+
+// validateTranslations validates the locales of the translations of the template, its rules and variants
+// and that each translation overrides at least one text. Errors are ordered by element and locale.
+func (bt *BasicTemplate) validateTranslations() []error {
+	var errs []error
+	errs = append(errs, validateTranslationsOf(bt.Name, "", bt.Translations, func(tr TemplateTranslation) bool {
+		return tr != TemplateTranslation{}
+	})...)
+
+	for _, name := range sortedKeys(bt.Rules) {
+		errs = append(errs, validateTranslationsOf(bt.Name, "rules."+name, bt.Rules[name].Translations, func(tr RuleTranslation) bool {
+			return tr != RuleTranslation{}
+		})...)
+	}
+
+	for _, name := range sortedKeys(bt.Variants) {
+		errs = append(errs, validateTranslationsOf(bt.Name, "variants."+name, bt.Variants[name].Translations, func(tr VariantTranslation) bool {
+			return tr != VariantTranslation{}
+		})...)
+	}
+
+	return errs
+}
+
+// validateTranslationsOf validates the translations of an element, see validateTranslations.
+func validateTranslationsOf[T any](template, element string, translations map[string]T, overrides func(T) bool) []error {
+	var errs []error
+	for _, locale := range sortedKeys(translations) {
+		if !localePathRegex.MatchString(locale) || !overrides(translations[locale]) {
+			errs = append(errs, TranslationError{Template: template, Element: element, Locale: locale})
+		}
+	}
+
+	return errs
+}
+
+// translationFor returns the translation for the locale path. If there is none, the translation of the locale's language is returned (en for en-US).
+func translationFor[T any](translations map[string]T, locale string) (T, bool) {
+	if translation, ok := translations[locale]; ok {
+		return translation, true
+	}
+
+	language, _, found := strings.Cut(locale, "-")
+	if !found {
+		var zero T
+		return zero, false
+	}
+
+	translation, ok := translations[language]
+
+	return translation, ok
+}
+
+// override sets the text to the translation if the translation is not empty.
+func override(text *string, translation string) {
+	if translation != "" {
+		*text = translation
+	}
+}
+
+// Error on TranslationError returns the error code of the error.
+func (e TranslationError) Error() string {
+	return "eiffel.parser.error.invalid-translation"
+}
+
+// UnwrapTransparent on TranslationError returns the error itself, implementing the validation.TransparentError interface.
+func (e TranslationError) UnwrapTransparent(err validation.Error) error {
+	return e
+}
+
+// Translate on TranslationError translates the error using the given translator.
+func (e TranslationError) Translate(t trans.Translator) string {
+	element := e.Element
+	if element == "" {
+		element = e.Template
+	}
+
+	return t.Tf(e.Error(), "template", e.Template, "element", element, "locale", e.Locale)
+}
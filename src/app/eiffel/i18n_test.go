@@ -0,0 +1,62 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBasicTemplate_LocalizeContent(t *testing.T) {
+	translated := func() *BasicTemplate {
+		bt := basicTemplate()
+		bt.Translations = map[string]TemplateTranslation{"de": {Name: "Testschablone"}}
+
+		rule := bt.Rules["fooRule"]
+		rule.Hint = "Enter foo"
+		rule.Translations = map[string]RuleTranslation{"de": {Name: "Foo-Regel", Hint: "Geben Sie foo ein"}}
+		bt.Rules["fooRule"] = rule
+
+		variant := bt.Variants["basicVariant"]
+		variant.Translations = map[string]VariantTranslation{"de": {Example: "foo ist foo"}}
+		bt.Variants["basicVariant"] = variant
+
+		return bt
+	}
+
+	bt := translated()
+	require.Empty(t, bt.Validate(validation.New(), ruleParsers()))
+
+	bt.LocalizeContent(localeCtx("de"))
+	assert.Equal(t, "Testschablone", bt.Name)
+	assert.Equal(t, "Foo-Regel", bt.Rules["fooRule"].Name)
+	assert.Equal(t, "Geben Sie foo ein", bt.Rules["fooRule"].Hint)
+	assert.Equal(t, basicTemplate().Rules["fooRule"].Explanation, bt.Rules["fooRule"].Explanation, "texts without translation are kept")
+	assert.Equal(t, "foo ist foo", bt.Variants["basicVariant"].Example)
+	assert.Equal(t, basicTemplate().Variants["basicVariant"].Name, bt.Variants["basicVariant"].Name)
+
+	bt = translated()
+	bt.LocalizeContent(localeCtx("de-AT"))
+	assert.Equal(t, "Testschablone", bt.Name, "locales fall back to their language")
+
+	for _, ctx := range []context.Context{localeCtx("en"), context.Background()} {
+		bt = translated()
+		bt.LocalizeContent(ctx)
+		assert.Equal(t, "Test Template", bt.Name)
+		assert.Equal(t, "Enter foo", bt.Rules["fooRule"].Hint)
+	}
+}
+
+func TestBasicTemplate_ValidateTranslations(t *testing.T) {
+	bt := basicTemplate()
+	bt.Translations = map[string]TemplateTranslation{"german": {Name: "Testschablone"}}
+	rule := bt.Rules["fooRule"]
+	rule.Translations = map[string]RuleTranslation{"de": {}, "en-US": {Hint: "Enter foo"}}
+	bt.Rules["fooRule"] = rule
+
+	errs := bt.Validate(validation.New(), ruleParsers())
+	assert.Contains(t, errs, TranslationError{Template: "Test Template", Locale: "german"})
+	assert.Contains(t, errs, TranslationError{Template: "Test Template", Element: "rules.fooRule", Locale: "de"})
+	assert.NotContains(t, errs, TranslationError{Template: "Test Template", Element: "rules.fooRule", Locale: "en-US"})
+}
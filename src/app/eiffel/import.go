@@ -0,0 +1,330 @@
+package eiffel
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/reqif"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxImportRows is the maximum number of requirements (rows without the header) imported from a single CSV file.
+const MaxImportRows = 1000
+
+var (
+	// ErrImportInvalidCSV is displayed to the user if the uploaded file is no valid CSV file.
+	ErrImportInvalidCSV = errors.New("eiffel.import.error.invalid-csv")
+	// ErrImportEmpty is displayed to the user if the uploaded CSV file contains no header or no rows.
+	ErrImportEmpty = errors.New("eiffel.import.error.empty")
+	// ErrImportTooManyRows is displayed to the user if the uploaded CSV file contains more than MaxImportRows rows.
+	ErrImportTooManyRows = errors.New("eiffel.import.error.too-many-rows")
+)
+
+// ImportTable is a CSV file read to import requirements from. The first row of the file is the Header naming the columns.
+// Rows are the remaining non-empty rows, each has as many cells as the header. Lines are the rows' lines in the file.
+// Comma is the delimiter the file was read with.
+type ImportTable struct {
+	Name   string
+	Comma  rune
+	Header []string
+	Rows   [][]string
+	Lines  []int
+}
+
+// sentenceColumns are the names of columns containing whole requirement sentences (case-insensitive), see DefaultImportMapping.
+var sentenceColumns = []string{strings.ToLower(reqif.TextAttribute), "requirement", "sentence", "text"}
+
+// ImportMapping maps the rules of a template to the columns of an ImportTable. Columns are the indexes of the columns by the
+// rules' keys, rules without a column are not mapped. TagsColumn is the column of the comma-separated free-form tags
+// (see requirement.ParseTags), it is -1 if the requirements are imported without tags. SentenceColumn is the column of whole
+// requirement sentences split into the segments of the rules without a column (see BasicTemplate.SegmentSentence), e.g. the text
+// of a ReqIF document. It is -1 if there is no such column.
+type ImportMapping struct {
+	Variant        string
+	Columns        map[string]int
+	TagsColumn     int
+	SentenceColumn int
+}
+
+// ImportRow is a row of an ImportTable validated against a template's variant. Line is the row's line in the CSV file.
+// Segments are the row's values of the variant's rules keyed by the rules' keys.
+type ImportRow struct {
+	Line     int
+	Values   []string
+	Segments map[string]string
+	Tags     []string
+	Result   parser.ParsingResult
+}
+
+// ReadImportCSV reads the CSV file to import requirements from. The delimiter is detected from the header: semicolons and tabs,
+// as exported by spreadsheets in many locales, are used if the header contains more of them than commas. A leading UTF-8 byte
+// order mark is removed, cells are trimmed and empty rows are skipped. ReadImportCSV returns ErrImportInvalidCSV if the file
+// could not be read, ErrImportEmpty if it contains no rows and ErrImportTooManyRows if it contains more than MaxImportRows rows.
+func ReadImportCSV(name string, r io.Reader) (*ImportTable, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Join(ErrImportInvalidCSV, err)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = detectDelimiter(content)
+	reader.FieldsPerRecord = -1
+
+	table := &ImportTable{Name: name, Comma: reader.Comma}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Join(ErrImportInvalidCSV, err)
+		}
+
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		if table.Header == nil {
+			table.Header = record
+			continue
+		}
+
+		if len(table.Rows) == MaxImportRows {
+			return nil, ErrImportTooManyRows
+		}
+
+		row := make([]string, len(table.Header))
+		copy(row, record)
+		line, _ := reader.FieldPos(0)
+		table.Rows = append(table.Rows, row)
+		table.Lines = append(table.Lines, line)
+	}
+
+	if len(table.Rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	return table, nil
+}
+
+// DefaultImportMapping maps the rules of the template to the columns of the header named after the rule's key or name
+// (case-insensitive), the tags to a column named "tags" and the sentences to the first column named after sentenceColumns.
+// The variant is kept as it is.
+func DefaultImportMapping(bt *BasicTemplate, variant string, header []string) ImportMapping {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(name)] = i
+	}
+
+	mapping := ImportMapping{Variant: variant, Columns: make(map[string]int), TagsColumn: -1, SentenceColumn: SentenceColumn(header)}
+	for key, rule := range bt.Rules {
+		if i, ok := columns[strings.ToLower(key)]; ok {
+			mapping.Columns[key] = i
+		} else if i, ok := columns[strings.ToLower(rule.Name)]; ok {
+			mapping.Columns[key] = i
+		}
+	}
+
+	if i, ok := columns["tags"]; ok {
+		mapping.TagsColumn = i
+	}
+
+	return mapping
+}
+
+// SentenceColumn returns the first column of the header named after sentenceColumns (case-insensitive) or -1 if there is none.
+func SentenceColumn(header []string) int {
+	for _, name := range sentenceColumns {
+		for i, column := range header {
+			if strings.EqualFold(column, name) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// Mapped returns the keys of the rules of the mapping's variant that are mapped to a column.
+func (m ImportMapping) Mapped(bt *BasicTemplate) []string {
+	var mapped []string
+	for _, rule := range bt.Variants[m.Variant].Rules {
+		if _, ok := m.Columns[rule]; ok {
+			mapped = append(mapped, rule)
+		}
+	}
+
+	return mapped
+}
+
+// ValidateImport parses each row of the table with the mapping's variant as a requirement. Values of columns that are not mapped
+// and of rules the variant does not use are ignored. Rules without a column are segmented from the row's sentence if the mapping
+// has a sentence column. It returns ErrInvalidVariant if the template has no such variant.
+func ValidateImport(ctx context.Context, bt *BasicTemplate, ruleParsers *RuleParserProvider, table *ImportTable, mapping ImportMapping) ([]*ImportRow, error) {
+	variant, ok := bt.Variants[mapping.Variant]
+	if !ok {
+		return nil, ErrInvalidVariant
+	}
+
+	rows := make([]*ImportRow, 0, len(table.Rows))
+	for i, values := range table.Rows {
+		row := &ImportRow{Line: i + 2, Values: values, Segments: make(map[string]string, len(variant.Rules))}
+		if i < len(table.Lines) {
+			row.Line = table.Lines[i]
+		}
+		for _, rule := range variant.Rules {
+			if column, ok := mapping.Columns[rule]; ok && column >= 0 && column < len(values) {
+				row.Segments[rule] = values[column]
+			}
+		}
+		if mapping.SentenceColumn >= 0 && mapping.SentenceColumn < len(values) {
+			segmentation, err := bt.SegmentSentence(ctx, mapping.Variant, values[mapping.SentenceColumn])
+			if err != nil {
+				return nil, err
+			}
+
+			for _, segment := range segmentation.Segments {
+				if _, ok := row.Segments[segment.Name]; !ok {
+					row.Segments[segment.Name] = segment.Value
+				}
+			}
+		}
+		if mapping.TagsColumn >= 0 && mapping.TagsColumn < len(values) {
+			row.Tags = requirement.ParseTags(values[mapping.TagsColumn])
+		}
+
+		result, err := bt.Parse(ctx, ruleParsers, mapping.Variant, SegmentMapToSegments(row.Segments)...)
+		if err != nil {
+			return nil, err
+		}
+		row.Result = result
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// ImportSummary counts the valid and invalid rows of a validated import.
+func ImportSummary(rows []*ImportRow) (valid int, invalid int) {
+	for _, row := range rows {
+		if row.Result.Ok() {
+			valid++
+		} else {
+			invalid++
+		}
+	}
+
+	return valid, invalid
+}
+
+// ImportRequirements stores the valid rows as requirements of the user created with the template (see RequirementToSave).
+// The requirements are added to the project unless projectID is uuid.Nil. It returns the number of imported requirements.
+func ImportRequirements(
+	ctx context.Context,
+	bt *BasicTemplate,
+	templateID uuid.UUID,
+	mapping ImportMapping,
+	rows []*ImportRow,
+	userID uuid.UUID,
+	projectID uuid.UUID,
+	requirementRepository requirement.Repository,
+	translator trans.Translator,
+) (int, error) {
+	variant, ok := bt.Variants[mapping.Variant]
+	if !ok {
+		return 0, ErrInvalidVariant
+	}
+
+	imported := 0
+	for _, row := range rows {
+		if !row.Result.Ok() {
+			continue
+		}
+
+		exported := ExportRequirement(bt, templateID, &variant, row.Segments, row.Result, translator)
+		toSave := RequirementToSave(uuid.New(), bt, exported, row.Tags, userID)
+		if projectID != uuid.Nil {
+			toSave.ProjectID = &projectID
+		}
+
+		if _, err := requirementRepository.Save(ctx, toSave); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+
+	return imported, nil
+}
+
+// WriteImportReport writes the invalid rows as CSV with the table's delimiter. The report contains the columns of the table
+// preceded by the row's line and followed by the row's translated errors, so the rows can be fixed and imported again.
+func WriteImportReport(w io.Writer, table *ImportTable, rows []*ImportRow, translator trans.Translator) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = table.Comma
+
+	header := append([]string{translator.T("eiffel.import.report.line")}, table.Header...)
+	header = append(header, translator.T("eiffel.import.report.errors"))
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if row.Result.Ok() {
+			continue
+		}
+
+		errs := make([]string, 0, len(row.Result.Errors))
+		for _, log := range row.Result.Errors {
+			errs = append(errs, log.Translate(translator))
+		}
+
+		record := append([]string{strconv.Itoa(row.Line)}, row.Values...)
+		record = append(record, strings.Join(errs, "; "))
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// sortedRuleKeys returns the keys of the template's rules sorted alphabetically.
+func sortedRuleKeys(bt *BasicTemplate) []string {
+	keys := make([]string, 0, len(bt.Rules))
+	for key := range bt.Rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// detectDelimiter returns the delimiter occurring most often in the first line of the content: a semicolon, a tab or a comma.
+// Commas are the default if the line contains none of them.
+func detectDelimiter(content []byte) rune {
+	firstLine, _, _ := bytes.Cut(content, []byte("\n"))
+
+	delimiter, count := ',', bytes.Count(firstLine, []byte(","))
+	for _, candidate := range []rune{';', '\t'} {
+		if n := bytes.Count(firstLine, []byte(string(candidate))); n > count {
+			delimiter, count = candidate, n
+		}
+	}
+
+	return delimiter
+}
@@ -0,0 +1,126 @@
+package eiffel
+
+import (
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/app/reqif"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// importSuggestionSample is the number of rows validated per variant to suggest the variants matching an import, see SuggestImportVariants.
+const importSuggestionSample = 20
+
+// ErrImportInvalidReqIF is displayed to the user if the uploaded file is no valid ReqIF document.
+var ErrImportInvalidReqIF = errors.New("eiffel.import.error.invalid-reqif")
+
+// ImportSuggestion is a variant of a template suggested for importing the rows of an ImportTable. Matches is the number of
+// the Sample rows that are valid requirements of the variant.
+type ImportSuggestion struct {
+	TemplateID string
+	Template   *BasicTemplate
+	Variant    string
+	Matches    int
+	Sample     int
+}
+
+// ReadImportReqIF reads the ReqIF document to import requirements from, e.g. exported from IBM DOORS or Polarion (see reqif.Parse).
+// The attributes of the document are the columns of the table and each spec object with a text is a row (see reqif.Document.DefaultMapping),
+// headings and other spec objects without a text are skipped. Lines are the positions of the spec objects in the document starting at 1.
+// ReadImportReqIF returns ErrImportInvalidReqIF if the document could not be read, ErrImportEmpty if it contains no requirements
+// and ErrImportTooManyRows if it contains more than MaxImportRows requirements.
+func ReadImportReqIF(name string, r io.Reader) (*ImportTable, error) {
+	doc, err := reqif.Parse(r)
+	if err != nil {
+		return nil, errors.Join(ErrImportInvalidReqIF, err)
+	}
+
+	positions := make(map[*reqif.SpecObject]int, len(doc.Objects))
+	for i, object := range doc.Objects {
+		positions[object] = i + 1
+	}
+
+	table := &ImportTable{Name: name, Comma: ',', Header: doc.Attributes}
+	for _, req := range doc.Requirements(doc.DefaultMapping()) {
+		if len(table.Rows) == MaxImportRows {
+			return nil, ErrImportTooManyRows
+		}
+
+		row := make([]string, len(table.Header))
+		for i, attribute := range table.Header {
+			row[i] = strings.TrimSpace(req.Object.Values[attribute])
+		}
+
+		table.Rows = append(table.Rows, row)
+		table.Lines = append(table.Lines, positions[req.Object])
+	}
+
+	if len(table.Rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	return table, nil
+}
+
+// IsReqIFFile returns true if the file name has the extension of a ReqIF document (.reqif or .xml).
+func IsReqIFFile(name string) bool {
+	ext := path.Ext(name)
+
+	return strings.EqualFold(ext, ".reqif") || strings.EqualFold(ext, ".xml")
+}
+
+// SuggestImportVariants suggests the variants of the templates for importing the whole requirement sentences of the table's column.
+// The first importSuggestionSample rows are validated against each variant (see ValidateImport), the variants are ordered by their matches,
+// ties by the template's name and the variant's key. Variants without matches are not suggested. The templates are keyed by their id.
+func SuggestImportVariants(ctx context.Context, ruleParsers *RuleParserProvider, table *ImportTable, column int, templates map[string]*BasicTemplate) ([]ImportSuggestion, error) {
+	if column < 0 || column >= len(table.Header) {
+		return nil, nil
+	}
+
+	sample := &ImportTable{Name: table.Name, Comma: table.Comma, Header: table.Header, Rows: table.Rows, Lines: table.Lines}
+	if len(sample.Rows) > importSuggestionSample {
+		sample.Rows = sample.Rows[:importSuggestionSample]
+		sample.Lines = sample.Lines[:min(len(sample.Lines), importSuggestionSample)]
+	}
+
+	var suggestions []ImportSuggestion
+	for id, bt := range templates {
+		for variant := range bt.Variants {
+			rows, err := ValidateImport(ctx, bt, ruleParsers, sample, ImportMapping{Variant: variant, TagsColumn: -1, SentenceColumn: column})
+			if err != nil {
+				return nil, err
+			}
+
+			matches, _ := ImportSummary(rows)
+			if matches == 0 {
+				continue
+			}
+
+			suggestions = append(suggestions, ImportSuggestion{TemplateID: id, Template: bt, Variant: variant, Matches: matches, Sample: len(rows)})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Matches != b.Matches {
+			return a.Matches > b.Matches
+		}
+		if a.Template.Name != b.Template.Name {
+			return a.Template.Name < b.Template.Name
+		}
+		if a.TemplateID != b.TemplateID {
+			return a.TemplateID < b.TemplateID
+		}
+
+		return a.Variant < b.Variant
+	})
+
+	return suggestions, nil
+}
+
+// VariantName returns the name of the suggested variant.
+func (s ImportSuggestion) VariantName() string {
+	return s.Template.Variants[s.Variant].Name
+}
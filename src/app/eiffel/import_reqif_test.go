@@ -0,0 +1,143 @@
+package eiffel
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/reqif"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestReadImportReqIF(t *testing.T) {
+	table, err := ReadImportReqIF("machine.reqif", bytes.NewReader(importTestReqIF(t)))
+	require.NoError(t, err)
+	assert.Equal(t, "machine.reqif", table.Name)
+	assert.Equal(t, []string{reqif.ForeignIDAttribute, reqif.ChapterNameAttribute, reqif.TextAttribute, "Tags"}, table.Header)
+	assert.Equal(t, [][]string{
+		{"REQ-1", "", "The coffee machine shall brew coffee", "brewing"},
+		{"REQ-2", "", "The coffee machine should heat the milk", ""},
+		{"REQ-3", "", "The coffee machine could grind beans", ""},
+	}, table.Rows, "the heading is skipped")
+	assert.Equal(t, []int{2, 3, 4}, table.Lines)
+	assert.Equal(t, 2, SentenceColumn(table.Header))
+
+	_, err = ReadImportReqIF("invalid.reqif", strings.NewReader("<REQ-IF><CORE-CONTENT>"))
+	assert.ErrorIs(t, err, ErrImportInvalidReqIF)
+
+	var empty bytes.Buffer
+	require.NoError(t, reqif.Write(&empty, &reqif.Document{Attributes: []string{reqif.TextAttribute}}))
+	_, err = ReadImportReqIF("empty.reqif", &empty)
+	assert.ErrorIs(t, err, ErrImportEmpty)
+
+	assert.True(t, IsReqIFFile("export.ReqIF"))
+	assert.True(t, IsReqIFFile("export.xml"))
+	assert.False(t, IsReqIFFile("export.csv"))
+}
+
+// TestImportReqIF imports a ReqIF document as the import wizard does: the document is read, the template's variant is suggested,
+// the requirements are validated with the default mapping and the valid requirements are stored.
+func TestImportReqIF(t *testing.T) {
+	ctx := context.Background()
+	bt := importTestTemplate()
+	other := &BasicTemplate{
+		ID:      "other",
+		Name:    "Other",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"system":  {Name: "System", Type: "placeholder"},
+			"must":    {Name: "Must", Type: "equals", Value: "must"},
+			"process": {Name: "Process", Type: "placeholder"},
+		},
+		Variants: map[string]BasicVariant{
+			"must": {Name: "Must", Rules: []string{"system", "must", "process"}},
+		},
+	}
+	templateID := uuid.New()
+
+	table, err := ReadImportReqIF("machine.reqif", bytes.NewReader(importTestReqIF(t)))
+	require.NoError(t, err)
+
+	suggestions, err := SuggestImportVariants(ctx, RuleParsers(), table, SentenceColumn(table.Header), map[string]*BasicTemplate{
+		templateID.String(): bt,
+		uuid.NewString():    other,
+	})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1, "variants without matches are not suggested")
+	assert.Equal(t, templateID.String(), suggestions[0].TemplateID)
+	assert.Equal(t, "default", suggestions[0].Variant)
+	assert.Equal(t, "Default", suggestions[0].VariantName())
+	assert.Equal(t, 2, suggestions[0].Matches)
+	assert.Equal(t, 3, suggestions[0].Sample)
+
+	mapping := DefaultImportMapping(bt, suggestions[0].Variant, table.Header)
+	assert.Equal(t, 2, mapping.SentenceColumn)
+	assert.Equal(t, 3, mapping.TagsColumn)
+	assert.Empty(t, mapping.Mapped(bt))
+
+	rows, err := ValidateImport(ctx, bt, RuleParsers(), table, mapping)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, map[string]string{"system": "The coffee machine", "modal": "shall", "process": "brew coffee"}, rows[0].Segments)
+	assert.Equal(t, []string{"brewing"}, rows[0].Tags)
+	assert.False(t, rows[2].Result.Ok())
+
+	repository := &importRequirementRepository{}
+	userID, projectID := uuid.New(), uuid.New()
+	imported, err := ImportRequirements(ctx, bt, templateID, mapping, rows, userID, projectID, repository, trans.NewTranslator())
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported)
+
+	require.Len(t, repository.saved, 2)
+	saved := repository.saved[0]
+	assert.Equal(t, templateID, saved.TemplateID)
+	assert.Equal(t, "Default", saved.Variant)
+	assert.Equal(t, "The coffee machine shall brew coffee", saved.Text)
+	assert.Equal(t, userID, saved.CreatedBy)
+	assert.Equal(t, &projectID, saved.ProjectID)
+	assert.Contains(t, saved.Tags, requirement.Tag{Name: "brewing"})
+	assert.Equal(t, []requirement.Segment{
+		{Rule: "System", Value: "The coffee machine"},
+		{Rule: "Modal", Value: "shall"},
+		{Rule: "Process", Value: "brew coffee"},
+	}, saved.Segments)
+	assert.Equal(t, "The coffee machine should heat the milk", repository.saved[1].Text)
+	assert.Equal(t, &projectID, repository.saved[1].ProjectID)
+
+	_, err = ImportRequirements(ctx, bt, templateID, ImportMapping{Variant: "unknown"}, rows, userID, uuid.Nil, repository, trans.NewTranslator())
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+}
+
+// importRequirementRepository is a requirement.Repository recording the saved requirements.
+type importRequirementRepository struct {
+	requirement.Repository
+	saved []*requirement.ToSave
+}
+
+func (r *importRequirementRepository) Save(ctx context.Context, toSave *requirement.ToSave) (*requirement.Requirement, error) {
+	r.saved = append(r.saved, toSave)
+
+	return &requirement.Requirement{ID: toSave.ID}, nil
+}
+
+// importTestReqIF returns a ReqIF document with a heading and three requirements of which the last one is no valid requirement of importTestTemplate.
+func importTestReqIF(t *testing.T) []byte {
+	var buf bytes.Buffer
+	err := reqif.Write(&buf, &reqif.Document{
+		Title:      "Coffee Machine",
+		Attributes: []string{reqif.ForeignIDAttribute, reqif.ChapterNameAttribute, reqif.TextAttribute, "Tags"},
+		Objects: []*reqif.SpecObject{
+			{Values: map[string]string{reqif.ChapterNameAttribute: "Brewing"}},
+			{Values: map[string]string{reqif.ForeignIDAttribute: "REQ-1", reqif.TextAttribute: "The coffee machine shall brew coffee", "Tags": "brewing"}},
+			{Values: map[string]string{reqif.ForeignIDAttribute: "REQ-2", reqif.TextAttribute: "The coffee machine should heat the milk"}},
+			{Values: map[string]string{reqif.ForeignIDAttribute: "REQ-3", reqif.TextAttribute: "The coffee machine could grind beans"}},
+		},
+	})
+	require.NoError(t, err)
+
+	return buf.Bytes()
+}
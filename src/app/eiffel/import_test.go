@@ -0,0 +1,130 @@
+package eiffel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestReadImportCSV(t *testing.T) {
+	table, err := ReadImportCSV("backlog.csv", strings.NewReader("\xef\xbb\xbfSystem;Modal;Process\n The system ;shall;log\n\n;;\nThe app;should;\"sync, fast\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "backlog.csv", table.Name)
+	assert.Equal(t, ';', table.Comma)
+	assert.Equal(t, []string{"System", "Modal", "Process"}, table.Header)
+	assert.Equal(t, [][]string{{"The system", "shall", "log"}, {"The app", "should", "sync, fast"}}, table.Rows)
+	assert.Equal(t, []int{2, 5}, table.Lines)
+
+	table, err = ReadImportCSV("short.csv", strings.NewReader("a,b,c\n1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, ',', table.Comma)
+	assert.Equal(t, [][]string{{"1", "", ""}}, table.Rows)
+
+	table, err = ReadImportCSV("tabs.csv", strings.NewReader("a\tb\n1\t2"))
+	require.NoError(t, err)
+	assert.Equal(t, '\t', table.Comma)
+
+	_, err = ReadImportCSV("empty.csv", strings.NewReader("a,b\n\n"))
+	assert.ErrorIs(t, err, ErrImportEmpty)
+
+	_, err = ReadImportCSV("invalid.csv", strings.NewReader("a,b\n\"unterminated,1"))
+	assert.ErrorIs(t, err, ErrImportInvalidCSV)
+
+	var tooMany strings.Builder
+	tooMany.WriteString("a\n")
+	for i := 0; i <= MaxImportRows; i++ {
+		tooMany.WriteString(fmt.Sprintf("%d\n", i))
+	}
+	_, err = ReadImportCSV("large.csv", strings.NewReader(tooMany.String()))
+	assert.ErrorIs(t, err, ErrImportTooManyRows)
+}
+
+func TestDefaultImportMapping(t *testing.T) {
+	mapping := DefaultImportMapping(importTestTemplate(), "default", []string{"Process", "MODAL", "Notes", "Tags", "system"})
+
+	assert.Equal(t, "default", mapping.Variant)
+	assert.Equal(t, map[string]int{"system": 4, "modal": 1, "process": 0}, mapping.Columns)
+	assert.Equal(t, 3, mapping.TagsColumn)
+	assert.Equal(t, []string{"system", "modal", "process"}, mapping.Mapped(importTestTemplate()))
+
+	mapping = DefaultImportMapping(importTestTemplate(), "default", []string{"Notes"})
+	assert.Empty(t, mapping.Columns)
+	assert.Equal(t, -1, mapping.TagsColumn)
+	assert.Empty(t, mapping.Mapped(importTestTemplate()))
+}
+
+func TestValidateImport(t *testing.T) {
+	bt := importTestTemplate()
+	table := &ImportTable{
+		Header: []string{"System", "Modal", "Process", "Tags"},
+		Rows: [][]string{
+			{"The system", "shall", "log every access", "security, audit"},
+			{"The system", "could", "log every access", ""},
+		},
+		Lines: []int{2, 4},
+	}
+	mapping := ImportMapping{Variant: "default", Columns: map[string]int{"system": 0, "modal": 1, "process": 2}, TagsColumn: 3}
+
+	rows, err := ValidateImport(context.Background(), bt, RuleParsers(), table, mapping)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.True(t, rows[0].Result.Ok())
+	assert.Equal(t, map[string]string{"system": "The system", "modal": "shall", "process": "log every access"}, rows[0].Segments)
+	assert.Equal(t, []string{"security", "audit"}, rows[0].Tags)
+
+	assert.Equal(t, 4, rows[1].Line)
+	assert.False(t, rows[1].Result.Ok())
+	assert.Empty(t, rows[1].Tags)
+
+	valid, invalid := ImportSummary(rows)
+	assert.Equal(t, 1, valid)
+	assert.Equal(t, 1, invalid)
+
+	_, err = ValidateImport(context.Background(), bt, RuleParsers(), table, ImportMapping{Variant: "unknown", TagsColumn: -1})
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+}
+
+func TestWriteImportReport(t *testing.T) {
+	table := &ImportTable{Comma: ';', Header: []string{"System", "Modal"}}
+	rows := []*ImportRow{
+		{Line: 2, Values: []string{"The system", "shall"}, Result: parser.ParsingResult{Requirement: "The system shall"}},
+		{Line: 3, Values: []string{"The system", "could"}, Result: parser.ParsingResult{Errors: []parser.ParsingLog{
+			{Level: parser.ParsingLogLevelError, Message: "eiffel.import.test.modal"},
+			{Level: parser.ParsingLogLevelError, Message: "eiffel.import.test.process"},
+		}}},
+	}
+	translator := trans.NewTranslator(trans.WithTranslations(map[string]string{
+		"eiffel.import.report.line":   "Line",
+		"eiffel.import.report.errors": "Errors",
+		"eiffel.import.test.modal":    "Invalid modal",
+		"eiffel.import.test.process":  "Missing process",
+	}))
+
+	var report bytes.Buffer
+	require.NoError(t, WriteImportReport(&report, table, rows, translator))
+	assert.Equal(t, "Line;System;Modal;Errors\n3;The system;could;\"Invalid modal; Missing process\"\n", report.String())
+}
+
+func importTestTemplate() *BasicTemplate {
+	return &BasicTemplate{
+		ID:      "import",
+		Name:    "Import",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"system":  {Name: "System", Type: "placeholder"},
+			"modal":   {Name: "Modal", Type: "equalsAny", Value: []any{"shall", "should", "will"}},
+			"process": {Name: "Process", Type: "placeholder"},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {Name: "Default", Rules: []string{"system", "modal", "process"}},
+		},
+	}
+}
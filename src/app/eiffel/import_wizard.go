@@ -0,0 +1,536 @@
+package eiffel
+
+import (
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/project"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ImportWizardName is the name of the wizard importing requirements from a CSV file.
+	ImportWizardName = "eiffel-import"
+	// ImportWizardRoute is the base route of the import wizard.
+	ImportWizardRoute = "/eiffel/import"
+	// MaxImportPreview is the maximum number of valid requirements previewed before they are imported.
+	MaxImportPreview = 10
+	// importUploadStep is the step choosing the template and uploading the CSV file.
+	importUploadStep = "upload"
+	// importMappingStep is the step choosing the variant and mapping its rules to the columns of the CSV file.
+	importMappingStep = "mapping"
+	// importReviewStep is the step reviewing the validated rows before the valid rows are imported.
+	importReviewStep = "review"
+	// importColumnPrefix is the prefix of the form values of the mapped columns in the mapping step, the rule's key is appended.
+	importColumnPrefix = "column-"
+)
+
+var (
+	// ErrImportNoFile is displayed to the user if no CSV file was uploaded.
+	ErrImportNoFile = errors.New("eiffel.import.error.no-file")
+	// ErrImportTooLarge is displayed to the user if the uploaded CSV file exceeds the request body limit.
+	ErrImportTooLarge = errors.New("eiffel.import.error.too-large")
+	// ErrImportNoMapping is displayed to the user if none of the variant's rules is mapped to a column.
+	ErrImportNoMapping = errors.New("eiffel.import.error.no-mapping")
+	// ErrImportNothingValid is displayed to the user if none of the rows is a valid requirement.
+	ErrImportNothingValid = errors.New("eiffel.import.error.nothing-valid")
+	// ErrImportIncomplete is displayed to the user if a previous step of the import wizard is missing its values.
+	ErrImportIncomplete = errors.New("eiffel.import.error.incomplete")
+	// ErrImportNoSuggestion is displayed to the user if no template was chosen and none of the user's templates matches the requirements of the file.
+	ErrImportNoSuggestion = errors.New("eiffel.import.error.no-suggestion")
+)
+
+// ImportUpload is the value of the upload step of the import wizard: the template and the uploaded CSV or ReqIF file.
+type ImportUpload struct {
+	TemplateID string
+	Table      *ImportTable
+}
+
+// ImportUploadData is the data of the upload step. Templates are the user's EIFFEL templates to choose from.
+// MaxRows is the maximum number of rows of the CSV file (MaxImportRows).
+type ImportUploadData struct {
+	Templates []*template.Template
+	Upload    ImportUpload
+	MaxRows   int
+}
+
+// ImportMappingData is the data of the mapping step. Variants and Rules are the keys of the template's variants and rules sorted alphabetically.
+// Suggestions are the template's variants matching the sentences of the mapping's sentence column, see SuggestImportVariants.
+type ImportMappingData struct {
+	Template    *BasicTemplate
+	Table       *ImportTable
+	Mapping     ImportMapping
+	Variants    []string
+	Rules       []string
+	Suggestions []ImportSuggestion
+}
+
+// ImportReviewData is the data of the review step. Rows are the validated rows of the CSV file, Valid and Invalid count them.
+type ImportReviewData struct {
+	Template  *BasicTemplate
+	Variant   BasicVariant
+	Table     *ImportTable
+	Rows      []*ImportRow
+	Valid     int
+	Invalid   int
+	ReportURL string
+}
+
+// importState is the state of the import wizard read from the values of the upload and mapping step.
+type importState struct {
+	upload   ImportUpload
+	formData TemplateFormData
+	mapping  ImportMapping
+}
+
+// ImportWizard returns the wizard importing requirements from a CSV file or a ReqIF document (see ReadImportReqIF). The user chooses
+// an EIFFEL template and uploads the file (1), maps the rules of a variant to the file's columns (2) and reviews the validated rows (3)
+// before the valid rows are stored as requirements in the user's current project. If the user chooses no template, the template and
+// variant matching most of the file's requirement sentences are suggested (see SuggestImportVariants). The invalid rows can be downloaded as error report, see importReport.
+// The rows are parsed without the language checker (see NewLanguageToolChecker) as checking each row would slow down the import.
+// The wizard is owned by the logged-in user and must therefore be registered on a router requiring a logged-in user.
+func ImportWizard(cfg Cfg, appCtx *hctx.AppCtx) *web.Wizard {
+	return &web.Wizard{
+		Name:  ImportWizardName,
+		Route: ImportWizardRoute,
+		Title: "eiffel.import.title",
+		Steps: []web.WizardStep{
+			{
+				Name:  importUploadStep,
+				Title: "eiffel.import.upload.title",
+				Render: func(io web.IO, page *web.WizardPage) error {
+					if page.Form == nil {
+						data, err := importUploadData(io, web.WizardValue(page.Session, importUploadStep, ImportUpload{}))
+						if err != nil {
+							return io.Error(web.ErrInternal, err)
+						}
+						page.Form = data
+					}
+
+					return renderImportWizardStep(io, page, "eiffel/_import-step-upload.go.html")
+				},
+				Submit: func(io web.IO, page *web.WizardPage) error {
+					previous := web.WizardValue(page.Session, importUploadStep, ImportUpload{})
+					upload := ImportUpload{TemplateID: io.Request().FormValue("template-id"), Table: previous.Table}
+
+					data, err := importUploadData(io, upload)
+					if err != nil {
+						return err
+					}
+					page.Form = data
+
+					table, err := importTableFromRequest(io.Request())
+					if errors.Is(err, ErrImportNoFile) && previous.Table != nil {
+						table, err = previous.Table, nil
+					}
+					if err != nil {
+						page.ViolationsFromErrors(err)
+						return nil
+					}
+					data.Upload.Table = table
+
+					suggestion, err := suggestImportTemplate(io, cfg, appCtx, data)
+					if err != nil {
+						return err
+					}
+					if data.Upload.TemplateID == "" {
+						if suggestion == nil {
+							page.ViolationsFromErrors(ErrImportNoSuggestion)
+							return nil
+						}
+						data.Upload.TemplateID = suggestion.TemplateID
+					}
+
+					formData, err := TemplateFormFromRequest(io.Context(), data.Upload.TemplateID, "", importTemplateRepository(io), ruleParsersFor(cfg), appCtx.Validator, true)
+					if err != nil {
+						page.ViolationsFromErrors(err)
+						return nil
+					}
+
+					if table != previous.Table || data.Upload.TemplateID != previous.TemplateID {
+						variant := formData.VariantKey
+						if suggestion != nil {
+							variant = suggestion.Variant
+						}
+
+						err = page.Session.SetValue(importMappingStep, DefaultImportMapping(formData.Template, variant, table.Header))
+						if err != nil {
+							return err
+						}
+					}
+
+					return page.Session.SetValue(importUploadStep, data.Upload)
+				},
+			},
+			{
+				Name:  importMappingStep,
+				Title: "eiffel.import.mapping.title",
+				Render: func(io web.IO, page *web.WizardPage) error {
+					if page.Form == nil {
+						state, err := readImportState(io, cfg, appCtx, page.Session)
+						if err != nil {
+							return io.Error(err)
+						}
+
+						data, err := importMappingData(io, cfg, state)
+						if err != nil {
+							return io.Error(web.ErrInternal, err)
+						}
+						page.Form = data
+					}
+
+					return renderImportWizardStep(io, page, "eiffel/_import-step-mapping.go.html")
+				},
+				Submit: func(io web.IO, page *web.WizardPage) error {
+					state, err := readImportState(io, cfg, appCtx, page.Session)
+					if err != nil {
+						page.ViolationsFromErrors(err)
+						return nil
+					}
+
+					state.mapping = importMappingFromRequest(io.Request(), state.formData.Template, state.upload.Table)
+					data, err := importMappingData(io, cfg, state)
+					if err != nil {
+						return err
+					}
+					page.Form = data
+
+					if _, ok := state.formData.Template.Variants[state.mapping.Variant]; !ok {
+						page.ViolationsFromErrors(ErrTemplateVariantNotFound)
+						return nil
+					}
+					if len(state.mapping.Mapped(state.formData.Template)) == 0 && state.mapping.SentenceColumn < 0 {
+						page.ViolationsFromErrors(ErrImportNoMapping)
+						return nil
+					}
+
+					return page.Session.SetValue(importMappingStep, state.mapping)
+				},
+			},
+			{
+				Name:  importReviewStep,
+				Title: "eiffel.import.review.title",
+				Render: func(io web.IO, page *web.WizardPage) error {
+					if page.Form == nil {
+						data, err := importReviewData(io, cfg, appCtx, page.Session)
+						if err != nil {
+							return io.Error(err)
+						}
+						page.Form = data
+					}
+
+					return renderImportWizardStep(io, page, "eiffel/_import-step-review.go.html")
+				},
+				Submit: func(io web.IO, page *web.WizardPage) error {
+					data, err := importReviewData(io, cfg, appCtx, page.Session)
+					if err != nil {
+						page.ViolationsFromErrors(err)
+						return nil
+					}
+					page.Form = data
+
+					if data.Valid == 0 {
+						page.ViolationsFromErrors(ErrImportNothingValid)
+					}
+
+					return nil
+				},
+			},
+		},
+		Owner: func(io web.IO) string {
+			return user.MustFromIO(io).ID.String()
+		},
+		Complete: func(io web.IO, session *web.WizardSession) (string, error) {
+			return completeImportWizard(io, cfg, appCtx, session)
+		},
+		CancelURL: "/requirement",
+	}
+}
+
+// InvalidRows returns the rows that are no valid requirements.
+func (d *ImportReviewData) InvalidRows() []*ImportRow {
+	var rows []*ImportRow
+	for _, row := range d.Rows {
+		if !row.Result.Ok() {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// Preview returns the first valid rows up to MaxImportPreview.
+func (d *ImportReviewData) Preview() []*ImportRow {
+	var rows []*ImportRow
+	for _, row := range d.Rows {
+		if row.Result.Ok() && len(rows) < MaxImportPreview {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// Column returns the column the rule is mapped to or -1 if the rule is not mapped.
+func (d *ImportMappingData) Column(rule string) int {
+	if column, ok := d.Mapping.Columns[rule]; ok {
+		return column
+	}
+
+	return -1
+}
+
+// UsedByVariant returns true if the rule is used by the mapping's variant. Columns of other rules are not imported.
+func (d *ImportMappingData) UsedByVariant(rule string) bool {
+	for _, r := range d.Template.Variants[d.Mapping.Variant].Rules {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// completeImportWizard stores the valid rows as requirements in the user's current project. It returns the URL of the requirements list.
+func completeImportWizard(io web.IO, cfg Cfg, appCtx *hctx.AppCtx, session *web.WizardSession) (string, error) {
+	ctx := io.Context()
+	usr := user.MustFromIO(io)
+
+	state, err := readImportState(io, cfg, appCtx, session)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := ValidateImport(ctx, state.formData.Template, ruleParsersFor(cfg), state.upload.Table, state.mapping)
+	if err != nil {
+		return "", err
+	}
+
+	projectID := project.CurrentID(
+		ctx,
+		web.MustRepository[user.PreferenceRepository](io, user.PreferenceRepositoryName),
+		web.MustRepository[project.Repository](io, project.RepositoryName),
+		usr.ID,
+	)
+	requirementRepository := web.MustRepository[requirement.Repository](io, requirement.RepositoryName)
+
+	_, err = ImportRequirements(ctx, state.formData.Template, state.formData.TemplateID, state.mapping, rows, usr.ID, projectID, requirementRepository, io.Translator())
+	if err != nil {
+		return "", err
+	}
+
+	return "/requirement", nil
+}
+
+// importReport downloads the invalid rows of the import wizard's CSV file with their errors, see WriteImportReport.
+func importReport(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx, wizard *web.Wizard) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		session, err := wizard.SessionFromParams(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		data, err := importReviewData(io, cfg, appCtx, session)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		name := strings.TrimSuffix(data.Table.Name, ".csv")
+		if name == "" {
+			name = "requirements"
+		}
+
+		response := io.Response()
+		response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"-errors.csv"))
+
+		return WriteImportReport(response, data.Table, data.Rows, io.Translator())
+	})
+}
+
+// readImportState reads the template and the CSV file of the upload step and the mapping of the mapping step.
+// It returns ErrImportIncomplete if a step is missing its values or the template is no longer accessible.
+func readImportState(io web.IO, cfg Cfg, appCtx *hctx.AppCtx, session *web.WizardSession) (*importState, error) {
+	upload := web.WizardValue(session, importUploadStep, ImportUpload{})
+	mapping := web.WizardValue[*ImportMapping](session, importMappingStep, nil)
+	if upload.Table == nil || mapping == nil {
+		return nil, ErrImportIncomplete
+	}
+
+	formData, err := TemplateFormFromRequest(io.Context(), upload.TemplateID, "", importTemplateRepository(io), ruleParsersFor(cfg), appCtx.Validator, true)
+	if err != nil {
+		return nil, errors.Join(ErrImportIncomplete, err)
+	}
+
+	return &importState{upload: upload, formData: formData, mapping: *mapping}, nil
+}
+
+// importUploadData returns the data of the upload step listing the user's EIFFEL templates.
+func importUploadData(io web.IO, upload ImportUpload) (*ImportUploadData, error) {
+	templates, err := importTemplateRepository(io).FindByQueryForTypeAndUser(io.Context(), template.SearchFilter{}, BasicTemplateType, user.MustFromIO(io))
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, err
+	}
+
+	return &ImportUploadData{Templates: templates, Upload: upload, MaxRows: MaxImportRows}, nil
+}
+
+// importMappingData returns the data of the mapping step from the import's state suggesting the template's variants matching the sentences.
+func importMappingData(io web.IO, cfg Cfg, state *importState) (*ImportMappingData, error) {
+	variants := make([]string, 0, len(state.formData.Template.Variants))
+	for key := range state.formData.Template.Variants {
+		variants = append(variants, key)
+	}
+	sort.Strings(variants)
+
+	suggestions, err := SuggestImportVariants(io.Context(), ruleParsersFor(cfg), state.upload.Table, state.mapping.SentenceColumn,
+		map[string]*BasicTemplate{state.upload.TemplateID: state.formData.Template})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImportMappingData{
+		Template:    state.formData.Template,
+		Table:       state.upload.Table,
+		Mapping:     state.mapping,
+		Variants:    variants,
+		Rules:       sortedRuleKeys(state.formData.Template),
+		Suggestions: suggestions,
+	}, nil
+}
+
+// suggestImportTemplate returns the variant matching most of the sentences of the uploaded file, see SuggestImportVariants.
+// Only the variants of the chosen template are suggested, all templates of the upload step are considered if no template was chosen.
+// Templates that are invalid or not accessible are skipped. It returns nil if the file has no sentence column or no variant matches.
+func suggestImportTemplate(io web.IO, cfg Cfg, appCtx *hctx.AppCtx, data *ImportUploadData) (*ImportSuggestion, error) {
+	column := SentenceColumn(data.Upload.Table.Header)
+	if column < 0 {
+		return nil, nil
+	}
+
+	ids := []string{data.Upload.TemplateID}
+	if data.Upload.TemplateID == "" {
+		ids = make([]string, 0, len(data.Templates))
+		for _, tmpl := range data.Templates {
+			ids = append(ids, tmpl.ID.String())
+		}
+	}
+
+	templates := make(map[string]*BasicTemplate, len(ids))
+	for _, id := range ids {
+		formData, err := TemplateFormFromRequest(io.Context(), id, "", importTemplateRepository(io), ruleParsersFor(cfg), appCtx.Validator, true)
+		if err != nil {
+			continue
+		}
+		templates[id] = formData.Template
+	}
+
+	suggestions, err := SuggestImportVariants(io.Context(), ruleParsersFor(cfg), data.Upload.Table, column, templates)
+	if err != nil || len(suggestions) == 0 {
+		return nil, err
+	}
+
+	return &suggestions[0], nil
+}
+
+// importReviewData validates the rows of the CSV file with the mapping and returns the data of the review step.
+func importReviewData(io web.IO, cfg Cfg, appCtx *hctx.AppCtx, session *web.WizardSession) (*ImportReviewData, error) {
+	state, err := readImportState(io, cfg, appCtx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ValidateImport(io.Context(), state.formData.Template, ruleParsersFor(cfg), state.upload.Table, state.mapping)
+	if err != nil {
+		return nil, errors.Join(ErrImportIncomplete, err)
+	}
+
+	valid, invalid := ImportSummary(rows)
+
+	return &ImportReviewData{
+		Template:  state.formData.Template,
+		Variant:   state.formData.Template.Variants[state.mapping.Variant],
+		Table:     state.upload.Table,
+		Rows:      rows,
+		Valid:     valid,
+		Invalid:   invalid,
+		ReportURL: ImportWizardRoute + "/" + session.ID.String() + "/report",
+	}, nil
+}
+
+// importTableFromRequest reads the uploaded CSV file or ReqIF document (see IsReqIFFile) of the upload step. The returned errors are safe
+// to display to the user except for unexpected errors reading the file, which are wrapped in ErrImportInvalidCSV.
+func importTableFromRequest(request *http.Request) (*ImportTable, error) {
+	file, header, err := request.FormFile("file")
+	if web.IsBodyTooLarge(err) {
+		return nil, ErrImportTooLarge
+	}
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, ErrImportNoFile
+	}
+	if err != nil {
+		return nil, errors.Join(ErrImportInvalidCSV, err)
+	}
+	defer file.Close()
+
+	if IsReqIFFile(header.Filename) {
+		table, err := ReadImportReqIF(header.Filename, file)
+		if errors.Is(err, ErrImportInvalidReqIF) {
+			return nil, ErrImportInvalidReqIF
+		}
+
+		return table, err
+	}
+
+	table, err := ReadImportCSV(header.Filename, file)
+	if errors.Is(err, ErrImportInvalidCSV) {
+		return nil, ErrImportInvalidCSV
+	}
+
+	return table, err
+}
+
+// importMappingFromRequest reads the variant, the columns of the template's rules, the tags column and the sentence column of the mapping step.
+// Columns outside the table's header are not mapped.
+func importMappingFromRequest(request *http.Request, bt *BasicTemplate, table *ImportTable) ImportMapping {
+	column := func(name string) int {
+		i, err := strconv.Atoi(request.FormValue(name))
+		if err != nil || i < 0 || i >= len(table.Header) {
+			return -1
+		}
+
+		return i
+	}
+
+	mapping := ImportMapping{
+		Variant:        request.FormValue("variant"),
+		Columns:        make(map[string]int),
+		TagsColumn:     column("tags-column"),
+		SentenceColumn: column("sentence-column"),
+	}
+	for key := range bt.Rules {
+		if i := column(importColumnPrefix + key); i >= 0 {
+			mapping.Columns[key] = i
+		}
+	}
+
+	return mapping
+}
+
+// importTemplateRepository returns the template repository of the request.
+func importTemplateRepository(io web.IO) template.Repository {
+	return web.MustRepository[template.Repository](io, template.RepositoryName)
+}
+
+// renderImportWizardStep renders the step's template on the wizard page.
+func renderImportWizardStep(io web.IO, page *web.WizardPage, path string) error {
+	return io.Render(page, "eiffel.wizard.page", "eiffel/wizard-page.go.html", path)
+}
@@ -0,0 +1,208 @@
+package eiffel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/util"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// maxLanguageToolResponseSize is the maximum size in bytes of a response of the LanguageTool API read by the LanguageToolChecker.
+const maxLanguageToolResponseSize = 1 << 20
+
+// languageCheckContextKey is the context key under which the template's opt-in to the language check is stored during parsing.
+const languageCheckContextKey = "eiffel.languageCheck"
+
+var (
+	// ErrLanguageCheckFailed is returned by the LanguageToolChecker if the LanguageTool API responded with an unexpected status code.
+	ErrLanguageCheckFailed = errors.New("eiffel.parser.placeholder.language-check-failed")
+	// ErrLanguageCheckResponseTooLarge is returned by the LanguageToolChecker if the response exceeds maxLanguageToolResponseSize.
+	ErrLanguageCheckResponseTooLarge = errors.New("eiffel.parser.placeholder.language-check-response-too-large")
+)
+
+// LanguageToolCfg is the configuration for the optional integration of a LanguageTool-compatible API.
+// The integration is disabled by default. If enabled, placeholder segments are checked for spelling and grammar issues
+// if the rule or the template opts in through the 'languageCheck' extra property.
+type LanguageToolCfg struct {
+	Enabled bool `toml:"enabled" env:"EIFFEL_LANGUAGE_TOOL_ENABLED"`
+	// URL is the base URL of the LanguageTool-compatible API, e.g. https://api.languagetool.org.
+	// The check endpoint (/v2/check) is appended to the URL.
+	URL string `toml:"url" env:"EIFFEL_LANGUAGE_TOOL_URL"`
+	// Language is the language passed to the API if the user's locale could not be determined. Defaults to "auto".
+	Language string `toml:"language" env:"EIFFEL_LANGUAGE_TOOL_LANGUAGE"`
+	// Timeout is the timeout in seconds for a single check. Defaults to 5 seconds.
+	Timeout int `toml:"timeout" env:"EIFFEL_LANGUAGE_TOOL_TIMEOUT"`
+}
+
+// LanguageChecker checks a text for spelling and grammar issues.
+type LanguageChecker interface {
+	// Check checks the text in the given language and returns all issues found.
+	Check(ctx context.Context, text string, language string) ([]LanguageIssue, error)
+}
+
+// LanguageIssue is a spelling or grammar issue found by a LanguageChecker.
+type LanguageIssue struct {
+	// Message is a human-readable description of the issue. It is already in the checked language.
+	Message string
+	// Offset is the offset of the issue in the checked text counted in characters (runes).
+	Offset int
+	// Length is the length of the issue in the checked text counted in characters (runes).
+	Length int
+	// Replacements are suggested replacements for the offending part of the text.
+	Replacements []string
+	// RuleID is the ID of the checker's rule that found the issue.
+	RuleID string
+}
+
+// LanguageToolChecker is a LanguageChecker using a LanguageTool-compatible HTTP API.
+type LanguageToolChecker struct {
+	url      string
+	language string
+	client   *http.Client
+}
+
+// languageToolResponse is the relevant part of the response of the LanguageTool /v2/check endpoint.
+type languageToolResponse struct {
+	Matches []struct {
+		Message      string `json:"message"`
+		Offset       int    `json:"offset"`
+		Length       int    `json:"length"`
+		Replacements []struct {
+			Value string `json:"value"`
+		} `json:"replacements"`
+		Rule struct {
+			ID string `json:"id"`
+		} `json:"rule"`
+	} `json:"matches"`
+}
+
+// NewLanguageToolChecker constructs a new LanguageToolChecker from the config.
+// If the integration is disabled nil is returned, which is safe to pass to the PlaceholderRuleParser.
+func NewLanguageToolChecker(cfg LanguageToolCfg) LanguageChecker {
+	if !cfg.Enabled || cfg.URL == "" {
+		return nil
+	}
+
+	language := cfg.Language
+	if language == "" {
+		language = "auto"
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5
+	}
+
+	return &LanguageToolChecker{
+		url:      strings.TrimSuffix(cfg.URL, "/") + "/v2/check",
+		language: language,
+		client:   &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// Check implements the LanguageChecker interface. If no language is passed in the configured fallback language is used.
+// The API counts offsets in UTF-16 code units, they are converted to runes, see LanguageIssue.
+func (c *LanguageToolChecker) Check(ctx context.Context, text string, language string) ([]LanguageIssue, error) {
+	if language == "" {
+		language = c.language
+	}
+
+	form := url.Values{}
+	form.Set("text", text)
+	form.Set("language", language)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	response, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrLanguageCheckFailed, response.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxLanguageToolResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxLanguageToolResponseSize {
+		return nil, ErrLanguageCheckResponseTooLarge
+	}
+
+	var checkResponse languageToolResponse
+	err = json.Unmarshal(content, &checkResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	units := utf16.Encode([]rune(text))
+	issues := make([]LanguageIssue, 0, len(checkResponse.Matches))
+	for _, match := range checkResponse.Matches {
+		replacements := make([]string, 0, len(match.Replacements))
+		for _, replacement := range match.Replacements {
+			replacements = append(replacements, replacement.Value)
+		}
+
+		offset, end := utf16ToRuneOffset(units, match.Offset), utf16ToRuneOffset(units, match.Offset+match.Length)
+		issues = append(issues, LanguageIssue{
+			Message:      match.Message,
+			Offset:       offset,
+			Length:       end - offset,
+			Replacements: replacements,
+			RuleID:       match.Rule.ID,
+		})
+	}
+
+	return issues, nil
+}
+
+// utf16ToRuneOffset converts an offset in UTF-16 code units of the encoded text to an offset in runes.
+// Offsets are clamped to the text, an offset within a surrogate pair is rounded up to the next rune.
+func utf16ToRuneOffset(units []uint16, offset int) int {
+	offset = max(0, min(offset, len(units)))
+
+	runes := 0
+	for i := 0; i < offset; i++ {
+		if utf16.IsSurrogate(rune(units[i])) && i+1 < len(units) {
+			i++
+		}
+		runes++
+	}
+
+	return runes
+}
+
+// withLanguageCheck adds the template's opt-in to the language check through the 'languageCheck' extra property to the context.
+// It is called by BasicTemplate.Parse and BasicTemplate.ParseSegment, see PlaceholderRuleParser.
+func withLanguageCheck(ctx context.Context, bt *BasicTemplate) context.Context {
+	languageCheck, _ := bt.Extra["languageCheck"].(bool)
+
+	return context.WithValue(ctx, languageCheckContextKey, languageCheck)
+}
+
+// languageCheckEnabled returns true if the rule opted in to the language check through the 'languageCheck' extra property.
+// If the rule does not set the property the template's opt-in is used, see withLanguageCheck.
+func languageCheckEnabled(ctx context.Context, rule BasicRule) bool {
+	if languageCheck, ok := rule.Extra["languageCheck"].(bool); ok {
+		return languageCheck
+	}
+
+	languageCheck, _ := util.CtxValue[bool](ctx, languageCheckContextKey)
+
+	return languageCheck
+}
@@ -0,0 +1,144 @@
+package eiffel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"unicode/utf16"
+)
+
+type languageCheckerMock struct {
+	issues []LanguageIssue
+	err    error
+}
+
+func (m languageCheckerMock) Check(ctx context.Context, text string, language string) ([]LanguageIssue, error) {
+	return m.issues, m.err
+}
+
+func TestLanguageToolChecker_Check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v2/check", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "Thiss is a test", r.FormValue("text"))
+		assert.Equal(t, "en", r.FormValue("language"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"matches":[{"message":"Possible spelling mistake found.","offset":0,"length":5,"replacements":[{"value":"This"},{"value":"Thus"}],"rule":{"id":"MORFOLOGIK_RULE_EN_US"}}]}`))
+	}))
+	defer server.Close()
+
+	checker := NewLanguageToolChecker(LanguageToolCfg{Enabled: true, URL: server.URL + "/"})
+	require.NotNil(t, checker)
+
+	issues, err := checker.Check(context.Background(), "Thiss is a test", "en")
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 0, issues[0].Offset)
+	assert.Equal(t, 5, issues[0].Length)
+	assert.Equal(t, []string{"This", "Thus"}, issues[0].Replacements)
+	assert.Equal(t, "MORFOLOGIK_RULE_EN_US", issues[0].RuleID)
+
+	assert.Nil(t, NewLanguageToolChecker(LanguageToolCfg{URL: server.URL}))
+}
+
+func TestLanguageToolChecker_CheckUTF16Offsets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// "🚀" is a single rune encoded as two UTF-16 code units, LanguageTool counts "Thiss" at offset 3.
+		_, _ = w.Write([]byte(`{"matches":[{"message":"Spelling","offset":3,"length":5,"rule":{"id":"SPELLING"}}]}`))
+	}))
+	defer server.Close()
+
+	issues, err := NewLanguageToolChecker(LanguageToolCfg{Enabled: true, URL: server.URL}).Check(context.Background(), "🚀 Thiss is a test", "en")
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 2, issues[0].Offset)
+	assert.Equal(t, 5, issues[0].Length)
+	assert.Equal(t, "Thiss", string([]rune("🚀 Thiss is a test")[issues[0].Offset:issues[0].Offset+issues[0].Length]))
+}
+
+func TestLanguageToolChecker_CheckResponseTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"matches":[],"padding":"`))
+		_, _ = w.Write(bytes.Repeat([]byte("a"), maxLanguageToolResponseSize))
+		_, _ = w.Write([]byte(`"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewLanguageToolChecker(LanguageToolCfg{Enabled: true, URL: server.URL}).Check(context.Background(), "Thiss is a test", "en")
+	assert.ErrorIs(t, err, ErrLanguageCheckResponseTooLarge)
+}
+
+func TestUTF16ToRuneOffset(t *testing.T) {
+	units := utf16.Encode([]rune("a🚀b"))
+	assert.Equal(t, 0, utf16ToRuneOffset(units, 0))
+	assert.Equal(t, 1, utf16ToRuneOffset(units, 1))
+	assert.Equal(t, 2, utf16ToRuneOffset(units, 2), "an offset within a surrogate pair is rounded up")
+	assert.Equal(t, 2, utf16ToRuneOffset(units, 3))
+	assert.Equal(t, 3, utf16ToRuneOffset(units, 4))
+	assert.Equal(t, 3, utf16ToRuneOffset(units, 10))
+	assert.Equal(t, 0, utf16ToRuneOffset(units, -1))
+}
+
+func TestPlaceholderRuleParser_LanguageCheck(t *testing.T) {
+	rule := BasicRule{Name: "Placeholder", Type: "placeholder", Extra: map[string]any{"languageCheck": true}}
+	segment := parser.ParsingSegment{Name: "placeholder", Value: "Thiss is ä test"}
+	checker := languageCheckerMock{issues: []LanguageIssue{{Message: "Spelling", Offset: 9, Length: 1, Replacements: []string{"a"}}}}
+
+	t.Run("issues are reported as notices", func(t *testing.T) {
+		logs, err := PlaceholderRuleParser{LanguageChecker: checker}.Parse(context.Background(), rule, segment)
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, parser.ParsingLogLevelNotice, logs[0].Level)
+		assert.Equal(t, []string{"message", "Spelling", "actual", "ä", "suggestions", "a"}, logs[0].TranslationArgs)
+		assert.Equal(t, []parser.ParsingRange{{Start: 9, End: 10, Level: parser.ParsingLogLevelNotice}}, logs[0].Ranges)
+	})
+
+	t.Run("no check without opt-in", func(t *testing.T) {
+		logs, err := PlaceholderRuleParser{LanguageChecker: checker}.Parse(context.Background(), BasicRule{Type: "placeholder"}, segment)
+		require.NoError(t, err)
+		assert.Len(t, logs, 0)
+	})
+
+	t.Run("failing check does not fail parsing", func(t *testing.T) {
+		logs, err := PlaceholderRuleParser{LanguageChecker: languageCheckerMock{err: errors.New("unavailable")}}.Parse(context.Background(), rule, segment)
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "eiffel.parser.placeholder.language-check-unavailable", logs[0].Message)
+	})
+
+	t.Run("template opt-in", func(t *testing.T) {
+		bt := &BasicTemplate{
+			Extra: map[string]any{"languageCheck": true},
+			Rules: map[string]BasicRule{
+				"placeholder": {Name: "Placeholder", Type: "placeholder"},
+				"opt-out":     {Name: "Opt-out", Type: "placeholder", Extra: map[string]any{"languageCheck": false}},
+			},
+			Variants: map[string]BasicVariant{"default": {Name: "Default", Rules: []string{"placeholder", "opt-out"}}},
+		}
+		parsers := RuleParsers()
+		parsers.Register("placeholder", PlaceholderRuleParser{LanguageChecker: checker})
+
+		logs, err := bt.ParseSegment(context.Background(), parsers, "default", segment)
+		require.NoError(t, err)
+		assert.Len(t, logs, 1)
+
+		logs, err = bt.ParseSegment(context.Background(), parsers, "default", parser.ParsingSegment{Name: "opt-out", Value: segment.Value})
+		require.NoError(t, err)
+		assert.Len(t, logs, 0, "the rule's property takes precedence")
+
+		assert.Equal(t, []error{ErrInvalidLanguageCheck}, (&BasicTemplate{Extra: map[string]any{"languageCheck": "yes"}}).validateExtra())
+	})
+
+	t.Run("invalid opt-in flag", func(t *testing.T) {
+		errs := PlaceholderRuleParser{}.Validate(validation.New(), BasicRule{Type: "placeholder", Extra: map[string]any{"languageCheck": "yes"}})
+		require.Len(t, errs, 1)
+	})
+}
@@ -0,0 +1,109 @@
+package eiffel
+
+import (
+	"github.com/org-harmony/harmony/src/core/trans"
+	"sort"
+	"strconv"
+)
+
+const (
+	// DefaultMaxConfigSize is the default maximum size of a template config in bytes (1 MB).
+	DefaultMaxConfigSize = 1 << 20
+	// DefaultMaxRules is the default maximum number of rules of a template.
+	DefaultMaxRules = 500
+	// DefaultMaxVariants is the default maximum number of variants of a template.
+	DefaultMaxVariants = 100
+	// DefaultMaxEqualsAnyValues is the default maximum number of values of an equalsAny rule.
+	DefaultMaxEqualsAnyValues = 5000
+)
+
+// LimitsCfg configures the limits of EIFFEL basic templates enforced by their validation (see SubscribeTemplateValidation).
+// The limits protect the parsing latency and the elicitation form from pathological templates. Limits of 0 use the defaults.
+type LimitsCfg struct {
+	// MaxConfigSize is the maximum size of a template config in bytes. Defaults to DefaultMaxConfigSize.
+	MaxConfigSize int `toml:"max_config_size"`
+	// MaxRules is the maximum number of rules of a template. Defaults to DefaultMaxRules.
+	MaxRules int `toml:"max_rules"`
+	// MaxVariants is the maximum number of variants of a template. Defaults to DefaultMaxVariants.
+	MaxVariants int `toml:"max_variants"`
+	// MaxEqualsAnyValues is the maximum number of values of an equalsAny rule per locale. Defaults to DefaultMaxEqualsAnyValues.
+	MaxEqualsAnyValues int `toml:"max_equals_any_values"`
+}
+
+// LimitExceededError is a validation error of a template exceeding one of the limits of the LimitsCfg.
+// Rule is the name of the rule exceeding a per rule limit, it is empty for limits of the whole template.
+type LimitExceededError struct {
+	Msg    string
+	Limit  int
+	Actual int
+	Rule   string
+}
+
+// ValidateConfigSize returns a LimitExceededError if the config exceeds the maximum config size.
+// The size is validated before the config is unmarshalled as large configs are expensive to unmarshal and validate.
+func (cfg LimitsCfg) ValidateConfigSize(config string) []error {
+	maxSize := orDefault(cfg.MaxConfigSize, DefaultMaxConfigSize)
+	if len(config) <= maxSize {
+		return nil
+	}
+
+	return []error{LimitExceededError{Msg: "eiffel.parser.error.limit.config-size", Limit: maxSize, Actual: len(config)}}
+}
+
+// ValidateLimits returns a LimitExceededError for each limit of the LimitsCfg the template exceeds.
+// The template is expected to be resolved (see ResolveExtends) as inherited rules and variants count towards the limits.
+func (bt *BasicTemplate) ValidateLimits(cfg LimitsCfg) []error {
+	var errs []error
+
+	if maxRules := orDefault(cfg.MaxRules, DefaultMaxRules); len(bt.Rules) > maxRules {
+		errs = append(errs, LimitExceededError{Msg: "eiffel.parser.error.limit.rules", Limit: maxRules, Actual: len(bt.Rules)})
+	}
+	if maxVariants := orDefault(cfg.MaxVariants, DefaultMaxVariants); len(bt.Variants) > maxVariants {
+		errs = append(errs, LimitExceededError{Msg: "eiffel.parser.error.limit.variants", Limit: maxVariants, Actual: len(bt.Variants)})
+	}
+
+	maxValues := orDefault(cfg.MaxEqualsAnyValues, DefaultMaxEqualsAnyValues)
+	ruleNames := make([]string, 0, len(bt.Rules))
+	for name := range bt.Rules {
+		ruleNames = append(ruleNames, name)
+	}
+	sort.Strings(ruleNames)
+
+	for _, name := range ruleNames {
+		rule := bt.Rules[name]
+		if rule.Type != "equalsAny" {
+			continue
+		}
+
+		for _, localizedRule := range rule.LocaleVariants() {
+			values, err := toStringSlice(localizedRule.Value)
+			if err != nil || len(values) <= maxValues {
+				continue
+			}
+
+			errs = append(errs, LimitExceededError{Msg: "eiffel.parser.error.limit.equals-any-values", Limit: maxValues, Actual: len(values), Rule: rule.Name})
+			break
+		}
+	}
+
+	return errs
+}
+
+// Error returns the translation key of the error.
+func (e LimitExceededError) Error() string {
+	return e.Msg
+}
+
+// Translate translates the error using the given translator. The limit, the actual value and the rule's name are passed in.
+func (e LimitExceededError) Translate(t trans.Translator) string {
+	return t.Tf(e.Msg, "limit", strconv.Itoa(e.Limit), "actual", strconv.Itoa(e.Actual), "rule", e.Rule)
+}
+
+// orDefault returns the value if it is greater than 0 and the default value otherwise.
+func orDefault(value int, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+
+	return defaultValue
+}
@@ -0,0 +1,61 @@
+package eiffel
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestBasicTemplate_ValidateLimits(t *testing.T) {
+	bt := lintTemplate()
+	assert.Empty(t, bt.ValidateLimits(LimitsCfg{}))
+
+	bt.Rules["localizedModal"] = BasicRule{Name: "Localized Modal", Type: "equalsAny", Value: map[string]any{
+		"en": []any{"shall", "should", "may"},
+		"de": []any{"muss"},
+	}}
+
+	errs := bt.ValidateLimits(LimitsCfg{MaxRules: 5, MaxVariants: 1, MaxEqualsAnyValues: 2})
+	require.Len(t, errs, 3)
+	assert.Equal(t, LimitExceededError{Msg: "eiffel.parser.error.limit.rules", Limit: 5, Actual: 6}, errs[0])
+	assert.Equal(t, LimitExceededError{Msg: "eiffel.parser.error.limit.variants", Limit: 1, Actual: 2}, errs[1])
+	assert.Equal(t, LimitExceededError{Msg: "eiffel.parser.error.limit.equals-any-values", Limit: 2, Actual: 3, Rule: "Localized Modal"}, errs[2])
+
+	translator := trans.NewTranslator(trans.WithTranslations(map[string]string{
+		"eiffel.parser.error.limit.equals-any-values": "{{ .rule }}: {{ .actual }}/{{ .limit }}",
+	}))
+	assert.Equal(t, "Localized Modal: 3/2", errs[2].(LimitExceededError).Translate(translator))
+}
+
+func TestSubscribeTemplateValidation_Limits(t *testing.T) {
+	em := event.NewManager(trace.NewTestLogger(t))
+	SubscribeTemplateValidation(em, validation.New(), LimitsCfg{MaxConfigSize: 512, MaxVariants: 1}, func(uuid.UUID) (map[string]*BasicTemplate, error) {
+		return map[string]*BasicTemplate{}, nil
+	})
+
+	validate := func(config string) []error {
+		validationErrs, err := template.ValidateTemplateConfig(config, BasicTemplateType, uuid.Nil, em, trace.NewTestLogger(t))
+		require.NoError(t, err)
+
+		return validationErrs
+	}
+
+	config := `{"id": "limits", "name": "Limits", "version": "1.0.0", "rules": {"system": {"name": "System", "type": "placeholder"}},
+		"variants": {"default": {"name": "Default", "rules": ["system"]}}}`
+	assert.Empty(t, validate(config))
+
+	validationErrs := validate(config + strings.Repeat(" ", 512))
+	assert.Contains(t, validationErrs, LimitExceededError{Msg: "eiffel.parser.error.limit.config-size", Limit: 512, Actual: len(config) + 512})
+	assert.Contains(t, validationErrs, template.ErrInvalidTemplate)
+
+	config = strings.Replace(config, `"variants": {`, `"variants": {"short": {"name": "Short", "rules": ["system"]}, `, 1)
+	validationErrs = validate(config)
+	assert.Contains(t, validationErrs, LimitExceededError{Msg: "eiffel.parser.error.limit.variants", Limit: 1, Actual: 2})
+}
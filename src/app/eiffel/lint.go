@@ -0,0 +1,163 @@
+package eiffel
+
+import (
+	"encoding/json"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// LintCheckVariantExample reports variants without an example.
+	LintCheckVariantExample = "variant-example"
+	// LintCheckRuleHint reports rules displayed as inputs without a hint.
+	LintCheckRuleHint = "rule-hint"
+	// LintCheckEqualsAnySingleValue reports equalsAny rules with a single value, these are better expressed as equals rules.
+	LintCheckEqualsAnySingleValue = "equals-any-single-value"
+	// LintCheckUnusedRule reports rules that are neither used by a variant nor referenced by a combinator rule.
+	LintCheckUnusedRule = "unused-rule"
+)
+
+// lintSeverities are the default severities of the lint checks.
+var lintSeverities = map[string]template.LintSeverity{
+	LintCheckVariantExample:       template.LintSeverityWarning,
+	LintCheckRuleHint:             template.LintSeverityInfo,
+	LintCheckEqualsAnySingleValue: template.LintSeverityWarning,
+	LintCheckUnusedRule:           template.LintSeverityWarning,
+}
+
+// LintCfg configures the linting of EIFFEL basic templates, see BasicTemplate.Lint.
+type LintCfg struct {
+	// Suppress lists the names of the checks that are not reported, e.g. "rule-hint".
+	Suppress []string `toml:"suppress"`
+	// Severity overrides the default severity of checks by their name. Unknown severities are ignored.
+	Severity map[string]string `toml:"severity"`
+}
+
+// Lint reports parts of the template that are valid but likely unintended or incomplete. The template is expected
+// to be valid (see BasicTemplate.Validate) and resolved (see ResolveExtends). The checks are:
+//   - LintCheckVariantExample: a variant has no example
+//   - LintCheckRuleHint: a rule displayed as input has no hint
+//   - LintCheckEqualsAnySingleValue: an equalsAny rule has a single value (in any locale)
+//   - LintCheckUnusedRule: a rule is neither used by a variant nor referenced by a combinator rule
+//
+// Checks can be suppressed and their severity overridden through the LintCfg. The findings are sorted by check and technical name.
+func (bt *BasicTemplate) Lint(ruleParsers *RuleParserProvider, cfg LintCfg) []template.LintFinding {
+	var findings []template.LintFinding
+	report := func(check, msg string, args ...string) {
+		if slices.Contains(cfg.Suppress, check) {
+			return
+		}
+
+		severity, ok := template.ParseLintSeverity(cfg.Severity[check])
+		if !ok {
+			severity = lintSeverities[check]
+		}
+
+		args = append(args, "template", bt.Name)
+		findings = append(findings, template.LintFinding{Check: check, Severity: severity, Msg: msg, Args: args})
+	}
+
+	for _, variantKey := range sortedKeys(bt.Variants) {
+		variant := bt.Variants[variantKey]
+		if strings.TrimSpace(variant.Example) == "" {
+			report(LintCheckVariantExample, "eiffel.lint.variant-example", "variant", variant.Name)
+		}
+	}
+
+	used := make(map[string]bool, len(bt.Rules))
+	for _, variant := range bt.Variants {
+		for _, name := range variant.Rules {
+			used[name] = true
+		}
+	}
+	for _, rule := range bt.Rules {
+		if !IsCombinator(rule.Type) {
+			continue
+		}
+
+		references, _ := toStringSlice(rule.Value)
+		for _, reference := range references {
+			used[reference] = true
+		}
+	}
+
+	for _, name := range sortedKeys(bt.Rules) {
+		rule := bt.Rules[name]
+
+		ruleParser, err := ruleParsers.Parser(rule.Type)
+		if err == nil && strings.TrimSpace(rule.Hint) == "" && ruleParser.DisplayType(rule) != TemplateDisplayString {
+			report(LintCheckRuleHint, "eiffel.lint.rule-hint", "rule", rule.Name)
+		}
+
+		if rule.Type == "equalsAny" {
+			for _, localized := range rule.LocaleVariants() {
+				if values, err := toStringSlice(localized.Value); err == nil && len(values) == 1 {
+					report(LintCheckEqualsAnySingleValue, "eiffel.lint.equals-any-single-value", "rule", rule.Name)
+					break
+				}
+			}
+		}
+
+		if !used[name] {
+			report(LintCheckUnusedRule, "eiffel.lint.unused-rule", "rule", rule.Name)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Check < findings[j].Check
+	})
+
+	return findings
+}
+
+// SubscribeTemplateLint subscribes to the template.LintTemplateConfigEvent and lints EIFFEL basic templates (see BasicTemplate.Lint).
+// Extending templates are resolved using the templateSets lookup. Templates that can not be read or resolved are not linted,
+// they are reported by the validation (see SubscribeTemplateValidation).
+// This is exported to allow linting templates outside the web application, e.g. in the templatecheck command.
+func SubscribeTemplateLint(em event.Manager, cfg LintCfg, templateSets TemplateSetLookup) {
+	em.Subscribe(template.LintTemplateConfigEventID, func(event event.Event, args *event.PublishArgs) error {
+		lintEvent, ok := event.Payload().(*template.LintTemplateConfigEvent)
+		if !ok {
+			return nil
+		}
+		if strings.ToLower(lintEvent.TemplateType) != BasicTemplateType {
+			return nil
+		}
+
+		ebt := &BasicTemplate{}
+		if err := json.Unmarshal([]byte(lintEvent.Config), ebt); err != nil {
+			return nil
+		}
+
+		if ebt.Extends != "" {
+			set, err := templateSets(lintEvent.TemplateSet)
+			if err != nil {
+				return err
+			}
+			set[ebt.ID] = ebt
+
+			ebt, err = ResolveExtends(ebt, set)
+			if err != nil {
+				return nil
+			}
+		}
+
+		lintEvent.AddFindings(ebt.Lint(RuleParsers(), cfg)...)
+
+		return nil
+	}, event.DefaultPriority)
+}
+
+// sortedKeys returns the keys of the map in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,77 @@
+package eiffel
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBasicTemplate_Lint(t *testing.T) {
+	bt := lintTemplate()
+	rp := RuleParsers()
+
+	findings := bt.Lint(rp, LintCfg{})
+	var checks []string
+	for _, finding := range findings {
+		checks = append(checks, finding.Check+":"+finding.Args[1])
+	}
+	assert.Equal(t, []string{
+		"equals-any-single-value:Modal",
+		"rule-hint:Modal",
+		"rule-hint:Unused",
+		"unused-rule:Unused",
+		"variant-example:Short",
+	}, checks)
+	assert.Equal(t, template.LintSeverityInfo, findings[1].Severity)
+	assert.Equal(t, []string{"rule", "Modal", "template", "Lint"}, findings[0].Args)
+	assert.False(t, template.HasLintErrors(findings))
+
+	findings = bt.Lint(rp, LintCfg{
+		Suppress: []string{LintCheckRuleHint, LintCheckVariantExample},
+		Severity: map[string]string{LintCheckUnusedRule: "error", LintCheckEqualsAnySingleValue: "fatal"},
+	})
+	require.Len(t, findings, 2)
+	assert.Equal(t, template.LintSeverityWarning, findings[0].Severity, "unknown severities fall back to the default")
+	assert.Equal(t, template.LintSeverityError, findings[1].Severity)
+	assert.True(t, template.HasLintErrors(findings))
+}
+
+func TestSubscribeTemplateLint(t *testing.T) {
+	em := event.NewManager(trace.NewTestLogger(t))
+	SubscribeTemplateLint(em, LintCfg{Suppress: []string{LintCheckRuleHint}}, func(uuid.UUID) (map[string]*BasicTemplate, error) {
+		return map[string]*BasicTemplate{}, nil
+	})
+
+	config := `{"id": "lint", "name": "Lint", "version": "1.0.0", "rules": {"system": {"name": "System", "type": "placeholder"}},
+		"variants": {"default": {"name": "Default", "rules": ["system"], "example": "HARMONY"}}}`
+	findings, err := template.LintTemplateConfig(config, "EBT", uuid.Nil, em, trace.NewTestLogger(t))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	findings, err = template.LintTemplateConfig(config, "other", uuid.Nil, em, trace.NewTestLogger(t))
+	require.NoError(t, err)
+	assert.Empty(t, findings, "other template types are not linted")
+}
+
+func lintTemplate() *BasicTemplate {
+	return &BasicTemplate{
+		ID:      "lint",
+		Name:    "Lint",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"system": {Name: "System", Type: "placeholder", Hint: "The system"},
+			"modal":  {Name: "Modal", Type: "equalsAny", Value: []any{"shall"}},
+			"equals": {Name: "Equals", Type: "equals", Value: "the"},
+			"unused": {Name: "Unused", Type: "placeholder"},
+			"either": {Name: "Either", Type: "anyOf", Value: []any{"system"}, Hint: "Either"},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {Name: "Default", Rules: []string{"system", "modal", "equals", "either"}, Example: "The system shall"},
+			"short":   {Name: "Short", Rules: []string{"system"}},
+		},
+	}
+}
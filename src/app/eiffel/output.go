@@ -0,0 +1,146 @@
+package eiffel
+
+import (
+	"bytes"
+	"errors"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"strings"
+	"text/template"
+)
+
+// ErrNoOutput is returned by FormatOutput if neither the variant nor the template define an output format.
+var ErrNoOutput = errors.New("no output format")
+
+// OutputError is returned if the output format of a template or variant is invalid. It is returned by BasicTemplate.Validate.
+type OutputError struct {
+	// Variant is the name of the variant defining the output format, it is empty for the template's output format.
+	Variant string
+	// Err is the error of parsing or executing the output format. It is displayed to the template's author.
+	Err error
+}
+
+// OutputFormat returns the output format of the variant, the template's output format is the fallback
+// for variants without an output format of their own. It is empty if neither defines an output format.
+func (bt *BasicTemplate) OutputFormat(variant *BasicVariant) string {
+	if variant != nil && variant.Output != "" {
+		return variant.Output
+	}
+
+	return bt.Output
+}
+
+// FormatOutput formats the parsed requirement with the output format of the variant (see BasicTemplate.OutputFormat).
+// The output format is a Go text/template executed on the segments keyed by the rules' technical names, e.g.
+// "The system shall {{ .action }}.". Segments of repeatable groups are accessed by their indexed name, e.g. {{ index . "given[0]" }}.
+// Segments which are not filled in are empty strings. Besides the builtin functions the output format may call:
+//   - requirement: the requirement as it was parsed
+//   - lower, upper, trim: the lower-cased, upper-cased or trimmed string
+//   - join: the non-empty strings following the separator joined by the separator, e.g. {{ join " " .system .modal }}
+//
+// Leading and trailing whitespace of the output is removed. ErrNoOutput is returned if there is no output format.
+func (bt *BasicTemplate) FormatOutput(variant *BasicVariant, segments map[string]string, requirement string) (string, error) {
+	format := bt.OutputFormat(variant)
+	if format == "" {
+		return "", ErrNoOutput
+	}
+
+	tmpl, err := compileOutput(format, requirement)
+	if err != nil {
+		return "", err
+	}
+
+	data := make(map[string]string, len(segments))
+	for name, value := range segments {
+		data[name] = strings.TrimSpace(value)
+	}
+
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(output.String()), nil
+}
+
+// FormattedOutput returns the parsed requirement formatted with the variant's output format (see BasicTemplate.FormatOutput)
+// or an empty string if there is no output format or it could not be executed. Callers fall back to the requirement itself then.
+func (bt *BasicTemplate) FormattedOutput(variant *BasicVariant, segments map[string]string, requirement string) string {
+	output, err := bt.FormatOutput(variant, segments, requirement)
+	if err != nil {
+		return ""
+	}
+
+	return output
+}
+
+// validateOutputs validates that the output formats of the template and its variants can be parsed and executed
+// on empty segments of the variants' rules.
+func (bt *BasicTemplate) validateOutputs() []error {
+	var errs []error
+	if bt.Output != "" {
+		if _, err := bt.FormatOutput(&BasicVariant{}, map[string]string{}, ""); err != nil {
+			errs = append(errs, OutputError{Err: err})
+		}
+	}
+
+	for _, key := range sortedKeys(bt.Variants) {
+		variant := bt.Variants[key]
+		if variant.Output == "" {
+			continue
+		}
+
+		segments := make(map[string]string, len(variant.Rules))
+		for _, rule := range variant.Rules {
+			segments[rule] = ""
+		}
+
+		if _, err := bt.FormatOutput(&variant, segments, ""); err != nil {
+			errs = append(errs, OutputError{Variant: variant.Name, Err: err})
+		}
+	}
+
+	return errs
+}
+
+// compileOutput parses the output format with the functions available to output formats, see BasicTemplate.FormatOutput.
+func compileOutput(format string, requirement string) (*template.Template, error) {
+	return template.New("output").Option("missingkey=zero").Funcs(template.FuncMap{
+		"requirement": func() string { return requirement },
+		"lower":       strings.ToLower,
+		"upper":       strings.ToUpper,
+		"trim":        strings.TrimSpace,
+		"join":        joinNonEmpty,
+	}).Parse(format)
+}
+
+// joinNonEmpty joins the non-empty strings by the separator.
+func joinNonEmpty(sep string, values ...string) string {
+	var nonEmpty []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			nonEmpty = append(nonEmpty, value)
+		}
+	}
+
+	return strings.Join(nonEmpty, sep)
+}
+
+// Error on OutputError returns the translation key of the error.
+func (e OutputError) Error() string {
+	if e.Variant == "" {
+		return "eiffel.parser.error.invalid-template-output"
+	}
+
+	return "eiffel.parser.error.invalid-variant-output"
+}
+
+// UnwrapTransparent on OutputError returns the error itself, implementing the validation.TransparentError interface.
+func (e OutputError) UnwrapTransparent(err validation.Error) error {
+	return e
+}
+
+// Translate on OutputError translates the error using the given translator. The variant's name and the error are passed in.
+func (e OutputError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "variant", e.Variant, "error", e.Err.Error())
+}
@@ -0,0 +1,61 @@
+package eiffel
+
+import (
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBasicTemplate_FormatOutput(t *testing.T) {
+	bt := outputTestTemplate()
+	segments := map[string]string{"system": " The system ", "modal": "shall", "process": "log every access"}
+
+	output, err := bt.FormatOutput(&BasicVariant{Output: "{{ .system }} {{ upper .modal }} {{ .process }}."}, segments, "")
+	require.NoError(t, err)
+	assert.Equal(t, "The system SHALL log every access.", output)
+
+	output, err = bt.FormatOutput(&BasicVariant{}, map[string]string{"system": "The system", "process": "log"}, "The system log")
+	require.NoError(t, err)
+	assert.Equal(t, "[The system log] The system log", output, "the template's output format is the fallback, missing segments are empty")
+
+	_, err = (&BasicTemplate{}).FormatOutput(&BasicVariant{}, segments, "")
+	assert.ErrorIs(t, err, ErrNoOutput)
+	assert.Empty(t, (&BasicTemplate{}).FormattedOutput(&BasicVariant{}, segments, ""))
+
+	output, err = bt.FormatOutput(&BasicVariant{Output: `{{ index . "given[1]" }}`}, map[string]string{"given[1]": "a user"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "a user", output)
+}
+
+func TestBasicTemplate_ValidateOutputs(t *testing.T) {
+	bt := outputTestTemplate()
+	require.Empty(t, bt.Validate(validation.New(), RuleParsers()))
+
+	bt.Output = "{{ .system"
+	variant := bt.Variants["default"]
+	variant.Output = "{{ unknown .system }}"
+	bt.Variants["default"] = variant
+
+	errs := bt.validateOutputs()
+	require.Len(t, errs, 2)
+	assert.Equal(t, "eiffel.parser.error.invalid-template-output", errs[0].Error())
+	assert.Equal(t, OutputError{Variant: "Default", Err: errs[1].(OutputError).Err}, errs[1])
+}
+
+func outputTestTemplate() *BasicTemplate {
+	return &BasicTemplate{
+		ID:      "output",
+		Name:    "Output",
+		Version: "1.0.0",
+		Output:  "[{{ requirement }}] {{ join \" \" .system .modal .process }}",
+		Rules: map[string]BasicRule{
+			"system":  {Name: "System", Type: "placeholder"},
+			"modal":   {Name: "Modal", Type: "equalsAny", Value: []any{"shall", "should"}},
+			"process": {Name: "Process", Type: "placeholder"},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {Name: "Default", Rules: []string{"system", "modal", "process"}, Output: "{{ .system }} {{ .modal }} {{ .process }}."},
+		},
+	}
+}
@@ -6,9 +6,14 @@ import (
 	t "github.com/org-harmony/harmony/src/app/template"
 	"github.com/org-harmony/harmony/src/app/template/parser"
 	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/util"
 	"github.com/org-harmony/harmony/src/core/validation"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 )
 
 // BasicTemplateType is the type name of the basic EIFFEL template used to identify the corresponding parser for a template.
@@ -21,6 +26,12 @@ var (
 	ErrNotASlice = errors.New("eiffel.parser.error.not-a-slice")
 	// ErrNotAString is an error that is returned when trying to cast an any value to a string but the value is not a string.
 	ErrNotAString = errors.New("eiffel.parser.error.not-a-string")
+	// ErrUIUnknownDefaultVariant is returned if the default variant of the template's UI settings is not defined in the template.
+	ErrUIUnknownDefaultVariant = errors.New("eiffel.parser.error.ui-default-variant")
+	// ErrUIUnknownFieldOrderRule is returned if the field order of the template's UI settings references a rule not defined in the template.
+	ErrUIUnknownFieldOrderRule = errors.New("eiffel.parser.error.ui-field-order")
+	// ErrInvalidLanguageCheck is returned if the template's 'languageCheck' extra property is not a boolean.
+	ErrInvalidLanguageCheck = errors.New("eiffel.parser.error.invalid-language-check")
 )
 
 // BasicTemplate is the basic EIFFEL template.
@@ -50,10 +61,25 @@ type BasicTemplate struct {
 	Format string `json:"format"` // TODO remove this? Format is now defined in the variant.
 	// Example can be used to optionally provide an example of a requirement specified by the template.
 	Example string `json:"example"` // TODO remove this? Example is now defined in the variant.
+	// Output is the optional output format of requirements elicited with variants without an output format of their own.
+	// Requirements are copied, exported and listed in their output format, see BasicTemplate.FormatOutput.
+	Output string `json:"output"`
+	// Extends optionally references the ID of another template in the same template set.
+	// Rules and variants of the extended template are inherited and can be overridden, see ResolveExtends.
+	Extends string `json:"extends"`
 	// Rules are the rules that can be used in variants to validate requirements.
 	Rules map[string]BasicRule `json:"rules"`
 	// Variants are the variants that can be used to validate requirements.
 	Variants map[string]BasicVariant `json:"variants" hvalidate:"required"`
+	// Constraints are optional template-level constraints spanning multiple segments, see BasicConstraint.
+	Constraints []BasicConstraint `json:"constraints"`
+	// UI are the optional settings of the elicitation UI for the template, see template.UISettings.
+	UI t.UISettings `json:"ui"`
+	// Translations optionally override the name and description by locale path, see LocalizeContent.
+	Translations map[string]TemplateTranslation `json:"translations"`
+	// Extra is an optional map of additional data for the whole template.
+	// E.g. the 'languageCheck' extra property opts all placeholder rules in to the language check, see PlaceholderRuleParser.
+	Extra map[string]any `json:"extra"`
 }
 
 // BasicRule is a rule to reference in a variant.
@@ -72,6 +98,9 @@ type BasicRule struct {
 	// Value is the value of the rule used during rule parsing. Each rule type may expect different values or even no value at all.
 	// Value might be something like an exact string or a slice of strings.
 	// Check further documentation for all valid types that are supported by the EIFFEL basic template (EBT).
+	//
+	// Value may also be localized by defining a map keyed by locale path (e.g. {"de": [...], "en": [...]}).
+	// Localized values are resolved to the user's active locale at parse time, see LocalizedValue.
 	Value any `json:"value"`
 	// Optional means a rule is not required to be parsed without template.ParsingLogLevelError (parsing error).
 	// By that parsing an invalid requirement for an optional rule will not result in a parsing error.
@@ -90,6 +119,12 @@ type BasicRule struct {
 	Size string `json:"size"`
 	// Extra is an optional map of additional data that can be used by the rule parser.
 	Extra map[string]any `json:"extra"`
+	// Translations optionally override the name, hint and explanation by locale path, see BasicTemplate.LocalizeContent.
+	Translations map[string]RuleTranslation `json:"translations"`
+	// Default is optionally prefilled in the elicitation form, see BasicDefault.
+	Default *BasicDefault `json:"default"`
+	// compiled is the precompiled value of the rule, see BasicTemplate.Compile. It is nil if the rule was not compiled.
+	compiled *CompiledRule
 }
 
 // BasicVariant is a concrete variation of a template to parse requirements. Each variant contains a set of rules.
@@ -104,8 +139,17 @@ type BasicVariant struct {
 	Format string `json:"format"`
 	// Example can be used to optionally provide an example of a requirement following the format specified by the variant.
 	Example string `json:"example"`
+	// Output is the optional output format of requirements elicited with the variant, e.g. "The system shall {{ .action }}.".
+	// Requirements are copied, exported and listed in their output format, see BasicTemplate.FormatOutput.
+	Output string `json:"output"`
 	// Rules contains rule names, rule objects should be contained in the template
 	Rules []string `json:"rules"`
+	// Groups optionally make rules of the variant repeatable, keyed by the group's technical name, see BasicGroup.
+	Groups map[string]BasicGroup `json:"groups"`
+	// Conditions optionally activate rules of the variant depending on another segment, keyed by the rule's technical name, see BasicCondition.
+	Conditions map[string]BasicCondition `json:"conditions"`
+	// Translations optionally override the name, description, format and example by locale path, see BasicTemplate.LocalizeContent.
+	Translations map[string]VariantTranslation `json:"translations"`
 }
 
 // RuleMissingError is an error that is returned when a rule is referenced in a variant but not defined in the template.
@@ -137,7 +181,9 @@ type MissingRuleParserError struct {
 type RuleParserProvider struct {
 	// parsers is a map of rule parsers per rule type.
 	parsers map[string]RuleParser
-	mu      sync.RWMutex
+	// ruleTimeout is the maximum duration of parsing a single rule, see SetRuleTimeout.
+	ruleTimeout time.Duration
+	mu          sync.RWMutex
 }
 
 // RuleParser provides the capabilities to validate a rule (after defining it in a template), ideally during the template validation,
@@ -147,6 +193,8 @@ type RuleParserProvider struct {
 // as well as its own data type for the rule value.
 //
 // RuleParser is expected to be stateless and therefore safe for concurrent use by multiple goroutines.
+// Parse is expected to return once the context is done. Each rule is parsed within the rule timeout of the RuleParserProvider.
+// Implementations should pass the conformance suite of the package parsertest.
 type RuleParser interface {
 	// Parse parses a rule using a segment of a requirement and a parsing result.
 	Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error)
@@ -165,20 +213,50 @@ type EqualsRuleParser struct{}
 // It expects the rule's value to be a slice of strings. Any of the strings in the slice must match the segment's value.
 type EqualsAnyRuleParser struct{}
 
+// ForbidsRuleParser is a rule parser for the rule type 'forbids'. It expects the rule's value to be a slice of strings
+// containing weak words or forbidden phrases (e.g. "maybe", "as fast as possible"). The segment is scanned for each phrase
+// case-insensitively and on word boundaries. Each occurrence is reported as a parsing warning.
+// The segment itself is free text, therefore forbids rules are displayed like placeholders.
+type ForbidsRuleParser struct{}
+
 // PlaceholderRuleParser is a rule parser for the rule type 'placeholder'. Placeholders can be used to parse segments that contain some arbitrary string content.
 // Placeholders may be used to generate input fields for the user of the template without knowing the exact content of the segment.
 // If it wasn't for the input field the placeholder is used for, it would be useless.
 // Therefore, the value of a placeholder is optional.
-type PlaceholderRuleParser struct{}
+//
+// If a LanguageChecker is set and the rule or the template opts in through the 'languageCheck' extra property,
+// the segment is checked for spelling and grammar issues which are reported as parsing notices.
+// The rule's property takes precedence, e.g. a rule may opt out of the check enabled for the whole template.
+type PlaceholderRuleParser struct {
+	// LanguageChecker is optional. If it is nil, no language check is done.
+	LanguageChecker LanguageChecker
+}
 
-// RuleParsers constructs a new RuleParserProvider with the default rule parsers registered.
+// RuleParsers constructs a new RuleParserProvider with the default rule parsers and the plugins registered (see RegisterPlugin).
 func RuleParsers() *RuleParserProvider {
-	return &RuleParserProvider{
-		parsers: map[string]RuleParser{
-			"equals":      EqualsRuleParser{},
-			"equalsAny":   EqualsAnyRuleParser{},
-			"placeholder": PlaceholderRuleParser{},
-		},
+	parsers := builtinRuleParsers()
+
+	plugins.mu.RLock()
+	defer plugins.mu.RUnlock()
+
+	for ruleType, ruleParser := range plugins.parsers {
+		parsers[ruleType] = ruleParser
+	}
+
+	return &RuleParserProvider{parsers: parsers}
+}
+
+// builtinRuleParsers returns the default rule parsers keyed by their rule type.
+func builtinRuleParsers() map[string]RuleParser {
+	return map[string]RuleParser{
+		"equals":      EqualsRuleParser{},
+		"equalsAny":   EqualsAnyRuleParser{},
+		"placeholder": PlaceholderRuleParser{},
+		"forbids":     ForbidsRuleParser{},
+		"allOf":       AllOfRuleParser{},
+		"anyOf":       AnyOfRuleParser{},
+		"not":         NotRuleParser{},
+		"script":      ScriptRuleParser{},
 	}
 }
 
@@ -194,98 +272,110 @@ func RuleParsers() *RuleParserProvider {
 //     - superfluous segments are ignored
 //     - missing segments are reported as parsing errors
 //     - logs (errors, warning, notices) during rule parsing are reported
-//  4. Return the parsing result.
+//     - rules exceeding the rule timeout (see RuleParserProvider.SetRuleTimeout) are reported as parsing errors
+//     - repeated rules are parsed for each occurrence of their group where the group's first rule is, see BasicGroup
+//     - conditional rules are skipped if they are inactive and required if they are active, see BasicCondition
+//     - segments equal to the default of their rule are logged as untouched defaults, see WithPrefilled
+//  4. Evaluate the template's constraints applying to the variant against all segments, see BasicConstraint.
+//  5. Return the parsing result.
 //
 // Consequences of **optional** rule parsing: If a rule is optional (BasicRule.Optional flag) and the segment is missing, the rule is ignored.
 // If a rule is optional and the segment is present, Parse will parse the segment and report any warnings and notices.
 // After parsing an optional rule the errors will be downgraded to notices. The template.ParsingLog Downgrade flag is set to true.
 // Therefore, the parsing result will be ok and flawless. However, the parsing result will contain notices.
 // Also, it is possible that a parsed rule contains warnings those are not downgraded and the parsing result will not be flawless.
+//
+// Use ParseStream to receive the logs of each rule as soon as it is parsed or to stop parsing at the first error.
 func (bt *BasicTemplate) Parse(ctx context.Context, ruleParsers *RuleParserProvider, variantName string, segments ...parser.ParsingSegment) (parser.ParsingResult, error) {
-	result := parser.ParsingResult{
-		TemplateID:      bt.ID,
-		TemplateType:    BasicTemplateType,
-		TemplateVersion: bt.Version,
-		TemplateName:    bt.Name,
-		VariantName:     variantName,
-		Requirement:     "",
-	}
+	return bt.ParseStream(ctx, ruleParsers, variantName, segments, func(ParsedRule) error {
+		return nil
+	})
+}
 
-	indexedSegments := prepareSegments(segments)
+// ParseSegment parses a single segment of a requirement using the rule of the variant the segment is named after.
+// It allows validating a requirement segment by segment, e.g. while a user fills in one rule at a time.
+// The parsing logs are leveled like the logs of Parse: a missing segment is an error and errors of optional rules are downgraded to notices.
+// Segments of repeated rules are named by their occurrence, e.g. "stakeholder[1]" (see BasicGroup).
+// Conditions of rules are not evaluated as they depend on other segments (see BasicCondition).
+// ParseSegment returns ErrInvalidVariant if the variant does not exist and a RuleMissingError if the variant does not reference the rule.
+func (bt *BasicTemplate) ParseSegment(ctx context.Context, ruleParsers *RuleParserProvider, variantName string, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
 	variant, ok := bt.Variants[variantName]
 	if !ok {
-		return result, ErrInvalidVariant
+		return nil, ErrInvalidVariant
 	}
-	result.VariantName = variant.Name
 
-	for _, ruleName := range variant.Rules {
-		rule, ok := bt.Rules[ruleName]
-		if !ok {
-			return result, RuleMissingError{Rule: ruleName, Template: bt.Name, Variant: variant.Name}
+	ruleName := segment.Name
+	if repeated, _, ok := segment.Occurrence(); ok {
+		if _, grouped := variant.GroupOf(repeated); grouped {
+			ruleName = repeated
 		}
+	}
 
-		var parsingLogs []parser.ParsingLog
-		segment, ok := indexedSegments[ruleName]
+	rule, ok := bt.Rules[ruleName]
+	if !ok || !slices.Contains(variant.Rules, ruleName) {
+		return nil, RuleMissingError{Rule: segment.Name, Template: bt.Name, Variant: variant.Name}
+	}
 
-		isMissing := !ok || segment.Value == ""
-		if isMissing {
-			parsingLogs = append(parsingLogs, parser.ParsingLog{
-				Segment: &parser.ParsingSegment{Name: ruleName},
-				Level:   parser.ParsingLogLevelError, // if optional this will be downgraded to a notice in a moment
-				Message: "eiffel.parser.error.missing-segment",
-				TranslationArgs: []string{
-					"name",
-					rule.Name,
-					"technicalName",
-					ruleName,
-				},
-			})
-		}
+	ctx = withRuleResolver(ctx, bt, ruleParsers)
+	ctx = withLanguageCheck(ctx, bt)
+	segment.Value = strings.TrimSpace(segment.Value)
+	ctx = withSegments(ctx, bt.Rules, map[string]parser.ParsingSegment{segment.Name: segment})
 
-		if rule.Optional && isMissing && rule.IgnoreMissingWhenOptional {
-			continue
-		}
+	return parseSegment(ctx, ruleParsers, segment.Name, rule, segment)
+}
 
-		if !isMissing {
-			var err error
-			parsingLogs, err = parse(ctx, ruleParsers, rule, segment)
-			if err != nil {
-				return result, err
-			}
+// parseSegment parses the segment using the rule referenced by ruleName and returns the leveled parsing logs.
+// A missing (empty) segment is logged as an error unless the rule is optional and ignores missing segments.
+// Errors of optional rules are downgraded to notices.
+func parseSegment(ctx context.Context, ruleParsers *RuleParserProvider, ruleName string, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+	var parsingLogs []parser.ParsingLog
 
-			buildRequirementIncrementally(rule, segment, &result)
+	isMissing := segment.Value == ""
+	if isMissing {
+		if rule.Optional && rule.IgnoreMissingWhenOptional {
+			return nil, nil
 		}
 
-		for _, log := range parsingLogs {
-			switch log.Level {
-			case parser.ParsingLogLevelError:
-				if rule.Optional {
-					log.Level = parser.ParsingLogLevelNotice
-					log.Downgrade = true
-					result.Notices = append(result.Notices, log)
-					break
-				}
-				result.Errors = append(result.Errors, log)
-			case parser.ParsingLogLevelWarning:
-				result.Warnings = append(result.Warnings, log)
-			case parser.ParsingLogLevelNotice:
-				result.Notices = append(result.Notices, log)
-			}
+		parsingLogs = append(parsingLogs, parser.ParsingLog{
+			Segment: &parser.ParsingSegment{Name: ruleName},
+			Level:   parser.ParsingLogLevelError, // if optional this will be downgraded to a notice in a moment
+			Message: "eiffel.parser.error.missing-segment",
+			TranslationArgs: []string{
+				"name",
+				rule.Name,
+				"technicalName",
+				ruleName,
+			},
+		})
+	} else {
+		var err error
+		parsingLogs, err = parse(ctx, ruleParsers, ruleName, rule, segment)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	result.Requirement = strings.TrimSpace(result.Requirement)
+	if !rule.Optional {
+		return parsingLogs, nil
+	}
 
-	return result, nil
+	for i, log := range parsingLogs {
+		if log.Level == parser.ParsingLogLevelError {
+			parsingLogs[i] = log.Downgraded(parser.ParsingLogLevelNotice)
+		}
+	}
+
+	return parsingLogs, nil
 }
 
-func parse(ctx context.Context, ruleParsers *RuleParserProvider, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+// parse parses the segment with the rule parser of the rule's type within the rule timeout of the provider, see parseWithTimeout.
+func parse(ctx context.Context, ruleParsers *RuleParserProvider, ruleName string, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
 	ruleParser, err := ruleParsers.Parser(rule.Type)
 	if err != nil {
 		return nil, err
 	}
 
-	return ruleParser.Parse(ctx, rule, segment)
+	return parseWithTimeout(ctx, ruleParser, ruleParsers.RuleTimeout(), ruleName, rule, segment)
 }
 
 func buildRequirementIncrementally(rule BasicRule, segment parser.ParsingSegment, result *parser.ParsingResult) {
@@ -308,7 +398,7 @@ func buildRequirementIncrementally(rule BasicRule, segment parser.ParsingSegment
 // It returns a slice of validation errors that are safe to show to the user (translatable).
 // In almost any case, the returned slice will contain the template.ErrInvalidTemplate error.
 func (bt *BasicTemplate) Validate(v validation.V, ruleParsers *RuleParserProvider) []error {
-	errs := validation.Validate("", "", bt, RuleReferencesValidator)
+	errs := validation.Validate("", "", bt, RuleReferencesValidator, CombinatorReferencesValidator)
 	if len(errs) > 0 {
 		return append(errs, t.ErrInvalidTemplate)
 	}
@@ -333,10 +423,18 @@ func (bt *BasicTemplate) Validate(v validation.V, ruleParsers *RuleParserProvide
 			return []error{t.ErrInvalidTemplate, err}
 		}
 
+		if rule.IsLocalized() && len(rule.Locales()) == 0 {
+			validationErrs = append(validationErrs, RuleInvalidValueError{Rule: &rule, Msg: "eiffel.parser.error.empty-localized-value"})
+			continue
+		}
+
 		// This is requesting the rule parser to validate the rule value.
 		// A parser might for example validate that the rule value is of the correct data type.
-		ruleValidationErrs = ruleParser.Validate(v, rule)
-		validationErrs = append(validationErrs, ruleValidationErrs...)
+		// Localized rule values are validated for each locale separately.
+		for _, localizedRule := range rule.LocaleVariants() {
+			ruleValidationErrs = ruleParser.Validate(v, localizedRule)
+			validationErrs = append(validationErrs, ruleValidationErrs...)
+		}
 	}
 
 	for _, variant := range bt.Variants {
@@ -348,6 +446,23 @@ func (bt *BasicTemplate) Validate(v validation.V, ruleParsers *RuleParserProvide
 		validationErrs = append(validationErrs, variantValidationErrs...)
 	}
 
+	for _, constraint := range bt.Constraints {
+		err, constraintValidationErrs := v.ValidateStruct(constraint)
+		if err != nil {
+			return []error{t.ErrInvalidTemplate, err}
+		}
+
+		validationErrs = append(validationErrs, constraintValidationErrs...)
+	}
+	validationErrs = append(validationErrs, bt.validateGroups(v)...)
+	validationErrs = append(validationErrs, bt.validateConditions(v)...)
+	validationErrs = append(validationErrs, bt.validateDefaults()...)
+	validationErrs = append(validationErrs, bt.validateOutputs()...)
+	validationErrs = append(validationErrs, bt.validateConstraints()...)
+	validationErrs = append(validationErrs, bt.validateUI()...)
+	validationErrs = append(validationErrs, bt.validateExtra()...)
+	validationErrs = append(validationErrs, bt.validateTranslations()...)
+
 	if len(validationErrs) > 0 {
 		return append(validationErrs, t.ErrInvalidTemplate)
 	}
@@ -355,6 +470,34 @@ func (bt *BasicTemplate) Validate(v validation.V, ruleParsers *RuleParserProvide
 	return nil
 }
 
+// validateUI validates that the UI settings of the template reference variants and rules defined in the template.
+// The settings themselves are validated by the template module, see template.UISettings.Validate.
+func (bt *BasicTemplate) validateUI() []error {
+	var errs []error
+	if _, ok := bt.Variants[bt.UI.DefaultVariant]; !ok && bt.UI.DefaultVariant != "" {
+		errs = append(errs, ErrUIUnknownDefaultVariant)
+	}
+
+	for _, rule := range bt.UI.FieldOrder {
+		if _, ok := bt.Rules[rule]; !ok {
+			errs = append(errs, ErrUIUnknownFieldOrderRule)
+			break
+		}
+	}
+
+	return errs
+}
+
+// validateExtra validates the template's extra properties. The 'languageCheck' extra property is expected to be a boolean.
+func (bt *BasicTemplate) validateExtra() []error {
+	languageCheck, ok := bt.Extra["languageCheck"]
+	if _, lcOk := languageCheck.(bool); ok && !lcOk {
+		return []error{ErrInvalidLanguageCheck}
+	}
+
+	return nil
+}
+
 // RuleReferencesValidator validates that each rule referenced in a variant is defined in the template's 'rules' section.
 func RuleReferencesValidator(basicTemplate any) error {
 	bt, ok := basicTemplate.(*BasicTemplate)
@@ -409,6 +552,92 @@ func (p *RuleParserProvider) Register(ruleType string, parser RuleParser) {
 	p.parsers[ruleType] = parser
 }
 
+// IsLocalized returns true if the rule's value is a map keyed by locale path instead of a plain value.
+func (r BasicRule) IsLocalized() bool {
+	_, ok := r.Value.(map[string]any)
+	return ok
+}
+
+// Locales returns the sorted locale paths a localized rule value is defined for.
+// For rules without a localized value nil is returned.
+func (r BasicRule) Locales() []string {
+	localized, ok := r.Value.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	locales := make([]string, 0, len(localized))
+	for locale := range localized {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// ValueFor returns the rule's value for the given locale path. Plain (non-localized) values are returned as is.
+// If the localized value does not define the locale the value of the first locale (sorted by path) is used as a fallback.
+// If the localized value is empty, nil is returned.
+func (r BasicRule) ValueFor(locale string) any {
+	localized, ok := r.Value.(map[string]any)
+	if !ok {
+		return r.Value
+	}
+
+	if value, ok := localized[locale]; ok {
+		return value
+	}
+
+	locales := r.Locales()
+	if len(locales) == 0 {
+		return nil
+	}
+
+	return localized[locales[0]]
+}
+
+// LocaleVariants returns a copy of the rule for each locale its value is defined for with the value resolved to that locale.
+// For rules without a localized value a slice containing only the rule itself is returned.
+func (r BasicRule) LocaleVariants() []BasicRule {
+	if !r.IsLocalized() {
+		return []BasicRule{r}
+	}
+
+	locales := r.Locales()
+	variants := make([]BasicRule, 0, len(locales))
+	for _, locale := range locales {
+		variant := r
+		variant.Value = r.ValueFor(locale)
+		variants = append(variants, variant)
+	}
+
+	return variants
+}
+
+// LocalizedValue resolves the rule's value for the user's active locale. The locale is read from the trans.Translator
+// in the context. If no translator is found in the context the fallback of BasicRule.ValueFor is used.
+// Rule parsers should use LocalizedValue instead of accessing BasicRule.Value directly.
+func LocalizedValue(ctx context.Context, rule BasicRule) any {
+	if !rule.IsLocalized() {
+		return rule.Value
+	}
+
+	return rule.ValueFor(ctxLocale(ctx))
+}
+
+// LocalizeRules resolves all localized rule values of the template to the user's active locale read from the context.
+// This is useful before displaying the template, e.g. in the elicitation form. The template is modified in place.
+func (bt *BasicTemplate) LocalizeRules(ctx context.Context) {
+	for name, rule := range bt.Rules {
+		if !rule.IsLocalized() {
+			continue
+		}
+
+		rule.Value = LocalizedValue(ctx, rule)
+		bt.Rules[name] = rule
+	}
+}
+
 // Error on RuleMissingError returns the error code of the error.
 func (e RuleMissingError) Error() string {
 	return "eiffel.parser.error.missing-rule"
@@ -455,15 +684,19 @@ func (e MissingRuleParserError) Translate(t trans.Translator) string {
 // The equals rule expects a string value, converts it to lowercase and compares it to the lowercase segment's value.
 // If the values are not equal, a parsing error is reported.
 func (p EqualsRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
-	rv, ok := rule.Value.(string)
+	rv, ok := LocalizedValue(ctx, rule).(string)
 	if !ok {
 		return nil, RuleInvalidValueError{Rule: &rule}
 	}
 
-	segmentValue := strings.ToLower(segment.Value)
-	ruleValue := strings.ToLower(rv)
+	var ruleValue string
+	if compiled, ok := CompiledValue(ctx, rule); ok {
+		ruleValue = compiled.(compiledEquals).lower
+	} else {
+		ruleValue = strings.ToLower(rv)
+	}
 
-	if segmentValue == ruleValue {
+	if strings.ToLower(segment.Value) == ruleValue {
 		return nil, nil
 	}
 
@@ -472,6 +705,7 @@ func (p EqualsRuleParser) Parse(ctx context.Context, rule BasicRule, segment par
 		Level:           parser.ParsingLogLevelError,
 		Message:         "eiffel.parser.equals.error",
 		TranslationArgs: []string{"expected", rv, "actual", segment.Value}, // use the original values here
+		Ranges:          []parser.ParsingRange{segmentRange(segment, parser.ParsingLogLevelError)},
 	}}, nil
 }
 
@@ -493,15 +727,34 @@ func (p EqualsRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
 
 // Parse implements the RuleParser interface for the EqualsAnyRuleParser. It is used to parse rules of the type 'equalsAny'.
 // The equalsAny rule expects a slice of strings as value, converts each string to lowercase and compares it to the lowercase segment's value.
-// If any of the values are equal, no parsing error is reported.
+// If any of the values are equal, no parsing error is reported. If the template was compiled (see BasicTemplate.Compile)
+// the lowercase segment's value is looked up in the prebuilt set of lowercase values instead.
 //
 // The equalsAny rule has an extra property 'allowOthers' that can be set to true or false.
 // If set to true, the equalsAny rule will allow any other value than the ones defined in the rule's value, as long as the segment's value is not empty.
 // For allowing empty use the optional + ignoreMissingWhenOptional flags.
 func (p EqualsAnyRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
-	rv, err := toStringSlice(rule.Value)
-	if err != nil {
-		return nil, RuleInvalidValueError{Rule: &rule, Msg: err.Error()}
+	segmentValue := strings.ToLower(segment.Value)
+	matches := false
+
+	var rv []string
+	if compiled, ok := CompiledValue(ctx, rule); ok {
+		rv = compiled.(compiledEqualsAny).values
+		_, matches = compiled.(compiledEqualsAny).set[segmentValue]
+	} else {
+		var err error
+		rv, err = toStringSlice(LocalizedValue(ctx, rule))
+		if err != nil {
+			return nil, RuleInvalidValueError{Rule: &rule, Msg: err.Error()}
+		}
+
+		matches = slices.ContainsFunc(rv, func(v string) bool {
+			return strings.ToLower(v) == segmentValue
+		})
+	}
+
+	if matches {
+		return nil, nil
 	}
 
 	allowOthers, ok := rule.Extra["allowOthers"].(bool)
@@ -509,15 +762,6 @@ func (p EqualsAnyRuleParser) Parse(ctx context.Context, rule BasicRule, segment
 		allowOthers = false
 	}
 
-	segmentValue := strings.ToLower(segment.Value)
-	for _, v := range rv {
-		ruleValue := strings.ToLower(v)
-
-		if segmentValue == ruleValue {
-			return nil, nil
-		}
-	}
-
 	if allowOthers && segmentValue != "" {
 		return nil, nil
 	}
@@ -527,6 +771,7 @@ func (p EqualsAnyRuleParser) Parse(ctx context.Context, rule BasicRule, segment
 		Level:           parser.ParsingLogLevelError,
 		Message:         "eiffel.parser.equals-any.error",
 		TranslationArgs: []string{"expected", "\"" + strings.Join(rv, "\", \"") + "\"", "actual", segment.Value}, // use the original values here
+		Ranges:          []parser.ParsingRange{segmentRange(segment, parser.ParsingLogLevelError)},
 	}}, nil
 }
 
@@ -552,12 +797,67 @@ func (p EqualsAnyRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
 }
 
 // Parse implements the RuleParser interface for the PlaceholderRuleParser. It is used to parse rules of the type 'placeholder'.
+// Placeholders accept any content. However, if the rule or the template opted in to the language check and a LanguageChecker is available,
+// each spelling or grammar issue is reported as a parsing notice with the issue's range in the segment.
+// A failing language check never fails parsing, instead a notice is reported that the check was not possible.
 func (p PlaceholderRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
-	return nil, nil
+	if p.LanguageChecker == nil || !languageCheckEnabled(ctx, rule) || segment.Value == "" {
+		return nil, nil
+	}
+
+	issues, err := p.LanguageChecker.Check(ctx, segment.Value, ctxLocale(ctx))
+	if err != nil {
+		return []parser.ParsingLog{{
+			Segment: &segment,
+			Level:   parser.ParsingLogLevelNotice,
+			Message: "eiffel.parser.placeholder.language-check-unavailable",
+		}}, nil
+	}
+
+	runes := []rune(segment.Value)
+	logs := make([]parser.ParsingLog, 0, len(issues))
+	for _, issue := range issues {
+		offending := ""
+		if issue.Offset >= 0 && issue.Length >= 0 && issue.Offset+issue.Length <= len(runes) {
+			offending = string(runes[issue.Offset : issue.Offset+issue.Length])
+		}
+
+		replacements := issue.Replacements
+		if len(replacements) > 3 {
+			replacements = replacements[:3]
+		}
+
+		logs = append(logs, parser.ParsingLog{
+			Segment: &segment,
+			Level:   parser.ParsingLogLevelNotice,
+			Message: "eiffel.parser.placeholder.language-issue",
+			TranslationArgs: []string{
+				"message", issue.Message,
+				"actual", offending,
+				"suggestions", strings.Join(replacements, ", "),
+			},
+			Extra: map[string]any{
+				"ruleID": issue.RuleID,
+			},
+			Ranges: []parser.ParsingRange{{
+				Start: issue.Offset,
+				End:   issue.Offset + issue.Length,
+				Level: parser.ParsingLogLevelNotice,
+			}},
+		})
+	}
+
+	return logs, nil
 }
 
 // Validate implements the RuleParser interface for the PlaceholderRuleParser. It is used to validate rules of the type 'placeholder'.
+// If the 'languageCheck' extra property is set it expects a boolean value.
 func (p PlaceholderRuleParser) Validate(v validation.V, rule BasicRule) []error {
+	languageCheck, ok := rule.Extra["languageCheck"]
+	if _, lcOk := languageCheck.(bool); ok && !lcOk {
+		return []error{RuleInvalidValueError{Rule: &rule, Msg: "eiffel.parser.placeholder.invalid-language-check"}}
+	}
+
 	return nil
 }
 
@@ -578,6 +878,114 @@ func (p PlaceholderRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
 	}
 }
 
+// Parse implements the RuleParser interface for the ForbidsRuleParser. It is used to parse rules of the type 'forbids'.
+// Each occurrence of a forbidden phrase is reported as a parsing warning. The warning's TranslationArgs contain the exact
+// offending substring of the segment ("actual") and the forbidden phrase ("forbidden"). The warning's range marks
+// the substring in the segment to allow highlighting it.
+func (p ForbidsRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+	compiled, ok := CompiledValue(ctx, rule)
+	if !ok {
+		var err error
+		compiled, err = p.Compile(BasicRule{Value: LocalizedValue(ctx, rule)})
+		if err != nil {
+			return nil, RuleInvalidValueError{Rule: &rule, Msg: err.Error()}
+		}
+	}
+	forbids := compiled.(compiledForbids)
+
+	segmentRunes := []rune(segment.Value)
+	var logs []parser.ParsingLog
+	for i, phrase := range forbids.phrases {
+		for _, offset := range findPhrase(segmentRunes, forbids.runes[i]) {
+			length := len(forbids.runes[i])
+			logs = append(logs, parser.ParsingLog{
+				Segment: &segment,
+				Level:   parser.ParsingLogLevelWarning,
+				Message: "eiffel.parser.forbids.warning",
+				TranslationArgs: []string{
+					"actual", string(segmentRunes[offset : offset+length]),
+					"forbidden", phrase,
+				},
+				Ranges: []parser.ParsingRange{{
+					Start: offset,
+					End:   offset + length,
+					Level: parser.ParsingLogLevelWarning,
+				}},
+			})
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Ranges[0].Start < logs[j].Ranges[0].Start
+	})
+
+	return logs, nil
+}
+
+// Validate implements the RuleParser interface for the ForbidsRuleParser. It is used to validate rules of the type 'forbids'.
+// The forbids rule expects a slice of strings as value.
+func (p ForbidsRuleParser) Validate(v validation.V, rule BasicRule) []error {
+	_, err := toStringSlice(rule.Value)
+	if err == nil {
+		return nil
+	}
+
+	return []error{RuleInvalidValueError{Rule: &rule, Msg: err.Error()}}
+}
+
+// DisplayType implements the RuleParser interface for the ForbidsRuleParser. Forbids rules are displayed like placeholders.
+func (p ForbidsRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
+	return PlaceholderRuleParser{}.DisplayType(rule)
+}
+
+// segmentRange returns a range spanning the whole segment's value with the given level.
+func segmentRange(segment parser.ParsingSegment, level parser.ParsingLogLevel) parser.ParsingRange {
+	return parser.ParsingRange{Start: 0, End: len([]rune(segment.Value)), Level: level}
+}
+
+// findPhrase returns the offsets of all case-insensitive occurrences of the phrase in the text.
+// Only occurrences on word boundaries are returned, e.g. "may" is not found in "maybe".
+// Offsets are counted in runes. Empty phrases are never found.
+func findPhrase(text []rune, phrase []rune) []int {
+	if len(phrase) == 0 || len(phrase) > len(text) {
+		return nil
+	}
+
+	var offsets []int
+	for i := 0; i+len(phrase) <= len(text); i++ {
+		if !equalFoldRunes(text[i:i+len(phrase)], phrase) {
+			continue
+		}
+
+		if i > 0 && isWordRune(text[i-1]) && isWordRune(phrase[0]) {
+			continue
+		}
+
+		end := i + len(phrase)
+		if end < len(text) && isWordRune(text[end]) && isWordRune(phrase[len(phrase)-1]) {
+			continue
+		}
+
+		offsets = append(offsets, i)
+	}
+
+	return offsets
+}
+
+func equalFoldRunes(a []rune, b []rune) bool {
+	for i := range a {
+		if unicode.ToLower(a[i]) != unicode.ToLower(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
 // prepareSegments prepares segments by trimming whitespaces from the input string and indexing them.
 func prepareSegments(segments []parser.ParsingSegment) map[string]parser.ParsingSegment {
 	indexedSegments := make(map[string]parser.ParsingSegment, len(segments))
@@ -589,6 +997,22 @@ func prepareSegments(segments []parser.ParsingSegment) map[string]parser.Parsing
 	return indexedSegments
 }
 
+// ctxLocale returns the path of the locale of the translator in the context.
+// An empty string is returned if no translator is found in the context.
+func ctxLocale(ctx context.Context) string {
+	translator, ok := util.CtxValue[trans.Translator](ctx, trans.TranslatorContextKey)
+	if !ok || translator == nil {
+		return ""
+	}
+
+	locale := translator.Locale()
+	if locale == nil {
+		return ""
+	}
+
+	return locale.Path
+}
+
 // toStringSlice cast an any value to a slice of strings. If the value is not a slice of strings, an error is returned.
 // This is used by the equalsAny rule parser to cast the rule value to a slice of strings.
 // Important: The function does not convert the slice's elements to strings. It only casts the slice to a slice of strings.
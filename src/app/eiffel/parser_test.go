@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/org-harmony/harmony/src/app/template"
 	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,6 +25,19 @@ func TestBasicParser_Validate(t *testing.T) {
 	assert.ErrorIs(t, errs[1], template.ErrInvalidTemplate)
 }
 
+func TestBasicParser_ValidateUI(t *testing.T) {
+	v := validation.New()
+	bt := basicTemplate()
+	bt.UI = template.UISettings{DefaultVariant: "basicVariant", FieldOrder: []string{"fooRule"}}
+	require.Empty(t, bt.Validate(v, ruleParsers()))
+
+	bt.UI = template.UISettings{DefaultVariant: "unknown", FieldOrder: []string{"fooRule", "unknown"}}
+	errs := bt.Validate(v, ruleParsers())
+	require.Len(t, errs, 3)
+	assert.ErrorIs(t, errs[0], ErrUIUnknownDefaultVariant)
+	assert.ErrorIs(t, errs[1], ErrUIUnknownFieldOrderRule)
+}
+
 func TestBasicParser_Parse(t *testing.T) {
 	bt := basicTemplate()
 	rp := ruleParsers()
@@ -55,6 +69,7 @@ func TestBasicParser_Parse(t *testing.T) {
 		assert.True(t, parsingResult.Flawless(), "parsing result should be flawless")
 		assert.Equal(t, parsingResult.Notices[0].Segment.Name, "optionalErrorTestRule")
 		assert.True(t, parsingResult.Notices[0].Downgrade, "notice should be downgraded for optional rule")
+		assert.Equal(t, []parser.ParsingRange{{Start: 0, End: 3, Level: parser.ParsingLogLevelNotice}}, parsingResult.Notices[0].Ranges)
 	})
 
 	t.Run("missing optional rule with error downgraded to notice", func(t *testing.T) {
@@ -131,6 +146,119 @@ func TestBasicParser_Parse(t *testing.T) {
 	})
 }
 
+func TestBasicParser_ParseSegment(t *testing.T) {
+	bt := basicTemplate()
+	rp := ruleParsers()
+	ctx := context.Background()
+
+	logs, err := bt.ParseSegment(ctx, rp, "basicVariant", parser.ParsingSegment{Name: "stateVerbRule", Value: " is "})
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+
+	logs, err = bt.ParseSegment(ctx, rp, "basicVariant", parser.ParsingSegment{Name: "stateVerbRule", Value: "wrong-state-verb"})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, parser.ParsingLogLevelError, logs[0].Level)
+
+	logs, err = bt.ParseSegment(ctx, rp, "basicVariant", parser.ParsingSegment{Name: "fooRule"})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "eiffel.parser.error.missing-segment", logs[0].Message)
+
+	logs, err = bt.ParseSegment(ctx, rp, "basicVariant", parser.ParsingSegment{Name: "optionalErrorTestRule", Value: "bar"})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, parser.ParsingLogLevelNotice, logs[0].Level)
+	assert.True(t, logs[0].Downgrade)
+
+	logs, err = bt.ParseSegment(ctx, rp, "basicVariant", parser.ParsingSegment{Name: "optionalMissingTestRule"})
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+
+	_, err = bt.ParseSegment(ctx, rp, "invalidVariant", parser.ParsingSegment{Name: "fooRule", Value: "foo"})
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+
+	_, err = bt.ParseSegment(ctx, rp, "basicVariant", parser.ParsingSegment{Name: "unknownRule", Value: "foo"})
+	assert.ErrorAs(t, err, &RuleMissingError{})
+}
+
+func TestBasicParser_LocalizedRuleValues(t *testing.T) {
+	bt := basicTemplate()
+	rp := ruleParsers()
+	bt.Rules["stateVerbRule"] = BasicRule{
+		Name: "State Verb Rule",
+		Type: "equalsAny",
+		Value: map[string]any{
+			"de": []any{"war", "wird", "ist"},
+			"en": []any{"was", "will", "is"},
+		},
+	}
+
+	segments := func(stateVerb string) []parser.ParsingSegment {
+		return []parser.ParsingSegment{
+			{Name: "stateVerbRule", Value: stateVerb},
+			{Name: "fooRule", Value: "foo"},
+		}
+	}
+
+	t.Run("localized template is valid", func(t *testing.T) {
+		errs := bt.Validate(validation.New(), rp)
+		require.Len(t, errs, 0)
+	})
+
+	t.Run("value is resolved by the translator's locale", func(t *testing.T) {
+		parsingResult, err := bt.Parse(localeCtx("de"), rp, "basicVariant", segments("ist")...)
+		require.NoError(t, err)
+		assert.Len(t, parsingResult.Errors, 0)
+
+		parsingResult, err = bt.Parse(localeCtx("en"), rp, "basicVariant", segments("ist")...)
+		require.NoError(t, err)
+		assert.Len(t, parsingResult.Errors, 1)
+	})
+
+	t.Run("first locale is used as fallback", func(t *testing.T) {
+		parsingResult, err := bt.Parse(context.Background(), rp, "basicVariant", segments("wird")...)
+		require.NoError(t, err)
+		assert.Len(t, parsingResult.Errors, 0)
+	})
+
+	t.Run("each locale variant is validated", func(t *testing.T) {
+		bt := basicTemplate()
+		bt.Rules["fooRule"] = BasicRule{
+			Name:  "Foo Rule",
+			Type:  "equals",
+			Value: map[string]any{"de": "foo", "en": []any{"foo"}},
+		}
+
+		errs := bt.Validate(validation.New(), rp)
+		require.Len(t, errs, 2)
+		assert.ErrorAs(t, errs[0], &RuleInvalidValueError{})
+		assert.ErrorIs(t, errs[1], template.ErrInvalidTemplate)
+	})
+
+	t.Run("empty localized value is invalid", func(t *testing.T) {
+		bt := basicTemplate()
+		bt.Rules["fooRule"] = BasicRule{Name: "Foo Rule", Type: "equals", Value: map[string]any{}}
+
+		errs := bt.Validate(validation.New(), rp)
+		require.Len(t, errs, 2)
+		assert.Equal(t, "eiffel.parser.error.empty-localized-value", errs[0].Error())
+	})
+
+	t.Run("localize rules for display", func(t *testing.T) {
+		bt := basicTemplate()
+		bt.Rules["stateVerbRule"] = BasicRule{Name: "State Verb Rule", Type: "equalsAny", Value: map[string]any{"de": []any{"ist"}, "en": []any{"is"}}}
+		bt.LocalizeRules(localeCtx("en"))
+		assert.Equal(t, []any{"is"}, bt.Rules["stateVerbRule"].Value)
+		assert.Equal(t, "foo", bt.Rules["fooRule"].Value)
+	})
+}
+
+func localeCtx(locale string) context.Context {
+	translator := trans.NewTranslator(trans.ForLocale(&trans.Locale{Path: locale}))
+	return context.WithValue(context.Background(), trans.TranslatorContextKey, translator)
+}
+
 func basicTemplate() *BasicTemplate {
 	return &BasicTemplate{
 		ID:      "test-template",
@@ -203,3 +331,37 @@ func ruleParsers() *RuleParserProvider {
 		},
 	}
 }
+
+func TestForbidsRuleParser(t *testing.T) {
+	rule := BasicRule{
+		Name:  "Forbidden Phrases",
+		Type:  "forbids",
+		Value: []any{"maybe", "as fast as possible", "may"},
+	}
+	p := ForbidsRuleParser{}
+
+	t.Run("valid rule", func(t *testing.T) {
+		assert.Len(t, p.Validate(validation.New(), rule), 0)
+		assert.Len(t, p.Validate(validation.New(), BasicRule{Name: "Invalid", Type: "forbids", Value: "maybe"}), 1)
+	})
+
+	t.Run("offending substrings are reported with offset", func(t *testing.T) {
+		segment := parser.ParsingSegment{Name: "text", Value: "The süßen system should Maybe respond As fast as possible."}
+		logs, err := p.Parse(context.Background(), rule, segment)
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+
+		assert.Equal(t, parser.ParsingLogLevelWarning, logs[0].Level)
+		assert.Equal(t, []string{"actual", "Maybe", "forbidden", "maybe"}, logs[0].TranslationArgs)
+		assert.Equal(t, []parser.ParsingRange{{Start: 24, End: 29, Level: parser.ParsingLogLevelWarning}}, logs[0].Ranges)
+
+		assert.Equal(t, []string{"actual", "As fast as possible", "forbidden", "as fast as possible"}, logs[1].TranslationArgs)
+		assert.Equal(t, 38, logs[1].Ranges[0].Start)
+	})
+
+	t.Run("no warnings for clean segment", func(t *testing.T) {
+		logs, err := p.Parse(context.Background(), rule, parser.ParsingSegment{Name: "text", Value: "The system shall respond within 2 seconds."})
+		require.NoError(t, err)
+		assert.Len(t, logs, 0)
+	})
+}
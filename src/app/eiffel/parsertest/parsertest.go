@@ -0,0 +1,344 @@
+// Package parsertest is a conformance suite for rule parsers (eiffel.RuleParser). Rule parsers are called with rules
+// configured by template authors and segments entered by users, both are untrusted. The suite checks the contracts
+// every rule parser must fulfill, built-in parsers as well as parsers of plugins and third parties:
+//   - Validate, DisplayType and Parse never panic, not even for rule values of unexpected types or unusual segments.
+//   - Validate accepts the valid rules and rejects the invalid rules of the suite.
+//   - DisplayType returns one of the known display types (eiffel.TemplateDisplayType).
+//   - Segments are reported through parsing logs, not errors. Logs have a known level, a message and ranges within the segment.
+//   - Parsing is deterministic, parsing the same segment twice results in the same logs.
+//   - Optional rules never result in errors: invalid segments of optional rules are downgraded (see eiffel.BasicRule.Optional).
+//
+// A rule parser's test declares a Suite and runs it:
+//
+//	func TestRegexRuleParser(t *testing.T) {
+//		parsertest.Run(t, parsertest.Suite{
+//			Type:         "regex",
+//			Parser:       RegexRuleParser{},
+//			ValidRules:   []eiffel.BasicRule{{Value: "^[A-Z]+-[0-9]+$"}},
+//			InvalidRules: []eiffel.BasicRule{{Value: "("}, {Value: 42}},
+//			Cases: []parsertest.Case{
+//				{Name: "match", Rule: eiffel.BasicRule{Value: "^[A-Z]+-[0-9]+$"}, Segment: "REQ-1", Valid: true},
+//				{Name: "mismatch", Rule: eiffel.BasicRule{Value: "^[A-Z]+-[0-9]+$"}, Segment: "req", Valid: false},
+//			},
+//		})
+//	}
+package parsertest
+
+import (
+	"context"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/eiffel"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+const (
+	// ruleName is the technical name of the rule under test in the template built by the suite.
+	ruleName = "rule"
+	// variantName is the technical name of the variant of the template built by the suite.
+	variantName = "conformance"
+)
+
+// DisplayTypes are the known display types a rule parser's DisplayType may return.
+var DisplayTypes = []eiffel.TemplateDisplayType{
+	eiffel.TemplateDisplayString,
+	eiffel.TemplateDisplayInputTypeText,
+	eiffel.TemplateDisplayInputTypeTextarea,
+	eiffel.TemplateDisplayInputTypeSingleSelect,
+}
+
+// HostileValues are rule values of unexpected types and shapes. Validate and Parse must not panic for any of them.
+var HostileValues = []any{
+	nil,
+	"",
+	42,
+	3.14,
+	true,
+	[]any{},
+	[]any{nil, 1, ""},
+	[]string{"a"},
+	map[string]any{},
+	map[string]any{"en": nil},
+	map[string]any{"value": []any{map[string]any{}}},
+}
+
+// HostileSegments are segment values with unusual content. Parse must neither panic nor fail for valid rules.
+var HostileSegments = []string{
+	" ",
+	"\t\n",
+	"ä ö ü ß 漢字 🙂",
+	"\u200b\u0000",
+	"{{ .value }} %s %v ${value}",
+	"<script>alert(1)</script>",
+	`.*+?()[]{}|^$\`,
+	strings.Repeat("long ", 2000),
+}
+
+// Suite is the conformance suite of a rule parser. Rules of the suite without a name or type are named after
+// their index and typed with the suite's Type.
+type Suite struct {
+	// Type is the rule type the parser is registered for, e.g. "regex".
+	Type string
+	// Parser is the rule parser under test.
+	Parser eiffel.RuleParser
+	// Rules are additional rules of the template the rules under test are parsed in keyed by their technical name,
+	// e.g. the rules referenced by combinators. They must not use the technical name "rule".
+	Rules map[string]eiffel.BasicRule
+	// ValidRules are rules that Validate must accept. Each is parsed with the HostileSegments.
+	ValidRules []eiffel.BasicRule
+	// InvalidRules are rules that Validate must reject.
+	InvalidRules []eiffel.BasicRule
+	// Cases are segments parsed with valid rules and the expected outcome.
+	Cases []Case
+	// Providers returns the rule parser provider the parser is registered in, eiffel.RuleParsers by default.
+	Providers func() *eiffel.RuleParserProvider
+	// Ctx returns the context of parsing, e.g. to set the user's locale. It is context.Background by default.
+	Ctx func() context.Context
+}
+
+// Case is a segment parsed with a valid rule. The rule's Optional flag is ignored,
+// each case is parsed with the rule required and optional.
+type Case struct {
+	Name    string
+	Rule    eiffel.BasicRule
+	Segment string
+	// Valid expects the required rule to report no errors for the segment. Otherwise, at least one error is expected.
+	Valid bool
+	// Messages optionally expects the messages of the logs in the order they are reported.
+	Messages []string
+}
+
+// Run runs the conformance suite as subtests of the test: validate, display-type, parse, hostile and optional.
+func Run(t *testing.T, suite Suite) {
+	require.NotNil(t, suite.Parser, "the suite's parser is required")
+	require.NotEmpty(t, suite.Type, "the suite's rule type is required")
+
+	t.Run("validate", func(t *testing.T) {
+		v := validation.New()
+		for i, rule := range suite.ValidRules {
+			rule = suite.rule(rule, i)
+			errs, panicked := catch(func() []error { return suite.Parser.Validate(v, rule) })
+			noPanic(t, panicked, "Validate panicked for valid rule %s", describe(rule))
+			assert.Empty(t, errs, "Validate rejected valid rule %s", describe(rule))
+		}
+
+		for i, rule := range suite.InvalidRules {
+			rule = suite.rule(rule, i)
+			errs, panicked := catch(func() []error { return suite.Parser.Validate(v, rule) })
+			noPanic(t, panicked, "Validate panicked for invalid rule %s", describe(rule))
+			assert.NotEmpty(t, errs, "Validate accepted invalid rule %s", describe(rule))
+		}
+
+		for i, value := range HostileValues {
+			rule := suite.rule(eiffel.BasicRule{Value: value}, i)
+			_, panicked := catch(func() []error { return suite.Parser.Validate(v, rule) })
+			noPanic(t, panicked, "Validate panicked for rule %s", describe(rule))
+		}
+	})
+
+	t.Run("display-type", func(t *testing.T) {
+		rules := append(append([]eiffel.BasicRule{}, suite.ValidRules...), suite.InvalidRules...)
+		for i, rule := range rules {
+			rule = suite.rule(rule, i)
+			displayType, panicked := catch(func() eiffel.TemplateDisplayType { return suite.Parser.DisplayType(rule) })
+			noPanic(t, panicked, "DisplayType panicked for rule %s", describe(rule))
+			if panicked == nil {
+				assert.Contains(t, DisplayTypes, displayType, "DisplayType returned an unknown display type for rule %s", describe(rule))
+			}
+		}
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		for i, c := range suite.Cases {
+			t.Run(caseName(c, i), func(t *testing.T) {
+				rule := suite.rule(c.Rule, i)
+				rule.Optional = false
+
+				logs := suite.parse(t, rule, c.Segment)
+				if c.Valid {
+					assert.Empty(t, errorLogs(logs), "the segment %q is valid", c.Segment)
+				} else {
+					assert.NotEmpty(t, errorLogs(logs), "the segment %q is invalid", c.Segment)
+				}
+
+				if c.Messages != nil {
+					messages := make([]string, 0, len(logs))
+					for _, log := range logs {
+						messages = append(messages, log.Message)
+					}
+					assert.Equal(t, c.Messages, messages)
+				}
+
+				assert.Equal(t, logs, suite.parse(t, rule, c.Segment), "parsing the segment %q again resulted in other logs", c.Segment)
+			})
+		}
+	})
+
+	t.Run("hostile", func(t *testing.T) {
+		for i, rule := range suite.ValidRules {
+			rule = suite.rule(rule, i)
+			for _, segment := range HostileSegments {
+				suite.parse(t, rule, segment)
+			}
+		}
+
+		// invalid rules may fail parsing but must not panic
+		ctx := suite.ctx()
+		rules := append(append([]eiffel.BasicRule{}, suite.InvalidRules...), hostileRules(suite)...)
+		for i, rule := range rules {
+			rule = suite.rule(rule, i)
+			_, panicked := catch(func() error {
+				_, err := suite.Parser.Parse(ctx, rule, parser.ParsingSegment{Name: ruleName, Value: "value"})
+				return err
+			})
+			noPanic(t, panicked, "Parse panicked for rule %s", describe(rule))
+		}
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		for i, c := range suite.Cases {
+			rule := suite.rule(c.Rule, i)
+			rule.Optional = true
+
+			logs := suite.parse(t, rule, c.Segment)
+			assert.Empty(t, errorLogs(logs), "the optional rule %s reported errors for the segment %q", describe(rule), c.Segment)
+		}
+	})
+}
+
+// parse parses the segment with the rule in a template of the suite (see eiffel.BasicTemplate.ParseSegment)
+// and checks that parsing neither panics nor fails and that the logs are well-formed.
+func (s Suite) parse(t *testing.T, rule eiffel.BasicRule, segment string) []parser.ParsingLog {
+	t.Helper()
+
+	providers := eiffel.RuleParsers
+	if s.Providers != nil {
+		providers = s.Providers
+	}
+	ruleParsers := providers()
+	ruleParsers.Register(s.Type, s.Parser)
+
+	rules := make(map[string]eiffel.BasicRule, len(s.Rules)+1)
+	for name, r := range s.Rules {
+		rules[name] = r
+	}
+	rules[ruleName] = rule
+
+	bt := &eiffel.BasicTemplate{
+		ID:       "conformance",
+		Name:     "Conformance",
+		Version:  "1.0.0",
+		Rules:    rules,
+		Variants: map[string]eiffel.BasicVariant{variantName: {Name: "Conformance", Rules: []string{ruleName}}},
+	}
+
+	type parsed struct {
+		logs []parser.ParsingLog
+		err  error
+	}
+	result, panicked := catch(func() parsed {
+		logs, err := bt.ParseSegment(s.ctx(), ruleParsers, variantName, parser.ParsingSegment{Name: ruleName, Value: segment})
+		return parsed{logs: logs, err: err}
+	})
+	if !noPanic(t, panicked, "Parse panicked for the segment %q and rule %s", segment, describe(rule)) {
+		return nil
+	}
+	if !assert.NoError(t, result.err, "Parse failed for the segment %q and rule %s, segments are reported through logs", segment, describe(rule)) {
+		return nil
+	}
+
+	length := len([]rune(strings.TrimSpace(segment)))
+	for _, log := range result.logs {
+		assert.True(t, knownLevel(log.Level), "unknown level %d of the log %q", log.Level, log.Message)
+		assert.NotEmpty(t, log.Message, "log without a message for the segment %q", segment)
+
+		for _, r := range log.Ranges {
+			assert.True(t, knownLevel(r.Level), "unknown level %d of a range of the log %q", r.Level, log.Message)
+			assert.True(t, 0 <= r.Start && r.Start <= r.End && r.End <= length,
+				"the range [%d, %d) of the log %q is not within the segment %q", r.Start, r.End, log.Message, segment)
+		}
+	}
+
+	return result.logs
+}
+
+// rule names and types the rule of the suite if it is not named or typed yet, see Suite.
+func (s Suite) rule(rule eiffel.BasicRule, i int) eiffel.BasicRule {
+	if rule.Name == "" {
+		rule.Name = fmt.Sprintf("%s %d", s.Type, i)
+	}
+	if rule.Type == "" {
+		rule.Type = s.Type
+	}
+
+	return rule
+}
+
+func (s Suite) ctx() context.Context {
+	if s.Ctx == nil {
+		return context.Background()
+	}
+
+	return s.Ctx()
+}
+
+// hostileRules returns rules of the suite's type with the HostileValues as values and extra properties.
+func hostileRules(s Suite) []eiffel.BasicRule {
+	rules := make([]eiffel.BasicRule, 0, 2*len(HostileValues))
+	for _, value := range HostileValues {
+		rules = append(rules, eiffel.BasicRule{Value: value})
+		rules = append(rules, eiffel.BasicRule{Value: value, Extra: map[string]any{"message": value, "level": value, "allowOthers": value}})
+	}
+
+	return rules
+}
+
+// catch calls f and returns the recovered value if f panicked.
+func catch[T any](f func() T) (result T, panicked any) {
+	defer func() {
+		panicked = recover()
+	}()
+
+	return f(), nil
+}
+
+// noPanic asserts that the recovered value of catch is nil. The panic's value is reported otherwise.
+func noPanic(t *testing.T, panicked any, msgAndArgs ...any) bool {
+	t.Helper()
+	if panicked == nil {
+		return true
+	}
+
+	return assert.Fail(t, fmt.Sprintf("panic: %v", panicked), msgAndArgs...)
+}
+
+func errorLogs(logs []parser.ParsingLog) []parser.ParsingLog {
+	var errs []parser.ParsingLog
+	for _, log := range logs {
+		if log.Level == parser.ParsingLogLevelError {
+			errs = append(errs, log)
+		}
+	}
+
+	return errs
+}
+
+func knownLevel(level parser.ParsingLogLevel) bool {
+	return level == parser.ParsingLogLevelError || level == parser.ParsingLogLevelWarning || level == parser.ParsingLogLevelNotice
+}
+
+func caseName(c Case, i int) string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return fmt.Sprintf("case %d", i)
+}
+
+func describe(rule eiffel.BasicRule) string {
+	return fmt.Sprintf("%q (value %#v)", rule.Name, rule.Value)
+}
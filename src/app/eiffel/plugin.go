@@ -0,0 +1,338 @@
+package eiffel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	// PluginProtocolVersion is the version of the protocol spoken with plugin processes. It is sent with each request.
+	PluginProtocolVersion = 1
+	// DefaultPluginTimeout is the timeout of a single plugin call if the plugin does not configure one.
+	DefaultPluginTimeout = 2 * time.Second
+	// DefaultPluginMaxOutput is the maximum size of a plugin's response in bytes if the plugin does not configure one.
+	DefaultPluginMaxOutput = 1 << 20
+)
+
+var (
+	// ErrBuiltinRuleType is returned when trying to register a plugin for one of the rule types registered by RuleParsers.
+	ErrBuiltinRuleType = errors.New("built-in rule types can not be replaced by plugins")
+	// ErrInvalidPlugin is returned if a plugin is misconfigured, e.g. if the rule type or command is missing.
+	ErrInvalidPlugin = errors.New("invalid rule parser plugin")
+	// ErrPluginFailed is returned if a plugin process failed, timed out, exceeded its output limit or responded with an invalid response.
+	ErrPluginFailed = errors.New("rule parser plugin failed")
+)
+
+// plugins are the rule parsers registered through RegisterPlugin keyed by their rule type.
+var plugins = struct {
+	parsers map[string]RuleParser
+	mu      sync.RWMutex
+}{parsers: map[string]RuleParser{}}
+
+// PluginCfg configures an external rule parser. The plugin is an executable that is started for each call
+// and speaks the plugin protocol on stdin and stdout, see PluginRuleParser.
+type PluginCfg struct {
+	// Type is the rule type the plugin parses, e.g. "regex". It is referenced by BasicRule.Type.
+	Type string `toml:"type"`
+	// Command is the path to the plugin's executable.
+	Command string `toml:"command"`
+	// Args are passed to the plugin's executable.
+	Args []string `toml:"args"`
+	// Env is the complete environment of the plugin process (KEY=value). The environment of HARMONY is not passed on.
+	Env []string `toml:"env"`
+	// Display is the TemplateDisplayType of the plugin's rules. By default, they are displayed like placeholders.
+	Display string `toml:"display"`
+	// Timeout is the timeout of a single call in milliseconds. Defaults to DefaultPluginTimeout.
+	Timeout int `toml:"timeout"`
+	// MaxOutput is the maximum size of the plugin's response in bytes. Defaults to DefaultPluginMaxOutput.
+	MaxOutput int `toml:"max_output"`
+}
+
+// PluginRuleParser is a RuleParser delegating to an external executable. This allows third parties to add rule types
+// without forking HARMONY and in any language. Each call starts a new process which is killed after the timeout,
+// its environment only contains the configured variables and its response is limited in size.
+// Therefore, a misbehaving plugin can neither block parsing nor keep state between calls.
+// This is no replacement for an OS-level sandbox (e.g. a container), plugins run with the permissions of HARMONY.
+//
+// The protocol is a single JSON request written to the process' stdin, which must respond with a single JSON response on stdout
+// and exit with status 0. Requests contain the protocol version, the action ("parse" or "validate"), the rule and,
+// for parsing, the segment and the user's locale:
+//
+//	{"version": 1, "action": "parse", "rule": {"name": "ID", "type": "regex", "value": "^[A-Z]+-[0-9]+$", ...}, "segment": {"name": "id", "value": "REQ-1"}, "locale": "en"}
+//
+// Parsing responds with logs whose level is "error", "warning" or "notice" and whose ranges are counted in characters (runes).
+// Validation responds with errors describing why the rule's configuration is invalid. Messages are displayed as is,
+// they should be in the requested locale:
+//
+//	{"logs": [{"level": "error", "message": "The ID must look like REQ-1.", "ranges": [{"start": 0, "end": 3, "level": "error"}]}]}
+//	{"errors": ["The value must be a regular expression."]}
+//
+// A failing plugin never fails parsing, instead a warning is reported that the rule could not be checked.
+type PluginRuleParser struct {
+	cfg PluginCfg
+}
+
+// PluginError is a validation error of a rule reported by a plugin or the failure to validate the rule with the plugin.
+type PluginError struct {
+	Rule *BasicRule
+	// Message is the plugin's message. It is empty if the plugin failed.
+	Message string
+}
+
+// pluginRequest is the request written to the plugin's stdin.
+type pluginRequest struct {
+	Version int            `json:"version"`
+	Action  string         `json:"action"`
+	Rule    BasicRule      `json:"rule"`
+	Segment *pluginSegment `json:"segment,omitempty"`
+	Locale  string         `json:"locale,omitempty"`
+}
+
+// pluginSegment is the segment of a pluginRequest.
+type pluginSegment struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// pluginResponse is the response read from the plugin's stdout.
+type pluginResponse struct {
+	Logs   []pluginLog `json:"logs"`
+	Errors []string    `json:"errors"`
+}
+
+// pluginLog is a parsing log of a pluginResponse.
+type pluginLog struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Ranges  []struct {
+		Start int    `json:"start"`
+		End   int    `json:"end"`
+		Level string `json:"level"`
+	} `json:"ranges"`
+}
+
+// limitedBuffer is a bytes.Buffer failing writes beyond its limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+// NewPluginRuleParser constructs a new PluginRuleParser from the config.
+// It returns ErrInvalidPlugin if the rule type or command is missing or the display type is unknown.
+func NewPluginRuleParser(cfg PluginCfg) (*PluginRuleParser, error) {
+	if cfg.Type == "" || cfg.Command == "" {
+		return nil, fmt.Errorf("%w: type and command are required", ErrInvalidPlugin)
+	}
+
+	switch TemplateDisplayType(cfg.Display) {
+	case "", TemplateDisplayString, TemplateDisplayInputTypeText, TemplateDisplayInputTypeTextarea, TemplateDisplayInputTypeSingleSelect:
+	default:
+		return nil, fmt.Errorf("%w: unknown display type %q of %s", ErrInvalidPlugin, cfg.Display, cfg.Type)
+	}
+
+	if cfg.Env == nil {
+		cfg.Env = []string{}
+	}
+
+	return &PluginRuleParser{cfg: cfg}, nil
+}
+
+// RegisterPlugin registers a rule parser for the rule type. All RuleParserProvider constructed by RuleParsers afterward
+// contain the rule parser. Besides PluginRuleParser, this allows packages compiled into HARMONY to register rule parsers, e.g. on init.
+// A plugin registered for the same rule type before is replaced. It returns ErrBuiltinRuleType for the built-in rule types.
+func RegisterPlugin(ruleType string, ruleParser RuleParser) error {
+	if _, ok := builtinRuleParsers()[ruleType]; ok {
+		return fmt.Errorf("%w: %s", ErrBuiltinRuleType, ruleType)
+	}
+
+	plugins.mu.Lock()
+	defer plugins.mu.Unlock()
+
+	plugins.parsers[ruleType] = ruleParser
+
+	return nil
+}
+
+// RegisterPlugins constructs a PluginRuleParser per config and registers it for its rule type, see RegisterPlugin.
+func RegisterPlugins(cfgs []PluginCfg) error {
+	for _, cfg := range cfgs {
+		ruleParser, err := NewPluginRuleParser(cfg)
+		if err != nil {
+			return err
+		}
+
+		err = RegisterPlugin(cfg.Type, ruleParser)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Parse implements the RuleParser interface for the PluginRuleParser. The rule's value is resolved to the user's locale
+// (see LocalizedValue) before it is passed to the plugin. If the plugin fails, a parsing warning is reported.
+func (p *PluginRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+	rule.Value = LocalizedValue(ctx, rule)
+
+	response, err := p.call(ctx, pluginRequest{
+		Action:  "parse",
+		Rule:    rule,
+		Segment: &pluginSegment{Name: segment.Name, Value: segment.Value},
+		Locale:  ctxLocale(ctx),
+	})
+	if err != nil {
+		return []parser.ParsingLog{{
+			Segment:         &segment,
+			Level:           parser.ParsingLogLevelWarning,
+			Message:         "eiffel.parser.plugin.unavailable",
+			TranslationArgs: []string{"type", rule.Type},
+		}}, nil
+	}
+
+	logs := make([]parser.ParsingLog, 0, len(response.Logs))
+	for _, log := range response.Logs {
+		level, ok := parsingLogLevel(log.Level)
+		if !ok {
+			continue
+		}
+
+		ranges := make([]parser.ParsingRange, 0, len(log.Ranges))
+		for _, r := range log.Ranges {
+			rangeLevel, ok := parsingLogLevel(r.Level)
+			if !ok {
+				rangeLevel = level
+			}
+
+			ranges = append(ranges, parser.ParsingRange{Start: r.Start, End: r.End, Level: rangeLevel})
+		}
+
+		logs = append(logs, parser.ParsingLog{
+			Segment: &segment,
+			Level:   level,
+			// the plugin's message is passed as an argument because the message itself would be parsed as a translation template
+			Message:         "eiffel.parser.plugin.message",
+			TranslationArgs: []string{"message", log.Message},
+			Ranges:          ranges,
+		})
+	}
+
+	return logs, nil
+}
+
+// Validate implements the RuleParser interface for the PluginRuleParser. Each error reported by the plugin is returned as a PluginError.
+// If the plugin fails, the rule is considered invalid as it could not be validated.
+func (p *PluginRuleParser) Validate(v validation.V, rule BasicRule) []error {
+	response, err := p.call(context.Background(), pluginRequest{Action: "validate", Rule: rule})
+	if err != nil {
+		return []error{PluginError{Rule: &rule}}
+	}
+
+	errs := make([]error, 0, len(response.Errors))
+	for _, message := range response.Errors {
+		errs = append(errs, PluginError{Rule: &rule, Message: message})
+	}
+
+	return errs
+}
+
+// DisplayType implements the RuleParser interface for the PluginRuleParser. It returns the configured display type
+// and otherwise displays the rule like a placeholder.
+func (p *PluginRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
+	if p.cfg.Display != "" {
+		return TemplateDisplayType(p.cfg.Display)
+	}
+
+	return PlaceholderRuleParser{}.DisplayType(rule)
+}
+
+// call starts the plugin process, writes the request to its stdin and reads the response from its stdout.
+// The process is killed if it exceeds the timeout. It returns ErrPluginFailed if the process or its response is invalid.
+func (p *PluginRuleParser) call(ctx context.Context, request pluginRequest) (pluginResponse, error) {
+	timeout := DefaultPluginTimeout
+	if p.cfg.Timeout > 0 {
+		timeout = time.Duration(p.cfg.Timeout) * time.Millisecond
+	}
+	maxOutput := DefaultPluginMaxOutput
+	if p.cfg.MaxOutput > 0 {
+		maxOutput = p.cfg.MaxOutput
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request.Version = PluginProtocolVersion
+	input, err := json.Marshal(request)
+	if err != nil {
+		return pluginResponse{}, errors.Join(ErrPluginFailed, err)
+	}
+
+	stdout := &limitedBuffer{limit: maxOutput}
+	stderr := &limitedBuffer{limit: 1024}
+
+	cmd := exec.CommandContext(ctx, p.cfg.Command, p.cfg.Args...)
+	cmd.Env = p.cfg.Env
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	err = cmd.Run()
+	if err != nil {
+		return pluginResponse{}, errors.Join(ErrPluginFailed, fmt.Errorf("%s: %w (%s)", p.cfg.Type, err, stderr.String()))
+	}
+
+	var response pluginResponse
+	err = json.Unmarshal(stdout.Bytes(), &response)
+	if err != nil {
+		return pluginResponse{}, errors.Join(ErrPluginFailed, fmt.Errorf("%s: invalid response: %w", p.cfg.Type, err))
+	}
+
+	return response, nil
+}
+
+// Write on limitedBuffer writes to the buffer until the limit is reached.
+// Writes beyond the limit fail, which makes the process fail on writing too much output.
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	if b.Len()+len(data) > b.limit {
+		return 0, errors.New("output limit exceeded")
+	}
+
+	return b.Buffer.Write(data)
+}
+
+// Error on PluginError returns the error code of the error.
+func (e PluginError) Error() string {
+	if e.Message == "" {
+		return "eiffel.parser.plugin.validation-unavailable"
+	}
+
+	return "eiffel.parser.plugin.invalid-rule"
+}
+
+// Translate on PluginError translates the error using the given translator.
+// Rule name and type as well as the plugin's message are passed in.
+func (e PluginError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "rule", e.Rule.Name, "type", e.Rule.Type, "message", e.Message)
+}
+
+// parsingLogLevel converts the name of a level (see parser.ParsingLogLevel.String) to a parser.ParsingLogLevel.
+// It returns false for unknown levels.
+func parsingLogLevel(level string) (parser.ParsingLogLevel, bool) {
+	for _, l := range []parser.ParsingLogLevel{parser.ParsingLogLevelError, parser.ParsingLogLevelWarning, parser.ParsingLogLevelNotice} {
+		if l.String() == level {
+			return l, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,159 @@
+package eiffel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/config"
+	"github.com/org-harmony/harmony/src/core/herr"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// TestHelperPlugin is not a real test. It is started as a plugin process by the other tests
+// and implements a "regex" rule type through the plugin protocol.
+func TestHelperPlugin(t *testing.T) {
+	mode := os.Getenv("HARMONY_TEST_PLUGIN")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	switch mode {
+	case "sleep":
+		time.Sleep(time.Minute)
+	case "garbage":
+		fmt.Print("not json")
+		return
+	case "flood":
+		for {
+			fmt.Print("flood")
+		}
+	}
+
+	var request pluginRequest
+	input, _ := io.ReadAll(os.Stdin)
+	_ = json.Unmarshal(input, &request)
+
+	response := pluginResponse{}
+	expression, err := regexp.Compile(fmt.Sprint(request.Rule.Value))
+	switch {
+	case request.Action == "validate" && err != nil:
+		response.Errors = []string{"invalid expression"}
+	case request.Action == "parse" && !expression.MatchString(request.Segment.Value):
+		response.Logs = []pluginLog{{Level: "error", Message: "{{ .injected }} no match in " + request.Locale}}
+		response.Logs[0].Ranges = append(response.Logs[0].Ranges, struct {
+			Start int    `json:"start"`
+			End   int    `json:"end"`
+			Level string `json:"level"`
+		}{Start: 0, End: 1})
+	}
+
+	_ = json.NewEncoder(os.Stdout).Encode(response)
+}
+
+func TestPluginRuleParser(t *testing.T) {
+	regex := helperPlugin(t, "regex", 0)
+	rule := BasicRule{Name: "ID", Type: "regex", Value: "^REQ-[0-9]+$"}
+	segment := parser.ParsingSegment{Name: "id", Value: "ABC"}
+
+	logs, err := regex.Parse(context.Background(), rule, parser.ParsingSegment{Name: "id", Value: "REQ-1"})
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+
+	logs, err = regex.Parse(context.Background(), rule, segment)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, parser.ParsingLogLevelError, logs[0].Level)
+	assert.Equal(t, "eiffel.parser.plugin.message", logs[0].Message)
+	assert.Equal(t, []string{"message", "{{ .injected }} no match in "}, logs[0].TranslationArgs)
+	assert.Equal(t, []parser.ParsingRange{{Start: 0, End: 1, Level: parser.ParsingLogLevelError}}, logs[0].Ranges)
+
+	rule.Value = map[string]any{"de": "^ANF-[0-9]+$", "en": "^REQ-[0-9]+$"}
+	logs, err = regex.Parse(context.Background(), rule, parser.ParsingSegment{Name: "id", Value: "ANF-1"})
+	require.NoError(t, err)
+	assert.Empty(t, logs, "localized values are resolved before they are passed to the plugin")
+
+	assert.Empty(t, regex.Validate(validation.New(), BasicRule{Name: "ID", Type: "regex", Value: "^REQ$"}))
+	errs := regex.Validate(validation.New(), BasicRule{Name: "ID", Type: "regex", Value: "("})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "invalid expression", errs[0].(PluginError).Message)
+
+	assert.Equal(t, TemplateDisplayInputTypeTextarea, regex.DisplayType(BasicRule{Size: "full"}))
+	regex.cfg.Display = string(TemplateDisplayString)
+	assert.Equal(t, TemplateDisplayString, regex.DisplayType(BasicRule{Size: "full"}))
+
+	for _, mode := range []string{"sleep", "garbage", "flood"} {
+		failing := helperPlugin(t, mode, 200)
+
+		start := time.Now()
+		logs, err = failing.Parse(context.Background(), rule, segment)
+		require.NoError(t, err, mode)
+		require.Len(t, logs, 1, mode)
+		assert.Equal(t, parser.ParsingLogLevelWarning, logs[0].Level, mode)
+		assert.Equal(t, "eiffel.parser.plugin.unavailable", logs[0].Message, mode)
+		assert.Less(t, time.Since(start), 5*time.Second, mode)
+
+		errs = failing.Validate(validation.New(), rule)
+		require.Len(t, errs, 1, mode)
+		assert.Equal(t, "eiffel.parser.plugin.validation-unavailable", errs[0].Error(), mode)
+	}
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	assert.ErrorIs(t, RegisterPlugin("equals", EqualsRuleParser{}), ErrBuiltinRuleType)
+	assert.ErrorIs(t, RegisterPlugins([]PluginCfg{{Type: "regex"}}), ErrInvalidPlugin)
+	assert.ErrorIs(t, RegisterPlugins([]PluginCfg{{Type: "regex", Command: "regex", Display: "checkbox"}}), ErrInvalidPlugin)
+	assert.False(t, RuleParsers().Lookup("regex"))
+
+	require.NoError(t, RegisterPlugins([]PluginCfg{{Type: "regex", Command: "regex"}}))
+	defer func() {
+		plugins.mu.Lock()
+		delete(plugins.parsers, "regex")
+		plugins.mu.Unlock()
+	}()
+
+	assert.True(t, RuleParsers().Lookup("regex"))
+	assert.True(t, RuleParsers().Lookup("equals"))
+}
+
+func TestLoadCfg(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "eiffel.toml"), []byte("[[plugin]]\ntype = \"loaded\"\ncommand = \"loaded\"\n"), 0o600))
+	defer func() {
+		plugins.mu.Lock()
+		delete(plugins.parsers, "loaded")
+		plugins.mu.Unlock()
+	}()
+
+	cfg, err := LoadCfg(validation.New(), config.FromDir(dir))
+	require.NoError(t, err)
+	require.Len(t, cfg.Plugins, 1)
+	assert.True(t, RuleParsers().Lookup("loaded"), "plugins of the loaded config are registered")
+
+	_, err = LoadCfg(validation.New(), config.FromDir(t.TempDir()))
+	assert.ErrorIs(t, err, herr.ErrReadFile)
+}
+
+// helperPlugin returns a PluginRuleParser starting the test binary in the mode of TestHelperPlugin.
+func helperPlugin(t *testing.T, mode string, timeout int) *PluginRuleParser {
+	p, err := NewPluginRuleParser(PluginCfg{
+		Type:      "regex",
+		Command:   os.Args[0],
+		Args:      []string{"-test.run=^TestHelperPlugin$"},
+		Env:       []string{"HARMONY_TEST_PLUGIN=" + mode},
+		Timeout:   timeout,
+		MaxOutput: 4096,
+	})
+	require.NoError(t, err)
+
+	return p
+}
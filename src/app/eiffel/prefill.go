@@ -0,0 +1,213 @@
+package eiffel
+
+import (
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultSourceValue prefills the fixed value of the default. It is the default source.
+	DefaultSourceValue = "value"
+	// DefaultSourceLastUsed prefills the value last used for the rule in the user's history of the variant (see History).
+	// The fixed value of the default is prefilled if the rule was not used yet.
+	DefaultSourceLastUsed = "lastUsed"
+	// DefaultSourceProfile prefills a field of the user's profile, see the ProfileField constants.
+	DefaultSourceProfile = "profile"
+	// ProfileFieldFirstname is the user's first name.
+	ProfileFieldFirstname = "firstname"
+	// ProfileFieldLastname is the user's last name.
+	ProfileFieldLastname = "lastname"
+	// ProfileFieldName is the user's full name.
+	ProfileFieldName = "name"
+	// ProfileFieldEmail is the user's email address.
+	ProfileFieldEmail = "email"
+	// ExtraUntouchedDefault is set in the Extra of the log reporting an untouched default, see UntouchedDefaults.
+	ExtraUntouchedDefault = "untouchedDefault"
+)
+
+// BasicDefault is the default value of a rule prefilled in the elicitation form, see Prefill.
+//
+// Example:
+//
+//	"default": {"source": "lastUsed", "value": "The system"}
+type BasicDefault struct {
+	// Source is the source of the prefilled value: DefaultSourceValue (default), DefaultSourceLastUsed or DefaultSourceProfile.
+	Source string `json:"source"`
+	// Value is the fixed value. It is the fallback of the last used value.
+	Value string `json:"value"`
+	// Field is the field of the user's profile prefilled for the DefaultSourceProfile.
+	Field string `json:"field"`
+}
+
+// PrefillSources are the sources of values prefilled in the elicitation form. Sources may be nil, defaults of missing
+// sources fall back to their fixed value.
+type PrefillSources struct {
+	User *user.User
+	// History is the history of the variant in the user's session.
+	History *History
+}
+
+// DefaultError is returned if the default of a rule is invalid. It is returned by BasicTemplate.Validate.
+type DefaultError struct {
+	Rule string
+	// Msg is the error message, it is translated using the parameter "rule" (rule's name).
+	Msg string
+}
+
+// Prefill returns the values prefilled in the elicitation form for the variant's rules with a default keyed by the rules' names.
+// Empty values are omitted. The prefilled values are passed to the parser (see WithPrefilled) to log untouched defaults.
+func Prefill(bt *BasicTemplate, variant BasicVariant, sources PrefillSources) map[string]string {
+	var lastUsed map[string]string
+	if sources.History != nil && len(sources.History.Entries) > 0 {
+		lastUsed = sources.History.Entries[len(sources.History.Entries)-1].SegmentMap
+	}
+
+	prefilled := make(map[string]string)
+	for _, ruleName := range variant.Rules {
+		rule, ok := bt.Rules[ruleName]
+		if !ok || rule.Default == nil {
+			continue
+		}
+
+		value := rule.Default.Value
+		switch rule.Default.Source {
+		case DefaultSourceLastUsed:
+			if last := strings.TrimSpace(lastUsed[ruleName]); last != "" {
+				value = last
+			}
+		case DefaultSourceProfile:
+			value = profileField(sources.User, rule.Default.Field)
+		}
+
+		if value = strings.TrimSpace(value); value != "" {
+			prefilled[ruleName] = value
+		}
+	}
+
+	return prefilled
+}
+
+// PrefilledFromRequest returns the values prefilled in the elicitation form keyed by the rules' names.
+// Prefilled values are expected in the form of "prefilled-<name>".
+func PrefilledFromRequest(request *http.Request) (map[string]string, error) {
+	if err := request.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	prefilled := make(map[string]string)
+	for name, values := range request.Form {
+		if !strings.HasPrefix(name, "prefilled-") || len(values) < 1 {
+			continue
+		}
+
+		prefilled[strings.TrimPrefix(name, "prefilled-")] = values[0]
+	}
+
+	return prefilled, nil
+}
+
+// WithPrefilled passes the values prefilled in the elicitation form (see Prefill) to the parser. Segments which still
+// equal their prefilled value are untouched defaults, see UntouchedDefaults. If it is not passed, the fixed values of the
+// rules' defaults are considered prefilled.
+func WithPrefilled(prefilled map[string]string) ParseOption {
+	return func(o *parseOptions) {
+		o.prefilled = prefilled
+	}
+}
+
+// UntouchedDefaults returns the names of the rules whose segment was parsed with an untouched default.
+// Untouched defaults are logged as notices with the ExtraUntouchedDefault set, e.g. to track them in statistics.
+func UntouchedDefaults(result parser.ParsingResult) []string {
+	var rules []string
+	for _, log := range result.Notices {
+		if untouched, _ := log.Extra[ExtraUntouchedDefault].(bool); untouched && log.Segment != nil {
+			rules = append(rules, log.Segment.Name)
+		}
+	}
+
+	return rules
+}
+
+// untouchedDefaultLog returns the notice of an untouched default if the segment equals the prefilled value of the rule.
+// The fixed value of the rule's default is the prefilled value if no prefilled values are passed in.
+func untouchedDefaultLog(rule BasicRule, segment parser.ParsingSegment, prefilled map[string]string) []parser.ParsingLog {
+	value, ok := prefilled[segment.Name]
+	if prefilled == nil && rule.Default != nil && rule.Default.Source != DefaultSourceProfile {
+		value, ok = rule.Default.Value, true
+	}
+
+	if !ok || segment.Value == "" || strings.TrimSpace(value) != segment.Value {
+		return nil
+	}
+
+	return []parser.ParsingLog{{
+		Segment:         &parser.ParsingSegment{Name: segment.Name, Value: segment.Value},
+		Level:           parser.ParsingLogLevelNotice,
+		Message:         "eiffel.parser.default-untouched",
+		TranslationArgs: []string{"name", rule.Name, "technicalName", segment.Name},
+		Extra:           map[string]any{ExtraUntouchedDefault: true},
+	}}
+}
+
+// validateDefaults validates the sources and profile fields of the rules' defaults.
+func (bt *BasicTemplate) validateDefaults() []error {
+	var errs []error
+	for _, rule := range bt.Rules {
+		if rule.Default == nil {
+			continue
+		}
+
+		switch rule.Default.Source {
+		case "", DefaultSourceValue, DefaultSourceLastUsed:
+		case DefaultSourceProfile:
+			switch rule.Default.Field {
+			case ProfileFieldFirstname, ProfileFieldLastname, ProfileFieldName, ProfileFieldEmail:
+			default:
+				errs = append(errs, DefaultError{Rule: rule.Name, Msg: "eiffel.parser.error.default-field"})
+			}
+		default:
+			errs = append(errs, DefaultError{Rule: rule.Name, Msg: "eiffel.parser.error.default-source"})
+		}
+	}
+
+	return errs
+}
+
+// profileField returns the field of the user's profile. An empty string is returned for unknown fields and if the user is nil.
+func profileField(usr *user.User, field string) string {
+	if usr == nil {
+		return ""
+	}
+
+	switch field {
+	case ProfileFieldFirstname:
+		return usr.Firstname
+	case ProfileFieldLastname:
+		return usr.Lastname
+	case ProfileFieldName:
+		return strings.TrimSpace(usr.Firstname + " " + usr.Lastname)
+	case ProfileFieldEmail:
+		return usr.Email
+	default:
+		return ""
+	}
+}
+
+// Error on DefaultError returns the error code of the error.
+func (e DefaultError) Error() string {
+	return e.Msg
+}
+
+// UnwrapTransparent on DefaultError returns the error itself, implementing the validation.TransparentError interface.
+func (e DefaultError) UnwrapTransparent(err validation.Error) error {
+	return e
+}
+
+// Translate on DefaultError translates the error using the given translator.
+func (e DefaultError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "rule", e.Rule)
+}
@@ -0,0 +1,92 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPrefill(t *testing.T) {
+	bt := prefillTestTemplate()
+	variant := bt.Variants["default"]
+	usr := &user.User{Firstname: "Ada", Lastname: "Lovelace", Email: "ada@example.com"}
+
+	prefilled := Prefill(bt, variant, PrefillSources{User: usr})
+	assert.Equal(t, map[string]string{"system": "The system", "modal": "shall", "owner": "Ada Lovelace"}, prefilled)
+
+	history := &History{Entries: []HistoryEntry{
+		{SegmentMap: map[string]string{"system": "The app"}},
+		{SegmentMap: map[string]string{"system": "The server", "modal": "should"}},
+	}}
+	prefilled = Prefill(bt, variant, PrefillSources{History: history})
+	assert.Equal(t, map[string]string{"system": "The server", "modal": "shall"}, prefilled)
+}
+
+func TestPrefilledFromRequest(t *testing.T) {
+	form := url.Values{"prefilled-system": {"The system"}, "segment-system": {"The app"}}
+	request := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	prefilled, err := PrefilledFromRequest(request)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"system": "The system"}, prefilled)
+}
+
+func TestBasicTemplate_ValidateDefaults(t *testing.T) {
+	bt := prefillTestTemplate()
+	require.Empty(t, bt.Validate(validation.New(), RuleParsers()))
+
+	bt.Rules["system"] = BasicRule{Name: "System", Type: "placeholder", Default: &BasicDefault{Source: "random"}}
+	bt.Rules["owner"] = BasicRule{Name: "Owner", Type: "placeholder", Default: &BasicDefault{Source: DefaultSourceProfile, Field: "password"}}
+
+	errs := bt.Validate(validation.New(), RuleParsers())
+	assert.Contains(t, errs, DefaultError{Rule: "System", Msg: "eiffel.parser.error.default-source"})
+	assert.Contains(t, errs, DefaultError{Rule: "Owner", Msg: "eiffel.parser.error.default-field"})
+}
+
+func TestBasicTemplate_ParseUntouchedDefaults(t *testing.T) {
+	ctx := context.Background()
+	bt := prefillTestTemplate()
+	segments := []parser.ParsingSegment{
+		{Name: "system", Value: "The system"},
+		{Name: "modal", Value: "should"},
+		{Name: "process", Value: "log every access"},
+		{Name: "owner", Value: "Ada Lovelace"},
+	}
+
+	result, err := bt.Parse(ctx, RuleParsers(), "default", segments...)
+	require.NoError(t, err)
+	assert.True(t, result.Flawless())
+	assert.Equal(t, []string{"system"}, UntouchedDefaults(result))
+	assert.Equal(t, "eiffel.parser.default-untouched", result.Notices[0].Message)
+
+	noop := func(ParsedRule) error { return nil }
+	prefilled := map[string]string{"system": "The server", "modal": "should", "owner": "Ada Lovelace"}
+	result, err = bt.ParseStream(ctx, RuleParsers(), "default", segments, noop, WithPrefilled(prefilled))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"modal", "owner"}, UntouchedDefaults(result))
+}
+
+func prefillTestTemplate() *BasicTemplate {
+	return &BasicTemplate{
+		ID:      "prefill",
+		Name:    "Prefill",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"system":  {Name: "System", Type: "placeholder", Default: &BasicDefault{Source: DefaultSourceLastUsed, Value: "The system"}},
+			"modal":   {Name: "Modal", Type: "equalsAny", Value: []any{"shall", "should"}, Default: &BasicDefault{Value: "shall"}},
+			"process": {Name: "Process", Type: "placeholder"},
+			"owner":   {Name: "Owner", Type: "placeholder", Optional: true, Default: &BasicDefault{Source: DefaultSourceProfile, Field: ProfileFieldName}},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {Name: "Default", Rules: []string{"system", "modal", "process", "owner"}},
+		},
+	}
+}
@@ -0,0 +1,183 @@
+package eiffel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"sort"
+)
+
+var (
+	// ErrPreviewInvalidConfig is displayed to the user if the previewed config is no valid JSON or misses the template's type.
+	ErrPreviewInvalidConfig = errors.New("eiffel.preview.error.invalid-config")
+	// ErrPreviewUnsupportedType is displayed to the user if the previewed config is no EIFFEL basic template.
+	ErrPreviewUnsupportedType = errors.New("eiffel.preview.error.unsupported-type")
+	// ErrPreviewTemplateSetNotFound is displayed to the user if the template set could not be found or belongs to another user.
+	ErrPreviewTemplateSetNotFound = errors.New("eiffel.preview.error.set-not-found")
+)
+
+// TemplatePreviewData is the data that is passed to the template rendering the preview of an unsaved template config.
+type TemplatePreviewData struct {
+	Template *BasicTemplate
+	// TemplateSetID is the template set the config is previewed in. Extended templates are looked up in this set.
+	TemplateSetID uuid.UUID
+	// VariantKey is the key of the previewed variant. It is not the name of the variant.
+	VariantKey string
+	Variant    BasicVariant
+	// Variants are the keys of the template's variants sorted alphabetically.
+	Variants     []string
+	DisplayTypes map[string]TemplateDisplayType
+	// Segments are the sample values of the variant's rules keyed by the rules' keys.
+	Segments map[string]string
+	// ParsingResult is the result of test parsing the segments. It is nil if nothing was parsed yet.
+	ParsingResult *parser.ParsingResult
+}
+
+// PreviewTemplate builds the elicitation form of an unsaved template config in memory, nothing is written to the database.
+// Templates the config extends are looked up in the template set, the previewed config replaces a saved template with the same ID.
+// The variant is selected by its key, the template's default variant (see template.UISettings) or else the first variant
+// in alphabetical order is used if the template has no such variant. The config is expected to be validated before,
+// e.g. through template.ValidateTemplateConfig. Returned errors are safe to display to the user.
+func PreviewTemplate(
+	ctx context.Context,
+	config string,
+	templateSetID uuid.UUID,
+	variantKey string,
+	templateRepository template.Repository,
+	ruleParsers *RuleParserProvider,
+	validator validation.V,
+) (*TemplatePreviewData, error) {
+	bt := &BasicTemplate{}
+	if err := json.Unmarshal([]byte(config), bt); err != nil {
+		return nil, ErrPreviewInvalidConfig
+	}
+
+	if bt.Extends != "" {
+		set, err := BasicTemplatesOfSet(ctx, templateRepository, templateSetID)
+		if err != nil {
+			return nil, errors.Join(web.ErrInternal, err)
+		}
+		set[bt.ID] = bt
+
+		bt, err = ResolveExtends(bt, set)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if errs := bt.Validate(validator, ruleParsers); len(errs) > 0 {
+		return nil, template.ErrInvalidTemplate
+	}
+	bt.Localize(ctx)
+
+	variants := make([]string, 0, len(bt.Variants))
+	for key := range bt.Variants {
+		variants = append(variants, key)
+	}
+	sort.Strings(variants)
+	if len(variants) == 0 {
+		return nil, template.ErrInvalidTemplate
+	}
+
+	if _, ok := bt.Variants[variantKey]; !ok {
+		variantKey = variants[0]
+		if _, found := bt.Variants[bt.UI.DefaultVariant]; found {
+			variantKey = bt.UI.DefaultVariant
+		}
+	}
+
+	return &TemplatePreviewData{
+		Template:      bt,
+		TemplateSetID: templateSetID,
+		VariantKey:    variantKey,
+		Variant:       bt.Variants[variantKey],
+		Variants:      variants,
+		DisplayTypes:  TemplateDisplayTypes(bt, ruleParsers),
+		Segments:      make(map[string]string),
+	}, nil
+}
+
+// previewTemplate renders the elicitation form of the unsaved template config (form value Config) of the template editor,
+// see PreviewTemplate. If parse is true the sample requirement of the form is test parsed with the config. The config is validated
+// like a saved template (template.ValidateTemplateConfig) and its violations are rendered instead of the form.
+// Only the owner of the template set may preview configs in the set.
+func previewTemplate(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx, parse bool) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		ctx := request.Context()
+		config := request.FormValue("Config")
+
+		templateSetID, err := uuid.Parse(web.URLParam(request, "templateSetID"))
+		if err != nil {
+			return renderTemplatePreview(io, nil, ErrPreviewTemplateSetNotFound)
+		}
+
+		templateSet, err := templateSetRepository.FindByID(ctx, templateSetID)
+		if err != nil || templateSet.CreatedBy != user.MustFromIO(io).ID {
+			return renderTemplatePreview(io, nil, ErrPreviewTemplateSetNotFound)
+		}
+
+		toCreate, err := template.ToCreateFromConfig(config)
+		if err != nil || json.Unmarshal([]byte(config), &BasicTemplate{}) != nil {
+			return renderTemplatePreview(io, nil, ErrPreviewInvalidConfig)
+		}
+		if toCreate.Type != BasicTemplateType {
+			return renderTemplatePreview(io, nil, ErrPreviewUnsupportedType)
+		}
+
+		validationErrs, err := template.ValidateTemplateConfig(config, toCreate.Type, templateSet.ID, appCtx.EventManager, appCtx.Logger)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		if len(validationErrs) > 0 {
+			return renderTemplatePreview(io, nil, validationErrs...)
+		}
+
+		parsers := ruleParsersFor(cfg)
+		data, err := PreviewTemplate(ctx, config, templateSet.ID, request.FormValue("variant"), templateRepository, parsers, appCtx.Validator)
+		if err != nil {
+			return renderTemplatePreview(io, nil, err)
+		}
+
+		if !parse {
+			return renderTemplatePreview(io, data)
+		}
+
+		for _, rule := range data.Variant.Rules {
+			data.Segments[rule] = request.FormValue("segment-" + rule)
+		}
+
+		result, err := data.Template.Parse(ctx, parsers, data.VariantKey, SegmentMapToSegments(data.Segments)...)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		data.ParsingResult = &result
+
+		var success []string
+		if result.Flawless() {
+			success = []string{"eiffel.elicitation.parse.flawless-success"}
+		} else if result.Ok() {
+			success = []string{"eiffel.elicitation.parse.success"}
+		}
+
+		return io.Render(web.NewFormData(data, success), "eiffel.template.preview", "eiffel/_template-preview.go.html")
+	})
+}
+
+// renderTemplatePreview renders the preview of the template config, the errors are rendered as violations.
+// The data is nil if the config could not be previewed.
+func renderTemplatePreview(io web.IO, data *TemplatePreviewData, errs ...error) error {
+	return io.Render(web.NewFormData(data, nil, errs...), "eiffel.template.preview", "eiffel/_template-preview.go.html")
+}
@@ -0,0 +1,58 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const previewTestConfig = `{
+	"id": "preview",
+	"type": "ebt",
+	"name": "Preview",
+	"version": "1.0.0",
+	"rules": {
+		"system": {"name": "System", "type": "placeholder"},
+		"modal": {"name": "Modal", "type": "equalsAny", "value": ["shall", "should"]},
+		"process": {"name": "Process", "type": "placeholder"}
+	},
+	"variants": {
+		"short": {"name": "Short", "rules": ["system", "process"]},
+		"long": {"name": "Long", "rules": ["system", "modal", "process"]}
+	},
+	"ui": {"defaultVariant": "short"}
+}`
+
+func TestPreviewTemplate(t *testing.T) {
+	ctx := context.Background()
+	setID := uuid.New()
+
+	preview, err := PreviewTemplate(ctx, previewTestConfig, setID, "", nil, RuleParsers(), validation.New())
+	require.NoError(t, err)
+	assert.Equal(t, "Preview", preview.Template.Name)
+	assert.Equal(t, setID, preview.TemplateSetID)
+	assert.Equal(t, "short", preview.VariantKey)
+	assert.Equal(t, []string{"long", "short"}, preview.Variants)
+	assert.Equal(t, TemplateDisplayInputTypeSingleSelect, preview.DisplayTypes["modal"])
+	assert.Empty(t, preview.Segments)
+	assert.Nil(t, preview.ParsingResult)
+
+	preview, err = PreviewTemplate(ctx, previewTestConfig, setID, "long", nil, RuleParsers(), validation.New())
+	require.NoError(t, err)
+	assert.Equal(t, "long", preview.VariantKey)
+	assert.Equal(t, "Long", preview.Variant.Name)
+
+	preview, err = PreviewTemplate(ctx, previewTestConfig, setID, "unknown", nil, RuleParsers(), validation.New())
+	require.NoError(t, err)
+	assert.Equal(t, "short", preview.VariantKey)
+
+	_, err = PreviewTemplate(ctx, `{"type": "ebt"`, setID, "", nil, RuleParsers(), validation.New())
+	assert.ErrorIs(t, err, ErrPreviewInvalidConfig)
+
+	_, err = PreviewTemplate(ctx, `{"type": "ebt", "name": "Preview"}`, setID, "", nil, RuleParsers(), validation.New())
+	assert.ErrorIs(t, err, template.ErrInvalidTemplate)
+}
@@ -0,0 +1,295 @@
+package eiffel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// markdownSpecialChars are the characters escaped by markdownText.
+const markdownSpecialChars = "\\`*_[]<>#+-.!|~:@&"
+
+// reportIndexHTML is the HTML page the Gotenberg Markdown route renders the report into.
+const reportIndexHTML = `<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>Report</title></head>
+<body>{{ toHTML "report.md" }}</body>
+</html>`
+
+var (
+	// ErrPDFRenderingFailed is returned by the GotenbergRenderer if the API responded with an unexpected status code.
+	ErrPDFRenderingFailed = errors.New("eiffel.report.pdf-failed")
+	// ErrPDFDisabled will be displayed to the user if a PDF report is requested but no PDF renderer is configured.
+	ErrPDFDisabled = errors.New("eiffel.report.pdf-disabled")
+)
+
+// PDFCfg is the configuration for the optional rendering of reports as PDF using a Gotenberg-compatible API.
+// The rendering is disabled by default, reports are then only available as Markdown.
+type PDFCfg struct {
+	Enabled bool `toml:"enabled" env:"EIFFEL_PDF_ENABLED"`
+	// URL is the base URL of the Gotenberg-compatible API, e.g. http://localhost:3000.
+	// The Markdown conversion route (/forms/chromium/convert/markdown) is appended to the URL.
+	URL string `toml:"url" env:"EIFFEL_PDF_URL"`
+	// Timeout is the timeout in seconds for rendering a single report. Defaults to 30 seconds.
+	Timeout int `toml:"timeout" env:"EIFFEL_PDF_TIMEOUT"`
+}
+
+// ReportRenderer renders a Markdown report (see ReportMarkdown) into another format, e.g. PDF.
+type ReportRenderer interface {
+	// Render renders the Markdown document and writes the rendered document to w.
+	Render(ctx context.Context, markdown string, w io.Writer) error
+}
+
+// GotenbergRenderer is a ReportRenderer rendering reports as PDF using a Gotenberg-compatible HTTP API.
+type GotenbergRenderer struct {
+	url    string
+	client *http.Client
+}
+
+// Report summarizes elicited requirements. It contains the metadata of the templates used,
+// each requirement with its parsing logs and a summary of the logs per rule (see NewReport and ReportMarkdown).
+type Report struct {
+	Title     string
+	CreatedAt time.Time
+	// Templates are the templates used in the order they were first used.
+	Templates    []ReportTemplate
+	Requirements []ExportedRequirement
+	// Violations are the parsing logs counted per rule and level. The most frequent violations are first.
+	Violations []ReportViolation
+}
+
+// ReportTemplate is a template used to elicit requirements of a Report.
+type ReportTemplate struct {
+	// Name is the name and version of the template, see ExportedRequirement.Template.
+	Name string
+	// Template is the template's metadata. It is nil if the template could not be found, e.g. because it was deleted.
+	Template *BasicTemplate
+	// Requirements is the number of requirements elicited with the template.
+	Requirements int
+}
+
+// ReportViolation is the number of parsing logs of a level for a rule. Rule is the display name of the rule.
+type ReportViolation struct {
+	Rule  string
+	Level string
+	Count int
+}
+
+// NewPDFRenderer constructs a new GotenbergRenderer from the config.
+// If the rendering is disabled nil is returned, PDF reports are then not available.
+func NewPDFRenderer(cfg PDFCfg) ReportRenderer {
+	if !cfg.Enabled || cfg.URL == "" {
+		return nil
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30
+	}
+
+	return &GotenbergRenderer{
+		url:    strings.TrimSuffix(cfg.URL, "/") + "/forms/chromium/convert/markdown",
+		client: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// Render implements the ReportRenderer interface. The Markdown document is converted to HTML and printed as PDF by the API.
+func (r *GotenbergRenderer) Render(ctx context.Context, markdown string, w io.Writer) error {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{name: "index.html", content: reportIndexHTML},
+		{name: "report.md", content: markdown},
+	}
+	for _, file := range files {
+		part, err := form.CreateFormFile("files", file.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(part, file.content); err != nil {
+			return err
+		}
+	}
+
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	response, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrPDFRenderingFailed, response.StatusCode)
+	}
+
+	_, err = io.Copy(w, response.Body)
+
+	return err
+}
+
+// NewReport creates a report of the requirements. The templates are keyed by their ID (see ExportedRequirement.TemplateID),
+// they provide the metadata of the templates used. Requirements elicited with a template missing from templates are reported
+// with the template's name only.
+func NewReport(title string, requirements []ExportedRequirement, templates map[string]*BasicTemplate) *Report {
+	report := &Report{Title: title, CreatedAt: time.Now(), Requirements: requirements}
+
+	templateIndex := make(map[string]int)
+	violationIndex := make(map[[2]string]int)
+	for _, requirement := range requirements {
+		i, ok := templateIndex[requirement.Template]
+		if !ok {
+			i = len(report.Templates)
+			templateIndex[requirement.Template] = i
+			report.Templates = append(report.Templates, ReportTemplate{Name: requirement.Template, Template: templates[requirement.TemplateID]})
+		}
+		report.Templates[i].Requirements++
+
+		for _, log := range requirement.Logs {
+			key := [2]string{log.Rule, log.Level}
+			j, ok := violationIndex[key]
+			if !ok {
+				j = len(report.Violations)
+				violationIndex[key] = j
+				report.Violations = append(report.Violations, ReportViolation{Rule: log.Rule, Level: log.Level})
+			}
+			report.Violations[j].Count++
+		}
+	}
+
+	sort.SliceStable(report.Violations, func(i, j int) bool {
+		return report.Violations[i].Count > report.Violations[j].Count
+	})
+
+	return report
+}
+
+// Flawless returns the number of requirements of the report without warnings (see parser.ParsingResult.Flawless).
+func (r *Report) Flawless() int {
+	flawless := 0
+	for _, requirement := range r.Requirements {
+		if requirement.Flawless() {
+			flawless++
+		}
+	}
+
+	return flawless
+}
+
+// Flawless returns true if the requirement has no errors and no warnings.
+func (r ExportedRequirement) Flawless() bool {
+	for _, log := range r.Logs {
+		if log.Level == parser.ParsingLogLevelError.String() || log.Level == parser.ParsingLogLevelWarning.String() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReportMarkdown renders the report as a Markdown document. All values taken from templates and requirements are escaped (see markdownText),
+// they can neither break the report's layout nor add links or HTML to it. The document can be rendered further, e.g. as PDF (see ReportRenderer).
+func ReportMarkdown(report *Report) string {
+	md := &strings.Builder{}
+
+	fmt.Fprintf(md, "# %s\n\n", markdownText(report.Title))
+	fmt.Fprintf(md, "Created: %s\n\n", report.CreatedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(md, "Requirements: %d (flawless: %d)\n\n", len(report.Requirements), report.Flawless())
+
+	md.WriteString("## Templates\n\n")
+	for _, t := range report.Templates {
+		fmt.Fprintf(md, "### %s\n\n", markdownText(t.Name))
+		if t.Template != nil {
+			if t.Template.Description != "" {
+				fmt.Fprintf(md, "%s\n\n", markdownText(t.Template.Description))
+			}
+			if len(t.Template.Authors) > 0 {
+				fmt.Fprintf(md, "Authors: %s\n\n", markdownText(strings.Join(t.Template.Authors, ", ")))
+			}
+			if t.Template.License != "" {
+				fmt.Fprintf(md, "License: %s\n\n", markdownText(t.Template.License))
+			}
+		}
+		fmt.Fprintf(md, "Requirements: %d\n\n", t.Requirements)
+	}
+
+	md.WriteString("## Rule Violations\n\n")
+	if len(report.Violations) == 0 {
+		md.WriteString("None.\n\n")
+	} else {
+		md.WriteString("| Rule | Level | Count |\n")
+		md.WriteString("| --- | --- | --- |\n")
+		for _, v := range report.Violations {
+			fmt.Fprintf(md, "| %s | %s | %d |\n", markdownText(v.Rule), v.Level, v.Count)
+		}
+		md.WriteString("\n")
+	}
+
+	md.WriteString("## Requirements\n\n")
+	for i, requirement := range report.Requirements {
+		fmt.Fprintf(md, "### %d. %s\n\n", i+1, markdownText(requirement.Label()))
+		if requirement.Template != "" {
+			fmt.Fprintf(md, "Template: %s, Variant: %s\n\n", markdownText(requirement.Template), markdownText(requirement.Variant))
+		}
+
+		if len(requirement.Segments) > 0 {
+			md.WriteString("| Rule | Segment |\n")
+			md.WriteString("| --- | --- |\n")
+			for _, segment := range requirement.Segments {
+				fmt.Fprintf(md, "| %s | %s |\n", markdownText(segment.Rule), markdownText(segment.Value))
+			}
+			md.WriteString("\n")
+		}
+
+		for _, log := range requirement.Logs {
+			if log.Rule != "" {
+				fmt.Fprintf(md, "- **%s** %s: %s\n", log.Level, markdownText(log.Rule), markdownText(log.Message))
+				continue
+			}
+
+			fmt.Fprintf(md, "- **%s** %s\n", log.Level, markdownText(log.Message))
+		}
+		if len(requirement.Logs) > 0 {
+			md.WriteString("\n")
+		}
+	}
+
+	return strings.TrimSpace(md.String()) + "\n"
+}
+
+// markdownText escapes a value to be rendered as plain text inside a line of a Markdown document, e.g. a heading, a list item
+// or a table cell. The characters with a meaning in (GitHub-flavored) Markdown are escaped with a backslash, which CommonMark renders
+// as the literal character. This prevents emphasis, links, autolinks (e.g. "https://" and "www."), HTML and block structures
+// like headings or lists. Line breaks are replaced by spaces.
+func markdownText(value string) string {
+	escaped := &strings.Builder{}
+	for _, r := range strings.Join(strings.Fields(value), " ") {
+		if strings.ContainsRune(markdownSpecialChars, r) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(r)
+	}
+
+	return escaped.String()
+}
@@ -0,0 +1,111 @@
+package eiffel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func reportRequirements() []ExportedRequirement {
+	return []ExportedRequirement{
+		{
+			Requirement: "is foo",
+			TemplateID:  "test-id",
+			Template:    "Test Template (1.0.0)",
+			Variant:     "Basic",
+			Segments:    []ExportedSegment{{Rule: "Foo Rule", Value: "foo"}},
+			Logs:        []ExportedLog{{Level: "warning", Rule: "Foo Rule", Message: "weak | word"}},
+		},
+		{
+			Requirement: "was foo",
+			TemplateID:  "test-id",
+			Template:    "Test Template (1.0.0)",
+			Variant:     "Basic",
+			Logs:        []ExportedLog{{Level: "notice", Rule: "Verb", Message: "past"}, {Level: "warning", Rule: "Foo Rule", Message: "weak"}},
+		},
+		{Requirement: "bar", Template: "Deleted (0.1.0)", Variant: "Other"},
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	bt := basicTemplate()
+	report := NewReport("Report", reportRequirements(), map[string]*BasicTemplate{"test-id": bt})
+
+	assert.Equal(t, "Report", report.Title)
+	assert.Equal(t, []ReportTemplate{
+		{Name: "Test Template (1.0.0)", Template: bt, Requirements: 2},
+		{Name: "Deleted (0.1.0)", Requirements: 1},
+	}, report.Templates)
+	assert.Equal(t, []ReportViolation{
+		{Rule: "Foo Rule", Level: "warning", Count: 2},
+		{Rule: "Verb", Level: "notice", Count: 1},
+	}, report.Violations)
+	assert.Equal(t, 1, report.Flawless())
+}
+
+func TestReportMarkdown(t *testing.T) {
+	markdown := ReportMarkdown(NewReport("Report", reportRequirements(), map[string]*BasicTemplate{"test-id": basicTemplate()}))
+
+	assert.Contains(t, markdown, "# Report\n")
+	assert.Contains(t, markdown, "Requirements: 3 (flawless: 1)\n")
+	assert.Contains(t, markdown, "### Test Template (1\\.0\\.0)\n\nAuthors: John Doe, Max Mustermann\n\nLicense: MIT\n\nRequirements: 2\n")
+	assert.Contains(t, markdown, "### Deleted (0\\.1\\.0)\n\nRequirements: 1\n")
+	assert.Contains(t, markdown, "| Foo Rule | warning | 2 |\n")
+	assert.Contains(t, markdown, "### 1. is foo\n\nTemplate: Test Template (1\\.0\\.0), Variant: Basic\n\n| Rule | Segment |\n| --- | --- |\n| Foo Rule | foo |\n")
+	assert.Contains(t, markdown, "- **warning** Foo Rule: weak \\| word\n")
+
+	injected := ReportMarkdown(NewReport("# Title [link](https://example.com)", []ExportedRequirement{{
+		Requirement: "<img src=x>",
+		Logs:        []ExportedLog{{Level: "error", Message: "*bold*\n# heading www.example.com"}},
+	}}, nil))
+	assert.Contains(t, injected, "# \\# Title \\[link\\](https\\://example\\.com)\n")
+	assert.Contains(t, injected, "### 1. \\<img src=x\\>\n")
+	assert.Contains(t, injected, "- **error** \\*bold\\* \\# heading www\\.example\\.com\n", "messages are escaped and kept on a single line")
+
+	empty := ReportMarkdown(NewReport("Empty", nil, nil))
+	assert.Contains(t, empty, "## Rule Violations\n\nNone.\n")
+}
+
+func TestGotenbergRenderer_Render(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/forms/chromium/convert/markdown", r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+
+		files := r.MultipartForm.File["files"]
+		require.Len(t, files, 2)
+		assert.Equal(t, "index.html", files[0].Filename)
+		assert.Equal(t, "report.md", files[1].Filename)
+
+		file, err := files[1].Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+
+		if string(content) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write(append([]byte("%PDF "), content...))
+	}))
+	defer server.Close()
+
+	renderer := NewPDFRenderer(PDFCfg{Enabled: true, URL: server.URL + "/"})
+	require.NotNil(t, renderer)
+
+	pdf := &bytes.Buffer{}
+	require.NoError(t, renderer.Render(context.Background(), "# Report", pdf))
+	assert.Equal(t, "%PDF # Report", pdf.String())
+
+	err := renderer.Render(context.Background(), "fail", &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrPDFRenderingFailed))
+
+	assert.Nil(t, NewPDFRenderer(PDFCfg{URL: server.URL}))
+}
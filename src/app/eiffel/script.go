@@ -0,0 +1,147 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/expr"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/validation"
+)
+
+// segmentsContextKey is the context key under which the segment values of the parsed requirement are stored during parsing.
+const segmentsContextKey = "eiffel.segments"
+
+// ScriptRuleParser is a rule parser for the rule type 'script'. It expects the rule's value to be an expression
+// (see package expr) which must evaluate to true for the segment to be valid. This allows template authors to add custom checks
+// without Go code, e.g. that the actor differs from the system's name:
+//
+//	{"type": "script", "value": "lower(value) != lower(segments.system)", "extra": {"message": "The actor must not be the system."}}
+//
+// The expression can access the segment's value as 'value' and the values of all segments of the requirement
+// by their rule's technical name as 'segments' (e.g. segments.system). Segments which are not filled in are empty strings.
+// While parsing segment by segment (see BasicTemplate.ParseSegment) only the parsed segment is filled in.
+//
+// The optional extra property 'message' is reported if the expression evaluates to false, it may be localized like rule values.
+// The optional extra property 'level' sets the level of the report: "error" (default), "warning" or "notice".
+type ScriptRuleParser struct{}
+
+// Parse implements the RuleParser interface for the ScriptRuleParser. It is used to parse rules of the type 'script'.
+// If the expression evaluates to false, the message is reported. If it can not be evaluated for the segment
+// (e.g. because it references an unknown rule or the value is not a number) this is reported instead.
+func (p ScriptRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+	source, ok := LocalizedValue(ctx, rule).(string)
+	if !ok {
+		return nil, RuleInvalidValueError{Rule: &rule}
+	}
+
+	program, ok := compiledProgram(ctx, rule)
+	if !ok {
+		var err error
+		program, err = expr.Compile(source)
+		if err != nil {
+			return nil, RuleInvalidValueError{Rule: &rule, Msg: "eiffel.parser.script.invalid"}
+		}
+	}
+
+	segments, _ := util.CtxValue[map[string]any](ctx, segmentsContextKey)
+	if segments == nil {
+		segments = map[string]any{}
+	}
+
+	valid, err := program.EvalBool(map[string]any{"value": segment.Value, "segments": segments})
+	if valid {
+		return nil, nil
+	}
+
+	level := scriptLevel(rule)
+	message, _ := LocalizedValue(ctx, BasicRule{Value: rule.Extra["message"]}).(string)
+	log := parser.ParsingLog{
+		Segment:         &segment,
+		Level:           level,
+		Message:         "eiffel.parser.script.error",
+		TranslationArgs: []string{"name", rule.Name},
+		Ranges:          []parser.ParsingRange{segmentRange(segment, level)},
+	}
+	if err != nil {
+		log.Message = "eiffel.parser.script.failed"
+	} else if message != "" {
+		// the author's message is passed as an argument because the message itself would be parsed as a translation template
+		log.Message = "eiffel.parser.script.message"
+		log.TranslationArgs = []string{"message", message}
+	}
+
+	return []parser.ParsingLog{log}, nil
+}
+
+// Validate implements the RuleParser interface for the ScriptRuleParser. It is used to validate rules of the type 'script'.
+// The script rule expects a string value which is a valid expression. The 'message' extra property must be a string
+// or localized strings and the 'level' extra property one of the parsing log levels.
+func (p ScriptRuleParser) Validate(v validation.V, rule BasicRule) []error {
+	source, ok := rule.Value.(string)
+	if !ok {
+		return []error{RuleInvalidValueError{Rule: &rule}}
+	}
+
+	var errs []error
+	if _, err := expr.Compile(source); err != nil {
+		errs = append(errs, RuleInvalidValueError{Rule: &rule, Msg: "eiffel.parser.script.invalid"})
+	}
+
+	if message, ok := rule.Extra["message"]; ok {
+		for _, localized := range (BasicRule{Value: message}).LocaleVariants() {
+			if _, ok := localized.Value.(string); !ok {
+				errs = append(errs, RuleInvalidValueError{Rule: &rule, Msg: "eiffel.parser.script.invalid-message"})
+				break
+			}
+		}
+	}
+
+	if level, ok := rule.Extra["level"]; ok {
+		levelName, _ := level.(string)
+		if _, valid := parsingLogLevel(levelName); !valid {
+			errs = append(errs, RuleInvalidValueError{Rule: &rule, Msg: "eiffel.parser.script.invalid-level"})
+		}
+	}
+
+	return errs
+}
+
+// DisplayType implements the RuleParser interface for the ScriptRuleParser. Script rules are displayed like placeholders.
+func (p ScriptRuleParser) DisplayType(rule BasicRule) TemplateDisplayType {
+	return PlaceholderRuleParser{}.DisplayType(rule)
+}
+
+// withSegments adds the values of the segments keyed by their name to the context, see ScriptRuleParser.
+// Each of the template's rules is added with an empty value if the segment is missing.
+func withSegments(ctx context.Context, rules map[string]BasicRule, segments map[string]parser.ParsingSegment) context.Context {
+	values := make(map[string]any, len(rules))
+	for name := range rules {
+		values[name] = ""
+	}
+	for name, segment := range segments {
+		values[name] = segment.Value
+	}
+
+	return context.WithValue(ctx, segmentsContextKey, values)
+}
+
+// compiledProgram returns the compiled expression of the script rule, see ScriptRuleParser.Compile.
+func compiledProgram(ctx context.Context, rule BasicRule) (*expr.Program, bool) {
+	compiled, ok := CompiledValue(ctx, rule)
+	if !ok {
+		return nil, false
+	}
+
+	program, ok := compiled.(*expr.Program)
+	return program, ok
+}
+
+// scriptLevel returns the level of the script rule's 'level' extra property. It defaults to parser.ParsingLogLevelError.
+func scriptLevel(rule BasicRule) parser.ParsingLogLevel {
+	level, _ := rule.Extra["level"].(string)
+	if l, ok := parsingLogLevel(level); ok {
+		return l
+	}
+
+	return parser.ParsingLogLevelError
+}
@@ -0,0 +1,87 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestScriptRuleParser_Validate(t *testing.T) {
+	p := ScriptRuleParser{}
+	v := validation.New()
+
+	assert.Empty(t, p.Validate(v, BasicRule{Name: "Actor", Type: "script", Value: `value != segments.system`}))
+	assert.Empty(t, p.Validate(v, BasicRule{Name: "Actor", Type: "script", Value: `true`, Extra: map[string]any{
+		"message": map[string]any{"de": "Nachricht", "en": "Message"},
+		"level":   "warning",
+	}}))
+
+	assert.Len(t, p.Validate(v, BasicRule{Name: "Actor", Type: "script", Value: 42}), 1)
+	assert.Len(t, p.Validate(v, BasicRule{Name: "Actor", Type: "script", Value: `value ==`}), 1)
+	assert.Len(t, p.Validate(v, BasicRule{Name: "Actor", Type: "script", Value: `true`, Extra: map[string]any{
+		"message": 42,
+		"level":   "fatal",
+	}}), 2)
+}
+
+func TestScriptRuleParser_Parse(t *testing.T) {
+	bt := &BasicTemplate{
+		ID:      "script",
+		Name:    "Script",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"actor": {
+				Name:  "Actor",
+				Type:  "script",
+				Value: `lower(value) != lower(segments.system)`,
+				Extra: map[string]any{"message": map[string]any{"en": "The actor must not be the system."}},
+			},
+			"system": {Name: "System", Type: "placeholder"},
+			"count": {
+				Name:  "Count",
+				Type:  "script",
+				Value: `number(value) <= 10`,
+				Extra: map[string]any{"level": "warning"},
+			},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {Name: "Default", Rules: []string{"system", "actor", "count"}},
+		},
+	}
+	rp := RuleParsers()
+	require.Empty(t, bt.Validate(validation.New(), rp))
+
+	result, err := bt.Parse(context.Background(), rp, "default",
+		parser.ParsingSegment{Name: "system", Value: "HARMONY"},
+		parser.ParsingSegment{Name: "actor", Value: "User"},
+		parser.ParsingSegment{Name: "count", Value: "3"},
+	)
+	require.NoError(t, err)
+	assert.True(t, result.Flawless())
+
+	result, err = bt.Parse(context.Background(), rp, "default",
+		parser.ParsingSegment{Name: "system", Value: "HARMONY"},
+		parser.ParsingSegment{Name: "actor", Value: "harmony "},
+		parser.ParsingSegment{Name: "count", Value: "11"},
+	)
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "eiffel.parser.script.message", result.Errors[0].Message)
+	assert.Equal(t, []string{"message", "The actor must not be the system."}, result.Errors[0].TranslationArgs)
+	assert.Equal(t, []parser.ParsingRange{{Start: 0, End: 7, Level: parser.ParsingLogLevelError}}, result.Errors[0].Ranges)
+	require.Len(t, result.Warnings, 1)
+	assert.Equal(t, "eiffel.parser.script.error", result.Warnings[0].Message)
+
+	logs, err := bt.ParseSegment(context.Background(), rp, "default", parser.ParsingSegment{Name: "count", Value: "many"})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "eiffel.parser.script.failed", logs[0].Message)
+	assert.Equal(t, parser.ParsingLogLevelWarning, logs[0].Level)
+
+	logs, err = bt.ParseSegment(context.Background(), rp, "default", parser.ParsingSegment{Name: "actor", Value: "User"})
+	require.NoError(t, err)
+	assert.Empty(t, logs, "other segments are empty while parsing segment by segment")
+}
@@ -0,0 +1,68 @@
+package eiffel
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SearchDateLayout is the layout of the dates restricting the last update of searched templates, it is the layout of date inputs.
+const SearchDateLayout = "2006-01-02"
+
+// SearchFilterFromRequest reads the filter of the template search from the request's query and form: the query (search),
+// the template set (set), the version's prefix (version), the first and last day of the last update (updated-since, updated-until)
+// and the order (sort, see template.SearchSorts). Each request carries the whole filter, the search does not keep it between requests.
+// Invalid values are ignored.
+func SearchFilterFromRequest(request *http.Request) template.SearchFilter {
+	_ = request.ParseForm()
+
+	filter := template.SearchFilter{
+		Query:   strings.TrimSpace(request.Form.Get("search")),
+		Version: strings.TrimSpace(request.Form.Get("version")),
+	}
+	if templateSet, err := uuid.Parse(request.Form.Get("set")); err == nil {
+		filter.TemplateSet = templateSet
+	}
+	if since, err := time.Parse(SearchDateLayout, request.Form.Get("updated-since")); err == nil {
+		filter.UpdatedSince = since
+	}
+	// the last day is included, templates updated before the following day are selected
+	if until, err := time.Parse(SearchDateLayout, request.Form.Get("updated-until")); err == nil {
+		filter.UpdatedBefore = until.AddDate(0, 0, 1)
+	}
+	if sort, ok := template.ParseSearchSort(request.Form.Get("sort")); ok {
+		filter.Sort = sort
+	}
+
+	return filter
+}
+
+// UpdatedSince returns the first day of the last update the templates are filtered by formatted as SearchDateLayout or an empty string.
+func (d *SearchTemplateData) UpdatedSince() string {
+	if d.Filter.UpdatedSince.IsZero() {
+		return ""
+	}
+
+	return d.Filter.UpdatedSince.Format(SearchDateLayout)
+}
+
+// UpdatedUntil returns the last day of the last update the templates are filtered by formatted as SearchDateLayout or an empty string.
+func (d *SearchTemplateData) UpdatedUntil() string {
+	if d.Filter.UpdatedBefore.IsZero() {
+		return ""
+	}
+
+	return d.Filter.UpdatedBefore.AddDate(0, 0, -1).Format(SearchDateLayout)
+}
+
+// Sorts returns the orders the user can sort the found templates by, see template.SearchSorts.
+func (d *SearchTemplateData) Sorts() []template.SearchSort {
+	return template.SearchSorts
+}
+
+// SortedBy returns true if the found templates are ordered by the sort. Templates are ordered by template.SortName by default.
+func (d *SearchTemplateData) SortedBy(sort template.SearchSort) bool {
+	return d.Filter.Sort == sort || d.Filter.Sort == "" && sort == template.SortName
+}
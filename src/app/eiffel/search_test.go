@@ -0,0 +1,50 @@
+package eiffel
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchFilterFromRequest(t *testing.T) {
+	templateSet := uuid.New()
+	form := url.Values{
+		"search":        {" paris "},
+		"set":           {templateSet.String()},
+		"version":       {"1."},
+		"updated-since": {"2026-01-01"},
+		"updated-until": {"2026-01-31"},
+	}
+	request := httptest.NewRequest("POST", "/eiffel/elicitation/templates/search?sort=used", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	filter := SearchFilterFromRequest(request)
+	assert.Equal(t, template.SearchFilter{
+		Query:         "paris",
+		TemplateSet:   templateSet,
+		Version:       "1.",
+		UpdatedSince:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedBefore: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		Sort:          template.SortRecentlyUsed,
+	}, filter)
+
+	data := &SearchTemplateData{Filter: filter}
+	assert.Equal(t, "2026-01-01", data.UpdatedSince())
+	assert.Equal(t, "2026-01-31", data.UpdatedUntil(), "the last day is included")
+	assert.True(t, data.SortedBy(template.SortRecentlyUsed))
+	assert.False(t, data.SortedBy(template.SortName))
+
+	invalid := httptest.NewRequest("GET", "/eiffel/elicitation/templates/search/modal?set=foo&updated-since=yesterday&sort=random", nil)
+	filter = SearchFilterFromRequest(invalid)
+	assert.Equal(t, template.SearchFilter{}, filter, "invalid values are ignored")
+
+	data = &SearchTemplateData{Filter: filter}
+	assert.Empty(t, data.UpdatedSince())
+	assert.Empty(t, data.UpdatedUntil())
+	assert.True(t, data.SortedBy(template.SortName), "templates are sorted by name by default")
+}
@@ -0,0 +1,40 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+)
+
+// SegmentSentence splits a whole requirement sentence into the segments of the variant (see parser.SegmentSentence).
+// The values of equals and equalsAny rules are used as anchors delimiting the free text of all other rules.
+// EqualsAny rules allowing others (extra property 'allowOthers') accept other words as well.
+// This allows users to paste a whole requirement instead of filling in each segment on its own.
+// SegmentSentence returns ErrInvalidVariant if the variant does not exist.
+func (bt *BasicTemplate) SegmentSentence(ctx context.Context, variantName string, sentence string) (parser.Segmentation, error) {
+	variant, ok := bt.Variants[variantName]
+	if !ok {
+		return parser.Segmentation{}, ErrInvalidVariant
+	}
+
+	slots := make([]parser.SegmentationSlot, 0, len(variant.Rules))
+	for _, name := range variant.Rules {
+		rule := bt.Rules[name]
+		slot := parser.SegmentationSlot{Name: name, Optional: rule.Optional}
+
+		switch rule.Type {
+		case "equals":
+			if value, ok := LocalizedValue(ctx, rule).(string); ok && value != "" {
+				slot.Anchors = []string{value}
+			}
+		case "equalsAny":
+			if values, err := toStringSlice(LocalizedValue(ctx, rule)); err == nil {
+				slot.Anchors = values
+			}
+			slot.Open, _ = rule.Extra["allowOthers"].(bool)
+		}
+
+		slots = append(slots, slot)
+	}
+
+	return parser.SegmentSentence(sentence, slots), nil
+}
@@ -0,0 +1,39 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBasicTemplate_SegmentSentence(t *testing.T) {
+	bt := &BasicTemplate{
+		ID:      "sentence",
+		Name:    "Sentence",
+		Version: "1.0.0",
+		Rules: map[string]BasicRule{
+			"condition": {Name: "Condition", Type: "placeholder", Optional: true},
+			"system":    {Name: "System", Type: "placeholder"},
+			"modal":     {Name: "Modal", Type: "equalsAny", Value: []any{"shall", "should", "will"}},
+			"process":   {Name: "Process", Type: "placeholder"},
+		},
+		Variants: map[string]BasicVariant{
+			"default": {Name: "Default", Rules: []string{"condition", "system", "modal", "process"}},
+		},
+	}
+
+	segmentation, err := bt.SegmentSentence(context.Background(), "default", "The system should log every access")
+	require.NoError(t, err)
+	assert.Equal(t, []parser.ParsingSegment{
+		{Name: "condition"},
+		{Name: "system", Value: "The system"},
+		{Name: "modal", Value: "should"},
+		{Name: "process", Value: "log every access"},
+	}, segmentation.Segments)
+	assert.Equal(t, 1.0, segmentation.Confidence)
+
+	_, err = bt.SegmentSentence(context.Background(), "unknown", "The system should log every access")
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+}
@@ -3,15 +3,30 @@ package eiffel
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/reqif"
+	"github.com/org-harmony/harmony/src/app/requirement"
 	"github.com/org-harmony/harmony/src/app/template"
 	"github.com/org-harmony/harmony/src/app/template/parser"
 	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/feature"
+	"github.com/org-harmony/harmony/src/core/trans"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+const (
+	// TemplateAttribute is the name of the ReqIF attribute containing the template a requirement was elicited with.
+	TemplateAttribute = "HARMONY.Template"
+	// VariantAttribute is the name of the ReqIF attribute containing the variant a requirement was elicited with.
+	VariantAttribute = "HARMONY.Variant"
+)
+
 // TemplateDisplayTypes returns a map of rule names to display types. The rule names are the keys of the BasicTemplate.Rules map.
 // This can be used in the eiffel.TemplateFormData`.DisplayTypes field.
 func TemplateDisplayTypes(bt *BasicTemplate, ruleParsers *RuleParserProvider) map[string]TemplateDisplayType {
@@ -36,6 +51,7 @@ func TemplateDisplayTypes(bt *BasicTemplate, ruleParsers *RuleParserProvider) ma
 
 // TemplateIntoBasicTemplate parses a templates config into a BasicTemplate and validates it.
 // If unmarshalling the config into the BasicTemplate fails or validation fails, an error is returned.
+// Extended templates are not resolved, use ResolvedTemplateIntoBasicTemplate for templates that might extend others.
 func TemplateIntoBasicTemplate(t *template.Template, validator validation.V, ruleParsers *RuleParserProvider) (*BasicTemplate, error) {
 	ebt := &BasicTemplate{}
 	err := json.Unmarshal([]byte(t.Config), ebt)
@@ -53,8 +69,10 @@ func TemplateIntoBasicTemplate(t *template.Template, validator validation.V, rul
 
 // TemplateFormFromRequest parses the template and variant from the passed in templateID and variantKey and returns a
 // TemplateFormData struct. If the template or variant could not be found, an error is returned.
-// However, using the defaultFirstVariant flag, the first variant will be used if no variant was specified and no
-// error will be returned. TemplateFormFromRequest will also parse and validate the template.
+// However, using the defaultFirstVariant flag, the template's default variant (see template.UISettings) or else the first
+// variant will be used if no variant was specified and no error will be returned. TemplateFormFromRequest will also parse and validate the template (with its extended templates).
+// The rules of the template are compiled for parsing, see CompiledRulesFor.
+// Localized rule values are resolved to the user's active locale.
 // TemplateFormFromRequest will return an error if the user is not permitted to access the template.
 //
 // Returned errors from TemplateFormFromRequest are safe to display to the user.
@@ -82,20 +100,30 @@ func TemplateFormFromRequest(
 		return TemplateFormData{}, ErrTemplateNotFound
 	}
 
+	// templates shared with the user through a project can be elicited with as well
 	if tmpl.CreatedBy != usr.ID {
-		return TemplateFormData{}, ErrTemplateNotFound
+		accessible, err := templateRepository.IsAccessible(ctx, tmpl.ID, usr.ID)
+		if err != nil || !accessible {
+			return TemplateFormData{}, ErrTemplateNotFound
+		}
 	}
 
-	bt, err := TemplateIntoBasicTemplate(tmpl, validator, ruleParsers)
+	bt, err := ResolvedTemplateIntoBasicTemplate(ctx, tmpl, templateRepository, validator, ruleParsers)
 	if err != nil {
 		return TemplateFormData{}, err
 	}
+	CompiledRulesFor(tmpl, bt, ruleParsers)
+	bt.Localize(ctx)
 
 	variant, ok := bt.Variants[variantKey]
 	if !ok && !defaultFirstVariant {
 		return TemplateFormData{}, ErrTemplateVariantNotFound
 	}
 
+	if defaultVariant, found := bt.Variants[bt.UI.DefaultVariant]; !ok && found {
+		variant, variantKey, ok = defaultVariant, bt.UI.DefaultVariant, true
+	}
+
 	if !ok {
 		for n, v := range bt.Variants {
 			variant = v
@@ -107,14 +135,215 @@ func TemplateFormFromRequest(
 	displayTypes := TemplateDisplayTypes(bt, RuleParsers())
 
 	return TemplateFormData{
-		Template:     bt,
-		Variant:      &variant,
-		VariantKey:   variantKey,
-		DisplayTypes: displayTypes,
-		TemplateID:   templateUUID,
+		Template:      bt,
+		Variant:       &variant,
+		VariantKey:    variantKey,
+		DisplayTypes:  displayTypes,
+		TemplateID:    templateUUID,
+		RequirementID: uuid.New(),
+		UI:            bt.UI,
 	}, nil
 }
 
+// CompareVariants prepares all variants of the template to be displayed side by side. The variants are sorted by their key
+// and each variant's rules are resolved in the order they are referenced by the variant. Rules that are referenced
+// but not defined in the template are skipped, a validated template will never contain those.
+func CompareVariants(bt *BasicTemplate, displayTypes map[string]TemplateDisplayType) []VariantComparison {
+	keys := make([]string, 0, len(bt.Variants))
+	for key := range bt.Variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	comparison := make([]VariantComparison, 0, len(keys))
+	for _, key := range keys {
+		variant := bt.Variants[key]
+		rules := make([]VariantComparisonRule, 0, len(variant.Rules))
+		for _, ruleKey := range variant.Rules {
+			rule, ok := bt.Rules[ruleKey]
+			if !ok {
+				continue
+			}
+
+			rules = append(rules, VariantComparisonRule{Key: ruleKey, Rule: rule, DisplayType: displayTypes[ruleKey]})
+		}
+
+		comparison = append(comparison, VariantComparison{Key: key, Variant: variant, Rules: rules})
+	}
+
+	return comparison
+}
+
+// VariantComparisonMarkdown renders the variant comparison as a Markdown document.
+// The document can be exported and shared, e.g. to discuss a template's consistency in a workshop.
+func VariantComparisonMarkdown(bt *BasicTemplate, comparison []VariantComparison) string {
+	md := &strings.Builder{}
+
+	fmt.Fprintf(md, "# %s (%s)\n\n", bt.Name, bt.Version)
+	if bt.Description != "" {
+		fmt.Fprintf(md, "%s\n\n", bt.Description)
+	}
+
+	for _, vc := range comparison {
+		fmt.Fprintf(md, "## %s (%s)\n\n", vc.Variant.Name, vc.Key)
+		if vc.Variant.Description != "" {
+			fmt.Fprintf(md, "%s\n\n", vc.Variant.Description)
+		}
+		if vc.Variant.Format != "" {
+			fmt.Fprintf(md, "Format: %s\n\n", vc.Variant.Format)
+		}
+		if vc.Variant.Example != "" {
+			fmt.Fprintf(md, "Example: %s\n\n", vc.Variant.Example)
+		}
+
+		md.WriteString("| Rule | Type | Optional | Value | Hint |\n")
+		md.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, r := range vc.Rules {
+			fmt.Fprintf(
+				md,
+				"| %s (%s) | %s | %t | %s | %s |\n",
+				markdownCell(r.Rule.Name),
+				markdownCell(r.Key),
+				markdownCell(r.Rule.Type),
+				r.Rule.Optional,
+				markdownCell(ruleValueString(r.Rule.Value)),
+				markdownCell(r.Rule.Hint),
+			)
+		}
+		md.WriteString("\n")
+	}
+
+	return strings.TrimSpace(md.String()) + "\n"
+}
+
+// ExportRequirement prepares the parsed requirement to be exported. The segments are keyed by the rules' keys,
+// they are exported by the display name of their rule in the order of the variant's rules. Empty segments are skipped.
+// The requirement is formatted in the variant's output format, see BasicTemplate.FormattedOutput.
+// The parsing logs of the result are translated using the translator.
+func ExportRequirement(
+	bt *BasicTemplate,
+	templateID uuid.UUID,
+	variant *BasicVariant,
+	segments map[string]string,
+	result parser.ParsingResult,
+	translator trans.Translator,
+) *ExportedRequirement {
+	exported := &ExportedRequirement{
+		Requirement: result.Requirement,
+		Output:      bt.FormattedOutput(variant, segments, result.Requirement),
+		TemplateID:  templateID.String(),
+		Template:    fmt.Sprintf("%s (%s)", bt.Name, bt.Version),
+		Variant:     variant.Name,
+	}
+
+	for _, ruleKey := range variant.Rules {
+		rule, ok := bt.Rules[ruleKey]
+		value := strings.TrimSpace(segments[ruleKey])
+		if !ok || value == "" {
+			continue
+		}
+
+		exported.Segments = append(exported.Segments, ExportedSegment{Rule: rule.Name, Value: value})
+	}
+
+	for _, logs := range [][]parser.ParsingLog{result.Errors, result.Warnings, result.Notices} {
+		for _, log := range logs {
+			exportedLog := ExportedLog{Level: log.Level.String(), Message: log.Translate(translator)}
+			if log.Segment != nil {
+				exportedLog.Rule = bt.Rules[log.Segment.Name].Name
+			}
+
+			exported.Logs = append(exported.Logs, exportedLog)
+		}
+	}
+
+	return exported
+}
+
+// RequirementToSave converts the exported requirement into a requirement to store under the id. The requirement is tagged
+// with the free-form tags and the automatic tags of its template and variant (see requirement.TagsToSave).
+func RequirementToSave(id uuid.UUID, bt *BasicTemplate, exported *ExportedRequirement, tags []string, userID uuid.UUID) *requirement.ToSave {
+	templateID, _ := uuid.Parse(exported.TemplateID)
+	toSave := &requirement.ToSave{
+		ID:         id,
+		TemplateID: templateID,
+		Template:   exported.Template,
+		Variant:    exported.Variant,
+		Text:       exported.Requirement,
+		Output:     exported.Output,
+		Tags:       requirement.TagsToSave(tags, bt.Name, exported.Variant),
+		CreatedBy:  userID,
+	}
+
+	for _, segment := range exported.Segments {
+		toSave.Segments = append(toSave.Segments, requirement.Segment{Rule: segment.Rule, Value: segment.Value})
+	}
+
+	return toSave
+}
+
+// RequirementsReqIF converts the exported requirements into a ReqIF document. Each requirement is a spec object
+// with its formatted text (reqif.TextAttribute, see ExportedRequirement.Formatted), its identifier or else a generated id (reqif.ForeignIDAttribute), its template and variant (TemplateAttribute
+// and VariantAttribute) and an attribute per rule containing the requirement's segment. The rules' attributes are defined
+// in the order they first occur, requirements without a segment for a rule do not contain a value for the rule's attribute.
+func RequirementsReqIF(title string, requirements []ExportedRequirement) *reqif.Document {
+	doc := &reqif.Document{
+		Title:      title,
+		Attributes: []string{reqif.ForeignIDAttribute, reqif.TextAttribute, TemplateAttribute, VariantAttribute},
+		Objects:    make([]*reqif.SpecObject, 0, len(requirements)),
+	}
+
+	for i, requirement := range requirements {
+		foreignID := requirement.Identifier
+		if foreignID == "" {
+			foreignID = fmt.Sprintf("REQ-%d", i+1)
+		}
+
+		values := map[string]string{
+			reqif.ForeignIDAttribute: foreignID,
+			reqif.TextAttribute:      requirement.Formatted(),
+			TemplateAttribute:        requirement.Template,
+			VariantAttribute:         requirement.Variant,
+		}
+
+		for _, segment := range requirement.Segments {
+			if !slices.Contains(doc.Attributes, segment.Rule) {
+				doc.Attributes = append(doc.Attributes, segment.Rule)
+			}
+
+			values[segment.Rule] = segment.Value
+		}
+
+		doc.Objects = append(doc.Objects, &reqif.SpecObject{Values: values})
+	}
+
+	return doc
+}
+
+// ruleValueString converts a rule's value into a human-readable string. Slices are joined by a comma.
+func ruleValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+		return strings.Join(values, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// markdownCell escapes a value to be safely used inside a Markdown table cell.
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
 // SegmentMapFromRequest parses the segments from the request and returns a map of segment names to values.
 // The length parameter is used to initialize the map with a given length. If the length is 0, the map will be
 // initialized with a length of 0, no error will occur. The length is only used for pre-allocation.
@@ -215,3 +444,93 @@ func CopyAfterParseSetting(request *http.Request, sessionStore user.SessionRepos
 
 	return copyAfterParse == "on"
 }
+
+// GuidedModeFeature is the feature flag of the guided mode, see GuidedModeSetting.
+const GuidedModeFeature = "guided_elicitation"
+
+// UISettingsForUser returns the template's UI settings overridden by the user's settings for the template stored in the
+// user's preferences (see SetUserUISettings). The template's settings are returned if the user did not override them.
+func UISettingsForUser(ctx context.Context, preferences user.PreferenceRepository, userID uuid.UUID, templateID uuid.UUID, settings template.UISettings) template.UISettings {
+	override := template.UISettings{}
+	if err := preferences.Find(ctx, userID, uiPreferenceKey(templateID), &override); err != nil {
+		return settings
+	}
+
+	return settings.Merge(override)
+}
+
+// UISettingsFromRequest reads the UI settings from the request's form values: defaultVariant, fieldOrder
+// (technical names of rules separated by commas), textareaMinRows and parseHotkey. Invalid numbers are read as -1.
+func UISettingsFromRequest(request *http.Request) template.UISettings {
+	settings := template.UISettings{
+		DefaultVariant: strings.TrimSpace(request.FormValue("defaultVariant")),
+		ParseHotkey:    strings.TrimSpace(request.FormValue("parseHotkey")),
+	}
+
+	for _, rule := range strings.Split(request.FormValue("fieldOrder"), ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			settings.FieldOrder = append(settings.FieldOrder, rule)
+		}
+	}
+
+	if rows := strings.TrimSpace(request.FormValue("textareaMinRows")); rows != "" {
+		settings.TextareaMinRows = -1
+		if n, err := strconv.Atoi(rows); err == nil {
+			settings.TextareaMinRows = n
+		}
+	}
+
+	return settings
+}
+
+// SetUserUISettings stores the user's UI settings for the template in the user's preferences, see UISettingsForUser.
+// Empty settings reset the user's settings to the template's settings.
+func SetUserUISettings(ctx context.Context, preferences user.PreferenceRepository, userID uuid.UUID, templateID uuid.UUID, settings template.UISettings) error {
+	if settings.DefaultVariant == "" && len(settings.FieldOrder) == 0 && settings.TextareaMinRows == 0 && settings.ParseHotkey == "" {
+		return preferences.Delete(ctx, userID, uiPreferenceKey(templateID))
+	}
+
+	return preferences.Save(ctx, userID, uiPreferenceKey(templateID), settings)
+}
+
+// GuidedModeSetting returns true if the user turned on the guided mode of the elicitation form.
+// In guided mode the user fills in one rule at a time and each segment is validated immediately, which helps novice requirement writers.
+// The setting is stored in the user's session, see SetGuidedModeSetting. It is off if the session or setting could not be read
+// or the GuidedModeFeature flag is not active for the user.
+func GuidedModeSetting(request *http.Request, sessionStore user.SessionRepository) bool {
+	if !feature.Enabled(request.Context(), GuidedModeFeature) {
+		return false
+	}
+
+	session, err := user.SessionFromRequest(request, sessionStore)
+	if err != nil {
+		return false
+	}
+
+	guided, err := session.Setting("eiffel.GuidedMode")
+	if err != nil {
+		return false
+	}
+
+	return guided == "on"
+}
+
+// SetGuidedModeSetting turns the guided mode of the elicitation form on or off in the user's session, see GuidedModeSetting.
+func SetGuidedModeSetting(request *http.Request, sessionStore user.SessionRepository, guided bool) error {
+	session, err := user.SessionFromRequest(request, sessionStore)
+	if err != nil {
+		return err
+	}
+
+	value := "off"
+	if guided {
+		value = "on"
+	}
+	session.AddSetting("eiffel.GuidedMode", value)
+
+	return sessionStore.Write(request.Context(), session.ID, session)
+}
+
+func uiPreferenceKey(templateID uuid.UUID) string {
+	return fmt.Sprintf("template.ui.%s", templateID)
+}
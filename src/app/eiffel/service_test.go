@@ -0,0 +1,157 @@
+package eiffel
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/reqif"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCompareVariants(t *testing.T) {
+	bt := basicTemplate()
+	bt.Variants["anotherVariant"] = BasicVariant{
+		Name:  "Another Variant",
+		Rules: []string{"fooRule", "stateVerbRule"},
+	}
+
+	comparison := CompareVariants(bt, TemplateDisplayTypes(bt, ruleParsers()))
+	require.Len(t, comparison, 2)
+
+	assert.Equal(t, "anotherVariant", comparison[0].Key)
+	require.Len(t, comparison[0].Rules, 2)
+	assert.Equal(t, "fooRule", comparison[0].Rules[0].Key)
+	assert.Equal(t, TemplateDisplayString, comparison[0].Rules[0].DisplayType)
+	assert.Equal(t, "stateVerbRule", comparison[0].Rules[1].Key)
+
+	assert.Equal(t, "basicVariant", comparison[1].Key)
+	assert.Len(t, comparison[1].Rules, 5)
+}
+
+func TestVariantComparisonMarkdown(t *testing.T) {
+	bt := basicTemplate()
+	markdown := VariantComparisonMarkdown(bt, CompareVariants(bt, TemplateDisplayTypes(bt, ruleParsers())))
+
+	assert.Contains(t, markdown, "# Test Template (1.0.0)\n")
+	assert.Contains(t, markdown, "## Basic VariantName (matching \"foo\") (basicVariant)\n")
+	assert.Contains(t, markdown, "| State Verb Rule (stateVerbRule) | equalsAny | false | was, will, is |  |\n")
+	assert.Contains(t, markdown, "| Foo Postfix Rule (fooPostfixRule) | placeholder | true |  |  |\n")
+}
+
+func TestExportRequirement(t *testing.T) {
+	bt := basicTemplate()
+	variant := bt.Variants["basicVariant"]
+	templateID := uuid.New()
+	segments := map[string]string{"fooRule": "foo", "stateVerbRule": "is", "fooPostfixRule": "  ", "unknownRule": "bar"}
+	result := parser.ParsingResult{
+		Requirement: "is foo",
+		Warnings:    []parser.ParsingLog{{Segment: &parser.ParsingSegment{Name: "fooRule"}, Level: parser.ParsingLogLevelWarning, Message: "weak"}},
+		Notices:     []parser.ParsingLog{{Level: parser.ParsingLogLevelNotice, Message: "general"}},
+	}
+
+	exported := ExportRequirement(bt, templateID, &variant, segments, result, trans.NewTranslator())
+	assert.Equal(t, &ExportedRequirement{
+		Requirement: "is foo",
+		TemplateID:  templateID.String(),
+		Template:    "Test Template (1.0.0)",
+		Variant:     variant.Name,
+		Segments: []ExportedSegment{
+			{Rule: "State Verb Rule", Value: "is"},
+			{Rule: "Foo Rule", Value: "foo"},
+		},
+		Logs: []ExportedLog{
+			{Level: "warning", Rule: "Foo Rule", Message: "weak"},
+			{Level: "notice", Message: "general"},
+		},
+	}, exported)
+	assert.False(t, exported.Flawless())
+
+	variant.Output = "{{ .fooRule }} {{ .stateVerbRule }}!"
+	exported = ExportRequirement(bt, templateID, &variant, segments, result, trans.NewTranslator())
+	assert.Equal(t, "foo is!", exported.Output)
+	assert.Equal(t, "foo is!", RequirementToSave(uuid.New(), bt, exported, nil, uuid.New()).Output)
+}
+
+func TestRequirementsReqIF(t *testing.T) {
+	doc := RequirementsReqIF("Requirements", []ExportedRequirement{
+		{Requirement: "is foo", Template: "Test", Variant: "Basic", Segments: []ExportedSegment{{Rule: "Verb", Value: "is"}, {Rule: "Foo", Value: "foo"}}},
+		{Requirement: "bar", Identifier: "REQ-0042", Template: "Test", Variant: "Other", Segments: []ExportedSegment{{Rule: "Bar", Value: "bar"}, {Rule: "Verb", Value: "was"}}},
+	})
+
+	assert.Equal(t, "Requirements", doc.Title)
+	assert.Equal(t, []string{
+		reqif.ForeignIDAttribute,
+		reqif.TextAttribute,
+		TemplateAttribute,
+		VariantAttribute,
+		"Verb",
+		"Foo",
+		"Bar",
+	}, doc.Attributes)
+	require.Len(t, doc.Objects, 2)
+	assert.Equal(t, map[string]string{
+		reqif.ForeignIDAttribute: "REQ-0042",
+		reqif.TextAttribute:      "bar",
+		TemplateAttribute:        "Test",
+		VariantAttribute:         "Other",
+		"Bar":                    "bar",
+		"Verb":                   "was",
+	}, doc.Objects[1].Values)
+
+	requirements := doc.Requirements(doc.DefaultMapping())
+	require.Len(t, requirements, 2)
+	assert.Equal(t, "REQ-1", requirements[0].ID)
+	assert.Equal(t, "is foo", requirements[0].Text)
+}
+
+func TestUISettingsFromRequest(t *testing.T) {
+	form := url.Values{
+		"defaultVariant":  {" short "},
+		"fieldOrder":      {"system, condition,,"},
+		"textareaMinRows": {"3"},
+		"parseHotkey":     {"s"},
+	}
+	request := httptest.NewRequest("POST", "/eiffel/settings/id", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	assert.Equal(t, template.UISettings{
+		DefaultVariant:  "short",
+		FieldOrder:      []string{"system", "condition"},
+		TextareaMinRows: 3,
+		ParseHotkey:     "s",
+	}, UISettingsFromRequest(request))
+
+	request = httptest.NewRequest("POST", "/eiffel/settings/id", strings.NewReader("textareaMinRows=many"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, -1, UISettingsFromRequest(request).TextareaMinRows)
+}
+
+func TestTemplateFormData_OrderedRules(t *testing.T) {
+	formData := TemplateFormData{
+		Variant: &BasicVariant{Rules: []string{"a", "b", "c"}},
+		UI:      template.UISettings{FieldOrder: []string{"c"}},
+	}
+	assert.Equal(t, []string{"c", "a", "b"}, formData.OrderedRules())
+	assert.Nil(t, TemplateFormData{}.OrderedRules())
+}
+
+func TestTemplateFormData_RequirementLabel(t *testing.T) {
+	formData := TemplateFormData{ParsingResult: &parser.ParsingResult{Requirement: "The system shall log"}}
+	assert.Equal(t, "The system shall log", formData.RequirementLabel())
+
+	formData.Identifier = "REQ-0007"
+	assert.Equal(t, "REQ-0007: The system shall log", formData.RequirementLabel())
+	assert.Equal(t, "REQ-0007: The system shall log", ExportedRequirement{Requirement: "The system shall log", Identifier: "REQ-0007"}.Label())
+	assert.Empty(t, TemplateFormData{}.RequirementLabel())
+
+	formData.Template = &BasicTemplate{}
+	formData.Variant = &BasicVariant{Output: "{{ .system }} SHALL {{ .process }}."}
+	formData.SegmentMap = map[string]string{"system": "The system", "process": "log"}
+	assert.Equal(t, "REQ-0007: The system SHALL log.", formData.RequirementLabel())
+}
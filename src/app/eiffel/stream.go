@@ -0,0 +1,176 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"strings"
+)
+
+// ParsedRule is emitted by BasicTemplate.ParseStream after a rule of the variant was parsed. Rule is the technical name of the rule.
+// Repeated rules are emitted per occurrence (e.g. "stakeholder[0]"), too few or too many occurrences with the group's technical name.
+// The logs of the template's constraints are emitted last with an empty rule, see BasicConstraint.
+type ParsedRule struct {
+	Rule string
+	Logs []parser.ParsingLog
+}
+
+// ParseOption configures BasicTemplate.ParseStream.
+type ParseOption func(*parseOptions)
+
+// parseOptions are the options applied through ParseOption.
+type parseOptions struct {
+	stopAtFirstError bool
+	prefilled        map[string]string
+}
+
+// StopAtFirstError stops parsing after the first rule with a parsing error. The remaining rules are not parsed
+// and the constraints are not evaluated, the result only contains the logs up to and including the error.
+// Errors downgraded to notices (optional rules) do not stop parsing.
+func StopAtFirstError() ParseOption {
+	return func(o *parseOptions) {
+		o.stopAtFirstError = true
+	}
+}
+
+// ParseStream parses the requirement like Parse but emits the parsing logs of each rule of the variant as soon as the rule
+// is parsed, followed by the logs of the constraints. This reduces the latency of large batch jobs and API clients
+// processing the logs incrementally. If emit returns an error parsing is stopped and the error is returned.
+// The logs may be forwarded to a channel, e.g.:
+//
+//	_, err := bt.ParseStream(ctx, ruleParsers, variant, segments, func(parsed eiffel.ParsedRule) error {
+//		select {
+//		case logs <- parsed:
+//			return nil
+//		case <-ctx.Done():
+//			return ctx.Err()
+//		}
+//	})
+//
+// The returned result contains all emitted logs and the requirement built from the parsed segments.
+//
+// The context is checked before each rule: if it is done, parsing is stopped and the context's error is returned.
+// A rule parser exceeding the rule timeout of the provider (see RuleParserProvider.SetRuleTimeout) is reported as
+// a parsing error of the rule ("eiffel.parser.error.rule-timeout") and parsing continues with the next rule.
+func (bt *BasicTemplate) ParseStream(
+	ctx context.Context,
+	ruleParsers *RuleParserProvider,
+	variantName string,
+	segments []parser.ParsingSegment,
+	emit func(ParsedRule) error,
+	opts ...ParseOption,
+) (parser.ParsingResult, error) {
+	options := &parseOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	result := parser.ParsingResult{
+		TemplateID:      bt.ID,
+		TemplateType:    BasicTemplateType,
+		TemplateVersion: bt.Version,
+		TemplateName:    bt.Name,
+		VariantName:     variantName,
+		Requirement:     "",
+	}
+
+	ctx = withRuleResolver(ctx, bt, ruleParsers)
+	ctx = withLanguageCheck(ctx, bt)
+	indexedSegments := prepareSegments(segments)
+	ctx = withSegments(ctx, bt.Rules, indexedSegments)
+	variant, ok := bt.Variants[variantName]
+	if !ok {
+		return result, ErrInvalidVariant
+	}
+	result.VariantName = variant.Name
+
+	parsedGroups := make(map[string]bool, len(variant.Groups))
+	for _, ruleName := range variant.Rules {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		if groupKey, ok := variant.GroupOf(ruleName); ok {
+			if parsedGroups[groupKey] {
+				continue
+			}
+			parsedGroups[groupKey] = true
+
+			stop, err := bt.parseGroup(ctx, ruleParsers, variant.Groups[groupKey], groupKey, segments, &result, emit, options)
+			if err != nil {
+				return result, err
+			}
+			if stop {
+				result.Requirement = strings.TrimSpace(result.Requirement)
+				return result, nil
+			}
+			continue
+		}
+
+		rule, ok := bt.Rules[ruleName]
+		if !ok {
+			return result, RuleMissingError{Rule: ruleName, Template: bt.Name, Variant: variant.Name}
+		}
+
+		segment := indexedSegments[ruleName]
+		segment.Name = ruleName
+
+		rule, active, conditionLogs := conditionalRule(variant, ruleName, rule, indexedSegments)
+		if !active {
+			addParsingLogs(&result, conditionLogs)
+			if err := emit(ParsedRule{Rule: ruleName, Logs: conditionLogs}); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		parsingLogs, err := parseSegment(ctx, ruleParsers, ruleName, rule, segment)
+		if err != nil {
+			return result, err
+		}
+
+		if segment.Value != "" {
+			buildRequirementIncrementally(rule, segment, &result)
+		}
+		parsingLogs = append(parsingLogs, untouchedDefaultLog(rule, segment, options.prefilled)...)
+
+		errorsBefore := len(result.Errors)
+		addParsingLogs(&result, parsingLogs)
+		if err := emit(ParsedRule{Rule: ruleName, Logs: parsingLogs}); err != nil {
+			return result, err
+		}
+
+		if options.stopAtFirstError && len(result.Errors) > errorsBefore {
+			result.Requirement = strings.TrimSpace(result.Requirement)
+			return result, nil
+		}
+	}
+
+	result.Requirement = strings.TrimSpace(result.Requirement)
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	constraintLogs := bt.parseConstraints(ctx, variantName, indexedSegments, result)
+	addParsingLogs(&result, constraintLogs)
+	if len(constraintLogs) > 0 {
+		if err := emit(ParsedRule{Logs: constraintLogs}); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// addParsingLogs adds the logs to the errors, warnings and notices of the result by their level.
+func addParsingLogs(result *parser.ParsingResult, logs []parser.ParsingLog) {
+	for _, log := range logs {
+		switch log.Level {
+		case parser.ParsingLogLevelError:
+			result.Errors = append(result.Errors, log)
+		case parser.ParsingLogLevelWarning:
+			result.Warnings = append(result.Warnings, log)
+		case parser.ParsingLogLevelNotice:
+			result.Notices = append(result.Notices, log)
+		}
+	}
+}
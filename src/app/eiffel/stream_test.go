@@ -0,0 +1,66 @@
+package eiffel
+
+import (
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBasicTemplate_ParseStream(t *testing.T) {
+	bt := basicTemplate()
+	bt.Constraints = []BasicConstraint{{Name: "short", Expression: "len(requirement) < 5"}}
+	segments := []parser.ParsingSegment{
+		{Name: "stateVerbRule", Value: "are"},
+		{Name: "fooRule", Value: "bar"},
+		{Name: "fooPostfixRule", Value: "example"},
+	}
+
+	t.Run("emits the logs of each rule", func(t *testing.T) {
+		var parsed []ParsedRule
+		result, err := bt.ParseStream(context.Background(), ruleParsers(), "basicVariant", segments, func(rule ParsedRule) error {
+			parsed = append(parsed, rule)
+			return nil
+		})
+		require.NoError(t, err)
+
+		var rules []string
+		for _, rule := range parsed {
+			rules = append(rules, rule.Rule)
+		}
+		assert.Equal(t, []string{"stateVerbRule", "fooRule", "fooPostfixRule", "optionalMissingTestRule", "optionalErrorTestRule", ""}, rules)
+		assert.Len(t, parsed[0].Logs, 1)
+		assert.Equal(t, "short", parsed[5].Logs[0].Constraint)
+
+		expected, err := bt.Parse(context.Background(), ruleParsers(), "basicVariant", segments...)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("stops at the first error", func(t *testing.T) {
+		var rules []string
+		result, err := bt.ParseStream(context.Background(), ruleParsers(), "basicVariant", segments, func(rule ParsedRule) error {
+			rules = append(rules, rule.Rule)
+			return nil
+		}, StopAtFirstError())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"stateVerbRule"}, rules)
+		assert.Len(t, result.Errors, 1)
+		assert.Equal(t, "are", result.Requirement)
+	})
+
+	t.Run("stops on emit error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		_, err := bt.ParseStream(context.Background(), ruleParsers(), "basicVariant", segments, func(rule ParsedRule) error {
+			calls++
+			return stop
+		})
+
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+}
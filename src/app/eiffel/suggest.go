@@ -0,0 +1,108 @@
+package eiffel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultSuggestionsLimit is the number of suggestions returned if no limit is configured, see SuggestionsCfg.
+	DefaultSuggestionsLimit = 10
+)
+
+// ErrNoSuggestions is returned if suggestions are requested for a rule that does not define a list of values.
+var ErrNoSuggestions = errors.New("eiffel.elicitation.suggestions.unsupported")
+
+// SuggestionsCfg configures the suggestions for rules rendered as single-select, see BasicTemplate.Suggest.
+type SuggestionsCfg struct {
+	// Limit is the maximum number of suggestions returned per request. DefaultSuggestionsLimit is used if it is 0.
+	Limit int `toml:"limit" env:"EIFFEL_SUGGESTIONS_LIMIT"`
+	// Inline is the maximum number of values of a rule rendered inline into the elicitation form.
+	// Rules with more values fetch their suggestions while the user types. If it is 0 all values are rendered inline.
+	Inline int `toml:"inline" env:"EIFFEL_SUGGESTIONS_INLINE"`
+}
+
+// Suggest returns the values of the equalsAny rule matching the query ranked by relevance (see SuggestValues).
+// The values are localized to the user's locale read from the context. Suggest returns a RuleMissingError
+// if the template does not define the rule and ErrNoSuggestions if the rule is not of type equalsAny.
+func (bt *BasicTemplate) Suggest(ctx context.Context, ruleName string, query string, limit int) ([]string, error) {
+	rule, ok := bt.Rules[ruleName]
+	if !ok {
+		return nil, RuleMissingError{Rule: ruleName, Template: bt.Name}
+	}
+
+	if rule.Type != "equalsAny" {
+		return nil, ErrNoSuggestions
+	}
+
+	values, err := toStringSlice(LocalizedValue(ctx, rule))
+	if err != nil {
+		return nil, RuleInvalidValueError{Rule: &rule, Msg: err.Error()}
+	}
+
+	return SuggestValues(values, query, limit), nil
+}
+
+// SuggestValues filters the values by the query and ranks them: values equal to the query come first, followed by values
+// starting with the query, values containing a word starting with the query and values containing the query.
+// Values of the same rank keep their order. The comparison is case-insensitive and duplicates are removed.
+// An empty query matches all values. At most limit values are returned, all matching values if limit is 0 or less.
+func SuggestValues(values []string, query string, limit int) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	type suggestion struct {
+		value string
+		rank  int
+	}
+
+	seen := make(map[string]bool, len(values))
+	suggestions := make([]suggestion, 0, len(values))
+	for _, value := range values {
+		normalized := strings.ToLower(strings.TrimSpace(value))
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+
+		rank := suggestionRank(normalized, query)
+		if rank < 0 {
+			continue
+		}
+
+		seen[normalized] = true
+		suggestions = append(suggestions, suggestion{value: value, rank: rank})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].rank < suggestions[j].rank
+	})
+
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	ranked := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		ranked = append(ranked, s.value)
+	}
+
+	return ranked
+}
+
+// suggestionRank returns the rank of the value for the query (lower is better) or -1 if the value does not match.
+// Both value and query are expected to be lower case.
+func suggestionRank(value string, query string) int {
+	switch {
+	case value == query:
+		return 0
+	case strings.HasPrefix(value, query):
+		return 1
+	case strings.Contains(" "+value, " "+query):
+		return 2
+	case strings.Contains(value, query):
+		return 3
+	default:
+		return -1
+	}
+}
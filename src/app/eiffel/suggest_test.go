@@ -0,0 +1,38 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSuggestValues(t *testing.T) {
+	values := []string{"Customer Service", "Service Desk", "Administrator", "service", "Web Services", "SERVICE", "Accountant"}
+
+	assert.Equal(t, []string{"service", "Service Desk", "Customer Service", "Web Services"}, SuggestValues(values, " Service", 0))
+	assert.Equal(t, []string{"service", "Service Desk"}, SuggestValues(values, "service", 2))
+	assert.Equal(t, []string{"Administrator", "Accountant"}, SuggestValues(values, "a", 0)[:2])
+	assert.Equal(t, []string{"Customer Service", "Service Desk", "Administrator"}, SuggestValues(values, "", 3))
+	assert.Empty(t, SuggestValues(values, "unknown", 0))
+}
+
+func TestBasicTemplate_Suggest(t *testing.T) {
+	bt := &BasicTemplate{
+		Name: "Suggest",
+		Rules: map[string]BasicRule{
+			"actor":  {Name: "Actor", Type: "equalsAny", Value: map[string]any{"en": []any{"User", "Administrator"}, "de": []any{"Benutzer", "Administrator"}}},
+			"system": {Name: "System", Type: "placeholder"},
+		},
+	}
+
+	suggestions, err := bt.Suggest(context.Background(), "actor", "nutz", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Benutzer"}, suggestions, "without a translator the first locale is used")
+
+	_, err = bt.Suggest(context.Background(), "system", "", 10)
+	assert.ErrorIs(t, err, ErrNoSuggestions)
+
+	_, err = bt.Suggest(context.Background(), "unknown", "", 10)
+	assert.IsType(t, RuleMissingError{}, err)
+}
@@ -0,0 +1,122 @@
+package eiffel
+
+import (
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"time"
+)
+
+// DefaultRuleTimeout is the maximum duration a rule parser may take to parse a single rule, see RuleParserProvider.SetRuleTimeout.
+const DefaultRuleTimeout = 5 * time.Second
+
+// parsedLogs is the result of a rule parser running in its own goroutine, see parseWithTimeout.
+type parsedLogs struct {
+	logs []parser.ParsingLog
+	err  error
+}
+
+// SetRuleTimeout sets the maximum duration a rule parser may take to parse a single rule.
+// A timeout <= 0 resets the timeout to DefaultRuleTimeout.
+func (p *RuleParserProvider) SetRuleTimeout(timeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ruleTimeout = timeout
+}
+
+// RuleTimeout returns the maximum duration a rule parser may take to parse a single rule.
+func (p *RuleParserProvider) RuleTimeout() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.ruleTimeout <= 0 {
+		return DefaultRuleTimeout
+	}
+
+	return p.ruleTimeout
+}
+
+// ruleParsersFor returns the rule parsers (see RuleParsers) with the rule timeout configured in the EIFFEL config.
+func ruleParsersFor(cfg Cfg) *RuleParserProvider {
+	ruleParsers := RuleParsers()
+	ruleParsers.SetRuleTimeout(time.Duration(cfg.RuleTimeout) * time.Millisecond)
+
+	return ruleParsers
+}
+
+// parseWithTimeout parses the rule with the rule parser in a separate goroutine and stops waiting for it after the timeout.
+// This prevents a misbehaving (custom) rule parser from hanging the request. If the rule parser exceeds the timeout,
+// a parsing error identifying the rule is returned as a log. If the parent context is done, its error is returned instead.
+// The rule parser is expected to stop once its context is done, otherwise its goroutine is leaked until it returns.
+// Built-in rule parsers do not block and are called directly, the rules referenced by combinators are still parsed within the timeout.
+func parseWithTimeout(
+	ctx context.Context,
+	ruleParser RuleParser,
+	timeout time.Duration,
+	ruleName string,
+	rule BasicRule,
+	segment parser.ParsingSegment,
+) ([]parser.ParsingLog, error) {
+	if isBuiltinRuleParser(ruleParser) {
+		return ruleParser.Parse(ctx, rule, segment)
+	}
+
+	ruleCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan parsedLogs, 1)
+	go func() {
+		logs, err := ruleParser.Parse(ruleCtx, rule, segment)
+		done <- parsedLogs{logs: logs, err: err}
+	}()
+
+	var parsed parsedLogs
+	select {
+	case parsed = <-done:
+	case <-ruleCtx.Done():
+		parsed.err = ruleCtx.Err()
+	}
+
+	if parsed.err == nil {
+		return parsed.logs, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if errors.Is(parsed.err, context.DeadlineExceeded) && ruleCtx.Err() != nil {
+		return []parser.ParsingLog{ruleTimeoutLog(ruleName, rule, timeout)}, nil
+	}
+
+	return nil, parsed.err
+}
+
+// ruleTimeoutLog returns the parsing error of a rule whose parser exceeded the timeout.
+func ruleTimeoutLog(ruleName string, rule BasicRule, timeout time.Duration) parser.ParsingLog {
+	return parser.ParsingLog{
+		Segment: &parser.ParsingSegment{Name: ruleName},
+		Level:   parser.ParsingLogLevelError,
+		Message: "eiffel.parser.error.rule-timeout",
+		TranslationArgs: []string{
+			"name",
+			rule.Name,
+			"technicalName",
+			ruleName,
+			"timeout",
+			timeout.String(),
+		},
+	}
+}
+
+// isBuiltinRuleParser returns true if the rule parser is one of the built-in rule parsers, see builtinRuleParsers.
+func isBuiltinRuleParser(ruleParser RuleParser) bool {
+	switch ruleParser.(type) {
+	case EqualsRuleParser, EqualsAnyRuleParser, PlaceholderRuleParser, ForbidsRuleParser,
+		AllOfRuleParser, AnyOfRuleParser, NotRuleParser, ScriptRuleParser:
+		return true
+	}
+
+	return false
+}
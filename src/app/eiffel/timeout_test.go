@@ -0,0 +1,91 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// blockingRuleParser is a misbehaving rule parser blocking until its context is done.
+type blockingRuleParser struct {
+	PlaceholderRuleParser
+}
+
+func (p blockingRuleParser) Parse(ctx context.Context, rule BasicRule, segment parser.ParsingSegment) ([]parser.ParsingLog, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestBasicTemplate_ParseRuleTimeout(t *testing.T) {
+	bt := &BasicTemplate{
+		Name: "Timeout",
+		Rules: map[string]BasicRule{
+			"system":  {Name: "System", Type: "placeholder"},
+			"blocked": {Name: "Blocked", Type: "blocking"},
+			"verb":    {Name: "Verb", Type: "allOf", Value: []any{"blocked"}},
+		},
+		Variants: map[string]BasicVariant{"default": {Name: "Default", Rules: []string{"system", "blocked"}}},
+	}
+	segments := []parser.ParsingSegment{{Name: "system", Value: "The system"}, {Name: "blocked", Value: "shall"}, {Name: "verb", Value: "shall"}}
+
+	ruleParsers := RuleParsers()
+	ruleParsers.Register("blocking", blockingRuleParser{})
+	ruleParsers.SetRuleTimeout(10 * time.Millisecond)
+
+	t.Run("reports the rule exceeding the timeout", func(t *testing.T) {
+		result, err := bt.Parse(context.Background(), ruleParsers, "default", segments...)
+		require.NoError(t, err)
+
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "eiffel.parser.error.rule-timeout", result.Errors[0].Message)
+		assert.Equal(t, []string{"name", "Blocked", "technicalName", "blocked", "timeout", "10ms"}, result.Errors[0].TranslationArgs)
+		assert.Equal(t, "The system shall", result.Requirement)
+	})
+
+	t.Run("reports the rule referenced by a combinator", func(t *testing.T) {
+		logs, err := bt.ParseSegment(context.Background(), ruleParsers, "default", parser.ParsingSegment{Name: "blocked", Value: "shall"})
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+
+		bt.Variants["default"] = BasicVariant{Name: "Default", Rules: []string{"system", "verb"}}
+		defer func() {
+			bt.Variants["default"] = BasicVariant{Name: "Default", Rules: []string{"system", "blocked"}}
+		}()
+
+		result, err := bt.Parse(context.Background(), ruleParsers, "default", segments...)
+		require.NoError(t, err)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "eiffel.parser.error.rule-timeout", result.Errors[0].Message)
+		assert.Equal(t, "blocked", result.Errors[0].Segment.Name)
+	})
+
+	t.Run("stops on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := bt.Parse(ctx, ruleParsers, "default", segments...)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		ctx, cancel = context.WithCancel(context.Background())
+		ruleParsers.SetRuleTimeout(time.Minute)
+		defer ruleParsers.SetRuleTimeout(10 * time.Millisecond)
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err = bt.Parse(ctx, ruleParsers, "default", segments...)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRuleParserProvider_RuleTimeout(t *testing.T) {
+	ruleParsers := RuleParsers()
+	assert.Equal(t, DefaultRuleTimeout, ruleParsers.RuleTimeout())
+
+	ruleParsers.SetRuleTimeout(time.Second)
+	assert.Equal(t, time.Second, ruleParsers.RuleTimeout())
+
+	assert.Equal(t, DefaultRuleTimeout, ruleParsersFor(Cfg{}).RuleTimeout())
+	assert.Equal(t, 250*time.Millisecond, ruleParsersFor(Cfg{RuleTimeout: 250}).RuleTimeout())
+}
@@ -1,22 +1,34 @@
 package eiffel
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/attachment"
+	"github.com/org-harmony/harmony/src/app/project"
+	"github.com/org-harmony/harmony/src/app/reqif"
+	"github.com/org-harmony/harmony/src/app/requirement"
 	"github.com/org-harmony/harmony/src/app/template"
 	"github.com/org-harmony/harmony/src/app/template/parser"
 	"github.com/org-harmony/harmony/src/app/user"
-	"github.com/org-harmony/harmony/src/core/config"
 	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/feature"
 	"github.com/org-harmony/harmony/src/core/hctx"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/validation"
 	"github.com/org-harmony/harmony/src/core/web"
+	"maps"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -35,6 +47,10 @@ var (
 	ErrTemplateNotFound = errors.New("eiffel.elicitation.template.not-found")
 	// ErrTemplateVariantNotFound will be displayed to the user if the template variant could not be found.
 	ErrTemplateVariantNotFound = errors.New("eiffel.elicitation.template.variant.not-found")
+	// ErrHistoryEntryNotFound will be displayed to the user if the requested entry of the parse history does not exist.
+	ErrHistoryEntryNotFound = errors.New("eiffel.elicitation.history.not-found")
+	// ErrInvalidExport will be displayed to the user if the requirements to export could not be read from the request.
+	ErrInvalidExport = errors.New("eiffel.output.export.invalid")
 )
 
 // TemplateDisplayType specifies how a rule should be displayed in the UI.
@@ -59,48 +75,293 @@ type TemplateFormData struct {
 	// ParsingResult is the result of the parsing process. This can be empty if no parsing was done yet.
 	ParsingResult *parser.ParsingResult
 	// SegmentMap is a map of rule names to their corresponding segment value.
-	// This is used to fill the segments with their values after parsing.
+	// This is used to fill the segments with their values after parsing or with their prefilled values (see Prefill).
 	SegmentMap map[string]string
+	// Prefilled are the values prefilled in the form keyed by the rules' names, see Prefill.
+	// They are submitted with the form to log untouched defaults, see WithPrefilled.
+	Prefilled map[string]string
 	// NeglectOptional is a flag indicating if optional rules (inputs) should be displayed different from non-optional rules.
 	NeglectOptional bool
+	// RequirementID identifies the requirement being elicited. Files are attached to the requirement through this ID.
+	// It is generated for each new form and kept until the requirement is parsed successfully.
+	RequirementID uuid.UUID
+	// Identifier is the human-readable identifier of the parsed requirement once it was stored, see requirement.Requirement.Identifier.
+	Identifier string
+	// Tags are the comma-separated free-form tags the requirement is stored with once it is parsed successfully (see requirement.ParseTags).
+	// They are kept for the next requirement.
+	Tags string
+	// Guided is a flag indicating if the form is rendered in guided mode. In guided mode the user fills in one rule at a time
+	// and each segment is validated immediately, see GuidedModeSetting.
+	Guided bool
+	// PDFReports is a flag indicating if reports of the elicited requirements can be exported as PDF, see NewPDFRenderer.
+	PDFReports bool
+	// InlineValues is the maximum number of values of a single-select rule rendered inline. Rules with more values
+	// fetch their suggestions while the user types, see SuggestionsCfg. All values are rendered inline if it is 0.
+	InlineValues int
+	// History is the history of parse attempts of the variant in the user's session. It is nil if the session could not be read.
+	History *History
+	// Segmentation is the result of splitting a pasted sentence into the segments (see BasicTemplate.SegmentSentence).
+	// It is nil unless the form was filled from a whole sentence.
+	Segmentation *parser.Segmentation
+	// UI are the UI settings of the template overridden by the user's settings, see UISettingsForUser.
+	UI template.UISettings
+	// WorkingSet are the user's pinned and recently used templates. It is only filled before a template is selected.
+	WorkingSet *TemplateWorkingSet
+}
+
+// OrderedRules returns the rules of the variant in the order their inputs are displayed, see template.UISettings.Order.
+func (f TemplateFormData) OrderedRules() []string {
+	if f.Variant == nil {
+		return nil
+	}
+
+	return f.UI.Order(f.Variant.Rules)
+}
+
+// RequirementLabel returns the parsed requirement in the variant's output format (see BasicTemplate.FormattedOutput)
+// prefixed with its identifier (see requirement.IdentifiedText). It is the text copied to the clipboard.
+func (f TemplateFormData) RequirementLabel() string {
+	if f.ParsingResult == nil {
+		return ""
+	}
+
+	text := f.ParsingResult.Requirement
+	if f.Template != nil {
+		if output := f.Template.FormattedOutput(f.Variant, f.SegmentMap, text); output != "" {
+			text = output
+		}
+	}
+
+	return requirement.IdentifiedText(f.Identifier, text)
+}
+
+// UISettingsData is the data that is passed to the template rendering the modal of the user's UI settings for a template.
+type UISettingsData struct {
+	Template *BasicTemplate
+	// TemplateID is the ID of the template the settings are for.
+	TemplateID uuid.UUID
+	// Settings are the user's settings overriding the template's settings (BasicTemplate.UI). They are empty if the user did not override them.
+	Settings template.UISettings
+	// Variants are the technical names (keys) of the template's variants sorted alphabetically.
+	Variants []string
+	// Rules are the technical names (keys) of the template's rules sorted alphabetically.
+	Rules []string
+}
+
+// SegmentFeedbackData is the data that is passed to the template rendering the feedback on a single parsed segment.
+type SegmentFeedbackData struct {
+	// Rule is the key of the rule the segment was parsed with. It is not the name of the rule.
+	Rule string
+	// Logs are the parsing logs of the segment. The segment is valid if no log is an error.
+	Logs []parser.ParsingLog
+	// Parsed is a flag indicating if the segment was parsed. It is false if the segment is empty.
+	Parsed bool
+}
+
+// TemplateComparisonData is the data that is passed to the template rendering the variant comparison.
+type TemplateComparisonData struct {
+	Template *BasicTemplate
+	// TemplateID is the ID of the template whose variants are compared.
+	TemplateID uuid.UUID
+	// Variants are the variants of the template sorted by their key. See CompareVariants.
+	Variants []VariantComparison
+	// Print is a flag indicating if the printable version of the comparison is rendered.
+	Print bool
+}
+
+// VariantComparison is a single variant of a template prepared to be displayed side by side with the other variants.
+type VariantComparison struct {
+	// Key is the key through which the variant is referenced in the template. It is not the name of the variant.
+	Key     string
+	Variant BasicVariant
+	// Rules are the rules of the variant in the order they are defined in the variant.
+	Rules []VariantComparisonRule
+}
+
+// VariantComparisonRule is a rule referenced by a variant in a VariantComparison.
+type VariantComparisonRule struct {
+	// Key is the key through which the rule is referenced in the template. It is not the name of the rule.
+	Key         string
+	Rule        BasicRule
+	DisplayType TemplateDisplayType
 }
 
 // SearchTemplateData contains templates to render as search results and a flag indicating if the query was too short.
 type SearchTemplateData struct {
 	Templates []*template.Template
+	// Filter selected the Templates, see SearchFilterFromRequest.
+	Filter template.SearchFilter
+	// TemplateSets are the user's template sets the templates can be filtered by. They are only filled for the search modal.
+	TemplateSets []*template.Set
+	// WorkingSet are the user's pinned and recently used templates, they are listed at the top of the search modal.
+	WorkingSet *TemplateWorkingSet
 	// Deprecated this is expected to be unnecessary with the current implementation of EIFFEL
 	QueryTooShort bool
 }
 
+// TemplateSetLookup returns the EIFFEL basic templates of a template set keyed by their ID, see BasicTemplatesOfSet.
+type TemplateSetLookup func(templateSetID uuid.UUID) (map[string]*BasicTemplate, error)
+
+// TemplateEventsTopic returns the web.Broker topic of the server-sent events concerning the template, see templateEvents.
+func TemplateEventsTopic(templateID uuid.UUID) string {
+	return "eiffel.template." + templateID.String()
+}
+
 type HTMXTriggerParsingSuccessEvent struct {
 	ParsingSuccessEvent *parser.ParsingResult `json:"parsingSuccessEvent"`
+	// Attachments are the files attached to the parsed requirement.
+	Attachments []RequirementAttachment `json:"attachments,omitempty"`
+	// Export is the parsed requirement as it is kept with the recently elicited requirements for exports, see ExportRequirement.
+	Export *ExportedRequirement `json:"export,omitempty"`
+}
+
+// RequirementAttachment is a file attached to a parsed requirement. The URL can only be used by the requirement's author.
+type RequirementAttachment struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// ExportedRequirement is an elicited requirement as it is exported, e.g. to ReqIF (see RequirementsReqIF).
+// The recently elicited requirements are kept by the client, they are therefore sent back for exporting them.
+type ExportedRequirement struct {
+	Requirement string `json:"requirement"`
+	// Identifier is the human-readable identifier of the stored requirement, e.g. "REQ-0153". It is empty if the requirement was not stored.
+	Identifier string `json:"identifier,omitempty"`
+	// Output is the requirement in the output format of its variant, it is empty if there is no output format (see BasicTemplate.FormatOutput).
+	Output string `json:"output,omitempty"`
+	// TemplateID is the ID of the template the requirement was elicited with. It is used to look up the template's metadata.
+	TemplateID string `json:"templateID,omitempty"`
+	// Template is the name and version of the template the requirement was elicited with.
+	Template string `json:"template"`
+	// Variant is the name of the variant the requirement was elicited with.
+	Variant string `json:"variant"`
+	// Segments are the non-empty segments of the requirement in the order of the variant's rules.
+	Segments []ExportedSegment `json:"segments,omitempty"`
+	// Logs are the translated parsing logs of the requirement. As only valid requirements are exported, these are warnings and notices.
+	Logs []ExportedLog `json:"logs,omitempty"`
+}
+
+// Label returns the formatted requirement (see ExportedRequirement.Formatted) prefixed with its identifier, see requirement.IdentifiedText.
+func (e ExportedRequirement) Label() string {
+	return requirement.IdentifiedText(e.Identifier, e.Formatted())
+}
+
+// Formatted returns the requirement in its output format or the requirement itself if there is no output format.
+func (e ExportedRequirement) Formatted() string {
+	if e.Output != "" {
+		return e.Output
+	}
+
+	return e.Requirement
+}
+
+// ExportedSegment is a segment of an ExportedRequirement. Rule is the display name of the rule, not its key.
+type ExportedSegment struct {
+	Rule  string `json:"rule"`
+	Value string `json:"value"`
+}
+
+// ExportedLog is a translated parsing log of an ExportedRequirement. Level is the name of the log's level (see parser.ParsingLogLevel).
+// Rule is the display name of the rule the log refers to, it is empty if the log does not refer to a rule.
+type ExportedLog struct {
+	Level   string `json:"level"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
 }
 
 // RegisterController registers the controllers as well as the navigation and event listeners.
 func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
-	cfg := Cfg{}
-	util.Ok(config.C(&cfg, config.From("eiffel"), config.Validate(appCtx.Validator)))
+	cfg := util.Unwrap(LoadCfg(appCtx.Validator))
 
 	// TODO move this to module init when module manager is implemented (see subscribeEvents)
-	subscribeEvents(appCtx)
+	subscribeEvents(cfg, appCtx)
+	forwardTemplateUpdates(appCtx, webCtx)
 
 	registerNavigation(appCtx, webCtx)
+	registerCommands(webCtx)
+	webCtx.Errors.Map(ErrTemplateNotFound, http.StatusNotFound, nil)
+	webCtx.Errors.Map(ErrTemplateVariantNotFound, http.StatusNotFound, nil)
+	webCtx.Errors.Map(ErrInvalidExport, http.StatusBadRequest, ErrInvalidExport)
+	webCtx.Errors.Map(ErrPDFDisabled, http.StatusNotFound, ErrPDFDisabled)
+	webCtx.Errors.Map(ErrSetupWizardIncomplete, http.StatusBadRequest, ErrSetupWizardIncomplete)
+
+	languageChecker := NewLanguageToolChecker(cfg.LanguageTool)
 
 	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
 
 	router.Get("/eiffel", eiffelElicitationPage(cfg, appCtx, webCtx).ServeHTTP)
 	router.Get("/eiffel/{templateID}", eiffelElicitationPage(cfg, appCtx, webCtx).ServeHTTP)
 	router.Get("/eiffel/{templateID}/{variant}", eiffelElicitationPage(cfg, appCtx, webCtx).ServeHTTP)
+	router.Get("/eiffel/compare/{templateID}", compareVariants(appCtx, webCtx, false).ServeHTTP)
+	router.Get("/eiffel/compare/{templateID}/print", compareVariants(appCtx, webCtx, true).ServeHTTP)
+	router.Get("/eiffel/compare/{templateID}/export", exportVariantComparison(appCtx, webCtx).ServeHTTP)
+	router.Get("/eiffel/settings/{templateID}", uiSettingsModal(appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/settings/{templateID}", saveUISettings(appCtx, webCtx, false).ServeHTTP)
+	router.Delete("/eiffel/settings/{templateID}", saveUISettings(appCtx, webCtx, true).ServeHTTP)
+	router.Get("/eiffel/suggest/{templateID}/{rule}", suggestValues(cfg, appCtx, webCtx).ServeHTTP)
 	router.Get("/eiffel/elicitation/templates/search/modal", searchModal(appCtx, webCtx).ServeHTTP)
 	router.Post("/eiffel/elicitation/templates/search", searchTemplate(appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/elicitation/templates/{templateID}/pin", toggleTemplatePin(appCtx, webCtx).ServeHTTP)
 	router.Get("/eiffel/elicitation/{templateID}", elicitationTemplate(cfg, appCtx, webCtx, true).ServeHTTP)
 	router.Get("/eiffel/elicitation/{templateID}/{variant}", elicitationTemplate(cfg, appCtx, webCtx, false).ServeHTTP)
-	router.Post("/eiffel/elicitation/{templateID}/{variant}", parseRequirement(cfg, appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/elicitation/{templateID}/{variant}", parseRequirement(cfg, appCtx, webCtx, languageChecker).ServeHTTP)
+	router.Post("/eiffel/elicitation/{templateID}/{variant}/segment/{rule}", parseRequirementSegment(cfg, appCtx, webCtx, languageChecker).ServeHTTP)
+	router.Post("/eiffel/elicitation/{templateID}/{variant}/history/{step}", restoreHistory(cfg, appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/elicitation/{templateID}/{variant}/draft", saveDraft(appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/elicitation/{templateID}/{variant}/sentence", segmentRequirementSentence(cfg, appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/elicitation/{templateID}/{variant}/guided", toggleGuidedMode(cfg, appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/elicitation/export/reqif", exportRequirementsReqIF(appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/elicitation/export/report/{format}", exportReport(appCtx, webCtx, NewPDFRenderer(cfg.PDF)).ServeHTTP)
+	router.Get("/eiffel/events/template/{templateID}", templateEvents(appCtx, webCtx).ServeHTTP)
+	router.Post("/eiffel/preview/{templateSetID}", previewTemplate(cfg, appCtx, webCtx, false).ServeHTTP)
+	router.Post("/eiffel/preview/{templateSetID}/parse", previewTemplate(cfg, appCtx, webCtx, true).ServeHTTP)
+
+	SetupWizard(appCtx).Register(appCtx, webCtx, router)
+
+	importWizard := ImportWizard(cfg, appCtx)
+	// the upload step of the import wizard accepts CSV files
+	importWizard.Register(appCtx, webCtx, router.With(web.UploadLimit(webCtx.Config.Limits)))
+	router.Get(ImportWizardRoute+"/{wizardID}/report", importReport(cfg, appCtx, webCtx, importWizard).ServeHTTP)
 }
 
-func subscribeEvents(appCtx *hctx.AppCtx) {
+// forwardTemplateUpdates forwards the template.TemplateUpdatedEvent to the clients eliciting requirements with the template.
+func forwardTemplateUpdates(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	webCtx.Broker.ForwardEvents(appCtx.EventManager, template.TemplateUpdatedEventID, func(e event.Event) (string, web.StreamEvent, error) {
+		updated, ok := e.Payload().(*template.TemplateUpdatedEvent)
+		if !ok {
+			return "", web.StreamEvent{}, nil
+		}
+
+		name := "template-updated"
+		if updated.Deleted {
+			name = "template-deleted"
+		}
+
+		return TemplateEventsTopic(updated.Template), web.StreamEvent{Event: name, Data: updated.Template.String()}, nil
+	})
+}
+
+func subscribeEvents(cfg Cfg, appCtx *hctx.AppCtx) {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	templateSets := func(templateSetID uuid.UUID) (map[string]*BasicTemplate, error) {
+		// events do not carry a request context, the lookup of the template set is therefore not cancelable
+		return BasicTemplatesOfSet(context.Background(), templateRepository, templateSetID)
+	}
+
 	// TODO remove this with module manager
-	appCtx.EventManager.Subscribe("template.config.validate", func(event event.Event, args *event.PublishArgs) error {
+	SubscribeTemplateValidation(appCtx.EventManager, appCtx.Validator, cfg.Limits, templateSets)
+	SubscribeTemplateLint(appCtx.EventManager, cfg.Lint, templateSets)
+	SubscribeCompiledRulesInvalidation(appCtx.EventManager)
+}
+
+// SubscribeTemplateValidation subscribes to the template.ValidateTemplateConfigEvent and validates EIFFEL basic templates.
+// The templateSets lookup is used to resolve the templates an extending template extends. It is only called for extending templates.
+// Templates exceeding the limits are invalid, their size is validated before and the numbers of rules, variants
+// and equalsAny values (see BasicTemplate.ValidateLimits) after unmarshalling and resolving them.
+// This is exported to allow validating templates outside the web application, e.g. in the templatecheck command.
+// The subscriber is safe for concurrent use (see template.ValidationPipeline) if the templateSets lookup is.
+func SubscribeTemplateValidation(em event.Manager, validator validation.V, limits LimitsCfg, templateSets TemplateSetLookup) {
+	em.Subscribe("template.config.validate", func(event event.Event, args *event.PublishArgs) error {
 		validateEvent, ok := event.Payload().(*template.ValidateTemplateConfigEvent)
 		if !ok {
 			return nil
@@ -113,6 +374,11 @@ func subscribeEvents(appCtx *hctx.AppCtx) {
 		}
 		validateEvent.DidValidate = true
 
+		if limitErrs := limits.ValidateConfigSize(validateEvent.Config); len(limitErrs) > 0 {
+			validateEvent.AddErrors(append(limitErrs, template.ErrInvalidTemplate)...)
+			return nil
+		}
+
 		ebt := &BasicTemplate{}
 		// Important notice: Unmarshalling is always case-insensitive if no other match could be found.
 		// Therefore, NAME will be unmarshalled to Name. Keep this in mind.
@@ -121,7 +387,26 @@ func subscribeEvents(appCtx *hctx.AppCtx) {
 			return err
 		}
 
-		validationErrs := ebt.Validate(appCtx.Validator, RuleParsers())
+		if ebt.Extends != "" {
+			set, err := templateSets(validateEvent.TemplateSet)
+			if err != nil {
+				return err
+			}
+			set[ebt.ID] = ebt
+
+			ebt, err = ResolveExtends(ebt, set)
+			if err != nil {
+				validateEvent.AddErrors(err, template.ErrInvalidTemplate)
+				return nil
+			}
+		}
+
+		if limitErrs := ebt.ValidateLimits(limits); len(limitErrs) > 0 {
+			validateEvent.AddErrors(append(limitErrs, template.ErrInvalidTemplate)...)
+			return nil
+		}
+
+		validationErrs := ebt.Validate(validator, RuleParsers())
 		if len(validationErrs) > 0 {
 			validateEvent.AddErrors(validationErrs...)
 			return nil
@@ -138,19 +423,79 @@ func registerNavigation(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 		Display: func(io web.IO) (bool, error) {
 			return true, nil
 		},
-		Position: 100,
+		Position:       100,
+		ActivePrefixes: []string{"/eiffel/"},
+	})
+}
+
+// registerCommands adds capturing a new requirement, the guided setup wizard and
+// switching to each of the user's EIFFEL templates to the command palette.
+// The templates are not searched by the query as the query is matched against the commands' titles and keywords.
+func registerCommands(webCtx *web.Ctx) {
+	webCtx.Commands.Add("eiffel", func(io web.IO, query string) ([]web.Command, error) {
+		usr, err := user.FromIO(io)
+		if err != nil {
+			return nil, nil
+		}
+
+		commands := []web.Command{
+			{
+				Title:    "eiffel.command.new-requirement",
+				Group:    "eiffel.command.group",
+				URL:      "/eiffel",
+				Position: 100,
+			},
+			{
+				Title:    "eiffel.command.setup-wizard",
+				Group:    "eiffel.command.group",
+				URL:      SetupWizardRoute,
+				Position: 101,
+			},
+		}
+
+		templates, err := web.MustRepository[template.Repository](io, template.RepositoryName).
+			FindByQueryForTypeAndUser(io.Context(), template.SearchFilter{}, BasicTemplateType, usr)
+		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+			return nil, err
+		}
+
+		for _, t := range templates {
+			keywords := []string{t.Version}
+			if t.TemplateSetElem != nil {
+				keywords = append(keywords, t.TemplateSetElem.Name)
+			}
+
+			commands = append(commands, web.Command{
+				Title:     "eiffel.command.switch-template",
+				TitleArgs: []string{"name", t.Name},
+				Group:     "eiffel.command.group",
+				URL:       fmt.Sprintf("/eiffel/%s", t.ID),
+				Keywords:  keywords,
+				Position:  102,
+			})
+		}
+
+		return commands, nil
 	})
 }
 
 func eiffelElicitationPage(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
 	sessionStore := util.UnwrapType[user.SessionRepository](appCtx.Repository(user.SessionRepositoryName))
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+	favorites := util.UnwrapType[template.FavoriteRepository](appCtx.Repository(template.FavoriteRepositoryName))
 
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
 		templateID := web.URLParam(io.Request(), "templateID")
 		variantKey := web.URLParam(io.Request(), "variant")
+		pdfReports := NewPDFRenderer(cfg.PDF) != nil
 		if templateID == "" {
-			return renderElicitationPage(io, TemplateFormData{NeglectOptional: cfg.NeglectOptional}, nil, nil)
+			workingSet, err := WorkingSetFor(io.Context(), favorites, user.MustFromIO(io).ID)
+			if err != nil {
+				return io.Error(web.ErrInternal, err)
+			}
+
+			return renderElicitationPage(io, TemplateFormData{NeglectOptional: cfg.NeglectOptional, InlineValues: cfg.Suggestions.Inline, PDFReports: pdfReports, WorkingSet: workingSet}, nil, nil)
 		}
 
 		formData, err := TemplateFormFromRequest(
@@ -162,9 +507,17 @@ func eiffelElicitationPage(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.H
 			appCtx.Validator,
 			true,
 		)
+		if err == nil {
+			recordTemplateUse(io, appCtx, favorites, formData.TemplateID)
+			restoreForm(io.Request(), sessionStore, user.MustFromIO(io), templateID, &formData)
+		}
 
 		formData.NeglectOptional = cfg.NeglectOptional
+		formData.InlineValues = cfg.Suggestions.Inline
+		applyUserUISettings(io.Context(), preferences, &formData, variantKey)
 		formData.CopyAfterParse = CopyAfterParseSetting(io.Request(), sessionStore, true)
+		formData.Guided = GuidedModeSetting(io.Request(), sessionStore)
+		formData.PDFReports = pdfReports
 
 		return renderElicitationPage(io, formData, nil, []error{err})
 	})
@@ -176,6 +529,7 @@ func renderElicitationPage(io web.IO, data TemplateFormData, success []string, e
 		"eiffel.elicitation.page",
 		"eiffel/elicitation-page.go.html",
 		"eiffel/_elicitation-template.go.html",
+		"eiffel/_template-working-set.go.html",
 		"eiffel/_form-elicitation.go.html",
 		"eiffel/_list-requirements.go.html",
 	)
@@ -183,46 +537,62 @@ func renderElicitationPage(io web.IO, data TemplateFormData, success []string, e
 
 func searchModal(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
+	favorites := util.UnwrapType[template.FavoriteRepository](appCtx.Repository(template.FavoriteRepositoryName))
 
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
 		ctx := io.Context()
-		templates, err := templateRepository.FindByQueryForTypeAndUser(ctx, "", BasicTemplateType, user.MustCtxUser(ctx))
+		filter := SearchFilterFromRequest(io.Request())
+		templates, err := templateRepository.FindAccessibleByQuery(ctx, filter, BasicTemplateType, user.MustCtxUser(ctx))
+		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		templateSets, err := templateSetRepository.FindByCreatedBy(ctx, user.MustCtxUser(ctx).ID)
 		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
 			return io.InlineError(web.ErrInternal, err)
 		}
 
+		workingSet, err := WorkingSetFor(ctx, favorites, user.MustCtxUser(ctx).ID)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
 		return io.Render(
-			&SearchTemplateData{Templates: templates},
+			&SearchTemplateData{Templates: templates, Filter: filter, TemplateSets: templateSets, WorkingSet: workingSet},
 			"eiffel.template.search.modal",
 			"eiffel/_modal-template-search.go.html",
 			"eiffel/_template-search-result.go.html",
+			"eiffel/_template-working-set.go.html",
 		)
 	})
 }
 
 func searchTemplate(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	favorites := util.UnwrapType[template.FavoriteRepository](appCtx.Repository(template.FavoriteRepositoryName))
 
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
-		request := io.Request()
-		err := request.ParseForm()
-		if err != nil {
-			return io.InlineError(web.ErrInternal, err)
-		}
-
-		query := request.FormValue("search")
 		// query too short was removed as it is expected to be unnecessary
+		filter := SearchFilterFromRequest(io.Request())
 
 		ctx := io.Context()
-		templates, err := templateRepository.FindByQueryForTypeAndUser(ctx, query, BasicTemplateType, user.MustCtxUser(ctx))
+		templates, err := templateRepository.FindAccessibleByQuery(ctx, filter, BasicTemplateType, user.MustCtxUser(ctx))
 		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
 			return io.InlineError(web.ErrInternal, err)
 		}
 
+		// the working set is read for the pin state of the found templates
+		workingSet, err := WorkingSetFor(ctx, favorites, user.MustCtxUser(ctx).ID)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
 		return io.Render(
-			&SearchTemplateData{Templates: templates},
+			&SearchTemplateData{Templates: templates, Filter: filter, WorkingSet: workingSet},
 			"eiffel.template.search.result",
 			"eiffel/_template-search-result.go.html",
+			"eiffel/_template-working-set.go.html",
 		)
 	})
 }
@@ -230,6 +600,8 @@ func searchTemplate(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 func elicitationTemplate(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx, defaultFirstVariant bool) http.Handler {
 	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
 	sessionStore := util.UnwrapType[user.SessionRepository](appCtx.Repository(user.SessionRepositoryName))
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+	favorites := util.UnwrapType[template.FavoriteRepository](appCtx.Repository(template.FavoriteRepositoryName))
 
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
 		templateID := web.URLParam(io.Request(), "templateID")
@@ -249,9 +621,14 @@ func elicitationTemplate(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx, defaultF
 		if err != nil {
 			return io.InlineError(err)
 		}
+		recordTemplateUse(io, appCtx, favorites, formData.TemplateID)
 
 		formData.NeglectOptional = cfg.NeglectOptional
+		formData.InlineValues = cfg.Suggestions.Inline
+		applyUserUISettings(io.Context(), preferences, &formData, variant)
 		formData.CopyAfterParse = CopyAfterParseSetting(io.Request(), sessionStore, true)
+		formData.Guided = GuidedModeSetting(io.Request(), sessionStore)
+		restoreForm(io.Request(), sessionStore, user.MustFromIO(io), templateID, &formData)
 
 		io.Response().Header().Set("HX-Push-URL", fmt.Sprintf("/eiffel/%s/%s", templateID, formData.VariantKey))
 
@@ -259,19 +636,123 @@ func elicitationTemplate(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx, defaultF
 			web.NewFormData(formData, nil),
 			"eiffel.elicitation.template",
 			"eiffel/_elicitation-template.go.html",
+			"eiffel/_template-working-set.go.html",
 			"eiffel/_form-elicitation.go.html",
 		)
 	})
 }
 
-func parseRequirement(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+// TODO collaborative elicitation: let several users work on the same elicitation session through a WebSocket endpoint per session
+// broadcasting segment edits and parse results, with presence indication and last-write-wins per segment. This is blocked:
+//  - there are no shared elicitation sessions, the form's state is a draft in the user's own session (see Draft) and the
+//    elicited requirements are kept per user, so there is nothing edits, parse results or presence could be attached to
+//  - no WebSocket library is a dependency yet, it has to be added and vendored (hand-rolling RFC 6455 in core/web is not an option)
+//  - server-to-client notifications are covered by server-sent events (web.Broker, IO.EventStream, see templateEvents),
+//    client-to-server edits could be posted like the drafts once sessions are shared
+
+// templateEvents streams the server-sent events concerning the template to the client. The elicitation page uses them to
+// notify the user if the template was changed in the meantime. Only users with access to the template may subscribe to its events,
+// see template.Repository.IsAccessible.
+func templateEvents(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		templateID, err := uuid.Parse(web.URLParam(io.Request(), "templateID"))
+		if err != nil {
+			return io.Error(ErrTemplateNotFound, err)
+		}
+
+		accessible, err := templateRepository.IsAccessible(io.Context(), templateID, user.MustCtxUser(io.Context()).ID)
+		if err != nil || !accessible {
+			return io.Error(ErrTemplateNotFound, err)
+		}
+
+		events, unsubscribe := webCtx.Broker.Subscribe(TemplateEventsTopic(templateID))
+		defer unsubscribe()
+
+		stream, err := io.EventStream()
+		if err != nil {
+			return err
+		}
+
+		return stream.Forward(events)
+	})
+}
+
+func compareVariants(appCtx *hctx.AppCtx, webCtx *web.Ctx, printable bool) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		formData, err := TemplateFormFromRequest(
+			io.Context(),
+			web.URLParam(io.Request(), "templateID"),
+			"",
+			templateRepository,
+			RuleParsers(),
+			appCtx.Validator,
+			true,
+		)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		return io.Render(
+			TemplateComparisonData{
+				Template:   formData.Template,
+				TemplateID: formData.TemplateID,
+				Variants:   CompareVariants(formData.Template, formData.DisplayTypes),
+				Print:      printable,
+			},
+			"eiffel.compare.page",
+			"eiffel/compare-page.go.html",
+		)
+	})
+}
+
+func exportVariantComparison(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		formData, err := TemplateFormFromRequest(
+			io.Context(),
+			web.URLParam(io.Request(), "templateID"),
+			"",
+			templateRepository,
+			RuleParsers(),
+			appCtx.Validator,
+			true,
+		)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		markdown := VariantComparisonMarkdown(formData.Template, CompareVariants(formData.Template, formData.DisplayTypes))
+
+		response := io.Response()
+		response.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-%s.md\"", formData.Template.ID, formData.Template.Version))
+		_, err = response.Write([]byte(markdown))
+
+		return err
+	})
+}
+
+func parseRequirement(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx, languageChecker LanguageChecker) http.Handler {
 	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
 	sessionStore := util.UnwrapType[user.SessionRepository](appCtx.Repository(user.SessionRepositoryName))
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+	attachmentRepository := util.UnwrapType[attachment.Repository](appCtx.Repository(attachment.RepositoryName))
+	requirementRepository := util.UnwrapType[requirement.Repository](appCtx.Repository(requirement.RepositoryName))
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+	healthRepository := util.UnwrapType[template.HealthRepository](appCtx.Repository(template.HealthRepositoryName))
 
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
 		request := io.Request()
 		ctx := request.Context()
-		parsers := RuleParsers()
+		parsers := ruleParsersFor(cfg)
+		if languageChecker != nil {
+			parsers.Register("placeholder", PlaceholderRuleParser{LanguageChecker: languageChecker})
+		}
 
 		templateID := web.URLParam(request, "templateID")
 		variant := web.URLParam(request, "variant")
@@ -294,9 +775,30 @@ func parseRequirement(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handle
 			return io.InlineError(web.ErrInternal, err)
 		}
 		formData.SegmentMap = segmentMap
+		recordDraft(request, sessionStore, templateID, formData.VariantKey, segmentMap)
 
-		parsingResult, err := formData.Template.Parse(ctx, parsers, formData.VariantKey, SegmentMapToSegments(segmentMap)...)
+		prefilled, err := PrefilledFromRequest(request)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		formData.Prefilled = prefilled
+
+		if requirementID, err := uuid.Parse(request.FormValue("requirement-id")); err == nil {
+			formData.RequirementID = requirementID
+		}
+		formData.Tags = strings.Join(requirement.ParseTags(request.FormValue("requirement-tags")), ", ")
+
+		parsingResult, err := formData.Template.ParseStream(ctx, parsers, formData.VariantKey, SegmentMapToSegments(segmentMap), func(ParsedRule) error {
+			return nil
+		}, WithPrefilled(prefilled))
 		formData.ParsingResult = &parsingResult
+		if err == nil {
+			formData.History = recordHistory(request, sessionStore, templateID, formData.VariantKey, NewHistoryEntry(segmentMap, parsingResult))
+			// the template's health is statistics only, failing to record it does not fail the parse
+			if err := healthRepository.Record(ctx, formData.TemplateID, parsingResult); err != nil {
+				appCtx.Logger.Error(template.Pkg, "failed to record template health", err, "templateID", formData.TemplateID)
+			}
+		}
 
 		var s []string
 		if parsingResult.Flawless() {
@@ -306,7 +808,31 @@ func parseRequirement(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handle
 		}
 
 		if parsingResult.Ok() {
-			triggerEvent := &HTMXTriggerParsingSuccessEvent{ParsingSuccessEvent: &parsingResult}
+			attachments, err := requirementAttachments(ctx, attachmentRepository, formData.RequirementID, webCtx.Config.Server.BaseURL)
+			if err != nil {
+				return io.InlineError(web.ErrInternal, err)
+			}
+
+			exported := ExportRequirement(formData.Template, formData.TemplateID, formData.Variant, segmentMap, parsingResult, io.Translator())
+			usr := user.MustFromIO(io)
+			toSave := RequirementToSave(formData.RequirementID, formData.Template, exported, requirement.ParseTags(formData.Tags), usr.ID)
+			// requirements are elicited in the user's current project
+			if projectID := project.CurrentID(ctx, preferences, projectRepository, usr.ID); projectID != uuid.Nil {
+				toSave.ProjectID = &projectID
+			}
+
+			saved, err := requirementRepository.Save(ctx, toSave)
+			if err != nil {
+				return io.InlineError(web.ErrInternal, err)
+			}
+			exported.Identifier = saved.Identifier
+			formData.Identifier = saved.Identifier
+
+			triggerEvent := &HTMXTriggerParsingSuccessEvent{
+				ParsingSuccessEvent: &parsingResult,
+				Attachments:         attachments,
+				Export:              exported,
+			}
 			triggerEventJSON, err := json.Marshal(triggerEvent)
 			if err != nil {
 				return io.InlineError(web.ErrInternal, err)
@@ -315,11 +841,575 @@ func parseRequirement(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handle
 			// Using HX-Trigger header here is not possible because we could potentially use unicode characters in our response.
 			// To escape them we have to use base64 encoding and a custom header.
 			io.Response().Header().Set("ParsingSuccessEvent", base64.URLEncoding.EncodeToString(triggerEventJSON))
+
+			// the next requirement starts without attachments
+			formData.RequirementID = uuid.New()
 		}
 
 		formData.NeglectOptional = cfg.NeglectOptional
+		formData.InlineValues = cfg.Suggestions.Inline
+		applyUserUISettings(io.Context(), preferences, &formData, formData.VariantKey)
 		formData.CopyAfterParse = CopyAfterParseSetting(request, sessionStore, false)
+		formData.Guided = GuidedModeSetting(request, sessionStore)
 
 		return io.Render(web.NewFormData(formData, s, err), "eiffel.elicitation.form", "eiffel/_form-elicitation.go.html")
 	})
 }
+
+// parseRequirementSegment parses a single segment of a requirement with the rule of the template's variant (see BasicTemplate.ParseSegment).
+// It renders the feedback on the segment which is displayed below the segment's input in guided mode.
+func parseRequirementSegment(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx, languageChecker LanguageChecker) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		ctx := request.Context()
+		parsers := ruleParsersFor(cfg)
+		if languageChecker != nil {
+			parsers.Register("placeholder", PlaceholderRuleParser{LanguageChecker: languageChecker})
+		}
+
+		formData, err := TemplateFormFromRequest(
+			ctx,
+			web.URLParam(request, "templateID"),
+			web.URLParam(request, "variant"),
+			templateRepository,
+			parsers,
+			appCtx.Validator,
+			false,
+		)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		rule := web.URLParam(request, "rule")
+		segment := parser.ParsingSegment{Name: rule, Value: request.FormValue(fmt.Sprintf("segment-%s", rule))}
+
+		logs, err := formData.Template.ParseSegment(ctx, parsers, formData.VariantKey, segment)
+		if err != nil {
+			return io.InlineError(ErrTemplateVariantNotFound, err)
+		}
+
+		return io.Render(
+			SegmentFeedbackData{Rule: rule, Logs: logs, Parsed: strings.TrimSpace(segment.Value) != ""},
+			"eiffel.elicitation.segment.feedback",
+			"eiffel/_segment-feedback.go.html",
+			"eiffel/_form-elicitation.go.html",
+		)
+	})
+}
+
+// exportRequirementsReqIF exports the recently elicited requirements sent by the client as a ReqIF document (see RequirementsReqIF).
+// Stored requirements are exported from the requirements page, e.g. those of a template selected by its automatic tag.
+func exportRequirementsReqIF(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		requirements, err := exportedRequirementsFromRequest(io.Request())
+		if err != nil {
+			return io.Error(ErrInvalidExport, err)
+		}
+
+		doc := RequirementsReqIF(io.Translator().T("eiffel.output.export.title"), requirements)
+
+		response := io.Response()
+		response.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"requirements-%s.reqif\"", time.Now().Format("2006-01-02")))
+
+		return reqif.Write(response, doc)
+	})
+}
+
+// exportReport renders a report of the recently elicited requirements sent by the client (see NewReport) as Markdown
+// or, if the PDF renderer is configured, as PDF. The format is passed as URL parameter: "md" or "pdf".
+func exportReport(appCtx *hctx.AppCtx, webCtx *web.Ctx, pdfRenderer ReportRenderer) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		format := web.URLParam(request, "format")
+		if format != "md" && format != "pdf" {
+			return io.Error(web.ErrNotFound)
+		}
+		if format == "pdf" && pdfRenderer == nil {
+			return io.Error(ErrPDFDisabled)
+		}
+
+		requirements, err := exportedRequirementsFromRequest(request)
+		if err != nil {
+			return io.Error(ErrInvalidExport, err)
+		}
+
+		// templates that can not be found anymore are reported without their metadata
+		templates := make(map[string]*BasicTemplate)
+		for _, requirement := range requirements {
+			if _, ok := templates[requirement.TemplateID]; ok || requirement.TemplateID == "" {
+				continue
+			}
+
+			formData, err := TemplateFormFromRequest(io.Context(), requirement.TemplateID, "", templateRepository, RuleParsers(), appCtx.Validator, true)
+			if err != nil {
+				templates[requirement.TemplateID] = nil
+				continue
+			}
+
+			templates[requirement.TemplateID] = formData.Template
+		}
+
+		markdown := ReportMarkdown(NewReport(io.Translator().T("eiffel.output.report.title"), requirements, templates))
+		filename := fmt.Sprintf("report-%s.%s", time.Now().Format("2006-01-02"), format)
+
+		response := io.Response()
+		response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+		if format == "md" {
+			response.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, err = response.Write([]byte(markdown))
+			return err
+		}
+
+		pdf := &bytes.Buffer{}
+		err = pdfRenderer.Render(io.Context(), markdown, pdf)
+		if err != nil {
+			response.Header().Del("Content-Disposition")
+			return io.Error(web.ErrInternal, err)
+		}
+
+		response.Header().Set("Content-Type", "application/pdf")
+		_, err = pdf.WriteTo(response)
+
+		return err
+	})
+}
+
+// exportedRequirementsFromRequest reads the requirements to export from the form value "requirements".
+// The recently elicited requirements are kept by the client, they are sent as JSON array of ExportedRequirement.
+func exportedRequirementsFromRequest(request *http.Request) ([]ExportedRequirement, error) {
+	var requirements []ExportedRequirement
+	err := json.Unmarshal([]byte(request.FormValue("requirements")), &requirements)
+
+	return requirements, err
+}
+
+// restoreHistory steps back or forth through the history of parse attempts (see History) and renders the form filled with
+// the segments of the restored entry. The step is either "back", "forward" or the index of an entry.
+// The segments are parsed again, but the parsing success event is not triggered as the requirement was already elicited before.
+func restoreHistory(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	sessionStore := util.UnwrapType[user.SessionRepository](appCtx.Repository(user.SessionRepositoryName))
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		ctx := request.Context()
+		parsers := ruleParsersFor(cfg)
+		templateID := web.URLParam(request, "templateID")
+
+		formData, err := TemplateFormFromRequest(
+			ctx,
+			templateID,
+			web.URLParam(request, "variant"),
+			templateRepository,
+			parsers,
+			appCtx.Validator,
+			false,
+		)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		session, err := user.SessionFromRequest(request, sessionStore)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		history := HistoryFromSession(session, templateID, formData.VariantKey)
+
+		index := -1
+		switch step := web.URLParam(request, "step"); step {
+		case "back":
+			index = history.Position - 1
+		case "forward":
+			index = history.Position + 1
+		default:
+			if i, err := strconv.Atoi(step); err == nil {
+				index = i
+			}
+		}
+
+		entry, ok := history.Step(index)
+		if !ok {
+			return io.InlineError(ErrHistoryEntryNotFound)
+		}
+
+		if err := history.AddToSession(session, templateID, formData.VariantKey); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		if err := Draft(entry.SegmentMap).AddToSession(session, templateID, formData.VariantKey); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		if err := sessionStore.Write(ctx, session.ID, session); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		formData.History = &history
+
+		if requirementID, err := uuid.Parse(request.FormValue("requirement-id")); err == nil {
+			formData.RequirementID = requirementID
+		}
+		formData.Tags = strings.Join(requirement.ParseTags(request.FormValue("requirement-tags")), ", ")
+
+		formData.SegmentMap = entry.SegmentMap
+		parsingResult, err := formData.Template.Parse(ctx, parsers, formData.VariantKey, SegmentMapToSegments(entry.SegmentMap)...)
+		formData.ParsingResult = &parsingResult
+
+		formData.NeglectOptional = cfg.NeglectOptional
+		formData.InlineValues = cfg.Suggestions.Inline
+		applyUserUISettings(io.Context(), preferences, &formData, formData.VariantKey)
+		formData.CopyAfterParse = CopyAfterParseSetting(request, sessionStore, true)
+		formData.Guided = GuidedModeSetting(request, sessionStore)
+
+		return io.Render(web.NewFormData(formData, nil, err), "eiffel.elicitation.form", "eiffel/_form-elicitation.go.html")
+	})
+}
+
+// uiSettingsModal renders the modal of the user's UI settings for a template, see UISettingsForUser.
+func uiSettingsModal(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ctx := io.Context()
+
+		data, err := uiSettingsDataFromRequest(io.Request(), templateRepository, appCtx.Validator)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		// the user's settings are empty if the user did not override the template's settings
+		_ = preferences.Find(ctx, user.MustCtxUser(ctx).ID, uiPreferenceKey(data.TemplateID), &data.Settings)
+
+		return io.Render(web.NewFormData(data, nil), "eiffel.ui.settings.modal", "eiffel/_modal-ui-settings.go.html")
+	})
+}
+
+// saveUISettings stores the user's UI settings for a template from the settings modal in the user's preferences or resets them.
+// The settings are validated like the template's settings. After saving, the page is refreshed to apply the settings.
+func saveUISettings(appCtx *hctx.AppCtx, webCtx *web.Ctx, reset bool) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		ctx := io.Context()
+
+		data, err := uiSettingsDataFromRequest(request, templateRepository, appCtx.Validator)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		if !reset {
+			data.Settings = UISettingsFromRequest(request)
+
+			check := *data.Template
+			check.UI = data.Settings
+			if errs := append(data.Settings.Validate(), check.validateUI()...); len(errs) > 0 {
+				return io.Render(web.NewFormData(data, nil, errs...), "eiffel.ui.settings.modal", "eiffel/_modal-ui-settings.go.html")
+			}
+		}
+
+		err = SetUserUISettings(ctx, preferences, user.MustCtxUser(ctx).ID, data.TemplateID, data.Settings)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		io.Response().Header().Set("HX-Refresh", "true")
+
+		return io.Render(
+			web.NewFormData(data, []string{"eiffel.ui.settings.saved"}),
+			"eiffel.ui.settings.modal",
+			"eiffel/_modal-ui-settings.go.html",
+		)
+	})
+}
+
+// uiSettingsDataFromRequest reads the template of the request for the UI settings modal.
+func uiSettingsDataFromRequest(request *http.Request, templateRepository template.Repository, validator validation.V) (UISettingsData, error) {
+	formData, err := TemplateFormFromRequest(
+		request.Context(),
+		web.URLParam(request, "templateID"),
+		"",
+		templateRepository,
+		RuleParsers(),
+		validator,
+		true,
+	)
+	if err != nil {
+		return UISettingsData{}, err
+	}
+
+	data := UISettingsData{Template: formData.Template, TemplateID: formData.TemplateID}
+	for key := range formData.Template.Variants {
+		data.Variants = append(data.Variants, key)
+	}
+	for key := range formData.Template.Rules {
+		data.Rules = append(data.Rules, key)
+	}
+	sort.Strings(data.Variants)
+	sort.Strings(data.Rules)
+
+	return data, nil
+}
+
+// applyUserUISettings overrides the form's UI settings with the logged-in user's settings for the template, see UISettingsForUser.
+// The user's default variant is selected if the requested variant does not exist, e.g. because no variant was requested.
+func applyUserUISettings(ctx context.Context, preferences user.PreferenceRepository, formData *TemplateFormData, requestedVariant string) {
+	usr, err := user.CtxUser(ctx)
+	if err != nil || formData.Template == nil {
+		return
+	}
+
+	formData.UI = UISettingsForUser(ctx, preferences, usr.ID, formData.TemplateID, formData.UI)
+
+	if _, requested := formData.Template.Variants[requestedVariant]; requested {
+		return
+	}
+
+	if variant, ok := formData.Template.Variants[formData.UI.DefaultVariant]; ok {
+		formData.Variant = &variant
+		formData.VariantKey = formData.UI.DefaultVariant
+	}
+}
+
+// recordHistory pushes the entry to the history of the template's variant in the user's session and returns the history.
+// It returns nil if the session could not be read or written, the history is a convenience and not required for parsing.
+func recordHistory(request *http.Request, sessionStore user.SessionRepository, templateID string, variant string, entry HistoryEntry) *History {
+	session, err := user.SessionFromRequest(request, sessionStore)
+	if err != nil {
+		return nil
+	}
+
+	history := HistoryFromSession(session, templateID, variant)
+	history.Push(entry)
+
+	if err := history.AddToSession(session, templateID, variant); err != nil {
+		return nil
+	}
+	if err := sessionStore.Write(request.Context(), session.ID, session); err != nil {
+		return nil
+	}
+
+	return &history
+}
+
+// restoreForm fills the form of the elicitation template rendered on its own or as part of the elicitation page: the history
+// of the variant, the prefilled values and the segments. The segments are restored from the draft in the user's session
+// (see Draft), the form is prefilled if there is none. This way reloads, back and forward navigation and bookmarks keep the inputs.
+func restoreForm(request *http.Request, sessionStore user.SessionRepository, usr *user.User, templateID string, formData *TemplateFormData) {
+	var draft Draft
+	if session, err := user.SessionFromRequest(request, sessionStore); err == nil {
+		history := HistoryFromSession(session, templateID, formData.VariantKey)
+		formData.History = &history
+		draft, _ = DraftFromSession(session, templateID, formData.VariantKey)
+	}
+
+	formData.Prefilled = Prefill(formData.Template, *formData.Variant, PrefillSources{User: usr, History: formData.History})
+	formData.SegmentMap = maps.Clone(formData.Prefilled)
+	if draft != nil {
+		formData.SegmentMap = draft
+	}
+}
+
+// recordDraft stores the segments as the draft of the template's variant in the user's session, see Draft.
+// Failing to store the draft is ignored, the draft is a convenience and not required for eliciting.
+func recordDraft(request *http.Request, sessionStore user.SessionRepository, templateID string, variant string, segmentMap map[string]string) {
+	session, err := user.SessionFromRequest(request, sessionStore)
+	if err != nil {
+		return
+	}
+
+	if err := Draft(segmentMap).AddToSession(session, templateID, variant); err != nil {
+		return
+	}
+	_ = sessionStore.Write(request.Context(), session.ID, session)
+}
+
+// saveDraft stores the segments currently entered in the elicitation form as the draft of the template's variant (see Draft).
+// The form sends its segments whenever they change, nothing is rendered.
+func saveDraft(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	sessionStore := util.UnwrapType[user.SessionRepository](appCtx.Repository(user.SessionRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		templateID := web.URLParam(request, "templateID")
+
+		formData, err := TemplateFormFromRequest(
+			request.Context(),
+			templateID,
+			web.URLParam(request, "variant"),
+			templateRepository,
+			RuleParsers(),
+			appCtx.Validator,
+			false,
+		)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		segmentMap, err := SegmentMapFromRequest(request, len(formData.Variant.Rules))
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+		recordDraft(request, sessionStore, templateID, formData.VariantKey, segmentMap)
+
+		io.Response().WriteHeader(http.StatusNoContent)
+
+		return nil
+	})
+}
+
+// suggestValues renders the values of an equalsAny rule matching the query as options of the rule's datalist (see BasicTemplate.Suggest).
+// The query is read from the parameter q or, if it is missing, from the rule's segment input which htmx sends on its own.
+func suggestValues(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	limit := cfg.Suggestions.Limit
+	if limit == 0 {
+		limit = DefaultSuggestionsLimit
+	}
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		ctx := request.Context()
+
+		formData, err := TemplateFormFromRequest(
+			ctx,
+			web.URLParam(request, "templateID"),
+			"",
+			templateRepository,
+			RuleParsers(),
+			appCtx.Validator,
+			true,
+		)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		rule := web.URLParam(request, "rule")
+		query := request.URL.Query().Get("q")
+		if !request.URL.Query().Has("q") {
+			query = request.FormValue(fmt.Sprintf("segment-%s", rule))
+		}
+
+		suggestions, err := formData.Template.Suggest(ctx, rule, query, limit)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		return io.Render(suggestions, "eiffel.elicitation.suggestions", "eiffel/_suggestions.go.html")
+	})
+}
+
+// segmentRequirementSentence splits a pasted requirement sentence into the segments of the template's variant
+// (see BasicTemplate.SegmentSentence) and renders the form filled with the segments and their parsing result.
+// The parsing success event is not triggered so the user can review the segments before checking the requirement.
+func segmentRequirementSentence(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	sessionStore := util.UnwrapType[user.SessionRepository](appCtx.Repository(user.SessionRepositoryName))
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		ctx := request.Context()
+		parsers := ruleParsersFor(cfg)
+
+		formData, err := TemplateFormFromRequest(
+			ctx,
+			web.URLParam(request, "templateID"),
+			web.URLParam(request, "variant"),
+			templateRepository,
+			parsers,
+			appCtx.Validator,
+			false,
+		)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		segmentation, err := formData.Template.SegmentSentence(ctx, formData.VariantKey, request.FormValue("sentence"))
+		if err != nil {
+			return io.InlineError(ErrTemplateVariantNotFound, err)
+		}
+		formData.Segmentation = &segmentation
+
+		formData.SegmentMap = make(map[string]string, len(segmentation.Segments))
+		for _, segment := range segmentation.Segments {
+			formData.SegmentMap[segment.Name] = segment.Value
+		}
+		recordDraft(request, sessionStore, web.URLParam(request, "templateID"), formData.VariantKey, formData.SegmentMap)
+
+		if requirementID, err := uuid.Parse(request.FormValue("requirement-id")); err == nil {
+			formData.RequirementID = requirementID
+		}
+		formData.Tags = strings.Join(requirement.ParseTags(request.FormValue("requirement-tags")), ", ")
+
+		parsingResult, err := formData.Template.Parse(ctx, parsers, formData.VariantKey, segmentation.Segments...)
+		formData.ParsingResult = &parsingResult
+
+		formData.NeglectOptional = cfg.NeglectOptional
+		formData.InlineValues = cfg.Suggestions.Inline
+		applyUserUISettings(io.Context(), preferences, &formData, formData.VariantKey)
+		formData.CopyAfterParse = CopyAfterParseSetting(request, sessionStore, false)
+		formData.Guided = GuidedModeSetting(request, sessionStore)
+
+		return io.Render(web.NewFormData(formData, nil, err), "eiffel.elicitation.form", "eiffel/_form-elicitation.go.html")
+	})
+}
+
+// toggleGuidedMode turns the guided mode on or off (see GuidedModeSetting) and renders the elicitation template in the selected mode.
+// It responds with 404 if the GuidedModeFeature flag is not active for the user.
+func toggleGuidedMode(cfg Cfg, appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	sessionStore := util.UnwrapType[user.SessionRepository](appCtx.Repository(user.SessionRepositoryName))
+	renderTemplate := elicitationTemplate(cfg, appCtx, webCtx, false)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		if !feature.Enabled(request.Context(), GuidedModeFeature) {
+			return io.InlineError(web.NotFound(nil))
+		}
+
+		err := SetGuidedModeSetting(request, sessionStore, request.FormValue("guided") == "on")
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		renderTemplate.ServeHTTP(io.Response(), request)
+
+		return nil
+	})
+}
+
+// requirementAttachments returns the files the logged-in user attached to the requirement with absolute download URLs.
+func requirementAttachments(ctx context.Context, repo attachment.Repository, requirementID uuid.UUID, baseURL string) ([]RequirementAttachment, error) {
+	attachments, err := repo.FindByOwner(ctx, attachment.OwnerRequirement, requirementID)
+	if errors.Is(err, persistence.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	usr := user.MustCtxUser(ctx)
+	baseURL = tenant.BaseURL(ctx, baseURL)
+
+	var requirementAttachments []RequirementAttachment
+	for _, a := range attachments {
+		if a.CreatedBy != usr.ID {
+			continue
+		}
+
+		requirementAttachments = append(requirementAttachments, RequirementAttachment{
+			Name: a.Name,
+			URL:  baseURL + attachment.DownloadPath(a.ID),
+		})
+	}
+
+	return requirementAttachments, nil
+}
@@ -0,0 +1,305 @@
+package eiffel
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/org-harmony/harmony/src/core/web"
+	"sort"
+)
+
+const (
+	// SetupWizardName is the name of the guided setup wizard creating a template set with a first template.
+	SetupWizardName = "eiffel-setup"
+	// SetupWizardRoute is the base route of the guided setup wizard.
+	SetupWizardRoute = "/eiffel/wizard"
+	// setupWizardSetStep is the step creating the template set.
+	setupWizardSetStep = "set"
+	// setupWizardTemplateStep is the step adding the first template to the template set.
+	setupWizardTemplateStep = "template"
+	// setupWizardTestStep is the step test parsing a requirement with the template.
+	setupWizardTestStep = "test"
+	// setupWizardSegmentPrefix is the prefix of the form values of the segments in the test step, the rule's key is appended.
+	setupWizardSegmentPrefix = "segment-"
+	// setupWizardSampleConfig is the template config the template step is prefilled with.
+	setupWizardSampleConfig = `{
+  "id": "requirement",
+  "type": "ebt",
+  "name": "Requirement",
+  "version": "1.0.0",
+  "rules": {
+    "system": {
+      "name": "System",
+      "type": "placeholder",
+      "hint": "Which system?",
+      "size": "medium"
+    },
+    "modality": {
+      "name": "Modality",
+      "type": "equalsAny",
+      "hint": "shall, should or will",
+      "value": ["shall", "should", "will"],
+      "size": "small"
+    },
+    "activity": {
+      "name": "Activity",
+      "type": "placeholder",
+      "hint": "What does the system do?",
+      "size": "full"
+    }
+  },
+  "variants": {
+    "default": {
+      "name": "Default",
+      "format": "<system> <modality> <activity>",
+      "example": "The system shall send a confirmation email.",
+      "rules": ["system", "modality", "activity"]
+    }
+  }
+}`
+)
+
+var (
+	// ErrSetupWizardParsingFailed is displayed to the user if the test requirement could not be parsed without errors.
+	ErrSetupWizardParsingFailed = errors.New("eiffel.wizard.test.parsing-failed")
+	// ErrSetupWizardIncomplete is displayed to the user if a previous step of the wizard is missing its values.
+	ErrSetupWizardIncomplete = errors.New("eiffel.wizard.error.incomplete")
+)
+
+// SetupWizardTemplateForm is the form of the template step of the guided setup wizard.
+type SetupWizardTemplateForm struct {
+	Config string `hvalidate:"required" hform:"label=eiffel.wizard.template.config,widget=textarea"`
+}
+
+// SetupWizardTestData is the data of the test step of the guided setup wizard.
+type SetupWizardTestData struct {
+	Template *BasicTemplate
+	// VariantKey is the key of the variant that is tested. It is the first variant's key in alphabetical order.
+	VariantKey string
+	Variant    BasicVariant
+	// Segments are the test values of the variant's rules keyed by the rules' keys.
+	Segments map[string]string
+	// ParsingResult is the result of test parsing the segments. It is nil if the step was not submitted yet.
+	ParsingResult *parser.ParsingResult
+}
+
+// SetupWizard returns the guided setup wizard. The wizard creates a template set (1), adds a first EIFFEL basic template
+// to the set (2) and lets the user test parse a requirement with the template (3) before the set and template are saved.
+// The wizard is owned by the logged-in user and must therefore be registered on a router requiring a logged-in user.
+func SetupWizard(appCtx *hctx.AppCtx) *web.Wizard {
+	return &web.Wizard{
+		Name:  SetupWizardName,
+		Route: SetupWizardRoute,
+		Title: "eiffel.wizard.title",
+		Steps: []web.WizardStep{
+			{
+				Name:  setupWizardSetStep,
+				Title: "eiffel.wizard.set.title",
+				Render: func(io web.IO, page *web.WizardPage) error {
+					if page.Form == nil {
+						page.Form = setupWizardSetForm(page, web.WizardValue(page.Session, setupWizardSetStep, &template.SetToCreate{}))
+					}
+
+					return renderSetupWizardStep(io, page, "eiffel/_wizard-step-set.go.html")
+				},
+				Submit: func(io web.IO, page *web.WizardPage) error {
+					form := setupWizardSetForm(page, &template.SetToCreate{CreatedBy: user.MustFromIO(io).ID})
+					page.Form = form
+
+					return readSetupWizardForm(io, appCtx.Validator, page, form.FormData, setupWizardSetStep)
+				},
+			},
+			{
+				Name:  setupWizardTemplateStep,
+				Title: "eiffel.wizard.template.title",
+				Render: func(io web.IO, page *web.WizardPage) error {
+					if page.Form == nil {
+						fallback := &SetupWizardTemplateForm{Config: setupWizardSampleConfig}
+						page.Form = setupWizardTemplateForm(page, web.WizardValue(page.Session, setupWizardTemplateStep, fallback))
+					}
+
+					return renderSetupWizardStep(io, page, "eiffel/_wizard-step-template.go.html")
+				},
+				Submit: func(io web.IO, page *web.WizardPage) error {
+					form := setupWizardTemplateForm(page, &SetupWizardTemplateForm{})
+					page.Form = form
+
+					err := readSetupWizardForm(io, appCtx.Validator, page, form.FormData, "")
+					if err != nil || !page.Valid() {
+						return err
+					}
+
+					validationErrs, err := template.ValidateTemplateConfig(form.Form.Config, BasicTemplateType, uuid.Nil, appCtx.EventManager, appCtx.Logger)
+					if err != nil {
+						return err
+					}
+
+					if len(validationErrs) > 0 {
+						form.ViolationsFromErrors(validationErrs...)
+						page.ViolationsFromErrors(validationErrs...)
+						return nil
+					}
+
+					return page.Session.SetValue(setupWizardTemplateStep, form.Form)
+				},
+			},
+			{
+				Name:  setupWizardTestStep,
+				Title: "eiffel.wizard.test.title",
+				Render: func(io web.IO, page *web.WizardPage) error {
+					if page.Form == nil {
+						data, err := setupWizardTestData(io, appCtx, page.Session)
+						if err != nil {
+							return io.Error(web.ErrInternal, err)
+						}
+						page.Form = data
+					}
+
+					return renderSetupWizardStep(io, page, "eiffel/_wizard-step-test.go.html")
+				},
+				Submit: func(io web.IO, page *web.WizardPage) error {
+					data, err := setupWizardTestData(io, appCtx, page.Session)
+					if err != nil {
+						page.ViolationsFromErrors(err)
+						return nil
+					}
+					page.Form = data
+
+					request := io.Request()
+					for _, rule := range data.Variant.Rules {
+						data.Segments[rule] = request.FormValue(setupWizardSegmentPrefix + rule)
+					}
+
+					result, err := data.Template.Parse(io.Context(), RuleParsers(), data.VariantKey, SegmentMapToSegments(data.Segments)...)
+					if err != nil {
+						return err
+					}
+					data.ParsingResult = &result
+
+					if !result.Ok() {
+						page.ViolationsFromErrors(ErrSetupWizardParsingFailed)
+						return page.Session.SetValue(setupWizardTestStep, data.Segments)
+					}
+
+					page.Success = []string{"eiffel.elicitation.parse.success"}
+					if result.Flawless() {
+						page.Success = []string{"eiffel.elicitation.parse.flawless-success"}
+					}
+
+					return page.Session.SetValue(setupWizardTestStep, data.Segments)
+				},
+			},
+		},
+		Owner: func(io web.IO) string {
+			return user.MustFromIO(io).ID.String()
+		},
+		Complete:  completeSetupWizard,
+		CancelURL: "/template-set/list",
+	}
+}
+
+// completeSetupWizard creates the template set and the template from the values of the wizard's steps.
+// It returns the URL of the template set's template list.
+func completeSetupWizard(io web.IO, session *web.WizardSession) (string, error) {
+	ctx := io.Context()
+	usr := user.MustFromIO(io)
+
+	setToCreate := web.WizardValue[*template.SetToCreate](session, setupWizardSetStep, nil)
+	templateForm := web.WizardValue[*SetupWizardTemplateForm](session, setupWizardTemplateStep, nil)
+	if setToCreate == nil || templateForm == nil {
+		return "", ErrSetupWizardIncomplete
+	}
+	setToCreate.CreatedBy = usr.ID
+
+	setRepository := web.MustRepository[template.SetRepository](io, template.SetRepositoryName)
+	set, err := setRepository.Create(ctx, setToCreate)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = web.MustRepository[template.Repository](io, template.RepositoryName).Create(ctx, &template.ToCreate{
+		TemplateSet: set.ID,
+		Type:        BasicTemplateType,
+		Config:      templateForm.Config,
+		CreatedBy:   usr.ID,
+	})
+	if err != nil {
+		// the set is deleted again, completing the wizard again would otherwise create a duplicate set
+		return "", errors.Join(err, setRepository.Delete(ctx, set.ID))
+	}
+
+	return fmt.Sprintf("/template-set/%s/list", set.ID), nil
+}
+
+// setupWizardTestData returns the data of the test step from the template of the template step.
+// The tested variant is the first variant in alphabetical order of the variants' keys.
+// Previously entered test values are restored from the session. Returned errors are safe to display to the user.
+func setupWizardTestData(io web.IO, appCtx *hctx.AppCtx, session *web.WizardSession) (*SetupWizardTestData, error) {
+	templateForm := web.WizardValue[*SetupWizardTemplateForm](session, setupWizardTemplateStep, nil)
+	if templateForm == nil {
+		return nil, ErrSetupWizardIncomplete
+	}
+
+	bt, err := TemplateIntoBasicTemplate(&template.Template{Config: templateForm.Config}, appCtx.Validator, RuleParsers())
+	if err != nil {
+		return nil, ErrSetupWizardIncomplete
+	}
+	bt.Localize(io.Context())
+
+	keys := make([]string, 0, len(bt.Variants))
+	for key := range bt.Variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return nil, ErrSetupWizardIncomplete
+	}
+
+	return &SetupWizardTestData{
+		Template:   bt,
+		VariantKey: keys[0],
+		Variant:    bt.Variants[keys[0]],
+		Segments:   web.WizardValue(session, setupWizardTestStep, make(map[string]string)),
+	}, nil
+}
+
+// readSetupWizardForm reads the form of a wizard step and adds the validation errors to the form and the page.
+// If the form is valid and step is not empty, the form's values are stored as the step's values in the session.
+func readSetupWizardForm[T any](io web.IO, validator validation.V, page *web.WizardPage, form *web.FormData[T], step string) error {
+	err, validationErrs := web.ReadForm(io.Request(), form.Form, validator)
+	if err != nil {
+		return err
+	}
+
+	if len(validationErrs) > 0 {
+		form.ViolationsFromErrors(validationErrs...)
+		page.ViolationsFromErrors(validationErrs...)
+		return nil
+	}
+
+	if step == "" {
+		return nil
+	}
+
+	return page.Session.SetValue(step, form.Form)
+}
+
+// setupWizardSetForm returns the template set form of the set step.
+func setupWizardSetForm(page *web.WizardPage, toCreate *template.SetToCreate) *web.Form[*template.SetToCreate] {
+	return web.NewForm(web.Form[*template.SetToCreate]{ID: page.FormID(), Action: page.URL()}, toCreate, nil)
+}
+
+// setupWizardTemplateForm returns the template config form of the template step.
+func setupWizardTemplateForm(page *web.WizardPage, form *SetupWizardTemplateForm) *web.Form[*SetupWizardTemplateForm] {
+	return web.NewForm(web.Form[*SetupWizardTemplateForm]{ID: page.FormID(), Action: page.URL()}, form, nil)
+}
+
+// renderSetupWizardStep renders the step's template on the wizard page.
+func renderSetupWizardStep(io web.IO, page *web.WizardPage, path string) error {
+	return io.Render(page, "eiffel.wizard.page", "eiffel/wizard-page.go.html", path)
+}
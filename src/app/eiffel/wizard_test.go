@@ -0,0 +1,31 @@
+package eiffel
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSetupWizardSampleConfig(t *testing.T) {
+	bt, err := TemplateIntoBasicTemplate(&template.Template{Config: setupWizardSampleConfig}, validation.New(), RuleParsers())
+	require.NoError(t, err)
+
+	result, err := bt.Parse(context.Background(), RuleParsers(), "default", SegmentMapToSegments(map[string]string{
+		"system":   "The system",
+		"modality": "shall",
+		"activity": "send a confirmation email.",
+	})...)
+	require.NoError(t, err)
+	assert.True(t, result.Ok())
+	assert.Equal(t, "The system shall send a confirmation email.", result.Requirement)
+
+	result, err = bt.Parse(context.Background(), RuleParsers(), "default", SegmentMapToSegments(map[string]string{
+		"system":   "The system",
+		"modality": "could",
+	})...)
+	require.NoError(t, err)
+	assert.False(t, result.Ok())
+}
@@ -0,0 +1,337 @@
+// Package gallery is the public template gallery. Authors publish an immutable snapshot of a template set with a description
+// and license to the gallery, other users browse the gallery, preview the templates' variants and install a copy of the snapshot
+// as a new template set of their own. Users flag inappropriate entries, admins hide or remove them (moderation).
+// The gallery is opt-in through the Feature flag and shared by all users of a tenant.
+package gallery
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"strings"
+	"time"
+)
+
+const (
+	// RepositoryName is the name of the gallery repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "GalleryRepository"
+	// Pkg is the package name for logging.
+	Pkg = "app.gallery"
+	// Feature is the feature flag enabling the gallery.
+	Feature = "template_gallery"
+	// MaxLicenseLength is the maximum length of an entry's license in characters.
+	MaxLicenseLength = 100
+	// MaxReasonLength is the maximum length of the reason of a flag in characters.
+	MaxReasonLength = 1000
+	// entryColumns is the column list of the gallery_entries table in the order scanned by scanEntry.
+	entryColumns = "e.id, e.template_set_id, e.name, e.version, e.description, e.license, e.installs, e.hidden, e.published_by, u.email, e.published_at, " +
+		"(SELECT COUNT(*) FROM gallery_flags f WHERE f.entry_id = e.id)"
+)
+
+var (
+	// ErrVersionExists is returned if the author already published the version of the template set. Published versions are immutable.
+	ErrVersionExists = errors.New("gallery.error.version-exists")
+	// ErrEmptySet is returned if a template set without templates is published.
+	ErrEmptySet = errors.New("gallery.error.empty-set")
+	// ErrInvalidLicense is returned if the license is empty or longer than MaxLicenseLength.
+	ErrInvalidLicense = errors.New("gallery.error.invalid-license")
+	// ErrInvalidReason is returned if the reason of a flag is empty or longer than MaxReasonLength.
+	ErrInvalidReason = errors.New("gallery.error.invalid-reason")
+)
+
+// Entry is a published snapshot of a template set in the gallery. Entries are immutable, a new version of the template set is published as a new entry.
+type Entry struct {
+	ID uuid.UUID
+	// TemplateSet is the published template set, nil if it was deleted since. The entry is not affected by changes of the template set.
+	TemplateSet *uuid.UUID
+	Name        string
+	Version     string
+	Description string
+	License     string
+	// Templates are the snapshots of the template set's templates. They are only filled by Repository.FindByID.
+	Templates []Snapshot
+	// Installs is the number of times the entry was installed.
+	Installs int
+	// Hidden entries are not listed in the gallery, see Repository.SetHidden.
+	Hidden      bool
+	PublishedBy uuid.UUID
+	// PublishedByEmail is the email of the author joined onto the entry.
+	PublishedByEmail string
+	PublishedAt      time.Time
+	// Flags is the number of users that flagged the entry.
+	Flags int
+}
+
+// Snapshot is a template of an entry as it was published.
+type Snapshot struct {
+	Type   string `json:"type"`
+	Config string `json:"config"`
+}
+
+// ToCreate is the gallery entry that is used to publish a template set snapshot.
+type ToCreate struct {
+	TemplateSet uuid.UUID `hvalidate:"required"`
+	Name        string    `hvalidate:"required"`
+	Version     string    `hvalidate:"required"`
+	Description string
+	License     string     `hvalidate:"required"`
+	Templates   []Snapshot `hvalidate:"required"`
+	PublishedBy uuid.UUID  `hvalidate:"required"`
+}
+
+// Flag is a user's report of an inappropriate entry.
+type Flag struct {
+	EntryID uuid.UUID
+	UserID  uuid.UUID
+	// UserEmail is the email of the reporting user joined onto the flag.
+	UserEmail string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// Repository is the gallery repository. It contains all methods to interact with the gallery_entries and gallery_flags tables in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID). Callers are responsible for checking the user may moderate entries.
+// Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// FindListed finds the entries that are not hidden, optionally filtered by a query matching their name, description or license,
+	// ordered by their name and the newest version first. It returns an empty slice if no entries are listed and persistence.ErrReadRow for any other error.
+	FindListed(ctx context.Context, query string) ([]*Entry, error)
+	// FindModerated finds the flagged and the hidden entries ordered by the number of flags, the most flagged first.
+	// It returns an empty slice if no entries are flagged or hidden and persistence.ErrReadRow for any other error.
+	FindModerated(ctx context.Context) ([]*Entry, error)
+	// FindByID finds an entry by its id including its templates.
+	// It returns persistence.ErrNotFound if the entry could not be found and persistence.ErrReadRow for any other error.
+	FindByID(ctx context.Context, id uuid.UUID) (*Entry, error)
+	// FindFlags finds the flags of an entry ordered by their creation, the newest first.
+	// It returns an empty slice if the entry was not flagged and persistence.ErrReadRow for any other error.
+	FindFlags(ctx context.Context, entryID uuid.UUID) ([]*Flag, error)
+	// Create publishes a new entry and returns it. It returns ErrVersionExists if the author already published an entry with the name and version
+	// and persistence.ErrInsert for any other error.
+	Create(ctx context.Context, toCreate *ToCreate) (*Entry, error)
+	// Delete deletes an entry by its id including its flags. Installed copies are not affected.
+	// It returns persistence.ErrDelete if the entry could not be deleted.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// SetHidden hides or lists an entry. It returns persistence.ErrUpdate if the entry could not be updated.
+	SetHidden(ctx context.Context, id uuid.UUID, hidden bool) error
+	// CountInstall increments the number of installs of an entry. It returns persistence.ErrUpdate if the entry could not be updated.
+	CountInstall(ctx context.Context, id uuid.UUID) error
+	// Flag flags an entry for moderation. Flagging an entry again replaces the user's reason.
+	// It returns persistence.ErrInsert if the flag could not be saved.
+	Flag(ctx context.Context, entryID uuid.UUID, userID uuid.UUID, reason string) error
+}
+
+// PGRepository is the gallery repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &PGRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// FindListed finds the entries that are not hidden, optionally filtered by a query matching their name, description or license,
+// ordered by their name and the newest version first. It returns an empty slice if no entries are listed and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindListed(ctx context.Context, query string) ([]*Entry, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT `+entryColumns+` FROM gallery_entries e JOIN users u ON u.id = e.published_by
+		WHERE e.tenant_id = $1 AND NOT e.hidden
+		AND ($2 = '' OR e.name ILIKE '%' || $2 || '%' OR e.description ILIKE '%' || $2 || '%' OR e.license ILIKE '%' || $2 || '%')
+		ORDER BY lower(e.name), e.published_at DESC`,
+		tenant.ID(ctx), persistence.EscapeLike(strings.TrimSpace(query)),
+	)
+
+	return persistence.PGCollectRows(rows, err, scanEntry)
+}
+
+// FindModerated finds the flagged and the hidden entries ordered by the number of flags, the most flagged first.
+// It returns an empty slice if no entries are flagged or hidden and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindModerated(ctx context.Context) ([]*Entry, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT `+entryColumns+` FROM gallery_entries e JOIN users u ON u.id = e.published_by
+		WHERE e.tenant_id = $1 AND (e.hidden OR EXISTS (SELECT 1 FROM gallery_flags f WHERE f.entry_id = e.id))
+		ORDER BY 12 DESC, e.published_at DESC`,
+		tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, scanEntry)
+}
+
+// FindByID finds an entry by its id including its templates.
+// It returns persistence.ErrNotFound if the entry could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByID(ctx context.Context, id uuid.UUID) (*Entry, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+entryColumns+", e.templates FROM gallery_entries e JOIN users u ON u.id = e.published_by WHERE e.id = $1 AND e.tenant_id = $2",
+		id, tenant.ID(ctx),
+	), func(row pgx.Row) (*Entry, error) {
+		e := &Entry{}
+		err := row.Scan(
+			&e.ID, &e.TemplateSet, &e.Name, &e.Version, &e.Description, &e.License, &e.Installs, &e.Hidden,
+			&e.PublishedBy, &e.PublishedByEmail, &e.PublishedAt, &e.Flags, &e.Templates,
+		)
+
+		return e, err
+	})
+}
+
+// FindFlags finds the flags of an entry ordered by their creation, the newest first.
+// It returns an empty slice if the entry was not flagged and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindFlags(ctx context.Context, entryID uuid.UUID) ([]*Flag, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT f.entry_id, f.user_id, u.email, f.reason, f.created_at
+		FROM gallery_flags f JOIN gallery_entries e ON e.id = f.entry_id JOIN users u ON u.id = f.user_id
+		WHERE f.entry_id = $1 AND e.tenant_id = $2 ORDER BY f.created_at DESC`,
+		entryID, tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, func(row pgx.Row) (*Flag, error) {
+		f := &Flag{}
+		err := row.Scan(&f.EntryID, &f.UserID, &f.UserEmail, &f.Reason, &f.CreatedAt)
+
+		return f, err
+	})
+}
+
+// Create publishes a new entry and returns it. It returns ErrVersionExists if the author already published an entry with the name and version
+// and persistence.ErrInsert for any other error.
+func (r *PGRepository) Create(ctx context.Context, toCreate *ToCreate) (*Entry, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	entry := &Entry{
+		ID:          uuid.New(),
+		TemplateSet: &toCreate.TemplateSet,
+		Name:        toCreate.Name,
+		Version:     toCreate.Version,
+		Description: toCreate.Description,
+		License:     toCreate.License,
+		Templates:   toCreate.Templates,
+		PublishedBy: toCreate.PublishedBy,
+		PublishedAt: time.Now(),
+	}
+
+	err := r.db.QueryRow(
+		ctx,
+		`INSERT INTO gallery_entries (id, template_set_id, name, version, description, license, templates, published_by, published_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (tenant_id, published_by, name, version) DO NOTHING RETURNING (SELECT email FROM users WHERE id = $8)`,
+		entry.ID,
+		entry.TemplateSet,
+		entry.Name,
+		entry.Version,
+		entry.Description,
+		entry.License,
+		entry.Templates,
+		entry.PublishedBy,
+		entry.PublishedAt,
+		tenant.ID(ctx),
+	).Scan(&entry.PublishedByEmail)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrVersionExists
+	}
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return entry, nil
+}
+
+// Delete deletes an entry by its id including its flags. Installed copies are not affected.
+// It returns persistence.ErrDelete if the entry could not be deleted.
+func (r *PGRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM gallery_entries WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// SetHidden hides or lists an entry. It returns persistence.ErrUpdate if the entry could not be updated.
+func (r *PGRepository) SetHidden(ctx context.Context, id uuid.UUID, hidden bool) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "UPDATE gallery_entries SET hidden = $1 WHERE id = $2 AND tenant_id = $3", hidden, id, tenant.ID(ctx))
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// CountInstall increments the number of installs of an entry. It returns persistence.ErrUpdate if the entry could not be updated.
+func (r *PGRepository) CountInstall(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "UPDATE gallery_entries SET installs = installs + 1 WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// Flag flags an entry for moderation. Flagging an entry again replaces the user's reason.
+// It returns persistence.ErrInsert if the flag could not be saved.
+func (r *PGRepository) Flag(ctx context.Context, entryID uuid.UUID, userID uuid.UUID, reason string) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		`INSERT INTO gallery_flags (entry_id, user_id, reason, created_at)
+		SELECT id, $2, $3, NOW() FROM gallery_entries WHERE id = $1 AND tenant_id = $4
+		ON CONFLICT (entry_id, user_id) DO UPDATE SET reason = $3, created_at = NOW()`,
+		entryID, userID, reason, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// scanEntry scans a row containing the entryColumns into a new Entry.
+func scanEntry(row pgx.Row) (*Entry, error) {
+	e := &Entry{}
+	err := row.Scan(
+		&e.ID, &e.TemplateSet, &e.Name, &e.Version, &e.Description, &e.License, &e.Installs, &e.Hidden,
+		&e.PublishedBy, &e.PublishedByEmail, &e.PublishedAt, &e.Flags,
+	)
+
+	return e, err
+}
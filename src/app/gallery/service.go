@@ -0,0 +1,168 @@
+package gallery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Preview is the preview of a template of an entry with its variants. It is read from the template's config,
+// configs without variants are previewed by their name and description only.
+type Preview struct {
+	Type        string
+	Name        string
+	Version     string
+	Description string
+	Variants    []VariantPreview
+}
+
+// VariantPreview is the preview of a variant of a template ordered by its technical name.
+type VariantPreview struct {
+	Key         string
+	Name        string
+	Description string
+	Format      string
+	Example     string
+}
+
+// Publish publishes a snapshot of the template set's templates to the gallery with the license and description.
+// The template set's description is used if the description is empty. It returns ErrInvalidLicense for invalid licenses,
+// ErrEmptySet if the template set has no templates and ErrVersionExists if the author already published the template set's version.
+func Publish(
+	ctx context.Context,
+	repo Repository,
+	tmplRepo template.Repository,
+	templateSet *template.Set,
+	license string,
+	description string,
+	userID uuid.UUID,
+) (*Entry, error) {
+	license = strings.TrimSpace(license)
+	if license == "" || utf8.RuneCountInString(license) > MaxLicenseLength {
+		return nil, ErrInvalidLicense
+	}
+
+	templates, err := tmplRepo.FindByTemplateSetID(ctx, templateSet.ID)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, ErrEmptySet
+	}
+
+	snapshots := make([]Snapshot, 0, len(templates))
+	for _, tmpl := range templates {
+		snapshots = append(snapshots, Snapshot{Type: tmpl.Type, Config: tmpl.Config})
+	}
+
+	description = strings.TrimSpace(description)
+	if description == "" {
+		description = templateSet.Description
+	}
+
+	return repo.Create(ctx, &ToCreate{
+		TemplateSet: templateSet.ID,
+		Name:        templateSet.Name,
+		Version:     templateSet.Version,
+		Description: description,
+		License:     license,
+		Templates:   snapshots,
+		PublishedBy: userID,
+	})
+}
+
+// Install installs a copy of the entry's snapshot as a new template set of the user and publishes the template.SetImportedEvent.
+// The entry must contain its templates, see Repository.FindByID. Later changes of the copy do not affect the entry and vice versa.
+func Install(
+	ctx context.Context,
+	repo Repository,
+	tmplSetRepo template.SetRepository,
+	tmplRepo template.Repository,
+	em event.Manager,
+	entry *Entry,
+	userID uuid.UUID,
+) (*template.Set, error) {
+	templateSet, err := tmplSetRepo.Create(ctx, &template.SetToCreate{
+		Name:        entry.Name,
+		Version:     entry.Version,
+		Description: entry.Description,
+		CreatedBy:   userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, snapshot := range entry.Templates {
+		_, err := tmplRepo.Create(ctx, &template.ToCreate{
+			TemplateSet: templateSet.ID,
+			Type:        snapshot.Type,
+			Config:      snapshot.Config,
+			CreatedBy:   userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repo.CountInstall(ctx, entry.ID); err != nil {
+		return nil, err
+	}
+
+	template.PublishSetImported(ctx, em, templateSet)
+
+	return templateSet, nil
+}
+
+// ValidReason returns the trimmed reason of a flag. It returns ErrInvalidReason if the reason is empty or longer than MaxReasonLength.
+func ValidReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > MaxReasonLength {
+		return "", ErrInvalidReason
+	}
+
+	return reason, nil
+}
+
+// Previews returns the previews of the entry's templates ordered by their name. Invalid configs are previewed by their type only.
+func Previews(entry *Entry) []Preview {
+	previews := make([]Preview, 0, len(entry.Templates))
+	for _, snapshot := range entry.Templates {
+		previews = append(previews, previewOf(snapshot))
+	}
+
+	sort.SliceStable(previews, func(i, j int) bool {
+		return strings.ToLower(previews[i].Name) < strings.ToLower(previews[j].Name)
+	})
+
+	return previews
+}
+
+// previewOf reads the preview of the snapshot from its config.
+func previewOf(snapshot Snapshot) Preview {
+	cfg := struct {
+		Name        string                    `json:"name"`
+		Version     string                    `json:"version"`
+		Description string                    `json:"description"`
+		Variants    map[string]VariantPreview `json:"variants"`
+	}{}
+	_ = json.Unmarshal([]byte(snapshot.Config), &cfg)
+
+	preview := Preview{Type: snapshot.Type, Name: cfg.Name, Version: cfg.Version, Description: cfg.Description}
+	for key, variant := range cfg.Variants {
+		variant.Key = key
+		preview.Variants = append(preview.Variants, variant)
+	}
+
+	sort.Slice(preview.Variants, func(i, j int) bool {
+		return preview.Variants[i].Key < preview.Variants[j].Key
+	})
+
+	return preview
+}
@@ -0,0 +1,144 @@
+package gallery
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+type repositoryMock struct {
+	Repository
+	created  *ToCreate
+	installs int
+}
+
+type templateSetRepositoryMock struct {
+	template.SetRepository
+	created *template.SetToCreate
+}
+
+type templateRepositoryMock struct {
+	template.Repository
+	templates []*template.Template
+	created   []*template.ToCreate
+}
+
+func TestPreviews(t *testing.T) {
+	entry := &Entry{Templates: []Snapshot{
+		{Type: "ebt", Config: `{"name": "b", "version": "1.0.0", "variants": {"z": {"name": "Z"}, "a": {"name": "A", "format": "<x>", "example": "x"}}}`},
+		{Type: "ebt", Config: `{"name": "A", "description": "first"}`},
+		{Type: "other", Config: `invalid`},
+	}}
+
+	previews := Previews(entry)
+	require.Len(t, previews, 3)
+	assert.Equal(t, Preview{Type: "other"}, previews[0], "invalid configs are previewed by their type")
+	assert.Equal(t, Preview{Type: "ebt", Name: "A", Description: "first"}, previews[1])
+	assert.Equal(t, "1.0.0", previews[2].Version)
+	assert.Equal(t, []VariantPreview{{Key: "a", Name: "A", Format: "<x>", Example: "x"}, {Key: "z", Name: "Z"}}, previews[2].Variants)
+}
+
+func TestValidReason(t *testing.T) {
+	reason, err := ValidReason("  spam  ")
+	require.NoError(t, err)
+	assert.Equal(t, "spam", reason)
+
+	for _, r := range []string{" ", strings.Repeat("x", MaxReasonLength+1)} {
+		_, err = ValidReason(r)
+		assert.ErrorIs(t, err, ErrInvalidReason)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	repo := &repositoryMock{}
+	tmplRepo := &templateRepositoryMock{}
+	templateSet := &template.Set{ID: uuid.New(), Name: "Set", Version: "1.0.0", Description: "set description"}
+	userID := uuid.New()
+
+	_, err := Publish(ctx, repo, tmplRepo, templateSet, " ", "", userID)
+	assert.ErrorIs(t, err, ErrInvalidLicense)
+	_, err = Publish(ctx, repo, tmplRepo, templateSet, strings.Repeat("x", MaxLicenseLength+1), "", userID)
+	assert.ErrorIs(t, err, ErrInvalidLicense)
+	_, err = Publish(ctx, repo, tmplRepo, templateSet, "MIT", "", userID)
+	assert.ErrorIs(t, err, ErrEmptySet)
+
+	tmplRepo.templates = []*template.Template{{Type: "ebt", Config: `{"name": "A"}`}}
+	_, err = Publish(ctx, repo, tmplRepo, templateSet, " MIT ", "", userID)
+	require.NoError(t, err)
+	assert.Equal(t, &ToCreate{
+		TemplateSet: templateSet.ID,
+		Name:        "Set",
+		Version:     "1.0.0",
+		Description: "set description",
+		License:     "MIT",
+		Templates:   []Snapshot{{Type: "ebt", Config: `{"name": "A"}`}},
+		PublishedBy: userID,
+	}, repo.created)
+
+	_, err = Publish(ctx, repo, tmplRepo, templateSet, "MIT", " gallery description ", userID)
+	require.NoError(t, err)
+	assert.Equal(t, "gallery description", repo.created.Description)
+}
+
+func TestInstall(t *testing.T) {
+	ctx := context.Background()
+	em := event.NewManager(trace.NewLogger())
+	repo := &repositoryMock{}
+	setRepo := &templateSetRepositoryMock{}
+	tmplRepo := &templateRepositoryMock{}
+	userID := uuid.New()
+	entry := &Entry{ID: uuid.New(), Name: "Set", Version: "1.0.0", Templates: []Snapshot{{Type: "ebt", Config: `{"name": "A"}`}, {Type: "ebt", Config: `{"name": "B"}`}}}
+
+	imported := make(chan *template.SetImportedEvent, 1)
+	em.Subscribe(template.SetImportedEventID, func(e event.Event, args *event.PublishArgs) error {
+		imported <- e.Payload().(*template.SetImportedEvent)
+		return nil
+	}, event.DefaultPriority)
+
+	templateSet, err := Install(ctx, repo, setRepo, tmplRepo, em, entry, userID)
+	require.NoError(t, err)
+	assert.Equal(t, &template.SetToCreate{Name: "Set", Version: "1.0.0", CreatedBy: userID}, setRepo.created)
+	require.Len(t, tmplRepo.created, 2)
+	assert.Equal(t, templateSet.ID, tmplRepo.created[1].TemplateSet)
+	assert.Equal(t, `{"name": "B"}`, tmplRepo.created[1].Config)
+	assert.Equal(t, 1, repo.installs)
+
+	select {
+	case e := <-imported:
+		assert.Equal(t, templateSet, e.TemplateSet)
+	case <-time.After(time.Second):
+		t.Fatal("set imported event was not published")
+	}
+}
+
+func (r *repositoryMock) Create(ctx context.Context, toCreate *ToCreate) (*Entry, error) {
+	r.created = toCreate
+	return &Entry{ID: uuid.New(), Name: toCreate.Name, Version: toCreate.Version}, nil
+}
+
+func (r *repositoryMock) CountInstall(ctx context.Context, id uuid.UUID) error {
+	r.installs++
+	return nil
+}
+
+func (r *templateSetRepositoryMock) Create(ctx context.Context, toCreate *template.SetToCreate) (*template.Set, error) {
+	r.created = toCreate
+	return &template.Set{ID: uuid.New(), Name: toCreate.Name, Version: toCreate.Version, CreatedBy: toCreate.CreatedBy}, nil
+}
+
+func (r *templateRepositoryMock) FindByTemplateSetID(ctx context.Context, templateSetID uuid.UUID) ([]*template.Template, error) {
+	return r.templates, nil
+}
+
+func (r *templateRepositoryMock) Create(ctx context.Context, toCreate *template.ToCreate) (*template.Template, error) {
+	r.created = append(r.created, toCreate)
+	return &template.Template{ID: uuid.New(), TemplateSet: toCreate.TemplateSet, Type: toCreate.Type, Config: toCreate.Config}, nil
+}
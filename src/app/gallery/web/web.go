@@ -0,0 +1,390 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/gallery"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/feature"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+)
+
+// ListData is the data of the gallery listing the entries matching the query.
+type ListData struct {
+	Entries []*gallery.Entry
+	Query   string
+}
+
+// EntryData is the data of an entry's page with the previews of its templates.
+type EntryData struct {
+	Entry    *gallery.Entry
+	Previews []gallery.Preview
+	// IsAuthor is true if the logged-in user published the entry, IsAdmin if the user moderates the gallery.
+	IsAuthor bool
+	IsAdmin  bool
+	// Flags are the flags of the entry, they are only filled for admins.
+	Flags []*gallery.Flag
+}
+
+// PublishForm is the form publishing a template set to the gallery.
+type PublishForm struct {
+	License     string `hvalidate:"required" hform:"label=gallery.license.label,placeholder=gallery.license.placeholder"`
+	Description string `hform:"label=gallery.description.label,placeholder=gallery.description.placeholder,widget=textarea"`
+}
+
+// PublishData is the data of the page publishing a template set to the gallery.
+type PublishData struct {
+	TemplateSet *template.Set
+	Form        *web.Form[*PublishForm]
+}
+
+// controllerDeps are the dependencies shared by the gallery controllers.
+type controllerDeps struct {
+	entries      gallery.Repository
+	templateSets template.SetRepository
+	templates    template.Repository
+}
+
+// RegisterController registers the controllers of the gallery. All of them require the gallery.Feature flag:
+//   - GET /gallery Renders the gallery page listing the entries.
+//   - GET /gallery/list Renders the listed entries filtered by a query (q).
+//   - GET /gallery/{id} Renders an entry with the previews of its templates.
+//   - POST /gallery/{id}/install Installs a copy of the entry as a new template set of the user.
+//   - POST /gallery/{id}/flag Flags the entry for moderation (reason).
+//   - DELETE /gallery/{id} Removes the entry from the gallery (author and admins only).
+//   - POST /gallery/{id}/hidden Hides or lists the entry (state: on or off, admins only).
+//   - GET /gallery/publish/{templateSetID} Renders the form publishing the user's template set.
+//   - POST /gallery/publish/{templateSetID} Publishes a snapshot of the user's template set (license, description).
+//   - GET /admin/gallery Renders the flagged and hidden entries for moderation (admins only).
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	registerNavigation(webCtx)
+	registerErrors(webCtx)
+
+	deps := &controllerDeps{
+		entries:      util.UnwrapType[gallery.Repository](appCtx.Repository(gallery.RepositoryName)),
+		templateSets: util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName)),
+		templates:    util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName)),
+	}
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/gallery", listController(appCtx, webCtx, deps, true).ServeHTTP)
+	router.Get("/gallery/list", listController(appCtx, webCtx, deps, false).ServeHTTP)
+	router.Get("/gallery/{id}", entryController(appCtx, webCtx, deps).ServeHTTP)
+	router.Post("/gallery/{id}/install", installController(appCtx, webCtx, deps).ServeHTTP)
+	router.Post("/gallery/{id}/flag", flagController(appCtx, webCtx, deps).ServeHTTP)
+	router.Delete("/gallery/{id}", deleteController(appCtx, webCtx, deps).ServeHTTP)
+	router.Post("/gallery/{id}/hidden", hiddenController(appCtx, webCtx, deps).ServeHTTP)
+	router.Get("/gallery/publish/{templateSetID}", publishPageController(appCtx, webCtx, deps).ServeHTTP)
+	router.Post("/gallery/publish/{templateSetID}", publishController(appCtx, webCtx, deps).ServeHTTP)
+	router.Get("/admin/gallery", moderationController(appCtx, webCtx, deps).ServeHTTP)
+}
+
+// registerNavigation adds the gallery and its moderation to the navigation if the gallery.Feature flag is active.
+func registerNavigation(webCtx *web.Ctx) {
+	enabled := func(io web.IO) (bool, error) {
+		return feature.Enabled(io.Context(), gallery.Feature), nil
+	}
+
+	webCtx.Navigation.Add("gallery.list", web.NavItem{
+		URL:      "/gallery",
+		Name:     "harmony.menu.gallery",
+		Display:  enabled,
+		Position: 160,
+	})
+
+	webCtx.Navigation.Add("gallery.moderation", web.NavItem{
+		URL:        "/admin/gallery",
+		Name:       "harmony.menu.gallery-moderation",
+		Permission: feature.RoleAdmin,
+		Display:    enabled,
+		Position:   1051,
+	})
+}
+
+// registerErrors maps the errors of the gallery controllers to their HTTP status codes.
+func registerErrors(webCtx *web.Ctx) {
+	for _, err := range []error{gallery.ErrVersionExists, gallery.ErrEmptySet, gallery.ErrInvalidLicense, gallery.ErrInvalidReason} {
+		webCtx.Errors.Map(err, http.StatusUnprocessableEntity, err)
+	}
+}
+
+// controller constructs a controller that responds with web.ErrNotFound unless the gallery.Feature flag is active.
+func controller(appCtx *hctx.AppCtx, webCtx *web.Ctx, handler func(io web.IO) error) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		if !feature.Enabled(io.Context(), gallery.Feature) {
+			return io.Error(web.ErrNotFound)
+		}
+
+		return handler(io)
+	})
+}
+
+// listController renders the listed entries matching the request's query, either as page or only the list.
+func listController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps, page bool) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		query := io.Request().URL.Query().Get("q")
+
+		entries, err := deps.entries.FindListed(io.Context(), query)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		data := &ListData{Entries: entries, Query: query}
+		if page {
+			return io.Render(data, "gallery.list.page", "gallery/list-page.go.html", "gallery/_list.go.html")
+		}
+
+		return io.Render(data, "gallery.list", "gallery/_list.go.html")
+	})
+}
+
+func entryController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		entry, err := deps.visibleEntry(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		return deps.renderEntry(io, entry, true, nil)
+	})
+}
+
+func installController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		entry, err := deps.visibleEntry(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		ctx := io.Context()
+		templateSet, err := gallery.Install(ctx, deps.entries, deps.templateSets, deps.templates, appCtx.EventManager, entry, user.MustCtxUser(ctx).ID)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		return io.Redirect(fmt.Sprintf("/template-set/%s/list", templateSet.ID), http.StatusFound)
+	})
+}
+
+func flagController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		entry, err := deps.visibleEntry(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		reason, err := gallery.ValidReason(io.Request().FormValue("reason"))
+		if err != nil {
+			return deps.renderEntry(io, entry, false, nil, err)
+		}
+
+		if err := deps.entries.Flag(io.Context(), entry.ID, user.MustCtxUser(io.Context()).ID, reason); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		appCtx.Info(gallery.Pkg, "gallery entry flagged", "entry", entry.ID)
+
+		return deps.renderEntry(io, entry, false, []string{"gallery.flag.success"})
+	})
+}
+
+func deleteController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		entry, err := deps.visibleEntry(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		if entry.PublishedBy != user.MustCtxUser(io.Context()).ID && !isAdmin(io) {
+			return io.InlineError(web.Forbidden(nil))
+		}
+
+		if err := deps.entries.Delete(io.Context(), entry.ID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		appCtx.Info(gallery.Pkg, "gallery entry deleted", "entry", entry.ID, "by", user.MustCtxUser(io.Context()).ID)
+
+		io.Response().Header().Set("HX-Redirect", "/gallery")
+
+		return nil
+	})
+}
+
+func hiddenController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		if !isAdmin(io) {
+			return io.InlineError(web.Forbidden(nil))
+		}
+
+		entry, err := deps.visibleEntry(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		state := io.Request().FormValue("state")
+		if state != "on" && state != "off" {
+			return io.InlineError(web.NewHTTPError(http.StatusBadRequest, nil, fmt.Errorf("invalid hidden state %q", state)))
+		}
+
+		if err := deps.entries.SetHidden(io.Context(), entry.ID, state == "on"); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		appCtx.Info(gallery.Pkg, "gallery entry moderated", "entry", entry.ID, "hidden", state == "on")
+
+		entry.Hidden = state == "on"
+
+		return deps.renderEntry(io, entry, false, []string{"gallery.moderation.success"})
+	})
+}
+
+func publishPageController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		templateSet, err := deps.ownTemplateSet(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		return renderPublishPage(io, templateSet, publishForm(templateSet, &PublishForm{Description: templateSet.Description}))
+	})
+}
+
+func publishController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		templateSet, err := deps.ownTemplateSet(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		form := publishForm(templateSet, &PublishForm{})
+
+		return web.HandleForm(io, appCtx.Validator, form, func(form *web.Form[*PublishForm]) error {
+			return renderPublishPage(io, templateSet, form)
+		}, func(form *web.Form[*PublishForm]) error {
+			ctx := io.Context()
+			entry, err := gallery.Publish(ctx, deps.entries, deps.templates, templateSet, form.Form.License, form.Form.Description, user.MustCtxUser(ctx).ID)
+			if errors.Is(err, gallery.ErrInvalidLicense) || errors.Is(err, gallery.ErrEmptySet) || errors.Is(err, gallery.ErrVersionExists) {
+				form.ViolationsFromErrors(err)
+				return renderPublishPage(io, templateSet, form)
+			}
+			if err != nil {
+				return err
+			}
+
+			appCtx.Info(gallery.Pkg, "template set published to gallery", "entry", entry.ID, "templateSet", templateSet.ID)
+
+			return io.Redirect(fmt.Sprintf("/gallery/%s", entry.ID), http.StatusFound)
+		})
+	})
+}
+
+func moderationController(appCtx *hctx.AppCtx, webCtx *web.Ctx, deps *controllerDeps) http.Handler {
+	return controller(appCtx, webCtx, func(io web.IO) error {
+		if !isAdmin(io) {
+			return io.Error(web.Forbidden(nil))
+		}
+
+		entries, err := deps.entries.FindModerated(io.Context())
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		return io.Render(&ListData{Entries: entries}, "gallery.moderation.page", "gallery/moderation-page.go.html")
+	})
+}
+
+// visibleEntry returns the entry of the request's id parameter. Hidden entries are only visible to their author and admins.
+// The errors returned are safe to be displayed to the user.
+func (d *controllerDeps) visibleEntry(io web.IO) (*gallery.Entry, error) {
+	id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+	if err != nil {
+		return nil, web.NotFound(err)
+	}
+
+	entry, err := d.entries.FindByID(io.Context(), id)
+	if err != nil && errors.Is(err, persistence.ErrNotFound) {
+		return nil, web.NotFound(err)
+	} else if err != nil {
+		return nil, errors.Join(web.ErrInternal, err)
+	}
+
+	if entry.Hidden && entry.PublishedBy != user.MustCtxUser(io.Context()).ID && !isAdmin(io) {
+		return nil, web.ErrNotFound
+	}
+
+	return entry, nil
+}
+
+// ownTemplateSet returns the user's template set of the request's templateSetID parameter.
+// The errors returned are safe to be displayed to the user.
+func (d *controllerDeps) ownTemplateSet(io web.IO) (*template.Set, error) {
+	id, err := uuid.Parse(web.URLParam(io.Request(), "templateSetID"))
+	if err != nil {
+		return nil, web.NotFound(err)
+	}
+
+	templateSet, err := d.templateSets.FindByID(io.Context(), id)
+	if err != nil {
+		return nil, web.NotFound(err)
+	}
+
+	if templateSet.CreatedBy != user.MustCtxUser(io.Context()).ID {
+		return nil, web.Forbidden(nil)
+	}
+
+	return templateSet, nil
+}
+
+// renderEntry renders the entry either as page or only the entry's content, e.g. after flagging it.
+func (d *controllerDeps) renderEntry(io web.IO, entry *gallery.Entry, page bool, success []string, errs ...error) error {
+	data := &EntryData{
+		Entry:    entry,
+		Previews: gallery.Previews(entry),
+		IsAuthor: entry.PublishedBy == user.MustCtxUser(io.Context()).ID,
+		IsAdmin:  isAdmin(io),
+	}
+
+	if data.IsAdmin {
+		flags, err := d.entries.FindFlags(io.Context(), entry.ID)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		data.Flags = flags
+	}
+
+	formData := web.NewFormData(data, success, errs...)
+	if !page {
+		return io.Render(formData, "gallery.entry", "gallery/_entry.go.html")
+	}
+
+	return io.Render(formData, "gallery.entry.page", "gallery/entry-page.go.html", "gallery/_entry.go.html")
+}
+
+// isAdmin returns true if the request's subject has the feature.RoleAdmin role and therefore moderates the gallery.
+func isAdmin(io web.IO) bool {
+	flags, subject, ok := feature.FromContext(io.Context())
+
+	return ok && flags.HasRole(subject, feature.RoleAdmin)
+}
+
+// publishForm declares the form publishing the template set to the gallery.
+func publishForm(templateSet *template.Set, toPublish *PublishForm) *web.Form[*PublishForm] {
+	return web.NewForm(web.Form[*PublishForm]{
+		ID:     "gallery-publish-form",
+		Action: fmt.Sprintf("/gallery/publish/%s", templateSet.ID),
+		Submit: "gallery.publish.submit",
+	}, toPublish, nil)
+}
+
+// renderPublishPage renders the page publishing the template set to the gallery.
+func renderPublishPage(io web.IO, templateSet *template.Set, form *web.Form[*PublishForm]) error {
+	return io.Render(&PublishData{TemplateSet: templateSet, Form: form}, "gallery.publish.page", "gallery/publish-page.go.html")
+}
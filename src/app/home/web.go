@@ -1,17 +1,29 @@
 package home
 
 import (
+	"github.com/org-harmony/harmony/src/app/user"
 	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/org-harmony/harmony/src/core/web"
+	"time"
 )
 
-// RegisterController registers the home controller and navigation.
+// CacheTTL is the duration the home page is cached for anonymous users.
+const CacheTTL = 5 * time.Minute
+
+// RegisterController registers the home controller, navigation, the tenant's branding and the command palette's endpoint.
+// The home page is cached for anonymous users (see web.Cache).
 func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 	registerNavigation(appCtx, webCtx)
+	registerTemplateDataExtensions(webCtx)
+
+	cache := web.Cache(webCtx.Cache, web.PageCachePolicy(CacheTTL, user.IsLoggedIn))
 
-	webCtx.Router.Get("/", web.NewController(appCtx, webCtx, func(io web.IO) error {
+	webCtx.Router.With(cache).Get("/", web.NewController(appCtx, webCtx, func(io web.IO) error {
 		return io.Render(nil, "home", "home.go.html")
 	}).ServeHTTP)
+
+	webCtx.Router.Get(web.CommandsRoute, web.CommandsController(appCtx, webCtx).ServeHTTP)
 }
 
 func registerNavigation(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
@@ -24,3 +36,16 @@ func registerNavigation(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 		Position: 0,
 	})
 }
+
+// registerTemplateDataExtensions passes the branding of the request's tenant (if any) to the templates as Extra.Branding.
+func registerTemplateDataExtensions(webCtx *web.Ctx) {
+	webCtx.Extensions.Add("branding", func(io web.IO, data *web.BaseTemplateData) error {
+		t, ok := tenant.FromCtx(io.Context())
+		if !ok || t.Branding == nil {
+			return nil
+		}
+
+		data.Extra["Branding"] = t.Branding
+		return nil
+	})
+}
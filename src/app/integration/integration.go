@@ -0,0 +1,239 @@
+// Package integration stores the credentials of users for integrations with other tools, e.g. API tokens for Jira or webhook secrets.
+// Secrets are sensitive, they are therefore encrypted before they are stored (see crypto.Cipher).
+//
+// TODO add the integrations themselves (e.g. exporting requirements to Jira) and a settings page to manage the credentials.
+package integration
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/crypto"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"time"
+)
+
+const (
+	// RepositoryName is the name of the credential repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "IntegrationCredentialRepository"
+	// Pkg is the package name for logging.
+	Pkg = "app.integration"
+	// credentialColumns is the column list of the integration_credentials table in the order scanned by scanCredential.
+	credentialColumns = "id, user_id, integration, name, secret, created_at, updated_at"
+)
+
+// Credential is a secret of a user for an integration, e.g. the API token of the user's Jira account.
+// A user has at most one credential per integration and name.
+type Credential struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// Integration identifies the integration the credential is used for, e.g. jira or webhook.
+	Integration string
+	// Name distinguishes multiple credentials of a user for the same integration, e.g. the Jira instance.
+	Name string
+	// Secret is the decrypted secret. It is encrypted in the database.
+	Secret    string
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+// ToSave is the credential entity that is used to create a credential or to replace the secret of an existing one.
+type ToSave struct {
+	UserID      uuid.UUID `hvalidate:"required"`
+	Integration string    `hvalidate:"required"`
+	Name        string    `hvalidate:"required"`
+	Secret      string    `hvalidate:"required"`
+}
+
+// Repository is the credential repository. It contains all methods to interact with the integration_credentials table in the database.
+// All methods except RotateKeys are scoped to the tenant of the context (see tenant.ID) and to the passed in user.
+// If no encryption key is configured, all methods reading or writing secrets return crypto.ErrNoKey.
+// Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// FindByUser finds all credentials of a user. It returns an empty slice if the user has no credentials
+	// and persistence.ErrReadRow for any other error.
+	FindByUser(ctx context.Context, userID uuid.UUID) ([]*Credential, error)
+	// FindByName finds the credential of a user for the integration by its name.
+	// It returns persistence.ErrNotFound if the credential could not be found and persistence.ErrReadRow for any other error.
+	FindByName(ctx context.Context, userID uuid.UUID, integration string, name string) (*Credential, error)
+	// Save creates the credential or replaces the secret of the user's existing credential with the same integration and name.
+	// It returns persistence.ErrInsert if the credential could not be saved.
+	Save(ctx context.Context, toSave *ToSave) (*Credential, error)
+	// Delete deletes a credential of a user by its id. It returns persistence.ErrDelete if the credential could not be deleted.
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	// RotateKeys re-encrypts all secrets not encrypted with the primary key (see crypto.Cipher.Stale) and returns the number
+	// of re-encrypted secrets. The previous keys can be removed from the configuration afterward. RotateKeys is not scoped
+	// to a tenant, it rotates the secrets of all tenants. It returns persistence.ErrUpdate if a secret could not be re-encrypted.
+	RotateKeys(ctx context.Context) (int, error)
+}
+
+// PGRepository is the credential repository for PostgreSQL. It holds a reference to the database connection pool
+// and the cipher encrypting the secrets.
+type PGRepository struct {
+	persistence.Timeout
+	db     *pgxpool.Pool
+	cipher *crypto.Cipher
+}
+
+// owner identifies the owner of a credential's secret. It is the additional data the secret is encrypted with (see crypto.Cipher.Column),
+// therefore a secret can not be copied to the credential of another user, integration or name.
+type owner struct {
+	credential *Credential
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool and cipher.
+// The cipher may be nil if no encryption key is configured.
+func NewRepository(db *pgxpool.Pool, cipher *crypto.Cipher) Repository {
+	return &PGRepository{db: db, cipher: cipher}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// FindByUser finds all credentials of a user. It returns an empty slice if the user has no credentials
+// and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByUser(ctx context.Context, userID uuid.UUID) ([]*Credential, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		"SELECT "+credentialColumns+" FROM integration_credentials WHERE user_id = $1 AND tenant_id = $2 ORDER BY integration, name",
+		userID, tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, r.scanCredential)
+}
+
+// FindByName finds the credential of a user for the integration by its name.
+// It returns persistence.ErrNotFound if the credential could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByName(ctx context.Context, userID uuid.UUID, integration string, name string) (*Credential, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+credentialColumns+" FROM integration_credentials WHERE user_id = $1 AND integration = $2 AND name = $3 AND tenant_id = $4",
+		userID, integration, name, tenant.ID(ctx),
+	), r.scanCredential)
+}
+
+// Save creates the credential or replaces the secret of the user's existing credential with the same integration and name.
+// It returns persistence.ErrInsert if the credential could not be saved.
+func (r *PGRepository) Save(ctx context.Context, toSave *ToSave) (*Credential, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	credential := &Credential{
+		ID:          uuid.New(),
+		UserID:      toSave.UserID,
+		Integration: toSave.Integration,
+		Name:        toSave.Name,
+		Secret:      toSave.Secret,
+		CreatedAt:   time.Now(),
+	}
+
+	saved, err := r.scanCredential(r.db.QueryRow(
+		ctx,
+		`INSERT INTO integration_credentials (`+credentialColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (tenant_id, user_id, integration, name) DO UPDATE SET secret = EXCLUDED.secret, updated_at = NOW()
+		RETURNING `+credentialColumns,
+		credential.ID,
+		credential.UserID,
+		credential.Integration,
+		credential.Name,
+		r.cipher.Column(&credential.Secret, owner{credential}),
+		credential.CreatedAt,
+		credential.UpdatedAt,
+		tenant.ID(ctx),
+	))
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return saved, nil
+}
+
+// Delete deletes a credential of a user by its id. It returns persistence.ErrDelete if the credential could not be deleted.
+func (r *PGRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		"DELETE FROM integration_credentials WHERE id = $1 AND user_id = $2 AND tenant_id = $3",
+		id, userID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// RotateKeys re-encrypts all secrets not encrypted with the primary key (see crypto.Cipher.Stale) and returns the number
+// of re-encrypted secrets. The previous keys can be removed from the configuration afterward. RotateKeys is not scoped
+// to a tenant, it rotates the secrets of all tenants. It returns persistence.ErrUpdate if a secret could not be re-encrypted.
+func (r *PGRepository) RotateKeys(ctx context.Context) (int, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, "SELECT "+credentialColumns+" FROM integration_credentials")
+	if err != nil {
+		return 0, persistence.PGReadErr(err)
+	}
+
+	stale, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*Credential, error) {
+		credential := &Credential{}
+		secret := r.cipher.Column(&credential.Secret, owner{credential})
+		err := row.Scan(&credential.ID, &credential.UserID, &credential.Integration, &credential.Name, secret, &credential.CreatedAt, &credential.UpdatedAt)
+		if err != nil || !secret.Stale() {
+			return nil, err
+		}
+
+		return credential, nil
+	})
+	if err != nil {
+		return 0, persistence.PGReadErr(err)
+	}
+
+	rotated := 0
+	for _, credential := range stale {
+		if credential == nil {
+			continue
+		}
+
+		_, err := r.db.Exec(
+			ctx,
+			"UPDATE integration_credentials SET secret = $1 WHERE id = $2",
+			r.cipher.Column(&credential.Secret, owner{credential}), credential.ID,
+		)
+		if err != nil {
+			return rotated, errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+		}
+
+		rotated++
+	}
+
+	return rotated, nil
+}
+
+// String returns the owner's user, integration and name. They are unique per tenant.
+func (o owner) String() string {
+	return o.credential.UserID.String() + "/" + o.credential.Integration + "/" + o.credential.Name
+}
+
+// scanCredential scans a row containing the credentialColumns into a new Credential and decrypts its secret.
+func (r *PGRepository) scanCredential(row pgx.Row) (*Credential, error) {
+	c := &Credential{}
+	err := row.Scan(&c.ID, &c.UserID, &c.Integration, &c.Name, r.cipher.Column(&c.Secret, owner{c}), &c.CreatedAt, &c.UpdatedAt)
+
+	return c, err
+}
@@ -0,0 +1,240 @@
+// Package notification notifies users about events concerning them, e.g. a finished import of a template set.
+// Notifications are produced from events (see Subscribe) and stored per user until they are read.
+package notification
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"sort"
+	"time"
+)
+
+// TODO add optional weekly email digests of elicitation activity (configurable per user). This requires a mailer with
+// mail templates and a scheduler for recurring jobs, neither exists yet. The digest would summarize the notifications and
+// the requirements elicited in the user's projects (see requirement.Repository.FindByProject).
+
+const (
+	// RepositoryName is the name of the notification repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "NotificationRepository"
+	// Pkg is the package name for logging.
+	Pkg = "app.notification"
+	// URLKey is the payload key of the URL a notification links to.
+	URLKey = "url"
+	// notificationColumns is the column list of the notifications table in the order scanned by scanNotification.
+	notificationColumns = "id, user_id, type, payload, read_at, created_at"
+)
+
+// Notification is a message to a user. The Type determines the message, the message is translated with the Payload as arguments.
+// A notification is unread until ReadAt is set.
+type Notification struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// Type is the translation key of the notification's message, e.g. notification.type.import-finished.
+	Type string
+	// Payload contains the arguments of the notification's message and optionally the URL the notification links to (URLKey).
+	Payload   map[string]string
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+// ToCreate is the notification entity that is used to create a new notification.
+type ToCreate struct {
+	UserID  uuid.UUID `hvalidate:"required"`
+	Type    string    `hvalidate:"required"`
+	Payload map[string]string
+}
+
+// Repository is the notification repository. It contains all methods to interact with the notifications table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID) and to the passed in user.
+// Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// FindByUser finds the latest notifications of a user up to the limit ordered by their creation, the newest first.
+	// It returns an empty slice if the user has no notifications and persistence.ErrReadRow for any other error.
+	FindByUser(ctx context.Context, userID uuid.UUID, limit int) ([]*Notification, error)
+	// FindByID finds a notification of a user by its id.
+	// It returns persistence.ErrNotFound if the notification could not be found and persistence.ErrReadRow for any other error.
+	FindByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Notification, error)
+	// CountUnread counts the unread notifications of a user. It returns persistence.ErrReadRow if the notifications could not be counted.
+	CountUnread(ctx context.Context, userID uuid.UUID) (int, error)
+	// Create creates a new notification and returns it. It returns persistence.ErrInsert if the notification could not be inserted.
+	Create(ctx context.Context, toCreate *ToCreate) (*Notification, error)
+	// MarkRead marks a notification of a user as read. Notifications already read are not changed.
+	// It returns persistence.ErrUpdate if the notification could not be updated.
+	MarkRead(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	// MarkAllRead marks all notifications of a user as read. It returns persistence.ErrUpdate if the notifications could not be updated.
+	MarkAllRead(ctx context.Context, userID uuid.UUID) error
+}
+
+// PGRepository is the notification repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &PGRepository{db: db}
+}
+
+// Read returns true if the notification was read.
+func (n *Notification) Read() bool {
+	return n.ReadAt != nil
+}
+
+// URL returns the URL the notification links to. It is empty if the notification does not link anywhere.
+func (n *Notification) URL() string {
+	return n.Payload[URLKey]
+}
+
+// Args returns the payload as key value pairs sorted by their keys. They are passed to the translation of the notification's message.
+func (n *Notification) Args() []string {
+	keys := make([]string, 0, len(n.Payload))
+	for key := range n.Payload {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		args = append(args, key, n.Payload[key])
+	}
+
+	return args
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// FindByUser finds the latest notifications of a user up to the limit ordered by their creation, the newest first.
+// It returns an empty slice if the user has no notifications and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByUser(ctx context.Context, userID uuid.UUID, limit int) ([]*Notification, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		"SELECT "+notificationColumns+" FROM notifications WHERE user_id = $1 AND tenant_id = $2 ORDER BY created_at DESC LIMIT $3",
+		userID, tenant.ID(ctx), limit,
+	)
+
+	return persistence.PGCollectRows(rows, err, scanNotification)
+}
+
+// FindByID finds a notification of a user by its id.
+// It returns persistence.ErrNotFound if the notification could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Notification, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+notificationColumns+" FROM notifications WHERE id = $1 AND user_id = $2 AND tenant_id = $3",
+		id, userID, tenant.ID(ctx),
+	), scanNotification)
+}
+
+// CountUnread counts the unread notifications of a user. It returns persistence.ErrReadRow if the notifications could not be counted.
+func (r *PGRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	var count int
+	err := r.db.QueryRow(
+		ctx,
+		"SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND tenant_id = $2 AND read_at IS NULL",
+		userID, tenant.ID(ctx),
+	).Scan(&count)
+	if err != nil {
+		return 0, persistence.PGReadErr(err)
+	}
+
+	return count, nil
+}
+
+// Create creates a new notification and returns it. It returns persistence.ErrInsert if the notification could not be inserted.
+func (r *PGRepository) Create(ctx context.Context, toCreate *ToCreate) (*Notification, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	payload := toCreate.Payload
+	if payload == nil {
+		payload = make(map[string]string)
+	}
+
+	newNotification := &Notification{
+		ID:        uuid.New(),
+		UserID:    toCreate.UserID,
+		Type:      toCreate.Type,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		"INSERT INTO notifications ("+notificationColumns+", tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		newNotification.ID,
+		newNotification.UserID,
+		newNotification.Type,
+		newNotification.Payload,
+		newNotification.ReadAt,
+		newNotification.CreatedAt,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return newNotification, nil
+}
+
+// MarkRead marks a notification of a user as read. Notifications already read are not changed.
+// It returns persistence.ErrUpdate if the notification could not be updated.
+func (r *PGRepository) MarkRead(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		"UPDATE notifications SET read_at = NOW() WHERE id = $1 AND user_id = $2 AND tenant_id = $3 AND read_at IS NULL",
+		id, userID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// MarkAllRead marks all notifications of a user as read. It returns persistence.ErrUpdate if the notifications could not be updated.
+func (r *PGRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		"UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND tenant_id = $2 AND read_at IS NULL",
+		userID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// scanNotification scans a row containing the notificationColumns into a new Notification.
+func scanNotification(row pgx.Row) (*Notification, error) {
+	n := &Notification{}
+	err := row.Scan(&n.ID, &n.UserID, &n.Type, &n.Payload, &n.ReadAt, &n.CreatedAt)
+
+	return n, err
+}
@@ -0,0 +1,157 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/comment"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/validation"
+)
+
+const (
+	// TypeImportFinished is the type of the notification sent to the user after a template set was imported.
+	TypeImportFinished = "notification.type.import-finished"
+	// TypeReviewRequested is the type of the notification sent to the reviewer after a requirement was submitted for review.
+	TypeReviewRequested = "notification.type.review-requested"
+	// TypeReviewAccepted is the type of the notification sent to the author after the reviewer accepted a requirement.
+	TypeReviewAccepted = "notification.type.review-accepted"
+	// TypeReviewRejected is the type of the notification sent to the author after the reviewer rejected a requirement.
+	TypeReviewRejected = "notification.type.review-rejected"
+	// TypeReviewReminder is the type of the notification reminding the reviewer of a requirement still waiting for their review.
+	TypeReviewReminder = "notification.type.review-reminder"
+	// TypeCommentMention is the type of the notification sent to the users mentioned in a comment.
+	TypeCommentMention = "notification.type.comment-mention"
+)
+
+// ErrNoUser is returned if a produced notification is not addressed to a user.
+var ErrNoUser = errors.New("notification is not addressed to a user")
+
+// Producer produces the notifications of a published event. It returns no notifications if the event does not concern any user.
+// Events are handled outside the request, the returned context is used to create the notifications.
+// It should therefore contain the tenant the event was published in (see tenant.WithTenant).
+type Producer func(e event.Event) (context.Context, []*ToCreate)
+
+// Subscribe subscribes to the event by its id and creates the notifications produced by the Producer for each published event.
+// Invalid notifications are not created, the errors of creating the notifications are logged and returned to the event manager.
+func Subscribe(em event.Manager, eventID string, repository Repository, validator validation.V, logger trace.Logger, produce Producer) {
+	em.Subscribe(eventID, func(e event.Event, args *event.PublishArgs) error {
+		ctx, notifications := produce(e)
+
+		var errs []error
+		for _, toCreate := range notifications {
+			err, validationErrs := validator.ValidateStruct(toCreate)
+			if err == nil && len(validationErrs) > 0 {
+				err = errors.Join(validationErrs...)
+			}
+			if err == nil && toCreate.UserID == uuid.Nil {
+				err = ErrNoUser
+			}
+			if err == nil {
+				_, err = repository.Create(ctx, toCreate)
+			}
+			if err != nil {
+				logger.Error(Pkg, "failed to create notification", err, "eventID", eventID, "type", toCreate.Type)
+				errs = append(errs, err)
+			}
+		}
+
+		return errors.Join(errs...)
+	}, event.DefaultPriority)
+}
+
+// ImportFinished is the Producer of the template.SetImportedEvent. The creator of the imported template set is notified,
+// the notification links to the list of the set's templates.
+func ImportFinished(e event.Event) (context.Context, []*ToCreate) {
+	imported, ok := e.Payload().(*template.SetImportedEvent)
+	if !ok || imported.TemplateSet == nil {
+		return nil, nil
+	}
+
+	set := imported.TemplateSet
+
+	return imported.Ctx(), []*ToCreate{{
+		UserID: set.CreatedBy,
+		Type:   TypeImportFinished,
+		Payload: map[string]string{
+			"name":    set.Name,
+			"version": set.Version,
+			URLKey:    fmt.Sprintf("/template-set/%s/list", set.ID),
+		},
+	}}
+}
+
+// ReviewStateChanged is the Producer of the requirement.StateChangedEvent. The reviewer is notified after a draft was submitted
+// for review, the notification links to the review queue. The author is notified after the reviewer accepted or rejected
+// the requirement, the notification links to the list of requirements. Other state changes produce no notifications.
+func ReviewStateChanged(e event.Event) (context.Context, []*ToCreate) {
+	changed, ok := e.Payload().(*requirement.StateChangedEvent)
+	if !ok || changed.Requirement == nil {
+		return nil, nil
+	}
+
+	r := changed.Requirement
+	payload := map[string]string{"requirement": r.Text, "comment": changed.Comment}
+
+	var toCreate *ToCreate
+	switch {
+	case r.State == requirement.StateReview && changed.From == requirement.StateDraft && r.Reviewer != nil:
+		payload[URLKey] = "/requirement/review"
+		toCreate = &ToCreate{UserID: *r.Reviewer, Type: TypeReviewRequested, Payload: payload}
+	case r.State == requirement.StateAccepted:
+		payload[URLKey] = "/requirement"
+		toCreate = &ToCreate{UserID: r.CreatedBy, Type: TypeReviewAccepted, Payload: payload}
+	case r.State == requirement.StateRejected:
+		payload[URLKey] = "/requirement"
+		toCreate = &ToCreate{UserID: r.CreatedBy, Type: TypeReviewRejected, Payload: payload}
+	default:
+		return nil, nil
+	}
+
+	return changed.Ctx(), []*ToCreate{toCreate}
+}
+
+// ReviewReminderDue is the Producer of the requirement.ReviewReminderEvent. The reviewer is reminded of the requirement
+// still waiting for their review, the notification links to the review queue.
+func ReviewReminderDue(e event.Event) (context.Context, []*ToCreate) {
+	reminder, ok := e.Payload().(*requirement.ReviewReminderEvent)
+	if !ok || reminder.Requirement == nil || reminder.Requirement.Reviewer == nil {
+		return nil, nil
+	}
+
+	r := reminder.Requirement
+
+	return reminder.Ctx(), []*ToCreate{{
+		UserID:  *r.Reviewer,
+		Type:    TypeReviewReminder,
+		Payload: map[string]string{"requirement": r.Text, URLKey: "/requirement/review"},
+	}}
+}
+
+// CommentMentioned is the Producer of the comment.MentionedEvent. The mentioned users are notified,
+// the notification links to the page showing the comment's target (see comment.TargetURL).
+func CommentMentioned(e event.Event) (context.Context, []*ToCreate) {
+	mentioned, ok := e.Payload().(*comment.MentionedEvent)
+	if !ok || mentioned.Comment == nil {
+		return nil, nil
+	}
+
+	c := mentioned.Comment
+	notifications := make([]*ToCreate, 0, len(mentioned.Mentioned))
+	for _, userID := range mentioned.Mentioned {
+		notifications = append(notifications, &ToCreate{
+			UserID: userID,
+			Type:   TypeCommentMention,
+			Payload: map[string]string{
+				"author": c.CreatedByEmail,
+				URLKey:   comment.TargetURL(c.TargetType, c.TargetID),
+			},
+		})
+	}
+
+	return mentioned.Ctx(), notifications
+}
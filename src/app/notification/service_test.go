@@ -0,0 +1,234 @@
+package notification
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/comment"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memRepository is an in-memory Repository for tests. It records the tenant notifications were created in.
+type memRepository struct {
+	mu            sync.Mutex
+	notifications []*Notification
+	tenants       []string
+}
+
+func (r *memRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+func (r *memRepository) FindByUser(ctx context.Context, userID uuid.UUID, limit int) ([]*Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var notifications []*Notification
+	for i := len(r.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		if r.notifications[i].UserID == userID {
+			notifications = append(notifications, r.notifications[i])
+		}
+	}
+
+	return notifications, nil
+}
+
+func (r *memRepository) FindByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, n := range r.notifications {
+		if n.ID == id && n.UserID == userID {
+			return n, nil
+		}
+	}
+
+	return nil, persistence.ErrNotFound
+}
+
+func (r *memRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, n := range r.notifications {
+		if n.UserID == userID && !n.Read() {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *memRepository) Create(ctx context.Context, toCreate *ToCreate) (*Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := &Notification{ID: uuid.New(), UserID: toCreate.UserID, Type: toCreate.Type, Payload: toCreate.Payload, CreatedAt: time.Now()}
+	r.notifications = append(r.notifications, n)
+	r.tenants = append(r.tenants, tenant.ID(ctx))
+
+	return n, nil
+}
+
+func (r *memRepository) MarkRead(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	n, err := r.FindByID(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	n.ReadAt = &now
+	return nil
+}
+
+func (r *memRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, n := range r.notifications {
+		if n.UserID == userID {
+			n.ReadAt = &now
+		}
+	}
+
+	return nil
+}
+
+func TestNotification(t *testing.T) {
+	n := &Notification{Payload: map[string]string{"version": "1.0.0", URLKey: "/set", "name": "PARIS"}}
+
+	assert.Equal(t, []string{"name", "PARIS", "url", "/set", "version", "1.0.0"}, n.Args())
+	assert.Equal(t, "/set", n.URL())
+	assert.False(t, n.Read())
+
+	assert.Empty(t, (&Notification{}).Args())
+	assert.Empty(t, (&Notification{}).URL())
+}
+
+func TestSubscribe(t *testing.T) {
+	logger := trace.NewLogger()
+	em := event.NewManager(logger)
+	repository := &memRepository{}
+	Subscribe(em, template.SetImportedEventID, repository, validation.New(), logger, ImportFinished)
+
+	userID := uuid.New()
+	set := &template.Set{ID: uuid.New(), Name: "PARIS", Version: "1.0.0", CreatedBy: userID}
+	ctx := tenant.WithTenant(context.Background(), &tenant.Tenant{ID: "acme"})
+
+	dc := make(chan []error)
+	em.Publish(&template.SetImportedEvent{TemplateSet: set, Tenant: &tenant.Tenant{ID: "acme"}}, dc)
+	require.Empty(t, <-dc)
+
+	notifications, err := repository.FindByUser(ctx, userID, 10)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, TypeImportFinished, notifications[0].Type)
+	assert.Equal(t, "/template-set/"+set.ID.String()+"/list", notifications[0].URL())
+	assert.Equal(t, []string{"acme"}, repository.tenants)
+
+	t.Run("invalid notifications are not created", func(t *testing.T) {
+		Subscribe(em, "test.invalid", repository, validation.New(), logger, func(e event.Event) (context.Context, []*ToCreate) {
+			return context.Background(), []*ToCreate{{Type: TypeImportFinished}}
+		})
+
+		dc := make(chan []error)
+		em.Publish(testEvent{}, dc)
+		assert.NotEmpty(t, <-dc)
+		assert.Len(t, repository.notifications, 1)
+	})
+
+	t.Run("other events produce no notifications", func(t *testing.T) {
+		ctx, notifications := ImportFinished(testEvent{})
+		assert.Nil(t, ctx)
+		assert.Empty(t, notifications)
+	})
+}
+
+type testEvent struct{}
+
+func (testEvent) ID() string {
+	return "test.invalid"
+}
+
+func (testEvent) Payload() any {
+	return nil
+}
+
+func TestReviewStateChanged(t *testing.T) {
+	author, reviewer := uuid.New(), uuid.New()
+	r := &requirement.Requirement{Text: "The system shall log in users.", State: requirement.StateReview, CreatedBy: author, Reviewer: &reviewer}
+
+	ctx, notifications := ReviewStateChanged(&requirement.StateChangedEvent{Requirement: r, From: requirement.StateDraft, Tenant: &tenant.Tenant{ID: "acme"}})
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "acme", tenant.ID(ctx))
+	assert.Equal(t, reviewer, notifications[0].UserID)
+	assert.Equal(t, TypeReviewRequested, notifications[0].Type)
+	assert.Equal(t, "/requirement/review", notifications[0].Payload[URLKey])
+
+	_, notifications = ReviewStateChanged(&requirement.StateChangedEvent{Requirement: r, From: requirement.StateAccepted})
+	assert.Empty(t, notifications, "reopened requirements produce no notifications")
+
+	r.State = requirement.StateRejected
+	_, notifications = ReviewStateChanged(&requirement.StateChangedEvent{Requirement: r, From: requirement.StateReview, Comment: "too vague"})
+	require.Len(t, notifications, 1)
+	assert.Equal(t, author, notifications[0].UserID)
+	assert.Equal(t, TypeReviewRejected, notifications[0].Type)
+	assert.Equal(t, "too vague", notifications[0].Payload["comment"])
+
+	r.State = requirement.StateDraft
+	_, notifications = ReviewStateChanged(&requirement.StateChangedEvent{Requirement: r, From: requirement.StateRejected})
+	assert.Empty(t, notifications)
+
+	_, notifications = ReviewStateChanged(testEvent{})
+	assert.Empty(t, notifications)
+}
+
+func TestReviewReminderDue(t *testing.T) {
+	reviewer := uuid.New()
+	r := &requirement.Requirement{Text: "The system shall log in users.", State: requirement.StateReview, Reviewer: &reviewer}
+
+	ctx, notifications := ReviewReminderDue(&requirement.ReviewReminderEvent{Requirement: r, Tenant: &tenant.Tenant{ID: "acme"}})
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "acme", tenant.ID(ctx))
+	assert.Equal(t, reviewer, notifications[0].UserID)
+	assert.Equal(t, TypeReviewReminder, notifications[0].Type)
+	assert.Equal(t, "The system shall log in users.", notifications[0].Payload["requirement"])
+	assert.Equal(t, "/requirement/review", notifications[0].Payload[URLKey])
+
+	r.Reviewer = nil
+	_, notifications = ReviewReminderDue(&requirement.ReviewReminderEvent{Requirement: r})
+	assert.Empty(t, notifications)
+
+	_, notifications = ReviewReminderDue(testEvent{})
+	assert.Empty(t, notifications)
+}
+
+func TestCommentMentioned(t *testing.T) {
+	jane, max := uuid.New(), uuid.New()
+	templateID := uuid.New()
+	c := &comment.Comment{TargetType: comment.TargetTemplate, TargetID: templateID, CreatedByEmail: "author@example.com"}
+
+	ctx, notifications := CommentMentioned(&comment.MentionedEvent{Comment: c, Mentioned: []uuid.UUID{jane, max}, Tenant: &tenant.Tenant{ID: "acme"}})
+	require.Len(t, notifications, 2)
+	assert.Equal(t, "acme", tenant.ID(ctx))
+	assert.Equal(t, jane, notifications[0].UserID)
+	assert.Equal(t, max, notifications[1].UserID)
+	assert.Equal(t, TypeCommentMention, notifications[0].Type)
+	assert.Equal(t, "author@example.com", notifications[0].Payload["author"])
+	assert.Equal(t, "/template/"+templateID.String()+"/edit", notifications[0].Payload[URLKey])
+
+	_, notifications = CommentMentioned(testEvent{})
+	assert.Empty(t, notifications)
+}
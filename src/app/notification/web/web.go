@@ -0,0 +1,195 @@
+package web
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/comment"
+	"github.com/org-harmony/harmony/src/app/notification"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+)
+
+// MaxListed is the maximum number of notifications listed in the notification dropdown.
+const MaxListed = 10
+
+// ErrInvalidID is returned if the notification id of the request is not a valid UUID.
+var ErrInvalidID = errors.New("invalid notification id")
+
+// NavData is passed to the templates as Extra.Notifications for logged-in users.
+// It contains the number of unread notifications displayed in the navigation.
+type NavData struct {
+	Unread int
+}
+
+// ListData is the data of the notification dropdown.
+type ListData struct {
+	Notifications []Item
+	Unread        int
+}
+
+// Item is a notification with its translated message.
+type Item struct {
+	*notification.Notification
+	Message string
+}
+
+// RegisterController registers the controllers of the notification module, the producers of the notifications
+// and the unread count passed to the navigation:
+//   - GET /notification/list Renders the latest notifications of the user.
+//   - GET /notification/{id}/open Marks the notification as read and redirects to its URL.
+//   - POST /notification/read Marks all notifications of the user as read and renders the list.
+//
+// The notifications list is meant to be loaded into the navigation's dropdown when it is opened.
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	registerProducers(appCtx)
+	registerTemplateDataExtensions(appCtx, webCtx)
+	webCtx.Errors.Map(ErrInvalidID, http.StatusNotFound, web.ErrNotFound)
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/notification/list", listController(appCtx, webCtx).ServeHTTP)
+	router.Get("/notification/{id}/open", openController(appCtx, webCtx).ServeHTTP)
+	router.Post("/notification/read", readAllController(appCtx, webCtx).ServeHTTP)
+}
+
+// registerProducers subscribes the producers of notifications to their events.
+// TODO notify users about templates shared with them and finished exports once these are available
+func registerProducers(appCtx *hctx.AppCtx) {
+	repository := util.UnwrapType[notification.Repository](appCtx.Repository(notification.RepositoryName))
+
+	notification.Subscribe(
+		appCtx.EventManager,
+		template.SetImportedEventID,
+		repository,
+		appCtx.Validator,
+		appCtx.Logger,
+		notification.ImportFinished,
+	)
+	notification.Subscribe(
+		appCtx.EventManager,
+		requirement.StateChangedEventID,
+		repository,
+		appCtx.Validator,
+		appCtx.Logger,
+		notification.ReviewStateChanged,
+	)
+	notification.Subscribe(
+		appCtx.EventManager,
+		requirement.ReviewReminderEventID,
+		repository,
+		appCtx.Validator,
+		appCtx.Logger,
+		notification.ReviewReminderDue,
+	)
+	notification.Subscribe(
+		appCtx.EventManager,
+		comment.MentionedEventID,
+		repository,
+		appCtx.Validator,
+		appCtx.Logger,
+		notification.CommentMentioned,
+	)
+}
+
+// registerTemplateDataExtensions passes the number of unread notifications of the logged-in user to the templates
+// as Extra.Notifications (see NavData). Failing to count the notifications is logged but does not fail the request.
+func registerTemplateDataExtensions(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	webCtx.Extensions.Add("notifications", func(io web.IO, data *web.BaseTemplateData) error {
+		u, err := user.FromIO(io)
+		if err != nil {
+			return nil
+		}
+
+		repository, err := web.Repository[notification.Repository](io, notification.RepositoryName)
+		if err != nil {
+			return err
+		}
+
+		unread, err := repository.CountUnread(io.Context(), u.ID)
+		if err != nil {
+			appCtx.Warn(notification.Pkg, "failed to count unread notifications", "error", err)
+			return nil
+		}
+
+		data.Extra["Notifications"] = &NavData{Unread: unread}
+		return nil
+	})
+}
+
+func listController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		return renderList(io)
+	})
+}
+
+func openController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ctx := io.Context()
+		usr := user.MustFromIO(io)
+		repository := web.MustRepository[notification.Repository](io, notification.RepositoryName)
+
+		id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+		if err != nil {
+			return io.Error(ErrInvalidID, err)
+		}
+
+		n, err := repository.FindByID(ctx, usr.ID, id)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		err = repository.MarkRead(ctx, usr.ID, n.ID)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		url := n.URL()
+		if url == "" {
+			url = "/"
+		}
+
+		return io.Redirect(url, http.StatusSeeOther)
+	})
+}
+
+func readAllController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		repository := web.MustRepository[notification.Repository](io, notification.RepositoryName)
+
+		err := repository.MarkAllRead(io.Context(), user.MustFromIO(io).ID)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderList(io)
+	})
+}
+
+// renderList renders the latest notifications of the logged-in user with their translated messages.
+func renderList(io web.IO) error {
+	ctx := io.Context()
+	usr := user.MustFromIO(io)
+	repository := web.MustRepository[notification.Repository](io, notification.RepositoryName)
+
+	notifications, err := repository.FindByUser(ctx, usr.ID, MaxListed)
+	if err != nil {
+		return io.InlineError(web.ErrInternal, err)
+	}
+
+	unread, err := repository.CountUnread(ctx, usr.ID)
+	if err != nil {
+		return io.InlineError(web.ErrInternal, err)
+	}
+
+	translator := io.Translator()
+	items := make([]Item, 0, len(notifications))
+	for _, n := range notifications {
+		items = append(items, Item{Notification: n, Message: translator.Tf(n.Type, n.Args()...)})
+	}
+
+	return io.RespondNegotiated(ListData{Notifications: items, Unread: unread}, "notification.list", "notification/_list.go.html")
+}
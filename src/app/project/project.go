@@ -0,0 +1,369 @@
+// Package project groups template sets and requirements into projects. A project has members, each either an owner or a member.
+// Members associate their template sets with the project and elicit requirements within the project while it is their current project
+// (see Current). This allows large organizations to separate the concerns of their teams.
+package project
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"time"
+)
+
+const (
+	// RepositoryName is the name of the project repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "ProjectRepository"
+	// Pkg is the package name for logging.
+	Pkg = "app.project"
+	// RoleOwner is the role of members allowed to edit and delete the project and to manage its members.
+	RoleOwner Role = "owner"
+	// RoleMember is the role of members allowed to view the project, elicit requirements in it and associate their template sets.
+	RoleMember Role = "member"
+	// projectColumns is the column list of the projects table in the order scanned by scanProject.
+	projectColumns = "id, name, description, created_by, created_at, updated_at"
+)
+
+// Role is the role of a member in a project.
+type Role string
+
+// Project groups template sets and requirements. Members and TemplateSets are always loaded by the Repository.
+type Project struct {
+	ID          uuid.UUID
+	Name        string
+	Description string
+	// Members are the project's members, the owners first, each ordered by their email.
+	Members []Member
+	// TemplateSets are the ids of the template sets associated with the project. The template sets belong to the members.
+	TemplateSets []uuid.UUID
+	CreatedBy    uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    *time.Time
+}
+
+// Member is a user's membership in a project. The user's email and name are joined onto the membership.
+type Member struct {
+	UserID    uuid.UUID
+	Email     string
+	Firstname string
+	Lastname  string
+	Role      Role
+}
+
+// ToCreate is the project entity that is used to create a new project. The creator becomes the project's owner.
+// Its form fields are declared through the web.FormTag.
+type ToCreate struct {
+	Name        string    `hvalidate:"required" hform:"label=project.name"`
+	Description string    `hform:"label=project.description,widget=textarea"`
+	CreatedBy   uuid.UUID `hvalidate:"required"`
+}
+
+// ToUpdate is the project entity that is used to update an existing project.
+// Its form fields are declared through the web.FormTag.
+type ToUpdate struct {
+	ID          uuid.UUID `hvalidate:"required"`
+	Name        string    `hvalidate:"required" hform:"label=project.name"`
+	Description string    `hform:"label=project.description,widget=textarea"`
+}
+
+// Repository is the project repository. It contains all methods to interact with the projects, their members and template sets in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID). Reading projects is also scoped to the passed in user's memberships,
+// modifying projects is not: callers are responsible for checking the user's role (see Project.IsOwner and Project.IsMember).
+// Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// FindByID finds a project the user is a member of by its id.
+	// It returns persistence.ErrNotFound if the project could not be found and persistence.ErrReadRow for any other error.
+	FindByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Project, error)
+	// FindByMember finds all projects the user is a member of ordered by their name.
+	// It returns an empty slice if the user is not a member of any project and persistence.ErrReadRow for any other error.
+	FindByMember(ctx context.Context, userID uuid.UUID) ([]*Project, error)
+	// Create creates a new project with its creator as owner and returns it. It returns persistence.ErrInsert if the project could not be inserted.
+	Create(ctx context.Context, toCreate *ToCreate) (*Project, error)
+	// Update updates an existing project's name and description. It returns persistence.ErrUpdate if the project could not be updated.
+	Update(ctx context.Context, toUpdate *ToUpdate) error
+	// Delete deletes a project by its id. The project's requirements are kept without a project.
+	// It returns persistence.ErrDelete if the project could not be deleted.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// SaveMember adds the user to the project or changes the role of an existing member.
+	// It returns persistence.ErrInsert if the member could not be saved.
+	SaveMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, role Role) error
+	// RemoveMember removes the user from the project. It returns persistence.ErrDelete if the member could not be removed.
+	RemoveMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) error
+	// AddTemplateSet associates the template set with the project, associating it again has no effect.
+	// It returns persistence.ErrInsert if the template set could not be associated.
+	AddTemplateSet(ctx context.Context, projectID uuid.UUID, templateSetID uuid.UUID) error
+	// RemoveTemplateSet removes the association of the template set with the project.
+	// It returns persistence.ErrDelete if the association could not be removed.
+	RemoveTemplateSet(ctx context.Context, projectID uuid.UUID, templateSetID uuid.UUID) error
+}
+
+// PGRepository is the project repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &PGRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// FindByID finds a project the user is a member of by its id.
+// It returns persistence.ErrNotFound if the project could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Project, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	project, err := persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		`SELECT `+persistence.QualifyColumns("p", projectColumns)+` FROM projects p JOIN project_members m ON m.project_id = p.id
+		WHERE p.id = $1 AND m.user_id = $2 AND p.tenant_id = $3`,
+		id, userID, tenant.ID(ctx),
+	), scanProject)
+	if err != nil {
+		return nil, err
+	}
+
+	return project, r.loadDetails(ctx, []*Project{project})
+}
+
+// FindByMember finds all projects the user is a member of ordered by their name.
+// It returns an empty slice if the user is not a member of any project and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByMember(ctx context.Context, userID uuid.UUID) ([]*Project, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT `+persistence.QualifyColumns("p", projectColumns)+` FROM projects p JOIN project_members m ON m.project_id = p.id
+		WHERE m.user_id = $1 AND p.tenant_id = $2 ORDER BY p.name`,
+		userID, tenant.ID(ctx),
+	)
+	projects, err := persistence.PGCollectRows(rows, err, scanProject)
+	if err != nil {
+		return nil, err
+	}
+
+	return projects, r.loadDetails(ctx, projects)
+}
+
+// Create creates a new project with its creator as owner and returns it. It returns persistence.ErrInsert if the project could not be inserted.
+func (r *PGRepository) Create(ctx context.Context, toCreate *ToCreate) (*Project, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	project := &Project{
+		ID:          uuid.New(),
+		Name:        toCreate.Name,
+		Description: toCreate.Description,
+		CreatedBy:   toCreate.CreatedBy,
+	}
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		err := tx.QueryRow(
+			ctx,
+			"INSERT INTO projects (id, name, description, created_by, tenant_id) VALUES ($1, $2, $3, $4, $5) RETURNING created_at",
+			project.ID, project.Name, project.Description, project.CreatedBy, tenant.ID(ctx),
+		).Scan(&project.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"INSERT INTO project_members (project_id, user_id, role) VALUES ($1, $2, $3)",
+			project.ID, project.CreatedBy, RoleOwner,
+		)
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return project, r.loadDetails(ctx, []*Project{project})
+}
+
+// Update updates an existing project's name and description. It returns persistence.ErrUpdate if the project could not be updated.
+func (r *PGRepository) Update(ctx context.Context, toUpdate *ToUpdate) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		"UPDATE projects SET name = $1, description = $2, updated_at = current_timestamp WHERE id = $3 AND tenant_id = $4",
+		toUpdate.Name, toUpdate.Description, toUpdate.ID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// Delete deletes a project by its id. The project's requirements are kept without a project.
+// It returns persistence.ErrDelete if the project could not be deleted.
+func (r *PGRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM projects WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// SaveMember adds the user to the project or changes the role of an existing member.
+// It returns persistence.ErrInsert if the member could not be saved.
+func (r *PGRepository) SaveMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, role Role) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		`INSERT INTO project_members (project_id, user_id, role)
+		SELECT id, $2, $3 FROM projects WHERE id = $1 AND tenant_id = $4
+		ON CONFLICT (project_id, user_id) DO UPDATE SET role = excluded.role`,
+		projectID, userID, role, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// RemoveMember removes the user from the project. It returns persistence.ErrDelete if the member could not be removed.
+func (r *PGRepository) RemoveMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		`DELETE FROM project_members m USING projects p
+		WHERE m.project_id = p.id AND m.project_id = $1 AND m.user_id = $2 AND p.tenant_id = $3`,
+		projectID, userID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// AddTemplateSet associates the template set with the project, associating it again has no effect.
+// It returns persistence.ErrInsert if the template set could not be associated.
+func (r *PGRepository) AddTemplateSet(ctx context.Context, projectID uuid.UUID, templateSetID uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		`INSERT INTO project_template_sets (project_id, template_set)
+		SELECT id, $2 FROM projects WHERE id = $1 AND tenant_id = $3
+		ON CONFLICT DO NOTHING`,
+		projectID, templateSetID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// RemoveTemplateSet removes the association of the template set with the project.
+// It returns persistence.ErrDelete if the association could not be removed.
+func (r *PGRepository) RemoveTemplateSet(ctx context.Context, projectID uuid.UUID, templateSetID uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		`DELETE FROM project_template_sets s USING projects p
+		WHERE s.project_id = p.id AND s.project_id = $1 AND s.template_set = $2 AND p.tenant_id = $3`,
+		projectID, templateSetID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// loadDetails loads the members and template sets of the projects. It returns persistence.ErrReadRow if they could not be read.
+func (r *PGRepository) loadDetails(ctx context.Context, projects []*Project) error {
+	if len(projects) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*Project, len(projects))
+	ids := make([]uuid.UUID, 0, len(projects))
+	for _, project := range projects {
+		byID[project.ID] = project
+		ids = append(ids, project.ID)
+	}
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT m.project_id, m.user_id, u.email, u.firstname, u.lastname, m.role FROM project_members m JOIN users u ON u.id = m.user_id
+		WHERE m.project_id = ANY($1) ORDER BY m.role = $2 DESC, u.email`,
+		ids, RoleOwner,
+	)
+	if err != nil {
+		return persistence.PGReadErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID uuid.UUID
+		var member Member
+		if err := rows.Scan(&projectID, &member.UserID, &member.Email, &member.Firstname, &member.Lastname, &member.Role); err != nil {
+			return persistence.PGReadErr(err)
+		}
+
+		byID[projectID].Members = append(byID[projectID].Members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return persistence.PGReadErr(err)
+	}
+
+	rows, err = r.db.Query(ctx, "SELECT project_id, template_set FROM project_template_sets WHERE project_id = ANY($1)", ids)
+	if err != nil {
+		return persistence.PGReadErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID, templateSetID uuid.UUID
+		if err := rows.Scan(&projectID, &templateSetID); err != nil {
+			return persistence.PGReadErr(err)
+		}
+
+		byID[projectID].TemplateSets = append(byID[projectID].TemplateSets, templateSetID)
+	}
+	if err := rows.Err(); err != nil {
+		return persistence.PGReadErr(err)
+	}
+
+	return nil
+}
+
+// scanProject scans a row containing the projectColumns into a new Project.
+func scanProject(row pgx.Row) (*Project, error) {
+	p := &Project{}
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedBy, &p.CreatedAt, &p.UpdatedAt)
+
+	return p, err
+}
@@ -0,0 +1,109 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"slices"
+)
+
+// CurrentPreferenceKey is the key of the user preference storing the id of the user's current project, see Current.
+const CurrentPreferenceKey = "project.current"
+
+// Role returns the role of the user in the project and true if the user is a member of the project.
+func (p *Project) Role(userID uuid.UUID) (Role, bool) {
+	for _, member := range p.Members {
+		if member.UserID == userID {
+			return member.Role, true
+		}
+	}
+
+	return "", false
+}
+
+// IsMember returns true if the user is a member of the project regardless of the user's role.
+func (p *Project) IsMember(userID uuid.UUID) bool {
+	_, ok := p.Role(userID)
+	return ok
+}
+
+// IsOwner returns true if the user is an owner of the project.
+func (p *Project) IsOwner(userID uuid.UUID) bool {
+	role, _ := p.Role(userID)
+	return role == RoleOwner
+}
+
+// Owners returns the number of the project's owners.
+func (p *Project) Owners() int {
+	owners := 0
+	for _, member := range p.Members {
+		if member.Role == RoleOwner {
+			owners++
+		}
+	}
+
+	return owners
+}
+
+// CanRemove returns true if the user can be removed from the project or demoted to a member without leaving the project without an owner.
+func (p *Project) CanRemove(userID uuid.UUID) bool {
+	return p.IsMember(userID) && (!p.IsOwner(userID) || p.Owners() > 1)
+}
+
+// HasTemplateSet returns true if the template set is associated with the project.
+func (p *Project) HasTemplateSet(templateSetID uuid.UUID) bool {
+	return slices.Contains(p.TemplateSets, templateSetID)
+}
+
+// ParseRole returns the Role of the string and true if it is a known role.
+func ParseRole(s string) (Role, bool) {
+	switch role := Role(s); role {
+	case RoleOwner, RoleMember:
+		return role, true
+	default:
+		return "", false
+	}
+}
+
+// Current returns the user's current project. The current project scopes the user's work: requirements are elicited in it
+// and the user's requirements are filtered by it. Nil is returned if the user has no current project or is no longer a member of it.
+func Current(ctx context.Context, preferences user.PreferenceRepository, repository Repository, userID uuid.UUID) (*Project, error) {
+	var id uuid.UUID
+	err := preferences.Find(ctx, userID, CurrentPreferenceKey, &id)
+	if errors.Is(err, persistence.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := repository.FindByID(ctx, userID, id)
+	if errors.Is(err, persistence.ErrNotFound) {
+		return nil, nil
+	}
+
+	return project, err
+}
+
+// CurrentID returns the id of the user's current project (see Current) or uuid.Nil if the user has no current project
+// or it could not be read. It is meant for scoping the user's work, e.g. by setting requirement.Filter.Project.
+func CurrentID(ctx context.Context, preferences user.PreferenceRepository, repository Repository, userID uuid.UUID) uuid.UUID {
+	project, err := Current(ctx, preferences, repository, userID)
+	if err != nil || project == nil {
+		return uuid.Nil
+	}
+
+	return project.ID
+}
+
+// SetCurrent makes the project the user's current project. The caller is responsible for checking the user is a member of the project.
+func SetCurrent(ctx context.Context, preferences user.PreferenceRepository, userID uuid.UUID, projectID uuid.UUID) error {
+	return preferences.Save(ctx, userID, CurrentPreferenceKey, projectID)
+}
+
+// ClearCurrent removes the user's current project, the user's work is no longer scoped to a project.
+func ClearCurrent(ctx context.Context, preferences user.PreferenceRepository, userID uuid.UUID) error {
+	return preferences.Delete(ctx, userID, CurrentPreferenceKey)
+}
@@ -0,0 +1,116 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type preferencesMock struct {
+	user.PreferenceRepository
+	values map[string][]byte
+}
+
+type repositoryMock struct {
+	Repository
+	projects []*Project
+}
+
+func TestProjectRoles(t *testing.T) {
+	owner, member, other := uuid.New(), uuid.New(), uuid.New()
+	templateSet := uuid.New()
+	project := &Project{
+		Members:      []Member{{UserID: owner, Role: RoleOwner}, {UserID: member, Role: RoleMember}},
+		TemplateSets: []uuid.UUID{templateSet},
+	}
+
+	assert.True(t, project.IsOwner(owner))
+	assert.True(t, project.IsMember(owner))
+	assert.False(t, project.IsOwner(member))
+	assert.True(t, project.IsMember(member))
+	assert.False(t, project.IsMember(other))
+	assert.Equal(t, 1, project.Owners())
+
+	assert.False(t, project.CanRemove(owner), "the last owner can not be removed")
+	assert.True(t, project.CanRemove(member))
+	assert.False(t, project.CanRemove(other))
+
+	project.Members[1].Role = RoleOwner
+	assert.True(t, project.CanRemove(owner))
+
+	assert.True(t, project.HasTemplateSet(templateSet))
+	assert.False(t, project.HasTemplateSet(uuid.New()))
+
+	role, ok := ParseRole("owner")
+	assert.True(t, ok)
+	assert.Equal(t, RoleOwner, role)
+	_, ok = ParseRole("admin")
+	assert.False(t, ok)
+}
+
+func TestCurrent(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	project := &Project{ID: uuid.New(), Members: []Member{{UserID: userID, Role: RoleMember}}}
+	preferences := &preferencesMock{values: map[string][]byte{}}
+	repository := &repositoryMock{projects: []*Project{project}}
+
+	current, err := Current(ctx, preferences, repository, userID)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+	assert.Equal(t, uuid.Nil, CurrentID(ctx, preferences, repository, userID))
+
+	require.NoError(t, SetCurrent(ctx, preferences, userID, project.ID))
+	current, err = Current(ctx, preferences, repository, userID)
+	require.NoError(t, err)
+	assert.Equal(t, project, current)
+	assert.Equal(t, project.ID, CurrentID(ctx, preferences, repository, userID))
+
+	current, err = Current(ctx, preferences, repository, uuid.New())
+	require.NoError(t, err)
+	assert.Nil(t, current, "users without the preference have no current project")
+
+	repository.projects = nil
+	current, err = Current(ctx, preferences, repository, userID)
+	require.NoError(t, err)
+	assert.Nil(t, current, "projects the user is no longer a member of are not current")
+
+	require.NoError(t, ClearCurrent(ctx, preferences, userID))
+	assert.Empty(t, preferences.values)
+}
+
+func (p *preferencesMock) Find(ctx context.Context, userID uuid.UUID, key string, v any) error {
+	value, ok := p.values[userID.String()+key]
+	if !ok {
+		return persistence.ErrNotFound
+	}
+
+	return json.Unmarshal(value, v)
+}
+
+func (p *preferencesMock) Save(ctx context.Context, userID uuid.UUID, key string, v any) error {
+	value, err := json.Marshal(v)
+	p.values[userID.String()+key] = value
+
+	return err
+}
+
+func (p *preferencesMock) Delete(ctx context.Context, userID uuid.UUID, key string) error {
+	delete(p.values, userID.String()+key)
+	return nil
+}
+
+func (r *repositoryMock) FindByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Project, error) {
+	for _, project := range r.projects {
+		if project.ID == id && project.IsMember(userID) {
+			return project, nil
+		}
+	}
+
+	return nil, persistence.ErrNotFound
+}
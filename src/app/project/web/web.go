@@ -0,0 +1,542 @@
+package web
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/project"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// DashboardRequirements is the number of the newest requirements of all members shown on the project dashboard.
+const DashboardRequirements = 20
+
+var (
+	// ErrInvalidID is returned if an id of the request is not a valid UUID.
+	ErrInvalidID = errors.New("invalid project id")
+	// ErrNotPermitted is returned if the user's role in the project does not permit the action.
+	ErrNotPermitted = errors.New("user not permitted")
+	// ErrUserNotFound is displayed to the user if no user with the email of a new member exists.
+	ErrUserNotFound = errors.New("project.member.not-found")
+	// ErrLastOwner is displayed to the user if removing or demoting a member would leave the project without an owner.
+	ErrLastOwner = errors.New("project.member.last-owner")
+)
+
+// ListData is the data of the project list: the user's projects and the form creating a new project.
+type ListData struct {
+	Projects []*project.Project
+	// Current is the id of the user's current project, uuid.Nil if there is none.
+	Current uuid.UUID
+	UserID  uuid.UUID
+	Form    *web.Form[*project.ToCreate]
+}
+
+// DashboardData is the data of a project's dashboard.
+type DashboardData struct {
+	Project *project.Project
+	UserID  uuid.UUID
+	// Current is true if the project is the user's current project.
+	Current bool
+	// TemplateSets are the template sets associated with the project.
+	TemplateSets []*template.Set
+	// AvailableTemplateSets are the user's template sets not yet associated with the project.
+	AvailableTemplateSets []*template.Set
+	// Requirements are the newest requirements elicited in the project by any member, see DashboardRequirements.
+	Requirements []*requirement.Requirement
+	// Form edits the project, it is nil unless the user is an owner of the project.
+	Form *web.Form[*project.ToUpdate]
+}
+
+// RegisterController registers the controllers of the project module:
+//   - GET /project Renders the user's projects and the form creating a new project.
+//   - POST /project Creates a new project owned by the user.
+//   - GET /project/{id} Renders the project's dashboard.
+//   - PUT /project/{id} Updates the project's name and description (owners only).
+//   - DELETE /project/{id} Deletes the project (owners only).
+//   - PUT /project/{id}/current Makes the project the user's current project.
+//   - DELETE /project/current Removes the user's current project.
+//   - POST /project/{id}/member Adds the user with the email (email) to the project with the role (role) or changes its role (owners only).
+//   - DELETE /project/{id}/member/{userID} Removes the member from the project (owners only).
+//   - POST /project/{id}/template-set Associates the user's template set (template-set) with the project.
+//   - DELETE /project/{id}/template-set/{templateSetID} Removes the template set from the project (owners and the template set's creator only).
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	registerNavigation(webCtx)
+	webCtx.Errors.Map(ErrInvalidID, http.StatusNotFound, web.ErrNotFound)
+	webCtx.Errors.Map(ErrNotPermitted, http.StatusForbidden, web.ErrForbidden)
+	webCtx.Errors.Map(ErrUserNotFound, http.StatusUnprocessableEntity, ErrUserNotFound)
+	webCtx.Errors.Map(ErrLastOwner, http.StatusUnprocessableEntity, ErrLastOwner)
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/project", listController(appCtx, webCtx).ServeHTTP)
+	router.Post("/project", createController(appCtx, webCtx).ServeHTTP)
+	router.Delete("/project/current", clearCurrentController(appCtx, webCtx).ServeHTTP)
+	router.Get("/project/{id}", dashboardController(appCtx, webCtx).ServeHTTP)
+	router.Put("/project/{id}", updateController(appCtx, webCtx).ServeHTTP)
+	router.Delete("/project/{id}", deleteController(appCtx, webCtx).ServeHTTP)
+	router.Put("/project/{id}/current", currentController(appCtx, webCtx).ServeHTTP)
+	router.Post("/project/{id}/member", saveMemberController(appCtx, webCtx).ServeHTTP)
+	router.Delete("/project/{id}/member/{userID}", removeMemberController(appCtx, webCtx).ServeHTTP)
+	router.Post("/project/{id}/template-set", addTemplateSetController(appCtx, webCtx).ServeHTTP)
+	router.Delete("/project/{id}/template-set/{templateSetID}", removeTemplateSetController(appCtx, webCtx).ServeHTTP)
+}
+
+// ProjectFromParams returns the project of the URL parameter if the user is a member of it. It returns ErrInvalidID
+// if the parameter is not a valid UUID and persistence.ErrNotFound if the project does not exist or the user is not a member of it.
+func ProjectFromParams(io web.IO, repository project.Repository, param string) (*project.Project, error) {
+	id, err := uuid.Parse(web.URLParam(io.Request(), param))
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	return repository.FindByID(io.Context(), user.MustFromIO(io).ID, id)
+}
+
+// HasCurrent returns true if the user has a current project.
+func (d *ListData) HasCurrent() bool {
+	return d.Current != uuid.Nil
+}
+
+func registerNavigation(webCtx *web.Ctx) {
+	webCtx.Navigation.Add("project.list", web.NavItem{
+		URL:            "/project",
+		Name:           "harmony.menu.projects",
+		Position:       90,
+		ActivePrefixes: []string{"/project/"},
+	})
+}
+
+func listController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		data, err := listData(io, newForm(&project.ToCreate{}))
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		return renderListPage(io, data)
+	})
+}
+
+func createController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		form := newForm(&project.ToCreate{CreatedBy: user.MustFromIO(io).ID})
+
+		return web.HandleForm(io, appCtx.Validator, form, func(form *web.Form[*project.ToCreate]) error {
+			data, err := listData(io, form)
+			if err != nil {
+				return io.Error(web.ErrInternal, err)
+			}
+
+			return renderListPage(io, data)
+		}, func(form *web.Form[*project.ToCreate]) error {
+			p, err := projectRepository.Create(io.Context(), form.Form)
+			if err != nil {
+				return err
+			}
+
+			return io.Redirect("/project/"+p.ID.String(), http.StatusFound)
+		})
+	})
+}
+
+func dashboardController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		p, err := ProjectFromParams(io, projectRepository, "id")
+		if err != nil {
+			return io.Error(notFound(err)...)
+		}
+
+		data, err := dashboardData(io, p)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		return io.Render(data, "project.dashboard.page", "project/dashboard-page.go.html", "project/_dashboard.go.html")
+	})
+}
+
+func updateController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		p, err := ProjectFromParams(io, projectRepository, "id")
+		if err != nil {
+			return io.InlineError(notFound(err)...)
+		}
+		if !p.IsOwner(user.MustFromIO(io).ID) {
+			return io.InlineError(ErrNotPermitted)
+		}
+
+		form := editForm(&project.ToUpdate{ID: p.ID}, nil)
+		render := func(form *web.Form[*project.ToUpdate]) error {
+			return io.Render(form, "project.edit.form", "project/_dashboard.go.html")
+		}
+
+		return web.HandleForm(io, appCtx.Validator, form, render, func(form *web.Form[*project.ToUpdate]) error {
+			if err := projectRepository.Update(io.Context(), form.Form); err != nil {
+				return err
+			}
+
+			return render(editForm(form.Form, []string{"project.edit.updated"}))
+		})
+	})
+}
+
+func deleteController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		p, err := ProjectFromParams(io, projectRepository, "id")
+		if err != nil {
+			return io.InlineError(notFound(err)...)
+		}
+		if !p.IsOwner(user.MustFromIO(io).ID) {
+			return io.InlineError(ErrNotPermitted)
+		}
+
+		if err := projectRepository.Delete(io.Context(), p.ID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		data, err := listData(io, newForm(&project.ToCreate{}))
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return io.Render(data, "project.list", "project/_list.go.html")
+	})
+}
+
+// currentController makes the project the user's current project and refreshes the page as the navigation and the scoped pages change.
+func currentController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		p, err := ProjectFromParams(io, projectRepository, "id")
+		if err != nil {
+			return io.InlineError(notFound(err)...)
+		}
+
+		if err := project.SetCurrent(io.Context(), preferences, user.MustFromIO(io).ID, p.ID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		io.Response().Header().Set("HX-Refresh", "true")
+		return nil
+	})
+}
+
+func clearCurrentController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	preferences := util.UnwrapType[user.PreferenceRepository](appCtx.Repository(user.PreferenceRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		if err := project.ClearCurrent(io.Context(), preferences, user.MustFromIO(io).ID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		io.Response().Header().Set("HX-Refresh", "true")
+		return nil
+	})
+}
+
+func saveMemberController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+	userRepository := util.UnwrapType[user.Repository](appCtx.Repository(user.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ctx := io.Context()
+		usr := user.MustFromIO(io)
+
+		p, err := ProjectFromParams(io, projectRepository, "id")
+		if err != nil {
+			return io.InlineError(notFound(err)...)
+		}
+		if !p.IsOwner(usr.ID) {
+			return io.InlineError(ErrNotPermitted)
+		}
+
+		role, ok := project.ParseRole(io.Request().FormValue("role"))
+		if !ok {
+			role = project.RoleMember
+		}
+
+		member, err := userRepository.FindByEmail(ctx, strings.TrimSpace(io.Request().FormValue("email")))
+		if err != nil && errors.Is(err, persistence.ErrNotFound) {
+			return io.InlineError(ErrUserNotFound, err)
+		} else if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		if role != project.RoleOwner && p.IsOwner(member.ID) && !p.CanRemove(member.ID) {
+			return io.InlineError(ErrLastOwner)
+		}
+
+		if err := projectRepository.SaveMember(ctx, p.ID, member.ID, role); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderMembers(io, projectRepository, p.ID)
+	})
+}
+
+func removeMemberController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		p, err := ProjectFromParams(io, projectRepository, "id")
+		if err != nil {
+			return io.InlineError(notFound(err)...)
+		}
+		if !p.IsOwner(user.MustFromIO(io).ID) {
+			return io.InlineError(ErrNotPermitted)
+		}
+
+		memberID, err := uuid.Parse(web.URLParam(io.Request(), "userID"))
+		if err != nil {
+			return io.InlineError(ErrInvalidID, err)
+		}
+		if !p.CanRemove(memberID) {
+			return io.InlineError(ErrLastOwner)
+		}
+
+		if err := projectRepository.RemoveMember(io.Context(), p.ID, memberID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderMembers(io, projectRepository, p.ID)
+	})
+}
+
+func addTemplateSetController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ctx := io.Context()
+
+		p, err := ProjectFromParams(io, projectRepository, "id")
+		if err != nil {
+			return io.InlineError(notFound(err)...)
+		}
+
+		templateSetID, err := uuid.Parse(io.Request().FormValue("template-set"))
+		if err != nil {
+			return io.InlineError(ErrInvalidID, err)
+		}
+
+		templateSet, err := templateSetRepository.FindByID(ctx, templateSetID)
+		if err != nil {
+			return io.InlineError(notFound(err)...)
+		}
+		if templateSet.CreatedBy != user.MustFromIO(io).ID {
+			return io.InlineError(ErrNotPermitted)
+		}
+
+		if err := projectRepository.AddTemplateSet(ctx, p.ID, templateSet.ID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderTemplateSets(io, projectRepository, p.ID)
+	})
+}
+
+func removeTemplateSetController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	projectRepository := util.UnwrapType[project.Repository](appCtx.Repository(project.RepositoryName))
+	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ctx := io.Context()
+		usr := user.MustFromIO(io)
+
+		p, err := ProjectFromParams(io, projectRepository, "id")
+		if err != nil {
+			return io.InlineError(notFound(err)...)
+		}
+
+		templateSetID, err := uuid.Parse(web.URLParam(io.Request(), "templateSetID"))
+		if err != nil {
+			return io.InlineError(ErrInvalidID, err)
+		}
+
+		if !p.IsOwner(usr.ID) {
+			templateSet, err := templateSetRepository.FindByID(ctx, templateSetID)
+			if err != nil {
+				return io.InlineError(notFound(err)...)
+			}
+			if templateSet.CreatedBy != usr.ID {
+				return io.InlineError(ErrNotPermitted)
+			}
+		}
+
+		if err := projectRepository.RemoveTemplateSet(ctx, p.ID, templateSetID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderTemplateSets(io, projectRepository, p.ID)
+	})
+}
+
+// listData reads the user's projects and current project for the project list.
+func listData(io web.IO, form *web.Form[*project.ToCreate]) (*ListData, error) {
+	ctx := io.Context()
+	usr := user.MustFromIO(io)
+	projectRepository := web.MustRepository[project.Repository](io, project.RepositoryName)
+	preferences := web.MustRepository[user.PreferenceRepository](io, user.PreferenceRepositoryName)
+
+	projects, err := projectRepository.FindByMember(ctx, usr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListData{
+		Projects: projects,
+		Current:  project.CurrentID(ctx, preferences, projectRepository, usr.ID),
+		UserID:   usr.ID,
+		Form:     form,
+	}, nil
+}
+
+// dashboardData reads the project's template sets, the user's template sets available for the project and the project's newest requirements.
+func dashboardData(io web.IO, p *project.Project) (*DashboardData, error) {
+	ctx := io.Context()
+	usr := user.MustFromIO(io)
+	projectRepository := web.MustRepository[project.Repository](io, project.RepositoryName)
+	requirementRepository := web.MustRepository[requirement.Repository](io, requirement.RepositoryName)
+	preferences := web.MustRepository[user.PreferenceRepository](io, user.PreferenceRepositoryName)
+
+	data := &DashboardData{
+		Project: p,
+		UserID:  usr.ID,
+		Current: project.CurrentID(ctx, preferences, projectRepository, usr.ID) == p.ID,
+	}
+
+	var err error
+	data.TemplateSets, data.AvailableTemplateSets, err = templateSets(io, p)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Requirements, err = requirementRepository.FindByProject(ctx, p.ID, requirement.Filter{Limit: DashboardRequirements})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.IsOwner(usr.ID) {
+		data.Form = editForm(&project.ToUpdate{ID: p.ID, Name: p.Name, Description: p.Description}, nil)
+	}
+
+	return data, nil
+}
+
+// templateSets returns the template sets associated with the project and the user's template sets not yet associated with it.
+// Associated template sets that no longer exist are skipped.
+func templateSets(io web.IO, p *project.Project) ([]*template.Set, []*template.Set, error) {
+	ctx := io.Context()
+	templateSetRepository := web.MustRepository[template.SetRepository](io, template.SetRepositoryName)
+
+	var associated []*template.Set
+	for _, id := range p.TemplateSets {
+		templateSet, err := templateSetRepository.FindByID(ctx, id)
+		if errors.Is(err, persistence.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		associated = append(associated, templateSet)
+	}
+	slices.SortFunc(associated, func(a, b *template.Set) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	own, err := templateSetRepository.FindByCreatedBy(ctx, user.MustFromIO(io).ID)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	var available []*template.Set
+	for _, templateSet := range own {
+		if !p.HasTemplateSet(templateSet.ID) {
+			available = append(available, templateSet)
+		}
+	}
+
+	return associated, available, nil
+}
+
+// renderMembers re-reads the project and renders its members.
+func renderMembers(io web.IO, repository project.Repository, id uuid.UUID) error {
+	p, err := repository.FindByID(io.Context(), user.MustFromIO(io).ID, id)
+	if err != nil {
+		return io.InlineError(notFound(err)...)
+	}
+
+	return io.Render(&DashboardData{Project: p, UserID: user.MustFromIO(io).ID}, "project.members", "project/_dashboard.go.html")
+}
+
+// renderTemplateSets re-reads the project and renders its template sets.
+func renderTemplateSets(io web.IO, repository project.Repository, id uuid.UUID) error {
+	p, err := repository.FindByID(io.Context(), user.MustFromIO(io).ID, id)
+	if err != nil {
+		return io.InlineError(notFound(err)...)
+	}
+
+	associated, available, err := templateSets(io, p)
+	if err != nil {
+		return io.InlineError(web.ErrInternal, err)
+	}
+
+	return io.Render(&DashboardData{
+		Project:               p,
+		UserID:                user.MustFromIO(io).ID,
+		TemplateSets:          associated,
+		AvailableTemplateSets: available,
+	}, "project.template-sets", "project/_dashboard.go.html")
+}
+
+func renderListPage(io web.IO, data *ListData) error {
+	return io.Render(data, "project.list.page", "project/list-page.go.html", "project/_list.go.html")
+}
+
+// newForm declares the form creating a new project.
+func newForm(toCreate *project.ToCreate, validationErrs ...error) *web.Form[*project.ToCreate] {
+	return web.NewForm(web.Form[*project.ToCreate]{
+		ID:     "project-new-form",
+		Action: "/project",
+		Submit: "harmony.generic.create",
+	}, toCreate, nil, validationErrs...)
+}
+
+// editForm declares the form editing a project on its dashboard.
+func editForm(toUpdate *project.ToUpdate, success []string) *web.Form[*project.ToUpdate] {
+	return web.NewForm(web.Form[*project.ToUpdate]{
+		ID:     "project-edit-form",
+		Action: "/project/" + toUpdate.ID.String(),
+		Method: http.MethodPut,
+		HTMX:   true,
+		Submit: "harmony.generic.save",
+	}, toUpdate, success)
+}
+
+// notFound returns the errors to display if a project or template set could not be read:
+// ErrInvalidID and web.ErrNotFound for missing resources and web.ErrInternal otherwise.
+func notFound(err error) []error {
+	if errors.Is(err, ErrInvalidID) || errors.Is(err, persistence.ErrNotFound) {
+		return []error{web.ErrNotFound, err}
+	}
+
+	return []error{web.ErrInternal, err}
+}
@@ -0,0 +1,337 @@
+// Package reqif reads and writes requirements as documents in the Requirements Interchange Format (ReqIF).
+// ReqIF is the exchange format of requirements engineering tools such as IBM DOORS, Polarion or Capella.
+//
+// A ReqIF document contains spec objects (the requirements, headings, ...) with attribute values of the attributes
+// defined by the spec object's type. Which attributes contain the requirement's text and id differs between tools,
+// therefore a Mapping of attribute names is used to read requirements from the spec objects (see Document.Requirements).
+// Documents are imported as stored requirements by the requirements import wizard of the eiffel package (see eiffel.ReadImportReqIF).
+package reqif
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	// TextAttribute is the standard attribute name of a requirement's text as recommended by the ReqIF implementation guide.
+	TextAttribute = "ReqIF.Text"
+	// ForeignIDAttribute is the standard attribute name of a requirement's id in the tool that exported the document.
+	ForeignIDAttribute = "ReqIF.ForeignID"
+	// ChapterNameAttribute is the standard attribute name of a heading's text.
+	ChapterNameAttribute = "ReqIF.ChapterName"
+)
+
+// ErrInvalidDocument is returned if a document is not a valid ReqIF document.
+var ErrInvalidDocument = errors.New("invalid ReqIF document")
+
+// Document is a parsed ReqIF document.
+type Document struct {
+	// Title is the title of the document from its header. It is optional.
+	Title string
+	// Attributes are the names of all attributes defined by the document's spec object types, each name is contained once.
+	Attributes []string
+	// Objects are the spec objects of the document. They are ordered by the document's specifications (depth-first),
+	// spec objects not referenced by any specification follow in the order they are defined in.
+	Objects []*SpecObject
+}
+
+// SpecObject is a spec object of a ReqIF document, e.g. a requirement or a heading.
+type SpecObject struct {
+	// Identifier is the unique identifier of the spec object within the document.
+	Identifier string
+	// LongName is the optional display name of the spec object.
+	LongName string
+	// Type is the name of the spec object's type, e.g. "Requirement" or "Heading".
+	Type string
+	// Level is the depth of the spec object in the specification's hierarchy starting at 1.
+	// It is 0 if the spec object is not referenced by any specification.
+	Level int
+	// Values are the spec object's attribute values keyed by the attribute's name.
+	// XHTML values are converted to plain text and enumeration values are the names of the selected values separated by ", ".
+	Values map[string]string
+}
+
+// Mapping maps attributes of spec objects to the fields of a Requirement.
+type Mapping struct {
+	// ID is the name of the attribute containing the requirement's id. The spec object's identifier is used if it is empty or not set.
+	ID string
+	// Text is the name of the attribute containing the requirement's text.
+	Text string
+}
+
+// Requirement is a requirement read from a spec object using a Mapping.
+type Requirement struct {
+	ID     string
+	Text   string
+	Object *SpecObject
+}
+
+// xmlReqIF is the XML structure of a ReqIF document. Only the parts needed to read spec objects are unmarshalled.
+type xmlReqIF struct {
+	XMLName xml.Name   `xml:"REQ-IF"`
+	Title   string     `xml:"THE-HEADER>REQ-IF-HEADER>TITLE"`
+	Content xmlContent `xml:"CORE-CONTENT>REQ-IF-CONTENT"`
+}
+
+type xmlContent struct {
+	EnumValues     []xmlIdentifiable  `xml:"DATATYPES>DATATYPE-DEFINITION-ENUMERATION>SPECIFIED-VALUES>ENUM-VALUE"`
+	Types          []xmlSpecType      `xml:"SPEC-TYPES>SPEC-OBJECT-TYPE"`
+	Objects        []xmlSpecObject    `xml:"SPEC-OBJECTS>SPEC-OBJECT"`
+	Specifications []xmlSpecHierarchy `xml:"SPECIFICATIONS>SPECIFICATION"`
+}
+
+type xmlIdentifiable struct {
+	Identifier string `xml:"IDENTIFIER,attr"`
+	LongName   string `xml:"LONG-NAME,attr"`
+}
+
+type xmlSpecType struct {
+	xmlIdentifiable
+	Attributes struct {
+		Definitions []xmlIdentifiable `xml:",any"`
+	} `xml:"SPEC-ATTRIBUTES"`
+}
+
+type xmlSpecObject struct {
+	xmlIdentifiable
+	TypeRef string `xml:"TYPE>SPEC-OBJECT-TYPE-REF"`
+	Values  struct {
+		Values []xmlValue `xml:",any"`
+	} `xml:"VALUES"`
+}
+
+type xmlValue struct {
+	XMLName    xml.Name
+	TheValue   string   `xml:"THE-VALUE,attr"`
+	Definition xmlRef   `xml:"DEFINITION"`
+	XHTML      xmlInner `xml:"THE-VALUE"`
+	EnumRefs   []string `xml:"VALUES>ENUM-VALUE-REF"`
+}
+
+type xmlRef struct {
+	Ref string `xml:",any"`
+}
+
+type xmlInner struct {
+	Content string `xml:",innerxml"`
+}
+
+type xmlSpecHierarchy struct {
+	ObjectRef string             `xml:"OBJECT>SPEC-OBJECT-REF"`
+	Children  []xmlSpecHierarchy `xml:"CHILDREN>SPEC-HIERARCHY"`
+}
+
+// Parse reads a ReqIF document. It returns ErrInvalidDocument if the document could not be parsed.
+func Parse(r io.Reader) (*Document, error) {
+	var raw xmlReqIF
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, errors.Join(ErrInvalidDocument, err)
+	}
+
+	content := raw.Content
+	enumNames := make(map[string]string, len(content.EnumValues))
+	for _, enumValue := range content.EnumValues {
+		enumNames[enumValue.Identifier] = enumValue.name()
+	}
+
+	doc := &Document{Title: strings.TrimSpace(raw.Title)}
+	typeNames := make(map[string]string, len(content.Types))
+	attributeNames := make(map[string]string)
+	for _, specType := range content.Types {
+		typeNames[specType.Identifier] = specType.name()
+		for _, attribute := range specType.Attributes.Definitions {
+			name := attribute.name()
+			attributeNames[attribute.Identifier] = name
+			if !slices.Contains(doc.Attributes, name) {
+				doc.Attributes = append(doc.Attributes, name)
+			}
+		}
+	}
+
+	objects := make(map[string]*SpecObject, len(content.Objects))
+	order := make([]string, 0, len(content.Objects))
+	for _, raw := range content.Objects {
+		if raw.Identifier == "" {
+			return nil, errors.Join(ErrInvalidDocument, errors.New("spec object without identifier"))
+		}
+
+		object := &SpecObject{
+			Identifier: raw.Identifier,
+			LongName:   raw.LongName,
+			Type:       typeNames[raw.TypeRef],
+			Values:     make(map[string]string, len(raw.Values.Values)),
+		}
+
+		for _, value := range raw.Values.Values {
+			name, ok := attributeNames[value.Definition.Ref]
+			if !ok {
+				name = value.Definition.Ref
+			}
+
+			text, err := value.text(enumNames)
+			if err != nil {
+				return nil, errors.Join(ErrInvalidDocument, fmt.Errorf("spec object %s: %w", raw.Identifier, err))
+			}
+
+			object.Values[name] = text
+		}
+
+		objects[object.Identifier] = object
+		order = append(order, object.Identifier)
+	}
+
+	added := make(map[string]bool, len(objects))
+	var walk func(hierarchies []xmlSpecHierarchy, level int)
+	walk = func(hierarchies []xmlSpecHierarchy, level int) {
+		for _, hierarchy := range hierarchies {
+			if object, ok := objects[hierarchy.ObjectRef]; ok && !added[object.Identifier] {
+				object.Level = level
+				doc.Objects = append(doc.Objects, object)
+				added[object.Identifier] = true
+			}
+
+			walk(hierarchy.Children, level+1)
+		}
+	}
+	for _, specification := range content.Specifications {
+		walk(specification.Children, 1)
+	}
+
+	for _, identifier := range order {
+		if !added[identifier] {
+			doc.Objects = append(doc.Objects, objects[identifier])
+		}
+	}
+
+	return doc, nil
+}
+
+// DefaultMapping returns the mapping of the standard attributes (ForeignIDAttribute and TextAttribute) if the document defines them.
+// Otherwise, the spec object's identifier is used as the requirement's id and the first attribute defined is used as its text.
+func (d *Document) DefaultMapping() Mapping {
+	mapping := Mapping{}
+	if slices.Contains(d.Attributes, ForeignIDAttribute) {
+		mapping.ID = ForeignIDAttribute
+	}
+
+	switch {
+	case slices.Contains(d.Attributes, TextAttribute):
+		mapping.Text = TextAttribute
+	case len(d.Attributes) > 0:
+		mapping.Text = d.Attributes[0]
+	}
+
+	return mapping
+}
+
+// Requirements reads the requirements from the document's spec objects using the mapping.
+// Spec objects without a text, e.g. headings, are skipped.
+func (d *Document) Requirements(mapping Mapping) []Requirement {
+	requirements := make([]Requirement, 0, len(d.Objects))
+	for _, object := range d.Objects {
+		text := strings.TrimSpace(object.Values[mapping.Text])
+		if text == "" {
+			continue
+		}
+
+		id := strings.TrimSpace(object.Values[mapping.ID])
+		if id == "" {
+			id = object.Identifier
+		}
+
+		requirements = append(requirements, Requirement{ID: id, Text: text, Object: object})
+	}
+
+	return requirements
+}
+
+// name returns the long name or the identifier if no long name is set.
+func (i xmlIdentifiable) name() string {
+	if i.LongName != "" {
+		return i.LongName
+	}
+
+	return i.Identifier
+}
+
+// text returns the value as plain text. XHTML values are converted to text and enumeration references are resolved to their names.
+func (v xmlValue) text(enumNames map[string]string) (string, error) {
+	switch v.XMLName.Local {
+	case "ATTRIBUTE-VALUE-XHTML":
+		return xhtmlText(v.XHTML.Content)
+	case "ATTRIBUTE-VALUE-ENUMERATION":
+		names := make([]string, 0, len(v.EnumRefs))
+		for _, ref := range v.EnumRefs {
+			name, ok := enumNames[ref]
+			if !ok {
+				name = ref
+			}
+			names = append(names, name)
+		}
+
+		return strings.Join(names, ", "), nil
+	default:
+		return v.TheValue, nil
+	}
+}
+
+// xhtmlText converts the XHTML content of a value to plain text. Block elements and line breaks are converted to new lines,
+// whitespace within a line is collapsed.
+func xhtmlText(content string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	var text bytes.Buffer
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			if isLineBreak(t.Name.Local) {
+				text.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if isLineBreak(t.Name.Local) {
+				text.WriteByte('\n')
+			}
+		}
+	}
+
+	lines := strings.Split(text.String(), "\n")
+	cleaned := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			cleaned = append(cleaned, line)
+		}
+	}
+
+	return strings.Join(cleaned, "\n"), nil
+}
+
+// isLineBreak returns true if the XHTML element starts or ends a line.
+func isLineBreak(element string) bool {
+	switch element {
+	case "br", "p", "div", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,161 @@
+package reqif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+const testDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<REQ-IF xmlns="http://www.omg.org/spec/ReqIF/20110401/reqif.xsd" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <THE-HEADER>
+    <REQ-IF-HEADER IDENTIFIER="header">
+      <TITLE>Coffee Machine</TITLE>
+    </REQ-IF-HEADER>
+  </THE-HEADER>
+  <CORE-CONTENT>
+    <REQ-IF-CONTENT>
+      <DATATYPES>
+        <DATATYPE-DEFINITION-STRING IDENTIFIER="string" MAX-LENGTH="255"/>
+        <DATATYPE-DEFINITION-XHTML IDENTIFIER="xhtml"/>
+        <DATATYPE-DEFINITION-ENUMERATION IDENTIFIER="priority">
+          <SPECIFIED-VALUES>
+            <ENUM-VALUE IDENTIFIER="high" LONG-NAME="High"/>
+            <ENUM-VALUE IDENTIFIER="low" LONG-NAME="Low"/>
+          </SPECIFIED-VALUES>
+        </DATATYPE-DEFINITION-ENUMERATION>
+      </DATATYPES>
+      <SPEC-TYPES>
+        <SPEC-OBJECT-TYPE IDENTIFIER="requirement-type" LONG-NAME="Requirement">
+          <SPEC-ATTRIBUTES>
+            <ATTRIBUTE-DEFINITION-STRING IDENTIFIER="foreign-id" LONG-NAME="ReqIF.ForeignID"/>
+            <ATTRIBUTE-DEFINITION-XHTML IDENTIFIER="text" LONG-NAME="ReqIF.Text"/>
+            <ATTRIBUTE-DEFINITION-ENUMERATION IDENTIFIER="prio" LONG-NAME="Priority"/>
+          </SPEC-ATTRIBUTES>
+        </SPEC-OBJECT-TYPE>
+        <SPEC-OBJECT-TYPE IDENTIFIER="heading-type" LONG-NAME="Heading">
+          <SPEC-ATTRIBUTES>
+            <ATTRIBUTE-DEFINITION-STRING IDENTIFIER="chapter" LONG-NAME="ReqIF.ChapterName"/>
+          </SPEC-ATTRIBUTES>
+        </SPEC-OBJECT-TYPE>
+      </SPEC-TYPES>
+      <SPEC-OBJECTS>
+        <SPEC-OBJECT IDENTIFIER="unreferenced">
+          <TYPE><SPEC-OBJECT-TYPE-REF>requirement-type</SPEC-OBJECT-TYPE-REF></TYPE>
+          <VALUES>
+            <ATTRIBUTE-VALUE-XHTML>
+              <DEFINITION><ATTRIBUTE-DEFINITION-XHTML-REF>text</ATTRIBUTE-DEFINITION-XHTML-REF></DEFINITION>
+              <THE-VALUE><xhtml:div>The machine should be quiet.</xhtml:div></THE-VALUE>
+            </ATTRIBUTE-VALUE-XHTML>
+          </VALUES>
+        </SPEC-OBJECT>
+        <SPEC-OBJECT IDENTIFIER="req-1" LONG-NAME="Brewing">
+          <TYPE><SPEC-OBJECT-TYPE-REF>requirement-type</SPEC-OBJECT-TYPE-REF></TYPE>
+          <VALUES>
+            <ATTRIBUTE-VALUE-STRING THE-VALUE="REQ-1">
+              <DEFINITION><ATTRIBUTE-DEFINITION-STRING-REF>foreign-id</ATTRIBUTE-DEFINITION-STRING-REF></DEFINITION>
+            </ATTRIBUTE-VALUE-STRING>
+            <ATTRIBUTE-VALUE-XHTML>
+              <DEFINITION><ATTRIBUTE-DEFINITION-XHTML-REF>text</ATTRIBUTE-DEFINITION-XHTML-REF></DEFINITION>
+              <THE-VALUE>
+                <xhtml:div>
+                  <xhtml:p>The machine must brew   coffee&nbsp;within <xhtml:b>60 seconds</xhtml:b>.</xhtml:p>
+                  <xhtml:p>The user can choose &lt;strong&gt; coffee.</xhtml:p>
+                </xhtml:div>
+              </THE-VALUE>
+            </ATTRIBUTE-VALUE-XHTML>
+            <ATTRIBUTE-VALUE-ENUMERATION>
+              <DEFINITION><ATTRIBUTE-DEFINITION-ENUMERATION-REF>prio</ATTRIBUTE-DEFINITION-ENUMERATION-REF></DEFINITION>
+              <VALUES><ENUM-VALUE-REF>high</ENUM-VALUE-REF></VALUES>
+            </ATTRIBUTE-VALUE-ENUMERATION>
+          </VALUES>
+        </SPEC-OBJECT>
+        <SPEC-OBJECT IDENTIFIER="heading-1">
+          <TYPE><SPEC-OBJECT-TYPE-REF>heading-type</SPEC-OBJECT-TYPE-REF></TYPE>
+          <VALUES>
+            <ATTRIBUTE-VALUE-STRING THE-VALUE="Brewing">
+              <DEFINITION><ATTRIBUTE-DEFINITION-STRING-REF>chapter</ATTRIBUTE-DEFINITION-STRING-REF></DEFINITION>
+            </ATTRIBUTE-VALUE-STRING>
+          </VALUES>
+        </SPEC-OBJECT>
+      </SPEC-OBJECTS>
+      <SPECIFICATIONS>
+        <SPECIFICATION IDENTIFIER="spec">
+          <CHILDREN>
+            <SPEC-HIERARCHY IDENTIFIER="h1">
+              <OBJECT><SPEC-OBJECT-REF>heading-1</SPEC-OBJECT-REF></OBJECT>
+              <CHILDREN>
+                <SPEC-HIERARCHY IDENTIFIER="h2">
+                  <OBJECT><SPEC-OBJECT-REF>req-1</SPEC-OBJECT-REF></OBJECT>
+                </SPEC-HIERARCHY>
+              </CHILDREN>
+            </SPEC-HIERARCHY>
+          </CHILDREN>
+        </SPECIFICATION>
+      </SPECIFICATIONS>
+    </REQ-IF-CONTENT>
+  </CORE-CONTENT>
+</REQ-IF>`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testDocument))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Coffee Machine", doc.Title)
+	assert.Equal(t, []string{ForeignIDAttribute, TextAttribute, "Priority", ChapterNameAttribute}, doc.Attributes)
+	require.Len(t, doc.Objects, 3)
+
+	heading := doc.Objects[0]
+	assert.Equal(t, "heading-1", heading.Identifier)
+	assert.Equal(t, "Heading", heading.Type)
+	assert.Equal(t, 1, heading.Level)
+	assert.Equal(t, map[string]string{ChapterNameAttribute: "Brewing"}, heading.Values)
+
+	requirement := doc.Objects[1]
+	assert.Equal(t, "req-1", requirement.Identifier)
+	assert.Equal(t, "Brewing", requirement.LongName)
+	assert.Equal(t, "Requirement", requirement.Type)
+	assert.Equal(t, 2, requirement.Level)
+	assert.Equal(t, map[string]string{
+		ForeignIDAttribute: "REQ-1",
+		TextAttribute:      "The machine must brew coffee within 60 seconds.\nThe user can choose <strong> coffee.",
+		"Priority":         "High",
+	}, requirement.Values)
+
+	unreferenced := doc.Objects[2]
+	assert.Equal(t, "unreferenced", unreferenced.Identifier)
+	assert.Equal(t, 0, unreferenced.Level)
+	assert.Equal(t, "The machine should be quiet.", unreferenced.Values[TextAttribute])
+
+	t.Run("invalid documents", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("not xml"))
+		assert.ErrorIs(t, err, ErrInvalidDocument)
+
+		_, err = Parse(strings.NewReader("<OTHER/>"))
+		assert.ErrorIs(t, err, ErrInvalidDocument)
+	})
+}
+
+func TestRequirements(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testDocument))
+	require.NoError(t, err)
+
+	mapping := doc.DefaultMapping()
+	assert.Equal(t, Mapping{ID: ForeignIDAttribute, Text: TextAttribute}, mapping)
+
+	requirements := doc.Requirements(mapping)
+	require.Len(t, requirements, 2)
+	assert.Equal(t, "REQ-1", requirements[0].ID)
+	assert.Equal(t, "req-1", requirements[0].Object.Identifier)
+	assert.Equal(t, "unreferenced", requirements[1].ID)
+	assert.Equal(t, "The machine should be quiet.", requirements[1].Text)
+
+	headings := doc.Requirements(Mapping{Text: ChapterNameAttribute})
+	require.Len(t, headings, 1)
+	assert.Equal(t, "heading-1", headings[0].ID)
+	assert.Equal(t, "Brewing", headings[0].Text)
+
+	assert.Equal(t, Mapping{}, (&Document{}).DefaultMapping())
+	assert.Equal(t, Mapping{Text: "Description"}, (&Document{Attributes: []string{"Description", "Status"}}).DefaultMapping())
+}
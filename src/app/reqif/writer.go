@@ -0,0 +1,187 @@
+package reqif
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"time"
+)
+
+const (
+	// Namespace is the XML namespace of ReqIF 1.0 and later.
+	Namespace = "http://www.omg.org/spec/ReqIF/20110401/reqif.xsd"
+	// ToolID identifies harmony as the tool that wrote a document.
+	ToolID = "harmony"
+	// DefaultType is the name of the spec object type of spec objects without a type.
+	DefaultType = "Requirement"
+	// version is the ReqIF version of written documents.
+	version = "1.0"
+	// maxStringLength is the maximum length of string values.
+	maxStringLength = 32000
+)
+
+// ErrWrite is returned if a document could not be written.
+var ErrWrite = errors.New("could not write ReqIF document")
+
+type xmlOutReqIF struct {
+	XMLName xml.Name          `xml:"REQ-IF"`
+	XMLNS   string            `xml:"xmlns,attr"`
+	Header  xmlOutHeader      `xml:"THE-HEADER>REQ-IF-HEADER"`
+	Content xmlOutCoreContent `xml:"CORE-CONTENT>REQ-IF-CONTENT"`
+}
+
+type xmlOutHeader struct {
+	Identifier   string `xml:"IDENTIFIER,attr"`
+	CreationTime string `xml:"CREATION-TIME"`
+	ToolID       string `xml:"REQ-IF-TOOL-ID"`
+	Version      string `xml:"REQ-IF-VERSION"`
+	SourceToolID string `xml:"SOURCE-TOOL-ID"`
+	Title        string `xml:"TITLE"`
+}
+
+type xmlOutCoreContent struct {
+	Datatype      xmlOutDatatype         `xml:"DATATYPES>DATATYPE-DEFINITION-STRING"`
+	Types         []xmlOutSpecObjectType `xml:"SPEC-TYPES>SPEC-OBJECT-TYPE"`
+	SpecType      xmlOutIdentifiable     `xml:"SPEC-TYPES>SPECIFICATION-TYPE"`
+	Objects       []xmlOutSpecObject     `xml:"SPEC-OBJECTS>SPEC-OBJECT"`
+	Specification xmlOutSpecification    `xml:"SPECIFICATIONS>SPECIFICATION"`
+}
+
+type xmlOutIdentifiable struct {
+	Identifier string `xml:"IDENTIFIER,attr"`
+	LastChange string `xml:"LAST-CHANGE,attr"`
+	LongName   string `xml:"LONG-NAME,attr,omitempty"`
+}
+
+type xmlOutDatatype struct {
+	xmlOutIdentifiable
+	MaxLength int `xml:"MAX-LENGTH,attr"`
+}
+
+type xmlOutSpecObjectType struct {
+	xmlOutIdentifiable
+	Attributes []xmlOutAttributeDefinition `xml:"SPEC-ATTRIBUTES>ATTRIBUTE-DEFINITION-STRING"`
+}
+
+type xmlOutAttributeDefinition struct {
+	xmlOutIdentifiable
+	DatatypeRef string `xml:"TYPE>DATATYPE-DEFINITION-STRING-REF"`
+}
+
+type xmlOutSpecObject struct {
+	xmlOutIdentifiable
+	TypeRef string        `xml:"TYPE>SPEC-OBJECT-TYPE-REF"`
+	Values  []xmlOutValue `xml:"VALUES>ATTRIBUTE-VALUE-STRING"`
+}
+
+type xmlOutValue struct {
+	TheValue      string `xml:"THE-VALUE,attr"`
+	DefinitionRef string `xml:"DEFINITION>ATTRIBUTE-DEFINITION-STRING-REF"`
+}
+
+type xmlOutSpecification struct {
+	xmlOutIdentifiable
+	TypeRef  string                `xml:"TYPE>SPECIFICATION-TYPE-REF"`
+	Children []xmlOutSpecHierarchy `xml:"CHILDREN>SPEC-HIERARCHY"`
+}
+
+type xmlOutSpecHierarchy struct {
+	xmlOutIdentifiable
+	ObjectRef string `xml:"OBJECT>SPEC-OBJECT-REF"`
+}
+
+// Write writes the document as ReqIF. All attributes are written as string attributes, each spec object type
+// (see SpecObject.Type and DefaultType) defines all of the document's attributes. The spec objects are written
+// in their order into a single specification named by the document's title. Hierarchy levels are not written.
+// Spec objects without an identifier are assigned a generated one. Write returns ErrWrite if the document could not be written.
+func Write(w io.Writer, doc *Document) error {
+	now := time.Now().Format(time.RFC3339)
+	identifiable := func(longName string) xmlOutIdentifiable {
+		return xmlOutIdentifiable{Identifier: newIdentifier(), LastChange: now, LongName: longName}
+	}
+
+	content := xmlOutCoreContent{
+		Datatype: xmlOutDatatype{xmlOutIdentifiable: identifiable("String"), MaxLength: maxStringLength},
+		SpecType: identifiable("Specification"),
+	}
+
+	attributeIDs := make(map[string]map[string]string)
+	typeIDs := make(map[string]string)
+	for _, object := range doc.Objects {
+		typeName := object.Type
+		if typeName == "" {
+			typeName = DefaultType
+		}
+
+		if _, ok := typeIDs[typeName]; !ok {
+			specType := xmlOutSpecObjectType{xmlOutIdentifiable: identifiable(typeName)}
+			attributeIDs[typeName] = make(map[string]string, len(doc.Attributes))
+			for _, attribute := range doc.Attributes {
+				definition := xmlOutAttributeDefinition{xmlOutIdentifiable: identifiable(attribute), DatatypeRef: content.Datatype.Identifier}
+				attributeIDs[typeName][attribute] = definition.Identifier
+				specType.Attributes = append(specType.Attributes, definition)
+			}
+
+			typeIDs[typeName] = specType.Identifier
+			content.Types = append(content.Types, specType)
+		}
+
+		specObject := xmlOutSpecObject{
+			xmlOutIdentifiable: xmlOutIdentifiable{Identifier: object.Identifier, LastChange: now, LongName: object.LongName},
+			TypeRef:            typeIDs[typeName],
+		}
+		if specObject.Identifier == "" {
+			specObject.Identifier = newIdentifier()
+		}
+
+		for _, attribute := range doc.Attributes {
+			value, ok := object.Values[attribute]
+			if !ok {
+				continue
+			}
+
+			specObject.Values = append(specObject.Values, xmlOutValue{TheValue: value, DefinitionRef: attributeIDs[typeName][attribute]})
+		}
+
+		content.Objects = append(content.Objects, specObject)
+		content.Specification.Children = append(content.Specification.Children, xmlOutSpecHierarchy{
+			xmlOutIdentifiable: identifiable(""),
+			ObjectRef:          specObject.Identifier,
+		})
+	}
+
+	content.Specification.xmlOutIdentifiable = identifiable(doc.Title)
+	content.Specification.TypeRef = content.SpecType.Identifier
+
+	out := xmlOutReqIF{
+		XMLNS: Namespace,
+		Header: xmlOutHeader{
+			Identifier:   newIdentifier(),
+			CreationTime: now,
+			ToolID:       ToolID,
+			Version:      version,
+			SourceToolID: ToolID,
+			Title:        doc.Title,
+		},
+		Content: content,
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Join(ErrWrite, err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return errors.Join(ErrWrite, err)
+	}
+
+	return nil
+}
+
+// newIdentifier returns a new unique identifier. ReqIF identifiers must not start with a digit, they are therefore prefixed.
+func newIdentifier() string {
+	return fmt.Sprintf("_%s", uuid.New())
+}
@@ -0,0 +1,50 @@
+package reqif
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	doc := &Document{
+		Title:      "Coffee & Tea",
+		Attributes: []string{ForeignIDAttribute, TextAttribute, "Priority"},
+		Objects: []*SpecObject{
+			{
+				Identifier: "_req-1",
+				LongName:   "Brewing",
+				Values: map[string]string{
+					ForeignIDAttribute: "REQ-1",
+					TextAttribute:      "The machine must brew <strong> coffee.\nIt must not be loud.",
+					"Priority":         "High",
+				},
+			},
+			{Type: "Heading", Values: map[string]string{TextAttribute: "Tea"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, doc))
+	assert.True(t, strings.HasPrefix(buf.String(), "<?xml"))
+	assert.Contains(t, buf.String(), Namespace)
+
+	written, err := Parse(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, doc.Title, written.Title)
+	assert.Equal(t, doc.Attributes, written.Attributes)
+	require.Len(t, written.Objects, 2)
+
+	assert.Equal(t, "_req-1", written.Objects[0].Identifier)
+	assert.Equal(t, "Brewing", written.Objects[0].LongName)
+	assert.Equal(t, DefaultType, written.Objects[0].Type)
+	assert.Equal(t, 1, written.Objects[0].Level)
+	assert.Equal(t, doc.Objects[0].Values, written.Objects[0].Values)
+
+	assert.NotEmpty(t, written.Objects[1].Identifier)
+	assert.Equal(t, "Heading", written.Objects[1].Type)
+	assert.Equal(t, map[string]string{TextAttribute: "Tea"}, written.Objects[1].Values)
+}
@@ -0,0 +1,74 @@
+package requirement
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/org-harmony/harmony/src/core/tenant"
+)
+
+const (
+	// IdentifierPrefix prefixes the human-readable identifiers of requirements, e.g. "REQ-0153".
+	IdentifierPrefix = "REQ"
+	// IdentifierDigits is the minimum number of digits of an identifier's number, shorter numbers are padded with zeros.
+	IdentifierDigits = 4
+)
+
+// FormatIdentifier returns the human-readable identifier of the requirement with the number, e.g. "REQ-0153" for 153.
+func FormatIdentifier(number int64) string {
+	return fmt.Sprintf("%s-%0*d", IdentifierPrefix, IdentifierDigits, number)
+}
+
+// SequenceScope returns the scope of the sequence numbering a requirement: the requirement's project or,
+// for requirements elicited outside of a project, the requirement's author. Identifiers are unique within their scope.
+func SequenceScope(projectID *uuid.UUID, userID uuid.UUID) uuid.UUID {
+	if projectID != nil {
+		return *projectID
+	}
+
+	return userID
+}
+
+// NextIdentifier allocates the next identifier of the sequence of the scope (see SequenceScope) within the transaction.
+// The sequence's row is locked until the transaction ends, concurrent allocations in the same scope wait for it.
+// Numbers of rolled back transactions are therefore reused and the identifiers of a scope have no gaps.
+func NextIdentifier(ctx context.Context, tx pgx.Tx, scope uuid.UUID) (string, error) {
+	var number int64
+	err := tx.QueryRow(
+		ctx,
+		`INSERT INTO requirement_sequences (tenant_id, scope, last) VALUES ($1, $2, 1)
+		ON CONFLICT (tenant_id, scope) DO UPDATE SET last = requirement_sequences.last + 1
+		RETURNING last`,
+		tenant.ID(ctx), scope,
+	).Scan(&number)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatIdentifier(number), nil
+}
+
+// Label returns the requirement's formatted text (see Requirement.Formatted) prefixed with its identifier, e.g. "REQ-0153: The system shall...".
+// It is the text copied and listed in exports. Requirements without an identifier are labeled by their formatted text only.
+func (r *Requirement) Label() string {
+	return IdentifiedText(r.Identifier, r.Formatted())
+}
+
+// Formatted returns the requirement in the output format of its template or its text if the template defines no output format.
+func (r *Requirement) Formatted() string {
+	if r.Output != "" {
+		return r.Output
+	}
+
+	return r.Text
+}
+
+// IdentifiedText prefixes the text with the identifier, see Requirement.Label. The text is returned as is if the identifier is empty.
+func IdentifiedText(identifier, text string) string {
+	if identifier == "" {
+		return text
+	}
+
+	return identifier + ": " + text
+}
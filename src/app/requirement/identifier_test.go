@@ -0,0 +1,23 @@
+package requirement
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIdentifier(t *testing.T) {
+	assert.Equal(t, "REQ-0007", FormatIdentifier(7))
+	assert.Equal(t, "REQ-12345", FormatIdentifier(12345))
+
+	userID, projectID := uuid.New(), uuid.New()
+	assert.Equal(t, projectID, SequenceScope(&projectID, userID))
+	assert.Equal(t, userID, SequenceScope(nil, userID))
+
+	assert.Equal(t, "REQ-0007: The system shall log", (&Requirement{Identifier: "REQ-0007", Text: "The system shall log"}).Label())
+	assert.Equal(t, "The system shall log", IdentifiedText("", "The system shall log"))
+
+	formatted := &Requirement{Identifier: "REQ-0008", Text: "The system shall log", Output: "The system shall log every access."}
+	assert.Equal(t, "The system shall log every access.", formatted.Formatted())
+	assert.Equal(t, "REQ-0008: The system shall log every access.", formatted.Label())
+}
@@ -0,0 +1,538 @@
+// Package requirement stores elicited requirements and organizes them by tags. A requirement is stored when it was parsed
+// successfully in the elicitation form. Each requirement carries free-form tags given by the user and automatic tags
+// derived from the template and variant it was elicited with (see AutoTags).
+package requirement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"time"
+)
+
+const (
+	// RepositoryName is the name of the requirement repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "RequirementRepository"
+	// TagRepositoryName is the name of the requirement tag repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	TagRepositoryName = "RequirementTagRepository"
+	// Pkg is the package name for logging.
+	Pkg = "app.requirement"
+	// requirementSelect selects the requirements aliased as r joined with the email of their reviewer in the order scanned by scanRequirement.
+	requirementSelect = `SELECT r.id, r.identifier, r.template_id, r.template, r.variant, r.text, r.output, r.segments, r.project_id, r.state, r.reviewer, reviewer.email,
+		r.created_by, r.created_at, r.updated_at FROM requirements r LEFT JOIN users reviewer ON reviewer.id = r.reviewer`
+)
+
+// Requirement is an elicited requirement. Template and Variant are the names of the template and variant the requirement was
+// elicited with at that time, the template might have been changed or deleted since.
+type Requirement struct {
+	ID uuid.UUID
+	// Identifier is the human-readable identifier of the requirement, e.g. "REQ-0153". It is allocated once the requirement
+	// is created and unique within the requirement's project or, outside of projects, the requirement's author (see SequenceScope).
+	// Identifiers are stable, they do not change if the requirement is replaced.
+	Identifier string
+	TemplateID uuid.UUID
+	// Template is the name and version of the template.
+	Template string
+	// Variant is the name of the variant.
+	Variant string
+	// Text is the requirement built from its segments.
+	Text string
+	// Output is the requirement in the output format of its template, it is empty if the template defines no output format.
+	// Requirements are displayed, copied and exported in their output format, see Requirement.Formatted.
+	Output string
+	// Segments are the non-empty segments of the requirement in the order of the variant's rules.
+	Segments []Segment
+	// Tags are the automatic tags followed by the free-form tags, each ordered by their name.
+	Tags []Tag
+	// ProjectID is the project the requirement was elicited in, nil if it was elicited outside of a project.
+	ProjectID *uuid.UUID
+	// State is the requirement's state in the review workflow, see StateChange.
+	State State
+	// Reviewer is the user assigned to review the requirement, nil if no reviewer was assigned yet.
+	Reviewer *uuid.UUID
+	// ReviewerEmail is the email of the Reviewer joined onto the requirement, it is empty if there is no reviewer.
+	ReviewerEmail string
+	CreatedBy     uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     *time.Time
+}
+
+// Segment is a segment of a Requirement. Rule is the display name of the rule, not its key.
+type Segment struct {
+	Rule  string `json:"rule"`
+	Value string `json:"value"`
+}
+
+// Tag is a tag of a requirement. Automatic tags are derived from the requirement's template and variant, see AutoTags.
+type Tag struct {
+	Name      string
+	Automatic bool
+}
+
+// TagCount is a tag and the number of the user's requirements tagged with it.
+type TagCount struct {
+	Tag
+	Count int
+}
+
+// ToSave is the requirement entity that is used to create or replace a requirement. Tags are the tags of the
+// requirement including the automatic tags, see TagsToSave.
+type ToSave struct {
+	ID         uuid.UUID `hvalidate:"required"`
+	TemplateID uuid.UUID `hvalidate:"required"`
+	Template   string    `hvalidate:"required"`
+	Variant    string    `hvalidate:"required"`
+	Text       string    `hvalidate:"required"`
+	Output     string
+	Segments   []Segment
+	Tags       []Tag
+	ProjectID  *uuid.UUID
+	CreatedBy  uuid.UUID `hvalidate:"required"`
+}
+
+// Filter selects requirements. Requirements must be tagged with all Tags and contain the Query in their text or identifier (case-insensitive).
+// Empty tags and an empty query select all requirements. If Project is set, only requirements of the project are selected.
+// If State is set, only requirements in the state are selected. At most Limit requirements are selected, a Limit of 0 selects all.
+// The tags are expected to be normalized and unique, see NormalizeTag.
+type Filter struct {
+	Tags    []string
+	Query   string
+	Project uuid.UUID
+	State   State
+	Limit   int
+}
+
+// Repository is the requirement repository. It contains all methods to interact with the requirements table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID) and to the passed in user.
+// Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// Find finds the user's requirements selected by the filter ordered by their creation, the newest first.
+	// It returns an empty slice if no requirements are selected and persistence.ErrReadRow for any other error.
+	Find(ctx context.Context, userID uuid.UUID, filter Filter) ([]*Requirement, error)
+	// FindByProject finds the requirements of all users elicited in the project selected by the filter ordered by their creation,
+	// the newest first. The filter's Project is ignored. Callers are responsible for checking the user is a member of the project.
+	// It returns an empty slice if no requirements are selected and persistence.ErrReadRow for any other error.
+	FindByProject(ctx context.Context, projectID uuid.UUID, filter Filter) ([]*Requirement, error)
+	// FindByReviewer finds the requirements of all users the reviewer is assigned to selected by the filter ordered by their creation,
+	// the newest first. It returns an empty slice if no requirements are selected and persistence.ErrReadRow for any other error.
+	FindByReviewer(ctx context.Context, reviewerID uuid.UUID, filter Filter) ([]*Requirement, error)
+	// FindByIDAsParticipant finds a requirement by its id if the user created it or is assigned to review it.
+	// It returns persistence.ErrNotFound if the requirement could not be found and persistence.ErrReadRow for any other error.
+	FindByIDAsParticipant(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Requirement, error)
+	// IsParticipant returns true if the user created the requirement, is assigned to review it or is a member of its project.
+	// It returns persistence.ErrNotFound if the requirement does not exist and persistence.ErrReadRow for any other error.
+	IsParticipant(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error)
+	// FindByID finds a requirement of a user by its id.
+	// It returns persistence.ErrNotFound if the requirement could not be found and persistence.ErrReadRow for any other error.
+	FindByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Requirement, error)
+	// Save creates the requirement or replaces the requirement with the same id and its tags. New requirements are drafts
+	// and get the next identifier of their scope (see NextIdentifier), replaced requirements keep their identifier and move back
+	// to StateDraft if their text changed. It returns persistence.ErrInsert if the requirement could not be saved.
+	Save(ctx context.Context, toSave *ToSave) (*Requirement, error)
+	// Delete deletes a requirement of a user by its id. It returns persistence.ErrDelete if the requirement could not be deleted.
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+}
+
+// TagRepository is the requirement tag repository. It contains all methods to interact with the requirement_tags table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID) and to the passed in user.
+// TagRepository is safe for concurrent use by multiple goroutines.
+type TagRepository interface {
+	persistence.Repository
+
+	// FindByUser finds the tags of the user's requirements with the number of requirements per tag, the automatic tags first,
+	// each ordered by their name. It returns an empty slice if the user has no tags and persistence.ErrReadRow for any other error.
+	FindByUser(ctx context.Context, userID uuid.UUID) ([]*TagCount, error)
+	// SetFreeTags replaces the free-form tags of a user's requirement, the automatic tags are kept.
+	// It returns persistence.ErrNotFound if the requirement could not be found and persistence.ErrUpdate for any other error.
+	SetFreeTags(ctx context.Context, userID uuid.UUID, requirementID uuid.UUID, tags []string) error
+}
+
+// PGRepository is the requirement repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// PGTagRepository is the requirement tag repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGTagRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &PGRepository{db: db}
+}
+
+// NewTagRepository constructs a new PGTagRepository with the passed in database connection pool.
+func NewTagRepository(db *pgxpool.Pool) TagRepository {
+	return &PGTagRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// Find finds the user's requirements selected by the filter ordered by their creation, the newest first.
+// It returns an empty slice if no requirements are selected and persistence.ErrReadRow for any other error.
+func (r *PGRepository) Find(ctx context.Context, userID uuid.UUID, filter Filter) ([]*Requirement, error) {
+	var project *uuid.UUID
+	if filter.Project != uuid.Nil {
+		project = &filter.Project
+	}
+
+	return r.find(ctx, "r.created_by = $1 AND ($2::UUID IS NULL OR r.project_id = $2)", filter, userID, project)
+}
+
+// FindByProject finds the requirements of all users elicited in the project selected by the filter ordered by their creation,
+// the newest first. The filter's Project is ignored. Callers are responsible for checking the user is a member of the project.
+// It returns an empty slice if no requirements are selected and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByProject(ctx context.Context, projectID uuid.UUID, filter Filter) ([]*Requirement, error) {
+	return r.find(ctx, "r.project_id = $1", filter, projectID)
+}
+
+// FindByReviewer finds the requirements of all users the reviewer is assigned to selected by the filter ordered by their creation,
+// the newest first. It returns an empty slice if no requirements are selected and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByReviewer(ctx context.Context, reviewerID uuid.UUID, filter Filter) ([]*Requirement, error) {
+	return r.find(ctx, "r.reviewer = $1", filter, reviewerID)
+}
+
+// FindByID finds a requirement of a user by its id.
+// It returns persistence.ErrNotFound if the requirement could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Requirement, error) {
+	return r.findOne(ctx, "r.id = $1 AND r.created_by = $2", id, userID)
+}
+
+// FindByIDAsParticipant finds a requirement by its id if the user created it or is assigned to review it.
+// It returns persistence.ErrNotFound if the requirement could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindByIDAsParticipant(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Requirement, error) {
+	return r.findOne(ctx, "r.id = $1 AND (r.created_by = $2 OR r.reviewer = $2)", id, userID)
+}
+
+// IsParticipant returns true if the user created the requirement, is assigned to review it or is a member of its project.
+// It returns persistence.ErrNotFound if the requirement does not exist and persistence.ErrReadRow for any other error.
+func (r *PGRepository) IsParticipant(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	var participant bool
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT r.created_by = $2 OR r.reviewer IS NOT DISTINCT FROM $2
+			OR EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = r.project_id AND pm.user_id = $2)
+		FROM requirements r WHERE r.id = $1 AND r.tenant_id = $3`,
+		id, userID, tenant.ID(ctx),
+	).Scan(&participant)
+	if err != nil {
+		return false, persistence.PGReadErr(err)
+	}
+
+	return participant, nil
+}
+
+// Save creates the requirement or replaces the requirement with the same id and its tags. New requirements are drafts
+// and get the next identifier of their scope (see NextIdentifier), replaced requirements keep their identifier and move back
+// to StateDraft if their text changed. It returns persistence.ErrInsert if the requirement could not be saved.
+func (r *PGRepository) Save(ctx context.Context, toSave *ToSave) (*Requirement, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	segments := toSave.Segments
+	if segments == nil {
+		segments = []Segment{}
+	}
+
+	requirement := &Requirement{
+		ID:         toSave.ID,
+		TemplateID: toSave.TemplateID,
+		Template:   toSave.Template,
+		Variant:    toSave.Variant,
+		Text:       toSave.Text,
+		Output:     toSave.Output,
+		Segments:   segments,
+		Tags:       sortTags(toSave.Tags),
+		ProjectID:  toSave.ProjectID,
+		CreatedBy:  toSave.CreatedBy,
+	}
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var identifier string
+		err := tx.QueryRow(
+			ctx,
+			"SELECT identifier FROM requirements WHERE id = $1 AND tenant_id = $2 FOR UPDATE",
+			requirement.ID, tenant.ID(ctx),
+		).Scan(&identifier)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if identifier == "" {
+			identifier, err = NextIdentifier(ctx, tx, SequenceScope(requirement.ProjectID, requirement.CreatedBy))
+			if err != nil {
+				return err
+			}
+		}
+
+		// requirements of other users or tenants are not replaced, the insert then fails on the conflicting id
+		err = tx.QueryRow(
+			ctx,
+			`INSERT INTO requirements (id, identifier, template_id, template, variant, text, output, segments, project_id, created_by, tenant_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (id) DO UPDATE SET identifier = excluded.identifier, template_id = excluded.template_id, template = excluded.template,
+				variant = excluded.variant, text = excluded.text, output = excluded.output, segments = excluded.segments, project_id = excluded.project_id, updated_at = current_timestamp,
+				state = CASE WHEN requirements.text = excluded.text THEN requirements.state ELSE excluded.state END
+			WHERE requirements.created_by = excluded.created_by AND requirements.tenant_id = excluded.tenant_id
+			RETURNING identifier, created_at, updated_at, state, reviewer`,
+			requirement.ID,
+			identifier,
+			requirement.TemplateID,
+			requirement.Template,
+			requirement.Variant,
+			requirement.Text,
+			requirement.Output,
+			requirement.Segments,
+			requirement.ProjectID,
+			requirement.CreatedBy,
+			tenant.ID(ctx),
+		).Scan(&requirement.Identifier, &requirement.CreatedAt, &requirement.UpdatedAt, &requirement.State, &requirement.Reviewer)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM requirement_tags WHERE requirement_id = $1", requirement.ID)
+		if err != nil {
+			return err
+		}
+
+		return insertTags(ctx, tx, requirement.ID, requirement.Tags)
+	})
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return requirement, nil
+}
+
+// Delete deletes a requirement of a user by its id. It returns persistence.ErrDelete if the requirement could not be deleted.
+func (r *PGRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		"DELETE FROM requirements WHERE id = $1 AND created_by = $2 AND tenant_id = $3",
+		id, userID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// find finds the requirements matching the condition and selected by the filter (except for its Project) ordered by their creation,
+// the newest first. The condition is a WHERE clause on the requirements aliased as r using the args as its first parameters.
+func (r *PGRepository) find(ctx context.Context, condition string, filter Filter, args ...any) ([]*Requirement, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	tags := filter.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	n := len(args)
+	query := fmt.Sprintf(`%s
+		WHERE %s AND r.tenant_id = $%d
+		AND (r.text ILIKE '%%' || $%d || '%%' OR r.identifier ILIKE '%%' || $%d || '%%')
+		AND (SELECT COUNT(*) FROM requirement_tags t WHERE t.requirement_id = r.id AND t.tag = ANY($%d::VARCHAR[])) = cardinality($%d::VARCHAR[])
+		AND ($%d = '' OR r.state = $%d)
+		ORDER BY r.created_at DESC`, requirementSelect, condition, n+1, n+2, n+2, n+3, n+3, n+4, n+4)
+	args = append(args, tenant.ID(ctx), persistence.EscapeLike(filter.Query), tags, string(filter.State))
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", n+5)
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	requirements, err := persistence.PGCollectRows(rows, err, scanRequirement)
+	if err != nil {
+		return nil, err
+	}
+
+	return requirements, r.loadTags(ctx, requirements)
+}
+
+// findOne finds the requirement matching the condition, see find. The condition uses the args as its first parameters.
+// It returns persistence.ErrNotFound if the requirement could not be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) findOne(ctx context.Context, condition string, args ...any) (*Requirement, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf("%s WHERE %s AND r.tenant_id = $%d", requirementSelect, condition, len(args)+1)
+	requirement, err := persistence.PGScanRow(r.db.QueryRow(ctx, query, append(args, tenant.ID(ctx))...), scanRequirement)
+	if err != nil {
+		return nil, err
+	}
+
+	return requirement, r.loadTags(ctx, []*Requirement{requirement})
+}
+
+// loadTags loads the tags of the requirements. It returns persistence.ErrReadRow if the tags could not be read.
+func (r *PGRepository) loadTags(ctx context.Context, requirements []*Requirement) error {
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*Requirement, len(requirements))
+	ids := make([]uuid.UUID, 0, len(requirements))
+	for _, requirement := range requirements {
+		byID[requirement.ID] = requirement
+		ids = append(ids, requirement.ID)
+	}
+
+	rows, err := r.db.Query(
+		ctx,
+		"SELECT requirement_id, tag, automatic FROM requirement_tags WHERE requirement_id = ANY($1) ORDER BY automatic DESC, tag",
+		ids,
+	)
+	if err != nil {
+		return persistence.PGReadErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var requirementID uuid.UUID
+		var tag Tag
+		if err := rows.Scan(&requirementID, &tag.Name, &tag.Automatic); err != nil {
+			return persistence.PGReadErr(err)
+		}
+
+		byID[requirementID].Tags = append(byID[requirementID].Tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return persistence.PGReadErr(err)
+	}
+
+	return nil
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGTagRepository) RepositoryName() string {
+	return TagRepositoryName
+}
+
+// FindByUser finds the tags of the user's requirements with the number of requirements per tag, the automatic tags first,
+// each ordered by their name. It returns an empty slice if the user has no tags and persistence.ErrReadRow for any other error.
+func (r *PGTagRepository) FindByUser(ctx context.Context, userID uuid.UUID) ([]*TagCount, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT t.tag, t.automatic, COUNT(*) FROM requirement_tags t JOIN requirements r ON r.id = t.requirement_id
+		WHERE r.created_by = $1 AND r.tenant_id = $2 GROUP BY t.tag, t.automatic ORDER BY t.automatic DESC, t.tag`,
+		userID, tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, func(row pgx.Row) (*TagCount, error) {
+		count := &TagCount{}
+		err := row.Scan(&count.Name, &count.Automatic, &count.Count)
+
+		return count, err
+	})
+}
+
+// SetFreeTags replaces the free-form tags of a user's requirement, the automatic tags are kept.
+// It returns persistence.ErrNotFound if the requirement could not be found and persistence.ErrUpdate for any other error.
+func (r *PGTagRepository) SetFreeTags(ctx context.Context, userID uuid.UUID, requirementID uuid.UUID, tags []string) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(
+			ctx,
+			"SELECT EXISTS (SELECT 1 FROM requirements WHERE id = $1 AND created_by = $2 AND tenant_id = $3)",
+			requirementID, userID, tenant.ID(ctx),
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return persistence.ErrNotFound
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM requirement_tags WHERE requirement_id = $1 AND NOT automatic", requirementID)
+		if err != nil {
+			return err
+		}
+
+		freeTags := make([]Tag, 0, len(tags))
+		for _, tag := range tags {
+			freeTags = append(freeTags, Tag{Name: tag})
+		}
+
+		return insertTags(ctx, tx, requirementID, freeTags)
+	})
+	if errors.Is(err, persistence.ErrNotFound) {
+		return err
+	}
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// insertTags inserts the tags of the requirement. Tags already present are ignored.
+func insertTags(ctx context.Context, tx pgx.Tx, requirementID uuid.UUID, tags []Tag) error {
+	for _, tag := range tags {
+		_, err := tx.Exec(
+			ctx,
+			"INSERT INTO requirement_tags (requirement_id, tag, automatic) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+			requirementID, tag.Name, tag.Automatic,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanRequirement scans a row selected by the requirementSelect into a new Requirement.
+func scanRequirement(row pgx.Row) (*Requirement, error) {
+	r := &Requirement{}
+	var reviewerEmail *string
+	err := row.Scan(
+		&r.ID,
+		&r.Identifier,
+		&r.TemplateID,
+		&r.Template,
+		&r.Variant,
+		&r.Text,
+		&r.Output,
+		&r.Segments,
+		&r.ProjectID,
+		&r.State,
+		&r.Reviewer,
+		&reviewerEmail,
+		&r.CreatedBy,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if reviewerEmail != nil {
+		r.ReviewerEmail = *reviewerEmail
+	}
+
+	return r, err
+}
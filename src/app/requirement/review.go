@@ -0,0 +1,396 @@
+package requirement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/outbox"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"time"
+)
+
+const (
+	// ReviewRepositoryName is the name of the review repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	ReviewRepositoryName = "RequirementReviewRepository"
+	// StateChangedEventID is the id of the StateChangedEvent.
+	StateChangedEventID = "requirement.state.changed"
+	// ReviewReminderEventID is the id of the ReviewReminderEvent.
+	ReviewReminderEventID = "requirement.review.reminder"
+	// ReviewReminderDelay is the time after submitting a requirement for review the reviewer is reminded, see ReviewReminderEvent.
+	ReviewReminderDelay = 3 * 24 * time.Hour
+	// MaxCommentLength is the maximum length of a review comment in characters.
+	MaxCommentLength = 2000
+)
+
+const (
+	// StateDraft is the state of elicited requirements. Requirements move back to draft if their text changes.
+	StateDraft State = "draft"
+	// StateReview is the state of requirements waiting for their reviewer.
+	StateReview State = "review"
+	// StateAccepted is the state of requirements accepted by their reviewer.
+	StateAccepted State = "accepted"
+	// StateRejected is the state of requirements rejected by their reviewer. The author revises them as drafts.
+	StateRejected State = "rejected"
+)
+
+var (
+	// ErrInvalidTransition is returned if the requirement can not change into the state or the user is not permitted to change it.
+	ErrInvalidTransition = errors.New("requirement.review.invalid-transition")
+	// ErrNoReviewer is returned if a requirement is submitted for review without a reviewer.
+	ErrNoReviewer = errors.New("requirement.review.no-reviewer")
+	// ErrSelfReview is returned if the author of a requirement is assigned as its reviewer.
+	ErrSelfReview = errors.New("requirement.review.self-review")
+	// ErrCommentTooLong is returned if a review comment is longer than MaxCommentLength.
+	ErrCommentTooLong = errors.New("requirement.review.comment-too-long")
+	// ErrStateChanged is returned if the requirement's state was changed concurrently.
+	ErrStateChanged = errors.New("requirement.review.state-changed")
+)
+
+// States are all states of the review workflow in their usual order.
+var States = []State{StateDraft, StateReview, StateAccepted, StateRejected}
+
+// transitions are the state changes of the review workflow by the participant permitted to perform them:
+// the author submits drafts for review, withdraws them from review and revises rejected requirements.
+// The reviewer accepts or rejects requirements in review and reopens accepted requirements.
+var transitions = []transition{
+	{from: StateDraft, to: StateReview},
+	{from: StateReview, to: StateDraft},
+	{from: StateRejected, to: StateDraft},
+	{from: StateReview, to: StateAccepted, reviewer: true},
+	{from: StateReview, to: StateRejected, reviewer: true},
+	{from: StateAccepted, to: StateReview, reviewer: true},
+}
+
+// State is the state of a requirement in the review workflow.
+type State string
+
+// transition is a state change of the review workflow, performed either by the requirement's author or its reviewer.
+type transition struct {
+	from     State
+	to       State
+	reviewer bool
+}
+
+// ReviewEntry is an entry of a requirement's review log. It records a state change and the comment given with it.
+type ReviewEntry struct {
+	ID            uuid.UUID
+	RequirementID uuid.UUID
+	From          State
+	To            State
+	Comment       string
+	CreatedBy     uuid.UUID
+	// CreatedByEmail is the email of the user that changed the state joined onto the entry.
+	CreatedByEmail string
+	CreatedAt      time.Time
+}
+
+// StateChange changes the state of a requirement, see ChangeState. Reviewer is only set when submitting a requirement for review.
+type StateChange struct {
+	RequirementID uuid.UUID `hvalidate:"required"`
+	From          State     `hvalidate:"required"`
+	To            State     `hvalidate:"required"`
+	Reviewer      *uuid.UUID
+	Comment       string
+	CreatedBy     uuid.UUID `hvalidate:"required"`
+}
+
+// StateChangedEvent is published after the state of a requirement changed, e.g. to notify the reviewer or the author.
+// Events are handled outside the request, the event therefore carries the tenant of the request the state changed in.
+// The event is stored in the outbox with the state change and published by the outbox.Relay, see DecodeStateChangedEvent.
+type StateChangedEvent struct {
+	// Requirement is the requirement after the state change.
+	Requirement *Requirement
+	From        State
+	Comment     string
+	// ChangedBy is the user that changed the state, either the author or the reviewer.
+	ChangedBy uuid.UUID
+	// Tenant is the tenant the state changed in. It is nil if the application is not multi-tenant.
+	// It is not stored in the outbox but restored from the tenant the event was added in.
+	Tenant *tenant.Tenant `json:"-"`
+}
+
+// ReviewReminderEvent is published ReviewReminderDelay after a requirement was submitted for review to remind the reviewer.
+// It is scheduled in the outbox (see outbox.Schedule) and canceled if the requirement's state changes before, the reviewer is
+// therefore only reminded of requirements still waiting for their review. Like the StateChangedEvent it carries the tenant.
+type ReviewReminderEvent struct {
+	// Requirement is the requirement as it was submitted for review.
+	Requirement *Requirement
+	// Tenant is the tenant the requirement was submitted in. It is nil if the application is not multi-tenant.
+	Tenant *tenant.Tenant `json:"-"`
+}
+
+// ReviewRepository is the requirement review repository. It contains all methods to interact with the review log in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID). Callers are responsible for checking the user participates
+// in the requirement's review (see Repository.FindByIDAsParticipant). ReviewRepository is safe for concurrent use by multiple goroutines.
+type ReviewRepository interface {
+	persistence.Repository
+
+	// FindEntries finds the review log of a requirement ordered by their creation, the oldest first.
+	// It returns an empty slice if the requirement's state never changed and persistence.ErrReadRow for any other error.
+	FindEntries(ctx context.Context, requirementID uuid.UUID) ([]*ReviewEntry, error)
+	// ChangeState changes the requirement's state, assigns the reviewer if set and records the change in the review log.
+	// The changed event is stored in the outbox in the same transaction (see outbox.Add). A ReviewReminderEvent is scheduled
+	// if the requirement is submitted for review, a pending reminder is canceled by any other state change. It returns ErrStateChanged if the requirement is no longer in the change's From state and persistence.ErrUpdate for any other error.
+	ChangeState(ctx context.Context, change *StateChange, changed *StateChangedEvent) error
+}
+
+// PGReviewRepository is the requirement review repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGReviewRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewReviewRepository constructs a new PGReviewRepository with the passed in database connection pool.
+func NewReviewRepository(db *pgxpool.Pool) ReviewRepository {
+	return &PGReviewRepository{db: db}
+}
+
+// ParseState returns the State of the string and true if it is a known state.
+func ParseState(s string) (State, bool) {
+	switch state := State(s); state {
+	case StateDraft, StateReview, StateAccepted, StateRejected:
+		return state, true
+	default:
+		return "", false
+	}
+}
+
+// NextStates returns the states the user can change the requirement into. The author and the reviewer
+// of a requirement change its state (see transitions), other users can not change it.
+func (r *Requirement) NextStates(userID uuid.UUID) []State {
+	var states []State
+	for _, t := range transitions {
+		if r.State == t.from && r.permits(userID, t) {
+			states = append(states, t.to)
+		}
+	}
+
+	return states
+}
+
+// CanChangeState returns true if the user can change the requirement into the state.
+func (r *Requirement) CanChangeState(userID uuid.UUID, to State) bool {
+	for _, t := range transitions {
+		if r.State == t.from && t.to == to && r.permits(userID, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsReviewer returns true if the user is assigned to review the requirement.
+func (r *Requirement) IsReviewer(userID uuid.UUID) bool {
+	return r.Reviewer != nil && *r.Reviewer == userID
+}
+
+// ChangeState changes the requirement's state by the user and stores a StateChangedEvent in the outbox. Submitting a requirement
+// for review requires a reviewer other than the author, it is ignored for any other state change. The state change is
+// validated against the review workflow, ErrInvalidTransition is returned if the user can not change the requirement into the state.
+// The requirement is updated to reflect the change.
+func ChangeState(
+	ctx context.Context,
+	repository ReviewRepository,
+	r *Requirement,
+	userID uuid.UUID,
+	to State,
+	reviewer *uuid.UUID,
+	comment string,
+) error {
+	if !r.CanChangeState(userID, to) {
+		return ErrInvalidTransition
+	}
+	if len([]rune(comment)) > MaxCommentLength {
+		return ErrCommentTooLong
+	}
+
+	change := &StateChange{RequirementID: r.ID, From: r.State, To: to, Comment: comment, CreatedBy: userID}
+	if to == StateReview && r.State == StateDraft {
+		if reviewer == nil || *reviewer == uuid.Nil {
+			return ErrNoReviewer
+		}
+		if *reviewer == r.CreatedBy {
+			return ErrSelfReview
+		}
+
+		change.Reviewer = reviewer
+	}
+
+	changed := *r
+	changed.State = to
+	if change.Reviewer != nil {
+		changed.Reviewer = change.Reviewer
+	}
+
+	t, _ := tenant.FromCtx(ctx)
+	e := &StateChangedEvent{Requirement: &changed, From: r.State, Comment: comment, ChangedBy: userID, Tenant: t}
+	if err := repository.ChangeState(ctx, change, e); err != nil {
+		return err
+	}
+
+	*r = changed
+
+	return nil
+}
+
+// DecodeReviewReminderEvent is the outbox.Decoder of the ReviewReminderEvent. The tenant is restored from the context.
+func DecodeReviewReminderEvent(ctx context.Context, payload []byte) (event.Event, error) {
+	e := &ReviewReminderEvent{}
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+
+	e.Tenant, _ = tenant.FromCtx(ctx)
+
+	return e, nil
+}
+
+// DecodeStateChangedEvent is the outbox.Decoder of the StateChangedEvent. The tenant is restored from the context.
+func DecodeStateChangedEvent(ctx context.Context, payload []byte) (event.Event, error) {
+	e := &StateChangedEvent{}
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+
+	e.Tenant, _ = tenant.FromCtx(ctx)
+
+	return e, nil
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGReviewRepository) RepositoryName() string {
+	return ReviewRepositoryName
+}
+
+// FindEntries finds the review log of a requirement ordered by their creation, the oldest first.
+// It returns an empty slice if the requirement's state never changed and persistence.ErrReadRow for any other error.
+func (r *PGReviewRepository) FindEntries(ctx context.Context, requirementID uuid.UUID) ([]*ReviewEntry, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT e.id, e.requirement_id, e.from_state, e.to_state, e.comment, e.created_by, u.email, e.created_at
+		FROM requirement_reviews e JOIN requirements r ON r.id = e.requirement_id JOIN users u ON u.id = e.created_by
+		WHERE e.requirement_id = $1 AND r.tenant_id = $2 ORDER BY e.created_at`,
+		requirementID, tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, func(row pgx.Row) (*ReviewEntry, error) {
+		e := &ReviewEntry{}
+		err := row.Scan(&e.ID, &e.RequirementID, &e.From, &e.To, &e.Comment, &e.CreatedBy, &e.CreatedByEmail, &e.CreatedAt)
+
+		return e, err
+	})
+}
+
+// ChangeState changes the requirement's state, assigns the reviewer if set and records the change in the review log.
+// The changed event is stored in the outbox in the same transaction, deduplicated by the id of the review log entry.
+// The reviewer's reminder is scheduled and canceled by the id of the requirement, see ReviewReminderEvent. It returns ErrStateChanged if the requirement is no longer in the change's From state and persistence.ErrUpdate for any other error.
+func (r *PGReviewRepository) ChangeState(ctx context.Context, change *StateChange, changed *StateChangedEvent) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		result, err := tx.Exec(
+			ctx,
+			`UPDATE requirements SET state = $1, reviewer = COALESCE($2, reviewer)
+			WHERE id = $3 AND state = $4 AND tenant_id = $5`,
+			change.To, change.Reviewer, change.RequirementID, change.From, tenant.ID(ctx),
+		)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return ErrStateChanged
+		}
+
+		entryID := uuid.New()
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO requirement_reviews (id, requirement_id, from_state, to_state, comment, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			entryID, change.RequirementID, change.From, change.To, change.Comment, change.CreatedBy,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := outbox.Add(ctx, tx, StateChangedEventID+":"+entryID.String(), changed); err != nil {
+			return err
+		}
+
+		reminderKey := ReviewReminderEventID + ":" + change.RequirementID.String()
+		if err := outbox.Cancel(ctx, tx, reminderKey); err != nil {
+			return err
+		}
+		if change.To != StateReview {
+			return nil
+		}
+
+		reminder := &ReviewReminderEvent{Requirement: changed.Requirement, Tenant: changed.Tenant}
+		return outbox.Schedule(ctx, tx, reminderKey, reminder, time.Now().Add(ReviewReminderDelay))
+	})
+	if errors.Is(err, ErrStateChanged) {
+		return err
+	}
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// ID returns the event id.
+func (e *StateChangedEvent) ID() string {
+	return StateChangedEventID
+}
+
+// Payload returns the event payload. It is the event itself as a pointer.
+func (e *StateChangedEvent) Payload() any {
+	return e
+}
+
+// Ctx returns a new context containing the tenant the state changed in (see tenant.WithTenant).
+func (e *StateChangedEvent) Ctx() context.Context {
+	ctx := context.Background()
+	if e.Tenant != nil {
+		ctx = tenant.WithTenant(ctx, e.Tenant)
+	}
+
+	return ctx
+}
+
+// ID returns the event id.
+func (e *ReviewReminderEvent) ID() string {
+	return ReviewReminderEventID
+}
+
+// Payload returns the event payload. It is the event itself as a pointer.
+func (e *ReviewReminderEvent) Payload() any {
+	return e
+}
+
+// Ctx returns a new context containing the tenant the requirement was submitted in (see tenant.WithTenant).
+func (e *ReviewReminderEvent) Ctx() context.Context {
+	ctx := context.Background()
+	if e.Tenant != nil {
+		ctx = tenant.WithTenant(ctx, e.Tenant)
+	}
+
+	return ctx
+}
+
+// permits returns true if the user is the participant of the requirement permitted to perform the transition.
+func (r *Requirement) permits(userID uuid.UUID, t transition) bool {
+	if t.reviewer {
+		return r.IsReviewer(userID)
+	}
+
+	return r.CreatedBy == userID
+}
@@ -0,0 +1,136 @@
+package requirement
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+type reviewRepositoryMock struct {
+	ReviewRepository
+	changes []*StateChange
+	events  []*StateChangedEvent
+}
+
+func TestNextStates(t *testing.T) {
+	author, reviewer, other := uuid.New(), uuid.New(), uuid.New()
+	r := &Requirement{State: StateDraft, CreatedBy: author}
+
+	assert.Equal(t, []State{StateReview}, r.NextStates(author))
+	assert.Empty(t, r.NextStates(other))
+
+	r.State, r.Reviewer = StateReview, &reviewer
+	assert.Equal(t, []State{StateDraft}, r.NextStates(author))
+	assert.Equal(t, []State{StateAccepted, StateRejected}, r.NextStates(reviewer))
+	assert.Empty(t, r.NextStates(other))
+	assert.True(t, r.CanChangeState(reviewer, StateAccepted))
+	assert.False(t, r.CanChangeState(author, StateAccepted), "authors can not accept their own requirements")
+
+	r.State = StateAccepted
+	assert.Empty(t, r.NextStates(author))
+	assert.Equal(t, []State{StateReview}, r.NextStates(reviewer))
+
+	r.State = StateRejected
+	assert.Equal(t, []State{StateDraft}, r.NextStates(author))
+	assert.Empty(t, r.NextStates(reviewer))
+
+	state, ok := ParseState("accepted")
+	assert.True(t, ok)
+	assert.Equal(t, StateAccepted, state)
+	_, ok = ParseState("archived")
+	assert.False(t, ok)
+}
+
+func TestChangeState(t *testing.T) {
+	ctx := context.Background()
+	author, reviewer := uuid.New(), uuid.New()
+	repository := &reviewRepositoryMock{}
+
+	r := &Requirement{ID: uuid.New(), State: StateDraft, CreatedBy: author}
+
+	assert.ErrorIs(t, ChangeState(ctx, repository, r, author, StateReview, nil, ""), ErrNoReviewer)
+	assert.ErrorIs(t, ChangeState(ctx, repository, r, author, StateReview, &author, ""), ErrSelfReview)
+	assert.ErrorIs(t, ChangeState(ctx, repository, r, author, StateAccepted, &reviewer, ""), ErrInvalidTransition)
+	assert.ErrorIs(t, ChangeState(ctx, repository, r, author, StateReview, &reviewer, strings.Repeat("x", MaxCommentLength+1)), ErrCommentTooLong)
+	assert.Empty(t, repository.changes)
+
+	require.NoError(t, ChangeState(ctx, repository, r, author, StateReview, &reviewer, "please review"))
+	assert.Equal(t, StateReview, r.State)
+	assert.Equal(t, &reviewer, r.Reviewer)
+	require.Len(t, repository.changes, 1)
+	assert.Equal(t, StateChange{
+		RequirementID: r.ID,
+		From:          StateDraft,
+		To:            StateReview,
+		Reviewer:      &reviewer,
+		Comment:       "please review",
+		CreatedBy:     author,
+	}, *repository.changes[0])
+
+	other := uuid.New()
+	require.NoError(t, ChangeState(ctx, repository, r, reviewer, StateRejected, &other, "too vague"))
+	assert.Equal(t, StateRejected, r.State)
+	assert.Equal(t, &reviewer, r.Reviewer, "the reviewer is only assigned on submission")
+	assert.Nil(t, repository.changes[1].Reviewer)
+
+	require.Len(t, repository.events, 2)
+	assert.Equal(t, StateReview, repository.events[0].Requirement.State)
+	assert.Equal(t, StateDraft, repository.events[0].From)
+	assert.Equal(t, author, repository.events[0].ChangedBy)
+	assert.Equal(t, r, repository.events[1].Requirement)
+	assert.Equal(t, StateReview, repository.events[1].From)
+	assert.Equal(t, "too vague", repository.events[1].Comment)
+}
+
+func TestDecodeStateChangedEvent(t *testing.T) {
+	reviewer := uuid.New()
+	changed := &StateChangedEvent{
+		Requirement: &Requirement{ID: uuid.New(), Text: "The system shall work.", State: StateReview, Reviewer: &reviewer},
+		From:        StateDraft,
+		Comment:     "please review",
+		ChangedBy:   uuid.New(),
+		Tenant:      &tenant.Tenant{ID: "uni-a"},
+	}
+
+	payload, err := json.Marshal(changed.Payload())
+	require.NoError(t, err)
+	assert.NotContains(t, string(payload), "uni-a", "the tenant is not stored in the outbox")
+
+	decoded, err := DecodeStateChangedEvent(tenant.WithTenant(context.Background(), changed.Tenant), payload)
+	require.NoError(t, err)
+	assert.Equal(t, changed, decoded)
+
+	decoded, err = DecodeStateChangedEvent(context.Background(), payload)
+	require.NoError(t, err)
+	assert.Nil(t, decoded.(*StateChangedEvent).Tenant)
+
+	_, err = DecodeStateChangedEvent(context.Background(), []byte("{"))
+	assert.Error(t, err)
+}
+
+func TestDecodeReviewReminderEvent(t *testing.T) {
+	reviewer := uuid.New()
+	reminder := &ReviewReminderEvent{
+		Requirement: &Requirement{ID: uuid.New(), Text: "The system shall work.", State: StateReview, Reviewer: &reviewer},
+		Tenant:      &tenant.Tenant{ID: "uni-a"},
+	}
+
+	payload, err := json.Marshal(reminder.Payload())
+	require.NoError(t, err)
+
+	decoded, err := DecodeReviewReminderEvent(tenant.WithTenant(context.Background(), reminder.Tenant), payload)
+	require.NoError(t, err)
+	assert.Equal(t, reminder, decoded)
+	assert.Equal(t, "uni-a", tenant.ID(decoded.(*ReviewReminderEvent).Ctx()))
+}
+
+func (r *reviewRepositoryMock) ChangeState(ctx context.Context, change *StateChange, changed *StateChangedEvent) error {
+	r.changes = append(r.changes, change)
+	r.events = append(r.events, changed)
+	return nil
+}
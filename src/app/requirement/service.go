@@ -0,0 +1,109 @@
+package requirement
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTags is the maximum number of free-form tags of a requirement. Further tags are ignored.
+	MaxTags = 20
+	// MaxTagLength is the maximum length of a tag in characters. Longer free-form tags are ignored.
+	MaxTagLength = 50
+	// TemplateTagPrefix prefixes the automatic tag of the template a requirement was elicited with.
+	TemplateTagPrefix = "template:"
+	// VariantTagPrefix prefixes the automatic tag of the variant a requirement was elicited with.
+	VariantTagPrefix = "variant:"
+)
+
+// ParseTags parses comma-separated free-form tags, e.g. "Security, login ,security" into "security" and "login".
+// The tags are normalized (see NormalizeTag), empty and duplicate tags, tags longer than MaxTagLength
+// and tags using the prefix of an automatic tag are ignored. At most MaxTags tags are returned in the order they were passed in.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = NormalizeTag(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength || IsAutoTag(tag) {
+			continue
+		}
+
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+
+		if len(tags) == MaxTags {
+			break
+		}
+	}
+
+	return tags
+}
+
+// NormalizeTag lower-cases the tag and replaces consecutive whitespace by a single space.
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+// IsAutoTag returns true if the tag uses the prefix of an automatic tag, see AutoTags.
+func IsAutoTag(tag string) bool {
+	return strings.HasPrefix(tag, TemplateTagPrefix) || strings.HasPrefix(tag, VariantTagPrefix)
+}
+
+// AutoTags returns the automatic tags of a requirement elicited with the template and variant, e.g. "template:ziel" and "variant:default".
+func AutoTags(templateName, variantName string) []string {
+	var tags []string
+	if name := NormalizeTag(templateName); name != "" {
+		tags = append(tags, TemplateTagPrefix+name)
+	}
+	if name := NormalizeTag(variantName); name != "" {
+		tags = append(tags, VariantTagPrefix+name)
+	}
+
+	return tags
+}
+
+// TagsToSave returns the automatic tags of the template and variant (see AutoTags) and the free-form tags to save with a requirement.
+func TagsToSave(freeTags []string, templateName, variantName string) []Tag {
+	var tags []Tag
+	for _, tag := range AutoTags(templateName, variantName) {
+		tags = append(tags, Tag{Name: tag, Automatic: true})
+	}
+	for _, tag := range freeTags {
+		tags = append(tags, Tag{Name: tag})
+	}
+
+	return tags
+}
+
+// FreeTags returns the names of the requirement's free-form tags.
+func (r *Requirement) FreeTags() []string {
+	var tags []string
+	for _, tag := range r.Tags {
+		if !tag.Automatic {
+			tags = append(tags, tag.Name)
+		}
+	}
+
+	return tags
+}
+
+// JoinedFreeTags returns the requirement's free-form tags separated by commas, the format parsed by ParseTags.
+func (r *Requirement) JoinedFreeTags() string {
+	return strings.Join(r.FreeTags(), ", ")
+}
+
+// sortTags sorts the tags like the repository returns them: the automatic tags first, each ordered by their name.
+func sortTags(tags []Tag) []Tag {
+	sorted := append([]Tag(nil), tags...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Automatic != sorted[j].Automatic {
+			return sorted[i].Automatic
+		}
+
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return sorted
+}
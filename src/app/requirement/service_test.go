@@ -0,0 +1,37 @@
+package requirement
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{"security", "user login"}, ParseTags(" Security, user   LOGIN ,security,, "))
+	assert.Empty(t, ParseTags(""))
+	assert.Empty(t, ParseTags("template:ziel, variant:default"), "automatic tags can not be set by the user")
+	assert.Empty(t, ParseTags(strings.Repeat("a", MaxTagLength+1)))
+
+	many := make([]string, 0, MaxTags+5)
+	for i := 0; i < MaxTags+5; i++ {
+		many = append(many, strings.Repeat("t", i+1))
+	}
+	assert.Len(t, ParseTags(strings.Join(many, ",")), MaxTags)
+}
+
+func TestTagsToSave(t *testing.T) {
+	assert.Equal(t, []string{"template:esfa 1.0", "variant:default"}, AutoTags("ESFA  1.0", "Default"))
+	assert.Equal(t, []string{"template:esfa"}, AutoTags("ESFA", " "))
+
+	tags := TagsToSave([]string{"security"}, "ESFA", "Default")
+	assert.Equal(t, []Tag{
+		{Name: "template:esfa", Automatic: true},
+		{Name: "variant:default", Automatic: true},
+		{Name: "security"},
+	}, tags)
+
+	requirement := &Requirement{Tags: sortTags([]Tag{{Name: "b"}, {Name: "variant:x", Automatic: true}, {Name: "a"}})}
+	assert.Equal(t, []Tag{{Name: "variant:x", Automatic: true}, {Name: "a"}, {Name: "b"}}, requirement.Tags)
+	assert.Equal(t, []string{"a", "b"}, requirement.FreeTags())
+	assert.Equal(t, "a, b", requirement.JoinedFreeTags())
+}
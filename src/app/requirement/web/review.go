@@ -0,0 +1,194 @@
+package web
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/outbox"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"strings"
+)
+
+// ErrReviewerNotFound is displayed to the user if no user with the email of the reviewer exists.
+var ErrReviewerNotFound = errors.New("requirement.review.reviewer-not-found")
+
+// ReviewData is the data of the review queue: the requirements the user is assigned to review selected by the filter's state.
+type ReviewData struct {
+	Requirements []*requirement.Requirement
+	Filter       requirement.Filter
+	UserID       uuid.UUID
+}
+
+// ReviewLogData is the data of a requirement's review log.
+type ReviewLogData struct {
+	Requirement *requirement.Requirement
+	Entries     []*requirement.ReviewEntry
+}
+
+// registerReviewController registers the controllers of the review workflow on the router of the logged-in users:
+//   - GET /requirement/review Renders the page listing the requirements the user is assigned to review, by default those in review.
+//   - GET /requirement/review/list Renders the requirements the user is assigned to review in the state (state).
+//   - POST /requirement/{id}/state Changes the state (state) of a requirement with an optional comment (comment).
+//     Submitting a requirement for review assigns the user with the email (reviewer) as its reviewer.
+//   - GET /requirement/{id}/review-log Renders the review log of a requirement.
+//
+// The decoders of the requirement.StateChangedEvent and requirement.ReviewReminderEvent are registered for the outbox.Relay to publish them.
+func registerReviewController(appCtx *hctx.AppCtx, webCtx *web.Ctx, router web.Router) {
+	registerReviewNavigation(webCtx)
+	outbox.Register(requirement.StateChangedEventID, requirement.DecodeStateChangedEvent)
+	outbox.Register(requirement.ReviewReminderEventID, requirement.DecodeReviewReminderEvent)
+	webCtx.Errors.Map(ErrReviewerNotFound, http.StatusUnprocessableEntity, ErrReviewerNotFound)
+	for _, err := range []error{
+		requirement.ErrInvalidTransition,
+		requirement.ErrNoReviewer,
+		requirement.ErrSelfReview,
+		requirement.ErrCommentTooLong,
+		requirement.ErrStateChanged,
+	} {
+		webCtx.Errors.Map(err, http.StatusUnprocessableEntity, err)
+	}
+
+	router.Get("/requirement/review", reviewListController(appCtx, webCtx, true).ServeHTTP)
+	router.Get("/requirement/review/list", reviewListController(appCtx, webCtx, false).ServeHTTP)
+	router.Post("/requirement/{id}/state", stateController(appCtx, webCtx).ServeHTTP)
+	router.Get("/requirement/{id}/review-log", reviewLogController(appCtx, webCtx).ServeHTTP)
+}
+
+// Item returns the data of the listed requirement viewed by the reviewer.
+func (d ReviewData) Item(r *requirement.Requirement) ItemData {
+	return ItemData{Requirement: r, UserID: d.UserID}
+}
+
+// States returns the states the requirements can be filtered by.
+func (d ReviewData) States() []requirement.State {
+	return requirement.States
+}
+
+func registerReviewNavigation(webCtx *web.Ctx) {
+	webCtx.Navigation.Add("requirement.review", web.NavItem{
+		URL:      "/requirement/review",
+		Name:     "harmony.menu.reviews",
+		Position: 121,
+	})
+}
+
+// reviewListController renders the requirements the user is assigned to review, either as page or only the list.
+// The page lists the requirements in review unless another state is requested.
+func reviewListController(appCtx *hctx.AppCtx, webCtx *web.Ctx, page bool) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		usr := user.MustFromIO(io)
+		repository := web.MustRepository[requirement.Repository](io, requirement.RepositoryName)
+
+		filter := FilterFromRequest(io.Request())
+		if page && !io.Request().URL.Query().Has("state") {
+			filter.State = requirement.StateReview
+		}
+		filter.Limit = MaxListed
+
+		requirements, err := repository.FindByReviewer(io.Context(), usr.ID, filter)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		data := &ReviewData{Requirements: requirements, Filter: filter, UserID: usr.ID}
+		if page {
+			return io.Render(data, "requirement.review.page", "requirement/review-page.go.html", "requirement/_review.go.html", "requirement/_list.go.html")
+		}
+
+		return io.Render(data, "requirement.review.list", "requirement/_review.go.html", "requirement/_list.go.html")
+	})
+}
+
+func stateController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	reviewRepository := util.UnwrapType[requirement.ReviewRepository](appCtx.Repository(requirement.ReviewRepositoryName))
+	userRepository := util.UnwrapType[user.Repository](appCtx.Repository(user.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ctx := io.Context()
+		request := io.Request()
+		usr := user.MustFromIO(io)
+
+		r, err := participatingRequirement(io)
+		if err != nil {
+			return io.InlineError(readErrs(err)...)
+		}
+
+		to, ok := requirement.ParseState(request.FormValue("state"))
+		if !ok {
+			return io.InlineError(requirement.ErrInvalidTransition)
+		}
+
+		var reviewer *uuid.UUID
+		if email := strings.TrimSpace(request.FormValue("reviewer")); email != "" {
+			u, err := userRepository.FindByEmail(ctx, email)
+			if err != nil && errors.Is(err, persistence.ErrNotFound) {
+				return io.InlineError(ErrReviewerNotFound, err)
+			} else if err != nil {
+				return io.InlineError(web.ErrInternal, err)
+			}
+
+			reviewer = &u.ID
+		}
+
+		err = requirement.ChangeState(ctx, reviewRepository, r, usr.ID, to, reviewer, strings.TrimSpace(request.FormValue("comment")))
+		if err != nil && errors.Is(err, persistence.ErrUpdate) {
+			return io.InlineError(web.ErrInternal, err)
+		} else if err != nil {
+			return io.InlineError(err)
+		}
+
+		// the reviewer's email is only joined onto read requirements
+		r, err = participatingRequirement(io)
+		if err != nil {
+			return io.InlineError(readErrs(err)...)
+		}
+
+		return io.Render(ItemData{Requirement: r, UserID: usr.ID}, "requirement.item", "requirement/_list.go.html")
+	})
+}
+
+func reviewLogController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	reviewRepository := util.UnwrapType[requirement.ReviewRepository](appCtx.Repository(requirement.ReviewRepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		r, err := participatingRequirement(io)
+		if err != nil {
+			return io.InlineError(readErrs(err)...)
+		}
+
+		entries, err := reviewRepository.FindEntries(io.Context(), r.ID)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return io.Render(ReviewLogData{Requirement: r, Entries: entries}, "requirement.review.log", "requirement/_review.go.html")
+	})
+}
+
+// participatingRequirement reads the requirement of the id URL parameter if the user is its author or reviewer.
+// It returns ErrInvalidID if the parameter is not a valid UUID and persistence.ErrNotFound if the requirement could not be found.
+func participatingRequirement(io web.IO) (*requirement.Requirement, error) {
+	repository := web.MustRepository[requirement.Repository](io, requirement.RepositoryName)
+
+	id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	return repository.FindByIDAsParticipant(io.Context(), user.MustFromIO(io).ID, id)
+}
+
+// readErrs returns the errors to display if a requirement could not be read: web.ErrNotFound for missing requirements
+// and web.ErrInternal otherwise, followed by the cause.
+func readErrs(err error) []error {
+	if errors.Is(err, ErrInvalidID) || errors.Is(err, persistence.ErrNotFound) {
+		return []error{web.ErrNotFound, err}
+	}
+
+	return []error{web.ErrInternal, err}
+}
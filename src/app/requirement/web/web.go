@@ -0,0 +1,286 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/eiffel"
+	"github.com/org-harmony/harmony/src/app/project"
+	"github.com/org-harmony/harmony/src/app/reqif"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxListed is the maximum number of requirements listed at once. The export is not limited.
+const MaxListed = 100
+
+// ErrInvalidID is returned if the requirement id of the request is not a valid UUID.
+var ErrInvalidID = errors.New("invalid requirement id")
+
+// ListData is the data of the requirements list. Tags are all tags of the user's requirements, Filter selects the listed requirements.
+// Project is the user's current project the requirements are scoped to, nil if the user has no current project.
+type ListData struct {
+	Requirements []*requirement.Requirement
+	Tags         []*requirement.TagCount
+	Filter       requirement.Filter
+	Project      *project.Project
+	UserID       uuid.UUID
+}
+
+// ItemData is the data of a listed requirement. The actions on the requirement depend on the user viewing it:
+// only the author manages its tags and deletes it, the author and the reviewer change its state.
+type ItemData struct {
+	*requirement.Requirement
+	UserID uuid.UUID
+}
+
+// RegisterController registers the controllers of the requirement module. The listed and exported requirements
+// are scoped to the user's current project (see project.Current):
+//   - GET /requirement Renders the page listing the user's requirements.
+//   - GET /requirement/list Renders the requirements selected by the tags (tag), the text search (q) and the state (state).
+//   - GET /requirement/export/reqif Exports the requirements selected by the tags (tag), the text search (q) and the state (state) as ReqIF.
+//   - PUT /requirement/{id}/tags Replaces the free-form tags of a requirement by the comma-separated tags (tags).
+//   - DELETE /requirement/{id} Deletes a requirement.
+//
+// The controllers of the review workflow are registered through registerReviewController.
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	registerNavigation(webCtx)
+	webCtx.Errors.Map(ErrInvalidID, http.StatusNotFound, web.ErrNotFound)
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/requirement", listController(appCtx, webCtx, true).ServeHTTP)
+	router.Get("/requirement/list", listController(appCtx, webCtx, false).ServeHTTP)
+	router.Get("/requirement/export/reqif", exportReqIFController(appCtx, webCtx).ServeHTTP)
+	router.Put("/requirement/{id}/tags", tagsController(appCtx, webCtx).ServeHTTP)
+	router.Delete("/requirement/{id}", deleteController(appCtx, webCtx).ServeHTTP)
+
+	registerReviewController(appCtx, webCtx, router)
+}
+
+// FilterFromRequest reads the filter of the requirements from the request's query: the tags (tag, repeatable), the text search (q)
+// and the review state (state). The tags are normalized (see requirement.NormalizeTag), empty and duplicate tags are ignored.
+// Unknown states are ignored.
+func FilterFromRequest(request *http.Request) requirement.Filter {
+	query := request.URL.Query()
+	filter := requirement.Filter{Query: strings.TrimSpace(query.Get("q"))}
+	if state, ok := requirement.ParseState(query.Get("state")); ok {
+		filter.State = state
+	}
+
+	for _, tag := range query["tag"] {
+		tag = requirement.NormalizeTag(tag)
+		if tag != "" && !slices.Contains(filter.Tags, tag) {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	return filter
+}
+
+// Selected returns true if the requirements are filtered by the tag.
+func (d ListData) Selected(tag string) bool {
+	return slices.Contains(d.Filter.Tags, tag)
+}
+
+// ToggleQuery returns the query string of the filter with the tag added or removed if it is already selected.
+func (d ListData) ToggleQuery(tag string) string {
+	tags := slices.DeleteFunc(slices.Clone(d.Filter.Tags), func(t string) bool { return t == tag })
+	if !d.Selected(tag) {
+		tags = append(tags, tag)
+	}
+
+	return filterQuery(requirement.Filter{Tags: tags, Query: d.Filter.Query, State: d.Filter.State})
+}
+
+// Query returns the query string of the filter, e.g. to link to the export of the listed requirements.
+func (d ListData) Query() string {
+	return filterQuery(d.Filter)
+}
+
+// States returns the states the requirements can be filtered by.
+func (d ListData) States() []requirement.State {
+	return requirement.States
+}
+
+// Item returns the data of the listed requirement viewed by the user.
+func (d ListData) Item(r *requirement.Requirement) ItemData {
+	return ItemData{Requirement: r, UserID: d.UserID}
+}
+
+// IsAuthor returns true if the user viewing the requirement is its author.
+func (d ItemData) IsAuthor() bool {
+	return d.CreatedBy == d.UserID
+}
+
+// NextStates returns the states the user viewing the requirement can change it into, see requirement.Requirement.NextStates.
+func (d ItemData) NextStates() []requirement.State {
+	return d.Requirement.NextStates(d.UserID)
+}
+
+func registerNavigation(webCtx *web.Ctx) {
+	webCtx.Navigation.Add("requirement.list", web.NavItem{
+		URL:      "/requirement",
+		Name:     "harmony.menu.requirements",
+		Position: 120,
+	})
+}
+
+// listController renders the requirements selected by the request's filter, either as page or only the list.
+func listController(appCtx *hctx.AppCtx, webCtx *web.Ctx, page bool) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		data, err := listData(io, FilterFromRequest(io.Request()))
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		if page {
+			return io.Render(data, "requirement.list.page", "requirement/list-page.go.html", "requirement/_list.go.html")
+		}
+
+		return io.Render(data, "requirement.list", "requirement/_list.go.html")
+	})
+}
+
+func exportReqIFController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ctx := io.Context()
+		usr := user.MustFromIO(io)
+		repository := web.MustRepository[requirement.Repository](io, requirement.RepositoryName)
+
+		filter := FilterFromRequest(io.Request())
+		filter.Project = project.CurrentID(
+			ctx,
+			web.MustRepository[user.PreferenceRepository](io, user.PreferenceRepositoryName),
+			web.MustRepository[project.Repository](io, project.RepositoryName),
+			usr.ID,
+		)
+
+		requirements, err := repository.Find(ctx, usr.ID, filter)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		exported := make([]eiffel.ExportedRequirement, 0, len(requirements))
+		for _, r := range requirements {
+			e := eiffel.ExportedRequirement{
+				Requirement: r.Text,
+				Identifier:  r.Identifier,
+				Output:      r.Output,
+				TemplateID:  r.TemplateID.String(),
+				Template:    r.Template,
+				Variant:     r.Variant,
+			}
+			for _, segment := range r.Segments {
+				e.Segments = append(e.Segments, eiffel.ExportedSegment{Rule: segment.Rule, Value: segment.Value})
+			}
+
+			exported = append(exported, e)
+		}
+
+		response := io.Response()
+		response.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"requirements-%s.reqif\"", time.Now().Format("2006-01-02")))
+
+		return reqif.Write(response, eiffel.RequirementsReqIF(io.Translator().T("requirement.export.title"), exported))
+	})
+}
+
+func tagsController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		ctx := io.Context()
+		usr := user.MustFromIO(io)
+		repository := web.MustRepository[requirement.Repository](io, requirement.RepositoryName)
+		tagRepository := web.MustRepository[requirement.TagRepository](io, requirement.TagRepositoryName)
+
+		id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+		if err != nil {
+			return io.InlineError(ErrInvalidID, err)
+		}
+
+		err = tagRepository.SetFreeTags(ctx, usr.ID, id, requirement.ParseTags(io.Request().FormValue("tags")))
+		if err != nil && errors.Is(err, persistence.ErrNotFound) {
+			return io.InlineError(web.ErrNotFound, err)
+		} else if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		r, err := repository.FindByID(ctx, usr.ID, id)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return io.Render(ItemData{Requirement: r, UserID: usr.ID}, "requirement.item", "requirement/_list.go.html")
+	})
+}
+
+func deleteController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		repository := web.MustRepository[requirement.Repository](io, requirement.RepositoryName)
+
+		id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+		if err != nil {
+			return io.InlineError(ErrInvalidID, err)
+		}
+
+		if err := repository.Delete(io.Context(), user.MustFromIO(io).ID, id); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return nil
+	})
+}
+
+// listData reads the requirements of the user's current project selected by the filter (at most MaxListed)
+// and all tags of the user's requirements.
+func listData(io web.IO, filter requirement.Filter) (*ListData, error) {
+	ctx := io.Context()
+	usr := user.MustFromIO(io)
+	repository := web.MustRepository[requirement.Repository](io, requirement.RepositoryName)
+	tagRepository := web.MustRepository[requirement.TagRepository](io, requirement.TagRepositoryName)
+	preferences := web.MustRepository[user.PreferenceRepository](io, user.PreferenceRepositoryName)
+
+	current, err := project.Current(ctx, preferences, web.MustRepository[project.Repository](io, project.RepositoryName), usr.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		filter.Project = current.ID
+	}
+
+	filter.Limit = MaxListed
+	requirements, err := repository.Find(ctx, usr.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := tagRepository.FindByUser(ctx, usr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListData{Requirements: requirements, Tags: tags, Filter: filter, Project: current, UserID: usr.ID}, nil
+}
+
+// filterQuery encodes the filter's tags, text search and state as query string, see FilterFromRequest.
+func filterQuery(filter requirement.Filter) string {
+	values := url.Values{}
+	if filter.Query != "" {
+		values.Set("q", filter.Query)
+	}
+	if filter.State != "" {
+		values.Set("state", string(filter.State))
+	}
+	for _, tag := range filter.Tags {
+		values.Add("tag", tag)
+	}
+
+	return values.Encode()
+}
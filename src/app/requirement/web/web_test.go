@@ -0,0 +1,31 @@
+package web
+
+import (
+	"github.com/org-harmony/harmony/src/app/requirement"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFilterFromRequest(t *testing.T) {
+	request := httptest.NewRequest("GET", "/requirement/list?q=+login+&tag=Security&tag=security&tag=&tag=template:esfa", nil)
+	filter := FilterFromRequest(request)
+	assert.Equal(t, requirement.Filter{Query: "login", Tags: []string{"security", "template:esfa"}}, filter)
+
+	data := ListData{Filter: filter}
+	assert.True(t, data.Selected("security"))
+	assert.Equal(t, "q=login&tag=security&tag=template%3Aesfa", data.Query())
+	assert.Equal(t, "q=login&tag=template%3Aesfa", data.ToggleQuery("security"))
+	assert.Equal(t, "q=login&tag=security&tag=template%3Aesfa&tag=ui", data.ToggleQuery("ui"))
+	assert.Equal(t, []string{"security", "template:esfa"}, data.Filter.Tags, "toggling does not change the filter")
+}
+
+func TestFilterFromRequestState(t *testing.T) {
+	filter := FilterFromRequest(httptest.NewRequest("GET", "/requirement/list?state=review&tag=ui", nil))
+	assert.Equal(t, requirement.StateReview, filter.State)
+	assert.Equal(t, "state=review&tag=ui", ListData{Filter: filter}.Query())
+	assert.Equal(t, "state=review", ListData{Filter: filter}.ToggleQuery("ui"))
+
+	filter = FilterFromRequest(httptest.NewRequest("GET", "/requirement/list?state=archived", nil))
+	assert.Empty(t, filter.State, "unknown states are ignored")
+}
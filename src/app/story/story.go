@@ -0,0 +1,318 @@
+// Package story implements user story templates: a sentence of role, feature and benefit (e.g. "As a user, I want
+// to reset my password so that I can log in again.") followed by a repeatable list of acceptance criteria (Given/When/Then).
+// The acceptance criteria are a repeatable segment group, see parser.GroupSegments. Story templates are a template type
+// of their own (TemplateType), they are validated through the template.ValidateTemplateConfigEvent (see SubscribeTemplateValidation).
+package story
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// TemplateType is the type name of user story templates used to identify the corresponding parser for a template.
+	TemplateType = "story"
+	// Pkg is the package name for logging.
+	Pkg = "app.story"
+	// CriteriaConstraint is the name of the constraint reported if the story has too few or too many acceptance criteria.
+	CriteriaConstraint = "criteria"
+	// MaxCriteria is the maximum number of acceptance criteria of a story.
+	MaxCriteria = 20
+	// SegmentRole is the key of the segment naming the role wanting the feature, e.g. "user".
+	SegmentRole = "role"
+	// SegmentFeature is the key of the segment describing the feature, e.g. "to reset my password".
+	SegmentFeature = "feature"
+	// SegmentBenefit is the key of the segment describing the benefit of the feature, e.g. "I can log in again".
+	SegmentBenefit = "benefit"
+	// SegmentGiven is the key of the precondition of an acceptance criterion.
+	SegmentGiven = "given"
+	// SegmentWhen is the key of the action of an acceptance criterion.
+	SegmentWhen = "when"
+	// SegmentThen is the key of the expected outcome of an acceptance criterion.
+	SegmentThen = "then"
+)
+
+var (
+	// ErrInvalidConfig is returned if the config of a story template is no valid JSON.
+	ErrInvalidConfig = errors.New("story.error.invalid-config")
+	// ErrInvalidCriteria is returned if the minimum or maximum number of acceptance criteria is out of range.
+	ErrInvalidCriteria = errors.New("story.error.invalid-criteria")
+)
+
+var (
+	// SentenceSegments are the keys of the segments of the story's sentence in the order they are written.
+	SentenceSegments = []string{SegmentRole, SegmentFeature, SegmentBenefit}
+	// CriterionSegments are the keys of the segments of an acceptance criterion in the order they are written.
+	CriterionSegments = []string{SegmentGiven, SegmentWhen, SegmentThen}
+)
+
+// Template is a user story template. The segments of the story are fixed, the template configures their names,
+// prefixes and hints as well as the number of acceptance criteria.
+type Template struct {
+	// ID is the unique identifier of the template.
+	ID string `json:"id" hvalidate:"required"`
+	// Name is the name of the template.
+	Name string `json:"name" hvalidate:"required"`
+	// Version is the version of the template. It must be a semantic version.
+	Version string `json:"version" hvalidate:"required,semVer"`
+	// Authors is a list of authors of the template.
+	Authors []string `json:"authors"`
+	// License is the license of the template.
+	License string `json:"license"`
+	// Description is a description of the template.
+	Description string `json:"description"`
+	// Segments configures the segments keyed by their keys (SentenceSegments and CriterionSegments).
+	// Segments that are not configured use their default, see DefaultSegments.
+	Segments map[string]Segment `json:"segments"`
+	// MinCriteria is the minimum number of acceptance criteria. Stories without acceptance criteria are allowed by default.
+	MinCriteria int `json:"minCriteria"`
+	// MaxCriteria is the maximum number of acceptance criteria. It defaults to and can not exceed the package's MaxCriteria.
+	MaxCriteria int `json:"maxCriteria"`
+}
+
+// Segment configures a segment of the story.
+type Segment struct {
+	// Name is the name of the segment displayed as the input's label. It defaults to the default segment's name.
+	Name string `json:"name"`
+	// Prefix is written in front of the segment's value, e.g. "As a" or "Given". It defaults to the default segment's prefix.
+	Prefix string `json:"prefix"`
+	// Hint is an optional hint displayed as the input's placeholder.
+	Hint string `json:"hint"`
+	// Optional segments may be left empty.
+	Optional bool `json:"optional"`
+}
+
+// SegmentError is a validation error of a configured segment of a story template.
+type SegmentError struct {
+	Segment string
+	// Msg is the error message. It is translated using the parameter "segment" (segment's key).
+	Msg string
+}
+
+// DefaultSegments returns the default segments keyed by their keys. Only the benefit is optional.
+func DefaultSegments() map[string]Segment {
+	return map[string]Segment{
+		SegmentRole:    {Name: "Role", Prefix: "As a"},
+		SegmentFeature: {Name: "Feature", Prefix: "I want"},
+		SegmentBenefit: {Name: "Benefit", Prefix: "so that", Optional: true},
+		SegmentGiven:   {Name: "Given", Prefix: "Given"},
+		SegmentWhen:    {Name: "When", Prefix: "When"},
+		SegmentThen:    {Name: "Then", Prefix: "Then"},
+	}
+}
+
+// FromConfig parses the config of a story template. It returns ErrInvalidConfig if the config is no valid JSON.
+// The template is not validated, use Validate for that.
+func FromConfig(config string) (*Template, error) {
+	t := &Template{}
+	if err := json.Unmarshal([]byte(config), t); err != nil {
+		return nil, errors.Join(ErrInvalidConfig, err)
+	}
+
+	return t, nil
+}
+
+// IntoTemplate parses the template's config into a story Template and validates it.
+// It returns template.ErrInvalidTemplate if the config is invalid.
+func IntoTemplate(tmpl *template.Template, validator validation.V) (*Template, error) {
+	t, err := FromConfig(tmpl.Config)
+	if err != nil {
+		return nil, template.ErrInvalidTemplate
+	}
+
+	if errs := t.Validate(validator); len(errs) > 0 {
+		return nil, template.ErrInvalidTemplate
+	}
+
+	return t, nil
+}
+
+// Validate validates the template. Only known segments may be configured and the number of acceptance criteria
+// must be within 0 and MaxCriteria. It returns a slice of errors that are safe to display to the user,
+// template.ErrInvalidTemplate is always part of a non-empty slice.
+func (t *Template) Validate(v validation.V) []error {
+	err, errs := v.ValidateStruct(t)
+	if err != nil {
+		return []error{template.ErrInvalidTemplate, err}
+	}
+
+	defaults := DefaultSegments()
+	for key := range t.Segments {
+		if _, ok := defaults[key]; !ok {
+			errs = append(errs, SegmentError{Segment: key, Msg: "story.error.unknown-segment"})
+		}
+	}
+
+	if t.MinCriteria < 0 || t.MaxCriteria < 0 || t.MaxCriteria > MaxCriteria || t.MinCriteria > t.CriteriaLimit() {
+		errs = append(errs, ErrInvalidCriteria)
+	}
+
+	if len(errs) > 0 {
+		return append(errs, template.ErrInvalidTemplate)
+	}
+
+	return nil
+}
+
+// Segment returns the configured segment of the key. Name and prefix default to the default segment's (see DefaultSegments).
+// If the segment is not configured the default segment is returned.
+func (t *Template) Segment(key string) Segment {
+	segment, ok := t.Segments[key]
+	if !ok {
+		return DefaultSegments()[key]
+	}
+
+	defaultSegment := DefaultSegments()[key]
+	if segment.Name == "" {
+		segment.Name = defaultSegment.Name
+	}
+	if segment.Prefix == "" {
+		segment.Prefix = defaultSegment.Prefix
+	}
+
+	return segment
+}
+
+// CriteriaLimit returns the maximum number of acceptance criteria of a story using the template.
+func (t *Template) CriteriaLimit() int {
+	if t.MaxCriteria == 0 {
+		return MaxCriteria
+	}
+
+	return t.MaxCriteria
+}
+
+// Parse parses the story's segments. The sentence's segments are named by their keys (e.g. "role"), the segments of the
+// acceptance criteria are repeated segments indexed by their occurrence (e.g. "given[0]", see parser.IndexedSegmentName).
+// Empty acceptance criteria are ignored, logs are indexed by the occurrences of parser.GroupSegments. Empty required
+// segments are errors of the segment, too few or too many acceptance criteria are errors of the CriteriaConstraint. If the story is valid, the result's requirement is the story's sentence
+// followed by its acceptance criteria, e.g. "As a user, I want to log in.\n\nGiven a user\nWhen logging in\nThen it works".
+func (t *Template) Parse(segments ...parser.ParsingSegment) parser.ParsingResult {
+	result := parser.ParsingResult{
+		TemplateID:      t.ID,
+		TemplateType:    TemplateType,
+		TemplateVersion: t.Version,
+		TemplateName:    t.Name,
+	}
+
+	values := make(map[string]string, len(SentenceSegments))
+	for _, segment := range segments {
+		values[segment.Name] = strings.TrimSpace(segment.Value)
+	}
+
+	clauses := make(map[string]string, len(SentenceSegments))
+	for _, key := range SentenceSegments {
+		clause, log := t.clause(key, key, values[key])
+		if log != nil {
+			result.Errors = append(result.Errors, *log)
+		}
+		clauses[key] = clause
+	}
+
+	var criteria []string
+	for i, row := range parser.GroupSegments(CriterionSegments, segments...) {
+		if row.Empty() {
+			continue
+		}
+
+		lines := make([]string, 0, len(CriterionSegments))
+		for _, key := range CriterionSegments {
+			line, log := t.clause(key, parser.IndexedSegmentName(key, i), row[key])
+			if log != nil {
+				result.Errors = append(result.Errors, *log)
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		criteria = append(criteria, strings.Join(lines, "\n"))
+	}
+
+	if len(criteria) < t.MinCriteria {
+		result.Errors = append(result.Errors, t.criteriaLog("story.parser.too-few-criteria", "min", t.MinCriteria))
+	}
+	if len(criteria) > t.CriteriaLimit() {
+		result.Errors = append(result.Errors, t.criteriaLog("story.parser.too-many-criteria", "max", t.CriteriaLimit()))
+	}
+
+	if !result.Ok() {
+		return result
+	}
+
+	result.Requirement = sentence(clauses)
+	if len(criteria) > 0 {
+		result.Requirement += "\n\n" + strings.Join(criteria, "\n\n")
+	}
+
+	return result
+}
+
+// clause returns the segment's value with the segment's prefix. If a required segment is empty a log is returned instead.
+// The name is the name of the parsed segment, e.g. the grouped name of a criterion's segment.
+func (t *Template) clause(key string, name string, value string) (string, *parser.ParsingLog) {
+	segment := t.Segment(key)
+	if value != "" {
+		return strings.TrimSpace(segment.Prefix + " " + value), nil
+	}
+	if segment.Optional {
+		return "", nil
+	}
+
+	return "", &parser.ParsingLog{
+		Segment:         &parser.ParsingSegment{Name: name, Value: value},
+		Level:           parser.ParsingLogLevelError,
+		Message:         "story.parser.missing-segment",
+		TranslationArgs: []string{"segment", segment.Name},
+	}
+}
+
+func (t *Template) criteriaLog(msg string, arg string, value int) parser.ParsingLog {
+	return parser.ParsingLog{
+		Level:           parser.ParsingLogLevelError,
+		Message:         msg,
+		TranslationArgs: []string{arg, strconv.Itoa(value)},
+		Constraint:      CriteriaConstraint,
+	}
+}
+
+// sentence joins the clauses of the story's sentence keyed by the segments' keys: the role and the feature are separated
+// by a comma, the benefit follows the feature. The sentence ends with a period unless it ends with a punctuation mark.
+func sentence(clauses map[string]string) string {
+	var parts []string
+	for _, key := range []string{SegmentRole, SegmentFeature} {
+		if clauses[key] != "" {
+			parts = append(parts, clauses[key])
+		}
+	}
+
+	s := strings.Join(parts, ", ")
+	if benefit := clauses[SegmentBenefit]; benefit != "" {
+		s = strings.TrimSpace(s + " " + benefit)
+	}
+	if s == "" {
+		return ""
+	}
+
+	if runes := []rune(s); !unicode.IsPunct(runes[len(runes)-1]) {
+		s += "."
+	}
+
+	return s
+}
+
+// Error returns the translation key of the error.
+func (e SegmentError) Error() string {
+	return e.Msg
+}
+
+// Translate on SegmentError translates the error using the given translator. The segment's key is passed in.
+func (e SegmentError) Translate(t trans.Translator) string {
+	return t.Tf(e.Error(), "segment", e.Segment)
+}
@@ -0,0 +1,128 @@
+package story
+
+import (
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testConfig = `{
+	"id": "story",
+	"type": "story",
+	"name": "Story",
+	"version": "1.0.0",
+	"segments": {
+		"role": {"hint": "user"},
+		"benefit": {"prefix": "so that", "optional": false}
+	},
+	"minCriteria": 1,
+	"maxCriteria": 2
+}`
+
+func TestFromConfig(t *testing.T) {
+	tmpl, err := FromConfig(testConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "Story", tmpl.Name)
+	assert.Equal(t, 1, tmpl.MinCriteria)
+	assert.Equal(t, 2, tmpl.CriteriaLimit())
+	assert.Empty(t, tmpl.Validate(validation.New()))
+
+	assert.Equal(t, Segment{Name: "Role", Prefix: "As a", Hint: "user"}, tmpl.Segment(SegmentRole))
+	assert.Equal(t, Segment{Name: "Benefit", Prefix: "so that"}, tmpl.Segment(SegmentBenefit))
+	assert.Equal(t, DefaultSegments()[SegmentGiven], tmpl.Segment(SegmentGiven))
+
+	_, err = FromConfig(`{"segments": [`)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestValidate(t *testing.T) {
+	v := validation.New()
+
+	tmpl := &Template{ID: "story", Name: "Story", Version: "1.0.0", Segments: map[string]Segment{"actor": {}}, MinCriteria: 3, MaxCriteria: 2}
+	errs := tmpl.Validate(v)
+	assert.Contains(t, errs, SegmentError{Segment: "actor", Msg: "story.error.unknown-segment"})
+	assert.Contains(t, errs, ErrInvalidCriteria)
+	assert.Contains(t, errs, template.ErrInvalidTemplate)
+
+	tmpl = &Template{ID: "story", Name: "Story", Version: "1.0.0", MaxCriteria: MaxCriteria + 1}
+	assert.Contains(t, tmpl.Validate(v), ErrInvalidCriteria)
+
+	tmpl = &Template{ID: "story", Name: "Story", Version: "1.0.0", MinCriteria: MaxCriteria}
+	assert.Empty(t, tmpl.Validate(v))
+}
+
+func TestParse(t *testing.T) {
+	tmpl := &Template{ID: "story", Name: "Story", Version: "1.0.0"}
+
+	result := tmpl.Parse(
+		parser.ParsingSegment{Name: "role", Value: " user "},
+		parser.ParsingSegment{Name: "feature", Value: "to reset my password"},
+		parser.ParsingSegment{Name: "benefit", Value: "I can log in again"},
+		parser.ParsingSegment{Name: "given[0]", Value: ""},
+		parser.ParsingSegment{Name: "given[1]", Value: "a registered user"},
+		parser.ParsingSegment{Name: "when[1]", Value: "the user requests a reset"},
+		parser.ParsingSegment{Name: "then[1]", Value: "an email is sent"},
+		parser.ParsingSegment{Name: "given[4]", Value: "an unknown email"},
+		parser.ParsingSegment{Name: "when[4]", Value: "a reset is requested"},
+		parser.ParsingSegment{Name: "then[4]", Value: "no email is sent!"},
+	)
+	require.True(t, result.Ok())
+	assert.Equal(t, TemplateType, result.TemplateType)
+	assert.Equal(t, "As a user, I want to reset my password so that I can log in again.\n\n"+
+		"Given a registered user\nWhen the user requests a reset\nThen an email is sent\n\n"+
+		"Given an unknown email\nWhen a reset is requested\nThen no email is sent!", result.Requirement)
+
+	optionalRole := &Template{ID: "story", Name: "Story", Version: "1.0.0", Segments: map[string]Segment{"role": {Optional: true}}}
+	result = optionalRole.Parse(parser.ParsingSegment{Name: "feature", Value: "to log in"})
+	require.True(t, result.Ok())
+	assert.Equal(t, "I want to log in.", result.Requirement)
+
+	result = tmpl.Parse(
+		parser.ParsingSegment{Name: "role", Value: "user"},
+		parser.ParsingSegment{Name: "given[0]", Value: "a user"},
+		parser.ParsingSegment{Name: "given[1]", Value: "an admin"},
+		parser.ParsingSegment{Name: "when[1]", Value: "logging in"},
+	)
+	assert.False(t, result.Ok())
+	assert.Empty(t, result.Requirement)
+	require.Len(t, result.ViolationsForRule("feature"), 1)
+	assert.Equal(t, "story.parser.missing-segment", result.ViolationsForRule("feature")[0].Message)
+	assert.Equal(t, []string{"segment", "Feature"}, result.ViolationsForRule("feature")[0].TranslationArgs)
+	assert.Len(t, result.ViolationsForRule("when[0]"), 1)
+	assert.Len(t, result.ViolationsForRule("then[0]"), 1)
+	assert.Len(t, result.ViolationsForRule("then[1]"), 1)
+	assert.Empty(t, result.ViolationsForRule("when[1]"))
+	assert.Empty(t, result.ConstraintLogs())
+}
+
+func TestParse_Criteria(t *testing.T) {
+	tmpl, err := FromConfig(testConfig)
+	require.NoError(t, err)
+
+	segments := []parser.ParsingSegment{
+		{Name: "role", Value: "user"},
+		{Name: "feature", Value: "to log in"},
+		{Name: "benefit", Value: "I can work"},
+	}
+
+	result := tmpl.Parse(segments...)
+	require.Len(t, result.ConstraintLogs(), 1)
+	assert.Equal(t, "story.parser.too-few-criteria", result.ConstraintLogs()[0].Message)
+	assert.Equal(t, []string{"min", "1"}, result.ConstraintLogs()[0].TranslationArgs)
+
+	criteria := []parser.SegmentGroupRow{
+		{"given": "a", "when": "b", "then": "c"},
+		{"given": "d", "when": "e", "then": "f"},
+		{"given": "g", "when": "h", "then": "i"},
+	}
+	result = tmpl.Parse(append(segments, parser.UngroupSegments(criteria...)...)...)
+	require.Len(t, result.ConstraintLogs(), 1)
+	assert.Equal(t, "story.parser.too-many-criteria", result.ConstraintLogs()[0].Message)
+	assert.Equal(t, []string{"max", "2"}, result.ConstraintLogs()[0].TranslationArgs)
+
+	result = tmpl.Parse(append(segments, parser.UngroupSegments(criteria[:2]...)...)...)
+	assert.True(t, result.Ok())
+}
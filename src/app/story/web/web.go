@@ -0,0 +1,202 @@
+package web
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/story"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrTemplateNotFound is displayed to the user if the story template could not be found or belongs to another user.
+var ErrTemplateNotFound = errors.New("story.error.template-not-found")
+
+// FormData is the data of the story page and its elicitation form.
+type FormData struct {
+	// Templates are the user's story templates to choose from. They are only filled on the story page.
+	Templates []*template.Template
+	// Template is the chosen story template. It is nil if no template was chosen.
+	Template *story.Template
+	// TemplateID is the ID of the template that is elicited.
+	TemplateID uuid.UUID
+	// Segments are the values of the story's sentence keyed by the segments' keys.
+	Segments map[string]string
+	// Criteria are the rows of the acceptance criteria in the form, including empty rows.
+	Criteria []parser.SegmentGroupRow
+	// ParsingResult is the result of parsing the story. It is nil if the story was not parsed yet.
+	ParsingResult *parser.ParsingResult
+}
+
+// RegisterController registers the controllers of user story templates and subscribes to the validation of their configs:
+//   - GET /story Renders the story page listing the user's story templates.
+//   - GET /story/{templateID} Renders the story page with the elicitation form of the template.
+//   - POST /story/{templateID} Parses the story (segment-<key>, segment-<key>[<row>]) and renders the form with the result.
+//   - POST /story/{templateID}/criteria Adds (action=add) or removes (action=remove, row=<row>) a row of acceptance criteria
+//     and renders the form without parsing it.
+func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
+	// TODO remove this with module manager
+	story.SubscribeTemplateValidation(appCtx.EventManager, appCtx.Validator)
+
+	registerNavigation(webCtx)
+
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
+	router.Get("/story", storyPage(appCtx, webCtx, templateRepository).ServeHTTP)
+	router.Get("/story/{templateID}", storyPage(appCtx, webCtx, templateRepository).ServeHTTP)
+	router.Post("/story/{templateID}", parseStory(appCtx, webCtx, templateRepository).ServeHTTP)
+	router.Post("/story/{templateID}/criteria", editCriteria(appCtx, webCtx, templateRepository).ServeHTTP)
+}
+
+func registerNavigation(webCtx *web.Ctx) {
+	webCtx.Navigation.Add("story", web.NavItem{
+		URL:  "/story",
+		Name: "harmony.menu.story",
+		Display: func(io web.IO) (bool, error) {
+			return true, nil
+		},
+		Position:       106,
+		ActivePrefixes: []string{"/story/"},
+	})
+}
+
+func storyPage(appCtx *hctx.AppCtx, webCtx *web.Ctx, templateRepository template.Repository) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		templates, err := templateRepository.FindByQueryForTypeAndUser(io.Context(), template.SearchFilter{}, story.TemplateType, user.MustFromIO(io))
+		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		form := &FormData{}
+		var formErr error
+		if templateID := web.URLParam(io.Request(), "templateID"); templateID != "" {
+			form, formErr = formFromRequest(io, templateRepository, appCtx.Validator, templateID)
+			if formErr != nil {
+				form = &FormData{}
+			}
+		}
+		if form.Template != nil {
+			for i := 0; i < max(form.Template.MinCriteria, 1); i++ {
+				form.Criteria = append(form.Criteria, parser.SegmentGroupRow{})
+			}
+		}
+		form.Templates = templates
+
+		return io.Render(web.NewFormData(form, nil, formErr), "story.page", "story/page.go.html", "story/_form.go.html")
+	})
+}
+
+// parseStory parses the story (see story.Template.Parse) and renders the form with the result.
+func parseStory(appCtx *hctx.AppCtx, webCtx *web.Ctx, templateRepository template.Repository) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		form, err := formFromRequest(io, templateRepository, appCtx.Validator, web.URLParam(io.Request(), "templateID"))
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		segments := segmentsFromRequest(io.Request())
+		form.fill(segments)
+
+		result := form.Template.Parse(segments...)
+		form.ParsingResult = &result
+
+		var success []string
+		if result.Ok() {
+			success = []string{"story.form.success"}
+		}
+
+		return io.Render(web.NewFormData(form, success), "story.form", "story/_form.go.html")
+	})
+}
+
+// editCriteria adds or removes a row of acceptance criteria and renders the form with the submitted values.
+// Rows are not added beyond the template's story.Template.CriteriaLimit.
+func editCriteria(appCtx *hctx.AppCtx, webCtx *web.Ctx, templateRepository template.Repository) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		form, err := formFromRequest(io, templateRepository, appCtx.Validator, web.URLParam(request, "templateID"))
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		form.fill(segmentsFromRequest(request))
+
+		switch request.FormValue("action") {
+		case "add":
+			if len(form.Criteria) < form.Template.CriteriaLimit() {
+				form.Criteria = append(form.Criteria, parser.SegmentGroupRow{})
+			}
+		case "remove":
+			row, err := strconv.Atoi(request.FormValue("row"))
+			if err == nil && row >= 0 && row < len(form.Criteria) {
+				form.Criteria = append(form.Criteria[:row], form.Criteria[row+1:]...)
+			}
+		}
+
+		return io.Render(web.NewFormData(form, nil), "story.form", "story/_form.go.html")
+	})
+}
+
+// CriterionName returns the name of the segment of the acceptance criterion's row, see parser.IndexedSegmentName.
+func (f *FormData) CriterionName(row int, key string) string {
+	return parser.IndexedSegmentName(key, row)
+}
+
+// fill fills the form's sentence and acceptance criteria with the values of the segments.
+func (f *FormData) fill(segments []parser.ParsingSegment) {
+	for _, segment := range segments {
+		f.Segments[segment.Name] = segment.Value
+	}
+	f.Criteria = parser.GroupSegments(story.CriterionSegments, segments...)
+}
+
+// segmentsFromRequest returns the segments of the story's form. Segments are expected in the form of "segment-<name>".
+func segmentsFromRequest(request *http.Request) []parser.ParsingSegment {
+	if err := request.ParseForm(); err != nil {
+		return nil
+	}
+
+	var segments []parser.ParsingSegment
+	for name, values := range request.PostForm {
+		if !strings.HasPrefix(name, "segment-") || len(values) == 0 {
+			continue
+		}
+
+		segments = append(segments, parser.ParsingSegment{Name: strings.TrimPrefix(name, "segment-"), Value: values[0]})
+	}
+
+	return segments
+}
+
+// formFromRequest returns the form of the user's story template with the ID. Returned errors are safe to display to the user.
+func formFromRequest(io web.IO, templateRepository template.Repository, validator validation.V, templateID string) (*FormData, error) {
+	id, err := uuid.Parse(templateID)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	tmpl, err := templateRepository.FindByID(io.Context(), id)
+	if err != nil || tmpl.CreatedBy != user.MustFromIO(io).ID || tmpl.Type != story.TemplateType {
+		return nil, ErrTemplateNotFound
+	}
+
+	t, err := story.IntoTemplate(tmpl, validator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FormData{
+		Template:   t,
+		TemplateID: tmpl.ID,
+		Segments:   make(map[string]string),
+	}, nil
+}
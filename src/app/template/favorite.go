@@ -0,0 +1,137 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+)
+
+const (
+	// FavoriteRepositoryName is the name of the template favorite repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	FavoriteRepositoryName = "TemplateFavoriteRepository"
+	// MaxRecent is the number of recently used templates returned by FavoriteRepository.FindRecent.
+	MaxRecent = 5
+)
+
+// FavoriteRepository keeps the working set of templates of a user: the templates the user pinned and the templates the user used recently.
+// Users only pin and use their own templates. All methods are scoped to the tenant of the context (see tenant.ID).
+// FavoriteRepository is safe for concurrent use by multiple goroutines.
+type FavoriteRepository interface {
+	persistence.Repository
+
+	// Toggle pins the template for the user or unpins it if it is already pinned. It returns whether the template is pinned now.
+	// It returns persistence.ErrNotFound if the user has no such template and persistence.ErrUpdate for any other error.
+	Toggle(ctx context.Context, userID uuid.UUID, templateID uuid.UUID) (bool, error)
+	// Use records that the user used the template, e.g. selected it to elicit requirements.
+	// Templates of other users are ignored. It returns persistence.ErrUpdate if the use could not be recorded.
+	Use(ctx context.Context, userID uuid.UUID, templateID uuid.UUID) error
+	// FindPinned finds the user's pinned templates of the type with their template set (see Template.TemplateSetElem) ordered by their name.
+	// It returns an empty slice if the user pinned no templates and persistence.ErrReadRow for any other error.
+	FindPinned(ctx context.Context, userID uuid.UUID, templateType string) ([]*Template, error)
+	// FindRecent finds the MaxRecent templates of the type the user used last with their template set (see Template.TemplateSetElem),
+	// the latest first. Pinned templates are not contained. It returns an empty slice if the user used no templates
+	// and persistence.ErrReadRow for any other error.
+	FindRecent(ctx context.Context, userID uuid.UUID, templateType string) ([]*Template, error)
+}
+
+// PGFavoriteRepository is the template favorite repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGFavoriteRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewFavoriteRepository constructs a new PGFavoriteRepository with the passed in database connection pool.
+func NewFavoriteRepository(db *pgxpool.Pool) FavoriteRepository {
+	return &PGFavoriteRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGFavoriteRepository) RepositoryName() string {
+	return FavoriteRepositoryName
+}
+
+// Toggle pins the template for the user or unpins it if it is already pinned. It returns whether the template is pinned now.
+// It returns persistence.ErrNotFound if the user has no such template and persistence.ErrUpdate for any other error.
+func (r *PGFavoriteRepository) Toggle(ctx context.Context, userID uuid.UUID, templateID uuid.UUID) (bool, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	var pinned bool
+	err := r.db.QueryRow(
+		ctx,
+		`INSERT INTO template_favorites (user_id, template_id, tenant_id, pinned)
+		SELECT $1, id, tenant_id, TRUE FROM templates WHERE id = $2 AND created_by = $1 AND tenant_id = $3
+		ON CONFLICT (tenant_id, user_id, template_id) DO UPDATE SET pinned = NOT template_favorites.pinned
+		RETURNING pinned`,
+		userID, templateID, tenant.ID(ctx),
+	).Scan(&pinned)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, persistence.ErrNotFound
+	}
+	if err != nil {
+		return false, errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return pinned, nil
+}
+
+// Use records that the user used the template, e.g. selected it to elicit requirements.
+// Templates of other users are ignored. It returns persistence.ErrUpdate if the use could not be recorded.
+func (r *PGFavoriteRepository) Use(ctx context.Context, userID uuid.UUID, templateID uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		`INSERT INTO template_favorites (user_id, template_id, tenant_id, used_at)
+		SELECT $1, id, tenant_id, current_timestamp FROM templates WHERE id = $2 AND created_by = $1 AND tenant_id = $3
+		ON CONFLICT (tenant_id, user_id, template_id) DO UPDATE SET used_at = excluded.used_at`,
+		userID, templateID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// FindPinned finds the user's pinned templates of the type with their template set (see Template.TemplateSetElem) ordered by their name.
+// It returns an empty slice if the user pinned no templates and persistence.ErrReadRow for any other error.
+func (r *PGFavoriteRepository) FindPinned(ctx context.Context, userID uuid.UUID, templateType string) ([]*Template, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT `+persistence.QualifyColumns("templates", templateColumns)+`, `+persistence.QualifyColumns("template_sets", setColumns)+`
+		FROM template_favorites f JOIN templates ON templates.id = f.template_id LEFT JOIN template_sets ON templates.template_set = template_sets.id
+		WHERE f.user_id = $1 AND f.tenant_id = $2 AND f.pinned AND templates.type = $3
+		ORDER BY lower(templates.name), templates.version`,
+		userID, tenant.ID(ctx), templateType,
+	)
+
+	return persistence.PGCollectRows(rows, err, scanTemplateWithSet)
+}
+
+// FindRecent finds the MaxRecent templates of the type the user used last with their template set (see Template.TemplateSetElem),
+// the latest first. Pinned templates are not contained. It returns an empty slice if the user used no templates
+// and persistence.ErrReadRow for any other error.
+func (r *PGFavoriteRepository) FindRecent(ctx context.Context, userID uuid.UUID, templateType string) ([]*Template, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT `+persistence.QualifyColumns("templates", templateColumns)+`, `+persistence.QualifyColumns("template_sets", setColumns)+`
+		FROM template_favorites f JOIN templates ON templates.id = f.template_id LEFT JOIN template_sets ON templates.template_set = template_sets.id
+		WHERE f.user_id = $1 AND f.tenant_id = $2 AND NOT f.pinned AND f.used_at IS NOT NULL AND templates.type = $3
+		ORDER BY f.used_at DESC LIMIT $4`,
+		userID, tenant.ID(ctx), templateType, MaxRecent,
+	)
+
+	return persistence.PGCollectRows(rows, err, scanTemplateWithSet)
+}
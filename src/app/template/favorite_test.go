@@ -0,0 +1,59 @@
+package template
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPGFavoriteRepository(t *testing.T) {
+	registerAllCleanup(t)
+
+	favoriteRepo := NewFavoriteRepository(db)
+	u, tmplSet, foo := mockTemplate(t)
+	_, _, tmplToCreate := fooToCreate()
+	tmplToCreate.TemplateSet = tmplSet.ID
+	tmplToCreate.CreatedBy = u.ID
+	bar, err := templateRepo.Create(ctx, tmplToCreate)
+	require.NoError(t, err)
+
+	pinned, err := favoriteRepo.FindPinned(ctx, u.ID, "ebt")
+	require.NoError(t, err)
+	assert.Empty(t, pinned)
+
+	isPinned, err := favoriteRepo.Toggle(ctx, u.ID, foo.ID)
+	require.NoError(t, err)
+	assert.True(t, isPinned)
+	_, err = favoriteRepo.Toggle(ctx, uuid.New(), foo.ID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound, "users only pin their own templates")
+
+	require.NoError(t, favoriteRepo.Use(ctx, u.ID, bar.ID))
+	require.NoError(t, favoriteRepo.Use(ctx, u.ID, foo.ID))
+	require.NoError(t, favoriteRepo.Use(ctx, uuid.New(), foo.ID), "uses of other users' templates are ignored")
+
+	pinned, err = favoriteRepo.FindPinned(ctx, u.ID, "ebt")
+	require.NoError(t, err)
+	require.Len(t, pinned, 1)
+	assert.Equal(t, foo.ID, pinned[0].ID)
+	assert.Equal(t, tmplSet.Name, pinned[0].TemplateSetElem.Name)
+
+	recent, err := favoriteRepo.FindRecent(ctx, u.ID, "ebt")
+	require.NoError(t, err)
+	require.Len(t, recent, 1, "pinned templates are not recent")
+	assert.Equal(t, bar.ID, recent[0].ID)
+
+	isPinned, err = favoriteRepo.Toggle(ctx, u.ID, foo.ID)
+	require.NoError(t, err)
+	assert.False(t, isPinned)
+
+	recent, err = favoriteRepo.FindRecent(ctx, u.ID, "ebt")
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.Equal(t, foo.ID, recent[0].ID, "the template used last comes first")
+
+	recent, err = favoriteRepo.FindRecent(ctx, u.ID, "other")
+	require.NoError(t, err)
+	assert.Empty(t, recent)
+}
@@ -0,0 +1,233 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"sort"
+	"time"
+)
+
+// HealthRepositoryName is the name of the template health repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+const HealthRepositoryName = "TemplateHealthRepository"
+
+// Health aggregates the results of parsing requirements with a template since its statistics were last reset.
+// It shows the template's author which rules fail most frequently, e.g. to improve their hints and explanations.
+type Health struct {
+	TemplateID uuid.UUID
+	// Parses is the number of requirements parsed with the template.
+	Parses int64
+	// Failed is the number of parses with at least one error.
+	Failed int64
+	// Since is the time the first parse was recorded. It is zero if no parse was recorded yet.
+	Since time.Time
+	// Rules are the failures per rule, rules failing most frequently first. Rules which never failed are not contained.
+	Rules []*RuleFailures
+}
+
+// RuleFailures counts the parses in which a rule failed, i.e. the rule's segment was logged as an error or warning.
+// Each level is counted at most once per parse, the occurrences of repeated rules count as the rule itself.
+type RuleFailures struct {
+	Rule     string
+	Errors   int64
+	Warnings int64
+	// LastFailedAt is the time the rule failed last. It is not set by FailuresOf.
+	LastFailedAt time.Time
+}
+
+// HealthRepository records and reads the parsing statistics of templates, see Health.
+// All methods are scoped to the tenant of the context (see tenant.ID). Permissions are checked by the caller.
+// HealthRepository is safe for concurrent use by multiple goroutines.
+type HealthRepository interface {
+	persistence.Repository
+
+	// Record counts a parse of the template and the failures of its rules (see FailuresOf).
+	// It returns persistence.ErrInsert if the parse could not be recorded.
+	Record(ctx context.Context, templateID uuid.UUID, result parser.ParsingResult) error
+	// FindByTemplate returns the health of the template. A template without recorded parses has an empty health.
+	// It returns persistence.ErrReadRow if the statistics could not be read.
+	FindByTemplate(ctx context.Context, templateID uuid.UUID) (*Health, error)
+	// Reset deletes the statistics of the template, e.g. after its rules were improved.
+	// It returns persistence.ErrDelete if the statistics could not be deleted.
+	Reset(ctx context.Context, templateID uuid.UUID) error
+}
+
+// PGHealthRepository is the template health repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGHealthRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewHealthRepository constructs a new PGHealthRepository with the passed in database connection pool.
+func NewHealthRepository(db *pgxpool.Pool) HealthRepository {
+	return &PGHealthRepository{db: db}
+}
+
+// FailuresOf returns the failures of the rules in the parsing result ordered by the rule's name.
+// Errors and warnings are counted once per rule, logs of constraints (see parser.ParsingResult.ConstraintLogs) and notices are ignored.
+func FailuresOf(result parser.ParsingResult) []RuleFailures {
+	failures := make(map[string]*RuleFailures)
+	count := func(logs []parser.ParsingLog, counter func(*RuleFailures) *int64) {
+		counted := make(map[string]bool)
+		for _, log := range logs {
+			if log.Segment == nil {
+				continue
+			}
+
+			rule, _, _ := log.Segment.Occurrence()
+			if counted[rule] {
+				continue
+			}
+			counted[rule] = true
+
+			if failures[rule] == nil {
+				failures[rule] = &RuleFailures{Rule: rule}
+			}
+			*counter(failures[rule])++
+		}
+	}
+	count(result.Errors, func(f *RuleFailures) *int64 { return &f.Errors })
+	count(result.Warnings, func(f *RuleFailures) *int64 { return &f.Warnings })
+
+	ordered := make([]RuleFailures, 0, len(failures))
+	for _, f := range failures {
+		ordered = append(ordered, *f)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].Rule < ordered[j].Rule
+	})
+
+	return ordered
+}
+
+// Share returns the count's share of the template's parses in percent rounded down, e.g. the share of parses in which a rule failed.
+// It is 0 if no parse was recorded.
+func (h *Health) Share(count int64) int64 {
+	if h.Parses == 0 {
+		return 0
+	}
+
+	return count * 100 / h.Parses
+}
+
+// Total returns the number of errors and warnings of the rule.
+func (f *RuleFailures) Total() int64 {
+	return f.Errors + f.Warnings
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGHealthRepository) RepositoryName() string {
+	return HealthRepositoryName
+}
+
+// Record counts a parse of the template and the failures of its rules (see FailuresOf).
+// It returns persistence.ErrInsert if the parse could not be recorded.
+func (r *PGHealthRepository) Record(ctx context.Context, templateID uuid.UUID, result parser.ParsingResult) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	failed := 0
+	if !result.Ok() {
+		failed = 1
+	}
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(
+			ctx,
+			`INSERT INTO template_parse_stats (template_id, tenant_id, parses, failed) VALUES ($1, $2, 1, $3)
+			ON CONFLICT (tenant_id, template_id) DO UPDATE
+			SET parses = template_parse_stats.parses + 1, failed = template_parse_stats.failed + excluded.failed`,
+			templateID, tenant.ID(ctx), failed,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range FailuresOf(result) {
+			_, err = tx.Exec(
+				ctx,
+				`INSERT INTO template_rule_stats (template_id, tenant_id, rule, errors, warnings) VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (tenant_id, template_id, rule) DO UPDATE
+				SET errors = template_rule_stats.errors + excluded.errors, warnings = template_rule_stats.warnings + excluded.warnings,
+					last_failed_at = current_timestamp`,
+				templateID, tenant.ID(ctx), f.Rule, f.Errors, f.Warnings,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// FindByTemplate returns the health of the template. A template without recorded parses has an empty health.
+// It returns persistence.ErrReadRow if the statistics could not be read.
+func (r *PGHealthRepository) FindByTemplate(ctx context.Context, templateID uuid.UUID) (*Health, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	health := &Health{TemplateID: templateID}
+	err := r.db.QueryRow(
+		ctx,
+		"SELECT parses, failed, since FROM template_parse_stats WHERE template_id = $1 AND tenant_id = $2",
+		templateID, tenant.ID(ctx),
+	).Scan(&health.Parses, &health.Failed, &health.Since)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return health, nil
+	}
+	if err != nil {
+		return nil, persistence.PGReadErr(err)
+	}
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT rule, errors, warnings, last_failed_at FROM template_rule_stats WHERE template_id = $1 AND tenant_id = $2
+		ORDER BY errors DESC, warnings DESC, rule`,
+		templateID, tenant.ID(ctx),
+	)
+	health.Rules, err = persistence.PGCollectRows(rows, err, func(row pgx.Row) (*RuleFailures, error) {
+		f := &RuleFailures{}
+		err := row.Scan(&f.Rule, &f.Errors, &f.Warnings, &f.LastFailedAt)
+
+		return f, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return health, nil
+}
+
+// Reset deletes the statistics of the template, e.g. after its rules were improved.
+// It returns persistence.ErrDelete if the statistics could not be deleted.
+func (r *PGHealthRepository) Reset(ctx context.Context, templateID uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "DELETE FROM template_rule_stats WHERE template_id = $1 AND tenant_id = $2", templateID, tenant.ID(ctx))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM template_parse_stats WHERE template_id = $1 AND tenant_id = $2", templateID, tenant.ID(ctx))
+
+		return err
+	})
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
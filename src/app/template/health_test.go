@@ -0,0 +1,79 @@
+package template
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFailuresOf(t *testing.T) {
+	result := parser.ParsingResult{
+		Errors: []parser.ParsingLog{
+			{Segment: &parser.ParsingSegment{Name: "action"}},
+			{Segment: &parser.ParsingSegment{Name: "action"}},
+			{Segment: &parser.ParsingSegment{Name: "given[0]"}},
+			{Segment: &parser.ParsingSegment{Name: "given[1]"}},
+			{Constraint: "requires-system"},
+		},
+		Warnings: []parser.ParsingLog{
+			{Segment: &parser.ParsingSegment{Name: "action"}},
+			{Segment: &parser.ParsingSegment{Name: "system"}, Downgrade: true},
+		},
+		Notices: []parser.ParsingLog{
+			{Segment: &parser.ParsingSegment{Name: "modal"}},
+		},
+	}
+
+	assert.Equal(t, []RuleFailures{
+		{Rule: "action", Errors: 1, Warnings: 1},
+		{Rule: "given", Errors: 1},
+		{Rule: "system", Warnings: 1},
+	}, FailuresOf(result))
+	assert.Empty(t, FailuresOf(parser.ParsingResult{}))
+}
+
+func TestHealth_Share(t *testing.T) {
+	assert.Equal(t, int64(0), (&Health{}).Share(3))
+	assert.Equal(t, int64(33), (&Health{Parses: 3}).Share(1))
+	assert.Equal(t, int64(100), (&Health{Parses: 3}).Share(3))
+}
+
+func TestPGHealthRepository(t *testing.T) {
+	registerAllCleanup(t)
+
+	healthRepo := NewHealthRepository(db)
+	_, _, tmpl := mockTemplate(t)
+
+	health, err := healthRepo.FindByTemplate(ctx, tmpl.ID)
+	require.NoError(t, err)
+	assert.Zero(t, health.Parses)
+	assert.Empty(t, health.Rules)
+
+	failing := parser.ParsingResult{
+		Errors:   []parser.ParsingLog{{Segment: &parser.ParsingSegment{Name: "action"}}},
+		Warnings: []parser.ParsingLog{{Segment: &parser.ParsingSegment{Name: "system"}}},
+	}
+	require.NoError(t, healthRepo.Record(ctx, tmpl.ID, failing))
+	require.NoError(t, healthRepo.Record(ctx, tmpl.ID, failing))
+	require.NoError(t, healthRepo.Record(ctx, tmpl.ID, parser.ParsingResult{}))
+	assert.Error(t, healthRepo.Record(ctx, uuid.New(), failing), "parses of unknown templates are not recorded")
+
+	health, err = healthRepo.FindByTemplate(ctx, tmpl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), health.Parses)
+	assert.Equal(t, int64(2), health.Failed)
+	assert.False(t, health.Since.IsZero())
+	require.Len(t, health.Rules, 2)
+	assert.Equal(t, "action", health.Rules[0].Rule, "rules with the most errors come first")
+	assert.Equal(t, int64(2), health.Rules[0].Errors)
+	assert.Equal(t, "system", health.Rules[1].Rule)
+	assert.Equal(t, int64(2), health.Rules[1].Warnings)
+
+	require.NoError(t, healthRepo.Reset(ctx, tmpl.ID))
+	health, err = healthRepo.FindByTemplate(ctx, tmpl.ID)
+	require.NoError(t, err)
+	assert.Zero(t, health.Parses)
+	assert.Empty(t, health.Rules)
+}
@@ -0,0 +1,717 @@
+package template
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInvalidSource is returned if a source's URL is no valid HTTPS URL, its kind is unknown or its path leaves the repository.
+	ErrInvalidSource = errors.New("template.set.import.source.invalid")
+	// ErrHostNotAllowed is returned if a source's host is not contained in ImportCfg.AllowedHosts.
+	ErrHostNotAllowed = errors.New("template.set.import.source.host-not-allowed")
+	// ErrGitDisabled is returned for Git sources if no git executable is configured in ImportCfg.Git.
+	ErrGitDisabled = errors.New("template.set.import.source.git-disabled")
+	// ErrFetchSource is returned if a source could not be fetched, e.g. because it is unreachable or the ref does not exist.
+	ErrFetchSource = errors.New("template.set.import.source.fetch")
+	// ErrSourceTooLarge is returned if a source exceeds ImportCfg.MaxSize or contains more than ImportCfg.MaxTemplates templates.
+	ErrSourceTooLarge = errors.New("template.set.import.source.too-large")
+	// ErrInvalidContent is returned if a source contains no template configs or its content is neither JSON nor a zip or tar.gz archive.
+	ErrInvalidContent = errors.New("template.set.import.source.invalid-content")
+	// ErrAddressNotAllowed is returned when connecting to a loopback, private, link-local or otherwise non-public IP address while fetching a source.
+	ErrAddressNotAllowed = errors.New("template.set.import.source.address-not-allowed")
+)
+
+// Source is a remote source of template configs, see NewSource.
+type Source interface {
+	// Fetch fetches the template configs of the source and returns them with the revision of the fetched content.
+	Fetch(ctx context.Context) ([]string, string, error)
+	// Revision returns the current revision of the source. It is compared to SetSource.Revision to check for updates.
+	Revision(ctx context.Context) (string, error)
+}
+
+// HTTPSource fetches a template config, a JSON array of template configs or a zip or tar.gz archive of template configs (*.json) from an HTTPS URL.
+// The revision is the SHA-256 of the fetched content.
+type HTTPSource struct {
+	cfg    *ImportCfg
+	url    string
+	client *http.Client
+}
+
+// GitSource fetches the template configs (*.json) from a file or directory of a Git repository's branch or tag.
+// The repository is cloned shallowly using the configured git executable. The revision is the commit of the ref.
+type GitSource struct {
+	cfg  *ImportCfg
+	url  string
+	ref  string
+	path string
+}
+
+// NewSource returns the Source of the SetSource's kind. Sources are restricted to HTTPS URLs of the ImportCfg.AllowedHosts
+// and only public IP addresses are connected to, also after redirects and DNS changes (see publicAddressOnly). It returns ErrInvalidSource for invalid URLs, kinds and paths, ErrHostNotAllowed for hosts that are not allowed
+// and ErrGitDisabled for Git sources if Git is disabled.
+func NewSource(cfg *ImportCfg, source *SetSource) (Source, error) {
+	if err := checkSourceURL(cfg, source.URL); err != nil {
+		return nil, err
+	}
+
+	switch source.Kind {
+	case SourceURL:
+		return &HTTPSource{cfg: cfg, url: source.URL, client: cfg.client()}, nil
+	case SourceGit:
+		if cfg.Git == "" {
+			return nil, ErrGitDisabled
+		}
+
+		if strings.HasPrefix(source.Ref, "-") || strings.ContainsAny(source.Ref, " \t\n") {
+			return nil, ErrInvalidSource
+		}
+
+		if slices.Contains(strings.Split(filepath.ToSlash(source.Path), "/"), "..") {
+			return nil, ErrInvalidSource
+		}
+
+		return &GitSource{cfg: cfg, url: source.URL, ref: source.Ref, path: strings.Trim(path.Clean("/"+source.Path), "/")}, nil
+	default:
+		return nil, ErrInvalidSource
+	}
+}
+
+// Fetch fetches the template configs from the URL and returns them with the SHA-256 of the fetched content.
+func (s *HTTPSource) Fetch(ctx context.Context) ([]string, string, error) {
+	content, err := s.get(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	configs, err := ParseConfigs(content, s.cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return configs, revisionOf(content), nil
+}
+
+// Revision fetches the content of the URL and returns its SHA-256.
+func (s *HTTPSource) Revision(ctx context.Context) (string, error) {
+	content, err := s.get(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return revisionOf(content), nil
+}
+
+// get fetches the content of the URL. Redirects are followed as long as they stay within the allowed HTTPS hosts.
+func (s *HTTPSource) get(ctx context.Context) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidSource, err)
+	}
+
+	client := *s.client
+	client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return ErrFetchSource
+		}
+
+		return checkSourceURL(s.cfg, request.URL.String())
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Join(ErrFetchSource, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Join(ErrFetchSource, fmt.Errorf("unexpected status %d", response.StatusCode))
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, s.cfg.MaxSize+1))
+	if err != nil {
+		return nil, errors.Join(ErrFetchSource, err)
+	}
+	if int64(len(content)) > s.cfg.MaxSize {
+		return nil, ErrSourceTooLarge
+	}
+
+	return content, nil
+}
+
+// Fetch clones the ref of the repository and returns the template configs of the path with the cloned commit.
+// A file path is read as template config, a directory path is searched recursively for *.json files.
+func (s *GitSource) Fetch(ctx context.Context) ([]string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.timeout())
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "harmony-template-import-")
+	if err != nil {
+		return nil, "", errors.Join(ErrFetchSource, err)
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"clone", "--depth", "1", "--single-branch", "--no-tags"}
+	if s.ref != "" {
+		args = append(args, "--branch", s.ref)
+	}
+	if _, err := s.git(ctx, "", append(args, "--", s.url, dir)...); err != nil {
+		return nil, "", err
+	}
+
+	commit, err := s.git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, "", err
+	}
+
+	configs, err := s.readConfigs(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return configs, commit, nil
+}
+
+// Revision returns the commit the ref of the repository currently points to without cloning it.
+func (s *GitSource) Revision(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.timeout())
+	defer cancel()
+
+	ref := s.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	out, err := s.git(ctx, "", "ls-remote", "--", s.url, ref)
+	if err != nil {
+		return "", err
+	}
+
+	// annotated tags are listed twice, the peeled entry (^{}) is the tagged commit that is cloned
+	var commit string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if fields[1] == "refs/tags/"+ref+"^{}" {
+			return fields[0], nil
+		}
+		if commit == "" && (fields[1] == ref || fields[1] == "refs/heads/"+ref || fields[1] == "refs/tags/"+ref) {
+			commit = fields[0]
+		}
+	}
+
+	if commit == "" {
+		return "", errors.Join(ErrFetchSource, fmt.Errorf("ref %q not found", ref))
+	}
+
+	return commit, nil
+}
+
+// git runs the git executable in the directory and returns its trimmed output. Only the HTTPS protocol is allowed, redirects are not followed
+// and git never prompts for credentials. All connections of git are made through a gitProxy, restricting them to public addresses
+// of the allowed hosts and aborting them once more than ImportCfg.cloneSize bytes are received.
+func (s *GitSource) git(ctx context.Context, dir string, args ...string) (string, error) {
+	proxy, err := newGitProxy(s.cfg, s.cfg.dialer().DialContext)
+	if err != nil {
+		return "", errors.Join(ErrFetchSource, err)
+	}
+	defer proxy.Close()
+
+	cmd := exec.CommandContext(ctx, s.cfg.Git, append([]string{
+		"-c", "protocol.allow=never",
+		"-c", "protocol.https.allow=always",
+		"-c", "http.followRedirects=false",
+		"-c", "http.proxy=http://" + proxy.Addr(),
+	}, args...)...)
+	cmd.Dir = dir
+	// the proxy must not be bypassed by the proxy exceptions of the environment
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "NO_PROXY=", "no_proxy=")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if proxy.Exceeded() {
+		return "", ErrSourceTooLarge
+	}
+	if err != nil {
+		return "", errors.Join(ErrFetchSource, err, errors.New(strings.TrimSpace(stderr.String())))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitProxy is an HTTP CONNECT proxy on the loopback interface through which git connects to the repository.
+// Tunnels are only opened to the allowed hosts using the dial function, see ImportCfg.dialer. The data received through all tunnels
+// is limited to ImportCfg.cloneSize bytes, tunnels are closed once the limit is exceeded.
+type gitProxy struct {
+	cfg      *ImportCfg
+	dial     func(ctx context.Context, network, address string) (net.Conn, error)
+	listener net.Listener
+	received atomic.Int64
+	conns    sync.WaitGroup
+}
+
+// newGitProxy starts a gitProxy listening on a random port of the loopback interface. It must be closed after use.
+func newGitProxy(cfg *ImportCfg, dial func(ctx context.Context, network, address string) (net.Conn, error)) (*gitProxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := &gitProxy{cfg: cfg, dial: dial, listener: listener}
+	go proxy.serve()
+
+	return proxy, nil
+}
+
+// Addr returns the address the proxy listens on.
+func (p *gitProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// Exceeded returns true if more than ImportCfg.cloneSize bytes were received through the proxy.
+func (p *gitProxy) Exceeded() bool {
+	return p.received.Load() > p.cfg.cloneSize()
+}
+
+// Close stops the proxy and waits for the open tunnels to be closed.
+func (p *gitProxy) Close() error {
+	err := p.listener.Close()
+	p.conns.Wait()
+
+	return err
+}
+
+// serve accepts connections until the proxy is closed.
+func (p *gitProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		p.conns.Add(1)
+		go func() {
+			defer p.conns.Done()
+			p.tunnel(conn)
+		}()
+	}
+}
+
+// tunnel reads the CONNECT request of the client and tunnels the connection to the requested host if it is allowed.
+// The request is answered with 403 Forbidden if the host is not allowed and with 502 Bad Gateway if it could not be connected to.
+func (p *gitProxy) tunnel(client net.Conn) {
+	defer client.Close()
+
+	_ = client.SetReadDeadline(time.Now().Add(p.cfg.timeout()))
+	reader := bufio.NewReader(client)
+	request, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+	_ = client.SetReadDeadline(time.Time{})
+
+	if request.Method != http.MethodConnect || checkSourceURL(p.cfg, "https://"+request.Host) != nil {
+		_, _ = io.WriteString(client, "HTTP/1.1 403 Forbidden\r\n\r\n")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.timeout())
+	defer cancel()
+
+	upstream, err := p.dial(ctx, "tcp", request.Host)
+	if err != nil {
+		_, _ = io.WriteString(client, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return
+	}
+	defer upstream.Close()
+
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		return
+	}
+
+	go func() {
+		// data buffered while reading the request, e.g. the TLS client hello, is forwarded first
+		_, _ = io.Copy(upstream, reader)
+		upstream.Close()
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := upstream.Read(buf)
+		if p.received.Add(int64(n)) > p.cfg.cloneSize() {
+			return
+		}
+		if n > 0 {
+			if _, err := client.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readConfigs reads the template configs of the path within the cloned repository. Symbolic links leaving the repository are not followed.
+func (s *GitSource) readConfigs(dir string) ([]string, error) {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, errors.Join(ErrFetchSource, err)
+	}
+
+	root, err := filepath.EvalSymlinks(filepath.Join(dir, filepath.FromSlash(s.path)))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidSource, err)
+	}
+	if root != dir && !strings.HasPrefix(root, dir+string(filepath.Separator)) {
+		return nil, ErrInvalidSource
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidSource, err)
+	}
+	if info.Mode().IsRegular() {
+		content, err := readLimited(root, s.cfg.MaxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		return ParseConfigs(content, s.cfg)
+	}
+	if !info.IsDir() {
+		return nil, ErrInvalidSource
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(p string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if entry.Type().IsRegular() && isConfigFile(entry.Name()) {
+			files = append(files, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(ErrFetchSource, err)
+	}
+
+	if len(files) > s.cfg.MaxTemplates {
+		return nil, ErrSourceTooLarge
+	}
+
+	var size int64
+	configs := make([]string, 0, len(files))
+	for _, file := range files {
+		content, err := readLimited(file, s.cfg.MaxSize-size)
+		if err != nil {
+			return nil, err
+		}
+
+		size += int64(len(content))
+		configs = append(configs, string(content))
+	}
+
+	if len(configs) == 0 {
+		return nil, ErrInvalidContent
+	}
+
+	return configs, nil
+}
+
+// ParseConfigs parses the template configs of a source's content. The content is either a template config, a JSON array of template configs
+// or a zip or tar.gz archive of template configs (*.json) ordered by their path. It returns ErrInvalidContent if the content contains no template configs
+// and ErrSourceTooLarge if it contains more than ImportCfg.MaxTemplates templates or an archive exceeds ImportCfg.MaxSize when extracted.
+// The template configs themselves are not validated.
+func ParseConfigs(content []byte, cfg *ImportCfg) ([]string, error) {
+	var configs []string
+	var err error
+
+	switch {
+	case bytes.HasPrefix(content, []byte("PK\x03\x04")):
+		configs, err = parseZip(content, cfg.MaxSize)
+	case bytes.HasPrefix(content, []byte{0x1f, 0x8b}):
+		configs, err = parseTarGz(content, cfg.MaxSize)
+	default:
+		configs, err = parseJSON(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configs) == 0 {
+		return nil, ErrInvalidContent
+	}
+	if len(configs) > cfg.MaxTemplates {
+		return nil, ErrSourceTooLarge
+	}
+
+	return configs, nil
+}
+
+// parseJSON parses a template config or a JSON array of template configs.
+func parseJSON(content []byte) ([]string, error) {
+	content = bytes.TrimSpace(content)
+
+	if bytes.HasPrefix(content, []byte("{")) {
+		if !json.Valid(content) {
+			return nil, ErrInvalidContent
+		}
+
+		return []string{string(content)}, nil
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, errors.Join(ErrInvalidContent, err)
+	}
+
+	configs := make([]string, 0, len(raw))
+	for _, cfg := range raw {
+		configs = append(configs, string(cfg))
+	}
+
+	return configs, nil
+}
+
+// parseZip reads the template configs of a zip archive. The extracted size is limited to maxSize.
+func parseZip(content []byte, maxSize int64) ([]string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidContent, err)
+	}
+
+	files := make(map[string][]byte)
+	var size int64
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() || !isConfigFile(file.Name) {
+			continue
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			return nil, errors.Join(ErrInvalidContent, err)
+		}
+
+		cfg, err := readAllLimited(f, maxSize-size)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		size += int64(len(cfg))
+		files[file.Name] = cfg
+	}
+
+	return sortedConfigs(files), nil
+}
+
+// parseTarGz reads the template configs of a tar.gz archive. The extracted size is limited to maxSize.
+func parseTarGz(content []byte, maxSize int64) ([]string, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidContent, err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	var size int64
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Join(ErrInvalidContent, err)
+		}
+
+		if header.Typeflag != tar.TypeReg || !isConfigFile(header.Name) {
+			continue
+		}
+
+		cfg, err := readAllLimited(reader, maxSize-size)
+		if err != nil {
+			return nil, err
+		}
+
+		size += int64(len(cfg))
+		files[header.Name] = cfg
+	}
+
+	return sortedConfigs(files), nil
+}
+
+// isConfigFile returns true for JSON files that are not hidden, e.g. macOS resource forks.
+func isConfigFile(name string) bool {
+	base := path.Base(filepath.ToSlash(name))
+
+	return strings.EqualFold(path.Ext(base), ".json") && !strings.HasPrefix(base, ".") && !strings.Contains(name, "__MACOSX")
+}
+
+// sortedConfigs returns the contents of the files ordered by the file's path.
+func sortedConfigs(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	configs := make([]string, 0, len(names))
+	for _, name := range names {
+		configs = append(configs, string(files[name]))
+	}
+
+	return configs
+}
+
+// readLimited reads a file returning ErrSourceTooLarge if it is larger than maxSize.
+func readLimited(name string, maxSize int64) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Join(ErrFetchSource, err)
+	}
+	defer f.Close()
+
+	return readAllLimited(f, maxSize)
+}
+
+// readAllLimited reads the reader returning ErrSourceTooLarge if it contains more than maxSize bytes.
+func readAllLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize < 0 {
+		return nil, ErrSourceTooLarge
+	}
+
+	content, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidContent, err)
+	}
+	if int64(len(content)) > maxSize {
+		return nil, ErrSourceTooLarge
+	}
+
+	return content, nil
+}
+
+// checkSourceURL returns ErrInvalidSource if the URL is no valid HTTPS URL and ErrHostNotAllowed if its host is not allowed.
+// Credentials within the URL are not allowed as they would be stored with the source. No host is allowed if ImportCfg.AllowedHosts is empty.
+func checkSourceURL(cfg *ImportCfg, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return ErrInvalidSource
+	}
+
+	if !slices.ContainsFunc(cfg.AllowedHosts, func(host string) bool { return strings.EqualFold(host, u.Hostname()) }) {
+		return ErrHostNotAllowed
+	}
+
+	return nil
+}
+
+// publicAddressOnly is the net.Dialer.Control function of the sources' dialer. It returns ErrAddressNotAllowed for IP addresses that are not public.
+// Because it checks the resolved address right before connecting, it also applies to redirects and hosts resolving to internal addresses.
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return errors.Join(ErrAddressNotAllowed, err)
+	}
+
+	if !isPublicAddr(addrPort.Addr()) {
+		return ErrAddressNotAllowed
+	}
+
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598) that is not covered by netip.Addr.IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublicAddr returns false for unspecified, loopback, private, shared, link-local and multicast addresses, e.g. 127.0.0.1, 10.0.0.1,
+// 169.254.169.254 or ::1. IPv4-mapped IPv6 addresses are checked as IPv4 addresses.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsValid() &&
+		!addr.IsUnspecified() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// revisionOf returns the hex encoded SHA-256 of the content.
+func revisionOf(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// timeout returns the configured timeout of fetching a source.
+func (cfg *ImportCfg) timeout() time.Duration {
+	return time.Duration(cfg.Timeout) * time.Second
+}
+
+// cloneSize returns the maximum number of bytes received while cloning a Git repository. It defaults to ten times ImportCfg.MaxSize.
+func (cfg *ImportCfg) cloneSize() int64 {
+	if cfg.MaxCloneSize > 0 {
+		return cfg.MaxCloneSize
+	}
+
+	return 10 * cfg.MaxSize
+}
+
+// dialer returns the dialer connecting to sources. Only public IP addresses are connected to, see publicAddressOnly.
+func (cfg *ImportCfg) dialer() *net.Dialer {
+	return &net.Dialer{Timeout: cfg.timeout(), Control: publicAddressOnly}
+}
+
+// client returns the HTTP client of HTTPSource connecting through ImportCfg.dialer. Proxies of the environment are not used
+// as the dialer would only check the proxy's address.
+func (cfg *ImportCfg) client() *http.Client {
+	return &http.Client{
+		Timeout: cfg.timeout(),
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         cfg.dialer().DialContext,
+			TLSHandshakeTimeout: cfg.timeout(),
+			ForceAttemptHTTP2:   true,
+		},
+	}
+}
@@ -0,0 +1,276 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// LabelRepositoryName is the name of the template set label repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	LabelRepositoryName = "TemplateSetLabelRepository"
+	// MaxLabelLength is the maximum length of a label's name in characters.
+	MaxLabelLength = 50
+)
+
+var (
+	// ErrInvalidLabel is returned if a label's name is empty or longer than MaxLabelLength.
+	ErrInvalidLabel = errors.New("template.set.label.invalid")
+	// ErrLabelExists is returned if the user already has a label with the name.
+	ErrLabelExists = errors.New("template.set.label.exists")
+)
+
+// Label organizes template sets, e.g. by domain or customer. Labels are private to their creator,
+// each template set can have any number of the user's labels.
+type Label struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+	// Sets is the number of template sets with the label. It is only filled by LabelRepository.FindByUser.
+	Sets int
+}
+
+// LabelRepository is the template set label repository. It contains all methods to interact with the template_set_labels table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID) and to the passed in user.
+// LabelRepository is safe for concurrent use by multiple goroutines.
+type LabelRepository interface {
+	persistence.Repository
+
+	// FindByUser finds the user's labels with the number of template sets per label ordered by their name.
+	// It returns an empty slice if the user has no labels and persistence.ErrReadRow for any other error.
+	FindByUser(ctx context.Context, userID uuid.UUID) ([]*Label, error)
+	// FindBySets finds the labels of the user's template sets by the id of the template set, each ordered by their name.
+	// Template sets without labels are not contained. It returns persistence.ErrReadRow if the labels could not be read.
+	FindBySets(ctx context.Context, userID uuid.UUID, templateSetIDs []uuid.UUID) (map[uuid.UUID][]*Label, error)
+	// FindSets finds the user's template sets having all of the labels ordered by their name.
+	// No labels select all of the user's template sets. It returns an empty slice if no template sets are selected
+	// and persistence.ErrReadRow for any other error.
+	FindSets(ctx context.Context, userID uuid.UUID, labelIDs []uuid.UUID) ([]*Set, error)
+	// Create creates a new label with the normalized name (see NormalizeLabel) and returns it.
+	// It returns ErrInvalidLabel for invalid names, ErrLabelExists if the user already has a label with the name
+	// and persistence.ErrInsert for any other error.
+	Create(ctx context.Context, userID uuid.UUID, name string) (*Label, error)
+	// Delete deletes a label of the user, the template sets keep their other labels.
+	// It returns persistence.ErrDelete if the label could not be deleted.
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	// Assign labels a template set. Assigning a label twice has no effect. Both the label and the template set have to belong to the user.
+	// It returns persistence.ErrNotFound if either could not be found and persistence.ErrUpdate for any other error.
+	Assign(ctx context.Context, userID uuid.UUID, templateSetID uuid.UUID, labelID uuid.UUID) error
+	// Unassign removes a label from a template set of the user.
+	// It returns persistence.ErrUpdate if the label could not be removed.
+	Unassign(ctx context.Context, userID uuid.UUID, templateSetID uuid.UUID, labelID uuid.UUID) error
+}
+
+// PGLabelRepository is the template set label repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGLabelRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewLabelRepository constructs a new PGLabelRepository with the passed in database connection pool.
+func NewLabelRepository(db *pgxpool.Pool) LabelRepository {
+	return &PGLabelRepository{db: db}
+}
+
+// NormalizeLabel trims the label's name and collapses its whitespace. Labels keep their case, e.g. for abbreviations.
+// An empty string is returned for names longer than MaxLabelLength.
+func NormalizeLabel(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if utf8.RuneCountInString(name) > MaxLabelLength {
+		return ""
+	}
+
+	return name
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGLabelRepository) RepositoryName() string {
+	return LabelRepositoryName
+}
+
+// FindByUser finds the user's labels with the number of template sets per label ordered by their name.
+// It returns an empty slice if the user has no labels and persistence.ErrReadRow for any other error.
+func (r *PGLabelRepository) FindByUser(ctx context.Context, userID uuid.UUID) ([]*Label, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT l.id, l.name, l.created_by, l.created_at, COUNT(a.template_set_id)
+		FROM template_set_labels l LEFT JOIN template_set_label_assignments a ON a.label_id = l.id
+		WHERE l.created_by = $1 AND l.tenant_id = $2 GROUP BY l.id ORDER BY lower(l.name)`,
+		userID, tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, func(row pgx.Row) (*Label, error) {
+		l := &Label{}
+		err := row.Scan(&l.ID, &l.Name, &l.CreatedBy, &l.CreatedAt, &l.Sets)
+
+		return l, err
+	})
+}
+
+// FindBySets finds the labels of the user's template sets by the id of the template set, each ordered by their name.
+// Template sets without labels are not contained. It returns persistence.ErrReadRow if the labels could not be read.
+func (r *PGLabelRepository) FindBySets(ctx context.Context, userID uuid.UUID, templateSetIDs []uuid.UUID) (map[uuid.UUID][]*Label, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	type assigned struct {
+		templateSetID uuid.UUID
+		label         *Label
+	}
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT a.template_set_id, l.id, l.name, l.created_by, l.created_at
+		FROM template_set_label_assignments a JOIN template_set_labels l ON l.id = a.label_id
+		WHERE a.template_set_id = ANY($1) AND l.created_by = $2 AND l.tenant_id = $3 ORDER BY lower(l.name)`,
+		templateSetIDs, userID, tenant.ID(ctx),
+	)
+	assignments, err := persistence.PGCollectRows(rows, err, func(row pgx.Row) (assigned, error) {
+		a := assigned{label: &Label{}}
+		err := row.Scan(&a.templateSetID, &a.label.ID, &a.label.Name, &a.label.CreatedBy, &a.label.CreatedAt)
+
+		return a, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[uuid.UUID][]*Label)
+	for _, a := range assignments {
+		labels[a.templateSetID] = append(labels[a.templateSetID], a.label)
+	}
+
+	return labels, nil
+}
+
+// FindSets finds the user's template sets having all of the labels ordered by their name.
+// No labels select all of the user's template sets. It returns an empty slice if no template sets are selected
+// and persistence.ErrReadRow for any other error.
+func (r *PGLabelRepository) FindSets(ctx context.Context, userID uuid.UUID, labelIDs []uuid.UUID) ([]*Set, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	if labelIDs == nil {
+		labelIDs = []uuid.UUID{}
+	}
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT `+persistence.QualifyColumns("s", setColumns)+` FROM template_sets s
+		WHERE s.created_by = $1 AND s.tenant_id = $2
+		AND (SELECT COUNT(*) FROM template_set_label_assignments a WHERE a.template_set_id = s.id AND a.label_id = ANY($3)) = cardinality($3::UUID[])
+		ORDER BY lower(s.name), s.version`,
+		userID, tenant.ID(ctx), labelIDs,
+	)
+
+	return persistence.PGCollectRows(rows, err, scanSet)
+}
+
+// Create creates a new label with the normalized name (see NormalizeLabel) and returns it.
+// It returns ErrInvalidLabel for invalid names, ErrLabelExists if the user already has a label with the name
+// and persistence.ErrInsert for any other error.
+func (r *PGLabelRepository) Create(ctx context.Context, userID uuid.UUID, name string) (*Label, error) {
+	name = NormalizeLabel(name)
+	if name == "" {
+		return nil, ErrInvalidLabel
+	}
+
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	label := &Label{ID: uuid.New(), Name: name, CreatedBy: userID, CreatedAt: time.Now()}
+	result, err := r.db.Exec(
+		ctx,
+		`INSERT INTO template_set_labels (id, name, created_by, created_at, tenant_id) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, created_by, name) DO NOTHING`,
+		label.ID, label.Name, label.CreatedBy, label.CreatedAt, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+	if result.RowsAffected() == 0 {
+		return nil, ErrLabelExists
+	}
+
+	return label, nil
+}
+
+// Delete deletes a label of the user, the template sets keep their other labels.
+// It returns persistence.ErrDelete if the label could not be deleted.
+func (r *PGLabelRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		"DELETE FROM template_set_labels WHERE id = $1 AND created_by = $2 AND tenant_id = $3",
+		id, userID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// Assign labels a template set. Assigning a label twice has no effect. Both the label and the template set have to belong to the user.
+// It returns persistence.ErrNotFound if either could not be found and persistence.ErrUpdate for any other error.
+func (r *PGLabelRepository) Assign(ctx context.Context, userID uuid.UUID, templateSetID uuid.UUID, labelID uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	var found bool
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM template_sets WHERE id = $1 AND created_by = $3 AND tenant_id = $4)
+		AND EXISTS (SELECT 1 FROM template_set_labels WHERE id = $2 AND created_by = $3 AND tenant_id = $4)`,
+		templateSetID, labelID, userID, tenant.ID(ctx),
+	).Scan(&found)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+	if !found {
+		return persistence.ErrNotFound
+	}
+
+	_, err = r.db.Exec(
+		ctx,
+		"INSERT INTO template_set_label_assignments (template_set_id, label_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		templateSetID, labelID,
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// Unassign removes a label from a template set of the user.
+// It returns persistence.ErrUpdate if the label could not be removed.
+func (r *PGLabelRepository) Unassign(ctx context.Context, userID uuid.UUID, templateSetID uuid.UUID, labelID uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		`DELETE FROM template_set_label_assignments a USING template_set_labels l
+		WHERE a.label_id = l.id AND a.template_set_id = $1 AND a.label_id = $2 AND l.created_by = $3 AND l.tenant_id = $4`,
+		templateSetID, labelID, userID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
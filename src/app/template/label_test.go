@@ -0,0 +1,83 @@
+package template
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLabel(t *testing.T) {
+	assert.Equal(t, "Customer ACME", NormalizeLabel("  Customer \t ACME "))
+	assert.Empty(t, NormalizeLabel(" "))
+	assert.Empty(t, NormalizeLabel(strings.Repeat("a", MaxLabelLength+1)))
+}
+
+func TestPGLabelRepository(t *testing.T) {
+	registerAllCleanup(t)
+
+	labelRepo := NewLabelRepository(db)
+	u, tmplSet, _ := mockTemplate(t)
+	other, err := templateSetRepo.Create(ctx, &SetToCreate{Name: "Bar", Version: "1.0.0", CreatedBy: u.ID})
+	require.NoError(t, err)
+
+	security, err := labelRepo.Create(ctx, u.ID, " Security ")
+	require.NoError(t, err)
+	assert.Equal(t, "Security", security.Name)
+	acme, err := labelRepo.Create(ctx, u.ID, "ACME")
+	require.NoError(t, err)
+
+	_, err = labelRepo.Create(ctx, u.ID, "Security")
+	assert.ErrorIs(t, err, ErrLabelExists)
+	_, err = labelRepo.Create(ctx, u.ID, "")
+	assert.ErrorIs(t, err, ErrInvalidLabel)
+
+	require.NoError(t, labelRepo.Assign(ctx, u.ID, tmplSet.ID, security.ID))
+	require.NoError(t, labelRepo.Assign(ctx, u.ID, tmplSet.ID, security.ID), "assigning a label twice has no effect")
+	require.NoError(t, labelRepo.Assign(ctx, u.ID, tmplSet.ID, acme.ID))
+	require.NoError(t, labelRepo.Assign(ctx, u.ID, other.ID, acme.ID))
+	assert.ErrorIs(t, labelRepo.Assign(ctx, uuid.New(), tmplSet.ID, acme.ID), persistence.ErrNotFound)
+
+	t.Run("FindByUser", func(t *testing.T) {
+		labels, err := labelRepo.FindByUser(ctx, u.ID)
+		require.NoError(t, err)
+		require.Len(t, labels, 2)
+		assert.Equal(t, "ACME", labels[0].Name)
+		assert.Equal(t, 2, labels[0].Sets)
+		assert.Equal(t, 1, labels[1].Sets)
+	})
+
+	t.Run("FindSets", func(t *testing.T) {
+		sets, err := labelRepo.FindSets(ctx, u.ID, []uuid.UUID{acme.ID, security.ID})
+		require.NoError(t, err)
+		require.Len(t, sets, 1)
+		assert.Equal(t, tmplSet.ID, sets[0].ID)
+
+		sets, err = labelRepo.FindSets(ctx, u.ID, []uuid.UUID{acme.ID})
+		require.NoError(t, err)
+		assert.Len(t, sets, 2)
+
+		sets, err = labelRepo.FindSets(ctx, u.ID, nil)
+		require.NoError(t, err)
+		assert.Len(t, sets, 2, "no labels select all template sets")
+	})
+
+	t.Run("FindBySets", func(t *testing.T) {
+		labels, err := labelRepo.FindBySets(ctx, u.ID, []uuid.UUID{tmplSet.ID, other.ID})
+		require.NoError(t, err)
+		assert.Len(t, labels[tmplSet.ID], 2)
+		assert.Len(t, labels[other.ID], 1)
+	})
+
+	t.Run("Unassign and Delete", func(t *testing.T) {
+		require.NoError(t, labelRepo.Unassign(ctx, u.ID, tmplSet.ID, acme.ID))
+		require.NoError(t, labelRepo.Delete(ctx, u.ID, security.ID))
+
+		labels, err := labelRepo.FindBySets(ctx, u.ID, []uuid.UUID{tmplSet.ID, other.ID})
+		require.NoError(t, err)
+		assert.Empty(t, labels[tmplSet.ID])
+		assert.Len(t, labels[other.ID], 1)
+	})
+}
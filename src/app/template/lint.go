@@ -0,0 +1,109 @@
+package template
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/trans"
+)
+
+const (
+	// LintSeverityError marks findings that should be fixed. The templatecheck command fails on these findings.
+	LintSeverityError LintSeverity = "error"
+	// LintSeverityWarning marks findings that are likely to confuse users eliciting requirements with the template.
+	LintSeverityWarning LintSeverity = "warning"
+	// LintSeverityInfo marks findings that are hints for improving the template.
+	LintSeverityInfo LintSeverity = "info"
+)
+
+// LintTemplateConfigEventID is the id of the LintTemplateConfigEvent.
+const LintTemplateConfigEventID = "template.config.lint"
+
+// ErrLintConfigEvent is returned when linting a template config failed during an event. This is an internal error.
+var ErrLintConfigEvent = errors.New("linting template config failed during event")
+
+// LintSeverity is the severity of a LintFinding.
+type LintSeverity string
+
+// LintFinding is a finding of a template linter. In contrast to validation errors findings do not prevent a template
+// from being saved or used. They point out parts of a template that are valid but likely unintended or incomplete,
+// e.g. a variant without an example.
+type LintFinding struct {
+	// Check is the name of the check reporting the finding. Checks can be suppressed by their name.
+	Check    string       `json:"check"`
+	Severity LintSeverity `json:"severity"`
+	// Msg is the translation key of the finding's message, it is translated using the Args.
+	Msg string `json:"message"`
+	// Args are the translation arguments of the message as key-value pairs.
+	Args []string `json:"args,omitempty"`
+}
+
+// LintTemplateConfigEvent is published to lint a template config. Like the ValidateTemplateConfigEvent it allows other
+// modules to lint the templates of the types they support. The config is expected to be valid.
+type LintTemplateConfigEvent struct {
+	Config       string
+	TemplateType string
+	// TemplateSet is the template set the template belongs to. It may be uuid.Nil.
+	TemplateSet uuid.UUID
+	findings    []LintFinding
+}
+
+// ParseLintSeverity returns the LintSeverity of the string and true if it is a known severity.
+func ParseLintSeverity(s string) (LintSeverity, bool) {
+	switch severity := LintSeverity(s); severity {
+	case LintSeverityError, LintSeverityWarning, LintSeverityInfo:
+		return severity, true
+	default:
+		return "", false
+	}
+}
+
+// LintTemplateConfig lints the template config of the template type using the LintTemplateConfigEvent.
+// The event is published synchronously, ErrLintConfigEvent is returned if any subscriber failed.
+// The findings are returned in the order the subscribers added them.
+func LintTemplateConfig(config, templateType string, templateSet uuid.UUID, em event.Manager, logger trace.Logger) ([]LintFinding, error) {
+	lintEvent := &LintTemplateConfigEvent{
+		Config:       config,
+		TemplateType: templateType,
+		TemplateSet:  templateSet,
+	}
+
+	if errs := em.PublishSync(lintEvent); errs != nil {
+		logger.Error(Pkg, "linting template config failed during event", nil, "errors", errs, "event", lintEvent.ID())
+		return nil, ErrLintConfigEvent
+	}
+
+	return lintEvent.findings, nil
+}
+
+// HasLintErrors returns true if any of the findings has the severity LintSeverityError.
+func HasLintErrors(findings []LintFinding) bool {
+	for _, finding := range findings {
+		if finding.Severity == LintSeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ID returns the event id.
+func (e *LintTemplateConfigEvent) ID() string {
+	return LintTemplateConfigEventID
+}
+
+// Payload returns the event payload. It is the event itself as a pointer, only findings should be added to it.
+func (e *LintTemplateConfigEvent) Payload() any {
+	return e
+}
+
+// AddFindings adds findings to the event. They are returned by LintTemplateConfig.
+func (e *LintTemplateConfigEvent) AddFindings(findings ...LintFinding) {
+	e.findings = append(e.findings, findings...)
+}
+
+// Translate on LintFinding translates the finding's message using the given translator.
+func (f LintFinding) Translate(t trans.Translator) string {
+	return t.Tf(f.Msg, f.Args...)
+}
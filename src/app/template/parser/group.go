@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxOccurrences is the maximum number of occurrences of a repeatable segment group.
+const MaxOccurrences = 50
+
+// SegmentGroupRow is an occurrence of a repeatable segment group. It holds the values of the occurrence's segments
+// keyed by the rules' names.
+type SegmentGroupRow map[string]string
+
+// Multiplicity is the number of occurrences allowed of a repeatable segment group, e.g. 1 to 3 stakeholders.
+// Max 0 allows up to MaxOccurrences occurrences.
+type Multiplicity struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// IndexedSegmentName returns the name of the index's occurrence of a repeated rule's segment, e.g. "stakeholder[0]".
+// Repeatable segment groups are rules that occur 0..n times, e.g. the stakeholders or the conditions of a requirement.
+// Each occurrence's segments are passed to the parser as ParsingSegments like any other segment named by IndexedSegmentName,
+// use GroupSegments to collect the occurrences of a group.
+func IndexedSegmentName(rule string, index int) string {
+	return rule + "[" + strconv.Itoa(index) + "]"
+}
+
+// SplitIndexedSegmentName splits the name of a repeated segment into the rule's name and the index (see IndexedSegmentName).
+// Ok is false if the name is not the name of a repeated segment.
+func SplitIndexedSegmentName(name string) (rule string, index int, ok bool) {
+	open := strings.LastIndex(name, "[")
+	if open <= 0 || !strings.HasSuffix(name, "]") {
+		return "", 0, false
+	}
+
+	index, err := strconv.Atoi(name[open+1 : len(name)-1])
+	if err != nil || index < 0 {
+		return "", 0, false
+	}
+
+	return name[:open], index, true
+}
+
+// GroupSegments collects the occurrences of the repeatable group of the rules from the segments ordered by their indexes.
+// Occurrences are renumbered, gaps left by removed occurrences are closed. Empty occurrences are kept, see SegmentGroupRow.Empty.
+// Segments of other rules and segments that are not repeated are ignored. Values are trimmed.
+func GroupSegments(rules []string, segments ...ParsingSegment) []SegmentGroupRow {
+	inGroup := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		inGroup[rule] = true
+	}
+
+	rows := make(map[int]SegmentGroupRow)
+	for _, segment := range segments {
+		rule, index, ok := segment.Occurrence()
+		if !ok || !inGroup[rule] {
+			continue
+		}
+
+		if rows[index] == nil {
+			rows[index] = make(SegmentGroupRow)
+		}
+		rows[index][rule] = strings.TrimSpace(segment.Value)
+	}
+
+	indexes := make([]int, 0, len(rows))
+	for index := range rows {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	grouped := make([]SegmentGroupRow, 0, len(indexes))
+	for _, index := range indexes {
+		grouped = append(grouped, rows[index])
+	}
+
+	return grouped
+}
+
+// UngroupSegments returns the segments of the occurrences named by IndexedSegmentName.
+// The occurrences are indexed in their order starting at 0. The segments of an occurrence are ordered by the rules' names.
+func UngroupSegments(rows ...SegmentGroupRow) []ParsingSegment {
+	var segments []ParsingSegment
+	for i, row := range rows {
+		rules := make([]string, 0, len(row))
+		for rule := range row {
+			rules = append(rules, rule)
+		}
+		sort.Strings(rules)
+
+		for _, rule := range rules {
+			segments = append(segments, ParsingSegment{Name: IndexedSegmentName(rule, i), Value: row[rule]})
+		}
+	}
+
+	return segments
+}
+
+// Occurrence returns the rule's name and the index of a repeated segment, see IndexedSegmentName.
+// Repeated is false if the segment is not repeated, the rule's name is the segment's name then.
+func (s ParsingSegment) Occurrence() (rule string, index int, repeated bool) {
+	rule, index, repeated = SplitIndexedSegmentName(s.Name)
+	if !repeated {
+		return s.Name, 0, false
+	}
+
+	return rule, index, true
+}
+
+// Empty returns true if all values of the occurrence are empty.
+func (r SegmentGroupRow) Empty() bool {
+	for _, value := range r {
+		if value != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Valid returns true if the multiplicity's minimum and maximum are within 0 and MaxOccurrences and the minimum does not exceed the maximum.
+func (m Multiplicity) Valid() bool {
+	return m.Min >= 0 && m.Max >= 0 && m.Max <= MaxOccurrences && m.Min <= m.Limit()
+}
+
+// Limit returns the maximum number of occurrences: Max or MaxOccurrences if Max is 0.
+func (m Multiplicity) Limit() int {
+	if m.Max == 0 {
+		return MaxOccurrences
+	}
+
+	return m.Max
+}
+
+// Check checks the number of occurrences of the group. Too few or too many occurrences are reported as an error
+// of the group ("template.parser.too-few-occurrences", "template.parser.too-many-occurrences") translated with the
+// parameters "group" (group's name), "min" or "max". The log's Constraint is the group's name as it refers to all occurrences.
+func (m Multiplicity) Check(group string, occurrences int) []ParsingLog {
+	log := func(msg string, arg string, value int) []ParsingLog {
+		return []ParsingLog{{
+			Level:           ParsingLogLevelError,
+			Message:         msg,
+			TranslationArgs: []string{"group", group, arg, strconv.Itoa(value)},
+			Constraint:      group,
+		}}
+	}
+
+	if occurrences < m.Min {
+		return log("template.parser.too-few-occurrences", "min", m.Min)
+	}
+	if occurrences > m.Limit() {
+		return log("template.parser.too-many-occurrences", "max", m.Limit())
+	}
+
+	return nil
+}
+
+// LogsByOccurrence aggregates the logs of all levels of the repeated segments of the rules by the occurrences' indexes.
+// It can be used to display the violations of each occurrence of a repeatable group, see IndexedSegmentName.
+func (r ParsingResult) LogsByOccurrence(rules ...string) map[int][]ParsingLog {
+	inGroup := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		inGroup[rule] = true
+	}
+
+	logs := make(map[int][]ParsingLog)
+	for _, levelLogs := range [][]ParsingLog{r.Errors, r.Warnings, r.Notices} {
+		for _, log := range levelLogs {
+			if log.Segment == nil {
+				continue
+			}
+
+			rule, index, ok := log.Segment.Occurrence()
+			if ok && inGroup[rule] {
+				logs[index] = append(logs[index], log)
+			}
+		}
+	}
+
+	return logs
+}
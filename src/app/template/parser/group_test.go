@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSplitIndexedSegmentName(t *testing.T) {
+	name := IndexedSegmentName("stakeholder", 2)
+	assert.Equal(t, "stakeholder[2]", name)
+
+	rule, index, ok := SplitIndexedSegmentName(name)
+	assert.True(t, ok)
+	assert.Equal(t, "stakeholder", rule)
+	assert.Equal(t, 2, index)
+
+	rule, index, ok = ParsingSegment{Name: "a[b][10]"}.Occurrence()
+	assert.True(t, ok)
+	assert.Equal(t, "a[b]", rule)
+	assert.Equal(t, 10, index)
+
+	rule, _, ok = ParsingSegment{Name: "system"}.Occurrence()
+	assert.False(t, ok)
+	assert.Equal(t, "system", rule)
+
+	for _, name := range []string{"given", "[0]", "given[]", "given[x]", "given[-1]", "given[0"} {
+		_, _, ok = SplitIndexedSegmentName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestGroupSegments(t *testing.T) {
+	rows := GroupSegments(
+		[]string{"given", "then"},
+		ParsingSegment{Name: "role", Value: "user"},
+		ParsingSegment{Name: "given[5]", Value: " a user "},
+		ParsingSegment{Name: "then[5]", Value: "it works"},
+		ParsingSegment{Name: "given[1]", Value: "an admin"},
+		ParsingSegment{Name: "given[3]", Value: ""},
+		ParsingSegment{Name: "then[3]", Value: " "},
+		ParsingSegment{Name: "other[0]", Value: "ignored"},
+	)
+
+	assert.Equal(t, []SegmentGroupRow{
+		{"given": "an admin"},
+		{"given": "", "then": ""},
+		{"given": "a user", "then": "it works"},
+	}, rows)
+	assert.True(t, rows[1].Empty())
+	assert.False(t, rows[2].Empty())
+
+	assert.Equal(t, []ParsingSegment{
+		{Name: "given[0]", Value: "an admin"},
+		{Name: "given[1]", Value: "a user"},
+		{Name: "then[1]", Value: "it works"},
+	}, UngroupSegments(rows[0], rows[2]))
+
+	assert.Empty(t, GroupSegments([]string{"given"}))
+}
+
+func TestMultiplicity(t *testing.T) {
+	assert.True(t, Multiplicity{}.Valid())
+	assert.True(t, Multiplicity{Min: 1, Max: 3}.Valid())
+	assert.True(t, Multiplicity{Min: MaxOccurrences}.Valid())
+	assert.False(t, Multiplicity{Min: 3, Max: 1}.Valid())
+	assert.False(t, Multiplicity{Min: -1}.Valid())
+	assert.False(t, Multiplicity{Max: MaxOccurrences + 1}.Valid())
+
+	m := Multiplicity{Min: 1, Max: 2}
+	assert.Empty(t, m.Check("stakeholders", 1))
+	assert.Empty(t, m.Check("stakeholders", 2))
+
+	logs := m.Check("stakeholders", 0)
+	assert.Equal(t, []ParsingLog{{
+		Level:           ParsingLogLevelError,
+		Message:         "template.parser.too-few-occurrences",
+		TranslationArgs: []string{"group", "stakeholders", "min", "1"},
+		Constraint:      "stakeholders",
+	}}, logs)
+
+	logs = m.Check("stakeholders", 3)
+	assert.Equal(t, "template.parser.too-many-occurrences", logs[0].Message)
+	assert.Equal(t, []string{"group", "stakeholders", "max", "2"}, logs[0].TranslationArgs)
+
+	assert.Len(t, Multiplicity{}.Check("stakeholders", MaxOccurrences+1), 1)
+}
+
+func TestParsingResult_LogsByOccurrence(t *testing.T) {
+	result := ParsingResult{
+		Errors: []ParsingLog{
+			{Segment: &ParsingSegment{Name: "given[0]"}, Message: "a"},
+			{Segment: &ParsingSegment{Name: "then[1]"}, Message: "b"},
+			{Segment: &ParsingSegment{Name: "system"}, Message: "c"},
+			{Message: "d", Constraint: "criteria"},
+		},
+		Warnings: []ParsingLog{{Segment: &ParsingSegment{Name: "when[0]"}, Message: "e"}},
+		Notices:  []ParsingLog{{Segment: &ParsingSegment{Name: "then[0]"}, Message: "f"}},
+	}
+
+	logs := result.LogsByOccurrence("given", "then")
+	assert.Len(t, logs, 2)
+	assert.Equal(t, []string{"a", "f"}, []string{logs[0][0].Message, logs[0][1].Message})
+	assert.Equal(t, "b", logs[1][0].Message)
+}
@@ -50,6 +50,42 @@ type ParsingLog struct {
 	// Downgrade indicates that the parsing log was downgraded to a lower level.
 	// This is usually the case when parsing errors occur on optional rules.
 	Downgrade bool
+	// Ranges optionally mark the exact parts of the segment's value the log refers to.
+	// They can be used to visually highlight the violating part of the user's input.
+	Ranges []ParsingRange
+	// Constraint is the name of the template-level constraint that reported the log. It is empty for logs of segments.
+	// Logs of constraints refer to the whole requirement, therefore they have no segment.
+	Constraint string
+}
+
+// ParsingRange is a severity-tagged range within a segment's value. Start and End are counted in characters (runes),
+// Start is inclusive and End is exclusive. A range spanning the whole segment is from 0 to the segment's length.
+type ParsingRange struct {
+	Start int
+	End   int
+	Level ParsingLogLevel
+}
+
+// HighlightPart is a consecutive part of a segment's value that is either highlighted with a level or not highlighted at all.
+// A segment's value split into HighlightPart's can be rendered by the UI to highlight ranges of the value.
+type HighlightPart struct {
+	Text        string
+	Highlighted bool
+	Level       ParsingLogLevel
+}
+
+// String on ParsingLogLevel returns the name of the level: "error", "warning" or "notice".
+func (l ParsingLogLevel) String() string {
+	switch l {
+	case ParsingLogLevelError:
+		return "error"
+	case ParsingLogLevelWarning:
+		return "warning"
+	case ParsingLogLevelNotice:
+		return "notice"
+	default:
+		return "unknown"
+	}
 }
 
 // String on ParsingLog returns the message of the log.
@@ -62,6 +98,80 @@ func (l ParsingLog) Translate(translator trans.Translator) string {
 	return translator.Tf(l.Message, l.TranslationArgs...)
 }
 
+// Highlight on ParsingLog splits the value of the log's segment into parts highlighted by the log's ranges.
+// If the log has no segment or no ranges, nil is returned.
+func (l ParsingLog) Highlight() []HighlightPart {
+	if l.Segment == nil || len(l.Ranges) == 0 {
+		return nil
+	}
+
+	return Highlight(l.Segment.Value, l.Ranges...)
+}
+
+// Downgraded returns a copy of the log downgraded to the given level. The Downgrade flag is set and all
+// ranges of a higher severity are downgraded to the level as well.
+func (l ParsingLog) Downgraded(level ParsingLogLevel) ParsingLog {
+	l.Level = level
+	l.Downgrade = true
+
+	ranges := make([]ParsingRange, len(l.Ranges))
+	for i, r := range l.Ranges {
+		if r.Level < level {
+			r.Level = level
+		}
+		ranges[i] = r
+	}
+	if len(ranges) > 0 {
+		l.Ranges = ranges
+	}
+
+	return l
+}
+
+// Highlight splits the value into consecutive parts that are highlighted by the given ranges.
+// Overlapping ranges are merged, the most severe level of all ranges covering a character is used.
+// Ranges are clamped to the value. Offsets are counted in characters (runes).
+func Highlight(value string, ranges ...ParsingRange) []HighlightPart {
+	runes := []rune(value)
+	if len(runes) == 0 {
+		return nil
+	}
+
+	// levels holds the most severe level per rune or -1 if the rune is not highlighted
+	levels := make([]ParsingLogLevel, len(runes))
+	for i := range levels {
+		levels[i] = -1
+	}
+
+	for _, r := range ranges {
+		start := max(r.Start, 0)
+		end := min(r.End, len(runes))
+		for i := start; i < end; i++ {
+			if levels[i] == -1 || r.Level < levels[i] {
+				levels[i] = r.Level
+			}
+		}
+	}
+
+	var parts []HighlightPart
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && levels[i] == levels[start] {
+			continue
+		}
+
+		part := HighlightPart{Text: string(runes[start:i])}
+		if levels[start] != -1 {
+			part.Highlighted = true
+			part.Level = levels[start]
+		}
+		parts = append(parts, part)
+		start = i
+	}
+
+	return parts
+}
+
 // Ok returns true if the parsing result has no errors.
 func (r ParsingResult) Ok() bool {
 	return len(r.Errors) == 0
@@ -75,10 +185,11 @@ func (r ParsingResult) Flawless() bool {
 // ViolationsForRule returns all violations (errors) for a given rule.
 // This can be used to check if a rule was violated and what to display to the user.
 // Warnings and Notices are not considered violations and will be displayed to the user as general information, not per rule.
+// Violations of constraints refer to no rule, see ConstraintLogs.
 func (r ParsingResult) ViolationsForRule(rule string) []ParsingLog {
 	var violations []ParsingLog
 	for _, log := range r.Errors {
-		if log.Segment.Name != rule {
+		if log.Segment == nil || log.Segment.Name != rule {
 			continue
 		}
 
@@ -87,3 +198,18 @@ func (r ParsingResult) ViolationsForRule(rule string) []ParsingLog {
 
 	return violations
 }
+
+// ConstraintLogs returns the logs of all levels reported by template-level constraints ordered by their level.
+// They are part of Errors, Warnings and Notices as well but refer to the whole requirement instead of a rule.
+func (r ParsingResult) ConstraintLogs() []ParsingLog {
+	var logs []ParsingLog
+	for _, levelLogs := range [][]ParsingLog{r.Errors, r.Warnings, r.Notices} {
+		for _, log := range levelLogs {
+			if log.Constraint != "" {
+				logs = append(logs, log)
+			}
+		}
+	}
+
+	return logs
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	t.Run("no ranges", func(t *testing.T) {
+		assert.Equal(t, []HighlightPart{{Text: "foo bar"}}, Highlight("foo bar"))
+		assert.Nil(t, Highlight(""))
+	})
+
+	t.Run("ranges are split into parts", func(t *testing.T) {
+		parts := Highlight("das ist größer", ParsingRange{Start: 8, End: 14, Level: ParsingLogLevelWarning})
+		assert.Equal(t, []HighlightPart{
+			{Text: "das ist "},
+			{Text: "größer", Highlighted: true, Level: ParsingLogLevelWarning},
+		}, parts)
+	})
+
+	t.Run("overlapping ranges use the most severe level and are clamped", func(t *testing.T) {
+		parts := Highlight(
+			"abcdef",
+			ParsingRange{Start: -2, End: 3, Level: ParsingLogLevelNotice},
+			ParsingRange{Start: 2, End: 4, Level: ParsingLogLevelError},
+			ParsingRange{Start: 5, End: 10, Level: ParsingLogLevelWarning},
+		)
+		assert.Equal(t, []HighlightPart{
+			{Text: "ab", Highlighted: true, Level: ParsingLogLevelNotice},
+			{Text: "cd", Highlighted: true, Level: ParsingLogLevelError},
+			{Text: "e"},
+			{Text: "f", Highlighted: true, Level: ParsingLogLevelWarning},
+		}, parts)
+	})
+}
+
+func TestParsingLog_Downgraded(t *testing.T) {
+	log := ParsingLog{
+		Segment: &ParsingSegment{Name: "foo", Value: "bar"},
+		Level:   ParsingLogLevelError,
+		Ranges:  []ParsingRange{{Start: 0, End: 3, Level: ParsingLogLevelError}},
+	}
+
+	downgraded := log.Downgraded(ParsingLogLevelNotice)
+	assert.True(t, downgraded.Downgrade)
+	assert.Equal(t, ParsingLogLevelNotice, downgraded.Level)
+	assert.Equal(t, ParsingLogLevelNotice, downgraded.Ranges[0].Level)
+	assert.Equal(t, ParsingLogLevelError, log.Ranges[0].Level, "original log must not be modified")
+	assert.Equal(t, []HighlightPart{{Text: "bar", Highlighted: true, Level: ParsingLogLevelNotice}}, downgraded.Highlight())
+}
@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxSentenceWords is the maximum number of words of a sentence to segment. Words beyond are appended to the last segment.
+const MaxSentenceWords = 200
+
+// SegmentationSlot describes a segment to find in a sentence. Slots are found in the order they are passed in.
+type SegmentationSlot struct {
+	// Name is the name of the segment, e.g. the rule's name.
+	Name string
+	// Anchors are the expected values of the segment, e.g. the values of an equals rule. The words of an anchored slot
+	// must equal one of the anchors (case-insensitive, ignoring punctuation). Anchors delimit the free text of the slots without anchors.
+	Anchors []string
+	// Open allows other words than the anchors, e.g. for equalsAny rules allowing other values.
+	Open bool
+	// Optional allows the slot to be empty.
+	Optional bool
+}
+
+// Segmentation is the result of splitting a sentence into segments, see SegmentSentence.
+type Segmentation struct {
+	// Segments are the segments in the order of the slots. Segments of empty slots have an empty value.
+	Segments []ParsingSegment
+	// Confidences are the confidences (0-1) of each segment keyed by the segment's name.
+	Confidences map[string]float64
+	// Confidence is the average confidence of all segments (0-1). It is 0 if no slots were passed in.
+	Confidence float64
+}
+
+// word is a word of a sentence and its normalized form used for comparisons.
+type word struct {
+	text       string
+	normalized string
+}
+
+const (
+	scoreAnchor   = 4
+	scoreFree     = 2
+	scoreOpen     = 1
+	scoreOptional = -1 // optional free text is only filled if the words can not be assigned otherwise
+	scoreMissing  = -4
+	scoreMismatch = -1000
+)
+
+// SegmentSentence splits a free-form sentence into segments using the slots' anchors as delimiters.
+// E.g. the slots "system" (free), "modal" (anchors "shall", "should") and "process" (free) split the sentence
+// "The system shall respond within 2 seconds." into "The system", "shall" and "respond within 2 seconds".
+//
+// The words of the sentence are assigned to the slots in order, maximizing matched anchors and filled slots.
+// Each segment's confidence is 1 for matched anchors and empty optional slots, 0.5 for free text which is not delimited
+// by anchors (or the sentence's start or end) on both sides and other words of open slots and 0 for missing required slots
+// or words not matching the anchors of a slot.
+// Leading and trailing punctuation of segments is removed.
+func SegmentSentence(sentence string, slots []SegmentationSlot) Segmentation {
+	segmentation := Segmentation{Confidences: make(map[string]float64, len(slots))}
+	if len(slots) == 0 {
+		return segmentation
+	}
+
+	words := sentenceWords(sentence)
+	n, s := len(words), len(slots)
+
+	// best[i][j] is the best score assigning the first j words to the first i slots, from[i][j] the start of slot i's words
+	best := make([][]int, s+1)
+	from := make([][]int, s+1)
+	for i := range best {
+		best[i] = make([]int, n+1)
+		from[i] = make([]int, n+1)
+		for j := range best[i] {
+			best[i][j] = scoreMismatch * (s + 1)
+		}
+	}
+	best[0][0] = 0
+
+	for i := 1; i <= s; i++ {
+		for j := 0; j <= n; j++ {
+			for k := 0; k <= j; k++ {
+				if best[i-1][k] <= scoreMismatch*(s+1) {
+					continue
+				}
+
+				score := best[i-1][k] + slotScore(slots[i-1], words[k:j])
+				if score > best[i][j] {
+					best[i][j] = score
+					from[i][j] = k
+				}
+			}
+		}
+	}
+
+	spans := make([][2]int, s)
+	for i, j := s, n; i > 0; i-- {
+		spans[i-1] = [2]int{from[i][j], j}
+		j = from[i][j]
+	}
+
+	anchored := make([]bool, s)
+	for i, slot := range slots {
+		anchored[i] = len(slot.Anchors) > 0 && matchAnchor(slot, words[spans[i][0]:spans[i][1]])
+	}
+
+	// delimited returns true if the next non-empty slot in the direction is anchored or there is none
+	delimited := func(i int, direction int) bool {
+		for i += direction; i >= 0 && i < s; i += direction {
+			if spans[i][0] != spans[i][1] {
+				return anchored[i]
+			}
+		}
+
+		return true
+	}
+
+	total := 0.0
+	for i, slot := range slots {
+		spanWords := words[spans[i][0]:spans[i][1]]
+		texts := make([]string, 0, len(spanWords))
+		for _, w := range spanWords {
+			texts = append(texts, w.text)
+		}
+		value := strings.TrimFunc(strings.Join(texts, " "), isPunctuation)
+
+		confidence := 0.5
+		switch {
+		case value == "" && slot.Optional:
+			confidence = 1
+		case value == "", len(slot.Anchors) > 0 && !anchored[i] && !slot.Open:
+			confidence = 0
+		case anchored[i]:
+			confidence = 1
+		case len(slot.Anchors) == 0 && delimited(i, -1) && delimited(i, 1):
+			confidence = 1
+		}
+
+		segmentation.Segments = append(segmentation.Segments, ParsingSegment{Name: slot.Name, Value: value})
+		segmentation.Confidences[slot.Name] = confidence
+		total += confidence
+	}
+	segmentation.Confidence = total / float64(s)
+
+	return segmentation
+}
+
+// slotScore scores assigning the words to the slot. It is scoreMismatch if the words can not be assigned to the slot.
+func slotScore(slot SegmentationSlot, words []word) int {
+	if len(words) == 0 {
+		if slot.Optional {
+			return 0
+		}
+
+		return scoreMissing
+	}
+
+	if len(slot.Anchors) == 0 && slot.Optional {
+		return scoreOptional
+	}
+
+	if len(slot.Anchors) == 0 {
+		return scoreFree
+	}
+
+	if matchAnchor(slot, words) {
+		return scoreAnchor + len(words)
+	}
+
+	if slot.Open {
+		return scoreOpen
+	}
+
+	return scoreMismatch
+}
+
+// matchAnchor returns true if the words equal one of the slot's anchors.
+func matchAnchor(slot SegmentationSlot, words []word) bool {
+	normalized := make([]string, 0, len(words))
+	for _, w := range words {
+		normalized = append(normalized, w.normalized)
+	}
+	joined := strings.Join(normalized, " ")
+
+	for _, anchor := range slot.Anchors {
+		if joined != "" && joined == normalizeWords(anchor) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sentenceWords splits the sentence into words on whitespace. Words beyond MaxSentenceWords are appended to the last word.
+func sentenceWords(sentence string) []word {
+	fields := strings.Fields(sentence)
+	if len(fields) > MaxSentenceWords {
+		fields = append(fields[:MaxSentenceWords-1], strings.Join(fields[MaxSentenceWords-1:], " "))
+	}
+
+	words := make([]word, 0, len(fields))
+	for _, field := range fields {
+		words = append(words, word{text: field, normalized: normalizeWords(field)})
+	}
+
+	return words
+}
+
+// normalizeWords lower-cases the text, removes leading and trailing punctuation of each word and joins the words by a single space.
+func normalizeWords(text string) string {
+	fields := strings.Fields(strings.ToLower(text))
+	normalized := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimFunc(field, isPunctuation); field != "" {
+			normalized = append(normalized, field)
+		}
+	}
+
+	return strings.Join(normalized, " ")
+}
+
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r)
+}
+
+// ConfidencePercent returns the confidence of the segmentation in percent (0-100) rounded down.
+func (s Segmentation) ConfidencePercent() int {
+	return int(s.Confidence * 100)
+}
+
+// Uncertain returns the names of the segments whose confidence is below 1 in the order of the segments.
+func (s Segmentation) Uncertain() []string {
+	var names []string
+	for _, segment := range s.Segments {
+		if s.Confidences[segment.Name] < 1 {
+			names = append(names, segment.Name)
+		}
+	}
+
+	return names
+}
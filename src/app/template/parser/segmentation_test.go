@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSegmentSentence(t *testing.T) {
+	slots := []SegmentationSlot{
+		{Name: "condition", Optional: true},
+		{Name: "system"},
+		{Name: "modal", Anchors: []string{"shall", "should", "will"}},
+		{Name: "process"},
+	}
+
+	segmentation := SegmentSentence("The System SHALL respond within 2 seconds.", slots)
+	assert.Equal(t, []ParsingSegment{
+		{Name: "condition"},
+		{Name: "system", Value: "The System"},
+		{Name: "modal", Value: "SHALL"},
+		{Name: "process", Value: "respond within 2 seconds"},
+	}, segmentation.Segments)
+	assert.Equal(t, map[string]float64{"condition": 1, "system": 1, "modal": 1, "process": 1}, segmentation.Confidences)
+	assert.Equal(t, 1.0, segmentation.Confidence)
+
+	segmentation = SegmentSentence("the system respond", slots)
+	assert.Equal(t, "", segmentation.Segments[2].Value)
+	assert.Equal(t, 0.0, segmentation.Confidences["modal"])
+	assert.Less(t, segmentation.Confidence, 1.0)
+	assert.Less(t, segmentation.ConfidencePercent(), 100)
+	assert.Contains(t, segmentation.Uncertain(), "modal")
+
+	assert.Empty(t, SegmentSentence("anything", nil).Segments)
+}
+
+func TestSegmentSentence_Anchors(t *testing.T) {
+	slots := []SegmentationSlot{
+		{Name: "prefix", Anchors: []string{"as a", "as an"}},
+		{Name: "role"},
+		{Name: "want", Anchors: []string{"I want to"}},
+		{Name: "goal"},
+		{Name: "benefit-prefix", Anchors: []string{", so that"}, Optional: true},
+		{Name: "benefit", Optional: true},
+	}
+
+	segmentation := SegmentSentence("As an administrator, I want to manage users so that teams can work together.", slots)
+	values := map[string]string{}
+	for _, segment := range segmentation.Segments {
+		values[segment.Name] = segment.Value
+	}
+	assert.Equal(t, map[string]string{
+		"prefix":         "As an",
+		"role":           "administrator",
+		"want":           "I want to",
+		"goal":           "manage users",
+		"benefit-prefix": "so that",
+		"benefit":        "teams can work together",
+	}, values)
+	assert.Equal(t, 1.0, segmentation.Confidence)
+
+	segmentation = SegmentSentence("As an administrator I want to manage users", slots)
+	assert.Equal(t, "", segmentation.Segments[4].Value)
+	assert.Equal(t, 1.0, segmentation.Confidence, "missing optional slots are certain")
+
+	open := []SegmentationSlot{{Name: "modal", Anchors: []string{"shall"}, Open: true}, {Name: "rest"}}
+	segmentation = SegmentSentence("must respond", open)
+	assert.Equal(t, "must", segmentation.Segments[0].Value)
+	assert.Equal(t, 0.5, segmentation.Confidences["modal"])
+
+	long := strings.Repeat("word ", MaxSentenceWords+10)
+	segmentation = SegmentSentence(long, []SegmentationSlot{{Name: "text"}})
+	assert.Equal(t, strings.TrimSpace(long), segmentation.Segments[0].Value)
+}
@@ -0,0 +1,130 @@
+package template
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"runtime"
+	"sync"
+)
+
+// ValidationJob is a template config validated by a ValidationPipeline.
+type ValidationJob struct {
+	Config       string
+	TemplateType string
+	// TemplateSet is the template set the template belongs to. It may be uuid.Nil if the template set does not exist (yet).
+	TemplateSet uuid.UUID
+}
+
+// ValidationResult is the result of a ValidationJob. Errs are the validation errors safe to show to the user,
+// Err is set if the job could not be validated, e.g. because the validation was canceled.
+type ValidationResult struct {
+	Job  ValidationJob
+	Errs []error
+	Err  error
+}
+
+// ValidationPipeline validates many template configs concurrently, e.g. the templates of an imported template set.
+// Each config is validated through the ValidateTemplateConfigEvent (see ValidateTemplateConfig) by a bounded pool of workers.
+// The event is published synchronously in the worker (see event.Manager.PublishSync),
+// the subscribers of the ValidateTemplateConfigEvent must therefore be safe for concurrent use.
+type ValidationPipeline struct {
+	em      event.Manager
+	logger  trace.Logger
+	workers int
+}
+
+// ValidationPipelineOption configures the ValidationPipeline on creation through NewValidationPipeline.
+type ValidationPipelineOption func(*ValidationPipeline)
+
+// WithWorkers sets the maximum number of configs validated concurrently. By default, it is the number of usable CPUs.
+// Values smaller than 1 are ignored.
+func WithWorkers(workers int) ValidationPipelineOption {
+	return func(p *ValidationPipeline) {
+		if workers > 0 {
+			p.workers = workers
+		}
+	}
+}
+
+// NewValidationPipeline constructs a new ValidationPipeline publishing the validation events through the event manager.
+func NewValidationPipeline(em event.Manager, logger trace.Logger, opts ...ValidationPipelineOption) *ValidationPipeline {
+	p := &ValidationPipeline{
+		em:      em,
+		logger:  logger,
+		workers: runtime.GOMAXPROCS(0),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Validate validates the jobs and returns their results in the order of the jobs. It returns once all jobs were validated
+// or the context was canceled. Jobs not validated before the cancellation carry the context's error as their Err.
+func (p *ValidationPipeline) Validate(ctx context.Context, jobs []ValidationJob) []ValidationResult {
+	results := make([]ValidationResult, len(jobs))
+	for i, job := range jobs {
+		results[i] = ValidationResult{Job: job}
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(p.workers, len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				results[i].Errs, results[i].Err = p.validate(ctx, jobs[i])
+			}
+		}()
+	}
+
+	next := 0
+dispatch:
+	for ; next < len(jobs); next++ {
+		select {
+		case indexes <- next:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for ; next < len(jobs); next++ {
+		results[next].Err = ctx.Err()
+	}
+
+	return results
+}
+
+// validate validates a single job unless the context was canceled in the meantime.
+func (p *ValidationPipeline) validate(ctx context.Context, job ValidationJob) ([]error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	validationEvent := &ValidateTemplateConfigEvent{
+		Config:       job.Config,
+		TemplateType: job.TemplateType,
+		TemplateSet:  job.TemplateSet,
+	}
+
+	return validationEventResult(validationEvent, p.em.PublishSync(validationEvent), p.logger)
+}
+
+// Valid returns true if all results were validated without validation errors.
+func Valid(results []ValidationResult) bool {
+	for _, result := range results {
+		if result.Err != nil || len(result.Errs) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
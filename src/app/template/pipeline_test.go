@@ -0,0 +1,89 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+)
+
+func TestValidationPipeline(t *testing.T) {
+	logger := trace.NewTestLogger(t)
+	em := event.NewManager(logger)
+
+	var running, maxRunning int32
+	em.Subscribe("template.config.validate", func(e event.Event, args *event.PublishArgs) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			peak := atomic.LoadInt32(&maxRunning)
+			if current <= peak || atomic.CompareAndSwapInt32(&maxRunning, peak, current) {
+				break
+			}
+		}
+
+		validateEvent := e.Payload().(*ValidateTemplateConfigEvent)
+		if validateEvent.TemplateType != "test" {
+			return nil
+		}
+
+		validateEvent.DidValidate = true
+		if validateEvent.Config == "invalid" {
+			validateEvent.AddErrors(ErrInvalidTemplate)
+		}
+
+		return nil
+	}, event.DefaultPriority)
+
+	jobs := make([]ValidationJob, 50)
+	for i := range jobs {
+		jobs[i] = ValidationJob{Config: "valid", TemplateType: "test"}
+	}
+	jobs[10].Config = "invalid"
+	jobs[20].TemplateType = "unknown"
+
+	results := NewValidationPipeline(em, logger, WithWorkers(4)).Validate(context.Background(), jobs)
+	require.Len(t, results, len(jobs))
+	assert.False(t, Valid(results))
+	assert.LessOrEqual(t, maxRunning, int32(4))
+
+	for i, result := range results {
+		assert.Equal(t, jobs[i], result.Job)
+		assert.NoError(t, result.Err)
+
+		switch i {
+		case 10:
+			assert.Equal(t, []error{ErrInvalidTemplate}, result.Errs)
+		case 20:
+			assert.Equal(t, []error{ErrDidNotValidate}, result.Errs)
+		default:
+			assert.Empty(t, result.Errs)
+		}
+	}
+
+	assert.True(t, Valid(NewValidationPipeline(em, logger).Validate(context.Background(), jobs[:5])))
+	assert.True(t, Valid(NewValidationPipeline(em, logger).Validate(context.Background(), nil)))
+}
+
+func TestValidationPipelineCanceled(t *testing.T) {
+	logger := trace.NewTestLogger(t)
+	em := event.NewManager(logger)
+	em.Subscribe("template.config.validate", func(e event.Event, args *event.PublishArgs) error {
+		e.Payload().(*ValidateTemplateConfigEvent).DidValidate = true
+		return nil
+	}, event.DefaultPriority)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := NewValidationPipeline(em, logger).Validate(canceled, []ValidationJob{{Config: "a"}, {Config: "b"}})
+	require.Len(t, results, 2)
+	assert.False(t, Valid(results))
+	for _, result := range results {
+		assert.True(t, errors.Is(result.Err, context.Canceled))
+	}
+}
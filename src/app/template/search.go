@@ -0,0 +1,78 @@
+package template
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	// SortName orders the found templates by their name and version.
+	SortName SearchSort = "name"
+	// SortRecentlyUsed orders the found templates by the time the user used them last (see FavoriteRepository.Use), the latest first.
+	// Templates the user never used follow ordered by their name.
+	SortRecentlyUsed SearchSort = "used"
+	// SortRecentlyUpdated orders the found templates by the time they were last updated or created, the latest first.
+	SortRecentlyUpdated SearchSort = "updated"
+)
+
+const (
+	// OriginOwn marks templates the user created.
+	OriginOwn Origin = "own"
+	// OriginProject marks templates shared with the user through a template set associated with one of the user's projects.
+	OriginProject Origin = "project"
+)
+
+// SearchSorts are the orders of found templates in the order they are offered to the user. SortName is the default.
+var SearchSorts = []SearchSort{SortName, SortRecentlyUsed, SortRecentlyUpdated}
+
+// SearchSort is the order of the templates found by Repository.FindByQueryForTypeAndUser and Repository.FindAccessibleByQuery.
+type SearchSort string
+
+// Origin is how the user has access to a template found by Repository.FindAccessibleByQuery.
+type Origin string
+
+// SearchFilter selects the templates found by Repository.FindByQueryForTypeAndUser and Repository.FindAccessibleByQuery. Templates must contain the Query
+// in their name, version or template set's name (case-insensitive). If TemplateSet is set, only templates of the template set are selected.
+// If Version is set, only templates whose version starts with it are selected, e.g. "1." selects all 1.x versions.
+// Templates last updated (or created if they were never updated) before UpdatedSince or at or after UpdatedBefore are not selected,
+// zero times do not restrict the selection. The templates are ordered by Sort, an empty Sort orders them by SortName.
+type SearchFilter struct {
+	Query         string
+	TemplateSet   uuid.UUID
+	Version       string
+	UpdatedSince  time.Time
+	UpdatedBefore time.Time
+	Sort          SearchSort
+}
+
+// ParseSearchSort returns the SearchSort of the string and true if it is a known order.
+func ParseSearchSort(s string) (SearchSort, bool) {
+	switch sort := SearchSort(s); sort {
+	case SortName, SortRecentlyUsed, SortRecentlyUpdated:
+		return sort, true
+	default:
+		return "", false
+	}
+}
+
+// orderBy returns the ORDER BY clause of the sort. The templates are expected to be joined with the user's template_favorites aliased as f.
+func (s SearchSort) orderBy() string {
+	switch s {
+	case SortRecentlyUsed:
+		return "f.used_at DESC NULLS LAST, lower(templates.name), templates.version"
+	case SortRecentlyUpdated:
+		return "COALESCE(templates.updated_at, templates.created_at) DESC, lower(templates.name), templates.version"
+	default:
+		return "lower(templates.name), templates.version"
+	}
+}
+
+// nilIfZero returns nil for the zero value, e.g. to pass optional filters as NULL parameters.
+func nilIfZero[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+
+	return &v
+}
@@ -0,0 +1,118 @@
+package template
+
+import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseSearchSort(t *testing.T) {
+	for _, sort := range SearchSorts {
+		parsed, ok := ParseSearchSort(string(sort))
+		assert.True(t, ok)
+		assert.Equal(t, sort, parsed)
+	}
+
+	_, ok := ParseSearchSort("name; DROP TABLE templates")
+	assert.False(t, ok)
+}
+
+func TestPGRepository_FindByQueryForTypeAndUser(t *testing.T) {
+	registerAllCleanup(t)
+
+	u, tmplSet, foo := mockTemplate(t)
+	other, err := templateSetRepo.Create(ctx, &SetToCreate{Name: "Other", Version: "1.0.0", CreatedBy: u.ID})
+	require.NoError(t, err)
+	bar, err := templateRepo.Create(ctx, &ToCreate{
+		Type:        "ebt",
+		Config:      `{"name": "Bar", "version": "2.1.0", "authors": ["Foo Bar"], "license": "MIT", "description": "Bar"}`,
+		TemplateSet: other.ID,
+		CreatedBy:   u.ID,
+	})
+	require.NoError(t, err)
+	require.NoError(t, NewFavoriteRepository(db).Use(ctx, u.ID, foo.ID))
+
+	find := func(filter SearchFilter) []uuid.UUID {
+		found, err := templateRepo.FindByQueryForTypeAndUser(ctx, filter, "ebt", u)
+		require.NoError(t, err)
+
+		ids := make([]uuid.UUID, 0, len(found))
+		for _, tmpl := range found {
+			ids = append(ids, tmpl.ID)
+		}
+
+		return ids
+	}
+
+	assert.Equal(t, []uuid.UUID{bar.ID, foo.ID}, find(SearchFilter{}), "templates are ordered by their name by default")
+	assert.Equal(t, []uuid.UUID{foo.ID, bar.ID}, find(SearchFilter{Sort: SortRecentlyUsed}))
+	assert.Equal(t, []uuid.UUID{bar.ID, foo.ID}, find(SearchFilter{Sort: SortRecentlyUpdated}))
+	assert.Equal(t, []uuid.UUID{foo.ID}, find(SearchFilter{TemplateSet: tmplSet.ID}))
+	assert.Equal(t, []uuid.UUID{bar.ID}, find(SearchFilter{Version: "2."}))
+	assert.Empty(t, find(SearchFilter{Version: "%"}), "wildcards are matched literally")
+	assert.Equal(t, []uuid.UUID{bar.ID}, find(SearchFilter{Query: "other"}), "the query matches the template set's name")
+	assert.Empty(t, find(SearchFilter{UpdatedSince: time.Now().Add(time.Hour)}))
+	assert.Empty(t, find(SearchFilter{UpdatedBefore: time.Now().Add(-time.Hour)}))
+	assert.Len(t, find(SearchFilter{UpdatedSince: time.Now().Add(-time.Hour), UpdatedBefore: time.Now().Add(time.Hour)}), 2)
+}
+
+func TestPGRepository_FindAccessibleByQuery(t *testing.T) {
+	registerAllCleanup(t)
+
+	owner, tmplSet, shared := mockTemplate(t)
+	member, err := userRepo.Create(ctx, &user.ToCreate{Email: "member@bar.com", Firstname: "Member", Lastname: "Bar"})
+	require.NoError(t, err)
+	memberSet, err := templateSetRepo.Create(ctx, &SetToCreate{Name: "Member", Version: "1.0.0", CreatedBy: member.ID})
+	require.NoError(t, err)
+	own, err := templateRepo.Create(ctx, &ToCreate{
+		Type:        "ebt",
+		Config:      `{"name": "Bar", "version": "1.0.0", "authors": ["Foo Bar"], "license": "MIT", "description": "Bar"}`,
+		TemplateSet: memberSet.ID,
+		CreatedBy:   member.ID,
+	})
+	require.NoError(t, err)
+
+	accessible, err := templateRepo.IsAccessible(ctx, shared.ID, member.ID)
+	require.NoError(t, err)
+	assert.False(t, accessible, "templates are private unless they are shared")
+
+	found, err := templateRepo.FindAccessibleByQuery(ctx, SearchFilter{}, "ebt", member)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, own.ID, found[0].ID)
+	assert.Equal(t, OriginOwn, found[0].Origin)
+
+	projectID := uuid.New()
+	_, err = db.Exec(ctx, "INSERT INTO projects (id, name, created_by) VALUES ($1, 'Paris', $2)", projectID, owner.ID)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "INSERT INTO project_members (project_id, user_id, role) VALUES ($1, $2, 'owner'), ($1, $3, 'member')", projectID, owner.ID, member.ID)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "INSERT INTO project_template_sets (project_id, template_set) VALUES ($1, $2)", projectID, tmplSet.ID)
+	require.NoError(t, err)
+
+	accessible, err = templateRepo.IsAccessible(ctx, shared.ID, member.ID)
+	require.NoError(t, err)
+	assert.True(t, accessible)
+
+	found, err = templateRepo.FindAccessibleByQuery(ctx, SearchFilter{}, "ebt", member)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, own.ID, found[0].ID)
+	assert.Equal(t, shared.ID, found[1].ID)
+	assert.Equal(t, OriginProject, found[1].Origin)
+	assert.Equal(t, "Paris", found[1].SharedIn)
+	assert.Equal(t, tmplSet.Name, found[1].TemplateSetElem.Name)
+
+	found, err = templateRepo.FindAccessibleByQuery(ctx, SearchFilter{}, "ebt", owner)
+	require.NoError(t, err)
+	require.Len(t, found, 1, "the owner's own templates are not shared with the owner")
+	assert.Equal(t, OriginOwn, found[0].Origin)
+	assert.Empty(t, found[0].SharedIn)
+
+	found, err = templateRepo.FindAccessibleByQuery(ctx, SearchFilter{Query: "foo"}, "ebt", member)
+	require.NoError(t, err)
+	assert.Len(t, found, 1, "shared templates are filtered as well")
+}
@@ -1,8 +1,11 @@
 package template
 
 import (
+	"context"
 	"errors"
+	"github.com/google/uuid"
 	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/org-harmony/harmony/src/core/trace"
 	"github.com/org-harmony/harmony/src/core/validation"
 )
@@ -17,18 +20,44 @@ var (
 	ErrDidNotValidate = validation.Error{Msg: "template.new.did-not-validate"}
 )
 
+const (
+	// TemplateUpdatedEventID is the id of the TemplateUpdatedEvent.
+	TemplateUpdatedEventID = "template.template.updated"
+	// SetImportedEventID is the id of the SetImportedEvent.
+	SetImportedEventID = "template.set.imported"
+)
+
 // ValidateTemplateConfigEvent is published to validate a template config. It allows for other modules to validate
 // specific parts or entire templates based on their own rules. This is helpful if a template should be validated against the rules of the parser.
 type ValidateTemplateConfigEvent struct {
-	Config         string
-	TemplateType   string
+	Config       string
+	TemplateType string
+	// TemplateSet is the template set the template belongs to. It allows validating a template in the context of its set.
+	TemplateSet    uuid.UUID
 	validationErrs []error
 	DidValidate    bool
 }
 
+// TemplateUpdatedEvent is published after a template was updated or deleted. It allows other modules to react to changes
+// of a template, e.g. to notify clients currently using the template. The event is published without waiting for subscribers.
+type TemplateUpdatedEvent struct {
+	Template uuid.UUID
+	Deleted  bool
+}
+
+// SetImportedEvent is published after a template set was imported, e.g. the default PARIS templates.
+// Events are handled outside the request, the event therefore carries the tenant of the request the set was imported in.
+// The event is published without waiting for subscribers.
+type SetImportedEvent struct {
+	TemplateSet *Set
+	// Tenant is the tenant the set was imported for. It is nil if the application is not multi-tenant.
+	Tenant *tenant.Tenant
+}
+
 // ValidateTemplateToCreate validates the template to create against the template set's rules and publishes an event
 // to validate the template config. The event allows for other modules to validate specific parts or entire templates
 // based on their own rules. This is helpful if a template should be validated against the rules of the parser.
+// The UI settings of the config (see UISettings) are validated by the template module itself.
 func ValidateTemplateToCreate(toCreate *ToCreate, validator validation.V, em event.Manager, logger trace.Logger) ([]error, error) {
 	err, validationErrs := validator.ValidateStruct(toCreate)
 	if err != nil {
@@ -38,12 +67,14 @@ func ValidateTemplateToCreate(toCreate *ToCreate, validator validation.V, em eve
 	configValidationErrs, err := publishValidationEvent(&ValidateTemplateConfigEvent{
 		Config:       toCreate.Config,
 		TemplateType: toCreate.Type,
+		TemplateSet:  toCreate.TemplateSet,
 	}, em, logger)
 	if err != nil {
 		return nil, err
 	}
 
 	validationErrs = append(validationErrs, configValidationErrs...)
+	validationErrs = append(validationErrs, validateUISettings(toCreate.Config)...)
 
 	return validationErrs, nil
 }
@@ -51,6 +82,7 @@ func ValidateTemplateToCreate(toCreate *ToCreate, validator validation.V, em eve
 // ValidateTemplateToUpdate validates the template to update against the template set's rules and publishes an event
 // to validate the template config. The event allows for other modules to validate specific parts or entire templates
 // based on their own rules. This is helpful if a template should be validated against the rules of the parser.
+// The UI settings of the config (see UISettings) are validated by the template module itself.
 func ValidateTemplateToUpdate(toUpdate *ToUpdate, validator validation.V, em event.Manager, logger trace.Logger) ([]error, error) {
 	err, validationErrs := validator.ValidateStruct(toUpdate)
 	if err != nil {
@@ -60,12 +92,14 @@ func ValidateTemplateToUpdate(toUpdate *ToUpdate, validator validation.V, em eve
 	configValidationErrs, err := publishValidationEvent(&ValidateTemplateConfigEvent{
 		Config:       toUpdate.Config,
 		TemplateType: toUpdate.Type,
+		TemplateSet:  toUpdate.TemplateSet,
 	}, em, logger)
 	if err != nil {
 		return nil, err
 	}
 
 	validationErrs = append(validationErrs, configValidationErrs...)
+	validationErrs = append(validationErrs, validateUISettings(toUpdate.Config)...)
 
 	return validationErrs, nil
 }
@@ -87,6 +121,59 @@ func (e *ValidateTemplateConfigEvent) AddErrors(errs ...error) {
 	e.validationErrs = append(e.validationErrs, errs...)
 }
 
+// ID returns the event id.
+func (e *TemplateUpdatedEvent) ID() string {
+	return TemplateUpdatedEventID
+}
+
+// Payload returns the event payload. It is the event itself as a pointer.
+func (e *TemplateUpdatedEvent) Payload() any {
+	return e
+}
+
+// PublishTemplateUpdated publishes a TemplateUpdatedEvent for the template without waiting for the subscribers.
+func PublishTemplateUpdated(em event.Manager, templateID uuid.UUID, deleted bool) {
+	em.Publish(&TemplateUpdatedEvent{Template: templateID, Deleted: deleted}, nil)
+}
+
+// ID returns the event id.
+func (e *SetImportedEvent) ID() string {
+	return SetImportedEventID
+}
+
+// Payload returns the event payload. It is the event itself as a pointer.
+func (e *SetImportedEvent) Payload() any {
+	return e
+}
+
+// Ctx returns a new context containing the tenant the set was imported for (see tenant.WithTenant).
+func (e *SetImportedEvent) Ctx() context.Context {
+	ctx := context.Background()
+	if e.Tenant != nil {
+		ctx = tenant.WithTenant(ctx, e.Tenant)
+	}
+
+	return ctx
+}
+
+// PublishSetImported publishes a SetImportedEvent for the template set imported in the context's tenant
+// without waiting for the subscribers.
+func PublishSetImported(ctx context.Context, em event.Manager, templateSet *Set) {
+	t, _ := tenant.FromCtx(ctx)
+	em.Publish(&SetImportedEvent{TemplateSet: templateSet, Tenant: t}, nil)
+}
+
+// ValidateTemplateConfig validates a template config of the template type using the ValidateTemplateConfigEvent
+// without validating a ToCreate or ToUpdate struct. This allows validating template configs that are not (yet) persisted,
+// e.g. template files validated offline. The template set is passed on to the event and may be uuid.Nil.
+func ValidateTemplateConfig(config, templateType string, templateSet uuid.UUID, em event.Manager, logger trace.Logger) ([]error, error) {
+	return publishValidationEvent(&ValidateTemplateConfigEvent{
+		Config:       config,
+		TemplateType: templateType,
+		TemplateSet:  templateSet,
+	}, em, logger)
+}
+
 // publishValidationEvent validates the config using an event published to other modules that may define their own parsers.
 // It returns an error if the event execution failed. Otherwise, a slice of validation errors is returned.
 func publishValidationEvent(validationEvent *ValidateTemplateConfigEvent, em event.Manager, logger trace.Logger) ([]error, error) {
@@ -94,7 +181,12 @@ func publishValidationEvent(validationEvent *ValidateTemplateConfigEvent, em eve
 	dc := make(chan []error)
 	em.Publish(validationEvent, dc)
 
-	errs := <-dc
+	return validationEventResult(validationEvent, <-dc, logger)
+}
+
+// validationEventResult returns the validation errors of a handled validation event. The errs are the errors of the event's subscribers,
+// ErrValidateConfigEvent is returned if any subscriber failed. ErrDidNotValidate is added if no subscriber validated the config.
+func validationEventResult(validationEvent *ValidateTemplateConfigEvent, errs []error, logger trace.Logger) ([]error, error) {
 	if errs != nil {
 		logger.Error(Pkg, "validating template config failed during event", nil, "errors", errs, "event", validationEvent.ID())
 		return nil, ErrValidateConfigEvent
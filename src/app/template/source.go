@@ -0,0 +1,138 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"time"
+)
+
+const (
+	// SourceRepositoryName is the name of the template set source repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	SourceRepositoryName = "TemplateSetSourceRepository"
+	// SourceURL is the kind of sources fetching a template config or an archive of template configs from an HTTPS URL.
+	SourceURL SourceKind = "url"
+	// SourceGit is the kind of sources fetching the template configs from a Git repository.
+	SourceGit SourceKind = "git"
+	// sourceColumns is the column list of the template_set_sources table in the order scanned by scanSource.
+	sourceColumns = "template_set_id, kind, url, ref, path, revision, base, synced_at"
+)
+
+// Cfg is the template package's configuration.
+type Cfg struct {
+	Import *ImportCfg `toml:"import" hvalidate:"required"`
+}
+
+// ImportCfg configures the import of templates from remote sources, see Source.
+type ImportCfg struct {
+	// MaxSize is the maximum size of a fetched template config or archive in bytes.
+	MaxSize int64 `toml:"max_size" hvalidate:"required"`
+	// MaxTemplates is the maximum number of templates imported from a source at once.
+	MaxTemplates int `toml:"max_templates" hvalidate:"required"`
+	// Timeout is the timeout of fetching a source in seconds.
+	Timeout int `toml:"timeout" hvalidate:"required"`
+	// MaxCloneSize is the maximum number of bytes received while cloning a Git repository. It defaults to ten times MaxSize.
+	MaxCloneSize int64 `toml:"max_clone_size"`
+	// AllowedHosts are the hosts templates may be imported from. Imports are disabled if it is empty.
+	AllowedHosts []string `toml:"allowed_hosts"`
+	// Git is the path to the git executable used by Git sources. Git sources are disabled if it is empty.
+	Git string `toml:"git"`
+}
+
+// SourceKind is the kind of remote source a template set was imported from.
+type SourceKind string
+
+// SetSource is the remote source a template set was imported from. It allows checking for updates and re-syncing the template set.
+type SetSource struct {
+	TemplateSet uuid.UUID
+	Kind        SourceKind
+	// URL is the HTTPS URL of the template config or archive, or of the Git repository.
+	URL string
+	// Ref is the branch or tag of Git sources.
+	Ref string
+	// Path is the file or directory within Git sources containing the template configs. It is the repository's root if it is empty.
+	Path string
+	// Revision identifies the fetched content: the commit of Git sources and the SHA-256 of the fetched content otherwise.
+	Revision string
+	// Base are the template configs fetched at the revision keyed by their SourceKey. They are the common ancestor
+	// of the local templates and the source's templates when planning an upgrade, see PlanUpgrade.
+	Base     map[string]string
+	SyncedAt time.Time
+}
+
+// SourceRepository is the template set source repository. It contains all methods to interact with the template_set_sources table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID). Callers are responsible for checking the user may access the template set.
+// SourceRepository is safe for concurrent use by multiple goroutines.
+type SourceRepository interface {
+	persistence.Repository
+
+	// FindBySet finds the source a template set was imported from.
+	// It returns persistence.ErrNotFound if the template set was not imported from a source and persistence.ErrReadRow for any other error.
+	FindBySet(ctx context.Context, templateSetID uuid.UUID) (*SetSource, error)
+	// Save creates or replaces the source of a template set. It returns persistence.ErrInsert if the source could not be saved.
+	Save(ctx context.Context, source *SetSource) error
+}
+
+// PGSourceRepository is the template set source repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGSourceRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewSourceRepository constructs a new PGSourceRepository with the passed in database connection pool.
+func NewSourceRepository(db *pgxpool.Pool) SourceRepository {
+	return &PGSourceRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGSourceRepository) RepositoryName() string {
+	return SourceRepositoryName
+}
+
+// FindBySet finds the source a template set was imported from.
+// It returns persistence.ErrNotFound if the template set was not imported from a source and persistence.ErrReadRow for any other error.
+func (r *PGSourceRepository) FindBySet(ctx context.Context, templateSetID uuid.UUID) (*SetSource, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+sourceColumns+" FROM template_set_sources WHERE template_set_id = $1 AND tenant_id = $2",
+		templateSetID, tenant.ID(ctx),
+	), scanSource)
+}
+
+// Save creates or replaces the source of a template set. It returns persistence.ErrInsert if the source could not be saved.
+func (r *PGSourceRepository) Save(ctx context.Context, source *SetSource) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	base := source.Base
+	if base == nil {
+		base = map[string]string{}
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		`INSERT INTO template_set_sources (`+sourceColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (template_set_id) DO UPDATE SET kind = $2, url = $3, ref = $4, path = $5, revision = $6, base = $7, synced_at = $8`,
+		source.TemplateSet, source.Kind, source.URL, source.Ref, source.Path, source.Revision, base, source.SyncedAt, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// scanSource scans a row containing the sourceColumns into a new SetSource.
+func scanSource(row pgx.Row) (*SetSource, error) {
+	s := &SetSource{}
+	err := row.Scan(&s.TemplateSet, &s.Kind, &s.URL, &s.Ref, &s.Path, &s.Revision, &s.Base, &s.SyncedAt)
+
+	return s, err
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/org-harmony/harmony/src/app/user"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"strings"
 	"time"
 )
@@ -18,7 +20,11 @@ const (
 	// SetRepositoryName is the name of the template set repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
 	SetRepositoryName = "SetRepository"
 	// Pkg is the package name for logging.
-	Pkg = "template"
+	Pkg = "app.template"
+	// templateColumns is the column list of the templates table in the order scanned by scanTemplate.
+	templateColumns = "id, template_set, type, name, version, config, created_by, created_at, updated_at"
+	// setColumns is the column list of the template_sets table in the order scanned by scanSet.
+	setColumns = "id, name, version, description, created_by, created_at, updated_at"
 )
 
 var (
@@ -44,6 +50,10 @@ type Template struct {
 	// TemplateSetElem is the template set that the template belongs to joined onto the template.
 	// Don't expect this to be filled unless the origin of the template object explicitly states that it is filled.
 	TemplateSetElem *Set
+	// Origin is how the user has access to the template and SharedIn the name of the project the template is shared in.
+	// Don't expect these to be filled unless the template was found by Repository.FindAccessibleByQuery.
+	Origin   Origin
+	SharedIn string
 }
 
 // ToCreate is the template entity that is used to create a new template.
@@ -83,42 +93,56 @@ type Set struct {
 }
 
 // SetToCreate is the template set entity that is used to create a new template set.
+// Its form fields are declared through the web.FormTag.
 type SetToCreate struct {
-	Name        string    `hvalidate:"required"`
-	Version     string    `hvalidate:"required,semVer"`
+	Name        string    `hvalidate:"required" hmsg:"template.set.validation.name-required" hform:"label=template.set.name,cols=6"`
+	Version     string    `hvalidate:"required,semVer" hmsg:"required=template.set.validation.version-required,semVer=template.set.validation.version-invalid" hform:"label=template.set.version,cols=6"`
 	CreatedBy   uuid.UUID `hvalidate:"required"`
-	Description string
+	Description string    `hform:"label=template.set.description,widget=textarea"`
 }
 
 // SetToUpdate is the template set entity that is used to update an existing template set.
+// Its form fields are declared through the web.FormTag.
 type SetToUpdate struct {
 	ID          uuid.UUID `hvalidate:"required"`
-	Name        string    `hvalidate:"required"`
-	Version     string    `hvalidate:"required,semVer"`
-	Description string
+	Name        string    `hvalidate:"required" hmsg:"template.set.validation.name-required" hform:"label=template.set.name,cols=6"`
+	Version     string    `hvalidate:"required,semVer" hmsg:"required=template.set.validation.version-required,semVer=template.set.validation.version-invalid" hform:"label=template.set.version,cols=6"`
+	Description string    `hform:"label=template.set.description,widget=textarea"`
 }
 
 // PGRepository is the template repository for PostgreSQL. It holds a reference to the database connection pool.
 type PGRepository struct {
+	persistence.Timeout
 	db *pgxpool.Pool
 }
 
 // PGSetRepository is the template set repository for PostgreSQL. It holds a reference to the database connection pool.
 type PGSetRepository struct {
+	persistence.Timeout
 	db *pgxpool.Pool
 }
 
 // Repository is the template repository it contains the necessary methods to interact with the database.
+// All methods are scoped to the tenant of the context (see tenant.ID).
 // Repository is safe for concurrent use by multiple goroutines.
 type Repository interface {
 	persistence.Repository
 
-	// FindByQueryForTypeAndUser finds all templates by a query for a specified template type and user.
-	// The query will be searched for in the template's name, version and in the template set's name.
+	// FindByQueryForTypeAndUser finds all templates selected by the filter for a specified template type and user.
+	// The filter's query will be searched for in the template's name, version and in the template set's name, see SearchFilter.
 	// It will join the template.Set onto template.Template and read it into Set.TemplateSetElem.
 	// The search is limited to the user's templates as templates are private.
 	// It returns persistence.ErrNotFound if no templates could be found and persistence.ErrReadRow for any other error.
-	FindByQueryForTypeAndUser(ctx context.Context, query, templateType string, usr *user.User) ([]*Template, error)
+	FindByQueryForTypeAndUser(ctx context.Context, filter SearchFilter, templateType string, usr *user.User) ([]*Template, error)
+	// FindAccessibleByQuery finds all templates selected by the filter for a specified template type the user has access to.
+	// These are the user's own templates and the templates shared with the user. Templates are shared with all members of
+	// a project through the template sets associated with the project. Found templates have their Origin and SharedIn filled
+	// in addition to the Set.TemplateSetElem, see FindByQueryForTypeAndUser.
+	// It returns persistence.ErrNotFound if no templates could be found and persistence.ErrReadRow for any other error.
+	FindAccessibleByQuery(ctx context.Context, filter SearchFilter, templateType string, usr *user.User) ([]*Template, error)
+	// IsAccessible returns true if the template is the user's own template or is shared with the user, see FindAccessibleByQuery.
+	// It returns persistence.ErrReadRow if the access could not be checked.
+	IsAccessible(ctx context.Context, templateID uuid.UUID, userID uuid.UUID) (bool, error)
 	// FindByID finds a template by its id.
 	// It returns persistence.ErrNotFound if the template could not be found and persistence.ErrReadRow for any other error.
 	FindByID(ctx context.Context, id uuid.UUID) (*Template, error)
@@ -142,6 +166,7 @@ type Repository interface {
 }
 
 // SetRepository is the template set repository it contains the necessary methods to interact with the database.
+// All methods are scoped to the tenant of the context (see tenant.ID).
 // SetRepository is safe for concurrent use by multiple goroutines.
 // TODO move SetRepository and Repository together to handle template concerns all in one repo.
 type SetRepository interface {
@@ -159,6 +184,15 @@ type SetRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// Modified returns the time of the template's last modification. It is the creation time if the template was never updated.
+func (t *Template) Modified() time.Time {
+	if t.UpdatedAt != nil {
+		return *t.UpdatedAt
+	}
+
+	return t.CreatedAt
+}
+
 // ToUpdate returns a ToUpdate from a Template.
 func (t *Template) ToUpdate() *ToUpdate {
 	return &ToUpdate{
@@ -201,6 +235,15 @@ func (t *Template) NecessaryInfo() (*NecessaryInfo, error) {
 	return info, err
 }
 
+// Modified returns the time of the template set's last modification. It is the creation time if the template set was never updated.
+func (t *Set) Modified() time.Time {
+	if t.UpdatedAt != nil {
+		return *t.UpdatedAt
+	}
+
+	return t.CreatedAt
+}
+
 // ToUpdate returns a SetToUpdate from a Set.
 func (t *Set) ToUpdate() *SetToUpdate {
 	return &SetToUpdate{
@@ -231,81 +274,137 @@ func (r *PGSetRepository) RepositoryName() string {
 	return SetRepositoryName
 }
 
-// FindByQueryForTypeAndUser finds all templates by a query for a specified template type and user.
+// FindByQueryForTypeAndUser finds all templates selected by the filter for a specified template type and user.
 // It returns persistence.ErrNotFound if no templates could be found and persistence.ErrReadRow for any other error.
-func (r *PGRepository) FindByQueryForTypeAndUser(ctx context.Context, query, templateType string, usr *user.User) ([]*Template, error) {
+func (r *PGRepository) FindByQueryForTypeAndUser(ctx context.Context, filter SearchFilter, templateType string, usr *user.User) ([]*Template, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
 	rows, err := r.db.Query(
 		ctx,
-		`SELECT 
-templates.id, templates.template_set, templates.type, templates.name, templates.version, templates.config, templates.created_by, templates.created_at, templates.updated_at,
-template_sets.name, template_sets.version, template_sets.description, template_sets.created_by, template_sets.created_at, template_sets.updated_at
+		`SELECT `+persistence.QualifyColumns("templates", templateColumns)+`, `+persistence.QualifyColumns("template_sets", setColumns)+`
 FROM templates LEFT JOIN template_sets ON templates.template_set = template_sets.id
-WHERE (templates.name ILIKE $1 OR templates.version ILIKE $1 OR template_sets.name ILIKE $1) AND templates.type = $2 AND templates.created_by = $3`,
-		"%"+query+"%",
+LEFT JOIN template_favorites f ON f.template_id = templates.id AND f.user_id = $3 AND f.tenant_id = $4
+WHERE (templates.name ILIKE $1 OR templates.version ILIKE $1 OR template_sets.name ILIKE $1) AND templates.type = $2 AND templates.created_by = $3 AND templates.tenant_id = $4
+AND ($5::UUID IS NULL OR templates.template_set = $5)
+AND ($6 = '' OR templates.version LIKE $6 || '%')
+AND ($7::TIMESTAMPTZ IS NULL OR COALESCE(templates.updated_at, templates.created_at) >= $7)
+AND ($8::TIMESTAMPTZ IS NULL OR COALESCE(templates.updated_at, templates.created_at) < $8)
+ORDER BY `+filter.Sort.orderBy(),
+		"%"+persistence.EscapeLike(filter.Query)+"%",
 		templateType,
 		usr.ID,
+		tenant.ID(ctx),
+		nilIfZero(filter.TemplateSet),
+		persistence.EscapeLike(filter.Version),
+		nilIfZero(filter.UpdatedSince),
+		nilIfZero(filter.UpdatedBefore),
 	)
-	if err != nil {
-		return nil, persistence.PGReadErr(err)
-	}
 
-	var templates []*Template
-	for rows.Next() {
-		t := &Template{TemplateSetElem: &Set{}}
-		err := rows.Scan(
-			&t.ID, &t.TemplateSet, &t.Type, &t.Name, &t.Version, &t.Config, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
-			&t.TemplateSetElem.Name, &t.TemplateSetElem.Version, &t.TemplateSetElem.Description, &t.TemplateSetElem.CreatedBy, &t.TemplateSetElem.CreatedAt, &t.TemplateSetElem.UpdatedAt,
-		)
-		if err != nil {
-			return nil, persistence.PGReadErr(err)
-		}
-
-		templates = append(templates, t)
+	return persistence.PGCollectRows(rows, err, scanTemplateWithSet)
+}
+
+// FindAccessibleByQuery finds all templates selected by the filter for a specified template type the user has access to.
+// These are the user's own templates and the templates shared with the user. Templates are shared with all members of
+// a project through the template sets associated with the project. Found templates have their Origin and SharedIn filled
+// in addition to the Set.TemplateSetElem, see FindByQueryForTypeAndUser.
+// It returns persistence.ErrNotFound if no templates could be found and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindAccessibleByQuery(ctx context.Context, filter SearchFilter, templateType string, usr *user.User) ([]*Template, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	// a template set may be associated with several of the user's projects, the first project by name is shown
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT `+persistence.QualifyColumns("templates", templateColumns)+`, `+persistence.QualifyColumns("template_sets", setColumns)+`,
+CASE WHEN templates.created_by = $3 THEN NULL ELSE shared.name END
+FROM templates LEFT JOIN template_sets ON templates.template_set = template_sets.id
+LEFT JOIN LATERAL (
+	SELECT min(projects.name) AS name FROM project_template_sets
+	JOIN projects ON projects.id = project_template_sets.project_id
+	JOIN project_members ON project_members.project_id = projects.id
+	WHERE project_template_sets.template_set = templates.template_set AND project_members.user_id = $3 AND projects.tenant_id = $4
+) shared ON TRUE
+LEFT JOIN template_favorites f ON f.template_id = templates.id AND f.user_id = $3 AND f.tenant_id = $4
+WHERE (templates.name ILIKE $1 OR templates.version ILIKE $1 OR template_sets.name ILIKE $1) AND templates.type = $2 AND templates.tenant_id = $4
+AND (templates.created_by = $3 OR shared.name IS NOT NULL)
+AND ($5::UUID IS NULL OR templates.template_set = $5)
+AND ($6 = '' OR templates.version LIKE $6 || '%')
+AND ($7::TIMESTAMPTZ IS NULL OR COALESCE(templates.updated_at, templates.created_at) >= $7)
+AND ($8::TIMESTAMPTZ IS NULL OR COALESCE(templates.updated_at, templates.created_at) < $8)
+ORDER BY `+filter.Sort.orderBy(),
+		"%"+persistence.EscapeLike(filter.Query)+"%",
+		templateType,
+		usr.ID,
+		tenant.ID(ctx),
+		nilIfZero(filter.TemplateSet),
+		persistence.EscapeLike(filter.Version),
+		nilIfZero(filter.UpdatedSince),
+		nilIfZero(filter.UpdatedBefore),
+	)
+
+	return persistence.PGCollectRows(rows, err, scanAccessibleTemplate)
+}
+
+// IsAccessible returns true if the template is the user's own template or is shared with the user, see FindAccessibleByQuery.
+// It returns persistence.ErrReadRow if the access could not be checked.
+func (r *PGRepository) IsAccessible(ctx context.Context, templateID uuid.UUID, userID uuid.UUID) (bool, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	var accessible bool
+	err := r.db.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM templates WHERE id = $1 AND tenant_id = $3 AND (created_by = $2 OR template_set IN (
+			SELECT project_template_sets.template_set FROM project_template_sets
+			JOIN projects ON projects.id = project_template_sets.project_id
+			JOIN project_members ON project_members.project_id = projects.id
+			WHERE project_members.user_id = $2 AND projects.tenant_id = $3
+		)))`,
+		templateID, userID, tenant.ID(ctx),
+	).Scan(&accessible)
+	if err != nil {
+		return false, persistence.PGReadErr(err)
 	}
 
-	return templates, nil
+	return accessible, nil
 }
 
 // FindByID finds a template by its id.
 // It returns persistence.ErrNotFound if the template could not be found and persistence.ErrReadRow for any other error.
 func (r *PGRepository) FindByID(ctx context.Context, id uuid.UUID) (*Template, error) {
-	t := &Template{}
-	err := r.db.QueryRow(ctx, "SELECT id, template_set, type, name, version, config, created_by, created_at, updated_at FROM templates WHERE id = $1", id).
-		Scan(&t.ID, &t.TemplateSet, &t.Type, &t.Name, &t.Version, &t.Config, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
-
-	if err != nil {
-		return nil, persistence.PGReadErr(err)
-	}
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-	return t, nil
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+templateColumns+" FROM templates WHERE id = $1 AND tenant_id = $2",
+		id, tenant.ID(ctx),
+	), scanTemplate)
 }
 
 // FindByTemplateSetID finds all templates by their template set id.
 // It returns persistence.ErrNotFound if no templates could be found and persistence.ErrReadRow for any other error.
 func (r *PGRepository) FindByTemplateSetID(ctx context.Context, templateSetID uuid.UUID) ([]*Template, error) {
-	rows, err := r.db.Query(ctx, "SELECT id, template_set, type, name, version, config, created_by, created_at, updated_at FROM templates WHERE template_set = $1", templateSetID)
-	if err != nil {
-		return nil, persistence.PGReadErr(err)
-	}
-
-	var templates []*Template
-	for rows.Next() {
-		t := &Template{}
-		err := rows.Scan(&t.ID, &t.TemplateSet, &t.Type, &t.Name, &t.Version, &t.Config, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
-		if err != nil {
-			return nil, persistence.PGReadErr(err)
-		}
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-		templates = append(templates, t)
-	}
+	rows, err := r.db.Query(
+		ctx,
+		"SELECT "+templateColumns+" FROM templates WHERE template_set = $1 AND tenant_id = $2",
+		templateSetID, tenant.ID(ctx),
+	)
 
-	return templates, nil
+	return persistence.PGCollectRows(rows, err, scanTemplate)
 }
 
 // Create creates a new template and returns it. It returns persistence.ErrInsert if the template could not be inserted.
 // It also checks if the template's config JSON contains the necessary information (name and version).
 // If the config JSON does not contain the necessary information, it returns ErrTemplateConfigMissingInfo.
 func (r *PGRepository) Create(ctx context.Context, toCreate *ToCreate) (*Template, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
 	newTemplate := &Template{
 		ID:          uuid.New(),
 		TemplateSet: toCreate.TemplateSet,
@@ -325,11 +424,11 @@ func (r *PGRepository) Create(ctx context.Context, toCreate *ToCreate) (*Templat
 
 	_, err = r.db.Exec(
 		ctx,
-		"INSERT INTO templates (id, template_set, name, version, type, config, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
-		newTemplate.ID, newTemplate.TemplateSet, newTemplate.Name, newTemplate.Version, newTemplate.Type, newTemplate.Config, newTemplate.CreatedBy, newTemplate.CreatedAt,
+		"INSERT INTO templates (id, template_set, name, version, type, config, created_by, created_at, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		newTemplate.ID, newTemplate.TemplateSet, newTemplate.Name, newTemplate.Version, newTemplate.Type, newTemplate.Config, newTemplate.CreatedBy, newTemplate.CreatedAt, tenant.ID(ctx),
 	)
 	if err != nil {
-		return nil, errors.Join(persistence.ErrInsert, err)
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
 	}
 
 	return newTemplate, nil
@@ -339,36 +438,24 @@ func (r *PGRepository) Create(ctx context.Context, toCreate *ToCreate) (*Templat
 // It also checks if the template's config JSON contains the necessary information (name and version).
 // If the config JSON does not contain the necessary information, it returns ErrTemplateConfigMissingInfo.
 func (r *PGRepository) Update(ctx context.Context, toUpdate *ToUpdate) (*Template, error) {
-	template := &Template{
-		ID:     toUpdate.ID,
-		Config: toUpdate.Config,
-	}
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-	tmplInfo, err := template.NecessaryInfo()
+	tmplInfo, err := (&Template{Config: toUpdate.Config}).NecessaryInfo()
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.db.QueryRow(
+	template, err := scanTemplate(r.db.QueryRow(
 		ctx,
 		`UPDATE templates
 	 	SET template_set = $1, type = $2, name = $3, version = $4, config = $5, updated_at = NOW()
-	 	WHERE id = $6
-	 	RETURNING template_set, type, name, version, config, created_by, created_at, updated_at`,
-		toUpdate.TemplateSet, toUpdate.Type, tmplInfo.Name, tmplInfo.Version, toUpdate.Config, toUpdate.ID,
-	).Scan(
-		&template.TemplateSet,
-		&template.Type,
-		&template.Name,
-		&template.Version,
-		&template.Config,
-		&template.CreatedBy,
-		&template.CreatedAt,
-		&template.UpdatedAt,
-	)
-
+	 	WHERE id = $6 AND tenant_id = $7
+	 	RETURNING `+templateColumns,
+		toUpdate.TemplateSet, toUpdate.Type, tmplInfo.Name, tmplInfo.Version, toUpdate.Config, toUpdate.ID, tenant.ID(ctx),
+	))
 	if err != nil {
-		return nil, errors.Join(persistence.ErrUpdate, err)
+		return nil, errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
 	}
 
 	return template, nil
@@ -378,21 +465,20 @@ func (r *PGRepository) Update(ctx context.Context, toUpdate *ToUpdate) (*Templat
 // It returns persistence.ErrInsert if the template could not be inserted.
 // The new template will also have a new UUID but the same config.
 func (r *PGRepository) CopyInto(ctx context.Context, templateID uuid.UUID, templateSetID uuid.UUID, createdBy uuid.UUID) (*Template, error) {
-	t := &Template{ID: uuid.New()}
-	err := r.db.QueryRow(
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	t, err := scanTemplate(r.db.QueryRow(
 		ctx,
-		`INSERT INTO templates (id, template_set, type, name, version, config, created_by, created_at)
-		SELECT $1, $2, type, name, version, config, $3, NOW()
+		`INSERT INTO templates (id, template_set, type, name, version, config, created_by, created_at, tenant_id)
+		SELECT $1, $2, type, name, version, config, $3, NOW(), tenant_id
 		FROM templates
-		WHERE id = $4
-		RETURNING id, template_set, type, name, version, config, created_by, created_at, updated_at`,
-		t.ID, templateSetID, createdBy, templateID,
-	).Scan(
-		&t.ID, &t.TemplateSet, &t.Type, &t.Name, &t.Version, &t.Config, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
-	)
-
+		WHERE id = $4 AND tenant_id = $5
+		RETURNING `+templateColumns,
+		uuid.New(), templateSetID, createdBy, templateID, tenant.ID(ctx),
+	))
 	if err != nil {
-		return nil, errors.Join(persistence.ErrInsert, err)
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
 	}
 
 	return t, nil
@@ -401,9 +487,12 @@ func (r *PGRepository) CopyInto(ctx context.Context, templateID uuid.UUID, templ
 // Delete deletes an existing template by its id.
 // It returns persistence.ErrDelete if the template could not be deleted.
 func (r *PGRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.Exec(ctx, "DELETE FROM templates WHERE id = $1", id)
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM templates WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
 	if err != nil {
-		return errors.Join(persistence.ErrDelete, err)
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
 	}
 
 	return nil
@@ -412,41 +501,36 @@ func (r *PGRepository) Delete(ctx context.Context, id uuid.UUID) error {
 // FindByID finds a template set by its id.
 // It returns persistence.ErrNotFound if the template set could not be found and persistence.ErrReadRow for any other error.
 func (r *PGSetRepository) FindByID(ctx context.Context, id uuid.UUID) (*Set, error) {
-	t := &Set{}
-	err := r.db.QueryRow(ctx, "SELECT id, name, version, description, created_by, created_at, updated_at FROM template_sets WHERE id = $1", id).
-		Scan(&t.ID, &t.Name, &t.Version, &t.Description, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
-
-	if err != nil {
-		return nil, persistence.PGReadErr(err)
-	}
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-	return t, nil
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+setColumns+" FROM template_sets WHERE id = $1 AND tenant_id = $2",
+		id, tenant.ID(ctx),
+	), scanSet)
 }
 
 // FindByCreatedBy finds all template sets for a user.
 // It returns persistence.ErrNotFound if no template sets could be found and persistence.ErrReadRow for any other error.
 func (r *PGSetRepository) FindByCreatedBy(ctx context.Context, userID uuid.UUID) ([]*Set, error) {
-	rows, err := r.db.Query(ctx, "SELECT id, name, version, description, created_by, created_at, updated_at FROM template_sets WHERE created_by = $1", userID)
-	if err != nil {
-		return nil, persistence.PGReadErr(err)
-	}
-
-	var templates []*Set
-	for rows.Next() {
-		t := &Set{}
-		err := rows.Scan(&t.ID, &t.Name, &t.Version, &t.Description, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
-		if err != nil {
-			return nil, persistence.PGReadErr(err)
-		}
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-		templates = append(templates, t)
-	}
+	rows, err := r.db.Query(
+		ctx,
+		"SELECT "+setColumns+" FROM template_sets WHERE created_by = $1 AND tenant_id = $2",
+		userID, tenant.ID(ctx),
+	)
 
-	return templates, nil
+	return persistence.PGCollectRows(rows, err, scanSet)
 }
 
 // Create creates a new template set and returns it. It returns persistence.ErrInsert if the template set could not be inserted.
 func (r *PGSetRepository) Create(ctx context.Context, toCreate *SetToCreate) (*Set, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
 	newTemplateSet := &Set{
 		ID:          uuid.New(),
 		Name:        toCreate.Name,
@@ -458,16 +542,17 @@ func (r *PGSetRepository) Create(ctx context.Context, toCreate *SetToCreate) (*S
 
 	_, err := r.db.Exec(
 		ctx,
-		"INSERT INTO template_sets (id, name, version, description, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		"INSERT INTO template_sets (id, name, version, description, created_by, created_at, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7)",
 		newTemplateSet.ID,
 		newTemplateSet.Name,
 		newTemplateSet.Version,
 		newTemplateSet.Description,
 		newTemplateSet.CreatedBy,
 		newTemplateSet.CreatedAt,
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		return nil, errors.Join(persistence.ErrInsert, err)
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
 	}
 
 	return newTemplateSet, nil
@@ -475,28 +560,19 @@ func (r *PGSetRepository) Create(ctx context.Context, toCreate *SetToCreate) (*S
 
 // Update updates an existing template set and returns it. It returns persistence.ErrUpdate if the template set could not be updated.
 func (r *PGSetRepository) Update(ctx context.Context, toUpdate *SetToUpdate) (*Set, error) {
-	templateSet := &Set{
-		ID: toUpdate.ID,
-	}
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-	err := r.db.QueryRow(
+	templateSet, err := scanSet(r.db.QueryRow(
 		ctx,
 		`UPDATE template_sets
 	 	SET name = $1, version = $2, description = $3, updated_at = NOW()
-	 	WHERE id = $4
-	 	RETURNING name, version, description, created_by, created_at, updated_at`,
-		toUpdate.Name, toUpdate.Version, toUpdate.Description, toUpdate.ID,
-	).Scan(
-		&templateSet.Name,
-		&templateSet.Version,
-		&templateSet.Description,
-		&templateSet.CreatedBy,
-		&templateSet.CreatedAt,
-		&templateSet.UpdatedAt,
-	)
-
+	 	WHERE id = $4 AND tenant_id = $5
+	 	RETURNING `+setColumns,
+		toUpdate.Name, toUpdate.Version, toUpdate.Description, toUpdate.ID, tenant.ID(ctx),
+	))
 	if err != nil {
-		return nil, errors.Join(persistence.ErrUpdate, err)
+		return nil, errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
 	}
 
 	return templateSet, nil
@@ -504,10 +580,61 @@ func (r *PGSetRepository) Update(ctx context.Context, toUpdate *SetToUpdate) (*S
 
 // Delete deletes an existing template set by its id. It returns persistence.ErrDelete if the template set could not be deleted.
 func (r *PGSetRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.Exec(ctx, "DELETE FROM template_sets WHERE id = $1", id)
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM template_sets WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
 	if err != nil {
-		return errors.Join(persistence.ErrDelete, err)
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
 	}
 
 	return nil
 }
+
+// scanTemplate scans a row containing the templateColumns into a new Template.
+func scanTemplate(row pgx.Row) (*Template, error) {
+	t := &Template{}
+	err := row.Scan(&t.ID, &t.TemplateSet, &t.Type, &t.Name, &t.Version, &t.Config, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
+
+	return t, err
+}
+
+// scanTemplateWithSet scans a row containing the templateColumns followed by the setColumns into a new Template
+// with the template set joined onto the template (Template.TemplateSetElem).
+func scanTemplateWithSet(row pgx.Row) (*Template, error) {
+	t := &Template{TemplateSetElem: &Set{}}
+	s := t.TemplateSetElem
+	err := row.Scan(
+		&t.ID, &t.TemplateSet, &t.Type, &t.Name, &t.Version, &t.Config, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
+		&s.ID, &s.Name, &s.Version, &s.Description, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
+	)
+
+	return t, err
+}
+
+// scanAccessibleTemplate scans a row like scanTemplateWithSet followed by the name of the project the template is shared in.
+// The name is NULL for the user's own templates.
+func scanAccessibleTemplate(row pgx.Row) (*Template, error) {
+	t := &Template{TemplateSetElem: &Set{}, Origin: OriginOwn}
+	s := t.TemplateSetElem
+	var sharedIn *string
+	err := row.Scan(
+		&t.ID, &t.TemplateSet, &t.Type, &t.Name, &t.Version, &t.Config, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
+		&s.ID, &s.Name, &s.Version, &s.Description, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
+		&sharedIn,
+	)
+	if sharedIn != nil {
+		t.Origin = OriginProject
+		t.SharedIn = *sharedIn
+	}
+
+	return t, err
+}
+
+// scanSet scans a row containing the setColumns into a new Set.
+func scanSet(row pgx.Row) (*Set, error) {
+	s := &Set{}
+	err := row.Scan(&s.ID, &s.Name, &s.Version, &s.Description, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt)
+
+	return s, err
+}
@@ -0,0 +1,151 @@
+package template
+
+import (
+	"encoding/json"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"slices"
+	"unicode/utf8"
+)
+
+const (
+	// UIKey is the reserved key of a template's config JSON holding the template's UISettings.
+	UIKey = "ui"
+	// MaxTextareaMinRows is the maximum of UISettings.TextareaMinRows.
+	MaxTextareaMinRows = 20
+	// DefaultParseHotkey is the key that is pressed together with Alt to parse a requirement if no other key is configured.
+	DefaultParseHotkey = "Enter"
+)
+
+var (
+	// ErrInvalidUISettings is returned if the UI settings of a template's config JSON are not an object of the expected shape.
+	ErrInvalidUISettings = validation.Error{Msg: "template.ui.invalid"}
+	// ErrInvalidTextareaMinRows is returned if UISettings.TextareaMinRows is negative or greater than MaxTextareaMinRows.
+	ErrInvalidTextareaMinRows = validation.Error{Msg: "template.ui.invalid-textarea-min-rows"}
+	// ErrInvalidParseHotkey is returned if UISettings.ParseHotkey is neither a single character nor "Enter".
+	ErrInvalidParseHotkey = validation.Error{Msg: "template.ui.invalid-parse-hotkey"}
+	// ErrInvalidFieldOrder is returned if UISettings.FieldOrder contains empty or duplicate rule names.
+	ErrInvalidFieldOrder = validation.Error{Msg: "template.ui.invalid-field-order"}
+)
+
+// UISettings are the settings of the elicitation UI for a template. They are stored in the template's config JSON under the
+// reserved UIKey and can be overridden per user. Empty settings fall back to the defaults of the UI.
+//
+// Example:
+//
+//	"ui": {"defaultVariant": "short", "fieldOrder": ["system", "condition"], "textareaMinRows": 3, "parseHotkey": "s"}
+type UISettings struct {
+	// DefaultVariant is the technical name (key) of the variant that is selected if no variant is selected explicitly.
+	DefaultVariant string `json:"defaultVariant,omitempty"`
+	// FieldOrder lists the technical names of the rules in the order their inputs are displayed. Rules not listed follow
+	// in the order of the variant. The order of the segments of the requirement is not changed.
+	FieldOrder []string `json:"fieldOrder,omitempty"`
+	// TextareaMinRows is the minimum number of rows of textareas (0-MaxTextareaMinRows). Textareas grow with their content.
+	TextareaMinRows int `json:"textareaMinRows,omitempty"`
+	// ParseHotkey is the key that is pressed together with Alt to parse the requirement, DefaultParseHotkey if it is empty.
+	ParseHotkey string `json:"parseHotkey,omitempty"`
+}
+
+// UISettingsFromConfig returns the UI settings of the template's config JSON. Empty settings are returned
+// if the config does not contain the UIKey. ErrInvalidUISettings is returned if the settings can not be read.
+func UISettingsFromConfig(config string) (UISettings, error) {
+	c := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(config), &c); err != nil {
+		return UISettings{}, ErrInvalidUISettings
+	}
+
+	raw, ok := c[UIKey]
+	if !ok || string(raw) == "null" {
+		return UISettings{}, nil
+	}
+
+	settings := UISettings{}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return UISettings{}, ErrInvalidUISettings
+	}
+
+	return settings, nil
+}
+
+// Validate validates the settings independent of a template's type. It does not check that the default variant
+// or the rules of the field order exist, that is up to the module parsing the template.
+func (s UISettings) Validate() []error {
+	var errs []error
+
+	if s.TextareaMinRows < 0 || s.TextareaMinRows > MaxTextareaMinRows {
+		errs = append(errs, ErrInvalidTextareaMinRows)
+	}
+
+	if s.ParseHotkey != "" && s.ParseHotkey != DefaultParseHotkey && utf8.RuneCountInString(s.ParseHotkey) != 1 {
+		errs = append(errs, ErrInvalidParseHotkey)
+	}
+
+	for i, rule := range s.FieldOrder {
+		if rule == "" || slices.Contains(s.FieldOrder[:i], rule) {
+			errs = append(errs, ErrInvalidFieldOrder)
+			break
+		}
+	}
+
+	return errs
+}
+
+// Merge returns the settings overridden by all non-empty settings of the override, e.g. a user's settings for the template.
+func (s UISettings) Merge(override UISettings) UISettings {
+	if override.DefaultVariant != "" {
+		s.DefaultVariant = override.DefaultVariant
+	}
+	if len(override.FieldOrder) > 0 {
+		s.FieldOrder = override.FieldOrder
+	}
+	if override.TextareaMinRows > 0 {
+		s.TextareaMinRows = override.TextareaMinRows
+	}
+	if override.ParseHotkey != "" {
+		s.ParseHotkey = override.ParseHotkey
+	}
+
+	return s
+}
+
+// Hotkey returns the key pressed together with Alt to parse the requirement.
+func (s UISettings) Hotkey() string {
+	if s.ParseHotkey == "" {
+		return DefaultParseHotkey
+	}
+
+	return s.ParseHotkey
+}
+
+// Order returns the rules in the order of the field order. Rules of the field order not contained in rules are skipped,
+// rules not listed in the field order follow in their original order.
+func (s UISettings) Order(rules []string) []string {
+	ordered := make([]string, 0, len(rules))
+	for _, rule := range s.FieldOrder {
+		if slices.Contains(rules, rule) {
+			ordered = append(ordered, rule)
+		}
+	}
+
+	for _, rule := range rules {
+		if !slices.Contains(ordered, rule) {
+			ordered = append(ordered, rule)
+		}
+	}
+
+	return ordered
+}
+
+// validateUISettings reads and validates the UI settings of the template's config JSON.
+// Invalid JSON is not reported as the config is validated by the modules parsing it.
+func validateUISettings(config string) []error {
+	if !json.Valid([]byte(config)) {
+		return nil
+	}
+
+	settings, err := UISettingsFromConfig(config)
+	if err != nil {
+		return []error{err}
+	}
+
+	return settings.Validate()
+}
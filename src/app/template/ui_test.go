@@ -0,0 +1,40 @@
+package template
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUISettingsFromConfig(t *testing.T) {
+	settings, err := UISettingsFromConfig(`{"name": "Template", "ui": {"defaultVariant": "short", "fieldOrder": ["b", "a"], "textareaMinRows": 3, "parseHotkey": "s"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, UISettings{DefaultVariant: "short", FieldOrder: []string{"b", "a"}, TextareaMinRows: 3, ParseHotkey: "s"}, settings)
+	assert.Empty(t, settings.Validate())
+
+	settings, err = UISettingsFromConfig(`{"name": "Template"}`)
+	require.NoError(t, err)
+	assert.Equal(t, UISettings{}, settings)
+	assert.Equal(t, DefaultParseHotkey, settings.Hotkey())
+
+	_, err = UISettingsFromConfig(`{"ui": "compact"}`)
+	assert.ErrorIs(t, err, ErrInvalidUISettings)
+}
+
+func TestUISettings_Validate(t *testing.T) {
+	errs := UISettings{FieldOrder: []string{"a", "a"}, TextareaMinRows: 21, ParseHotkey: "ctrl"}.Validate()
+	assert.Equal(t, []error{ErrInvalidTextareaMinRows, ErrInvalidParseHotkey, ErrInvalidFieldOrder}, errs)
+	assert.Empty(t, UISettings{ParseHotkey: "Enter"}.Validate())
+
+	assert.Equal(t, []error{ErrInvalidUISettings}, validateUISettings(`{"ui": []}`))
+	assert.Empty(t, validateUISettings(`{"invalid json`), "invalid JSON is reported by the modules parsing the config")
+}
+
+func TestUISettings_MergeAndOrder(t *testing.T) {
+	template := UISettings{DefaultVariant: "short", FieldOrder: []string{"b"}, TextareaMinRows: 3}
+	merged := template.Merge(UISettings{DefaultVariant: "long", ParseHotkey: "s"})
+	assert.Equal(t, UISettings{DefaultVariant: "long", FieldOrder: []string{"b"}, TextareaMinRows: 3, ParseHotkey: "s"}, merged)
+
+	assert.Equal(t, []string{"c", "a", "b"}, UISettings{FieldOrder: []string{"c", "x"}}.Order([]string{"a", "b", "c"}))
+	assert.Equal(t, []string{"a", "b"}, UISettings{}.Order([]string{"a", "b"}))
+}
@@ -0,0 +1,406 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+const (
+	// ChangeAdded is the kind of changes adding an element to a template config.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is the kind of changes removing an element from a template config.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified is the kind of changes modifying an element of a template config.
+	ChangeModified ChangeKind = "modified"
+)
+
+var (
+	// ErrInvalidVersion is returned if a version is no semantic version, see ParseVersion.
+	ErrInvalidVersion = errors.New("template.upgrade.invalid-version")
+	// versionRegex matches semantic versions with an optional minor and patch version like the semVer validation.
+	versionRegex = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?$`)
+	// keyedElements are the elements of a template config keyed by their technical name. They are diffed and merged by their keys.
+	keyedElements = map[string]bool{"rules": true, "variants": true}
+	// breakingFields are the fields of keyed elements that may invalidate requirements if they are changed.
+	breakingFields = map[string][]string{"rules": {"type", "value"}, "variants": {"rules"}}
+)
+
+// Version is a semantic version of a template or template set. Missing minor and patch versions are 0.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ChangeKind is the kind of change of an element of a template config.
+type ChangeKind string
+
+// Change is a change of an element of a template config by an upgrade.
+type Change struct {
+	Kind ChangeKind
+	// Element is the changed part of the config: rules, variants or any other field of the config (e.g. description).
+	Element string
+	// Key is the key of the changed rule or variant. It is empty for other fields.
+	Key string
+	// Breaking changes may invalidate existing requirements, e.g. removed rules and variants or changed rule types and values.
+	Breaking bool
+	// Conflict is true if the element was also modified locally. The source's element replaces the local modification.
+	Conflict bool
+}
+
+// TemplateUpgrade is the planned upgrade of a template to the template of the source, see PlanUpgrade.
+type TemplateUpgrade struct {
+	Type string
+	Name string
+	// Local is the upgraded template. It is nil if the template is new in the source and created by the upgrade.
+	Local *Template
+	// From is the version of the local template, To the version of the source's template.
+	From string
+	To   string
+	// Config is the source's config merged with the local modifications.
+	Config  string
+	Changes []Change
+	// Preserved are the locally modified elements that are kept by the upgrade, e.g. rules.role.
+	Preserved []string
+}
+
+// UpgradePlan is the planned upgrade of a template set to a revision of its source, see PlanUpgrade.
+type UpgradePlan struct {
+	Revision  string
+	Templates []*TemplateUpgrade
+	// Removed are the local templates that are no longer contained in the source. They are kept by the upgrade.
+	Removed []*Template
+	// Base are the source's configs of the revision. They replace the SetSource.Base once the plan is applied.
+	Base map[string]string
+}
+
+// ParseVersion parses a semantic version (e.g. 1.2.3 or 1.2). It returns ErrInvalidVersion if the version is no semantic version.
+func ParseVersion(version string) (Version, error) {
+	matches := versionRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return Version{}, ErrInvalidVersion
+	}
+
+	parts := make([]int, 3)
+	for i, match := range matches[1:] {
+		if match == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(match)
+		if err != nil {
+			return Version{}, ErrInvalidVersion
+		}
+		parts[i] = n
+	}
+
+	return Version{Major: parts[0], Minor: parts[1], Patch: parts[2]}, nil
+}
+
+// Compare returns -1 if the version is lower than the other version, 1 if it is greater and 0 if they are equal.
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Breaking returns true if upgrading from the version to the other version is breaking by semantic versioning:
+// the major version increases or, for versions below 1.0.0, the minor version increases.
+func (v Version) Breaking(to Version) bool {
+	if v.Major == 0 && to.Major == 0 {
+		return to.Minor > v.Minor
+	}
+
+	return to.Major > v.Major
+}
+
+// String returns the version formatted as major.minor.patch.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// SourceKey is the key matching a template of a source to the local template: its type and name.
+func SourceKey(templateType, name string) string {
+	return templateType + "/" + name
+}
+
+// Newer returns true if the source's version is newer than the local version. It is true for templates new in the source.
+// Versions that are no semantic versions are never newer.
+func (u *TemplateUpgrade) Newer() bool {
+	if u.Local == nil {
+		return true
+	}
+
+	from, err := ParseVersion(u.From)
+	if err != nil {
+		return false
+	}
+	to, err := ParseVersion(u.To)
+	if err != nil {
+		return false
+	}
+
+	return to.Compare(from) > 0
+}
+
+// Breaking returns true if the upgrade increases the major version (see Version.Breaking) or contains any breaking change.
+func (u *TemplateUpgrade) Breaking() bool {
+	if u.Local == nil {
+		return false
+	}
+
+	from, fromErr := ParseVersion(u.From)
+	to, toErr := ParseVersion(u.To)
+	if fromErr == nil && toErr == nil && from.Breaking(to) {
+		return true
+	}
+
+	for _, change := range u.Changes {
+		if change.Breaking {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Breaking returns true if any template upgrade of the plan is breaking and must be reviewed by the user.
+func (p *UpgradePlan) Breaking() bool {
+	for _, u := range p.Templates {
+		if u.Breaking() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Empty returns true if the plan changes no template.
+func (p *UpgradePlan) Empty() bool {
+	return len(p.Templates) == 0
+}
+
+// PlanUpgrade plans the upgrade of the local templates to the source's templates of the revision. Templates are matched by their SourceKey.
+// The configs are merged three-way with the base configs (see SetSource.Base) as common ancestor: elements only modified locally are preserved,
+// elements only modified by the source are upgraded and elements modified by both are replaced by the source's element (conflict).
+// Rules and variants are merged by their keys, any other field of the config as a whole. Without a base config all local modifications are replaced.
+// Templates whose merged config equals the local config are not part of the plan.
+func PlanUpgrade(base map[string]string, local []*Template, remote []*ToCreate, revision string) (*UpgradePlan, error) {
+	plan := &UpgradePlan{Revision: revision, Base: make(map[string]string, len(remote))}
+
+	byKey := make(map[string]*Template, len(local))
+	for _, tmpl := range local {
+		byKey[SourceKey(tmpl.Type, tmpl.Name)] = tmpl
+	}
+
+	for _, tmpl := range remote {
+		info, err := (&Template{Config: tmpl.Config}).NecessaryInfo()
+		if err != nil {
+			return nil, err
+		}
+
+		key := SourceKey(tmpl.Type, info.Name)
+		plan.Base[key] = tmpl.Config
+
+		match, ok := byKey[key]
+		delete(byKey, key)
+		if !ok {
+			plan.Templates = append(plan.Templates, &TemplateUpgrade{Type: tmpl.Type, Name: info.Name, To: info.Version, Config: tmpl.Config})
+			continue
+		}
+
+		baseConfig, ok := base[key]
+		if !ok {
+			baseConfig = match.Config
+		}
+
+		upgrade, err := mergeConfig(baseConfig, match.Config, tmpl.Config)
+		if err != nil {
+			return nil, fmt.Errorf("merging template %s failed: %w", key, err)
+		}
+		if len(upgrade.Changes) == 0 && canonical([]byte(upgrade.Config)) == canonical([]byte(match.Config)) {
+			continue
+		}
+
+		upgrade.Type, upgrade.Name, upgrade.Local = tmpl.Type, info.Name, match
+		upgrade.From, upgrade.To = match.Version, info.Version
+		plan.Templates = append(plan.Templates, upgrade)
+	}
+
+	for _, tmpl := range byKey {
+		plan.Removed = append(plan.Removed, tmpl)
+	}
+
+	sort.Slice(plan.Templates, func(i, j int) bool {
+		return SourceKey(plan.Templates[i].Type, plan.Templates[i].Name) < SourceKey(plan.Templates[j].Type, plan.Templates[j].Name)
+	})
+	sort.Slice(plan.Removed, func(i, j int) bool {
+		return SourceKey(plan.Removed[i].Type, plan.Removed[i].Name) < SourceKey(plan.Removed[j].Type, plan.Removed[j].Name)
+	})
+
+	return plan, nil
+}
+
+// mergeConfig merges the local and the remote config three-way with the base config as common ancestor, see PlanUpgrade.
+// The returned TemplateUpgrade only contains the merged config, its changes and the preserved elements.
+func mergeConfig(base, local, remote string) (*TemplateUpgrade, error) {
+	b, l, r, err := decodeObjects([]byte(base), []byte(local), []byte(remote))
+	if err != nil {
+		return nil, err
+	}
+
+	upgrade := &TemplateUpgrade{}
+	merged := make(map[string]json.RawMessage, len(r))
+	for _, field := range unionKeys(b, l, r) {
+		if !keyedElements[field] {
+			value, change, preserved := mergeElement(field, "", b[field], l[field], r[field])
+			upgrade.addResult(change, preserved, field)
+			if value != nil {
+				merged[field] = value
+			}
+
+			continue
+		}
+
+		be, le, re, err := decodeObjects(b[field], l[field], r[field])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field, err)
+		}
+
+		elements := make(map[string]json.RawMessage, len(re))
+		for _, key := range unionKeys(be, le, re) {
+			value, change, preserved := mergeElement(field, key, be[key], le[key], re[key])
+			upgrade.addResult(change, preserved, field+"."+key)
+			if value != nil {
+				elements[key] = value
+			}
+		}
+
+		raw, err := json.Marshal(elements)
+		if err != nil {
+			return nil, err
+		}
+		merged[field] = raw
+	}
+
+	config, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	upgrade.Config = string(config)
+
+	return upgrade, nil
+}
+
+// mergeElement merges an element three-way. Missing elements are nil. It returns the merged element (nil if it is removed),
+// the change applied by the source (nil if the local element is kept) and whether a local modification is preserved.
+func mergeElement(element, key string, base, local, remote json.RawMessage) (json.RawMessage, *Change, bool) {
+	b, l, r := canonical(base), canonical(local), canonical(remote)
+
+	switch {
+	case l == r:
+		return local, nil, false
+	case r == b:
+		return local, nil, true
+	}
+
+	change := &Change{Kind: ChangeModified, Element: element, Key: key, Conflict: l != b}
+	switch {
+	case local == nil:
+		change.Kind = ChangeAdded
+	case remote == nil:
+		change.Kind = ChangeRemoved
+		change.Breaking = key != ""
+	default:
+		change.Breaking = breaking(element, local, remote)
+	}
+
+	return remote, change, false
+}
+
+// breaking returns true if any of the breakingFields of the element differ between the local and the remote element.
+// Rules also break existing requirements if they are no longer optional.
+func breaking(element string, local, remote json.RawMessage) bool {
+	var l, r map[string]json.RawMessage
+	if json.Unmarshal(local, &l) != nil || json.Unmarshal(remote, &r) != nil {
+		return false
+	}
+
+	for _, field := range breakingFields[element] {
+		if canonical(l[field]) != canonical(r[field]) {
+			return true
+		}
+	}
+
+	return element == "rules" && canonical(l["optional"]) == "true" && canonical(r["optional"]) != "true"
+}
+
+// addResult adds the change or the preserved element to the upgrade.
+func (u *TemplateUpgrade) addResult(change *Change, preserved bool, element string) {
+	if change != nil {
+		u.Changes = append(u.Changes, *change)
+	}
+	if preserved {
+		u.Preserved = append(u.Preserved, element)
+	}
+}
+
+// canonical returns the JSON value in its canonical form (compact with sorted keys) to compare values regardless of their formatting.
+// It returns an empty string for missing values.
+func canonical(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw)
+	}
+
+	normalized, _ := json.Marshal(value)
+
+	return string(normalized)
+}
+
+// unionKeys returns the sorted union of the keys of the maps.
+func unionKeys(maps ...map[string]json.RawMessage) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// decodeObjects decodes the base, local and remote JSON objects. Missing (nil) objects are decoded as nil maps.
+func decodeObjects(base, local, remote []byte) (map[string]json.RawMessage, map[string]json.RawMessage, map[string]json.RawMessage, error) {
+	objects := make([]map[string]json.RawMessage, 3)
+	for i, raw := range [][]byte{base, local, remote} {
+		if raw == nil {
+			continue
+		}
+		if err := json.Unmarshal(raw, &objects[i]); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return objects[0], objects[1], objects[2], nil
+}
@@ -0,0 +1,102 @@
+package template
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, Version{Major: 1, Minor: 2, Patch: 3}, v)
+
+	v, err = ParseVersion("2")
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", v.String())
+
+	for _, invalid := range []string{"", "v1.0.0", "1.0.0-beta", "1.0.0.0"} {
+		_, err = ParseVersion(invalid)
+		assert.ErrorIs(t, err, ErrInvalidVersion, invalid)
+	}
+
+	assert.Equal(t, -1, Version{Major: 1, Minor: 9}.Compare(Version{Major: 1, Minor: 10}), "versions are compared numerically")
+	assert.Equal(t, 0, Version{Major: 1}.Compare(Version{Major: 1}))
+	assert.Equal(t, 1, Version{Major: 2}.Compare(Version{Major: 1, Minor: 9, Patch: 9}))
+
+	assert.True(t, Version{Major: 1, Minor: 4}.Breaking(Version{Major: 2}))
+	assert.False(t, Version{Major: 1}.Breaking(Version{Major: 1, Minor: 5}))
+	assert.True(t, Version{Minor: 1}.Breaking(Version{Minor: 2}), "minor versions below 1.0.0 are breaking")
+	assert.False(t, Version{Minor: 1}.Breaking(Version{Minor: 1, Patch: 1}))
+}
+
+func TestPlanUpgrade(t *testing.T) {
+	base := `{"type": "ebt", "name": "A", "version": "1.0.0", "description": "base",
+		"rules": {"role": {"name": "Role", "type": "placeholder"}, "verb": {"name": "Verb", "type": "equalsAny", "value": ["shall"]}, "old": {"name": "Old", "type": "placeholder"}},
+		"variants": {"default": {"name": "Default", "rules": ["role", "verb"]}}}`
+	local := &Template{Type: "ebt", Name: "A", Version: "1.0.0", Config: `{"type": "ebt", "name": "A", "version": "1.0.0", "description": "local",
+		"rules": {"role": {"name": "Actor", "type": "placeholder"}, "verb": {"name": "Modal verb", "type": "equalsAny", "value": ["shall"]}, "old": {"name": "Old", "type": "placeholder"}},
+		"variants": {"default": {"name": "Default", "rules": ["role", "verb"]}}}`}
+	remote := &ToCreate{Type: "ebt", Config: `{"type": "ebt", "name": "A", "version": "2.0.0", "description": "base",
+		"rules": {"role": {"name": "Role", "type": "placeholder", "hint": "Who?"}, "verb": {"name": "Verb", "type": "equalsAny", "value": ["shall", "should"]}},
+		"variants": {"default": {"name": "Default", "rules": ["role", "verb"]}, "short": {"name": "Short", "rules": ["verb"]}}}`}
+	added := &ToCreate{Type: "ebt", Config: `{"type": "ebt", "name": "B", "version": "1.0.0", "variants": {}}`}
+	unchanged := &Template{Type: "ebt", Name: "C", Version: "1.0.0", Config: `{"type": "ebt", "name": "C", "version": "1.0.0"}`}
+	removed := &Template{Type: "ebt", Name: "D", Version: "1.0.0", Config: `{"type": "ebt", "name": "D", "version": "1.0.0"}`}
+
+	plan, err := PlanUpgrade(
+		map[string]string{"ebt/A": base, "ebt/C": unchanged.Config},
+		[]*Template{local, unchanged, removed},
+		[]*ToCreate{remote, added, {Type: "ebt", Config: "{\n\"name\": \"C\", \"version\": \"1.0.0\", \"type\": \"ebt\"}"}},
+		"rev",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "rev", plan.Revision)
+	assert.Equal(t, []*Template{removed}, plan.Removed)
+	assert.Equal(t, map[string]string{"ebt/A": remote.Config, "ebt/B": added.Config, "ebt/C": "{\n\"name\": \"C\", \"version\": \"1.0.0\", \"type\": \"ebt\"}"}, plan.Base)
+	require.Len(t, plan.Templates, 2, "templates without changes are not planned")
+
+	upgrade := plan.Templates[0]
+	assert.Equal(t, local, upgrade.Local)
+	assert.Equal(t, "1.0.0", upgrade.From)
+	assert.Equal(t, "2.0.0", upgrade.To)
+	assert.True(t, upgrade.Newer())
+	assert.True(t, upgrade.Breaking())
+	assert.True(t, plan.Breaking())
+	assert.Equal(t, []Change{
+		{Kind: ChangeRemoved, Element: "rules", Key: "old", Breaking: true},
+		{Kind: ChangeModified, Element: "rules", Key: "role", Conflict: true},
+		{Kind: ChangeModified, Element: "rules", Key: "verb", Breaking: true, Conflict: true},
+		{Kind: ChangeAdded, Element: "variants", Key: "short"},
+		{Kind: ChangeModified, Element: "version"},
+	}, upgrade.Changes)
+	assert.Equal(t, []string{"description"}, upgrade.Preserved)
+
+	merged := &Template{Config: upgrade.Config}
+	info, err := merged.NecessaryInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", info.Version)
+	assert.Contains(t, upgrade.Config, `"description": "local"`)
+	assert.Contains(t, upgrade.Config, `"hint": "Who?"`)
+	assert.NotContains(t, upgrade.Config, `"old"`)
+
+	assert.Nil(t, plan.Templates[1].Local)
+	assert.Equal(t, "B", plan.Templates[1].Name)
+	assert.Equal(t, added.Config, plan.Templates[1].Config)
+	assert.False(t, plan.Templates[1].Breaking())
+}
+
+func TestPlanUpgradeWithoutBase(t *testing.T) {
+	local := &Template{Type: "ebt", Name: "A", Version: "1.0.0", Config: `{"type": "ebt", "name": "A", "version": "1.0.0", "description": "local"}`}
+	remote := &ToCreate{Type: "ebt", Config: `{"type": "ebt", "name": "A", "version": "1.1.0", "description": "remote"}`}
+
+	plan, err := PlanUpgrade(nil, []*Template{local}, []*ToCreate{remote}, "rev")
+	require.NoError(t, err)
+	require.Len(t, plan.Templates, 1)
+	assert.False(t, plan.Breaking())
+	assert.Empty(t, plan.Templates[0].Preserved, "local modifications are unknown without a base")
+	assert.Contains(t, plan.Templates[0].Config, `"description": "remote"`)
+	for _, change := range plan.Templates[0].Changes {
+		assert.False(t, change.Conflict)
+	}
+}
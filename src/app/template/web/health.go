@@ -0,0 +1,69 @@
+package web
+
+import (
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+)
+
+// templateHealthData is passed to the template health page listing the rules of the template failing most frequently.
+type templateHealthData struct {
+	Template *template.Template
+	Health   *template.Health
+}
+
+// registerHealthController registers the controllers of the template health page (see template.Health).
+// Only the template's author may view and reset the template's health:
+//   - GET /template/{id}/health Renders the template health page.
+//   - DELETE /template/{id}/health Resets the template's statistics and renders the emptied health.
+func registerHealthController(appCtx *hctx.AppCtx, webCtx *web.Ctx, router web.Router) {
+	router.Get("/template/{id}/health", templateHealthController(appCtx, webCtx).ServeHTTP)
+	router.Delete("/template/{id}/health", templateHealthResetController(appCtx, webCtx).ServeHTTP)
+}
+
+func templateHealthController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		templateRepository := web.MustRepository[template.Repository](io, template.RepositoryName)
+		healthRepository := web.MustRepository[template.HealthRepository](io, template.HealthRepositoryName)
+
+		tmpl, err := TemplateFromParams(io, templateRepository, "id")
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		health, err := healthRepository.FindByTemplate(io.Context(), tmpl.ID)
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		return io.Render(
+			web.NewFormData(&templateHealthData{Template: tmpl, Health: health}, nil),
+			"template.health.page",
+			"template/health-page.go.html",
+			"template/_health.go.html",
+		)
+	})
+}
+
+func templateHealthResetController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		templateRepository := web.MustRepository[template.Repository](io, template.RepositoryName)
+		healthRepository := web.MustRepository[template.HealthRepository](io, template.HealthRepositoryName)
+
+		tmpl, err := TemplateFromParams(io, templateRepository, "id")
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		if err := healthRepository.Reset(io.Context(), tmpl.ID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return io.Render(
+			web.NewFormData(&templateHealthData{Template: tmpl, Health: &template.Health{TemplateID: tmpl.ID}}, []string{"template.health.reset.success"}),
+			"template.health",
+			"template/_health.go.html",
+		)
+	})
+}
@@ -0,0 +1,466 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"time"
+)
+
+// sourceErrs are the errors of importing templates from a source that are displayed to the user.
+var sourceErrs = []error{
+	ErrInvalidImport,
+	template.ErrInvalidSource,
+	template.ErrHostNotAllowed,
+	template.ErrGitDisabled,
+	template.ErrFetchSource,
+	ErrUpgradeOutdated,
+	ErrBreakingNotAccepted,
+}
+
+// SourceImportForm is the form importing a template set from a remote source. The source is a Git repository if Git is checked.
+type SourceImportForm struct {
+	URL         string `hvalidate:"required" hform:"label=template.set.import.source.url"`
+	Git         bool   `hform:"label=template.set.import.source.git,widget=checkbox"`
+	Ref         string `hform:"label=template.set.import.source.ref,cols=6"`
+	Path        string `hform:"label=template.set.import.source.path,cols=6"`
+	Name        string `hvalidate:"required" hform:"label=template.set.name,cols=6"`
+	Version     string `hvalidate:"required,semVer" hform:"label=template.set.version,cols=6"`
+	Description string `hform:"label=template.set.description,widget=textarea"`
+}
+
+// SourceData is the data of the source card of a template set imported from a source.
+type SourceData struct {
+	TemplateSet *template.Set
+	// Source is nil if the template set was not imported from a source.
+	Source *template.SetSource
+	// Checked is true if the source was checked for updates, UpdateAvailable is the result of the check.
+	Checked         bool
+	UpdateAvailable bool
+}
+
+// SyncResult is the result of re-syncing a template set with its source, see SyncFromSource.
+type SyncResult struct {
+	Created   []*template.Template
+	Updated   []*template.Template
+	Unchanged int
+}
+
+// registerImportController registers the controllers importing template sets from remote sources:
+//   - GET /template-set/import/remote Renders the import form.
+//   - POST /template-set/import/remote Imports a template set from a source, see ImportFromSource.
+//   - GET /template-set/{id}/source Renders the source card of a template set. It is empty if the template set was not imported from a source.
+//   - GET /template-set/{id}/source/check Checks the source for updates.
+//   - POST /template-set/{id}/source/sync Re-syncs the template set with its source and reloads the page, see SyncFromSource.
+//   - GET /template-set/{id}/source/upgrade Renders the planned upgrade to the source's current revision for review, see PlanFromSource.
+//   - POST /template-set/{id}/source/upgrade Applies the reviewed upgrade (revision, breaking), see ApplyUpgrade.
+func registerImportController(appCtx *hctx.AppCtx, webCtx *web.Ctx, router web.Router, cfg *template.ImportCfg) {
+	router.Get("/template-set/import/remote", templateSetImportRemotePageController(appCtx, webCtx).ServeHTTP)
+	router.Post("/template-set/import/remote", templateSetImportRemoteController(appCtx, webCtx, cfg).ServeHTTP)
+	router.Get("/template-set/{id}/source", templateSetSourceController(appCtx, webCtx).ServeHTTP)
+	router.Get("/template-set/{id}/source/check", templateSetSourceCheckController(appCtx, webCtx, cfg).ServeHTTP)
+	router.Post("/template-set/{id}/source/sync", templateSetSourceSyncController(appCtx, webCtx, cfg).ServeHTTP)
+	router.Get("/template-set/{id}/source/upgrade", templateSetUpgradePageController(appCtx, webCtx, cfg).ServeHTTP)
+	router.Post("/template-set/{id}/source/upgrade", templateSetUpgradeController(appCtx, webCtx, cfg).ServeHTTP)
+}
+
+// SetSource returns the source the form declares.
+func (f *SourceImportForm) SetSource() *template.SetSource {
+	if !f.Git {
+		return &template.SetSource{Kind: template.SourceURL, URL: f.URL}
+	}
+
+	return &template.SetSource{Kind: template.SourceGit, URL: f.URL, Ref: f.Ref, Path: f.Path}
+}
+
+// ImportFromSource fetches the template configs of the source and imports them as a new template set of the user.
+// All templates are validated by the pipeline before the template set is created (see validTemplatesFromConfigs).
+// The source is saved with the fetched revision and configs to allow checking for updates, re-syncing and upgrading the template set.
+func ImportFromSource(
+	ctx context.Context,
+	source template.Source,
+	setSource *template.SetSource,
+	toCreate *template.SetToCreate,
+	tmplSetRepo template.SetRepository,
+	tmplRepo template.Repository,
+	sourceRepo template.SourceRepository,
+	pipeline *template.ValidationPipeline,
+) (*template.Set, error) {
+	configs, revision, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := validTemplatesFromConfigs(ctx, pipeline, configs)
+	if err != nil {
+		return nil, err
+	}
+
+	tmplSet, err := tmplSetRepo.Create(ctx, toCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tmpl := range templates {
+		tmpl.TemplateSet = tmplSet.ID
+		tmpl.CreatedBy = toCreate.CreatedBy
+
+		if _, err := tmplRepo.Create(ctx, tmpl); err != nil {
+			return nil, err
+		}
+	}
+
+	setSource.TemplateSet = tmplSet.ID
+	setSource.Revision = revision
+	setSource.Base = sourceBase(templates)
+	setSource.SyncedAt = time.Now()
+	if err := sourceRepo.Save(ctx, setSource); err != nil {
+		return nil, err
+	}
+
+	return tmplSet, nil
+}
+
+// SyncFromSource fetches the template configs of the source and re-syncs the template set with them. Templates are matched by their type and name:
+// matched templates are updated if their config changed and new templates are created. Templates that are no longer contained in the source are kept.
+// Nothing is changed if any template is invalid (see validTemplatesFromConfigs). The source is saved with the fetched revision.
+// Local modifications of the templates are replaced, use PlanFromSource and ApplyUpgrade to preserve them.
+func SyncFromSource(
+	ctx context.Context,
+	source template.Source,
+	setSource *template.SetSource,
+	tmplRepo template.Repository,
+	sourceRepo template.SourceRepository,
+	pipeline *template.ValidationPipeline,
+	usrID uuid.UUID,
+) (*SyncResult, error) {
+	configs, revision, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := validTemplatesFromConfigs(ctx, pipeline, configs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := tmplRepo.FindByTemplateSetID(ctx, setSource.TemplateSet)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, err
+	}
+
+	byKey := make(map[string]*template.Template, len(existing))
+	for _, tmpl := range existing {
+		byKey[template.SourceKey(tmpl.Type, tmpl.Name)] = tmpl
+	}
+
+	result := &SyncResult{}
+	for _, tmpl := range templates {
+		info, _ := (&template.Template{Config: tmpl.Config}).NecessaryInfo()
+		match, ok := byKey[template.SourceKey(tmpl.Type, info.Name)]
+
+		switch {
+		case !ok:
+			tmpl.TemplateSet = setSource.TemplateSet
+			tmpl.CreatedBy = usrID
+
+			created, err := tmplRepo.Create(ctx, tmpl)
+			if err != nil {
+				return nil, err
+			}
+
+			result.Created = append(result.Created, created)
+		case match.Config != tmpl.Config:
+			updated, err := tmplRepo.Update(ctx, &template.ToUpdate{ID: match.ID, TemplateSet: match.TemplateSet, Type: tmpl.Type, Config: tmpl.Config})
+			if err != nil {
+				return nil, err
+			}
+
+			result.Updated = append(result.Updated, updated)
+		default:
+			result.Unchanged++
+		}
+	}
+
+	setSource.Revision = revision
+	setSource.Base = sourceBase(templates)
+	setSource.SyncedAt = time.Now()
+	if err := sourceRepo.Save(ctx, setSource); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validTemplatesFromConfigs returns the templates of the configs if all of them contain their type, name and version and are valid
+// according to the pipeline. Otherwise, ErrInvalidImport is returned along with the errors.
+func validTemplatesFromConfigs(ctx context.Context, pipeline *template.ValidationPipeline, configs []string) ([]*template.ToCreate, error) {
+	templates := make([]*template.ToCreate, 0, len(configs))
+	for _, cfg := range configs {
+		tmpl, err := template.ToCreateFromConfig(cfg)
+		if err != nil {
+			return nil, errors.Join(ErrInvalidImport, err)
+		}
+
+		if _, err := (&template.Template{Config: cfg}).NecessaryInfo(); err != nil {
+			return nil, errors.Join(ErrInvalidImport, err)
+		}
+
+		templates = append(templates, tmpl)
+	}
+
+	if err := validateImport(ctx, pipeline, templates); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// validateImport validates the templates to import by the pipeline. It returns ErrInvalidImport along with the validation errors
+// if any template is invalid.
+func validateImport(ctx context.Context, pipeline *template.ValidationPipeline, templates []*template.ToCreate) error {
+	jobs := make([]template.ValidationJob, 0, len(templates))
+	for _, tmpl := range templates {
+		jobs = append(jobs, template.ValidationJob{Config: tmpl.Config, TemplateType: tmpl.Type})
+	}
+
+	results := pipeline.Validate(ctx, jobs)
+	if template.Valid(results) {
+		return nil
+	}
+
+	var errs []error
+	for _, result := range results {
+		errs = append(errs, result.Err)
+		errs = append(errs, result.Errs...)
+	}
+
+	return errors.Join(ErrInvalidImport, errors.Join(errs...))
+}
+
+// sourceBase returns the configs of the templates fetched from a source keyed by their template.SourceKey, see template.SetSource.
+func sourceBase(templates []*template.ToCreate) map[string]string {
+	base := make(map[string]string, len(templates))
+	for _, tmpl := range templates {
+		info, err := (&template.Template{Config: tmpl.Config}).NecessaryInfo()
+		if err != nil {
+			continue
+		}
+
+		base[template.SourceKey(tmpl.Type, info.Name)] = tmpl.Config
+	}
+
+	return base
+}
+
+// fetchErrs are the errors of fetching a source. They are all displayed as template.ErrFetchSource so that users
+// cannot tell unreachable hosts from reachable ones and probe the hosts reachable by the server.
+var fetchErrs = []error{
+	template.ErrFetchSource,
+	template.ErrSourceTooLarge,
+	template.ErrInvalidContent,
+	template.ErrAddressNotAllowed,
+}
+
+// sourceErr returns the error of importing templates from a source that is displayed to the user, see sourceErrs and fetchErrs.
+// It returns nil for internal errors.
+func sourceErr(err error) error {
+	for _, e := range fetchErrs {
+		if errors.Is(err, e) {
+			return template.ErrFetchSource
+		}
+	}
+
+	for _, e := range sourceErrs {
+		if errors.Is(err, e) {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func templateSetImportRemotePageController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		return renderImportRemotePage(io, templateSetImportRemoteForm(&SourceImportForm{}))
+	})
+}
+
+func templateSetImportRemoteController(appCtx *hctx.AppCtx, webCtx *web.Ctx, cfg *template.ImportCfg) http.Handler {
+	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	sourceRepository := util.UnwrapType[template.SourceRepository](appCtx.Repository(template.SourceRepositoryName))
+	pipeline := template.NewValidationPipeline(appCtx.EventManager, appCtx.Logger)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		form := templateSetImportRemoteForm(&SourceImportForm{})
+
+		return web.HandleForm(io, appCtx.Validator, form, func(form *web.Form[*SourceImportForm]) error {
+			return renderImportRemotePage(io, form)
+		}, func(form *web.Form[*SourceImportForm]) error {
+			ctx := io.Context()
+			setSource := form.Form.SetSource()
+
+			source, err := template.NewSource(cfg, setSource)
+			if err != nil {
+				form.ViolationsFromErrors(sourceErr(err))
+				return renderImportRemotePage(io, form)
+			}
+
+			templateSet, err := ImportFromSource(ctx, source, setSource, &template.SetToCreate{
+				Name:        form.Form.Name,
+				Version:     form.Form.Version,
+				Description: form.Form.Description,
+				CreatedBy:   user.MustCtxUser(ctx).ID,
+			}, templateSetRepository, templateRepository, sourceRepository, pipeline)
+			if sourceErr(err) != nil {
+				appCtx.Info(template.Pkg, "importing template set from source failed", "url", setSource.URL, "error", err)
+				form.ViolationsFromErrors(sourceErr(err))
+				return renderImportRemotePage(io, form)
+			}
+			if err != nil {
+				return err
+			}
+
+			template.PublishSetImported(ctx, appCtx.EventManager, templateSet)
+
+			return io.Redirect(fmt.Sprintf("/template-set/%s/list", templateSet.ID), http.StatusFound)
+		})
+	})
+}
+
+func templateSetSourceController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		data, err := templateSetSourceData(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		return renderSourceCard(io, data)
+	})
+}
+
+func templateSetSourceCheckController(appCtx *hctx.AppCtx, webCtx *web.Ctx, cfg *template.ImportCfg) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		data, err := templateSetSourceData(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+		if data.Source == nil {
+			return io.InlineError(ErrResourceNotFound)
+		}
+
+		source, err := template.NewSource(cfg, data.Source)
+		if err != nil {
+			return renderSourceCard(io, data, sourceErr(err))
+		}
+
+		revision, err := source.Revision(io.Context())
+		if sourceErr(err) != nil {
+			appCtx.Info(template.Pkg, "checking template set source for updates failed", "url", data.Source.URL, "error", err)
+			return renderSourceCard(io, data, sourceErr(err))
+		}
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		data.Checked = true
+		data.UpdateAvailable = revision != data.Source.Revision
+
+		return renderSourceCard(io, data)
+	})
+}
+
+func templateSetSourceSyncController(appCtx *hctx.AppCtx, webCtx *web.Ctx, cfg *template.ImportCfg) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	sourceRepository := util.UnwrapType[template.SourceRepository](appCtx.Repository(template.SourceRepositoryName))
+	pipeline := template.NewValidationPipeline(appCtx.EventManager, appCtx.Logger)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		data, err := templateSetSourceData(io)
+		if err != nil {
+			return io.InlineError(err)
+		}
+		if data.Source == nil {
+			return io.InlineError(ErrResourceNotFound)
+		}
+
+		source, err := template.NewSource(cfg, data.Source)
+		if err != nil {
+			return renderSourceCard(io, data, sourceErr(err))
+		}
+
+		result, err := SyncFromSource(io.Context(), source, data.Source, templateRepository, sourceRepository, pipeline, user.MustCtxUser(io.Context()).ID)
+		if sourceErr(err) != nil {
+			appCtx.Info(template.Pkg, "syncing template set with source failed", "url", data.Source.URL, "error", err)
+			return renderSourceCard(io, data, sourceErr(err))
+		}
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		for _, tmpl := range result.Updated {
+			template.PublishTemplateUpdated(appCtx.EventManager, tmpl.ID, false)
+		}
+		appCtx.Info(template.Pkg, "synced template set with source", "templateSet", data.TemplateSet.ID,
+			"created", len(result.Created), "updated", len(result.Updated), "unchanged", result.Unchanged)
+
+		io.Response().Header().Set("HX-Refresh", "true")
+
+		return renderSourceCard(io, data)
+	})
+}
+
+// templateSetSourceData reads the user's template set from the request's id parameter and the source it was imported from.
+// The errors returned are safe to be displayed to the user.
+func templateSetSourceData(io web.IO) (*SourceData, error) {
+	templateSetRepository := web.MustRepository[template.SetRepository](io, template.SetRepositoryName)
+	sourceRepository := web.MustRepository[template.SourceRepository](io, template.SourceRepositoryName)
+
+	templateSet, err := TemplateSetFromParams(io, templateSetRepository, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := sourceRepository.FindBySet(io.Context(), templateSet.ID)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, errors.Join(web.ErrInternal, err)
+	}
+	if err != nil {
+		source = nil
+	}
+
+	return &SourceData{TemplateSet: templateSet, Source: source}, nil
+}
+
+// templateSetImportRemoteForm declares the form importing a template set from a remote source.
+func templateSetImportRemoteForm(toImport *SourceImportForm, validationErrs ...error) *web.Form[*SourceImportForm] {
+	return web.NewForm(web.Form[*SourceImportForm]{
+		ID:     "template-set-import-remote-form",
+		Action: "/template-set/import/remote",
+		Submit: "template.set.import.source.submit",
+	}, toImport, nil, validationErrs...)
+}
+
+// renderImportRemotePage renders the page importing a template set from a remote source.
+func renderImportRemotePage(io web.IO, form *web.Form[*SourceImportForm]) error {
+	return io.Render(
+		form,
+		"template.set.import.page",
+		"template/set-import-page.go.html",
+		"template/_form-set-import.go.html",
+	)
+}
+
+// renderSourceCard renders the source card of a template set with the errors.
+func renderSourceCard(io web.IO, data *SourceData, errs ...error) error {
+	return io.Render(web.NewFormData(data, nil, errs...), "template.set.source", "template/_source.go.html")
+}
@@ -0,0 +1,219 @@
+package web
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// registerLabelController registers the controllers managing the labels of template sets. The controllers render the template set list
+// filtered by the selected labels (label, repeatable):
+//   - POST /template-set/label Creates a label (name).
+//   - DELETE /template-set/label/{labelID} Deletes a label.
+//   - POST /template-set/{id}/label Labels a template set with a label (label_id).
+//   - DELETE /template-set/{id}/label/{labelID} Removes a label from a template set.
+func registerLabelController(appCtx *hctx.AppCtx, webCtx *web.Ctx, router web.Router) {
+	router.Post("/template-set/label", labelCreateController(appCtx, webCtx).ServeHTTP)
+	router.Delete("/template-set/label/{labelID}", labelDeleteController(appCtx, webCtx).ServeHTTP)
+	router.Post("/template-set/{id}/label", labelAssignController(appCtx, webCtx).ServeHTTP)
+	router.Delete("/template-set/{id}/label/{labelID}", labelUnassignController(appCtx, webCtx).ServeHTTP)
+}
+
+// LabelsFromRequest reads the labels selected to filter the template sets (label, repeatable) from the request's query and form.
+// Invalid and duplicate labels are ignored.
+func LabelsFromRequest(request *http.Request) []uuid.UUID {
+	_ = request.ParseForm()
+
+	var labels []uuid.UUID
+	for _, value := range request.Form["label"] {
+		id, err := uuid.Parse(value)
+		if err == nil && !slices.Contains(labels, id) {
+			labels = append(labels, id)
+		}
+	}
+
+	return labels
+}
+
+// IsSelected returns true if the template sets are filtered by the label.
+func (d *TemplateSetListData) IsSelected(labelID uuid.UUID) bool {
+	return slices.Contains(d.SelectedLabels, labelID)
+}
+
+// ToggleQuery returns the query string of the label filter with the label added or removed if it is already selected.
+func (d *TemplateSetListData) ToggleQuery(labelID uuid.UUID) string {
+	labels := slices.DeleteFunc(slices.Clone(d.SelectedLabels), func(id uuid.UUID) bool { return id == labelID })
+	if !d.IsSelected(labelID) {
+		labels = append(labels, labelID)
+	}
+
+	values := url.Values{}
+	for _, id := range labels {
+		values.Add("label", id.String())
+	}
+
+	return values.Encode()
+}
+
+// LabelsOf returns the labels of the template set ordered by their name.
+func (d *TemplateSetListData) LabelsOf(templateSetID uuid.UUID) []*template.Label {
+	return d.SetLabels[templateSetID]
+}
+
+// UnassignedLabels returns the user's labels the template set is not labeled with.
+func (d *TemplateSetListData) UnassignedLabels(templateSetID uuid.UUID) []*template.Label {
+	var labels []*template.Label
+	for _, label := range d.Labels {
+		if !slices.ContainsFunc(d.SetLabels[templateSetID], func(l *template.Label) bool { return l.ID == label.ID }) {
+			labels = append(labels, label)
+		}
+	}
+
+	return labels
+}
+
+func labelCreateController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		labelRepository := web.MustRepository[template.LabelRepository](io, template.LabelRepositoryName)
+
+		_, labelErr := labelRepository.Create(io.Context(), user.MustFromIO(io).ID, io.Request().FormValue("name"))
+		if labelErr != nil && !errors.Is(labelErr, template.ErrInvalidLabel) && !errors.Is(labelErr, template.ErrLabelExists) {
+			return io.InlineError(web.ErrInternal, labelErr)
+		}
+
+		return renderTemplateSetList(io, labelErr)
+	})
+}
+
+func labelDeleteController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		labelRepository := web.MustRepository[template.LabelRepository](io, template.LabelRepositoryName)
+
+		labelID, err := uuid.Parse(web.URLParam(io.Request(), "labelID"))
+		if err != nil {
+			return io.InlineError(ErrInvalidUUID, err)
+		}
+
+		if err := labelRepository.Delete(io.Context(), user.MustFromIO(io).ID, labelID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderTemplateSetList(io, nil)
+	})
+}
+
+func labelAssignController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		labelRepository := web.MustRepository[template.LabelRepository](io, template.LabelRepositoryName)
+
+		templateSetID, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+		if err != nil {
+			return io.InlineError(ErrInvalidUUID, err)
+		}
+		labelID, err := uuid.Parse(io.Request().FormValue("label_id"))
+		if err != nil {
+			return io.InlineError(ErrInvalidUUID, err)
+		}
+
+		err = labelRepository.Assign(io.Context(), user.MustFromIO(io).ID, templateSetID, labelID)
+		if err != nil && errors.Is(err, persistence.ErrNotFound) {
+			return io.InlineError(ErrResourceNotFound, err)
+		} else if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderTemplateSetList(io, nil)
+	})
+}
+
+func labelUnassignController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		labelRepository := web.MustRepository[template.LabelRepository](io, template.LabelRepositoryName)
+
+		templateSetID, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+		if err != nil {
+			return io.InlineError(ErrInvalidUUID, err)
+		}
+		labelID, err := uuid.Parse(web.URLParam(io.Request(), "labelID"))
+		if err != nil {
+			return io.InlineError(ErrInvalidUUID, err)
+		}
+
+		if err := labelRepository.Unassign(io.Context(), user.MustFromIO(io).ID, templateSetID, labelID); err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderTemplateSetList(io, nil)
+	})
+}
+
+// renderTemplateSetList renders the template set list filtered by the labels selected in the request, see templateSetListData.
+// The labelErr is displayed next to the label form, e.g. if a label could not be created.
+func renderTemplateSetList(io web.IO, labelErr error) error {
+	data, errs := templateSetListData(io, LabelsFromRequest(io.Request()))
+	if errs != nil {
+		return io.InlineError(errs...)
+	}
+
+	data.LabelErr = labelErr
+
+	return io.Render(data, "template.set.list", "template/_list-set.go.html")
+}
+
+// templateSetListData reads the user's template sets having all selected labels, the user's labels and the labels of the listed template sets.
+// Selected labels that no longer exist are ignored. The returned errors are to be displayed if the data could not be read.
+func templateSetListData(io web.IO, selected []uuid.UUID) (*TemplateSetListData, []error) {
+	ctx := io.Context()
+	usr := user.MustFromIO(io)
+	templateSetRepository := web.MustRepository[template.SetRepository](io, template.SetRepositoryName)
+	labelRepository := web.MustRepository[template.LabelRepository](io, template.LabelRepositoryName)
+
+	labels, err := labelRepository.FindByUser(ctx, usr.ID)
+	if err != nil {
+		return nil, []error{web.ErrInternal, err}
+	}
+
+	selected = slices.DeleteFunc(selected, func(id uuid.UUID) bool {
+		return !slices.ContainsFunc(labels, func(l *template.Label) bool { return l.ID == id })
+	})
+
+	var templateSets []*template.Set
+	if len(selected) > 0 {
+		templateSets, err = labelRepository.FindSets(ctx, usr.ID, selected)
+	} else {
+		templateSets, err = templateSetRepository.FindByCreatedBy(ctx, usr.ID)
+	}
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, []error{web.ErrInternal, err}
+	}
+
+	setIDs := make([]uuid.UUID, 0, len(templateSets))
+	for _, templateSet := range templateSets {
+		setIDs = append(setIDs, templateSet.ID)
+	}
+
+	setLabels, err := labelRepository.FindBySets(ctx, usr.ID, setIDs)
+	if err != nil {
+		return nil, []error{web.ErrInternal, err}
+	}
+
+	ver, err := LatestPARISVersion(PARISTemplatesDir(ctx))
+	if err != nil {
+		return nil, []error{ErrDefaultTemplateDoesNotExist, err}
+	}
+
+	return &TemplateSetListData{
+		TemplateSets:   templateSets,
+		PARISVersion:   ver,
+		Labels:         labels,
+		SelectedLabels: selected,
+		SetLabels:      setLabels,
+	}, nil
+}
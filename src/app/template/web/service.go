@@ -8,14 +8,19 @@ import (
 	"github.com/org-harmony/harmony/src/app/template"
 	"github.com/org-harmony/harmony/src/app/user"
 	"github.com/org-harmony/harmony/src/core/event"
-	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/org-harmony/harmony/src/core/trace"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
 	"os"
 	"path/filepath"
 )
 
+// DefaultPARISTemplatesDir is the directory of the default PARIS templates offered to users for import.
+// Tenants may configure their own directory (see tenant.Tenant).
+const DefaultPARISTemplatesDir = "docs/templates/paris"
+
 var (
 	// ErrInvalidUUID is returned when the resource's id is not a valid uuid.
 	ErrInvalidUUID = errors.New("invalid resource uuid")
@@ -25,6 +30,12 @@ var (
 	ErrUserNotPermitted = errors.New("user not permitted")
 	// ErrDefaultTemplateDoesNotExist is returned when the default template does not exist.
 	ErrDefaultTemplateDoesNotExist = errors.New("default template does not exist")
+	// ErrInvalidImport is returned when a template set could not be imported because at least one of its templates is invalid.
+	ErrInvalidImport = errors.New("template.set.import.invalid")
+	// ErrUpgradeOutdated is returned when the reviewed upgrade is outdated because the source changed since, see ApplyUpgrade.
+	ErrUpgradeOutdated = errors.New("template.upgrade.outdated")
+	// ErrBreakingNotAccepted is returned when an upgrade with breaking changes is applied without accepting them.
+	ErrBreakingNotAccepted = errors.New("template.upgrade.breaking-not-accepted")
 )
 
 // templateFormData is the data passed to the template form. It contains the template and information about the
@@ -34,6 +45,8 @@ type templateFormData struct {
 	// I don't see another comfortable way to do this.
 	Template   any
 	IsEditForm bool
+	// Lint are the findings of linting the saved template (see template.LintTemplateConfig). They are only filled on the edit form.
+	Lint []template.LintFinding
 }
 
 // templateListPageData is the data for the template list page template.
@@ -96,6 +109,16 @@ func TemplateFromParams(io web.IO, repo template.Repository, param string) (*tem
 	return tmpl, nil
 }
 
+// TemplateSetETag returns the entity tag of the template set's current version (see web.VersionETag).
+func TemplateSetETag(templateSet *template.Set) string {
+	return web.VersionETag(templateSet.ID.String(), templateSet.Modified())
+}
+
+// TemplateETag returns the entity tag of the template's current version (see web.VersionETag).
+func TemplateETag(tmpl *template.Template) string {
+	return web.VersionETag(tmpl.ID.String(), tmpl.Modified())
+}
+
 // CopyTemplate copies the given template into the given template set. It returns the copied template.
 // The name of the template is set to the given name, the user id is set as the created by user id of the template.
 // Errors are returned transparently.
@@ -130,6 +153,16 @@ func CopyTemplate(ctx context.Context, tmpl *template.Template, tmplSetID, usrID
 	return newTmpl, nil
 }
 
+// PARISTemplatesDir returns the default PARIS templates directory of the context's tenant.
+// If the tenant has no default templates directory, DefaultPARISTemplatesDir is returned.
+func PARISTemplatesDir(ctx context.Context) string {
+	if t, ok := tenant.FromCtx(ctx); ok && t.DefaultTemplatesDir != "" {
+		return t.DefaultTemplatesDir
+	}
+
+	return DefaultPARISTemplatesDir
+}
+
 func LatestPARISVersion(baseDir string) (string, error) {
 	dir, err := os.ReadDir(baseDir)
 	if err != nil {
@@ -168,27 +201,28 @@ func LatestPARISVersion(baseDir string) (string, error) {
 	return latestVersion, nil
 }
 
-func ImportDefaultPARISTemplates(ctx context.Context, baseDir string, tmplSetRepo template.SetRepository, tmplRepo template.Repository, usrID uuid.UUID) error {
+// ImportDefaultPARISTemplates imports the latest version of the default PARIS templates from the base directory as a new template set of the user.
+// All templates are validated by the pipeline before the template set is created. If any template is invalid, nothing is imported
+// and ErrInvalidImport is returned along with the validation errors.
+func ImportDefaultPARISTemplates(
+	ctx context.Context,
+	baseDir string,
+	tmplSetRepo template.SetRepository,
+	tmplRepo template.Repository,
+	pipeline *template.ValidationPipeline,
+	usrID uuid.UUID,
+) (*template.Set, error) {
 	latestVersion, err := LatestPARISVersion(baseDir)
 	if err != nil {
-		return ErrDefaultTemplateDoesNotExist
+		return nil, ErrDefaultTemplateDoesNotExist
 	}
 
 	versionDir, err := os.ReadDir(filepath.Join(baseDir, "v"+latestVersion))
 	if err != nil {
-		return ErrDefaultTemplateDoesNotExist
-	}
-
-	tmplSet, err := tmplSetRepo.Create(ctx, &template.SetToCreate{
-		Name:        "PARIS",
-		Version:     latestVersion,
-		CreatedBy:   usrID,
-		Description: "Default PARIS templates. Change description and templates as needed.",
-	})
-	if err != nil {
-		return web.ErrInternal
+		return nil, ErrDefaultTemplateDoesNotExist
 	}
 
+	var toCreate []*template.ToCreate
 	for _, file := range versionDir {
 		if file.IsDir() {
 			continue
@@ -196,24 +230,42 @@ func ImportDefaultPARISTemplates(ctx context.Context, baseDir string, tmplSetRep
 
 		jsonCfg, err := os.ReadFile(filepath.Join(baseDir, "v"+latestVersion, file.Name()))
 		if err != nil {
-			return ErrDefaultTemplateDoesNotExist
+			return nil, ErrDefaultTemplateDoesNotExist
 		}
 
 		tmpl, err := template.ToCreateFromConfig(string(jsonCfg))
 		if err != nil {
-			return ErrDefaultTemplateDoesNotExist
+			return nil, ErrDefaultTemplateDoesNotExist
 		}
 
+		toCreate = append(toCreate, tmpl)
+	}
+
+	if err := validateImport(ctx, pipeline, toCreate); err != nil {
+		return nil, err
+	}
+
+	tmplSet, err := tmplSetRepo.Create(ctx, &template.SetToCreate{
+		Name:        "PARIS",
+		Version:     latestVersion,
+		CreatedBy:   usrID,
+		Description: "Default PARIS templates. Change description and templates as needed.",
+	})
+	if err != nil {
+		return nil, web.ErrInternal
+	}
+
+	for _, tmpl := range toCreate {
 		tmpl.TemplateSet = tmplSet.ID
 		tmpl.CreatedBy = usrID
 
 		_, err = tmplRepo.Create(ctx, tmpl)
 		if err != nil {
-			return web.ErrInternal
+			return nil, web.ErrInternal
 		}
 	}
 
-	return nil
+	return tmplSet, nil
 }
 
 // templateSetInlineDelete reads the template set id from the request 'id' parameter and deletes the template set.
@@ -232,19 +284,6 @@ func templateSetInlineDelete(io web.IO, repo template.SetRepository) error {
 	return nil
 }
 
-// templateSetsForList reads the current user from the context and returns all template sets created by the user.
-// It reports errors to the IO as inline errors. Errors are returned as internal errors safe to show to the user.
-func templateSetsForList(io web.IO, repo template.SetRepository) ([]*template.Set, error) {
-	usr := user.MustCtxUser(io.Context())
-
-	templateSets, err := repo.FindByCreatedBy(io.Context(), usr.ID)
-	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
-		return nil, io.InlineError(web.ErrInternal, err)
-	}
-
-	return templateSets, nil
-}
-
 // readValidTemplateForm reads the template form from the request and validates it. It returns the template to create
 // and a slice of validation errors. If the validation errors slice is not empty, the template to create is not valid.
 // Errors are returned as internal errors they are not safe to show to the user.
@@ -310,6 +349,14 @@ func readValidTemplateUpdateForm(
 	return toUpdate, validationErrs, err
 }
 
+// lintTemplate returns the lint findings of the template. Linting is optional, no findings are returned if it failed.
+// The failure is logged by template.LintTemplateConfig.
+func lintTemplate(tmpl *template.Template, em event.Manager, logger trace.Logger) []template.LintFinding {
+	findings, _ := template.LintTemplateConfig(tmpl.Config, tmpl.Type, tmpl.TemplateSet, em, logger)
+
+	return findings
+}
+
 // renderNewTemplatePage renders the template set new page template.
 func renderNewTemplatePage(io web.IO, toCreate *template.ToCreate, validationErrs []error) error {
 	return io.Render(
@@ -321,9 +368,9 @@ func renderNewTemplatePage(io web.IO, toCreate *template.ToCreate, validationErr
 }
 
 // renderEditTemplatePage renders the template set edit page template.
-func renderEditTemplatePage(io web.IO, toUpdate *template.ToUpdate, success []string, validationErrs []error) error {
+func renderEditTemplatePage(io web.IO, toUpdate *template.ToUpdate, success []string, validationErrs []error, lint []template.LintFinding) error {
 	return io.Render(
-		web.NewFormData(&templateFormData{Template: toUpdate, IsEditForm: true}, success, validationErrs...),
+		web.NewFormData(&templateFormData{Template: toUpdate, IsEditForm: true, Lint: lint}, success, validationErrs...),
 		"template.form.page",
 		"template/form-page.go.html",
 		"template/_form.go.html",
@@ -331,18 +378,38 @@ func renderEditTemplatePage(io web.IO, toUpdate *template.ToUpdate, success []st
 }
 
 // renderEditTemplateForm renders the template set edit form template.
-func renderEditTemplateForm(io web.IO, toUpdate *template.ToUpdate, success []string, validationErrs []error) error {
+func renderEditTemplateForm(io web.IO, toUpdate *template.ToUpdate, success []string, validationErrs []error, lint []template.LintFinding) error {
 	return io.Render(
-		web.NewFormData(&templateFormData{Template: toUpdate, IsEditForm: true}, success, validationErrs...),
+		web.NewFormData(&templateFormData{Template: toUpdate, IsEditForm: true, Lint: lint}, success, validationErrs...),
 		"template.form",
 		"template/_form.go.html",
 	)
 }
 
+// templateSetNewForm declares the form creating a template set.
+func templateSetNewForm(toCreate *template.SetToCreate, validationErrs ...error) *web.Form[*template.SetToCreate] {
+	return web.NewForm(web.Form[*template.SetToCreate]{
+		ID:       "template-set-new-form",
+		Action:   "/template-set/new",
+		Validate: "/template-set/validate",
+		Submit:   "harmony.generic.create",
+	}, toCreate, nil, validationErrs...)
+}
+
+// templateSetEditForm declares the form editing a template set. The form is submitted through the edit modal's button.
+func templateSetEditForm(toUpdate *template.SetToUpdate, success []string) *web.Form[*template.SetToUpdate] {
+	return web.NewForm(web.Form[*template.SetToUpdate]{
+		ID:     "edit-form-for-" + toUpdate.ID.String(),
+		Action: "/template-set/" + toUpdate.ID.String(),
+		Method: http.MethodPut,
+		HTMX:   true,
+	}, toUpdate, success)
+}
+
 // renderNewTemplateSetPage renders the template set new page template.
-func renderNewTemplateSetPage(io web.IO, toCreate *template.SetToCreate, validationErrs []error) error {
+func renderNewTemplateSetPage(io web.IO, form *web.Form[*template.SetToCreate]) error {
 	return io.Render(
-		web.NewFormData(toCreate, nil, validationErrs...),
+		form,
 		"template.set.new.page",
 		"template/set-new-page.go.html",
 		"template/_form-set-new.go.html",
@@ -350,9 +417,9 @@ func renderNewTemplateSetPage(io web.IO, toCreate *template.SetToCreate, validat
 }
 
 // renderEditTemplateSetForm renders the template set edit form template.
-func renderEditTemplateSetForm(io web.IO, templateSet *template.SetToUpdate, success []string, validationErrs []error) error {
+func renderEditTemplateSetForm(io web.IO, form *web.Form[*template.SetToUpdate]) error {
 	return io.Render(
-		web.NewFormData(templateSet, success, validationErrs...),
+		form,
 		"template.set.edit.form",
 		"template/_form-set-edit.go.html",
 	)
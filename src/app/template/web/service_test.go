@@ -3,12 +3,18 @@ package web
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/org-harmony/harmony/src/app/template"
 	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/event"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/trace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -142,3 +148,108 @@ func cleanupUserTable(t *testing.T) {
 	_, err := db.Exec(ctx, "TRUNCATE TABLE users CASCADE")
 	require.NoError(t, err)
 }
+
+func TestTemplateSetListLabels(t *testing.T) {
+	security, acme := &template.Label{ID: uuid.New(), Name: "Security"}, &template.Label{ID: uuid.New(), Name: "ACME"}
+	setID := uuid.New()
+
+	request := httptest.NewRequest("GET", "/template-set/list?label="+acme.ID.String()+"&label="+acme.ID.String()+"&label=invalid", nil)
+	selected := LabelsFromRequest(request)
+	assert.Equal(t, []uuid.UUID{acme.ID}, selected)
+
+	data := &TemplateSetListData{
+		Labels:         []*template.Label{acme, security},
+		SelectedLabels: selected,
+		SetLabels:      map[uuid.UUID][]*template.Label{setID: {acme}},
+	}
+	assert.True(t, data.IsSelected(acme.ID))
+	assert.False(t, data.IsSelected(security.ID))
+	assert.Empty(t, data.ToggleQuery(acme.ID))
+	assert.Equal(t, "label="+acme.ID.String()+"&label="+security.ID.String(), data.ToggleQuery(security.ID))
+	assert.Equal(t, []*template.Label{acme}, data.LabelsOf(setID))
+	assert.Equal(t, []*template.Label{security}, data.UnassignedLabels(setID))
+	assert.Equal(t, []*template.Label{acme, security}, data.UnassignedLabels(uuid.New()))
+}
+
+// sourceMock is a template.Source returning fixed template configs.
+type sourceMock struct {
+	configs  []string
+	revision string
+}
+
+func (s *sourceMock) Fetch(ctx context.Context) ([]string, string, error) {
+	return s.configs, s.revision, nil
+}
+
+func (s *sourceMock) Revision(ctx context.Context) (string, error) {
+	return s.revision, nil
+}
+
+func TestImportAndSyncFromSource(t *testing.T) {
+	registerAllCleanup(t)
+
+	sourceRepo := template.NewSourceRepository(db)
+	pipeline := template.NewValidationPipeline(event.NewManager(trace.NewLogger()), trace.NewLogger())
+	usr, err := userRepo.Create(ctx, &user.ToCreate{Email: "foo@bar.com", Firstname: "Foo", Lastname: "Bar"})
+	require.NoError(t, err)
+
+	source := &sourceMock{configs: []string{
+		`{"type": "ebt", "name": "A", "version": "1.0.0"}`,
+		`{"type": "ebt", "name": "B", "version": "1.0.0"}`,
+	}, revision: "r1"}
+	setSource := &template.SetSource{Kind: template.SourceURL, URL: "https://example.com/templates.json"}
+
+	tmplSet, err := ImportFromSource(ctx, source, setSource, &template.SetToCreate{Name: "Remote", Version: "1.0.0", CreatedBy: usr.ID},
+		templateSetRepo, templateRepo, sourceRepo, pipeline)
+	require.NoError(t, err)
+
+	saved, err := sourceRepo.FindBySet(ctx, tmplSet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", saved.Revision)
+	assert.Equal(t, setSource.URL, saved.URL)
+
+	source.configs = []string{
+		`{"type": "ebt", "name": "A", "version": "1.0.0"}`,
+		`{"type": "ebt", "name": "B", "version": "2.0.0"}`,
+		`{"type": "ebt", "name": "C", "version": "1.0.0"}`,
+	}
+	source.revision = "r2"
+
+	result, err := SyncFromSource(ctx, source, saved, templateRepo, sourceRepo, pipeline, usr.ID)
+	require.NoError(t, err)
+	require.Len(t, result.Created, 1)
+	require.Len(t, result.Updated, 1)
+	assert.Equal(t, "C", result.Created[0].Name)
+	assert.Equal(t, "2.0.0", result.Updated[0].Version)
+	assert.Equal(t, 1, result.Unchanged)
+
+	templates, err := templateRepo.FindByTemplateSetID(ctx, tmplSet.ID)
+	require.NoError(t, err)
+	assert.Len(t, templates, 3)
+
+	saved, err = sourceRepo.FindBySet(ctx, tmplSet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "r2", saved.Revision)
+
+	source.configs = []string{`{"name": "missing type"}`}
+	_, err = SyncFromSource(ctx, source, saved, templateRepo, sourceRepo, pipeline, usr.ID)
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	assert.Equal(t, ErrInvalidImport, sourceErr(err))
+}
+
+func TestSourceErr(t *testing.T) {
+	for _, err := range []error{template.ErrFetchSource, template.ErrSourceTooLarge, template.ErrInvalidContent, template.ErrAddressNotAllowed} {
+		assert.Equal(t, template.ErrFetchSource, sourceErr(errors.Join(err, io.EOF)), "fetch errors are not distinguished")
+	}
+
+	assert.Equal(t, template.ErrHostNotAllowed, sourceErr(template.ErrHostNotAllowed))
+	assert.Nil(t, sourceErr(io.EOF))
+}
+
+func TestSourceImportForm(t *testing.T) {
+	form := &SourceImportForm{URL: "https://example.com/repo.git", Ref: "main", Path: "templates"}
+	assert.Equal(t, &template.SetSource{Kind: template.SourceURL, URL: form.URL}, form.SetSource())
+
+	form.Git = true
+	assert.Equal(t, &template.SetSource{Kind: template.SourceGit, URL: form.URL, Ref: "main", Path: "templates"}, form.SetSource())
+}
@@ -0,0 +1,205 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
+	"net/http"
+	"time"
+)
+
+// UpgradeData is the data of the page reviewing the upgrade of a template set to the current revision of its source.
+type UpgradeData struct {
+	TemplateSet *template.Set
+	Source      *template.SetSource
+	// Plan is nil if the upgrade could not be planned, e.g. because the source could not be fetched.
+	Plan *template.UpgradePlan
+}
+
+// PlanFromSource fetches the template configs of the source and plans the upgrade of the template set to them, see template.PlanUpgrade.
+// Local modifications since the last import, sync or upgrade are preserved where possible. It returns ErrInvalidImport if any template is invalid.
+func PlanFromSource(
+	ctx context.Context,
+	source template.Source,
+	setSource *template.SetSource,
+	tmplRepo template.Repository,
+	pipeline *template.ValidationPipeline,
+) (*template.UpgradePlan, error) {
+	configs, revision, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := validTemplatesFromConfigs(ctx, pipeline, configs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := tmplRepo.FindByTemplateSetID(ctx, setSource.TemplateSet)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, err
+	}
+
+	return template.PlanUpgrade(setSource.Base, existing, templates, revision)
+}
+
+// ApplyUpgrade applies the planned upgrade: new templates are created and upgraded templates are updated with their merged config.
+// Nothing is changed if any merged config is invalid according to the pipeline (ErrInvalidImport). The source is saved with the plan's revision and base.
+func ApplyUpgrade(
+	ctx context.Context,
+	plan *template.UpgradePlan,
+	setSource *template.SetSource,
+	tmplRepo template.Repository,
+	sourceRepo template.SourceRepository,
+	pipeline *template.ValidationPipeline,
+	usrID uuid.UUID,
+) (*SyncResult, error) {
+	toValidate := make([]*template.ToCreate, 0, len(plan.Templates))
+	for _, upgrade := range plan.Templates {
+		toValidate = append(toValidate, &template.ToCreate{Type: upgrade.Type, Config: upgrade.Config})
+	}
+	if err := validateImport(ctx, pipeline, toValidate); err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{}
+	for _, upgrade := range plan.Templates {
+		if upgrade.Local == nil {
+			created, err := tmplRepo.Create(ctx, &template.ToCreate{TemplateSet: setSource.TemplateSet, Type: upgrade.Type, Config: upgrade.Config, CreatedBy: usrID})
+			if err != nil {
+				return nil, err
+			}
+
+			result.Created = append(result.Created, created)
+			continue
+		}
+
+		updated, err := tmplRepo.Update(ctx, &template.ToUpdate{
+			ID:          upgrade.Local.ID,
+			TemplateSet: upgrade.Local.TemplateSet,
+			Type:        upgrade.Type,
+			Config:      upgrade.Config,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Updated = append(result.Updated, updated)
+	}
+
+	setSource.Revision = plan.Revision
+	setSource.Base = plan.Base
+	setSource.SyncedAt = time.Now()
+	if err := sourceRepo.Save(ctx, setSource); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func templateSetUpgradePageController(appCtx *hctx.AppCtx, webCtx *web.Ctx, cfg *template.ImportCfg) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	pipeline := template.NewValidationPipeline(appCtx.EventManager, appCtx.Logger)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		data, err := templateSetUpgradeData(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		source, err := template.NewSource(cfg, data.Source)
+		if err != nil {
+			return renderUpgradePage(io, data, sourceErr(err))
+		}
+
+		data.Plan, err = PlanFromSource(io.Context(), source, data.Source, templateRepository, pipeline)
+		if sourceErr(err) != nil {
+			appCtx.Info(template.Pkg, "planning template set upgrade failed", "url", data.Source.URL, "error", err)
+			return renderUpgradePage(io, data, sourceErr(err))
+		}
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		return renderUpgradePage(io, data)
+	})
+}
+
+func templateSetUpgradeController(appCtx *hctx.AppCtx, webCtx *web.Ctx, cfg *template.ImportCfg) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	sourceRepository := util.UnwrapType[template.SourceRepository](appCtx.Repository(template.SourceRepositoryName))
+	pipeline := template.NewValidationPipeline(appCtx.EventManager, appCtx.Logger)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		data, err := templateSetUpgradeData(io)
+		if err != nil {
+			return io.Error(err)
+		}
+
+		source, err := template.NewSource(cfg, data.Source)
+		if err != nil {
+			return renderUpgradePage(io, data, sourceErr(err))
+		}
+
+		ctx := io.Context()
+		data.Plan, err = PlanFromSource(ctx, source, data.Source, templateRepository, pipeline)
+		if sourceErr(err) != nil {
+			appCtx.Info(template.Pkg, "planning template set upgrade failed", "url", data.Source.URL, "error", err)
+			return renderUpgradePage(io, data, sourceErr(err))
+		}
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		// the plan is re-computed and must match the reviewed revision, otherwise the user has to review the changed plan
+		if data.Plan.Revision != io.Request().FormValue("revision") {
+			return renderUpgradePage(io, data, ErrUpgradeOutdated)
+		}
+		if data.Plan.Breaking() && io.Request().FormValue("breaking") != "on" {
+			return renderUpgradePage(io, data, ErrBreakingNotAccepted)
+		}
+
+		result, err := ApplyUpgrade(ctx, data.Plan, data.Source, templateRepository, sourceRepository, pipeline, user.MustCtxUser(ctx).ID)
+		if sourceErr(err) != nil {
+			appCtx.Info(template.Pkg, "upgrading template set failed", "templateSet", data.TemplateSet.ID, "error", err)
+			return renderUpgradePage(io, data, sourceErr(err))
+		}
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		for _, tmpl := range result.Updated {
+			template.PublishTemplateUpdated(appCtx.EventManager, tmpl.ID, false)
+		}
+		appCtx.Info(template.Pkg, "upgraded template set", "templateSet", data.TemplateSet.ID, "revision", data.Plan.Revision,
+			"created", len(result.Created), "updated", len(result.Updated), "breaking", data.Plan.Breaking())
+
+		return io.Redirect(fmt.Sprintf("/template-set/%s/list", data.TemplateSet.ID), http.StatusFound)
+	})
+}
+
+// templateSetUpgradeData reads the user's template set from the request's id parameter and the source it was imported from.
+// It returns ErrResourceNotFound if the template set was not imported from a source. The errors returned are safe to be displayed to the user.
+func templateSetUpgradeData(io web.IO) (*UpgradeData, error) {
+	data, err := templateSetSourceData(io)
+	if err != nil {
+		return nil, err
+	}
+	if data.Source == nil {
+		return nil, ErrResourceNotFound
+	}
+
+	return &UpgradeData{TemplateSet: data.TemplateSet, Source: data.Source}, nil
+}
+
+// renderUpgradePage renders the page reviewing the upgrade of a template set with the errors.
+func renderUpgradePage(io web.IO, data *UpgradeData, errs ...error) error {
+	return io.Render(web.NewFormData(data, nil, errs...), "template.set.upgrade.page", "template/set-upgrade-page.go.html")
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/org-harmony/harmony/src/app/template"
 	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/config"
 	"github.com/org-harmony/harmony/src/core/hctx"
 	"github.com/org-harmony/harmony/src/core/persistence"
 	"github.com/org-harmony/harmony/src/core/util"
@@ -29,20 +30,35 @@ type TemplateCopyFormData struct {
 }
 
 // TemplateSetListData is passed to the template set list and contains the additional paris version.
+// The listed template sets are filtered by the SelectedLabels, see templateSetListData.
 type TemplateSetListData struct {
 	TemplateSets []*template.Set
 	PARISVersion string
+	// Labels are all labels of the user.
+	Labels         []*template.Label
+	SelectedLabels []uuid.UUID
+	// SetLabels are the labels of the listed template sets by the template set's id.
+	SetLabels map[uuid.UUID][]*template.Label
+	// LabelErr is displayed next to the label form if a label could not be created.
+	LabelErr error
 }
 
 // RegisterController registers the controllers and navigation for the template module.
 func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 	registerNavigation(appCtx, webCtx)
+	registerCommands(webCtx)
+	registerErrors(webCtx)
+
+	cfg := &template.Cfg{}
+	util.Ok(config.C(cfg, config.From("template"), config.Validate(appCtx.Validator)))
 
 	router := webCtx.Router.With(user.LoggedInMiddleware(appCtx))
 
 	router.Get("/template-set/list", templateSetListController(appCtx, webCtx).ServeHTTP)
 	router.Get("/template-set/new", templateSetNewController(appCtx, webCtx).ServeHTTP)
 	router.Post("/template-set/new", templateSetNewSaveController(appCtx, webCtx).ServeHTTP)
+	router.Post("/template-set/validate", web.ValidationController[template.SetToCreate](appCtx, webCtx).ServeHTTP)
+	router.Get("/template-set/{id}", templateSetController(appCtx, webCtx).ServeHTTP)
 	router.Get("/template-set/edit/{id}", templateSetEditFormController(appCtx, webCtx).ServeHTTP)
 	router.Put("/template-set/{id}", templateSetEditController(appCtx, webCtx).ServeHTTP)
 	router.Delete("/template-set/{id}", templateSetDeleteController(appCtx, webCtx).ServeHTTP)
@@ -52,71 +68,128 @@ func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 	router.Get("/template-set/{id}/list", templateListController(appCtx, webCtx).ServeHTTP)
 	router.Get("/template-set/{id}/new", templateNewController(appCtx, webCtx).ServeHTTP)
 	router.Post("/template-set/{id}/new", templateNewSaveController(appCtx, webCtx).ServeHTTP)
+	router.Get("/template/{id}", templateController(appCtx, webCtx).ServeHTTP)
 	router.Get("/template/{id}/edit", templateEditPageController(appCtx, webCtx).ServeHTTP)
 	router.Put("/template/{id}", templateEditSaveController(appCtx, webCtx).ServeHTTP)
 	router.Delete("/template/{id}", templateDeleteController(appCtx, webCtx).ServeHTTP)
 	router.Get("/template/{id}/copy/modal", templateCopyModalController(appCtx, webCtx).ServeHTTP)
 	router.Post("/template/{id}/copy", templateCopyController(appCtx, webCtx).ServeHTTP)
+
+	registerLabelController(appCtx, webCtx, router)
+	registerHealthController(appCtx, webCtx, router)
+	registerImportController(appCtx, webCtx, router, cfg.Import)
+}
+
+// registerErrors maps the errors of TemplateSetFromParams, TemplateFromParams and ImportDefaultPARISTemplates to their HTTP status codes.
+func registerErrors(webCtx *web.Ctx) {
+	webCtx.Errors.Map(ErrInvalidUUID, http.StatusNotFound, web.ErrNotFound)
+	webCtx.Errors.Map(ErrResourceNotFound, http.StatusNotFound, web.ErrNotFound)
+	webCtx.Errors.Map(ErrUserNotPermitted, http.StatusForbidden, web.ErrForbidden)
+	webCtx.Errors.Map(ErrInvalidImport, http.StatusUnprocessableEntity, ErrInvalidImport)
+	for _, err := range sourceErrs[1:] {
+		webCtx.Errors.Map(err, http.StatusUnprocessableEntity, err)
+	}
 }
 
 func registerNavigation(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 	webCtx.Navigation.Add("template.set.list", web.NavItem{
-		URL:      "/template-set/list",
-		Name:     "harmony.menu.template-sets",
-		Position: 150,
+		URL:            "/template-set/list",
+		Name:           "harmony.menu.template-sets",
+		Position:       150,
+		ActivePrefixes: []string{"/template-set/", "/template/"},
 	})
 }
 
-func templateSetListController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
-	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
+// registerCommands adds the creation of a template set and opening each of the user's template sets to the command palette.
+func registerCommands(webCtx *web.Ctx) {
+	webCtx.Commands.Add("template.sets", func(io web.IO, query string) ([]web.Command, error) {
+		usr, err := user.FromIO(io)
+		if err != nil {
+			return nil, nil
+		}
 
-	return web.NewController(appCtx, webCtx, func(io web.IO) error {
-		ctx := io.Context()
-		templateSets, err := templateSetRepository.FindByCreatedBy(ctx, user.MustCtxUser(ctx).ID)
+		commands := []web.Command{{
+			Title:    "template.command.new-set",
+			Group:    "template.command.group",
+			URL:      "/template-set/new",
+			Position: 150,
+		}}
+
+		templateSets, err := web.MustRepository[template.SetRepository](io, template.SetRepositoryName).FindByCreatedBy(io.Context(), usr.ID)
 		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
-			return io.Error(web.ErrInternal, err)
+			return nil, err
 		}
 
-		ver, err := LatestPARISVersion("docs/templates/paris")
-		if err != nil {
-			return io.Error(ErrDefaultTemplateDoesNotExist, err)
+		for _, templateSet := range templateSets {
+			commands = append(commands, web.Command{
+				Title:     "template.command.open-set",
+				TitleArgs: []string{"name", templateSet.Name},
+				Group:     "template.command.group",
+				URL:       fmt.Sprintf("/template-set/%s/list", templateSet.ID),
+				Keywords:  []string{templateSet.Version},
+				Position:  151,
+			})
+		}
+
+		return commands, nil
+	})
+}
+
+func templateSetListController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		data, errs := templateSetListData(io, LabelsFromRequest(io.Request()))
+		if errs != nil {
+			return io.Error(errs...)
 		}
 
-		return io.Render(TemplateSetListData{
-			TemplateSets: templateSets,
-			PARISVersion: ver,
-		}, "template.set.list.page", "template/set-list-page.go.html", "template/_list-set.go.html")
+		return io.RespondNegotiated(data, "template.set.list.page", "template/set-list-page.go.html", "template/_list-set.go.html")
 	})
 }
 
 func templateSetNewController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
-		return renderNewTemplateSetPage(io, &template.SetToCreate{}, nil)
+		return renderNewTemplateSetPage(io, templateSetNewForm(&template.SetToCreate{}))
 	})
 }
 
 func templateSetNewSaveController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		templateSetRepository := web.MustRepository[template.SetRepository](io, template.SetRepositoryName)
+		form := templateSetNewForm(&template.SetToCreate{CreatedBy: user.MustFromIO(io).ID})
+
+		return web.HandleForm(io, appCtx.Validator, form, func(form *web.Form[*template.SetToCreate]) error {
+			return renderNewTemplateSetPage(io, form)
+		}, func(form *web.Form[*template.SetToCreate]) error {
+			_, err := templateSetRepository.Create(io.Context(), form.Form)
+			if err != nil {
+				return err
+			}
+
+			return io.Redirect("/template-set/list", http.StatusFound)
+		})
+	})
+}
+
+// templateSetController serves the template set as JSON to clients requesting JSON (see web.WantsJSON) along with its entity tag.
+// Other clients are redirected to the template set's list of templates.
+func templateSetController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
 
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
-		ctx := io.Context()
-
-		toCreate := &template.SetToCreate{CreatedBy: user.MustCtxUser(ctx).ID}
-		err, validationErrs := web.ReadForm(io.Request(), toCreate, appCtx.Validator)
+		templateSet, err := TemplateSetFromParams(io, templateSetRepository, "id")
 		if err != nil {
 			return io.Error(web.ErrInternal, err)
 		}
 
-		if validationErrs != nil {
-			return renderNewTemplateSetPage(io, toCreate, validationErrs)
+		if !io.WantsJSON() {
+			return io.Redirect(fmt.Sprintf("/template-set/%s/list", templateSet.ID), http.StatusFound)
 		}
 
-		_, err = templateSetRepository.Create(ctx, toCreate)
-		if err != nil {
-			return io.Error(web.ErrInternal, err)
+		if web.NotModified(io, TemplateSetETag(templateSet)) {
+			return nil
 		}
 
-		return io.Redirect("/template-set/list", http.StatusFound)
+		return io.JSON(templateSet)
 	})
 }
 
@@ -129,7 +202,7 @@ func templateSetEditFormController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Ha
 			return io.InlineError(web.ErrInternal, err)
 		}
 
-		return renderEditTemplateSetForm(io, templateSet.ToUpdate(), nil, nil)
+		return renderEditTemplateSetForm(io, templateSetEditForm(templateSet.ToUpdate(), nil))
 	})
 }
 
@@ -137,29 +210,29 @@ func templateSetEditController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handle
 	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
 
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
-		ctx := io.Context()
-
 		templateSet, err := TemplateSetFromParams(io, templateSetRepository, "id")
 		if err != nil {
 			return io.InlineError(web.ErrInternal, err)
 		}
 
-		toUpdate := templateSet.ToUpdate()
-		err, validationErrs := web.ReadForm(io.Request(), toUpdate, appCtx.Validator)
-		if err != nil {
-			return io.InlineError(web.ErrInternal, err)
+		if err := web.CheckIfMatch(io, TemplateSetETag(templateSet)); err != nil {
+			return io.InlineError(err)
 		}
 
-		if validationErrs != nil {
-			return renderEditTemplateSetForm(io, toUpdate, nil, validationErrs)
+		form := templateSetEditForm(templateSet.ToUpdate(), nil)
+		render := func(form *web.Form[*template.SetToUpdate]) error {
+			return renderEditTemplateSetForm(io, form)
 		}
 
-		templateSet, err = templateSetRepository.Update(ctx, toUpdate)
-		if err != nil {
-			return io.InlineError(web.ErrInternal, err)
-		}
+		return web.HandleForm(io, appCtx.Validator, form, render, func(form *web.Form[*template.SetToUpdate]) error {
+			templateSet, err := templateSetRepository.Update(io.Context(), form.Form)
+			if err != nil {
+				return err
+			}
+			io.Response().Header().Set("ETag", TemplateSetETag(templateSet))
 
-		return renderEditTemplateSetForm(io, templateSet.ToUpdate(), []string{"template.set.edit.updated"}, nil)
+			return render(templateSetEditForm(templateSet.ToUpdate(), []string{"template.set.edit.updated"}))
+		})
 	})
 }
 
@@ -172,20 +245,7 @@ func templateSetDeleteController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Hand
 			return err
 		}
 
-		templateSets, err := templateSetsForList(io, templateSetRepository)
-		if err != nil {
-			return err
-		}
-
-		ver, err := LatestPARISVersion("docs/templates/paris")
-		if err != nil {
-			return io.InlineError(ErrDefaultTemplateDoesNotExist, err)
-		}
-
-		return io.Render(TemplateSetListData{
-			TemplateSets: templateSets,
-			PARISVersion: ver,
-		}, "template.set.list", "template/_list-set.go.html")
+		return renderTemplateSetList(io, nil)
 	})
 }
 
@@ -258,6 +318,29 @@ func templateNewSaveController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handle
 	})
 }
 
+// templateController serves the template as JSON to clients requesting JSON (see web.WantsJSON) along with its entity tag.
+// Other clients are redirected to the template's edit page.
+func templateController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		tmpl, err := TemplateFromParams(io, templateRepository, "id")
+		if err != nil {
+			return io.Error(web.ErrInternal, err)
+		}
+
+		if !io.WantsJSON() {
+			return io.Redirect(fmt.Sprintf("/template/%s/edit", tmpl.ID), http.StatusFound)
+		}
+
+		if web.NotModified(io, TemplateETag(tmpl)) {
+			return nil
+		}
+
+		return io.JSON(tmpl)
+	})
+}
+
 func templateEditPageController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
 
@@ -267,7 +350,7 @@ func templateEditPageController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handl
 			return io.Error(web.ErrInternal, err)
 		}
 
-		return renderEditTemplatePage(io, tmpl.ToUpdate(), nil, nil)
+		return renderEditTemplatePage(io, tmpl.ToUpdate(), nil, nil, lintTemplate(tmpl, appCtx.EventManager, appCtx.Logger))
 	})
 }
 
@@ -282,23 +365,30 @@ func templateEditSaveController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handl
 			return io.InlineError(web.ErrInternal, err)
 		}
 
+		if err := web.CheckIfMatch(io, TemplateETag(tmpl)); err != nil {
+			return io.InlineError(err)
+		}
+
 		toUpdate, validationErrs, err := readValidTemplateUpdateForm(io, tmpl, appCtx.Validator, appCtx.EventManager, appCtx.Logger)
 		if err != nil {
 			return io.InlineError(web.ErrInternal, err)
 		}
 
 		if validationErrs != nil {
-			return renderEditTemplateForm(io, toUpdate, nil, validationErrs)
+			return renderEditTemplateForm(io, toUpdate, nil, validationErrs, nil)
 		}
 
 		tmpl, err = templateRepository.Update(ctx, toUpdate)
 		if err != nil && errors.Is(err, template.ErrTemplateConfigMissingInfo) {
-			return renderEditTemplateForm(io, toUpdate, nil, []error{ErrTemplateConfigIncomplete})
+			return renderEditTemplateForm(io, toUpdate, nil, []error{ErrTemplateConfigIncomplete}, nil)
 		} else if err != nil {
 			return io.InlineError(web.ErrInternal, err)
 		}
 
-		return renderEditTemplateForm(io, tmpl.ToUpdate(), []string{"template.edit.updated"}, nil)
+		template.PublishTemplateUpdated(appCtx.EventManager, tmpl.ID, false)
+		io.Response().Header().Set("ETag", TemplateETag(tmpl))
+
+		return renderEditTemplateForm(io, tmpl.ToUpdate(), []string{"template.edit.updated"}, nil, lintTemplate(tmpl, appCtx.EventManager, appCtx.Logger))
 	})
 }
 
@@ -317,6 +407,8 @@ func templateDeleteController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler
 			return io.InlineError(web.ErrInternal, err)
 		}
 
+		template.PublishTemplateUpdated(appCtx.EventManager, tmpl.ID, true)
+
 		templateSet, err := templateSetRepository.FindByID(io.Context(), tmpl.TemplateSet)
 		if err != nil {
 			return io.InlineError(web.ErrInternal, err)
@@ -415,28 +507,18 @@ func templateCopyController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 func templateSetImportDefaultPARISController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	templateSetRepository := util.UnwrapType[template.SetRepository](appCtx.Repository(template.SetRepositoryName))
 	templateRepository := util.UnwrapType[template.Repository](appCtx.Repository(template.RepositoryName))
+	pipeline := template.NewValidationPipeline(appCtx.EventManager, appCtx.Logger)
 
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
 		ctx := io.Context()
 
-		err := ImportDefaultPARISTemplates(ctx, "docs/templates/paris", templateSetRepository, templateRepository, user.MustCtxUser(ctx).ID)
+		templateSet, err := ImportDefaultPARISTemplates(ctx, PARISTemplatesDir(ctx), templateSetRepository, templateRepository, pipeline, user.MustCtxUser(ctx).ID)
 		if err != nil {
 			return io.InlineError(err)
 		}
 
-		templateSets, err := templateSetRepository.FindByCreatedBy(ctx, user.MustCtxUser(ctx).ID)
-		if err != nil {
-			return io.InlineError(web.ErrInternal, err)
-		}
-
-		ver, err := LatestPARISVersion("docs/templates/paris")
-		if err != nil {
-			return io.InlineError(ErrDefaultTemplateDoesNotExist, err)
-		}
+		template.PublishSetImported(ctx, appCtx.EventManager, templateSet)
 
-		return io.Render(TemplateSetListData{
-			TemplateSets: templateSets,
-			PARISVersion: ver,
-		}, "template.set.list", "template/_list-set.go.html")
+		return renderTemplateSetList(io, nil)
 	})
 }
@@ -48,6 +48,7 @@ func Adapters() map[string]OAuthUserAdapter {
 // The email address of the user is used to find the user in the database.
 // If the user doesn't exist, the OAuthUserAdapter.CreateUser creates the user.
 // After creating the user, the user is logged in and LoginWithAdapter returns the session.
+// The LoginOptions are passed to Login.
 func LoginWithAdapter(
 	ctx context.Context,
 	token *oauth2.Token,
@@ -55,6 +56,7 @@ func LoginWithAdapter(
 	adapter OAuthUserAdapter,
 	userRepo Repository,
 	sessionStore SessionRepository,
+	opts ...LoginOption,
 ) (*Session, error) {
 	email, err := adapter.Email(ctx, token, provider, http.DefaultClient)
 	if err != nil {
@@ -67,7 +69,7 @@ func LoginWithAdapter(
 	}
 
 	if user != nil {
-		session, err := Login(ctx, user, sessionStore)
+		session, err := Login(ctx, user, sessionStore, opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -85,7 +87,7 @@ func LoginWithAdapter(
 		return nil, err
 	}
 
-	session, err := Login(ctx, user, sessionStore)
+	session, err := Login(ctx, user, sessionStore, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -4,15 +4,23 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/audit"
 	"github.com/org-harmony/harmony/src/core/auth"
+	"github.com/org-harmony/harmony/src/core/feature"
 	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/org-harmony/harmony/src/core/trace"
 	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/web"
 	"net/http"
-	"time"
 )
 
-const MiddlewarePkg = "user.middleware"
+// MiddlewarePkg is the package name used for logging in the user middleware.
+const MiddlewarePkg = "app.user.middleware"
+
+// auditedContextKey marks requests of impersonation sessions already recorded in the audit log.
+// Middlewares stacked on a route (e.g. on the root router and a route group) record each request only once.
+const auditedContextKey = "harmony-app-user-impersonation-audited"
 
 // MiddlewareOptions define possible options for Middleware they should be set through MiddlewareOption.
 type MiddlewareOptions struct {
@@ -20,6 +28,8 @@ type MiddlewareOptions struct {
 	notLoggedInHandler http.Handler
 	sessionStore       SessionRepository
 	userRepository     Repository
+	auditRepository    audit.Repository
+	privileged         func(r *http.Request, user *User) bool
 	logger             trace.Logger
 }
 
@@ -30,10 +40,17 @@ var ErrNotInContext = errors.New("user not in context")
 type MiddlewareOption func(*MiddlewareOptions)
 
 // RedirectToLogin redirects the user to the login page.
-// This is the default NotLoggedInHandler.
+// This is the default NotLoggedInHandler. HTMX requests (e.g. after the session expired) receive 401
+// with the web.HXRedirectHeader to load the login page as a full page instead of swapping it into the page.
 // TODO add a cookie to redirect the user back to the page he was on before logging-in.
 func RedirectToLogin(w http.ResponseWriter, r *http.Request) {
-	http.Redirect(w, r, "/auth/login", http.StatusTemporaryRedirect)
+	if !web.IsHTMX(r) {
+		http.Redirect(w, r, "/auth/login", http.StatusTemporaryRedirect)
+		return
+	}
+
+	w.Header().Set(web.HXRedirectHeader, "/auth/login")
+	w.WriteHeader(http.StatusUnauthorized)
 }
 
 // AllowAnonymous lets not logged-in users pass the middleware.
@@ -70,9 +87,31 @@ func WithLogger(logger trace.Logger) MiddlewareOption {
 	}
 }
 
+// AuditImpersonation sets the middleware to record all requests of impersonation sessions in the audit log with the ids
+// of the administrator and the impersonated user. Each request is recorded once, even if multiple middlewares with
+// the option are stacked on its route. Failing to record a request is logged but does not fail the request.
+func AuditImpersonation(repository audit.Repository) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.auditRepository = repository
+	}
+}
+
+// RotateOnPrivilegeChange sets the middleware to rotate the session (see RotateSession) when the privileges of its user
+// change, e.g. the user gained or lost the admin role. The privileged function reports whether the request's user has
+// elevated privileges, it is compared to SessionMeta.Privileged. The new session cookie is set on the response and
+// replaces the cookie of the request for the following middlewares and handlers. Impersonation sessions are not rotated.
+// Failing to rotate the session is logged but does not fail the request.
+func RotateOnPrivilegeChange(privileged func(r *http.Request, user *User) bool) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.privileged = privileged
+	}
+}
+
 // Middleware is the auth middleware that checks if a user is logged in and sets the user in the request context.
 // If the user is not logged in and the middleware requires it, the NotLoggedInHandler is called (defaults to RedirectToLogin).
 // Then it should be safe to use the CtxUser function without it returning an error.
+// The user's ID is tagged on the request's trace.ReportScope to be included in error reports.
+// For impersonation sessions the Impersonator is set in the context as well, see CtxImpersonator.
 //
 // If it is required for anonymous users to pass the middleware, use the AllowAnonymous option.
 //
@@ -87,7 +126,7 @@ func Middleware(sessionStore SessionRepository, opts ...MiddlewareOption) func(n
 
 	return func(next http.Handler) http.Handler {
 		f := func(w http.ResponseWriter, r *http.Request) {
-			user, err := LoggedInUser(r, m.sessionStore)
+			session, err := LoggedInSession(r, m.sessionStore)
 			if err != nil && m.requireAuth {
 				m.notLoggedInHandler.ServeHTTP(w, r)
 				return
@@ -98,6 +137,7 @@ func Middleware(sessionStore SessionRepository, opts ...MiddlewareOption) func(n
 				return
 			}
 
+			user := &session.Payload
 			if m.userRepository != nil {
 				user, err = m.userRepository.FindByID(r.Context(), user.ID)
 				if err != nil {
@@ -106,8 +146,17 @@ func Middleware(sessionStore SessionRepository, opts ...MiddlewareOption) func(n
 				}
 			}
 
+			r = m.rotateOnPrivilegeChange(w, r, session, user)
+
 			withUser := context.WithValue(r.Context(), ContextKey, user)
 			r = r.WithContext(withUser)
+			trace.SetReportTag(withUser, "user_id", user.ID.String())
+
+			if impersonator := session.Meta.Impersonator; impersonator != nil {
+				r = r.WithContext(context.WithValue(r.Context(), ImpersonatorContextKey, impersonator))
+				trace.SetReportTag(withUser, "impersonator_id", impersonator.UserID.String())
+				r = m.auditImpersonatedRequest(r, user, impersonator)
+			}
 
 			next.ServeHTTP(w, r)
 		}
@@ -116,27 +165,39 @@ func Middleware(sessionStore SessionRepository, opts ...MiddlewareOption) func(n
 	}
 }
 
-// LoggedInMiddleware is a convenience function that creates a middleware with the AlwaysFetchUser and WithLogger options.
+// LoggedInMiddleware is a convenience function that creates a middleware with the AlwaysFetchUser, AuditImpersonation and WithLogger options.
 // It looks all the required dependencies up in the passed-in hctx.AppCtx. Extra options can be passed in.
 func LoggedInMiddleware(appCtx *hctx.AppCtx, opts ...MiddlewareOption) func(next http.Handler) http.Handler {
 	userRepository := util.UnwrapType[Repository](appCtx.Repository(RepositoryName))
-	opts = append(opts, AlwaysFetchUser(userRepository), WithLogger(appCtx.Logger))
+	auditRepository := util.UnwrapType[audit.Repository](appCtx.Repository(audit.RepositoryName))
+	opts = append(opts, AlwaysFetchUser(userRepository), AuditImpersonation(auditRepository), WithLogger(appCtx.Logger))
 
 	return Middleware(SessionStore(appCtx), opts...)
 }
 
 // LoggedInUser reads the session id from the request, reads the user from the passed in session store and returns it.
-// If the user is not logged in, an error is returned.
+// If the user is not logged in, an error is returned. Expired sessions are extended unless they exceeded their idle
+// or absolute timeout (see Session.IsHardExpired), then they are deleted and ErrHardSessionExpiry is returned.
 //
 // Important: The function does not look the user up in the database. It simply returns the user from the session.
 func LoggedInUser(r *http.Request, sessionStore SessionRepository) (*User, error) {
+	userSession, err := LoggedInSession(r, sessionStore)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userSession.Payload, nil
+}
+
+// LoggedInSession returns the valid session of the request's logged-in user as described by LoggedInUser.
+func LoggedInSession(r *http.Request, sessionStore SessionRepository) (*Session, error) {
 	userSession, err := SessionFromRequest(r, sessionStore)
 	if err != nil {
 		return nil, err
 	}
 
-	if userSession.IsExpired() {
-		err = TryExtendSession(r.Context(), userSession, time.Hour, sessionStore)
+	if userSession.IsExpired() || userSession.IsHardExpired() {
+		err = TryExtendSession(r.Context(), userSession, userSession.Extension(), sessionStore)
 		if err != nil && !errors.Is(err, ErrHardSessionExpiry) {
 			return nil, err
 		}
@@ -151,7 +212,7 @@ func LoggedInUser(r *http.Request, sessionStore SessionRepository) (*User, error
 		}
 	}
 
-	return &userSession.Payload, nil
+	return userSession, nil
 }
 
 // SessionFromRequest returns the user session from the request.
@@ -201,6 +262,13 @@ func MustCtxUser(ctx context.Context) *User {
 	return u
 }
 
+// IsLoggedIn returns true if the request's context contains a user, see CtxUser.
+// It can be used to bypass caches for pages rendering user specific content (web.CachePolicy.Skip).
+func IsLoggedIn(r *http.Request) bool {
+	_, err := CtxUser(r.Context())
+	return err == nil
+}
+
 // CtxUser returns the user from the context. It will return ErrNotInContext if the user is not in the context.
 // This is ideally paired with the user.Middleware which sets the user in the context with the key user.ContextKey.
 // CtxUser looks for the user.ContextKey in the context.
@@ -213,6 +281,12 @@ func CtxUser(ctx context.Context) (*User, error) {
 	return u, nil
 }
 
+// CtxImpersonator returns the administrator impersonating the logged-in user. It returns false if the request is
+// not made in an impersonation session. The Impersonator is set in the context by the Middleware with the key ImpersonatorContextKey.
+func CtxImpersonator(ctx context.Context) (*Impersonator, bool) {
+	return util.CtxValue[*Impersonator](ctx, ImpersonatorContextKey)
+}
+
 func defaultUserMiddlewareOptions(sessionStore SessionRepository) *MiddlewareOptions {
 	return &MiddlewareOptions{
 		requireAuth:        true,
@@ -242,3 +316,89 @@ func (m *MiddlewareOptions) handleUserNotFound(w http.ResponseWriter, r *http.Re
 	auth.ClearSession(w, SessionCookieName)
 	m.notLoggedInHandler.ServeHTTP(w, r)
 }
+
+// rotateOnPrivilegeChange rotates the session if the privileges of the user changed, see RotateOnPrivilegeChange.
+// The returned request carries the new session cookie.
+func (m *MiddlewareOptions) rotateOnPrivilegeChange(w http.ResponseWriter, r *http.Request, session *Session, user *User) *http.Request {
+	if m.privileged == nil || session.Meta.Impersonator != nil {
+		return r
+	}
+
+	privileged := m.privileged(r, user)
+	if privileged == session.Meta.Privileged {
+		return r
+	}
+
+	session.Meta.Privileged = privileged
+	err := RotateSession(r.Context(), session, m.sessionStore)
+	if err != nil {
+		m.logger.Error(MiddlewarePkg, "failed to rotate session after privilege change", err, "user_id", user.ID.String())
+		return r
+	}
+
+	session.SetCookie(w)
+
+	return withSessionCookie(r, session.ID)
+}
+
+// withSessionCookie returns a copy of the request with the session id as the value of its SessionCookieName cookie.
+func withSessionCookie(r *http.Request, sessionID uuid.UUID) *http.Request {
+	cookies := r.Cookies()
+	r = r.Clone(r.Context())
+	r.Header.Del("Cookie")
+
+	for _, cookie := range cookies {
+		if cookie.Name == SessionCookieName {
+			cookie.Value = sessionID.String()
+		}
+		r.AddCookie(cookie)
+	}
+
+	return r
+}
+
+// auditImpersonatedRequest records the request of an impersonation session in the audit log unless it was recorded
+// by another middleware before. The returned request is marked as recorded.
+func (m *MiddlewareOptions) auditImpersonatedRequest(r *http.Request, user *User, impersonator *Impersonator) *http.Request {
+	if m.auditRepository == nil {
+		return r
+	}
+	if audited, _ := util.CtxValue[bool](r.Context(), auditedContextKey); audited {
+		return r
+	}
+
+	_, err := m.auditRepository.Create(r.Context(), &audit.Entry{
+		ActorID:        impersonator.UserID,
+		ImpersonatedID: &user.ID,
+		Action:         audit.ActionImpersonatedRequest,
+		Details:        map[string]string{"method": r.Method, "path": r.URL.Path},
+	})
+	if err != nil {
+		m.logger.Error(MiddlewarePkg, "failed to record impersonated request in audit log", err, "path", r.URL.Path)
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), auditedContextKey, true))
+}
+
+// FeatureSubject returns the logged-in user of the request as the subject feature flags are evaluated for (see feature.Middleware).
+// It returns an anonymous subject if no user is logged in. The subject belongs to the request's tenant (see tenant.ID).
+func FeatureSubject(r *http.Request) feature.Subject {
+	u, err := CtxUser(r.Context())
+	if err != nil {
+		return feature.Subject{Tenant: tenant.ID(r.Context())}
+	}
+
+	return feature.Subject{ID: u.ID.String(), Email: u.Email, Tenant: tenant.ID(r.Context())}
+}
+
+// FromIO returns the logged-in user of the request. It will return ErrNotInContext if no user is logged in.
+// Tests can log in a user for a single request by setting the user in the request's context with the key ContextKey.
+func FromIO(io web.IO) (*User, error) {
+	return CtxUser(io.Context())
+}
+
+// MustFromIO returns the logged-in user of the request. It will panic if no user is logged in.
+// It is safe to call this function if the user is required to be logged in for the route, see MustCtxUser.
+func MustFromIO(io web.IO) *User {
+	return MustCtxUser(io.Context())
+}
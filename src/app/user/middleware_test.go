@@ -1,7 +1,11 @@
 package user
 
 import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/audit"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/web"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -49,6 +53,26 @@ func TestMiddleware_DefaultNotLoggedInHandler(t *testing.T) {
 	assert.Equal(t, "/auth/login", recorder.Header().Get("Location"))
 }
 
+func TestMiddleware_DefaultNotLoggedInHandlerHTMX(t *testing.T) {
+	registerCleanupUserAndSessionTables(t)
+	setupMockUserAndSession(t)
+
+	middleware := Middleware(sessionStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "Should not be called")
+	})
+	wrappedHandler := middleware(handler)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(web.HXRequestHeader, "true")
+	recorder := httptest.NewRecorder()
+
+	wrappedHandler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, "/auth/login", recorder.Header().Get(web.HXRedirectHeader))
+	assert.Empty(t, recorder.Header().Get("Location"))
+}
+
 func TestMiddleware_NotLoggedInHandler(t *testing.T) {
 	registerCleanupUserAndSessionTables(t)
 	setupMockUserAndSession(t)
@@ -126,6 +150,137 @@ func TestMiddleware_AlwaysFetchUser(t *testing.T) {
 	assert.ErrorIs(t, err, persistence.ErrNotFound)
 }
 
+func TestMiddleware_Impersonation(t *testing.T) {
+	registerCleanupUserAndSessionTables(t)
+	user, session := setupMockUserAndSession(t)
+	impersonator := &Impersonator{UserID: uuid.New(), Email: "admin@example.com", SessionID: uuid.New()}
+	session.Meta.Impersonator = impersonator
+	require.NoError(t, sessionStore.Write(ctx, session.ID, session))
+
+	auditRepository := &recordingAuditRepository{}
+	middleware := Middleware(sessionStore, AuditImpersonation(auditRepository))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxImpersonator, ok := CtxImpersonator(r.Context())
+		require.True(t, ok)
+		assert.Equal(t, impersonator.UserID, ctxImpersonator.UserID)
+		assert.Equal(t, user.ID, MustCtxUser(r.Context()).ID)
+	})
+	wrappedHandler := middleware(handler)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req := httptest.NewRequest(method, "/template/set/new", nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: session.ID.String()})
+		recorder := httptest.NewRecorder()
+
+		wrappedHandler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	require.Len(t, auditRepository.entries, 2, "all requests are recorded")
+	entry := auditRepository.entries[1]
+	assert.Equal(t, audit.ActionImpersonatedRequest, entry.Action)
+	assert.Equal(t, impersonator.UserID, entry.ActorID)
+	assert.Equal(t, user.ID, *entry.ImpersonatedID)
+	assert.Equal(t, map[string]string{"method": http.MethodPost, "path": "/template/set/new"}, entry.Details)
+	assert.Equal(t, http.MethodGet, auditRepository.entries[0].Details["method"])
+}
+
+func TestMiddleware_ImpersonationStacked(t *testing.T) {
+	registerCleanupUserAndSessionTables(t)
+	_, session := setupMockUserAndSession(t)
+	session.Meta.Impersonator = &Impersonator{UserID: uuid.New(), Email: "admin@example.com", SessionID: uuid.New()}
+	require.NoError(t, sessionStore.Write(ctx, session.ID, session))
+
+	// like the root router's and a route group's middleware of the web application
+	auditRepository := &recordingAuditRepository{}
+	root := Middleware(sessionStore, AllowAnonymous, AuditImpersonation(auditRepository))
+	group := Middleware(sessionStore, AuditImpersonation(auditRepository))
+	wrappedHandler := root(group(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req := httptest.NewRequest(method, "/template/set/new", nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: session.ID.String()})
+		wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, auditRepository.entries, 2, "each request is recorded once")
+	assert.Equal(t, http.MethodGet, auditRepository.entries[0].Details["method"])
+	assert.Equal(t, http.MethodPost, auditRepository.entries[1].Details["method"])
+}
+
+func TestMiddleware_RotateOnPrivilegeChange(t *testing.T) {
+	registerCleanupUserAndSessionTables(t)
+	_, session := setupMockUserAndSession(t)
+	previousID := session.ID
+
+	privileged := true
+	root := Middleware(sessionStore, AllowAnonymous, RotateOnPrivilegeChange(func(r *http.Request, u *User) bool {
+		return privileged
+	}))
+	group := Middleware(sessionStore)
+	var handledID uuid.UUID
+	wrappedHandler := root(group(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handledID, _ = SessionIDFromRequest(r)
+	})))
+
+	serve := func(sessionID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID.String()})
+		recorder := httptest.NewRecorder()
+		wrappedHandler.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	recorder := serve(previousID)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+	rotatedID := uuid.MustParse(cookies[0].Value)
+	assert.NotEqual(t, previousID, rotatedID, "gaining privileges rotates the session")
+	assert.Equal(t, rotatedID, handledID, "the following middlewares and handlers read the rotated session")
+
+	recorder = serve(previousID)
+	assert.Equal(t, http.StatusTemporaryRedirect, recorder.Code, "the previous session id is rejected")
+
+	recorder = serve(rotatedID)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Result().Cookies(), "unchanged privileges do not rotate the session")
+
+	privileged = false
+	recorder = serve(rotatedID)
+	require.Len(t, recorder.Result().Cookies(), 1, "losing privileges rotates the session")
+	assert.Equal(t, http.StatusTemporaryRedirect, serve(rotatedID).Code)
+}
+
+func TestCtxImpersonator(t *testing.T) {
+	_, ok := CtxImpersonator(context.Background())
+	assert.False(t, ok)
+
+	impersonator := &Impersonator{UserID: uuid.New()}
+	actual, ok := CtxImpersonator(context.WithValue(context.Background(), ImpersonatorContextKey, impersonator))
+	assert.True(t, ok)
+	assert.Equal(t, impersonator, actual)
+}
+
+// recordingAuditRepository is an audit.Repository recording the created entries in memory.
+type recordingAuditRepository struct {
+	entries []*audit.Entry
+}
+
+func (r *recordingAuditRepository) RepositoryName() string {
+	return audit.RepositoryName
+}
+
+func (r *recordingAuditRepository) Create(ctx context.Context, entry *audit.Entry) (*audit.Entry, error) {
+	r.entries = append(r.entries, entry)
+	return entry, nil
+}
+
+func (r *recordingAuditRepository) FindRecent(ctx context.Context, limit int) ([]*audit.Entry, error) {
+	return r.entries, nil
+}
+
 func setupMockUserAndSession(t *testing.T) (*User, *Session) {
 	user, err := userRepo.Create(ctx, fooUserToCreate())
 	require.NoError(t, err)
@@ -0,0 +1,110 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+)
+
+// PreferenceRepositoryName is the name of the user preference repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+const PreferenceRepositoryName = "UserPreferenceRepository"
+
+// PreferenceRepository persists preferences of a user's profile, e.g. the user's UI settings of a template.
+// A preference is a JSON value stored under a key per user, modules should prefix their keys, e.g. "template.ui.<id>".
+// All methods are scoped to the tenant of the context (see tenant.ID). PreferenceRepository is safe for concurrent use by multiple goroutines.
+type PreferenceRepository interface {
+	persistence.Repository
+
+	// Find unmarshals the user's preference stored under the key into v.
+	// It returns persistence.ErrNotFound if the user has no such preference and persistence.ErrReadRow for any other error.
+	Find(ctx context.Context, userID uuid.UUID, key string, v any) error
+	// Save stores v as the user's preference under the key, an existing preference is replaced.
+	// It returns persistence.ErrUpdate if the preference could not be saved.
+	Save(ctx context.Context, userID uuid.UUID, key string, v any) error
+	// Delete deletes the user's preference stored under the key. It returns persistence.ErrDelete if the preference could not be deleted.
+	Delete(ctx context.Context, userID uuid.UUID, key string) error
+}
+
+// PGPreferenceRepository is the user preference repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGPreferenceRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewPreferenceRepository constructs a new PGPreferenceRepository with the passed in database connection pool.
+func NewPreferenceRepository(db *pgxpool.Pool) PreferenceRepository {
+	return &PGPreferenceRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGPreferenceRepository) RepositoryName() string {
+	return PreferenceRepositoryName
+}
+
+// Find unmarshals the user's preference stored under the key into v.
+// It returns persistence.ErrNotFound if the user has no such preference and persistence.ErrReadRow for any other error.
+func (r *PGPreferenceRepository) Find(ctx context.Context, userID uuid.UUID, key string, v any) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	var value []byte
+	err := r.db.QueryRow(
+		ctx,
+		"SELECT value FROM user_preferences WHERE user_id = $1 AND key = $2 AND tenant_id = $3",
+		userID, key, tenant.ID(ctx),
+	).Scan(&value)
+	if err != nil {
+		return persistence.PGReadErr(err)
+	}
+
+	if err := json.Unmarshal(value, v); err != nil {
+		return errors.Join(persistence.ErrReadRow, err)
+	}
+
+	return nil
+}
+
+// Save stores v as the user's preference under the key, an existing preference is replaced.
+// It returns persistence.ErrUpdate if the preference could not be saved.
+func (r *PGPreferenceRepository) Save(ctx context.Context, userID uuid.UUID, key string, v any) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	value, err := json.Marshal(v)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, err)
+	}
+
+	_, err = r.db.Exec(
+		ctx,
+		`INSERT INTO user_preferences (user_id, key, value, tenant_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, key) DO UPDATE SET value = excluded.value, updated_at = current_timestamp`,
+		userID, key, value, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// Delete deletes the user's preference stored under the key. It returns persistence.ErrDelete if the preference could not be deleted.
+func (r *PGPreferenceRepository) Delete(ctx context.Context, userID uuid.UUID, key string) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(
+		ctx,
+		"DELETE FROM user_preferences WHERE user_id = $1 AND key = $2 AND tenant_id = $3",
+		userID, key, tenant.ID(ctx),
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
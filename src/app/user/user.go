@@ -1,28 +1,34 @@
 package user
 
-// TODO add auto-refresh of sessions -> soft and hard expiry
-// TODO add refresh token for remember me functionality
-// TODO add logout everywhere functionality -> delete all sessions for a user
 // TODO remove expired sessions from database job
 
 import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/auth"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"time"
 )
 
 // RepositoryName is the name of the user repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
 const RepositoryName = "UserRepository"
 
+// userColumns is the column list of the users table in the order scanned by scanUser.
+const userColumns = "id, email, firstname, lastname, created_at, updated_at"
+
 // ContextKey is the key for the user in the context.
 // Example:
 //
 //	user := ctx.Value(user.ContextKey).(*user.User)
 const ContextKey = "harmony-app-user"
 
+// ImpersonatorContextKey is the key for the Impersonator in the context of requests of impersonation sessions, see CtxImpersonator.
+const ImpersonatorContextKey = "harmony-app-user-impersonator"
+
 // User is the user entity.
 // The User is also part of the Session which is stored in the session store.
 // The Session.ID is stored in a cookie on the client the default session store is the PGUserSessionRepository.
@@ -56,10 +62,12 @@ type ToUpdate struct {
 
 // PGUserRepository is the user repository for postgres. It holds a reference to the database connection pool.
 type PGUserRepository struct {
+	persistence.Timeout
 	db *pgxpool.Pool
 }
 
 // Repository is the user repository. It contains all methods to interact with the user table in the database.
+// All methods are scoped to the tenant of the context (see tenant.ID), a user's email is unique per tenant.
 // Repository is safe for concurrent use by multiple goroutines.
 type Repository interface {
 	persistence.Repository
@@ -98,33 +106,34 @@ func (r *PGUserRepository) RepositoryName() string {
 
 // FindByEmail returns a user by email. Returns ErrNotFound if no user was found.
 func (r *PGUserRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
-	user := &User{}
-	err := r.db.QueryRow(ctx, "SELECT id, email, firstname, lastname, created_at, updated_at FROM users WHERE email = $1", email).
-		Scan(&user.ID, &user.Email, &user.Firstname, &user.Lastname, &user.CreatedAt, &user.UpdatedAt)
-
-	if err != nil {
-		return nil, persistence.PGReadErr(err)
-	}
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-	return user, nil
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE email = $1 AND tenant_id = $2",
+		email, tenant.ID(ctx),
+	), scanUser)
 }
 
 // FindByID returns a user by id. Returns ErrNotFound if no user was found.
 func (r *PGUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*User, error) {
-	user := &User{}
-	err := r.db.QueryRow(ctx, "SELECT id, email, firstname, lastname, created_at, updated_at FROM users WHERE id = $1", id).
-		Scan(&user.ID, &user.Email, &user.Firstname, &user.Lastname, &user.CreatedAt, &user.UpdatedAt)
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-	if err != nil {
-		return nil, persistence.PGReadErr(err)
-	}
-
-	return user, nil
+	return persistence.PGScanRow(r.db.QueryRow(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE id = $1 AND tenant_id = $2",
+		id, tenant.ID(ctx),
+	), scanUser)
 }
 
 // Create creates a new user and return it. CreatedAt and id are set.
 // Returns ErrInsert if the user could not be created.
 func (r *PGUserRepository) Create(ctx context.Context, user *ToCreate) (*User, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
 	newUser := &User{
 		ID:        uuid.New(),
 		Email:     user.Email,
@@ -135,12 +144,12 @@ func (r *PGUserRepository) Create(ctx context.Context, user *ToCreate) (*User, e
 
 	_, err := r.db.Exec(
 		ctx,
-		"INSERT INTO users (id, email, firstname, lastname, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)",
-		newUser.ID, newUser.Email, newUser.Firstname, newUser.Lastname, newUser.CreatedAt, newUser.UpdatedAt,
+		"INSERT INTO users ("+userColumns+", tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		newUser.ID, newUser.Email, newUser.Firstname, newUser.Lastname, newUser.CreatedAt, newUser.UpdatedAt, tenant.ID(ctx),
 	)
 
 	if err != nil {
-		return nil, errors.Join(persistence.ErrInsert, err)
+		return nil, errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
 	}
 
 	return newUser, nil
@@ -149,21 +158,19 @@ func (r *PGUserRepository) Create(ctx context.Context, user *ToCreate) (*User, e
 // Update updates a user and returns it. Returns ErrUpdate if the user could not be updated.
 // UpdatedAt is set.
 func (r *PGUserRepository) Update(ctx context.Context, user *ToUpdate) (*User, error) {
-	updateUser := &User{
-		ID: user.ID(),
-	}
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
 
-	err := r.db.QueryRow(
+	updateUser, err := scanUser(r.db.QueryRow(
 		ctx,
 		`UPDATE users 
 		SET email = $1, firstname = $2, lastname = $3, updated_at = NOW() 
-		WHERE id = $4 
-		RETURNING email, firstname, lastname, created_at, updated_at`,
-		user.Email, user.Firstname, user.Lastname, user.ID(),
-	).Scan(&updateUser.Email, &updateUser.Firstname, &updateUser.Lastname, &updateUser.CreatedAt, &updateUser.UpdatedAt)
-
+		WHERE id = $4 AND tenant_id = $5
+		RETURNING `+userColumns,
+		user.Email, user.Firstname, user.Lastname, user.ID(), tenant.ID(ctx),
+	))
 	if err != nil {
-		return nil, errors.Join(persistence.ErrUpdate, err)
+		return nil, errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
 	}
 
 	return updateUser, nil
@@ -172,22 +179,106 @@ func (r *PGUserRepository) Update(ctx context.Context, user *ToUpdate) (*User, e
 // Delete deletes a user by id.
 // Returns ErrDelete if the user could not be deleted.
 func (r *PGUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM users WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
 	if err != nil {
-		return errors.Join(persistence.ErrDelete, err)
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
 	}
 
 	return nil
 }
 
+// LoginOptions define possible options for Login they should be set through LoginOption.
+type LoginOptions struct {
+	sessionCfg   *auth.SessionCfg
+	rememberMe   bool
+	deviceName   string
+	replaces     uuid.UUID
+	impersonator *Impersonator
+}
+
+// LoginOption modifies LoginOptions and is used to set options for Login.
+type LoginOption func(*LoginOptions)
+
+// WithSessionCfg sets the configuration the timeouts of the new session are taken from.
+// Without it, the session uses the defaults of the auth package.
+func WithSessionCfg(cfg *auth.SessionCfg) LoginOption {
+	return func(o *LoginOptions) {
+		o.sessionCfg = cfg
+	}
+}
+
+// RememberMe creates a long-lived session if remember me is enabled in the session configuration (see auth.RememberMeCfg).
+func RememberMe(o *LoginOptions) {
+	o.rememberMe = true
+}
+
+// WithDeviceName sets the name of the device the user logs in with, see DeviceName.
+func WithDeviceName(name string) LoginOption {
+	return func(o *LoginOptions) {
+		o.deviceName = name
+	}
+}
+
+// ReplacesSession deletes the session the request was made with before logging in.
+// Together with the new session id of each login this prevents session fixation.
+func ReplacesSession(id uuid.UUID) LoginOption {
+	return func(o *LoginOptions) {
+		o.replaces = id
+	}
+}
+
+// Impersonating creates an impersonation session for the impersonator (see SessionMeta.Impersonator).
+// Impersonation sessions are never long-lived and end after ImpersonationTimeout at the latest.
+func Impersonating(impersonator *Impersonator) LoginOption {
+	return func(o *LoginOptions) {
+		o.impersonator = impersonator
+	}
+}
+
 // Login creates a new user session and stores it in the session store.
 // Thereby, the user will be detected as logged in from the application.
-func Login(ctx context.Context, user *User, sessionStore SessionRepository) (*Session, error) {
-	session := NewUserSession(user, time.Hour)
+// Each login creates a session with a new id, a replaced session (see ReplacesSession) is deleted.
+func Login(ctx context.Context, user *User, sessionStore SessionRepository, opts ...LoginOption) (*Session, error) {
+	o := &LoginOptions{sessionCfg: &auth.SessionCfg{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.impersonator != nil {
+		o.rememberMe = false
+	}
+
+	session := NewUserSession(user, SessionExtension)
+	session.Meta.IdleTimeout, session.Meta.AbsoluteTimeout = o.sessionCfg.Timeouts(o.rememberMe)
+	session.Meta.RememberMe = o.rememberMe && o.sessionCfg.RememberMe.Enabled
+	session.Meta.DeviceName = o.deviceName
+	if o.impersonator != nil {
+		session.Meta.Impersonator = o.impersonator
+		session.Meta.AbsoluteTimeout = min(session.Meta.AbsoluteTimeout, ImpersonationTimeout)
+	}
+	session.ExpiresAt = session.CreatedAt.Add(session.Extension())
+
 	err := sessionStore.Insert(ctx, session)
 	if err != nil {
 		return nil, err
 	}
 
+	if o.replaces != uuid.Nil {
+		err = sessionStore.Delete(ctx, o.replaces)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return session, nil
 }
+
+// scanUser scans a row containing the userColumns into a new User.
+func scanUser(row pgx.Row) (*User, error) {
+	u := &User{}
+	err := row.Scan(&u.ID, &u.Email, &u.Firstname, &u.Lastname, &u.CreatedAt, &u.UpdatedAt)
+
+	return u, err
+}
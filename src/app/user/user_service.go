@@ -6,9 +6,13 @@ import (
 	"time"
 )
 
-// ErrHardSessionExpiry is returned when a session has expired and the user has not logged in for more than 24 hours.
-// Hard session expiry happens when the softly expired session could not be (further) extended.
-var ErrHardSessionExpiry = errors.New("session is expired and user has not logged in for more than 24 hours")
+var (
+	// ErrHardSessionExpiry is returned when a session has expired and the user has not logged in for more than 24 hours.
+	// Hard session expiry happens when the softly expired session could not be (further) extended.
+	ErrHardSessionExpiry = errors.New("session is expired and user has not logged in for more than 24 hours")
+	// ErrNotImpersonating is returned by StopImpersonation for sessions the user logged in to.
+	ErrNotImpersonating = errors.New("session is not an impersonation session")
+)
 
 // UpdateUser updates the user in the database and the session.
 // It is a service function agnostic from the calling controller.
@@ -27,6 +31,79 @@ func UpdateUser(ctx context.Context, toUpdate *ToUpdate, session *Session, repo
 	return update, nil
 }
 
+// RotateSession stores the session under a new id and deletes it under its previous id, the session's ID is updated.
+// The caller has to set the new session cookie (see Session.SetCookie). Sessions should be rotated whenever the privileges
+// of their user change, so that a leaked session id does not carry the new privileges.
+func RotateSession(ctx context.Context, session *Session, sessionStore SessionRepository) error {
+	previousID := session.ID
+
+	err := sessionStore.Insert(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	return sessionStore.Delete(ctx, previousID)
+}
+
+// Impersonate starts an impersonation session of the user for the administrator of the admin session (see Impersonating).
+// The administrator's session is a privilege change and therefore rotated (see RotateSession): it is kept under a new id
+// only known to the impersonation session and restored by StopImpersonation, its previous id is rejected afterward.
+// The LoginOptions are passed to Login.
+func Impersonate(ctx context.Context, adminSession *Session, user *User, sessionStore SessionRepository, opts ...LoginOption) (*Session, error) {
+	err := RotateSession(ctx, adminSession, sessionStore)
+	if err != nil {
+		return nil, err
+	}
+
+	admin := adminSession.Payload
+	impersonator := &Impersonator{UserID: admin.ID, Email: admin.Email, SessionID: adminSession.ID}
+
+	return Login(ctx, user, sessionStore, append(opts, Impersonating(impersonator))...)
+}
+
+// StopImpersonation deletes the impersonation session and returns the administrator's session to restore.
+// The administrator's session is rotated (see RotateSession), neither the impersonation session's id nor the id the
+// administrator's session was kept under are accepted afterward. If the administrator's session expired meanwhile,
+// it is deleted and ErrHardSessionExpiry is returned. It returns ErrNotImpersonating if the session is no impersonation session.
+func StopImpersonation(ctx context.Context, session *Session, sessionStore SessionRepository) (*Session, error) {
+	impersonator := session.Meta.Impersonator
+	if impersonator == nil {
+		return nil, ErrNotImpersonating
+	}
+
+	err := sessionStore.Delete(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	adminSession, err := sessionStore.Read(ctx, impersonator.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if adminSession.IsHardExpired() {
+		return nil, errors.Join(ErrHardSessionExpiry, sessionStore.Delete(ctx, adminSession.ID))
+	}
+
+	err = RotateSession(ctx, adminSession, sessionStore)
+	if err != nil {
+		return nil, err
+	}
+
+	return adminSession, nil
+}
+
+// RevokeSessions deletes all sessions of the user with the email, e.g. after the user's access was revoked at the OAuth2
+// provider. Impersonation sessions of the user are deleted as well.
+func RevokeSessions(ctx context.Context, email string, userRepo Repository, sessionStore SessionRepository) error {
+	u, err := userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	return sessionStore.DeleteByUser(ctx, u.ID)
+}
+
 // TryExtendSession tries to extend the passed in session to the passed in duration.
 // If the session is hard expired it returns ErrHardSessionExpiry. Hard expired is determined through Session.IsHardExpired.
 func TryExtendSession(ctx context.Context, session *Session, duration time.Duration, sessionStore SessionRepository) error {
@@ -1,6 +1,8 @@
 package user
 
 import (
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/persistence"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -52,6 +54,73 @@ func TestTryExtendSession(t *testing.T) {
 	assert.Equal(t, readSession.ExpiresAt.Truncate(time.Second), time.Now().Add(time.Hour).Truncate(time.Second))
 }
 
+func TestRotateSession(t *testing.T) {
+	registerCleanupUserAndSessionTables(t)
+	_, session := setupMockUserAndSession(t)
+	previousID := session.ID
+
+	err := RotateSession(ctx, session, sessionStore)
+	require.NoError(t, err)
+	assert.NotEqual(t, previousID, session.ID)
+
+	_, err = sessionStore.Read(ctx, previousID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+
+	readSession, err := sessionStore.Read(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, session.Payload.ID, readSession.Payload.ID)
+}
+
+func TestImpersonate(t *testing.T) {
+	registerCleanupUserAndSessionTables(t)
+	admin, adminSession := setupMockUserAndSession(t)
+	previousAdminID := adminSession.ID
+	impersonated, err := userRepo.Create(ctx, &ToCreate{Email: "impersonated@bar.com", Firstname: "Imp", Lastname: "Ersonated"})
+	require.NoError(t, err)
+
+	_, err = StopImpersonation(ctx, adminSession, sessionStore)
+	assert.ErrorIs(t, err, ErrNotImpersonating)
+
+	session, err := Impersonate(ctx, adminSession, impersonated, sessionStore)
+	require.NoError(t, err)
+	assert.Equal(t, impersonated.ID, session.Payload.ID)
+	require.NotNil(t, session.Meta.Impersonator)
+	assert.Equal(t, admin.ID, session.Meta.Impersonator.UserID)
+	assert.Equal(t, adminSession.ID, session.Meta.Impersonator.SessionID)
+
+	_, err = sessionStore.Read(ctx, previousAdminID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound, "the administrator's session id before the impersonation is rejected")
+
+	keptAdminID := adminSession.ID
+	restored, err := StopImpersonation(ctx, session, sessionStore)
+	require.NoError(t, err)
+	assert.Equal(t, admin.ID, restored.Payload.ID)
+	assert.NotEqual(t, keptAdminID, restored.ID)
+
+	_, err = sessionStore.Read(ctx, session.ID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound, "the impersonation session is rejected")
+	_, err = sessionStore.Read(ctx, keptAdminID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound, "the administrator's session id during the impersonation is rejected")
+	_, err = sessionStore.Read(ctx, restored.ID)
+	assert.NoError(t, err)
+}
+
+func TestRevokeSessions(t *testing.T) {
+	registerCleanupUserAndSessionTables(t)
+	user, session := setupMockUserAndSession(t)
+	other := NewUserSession(user, time.Hour)
+	require.NoError(t, sessionStore.Insert(ctx, other))
+
+	require.NoError(t, RevokeSessions(ctx, user.Email, userRepo, sessionStore))
+
+	for _, id := range []uuid.UUID{session.ID, other.ID} {
+		_, err := sessionStore.Read(ctx, id)
+		assert.ErrorIs(t, err, persistence.ErrNotFound, "all sessions of the user are rejected")
+	}
+
+	assert.ErrorIs(t, RevokeSessions(ctx, "unknown@bar.com", userRepo, sessionStore), persistence.ErrNotFound)
+}
+
 func registerCleanupUserAndSessionTables(t *testing.T) {
 	t.Cleanup(func() {
 		_, err := db.Exec(ctx, "DELETE FROM users")
@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/auth"
 	"github.com/org-harmony/harmony/src/core/hctx"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/org-harmony/harmony/src/core/util"
+	"net/http"
+	"strings"
 	"time"
 )
 
@@ -15,6 +20,11 @@ const (
 	SessionRepositoryName = "UserSessionRepository"
 	SessionCookieName     = "harmony_session"
 	SessionType           = "user"
+	// SessionExtension is the duration a session is extended by after it expired, see TryExtendSession.
+	// It is capped at the session's idle timeout.
+	SessionExtension = time.Hour
+	// ImpersonationTimeout is the absolute timeout of impersonation sessions, see Impersonating.
+	ImpersonationTimeout = time.Hour
 )
 
 // Session is a persistence.Session with the User as the payload and SessionMeta as the meta.
@@ -24,16 +34,39 @@ type Session struct {
 
 // SessionMeta is the meta for a user session. It contains extra settings for the user session and the first login time.
 // FirstLoginAt allows for soft-/hard-expiry of user sessions.
+// IdleTimeout and AbsoluteTimeout are set on login (see auth.SessionCfg), sessions without them use the auth package's defaults.
 type SessionMeta struct {
-	Settings     map[string]string
-	FirstLoginAt time.Time
-	ExtendedAt   *time.Time
+	Settings        map[string]string
+	FirstLoginAt    time.Time
+	ExtendedAt      *time.Time
+	IdleTimeout     time.Duration
+	AbsoluteTimeout time.Duration
+	// RememberMe is true for long-lived sessions the user opted in to when logging in.
+	RememberMe bool
+	// DeviceName describes the device the user logged in with, e.g. "Firefox on Linux" (see DeviceName).
+	DeviceName string
+	// Impersonator is the administrator impersonating the user. It is nil for sessions the user logged in to.
+	Impersonator *Impersonator
+	// Privileged records whether the user had elevated privileges (e.g. the admin role) when the session was last rotated,
+	// see RotateOnPrivilegeChange.
+	Privileged bool
+}
+
+// Impersonator is the administrator impersonating a user in an impersonation session.
+// SessionID is the administrator's own session which is restored when the impersonation is stopped.
+// Impersonation sessions are separate sessions of the impersonated user, the administrator's session is kept under
+// a rotated id while impersonating (see Impersonate).
+type Impersonator struct {
+	UserID    uuid.UUID
+	Email     string
+	SessionID uuid.UUID
 }
 
 // PGUserSessionRepository is a PostgreSQL implementation of the SessionRepository interface for user sessions.
 // It implements the SessionRepository interface and by that the persistence.SessionRepository interface.
 // For more details see the SessionRepository interface.
 type PGUserSessionRepository struct {
+	persistence.Timeout
 	db *pgxpool.Pool
 }
 
@@ -43,8 +76,17 @@ type PGUserSessionRepository struct {
 // It allows to read, write and delete user sessions from the database.
 // Insert should usually be preferred over Write as it does not require the id to be passed.
 // Write can be used to insert new items but also to update existing ones (upsert).
+//
+// FindByUser and DeleteByUser allow to list and invalidate all sessions of a user, e.g. to log the user out on all devices.
 type SessionRepository interface {
 	persistence.SessionRepository[*Session]
+
+	// FindByUser returns all sessions of the user, including expired ones, ordered by the login time (newest first).
+	// It returns persistence.ErrReadRow if the sessions could not be read.
+	FindByUser(ctx context.Context, userID uuid.UUID) ([]*Session, error)
+	// DeleteByUser deletes all sessions of the user except the sessions with the passed in ids.
+	// It returns persistence.ErrDelete if the sessions could not be deleted.
+	DeleteByUser(ctx context.Context, userID uuid.UUID, except ...uuid.UUID) error
 }
 
 // NewPGUserSessionRepository creates a new PGUserSessionRepository with the given database connection pool.
@@ -60,6 +102,9 @@ func (r *PGUserSessionRepository) RepositoryName() string {
 // Read reads a valid/invalid user session from the database by id.
 // If the session has expired it will still be returned and no error will be returned.
 func (r *PGUserSessionRepository) Read(ctx context.Context, id uuid.UUID) (*Session, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
 	session := &Session{
 		Session: persistence.Session[User, SessionMeta]{},
 	}
@@ -75,6 +120,9 @@ func (r *PGUserSessionRepository) Read(ctx context.Context, id uuid.UUID) (*Sess
 // Write writes a user session to the database, identified by the id passed in *not* the session's id on the struct.
 // The session structs id will be overwritten by the id passed as second argument to PGUserSessionRepository.Write.
 func (r *PGUserSessionRepository) Write(ctx context.Context, id uuid.UUID, session *Session) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
 	session.ID = id
 
 	err := persistence.PGWriteSession(ctx, r.db, &session.Session)
@@ -82,31 +130,80 @@ func (r *PGUserSessionRepository) Write(ctx context.Context, id uuid.UUID, sessi
 		return nil
 	}
 
-	return errors.Join(persistence.ErrInsert, err)
+	return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
 }
 
 // Delete deletes a user session from the database by id. If the session does not exist it returns nil.
 // If the session could not be deleted it returns persistence.ErrDelete.
 func (r *PGUserSessionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
 	err := persistence.PGDeleteSession(ctx, r.db, id)
 
 	if err == nil {
 		return nil
 	}
 
-	return errors.Join(persistence.ErrDelete, err)
+	return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
 }
 
 // Insert inserts a new user session into the database. A new uuid.UUID will be generated and set on the session struct.
 // Therefore, Insert has a side effect on the session struct. Insert should be preferred over Write for new sessions.
 // If the session could not be inserted it returns persistence.ErrInsert.
 func (r *PGUserSessionRepository) Insert(ctx context.Context, session *Session) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
 	id := uuid.New()
 	session.ID = id
 
 	err := persistence.PGWriteSession(ctx, r.db, &session.Session)
 	if err != nil {
-		return errors.Join(persistence.ErrInsert, err)
+		return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// FindByUser returns all sessions of the user, including expired ones, ordered by the login time (newest first).
+// It returns persistence.ErrReadRow if the sessions could not be read.
+func (r *PGUserSessionRepository) FindByUser(ctx context.Context, userID uuid.UUID) ([]*Session, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		`SELECT id, type, payload, meta, created_at, expires_at, updated_at FROM sessions
+		WHERE type = $1 AND payload->>'ID' = $2 AND tenant_id = $3 ORDER BY created_at DESC`,
+		SessionType, userID.String(), tenant.ID(ctx),
+	)
+
+	return persistence.PGCollectRows(rows, err, func(row pgx.Row) (*Session, error) {
+		s := &Session{}
+		err := row.Scan(&s.ID, &s.Type, &s.Payload, &s.Meta, &s.CreatedAt, &s.ExpiresAt, &s.UpdatedAt)
+
+		return s, err
+	})
+}
+
+// DeleteByUser deletes all sessions of the user except the sessions with the passed in ids.
+// It returns persistence.ErrDelete if the sessions could not be deleted.
+func (r *PGUserSessionRepository) DeleteByUser(ctx context.Context, userID uuid.UUID, except ...uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	if except == nil {
+		except = []uuid.UUID{}
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		"DELETE FROM sessions WHERE type = $1 AND payload->>'ID' = $2 AND tenant_id = $3 AND NOT (id = ANY($4))",
+		SessionType, userID.String(), tenant.ID(ctx), except,
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
 	}
 
 	return nil
@@ -136,30 +233,107 @@ func NewUserSession(user *User, duration time.Duration) *Session {
 	}
 }
 
-// IsHardExpired checks if a session has hard expired. This means the session has expired more than 24 hours ago or
-// the session has expired and not been extended within the last 3 hours or the first login was more than 24 hours ago.
+// IsHardExpired checks if a session has hard expired. This means the first login was longer ago than the absolute timeout or
+// the session has expired and either expired longer ago than the absolute timeout or was not created or extended within the idle timeout.
+// The timeouts default to 24 hours (absolute) and 3 hours (idle), see SessionMeta.
+// The idle timeout is tracked through the session's extensions, it is therefore only as accurate as the SessionExtension.
 func (s *Session) IsHardExpired() bool {
+	idleTimeout, absoluteTimeout := s.timeouts()
+
+	if s.Meta.FirstLoginAt.Before(time.Now().Add(-absoluteTimeout)) {
+		return true
+	}
+
 	if !s.IsExpired() {
 		return false
 	}
 
-	olderThan24h := s.ExpiresAt.Before(time.Now().Add(-24 * time.Hour))
-	if olderThan24h {
+	if s.ExpiresAt.Before(time.Now().Add(-absoluteTimeout)) {
 		return true
 	}
 
-	firstLoginOlderThan24h := s.Meta.FirstLoginAt.Before(time.Now().Add(-24 * time.Hour))
-	if firstLoginOlderThan24h {
-		return true
+	createdInTime := s.CreatedAt.After(time.Now().Add(-idleTimeout))
+	extendedInTime := s.Meta.ExtendedAt != nil && s.Meta.ExtendedAt.After(time.Now().Add(-idleTimeout))
+
+	return !createdInTime && !extendedInTime
+}
+
+// AbsoluteExpiresAt returns the time the session expires at regardless of its use, see SessionMeta.AbsoluteTimeout.
+func (s *Session) AbsoluteExpiresAt() time.Time {
+	_, absoluteTimeout := s.timeouts()
+
+	return s.Meta.FirstLoginAt.Add(absoluteTimeout)
+}
+
+// LastActiveAt returns the time the session was last created or extended at.
+func (s *Session) LastActiveAt() time.Time {
+	if s.Meta.ExtendedAt != nil && s.Meta.ExtendedAt.After(s.CreatedAt) {
+		return *s.Meta.ExtendedAt
 	}
 
-	createdInTime := s.CreatedAt.After(time.Now().Add(-3 * time.Hour))
-	extendedInTime := s.Meta.ExtendedAt != nil && s.Meta.ExtendedAt.After(time.Now().Add(-3*time.Hour))
-	if s.IsExpired() && !createdInTime && !extendedInTime {
-		return true
+	return s.CreatedAt
+}
+
+// Extension returns the duration the session is extended by after it expired.
+// It is the SessionExtension unless the session's idle timeout is shorter.
+func (s *Session) Extension() time.Duration {
+	idleTimeout, _ := s.timeouts()
+
+	return min(SessionExtension, idleTimeout)
+}
+
+// SetCookie sets the session cookie on the response. The cookie of long-lived sessions (see SessionMeta.RememberMe)
+// expires with the session's absolute timeout, the cookie of other sessions as described by auth.SetSession.
+func (s *Session) SetCookie(w http.ResponseWriter) {
+	if s.Meta.RememberMe {
+		auth.SetSessionUntil(w, SessionCookieName, &s.Session, s.AbsoluteExpiresAt())
+		return
 	}
 
-	return false
+	auth.SetSession(w, SessionCookieName, &s.Session)
+}
+
+// timeouts returns the session's idle and absolute timeout. It falls back to the auth package's defaults for sessions without timeouts.
+func (s *Session) timeouts() (time.Duration, time.Duration) {
+	idleTimeout, absoluteTimeout := s.Meta.IdleTimeout, s.Meta.AbsoluteTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = auth.DefaultIdleTimeout
+	}
+	if absoluteTimeout <= 0 {
+		absoluteTimeout = auth.DefaultAbsoluteTimeout
+	}
+
+	return idleTimeout, absoluteTimeout
+}
+
+// DeviceName returns a name describing the device of the user agent, e.g. "Firefox on Linux".
+// It is a best guess to let users recognize their sessions, it returns an empty string if neither browser nor platform are recognized.
+func DeviceName(userAgent string) string {
+	browser := firstContained(userAgent, [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	})
+	platform := firstContained(userAgent, [][2]string{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	default:
+		return platform
+	}
 }
 
 // AddSetting adds a setting to the session. Settings are stored in the SessionMeta.Settings map.
@@ -186,3 +360,14 @@ func (s *Session) Setting(key string) (string, error) {
 
 	return value, nil
 }
+
+// firstContained returns the name of the first pair whose token is contained in s. The pairs are token and name.
+func firstContained(s string, pairs [][2]string) string {
+	for _, pair := range pairs {
+		if strings.Contains(s, pair[0]) {
+			return pair[1]
+		}
+	}
+
+	return ""
+}
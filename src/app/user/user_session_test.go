@@ -5,6 +5,7 @@ import (
 	"github.com/org-harmony/harmony/src/core/persistence"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -135,6 +136,111 @@ func TestSession_IsHardExpired(t *testing.T) {
 	assert.True(t, s.IsHardExpired())
 }
 
+func TestSession_IsHardExpired_Timeouts(t *testing.T) {
+	s := NewUserSession(&User{}, 30*time.Minute)
+	s.Meta.IdleTimeout = 30 * time.Minute
+	s.Meta.AbsoluteTimeout = 30 * 24 * time.Hour
+
+	s.ExpiresAt = time.Now().Add(-time.Minute)
+	s.CreatedAt = time.Now().Add(-40 * time.Minute)
+	assert.True(t, s.IsHardExpired(), "not used within the idle timeout")
+
+	extendedAt := time.Now().Add(-20 * time.Minute)
+	s.Meta.ExtendedAt = &extendedAt
+	assert.False(t, s.IsHardExpired())
+
+	s.Meta.FirstLoginAt = time.Now().Add(-29 * 24 * time.Hour)
+	assert.False(t, s.IsHardExpired(), "long-lived sessions outlive the default absolute timeout")
+
+	s.Meta.FirstLoginAt = time.Now().Add(-31 * 24 * time.Hour)
+	s.ExpiresAt = time.Now().Add(time.Minute)
+	assert.True(t, s.IsHardExpired(), "the absolute timeout applies to sessions that did not expire yet")
+}
+
+func TestSession_Timeouts(t *testing.T) {
+	s := NewUserSession(&User{}, time.Hour)
+	assert.Equal(t, SessionExtension, s.Extension())
+	assert.Equal(t, s.Meta.FirstLoginAt.Add(24*time.Hour), s.AbsoluteExpiresAt())
+	assert.Equal(t, s.CreatedAt, s.LastActiveAt())
+
+	s.Meta.IdleTimeout = 15 * time.Minute
+	s.Meta.AbsoluteTimeout = 2 * time.Hour
+	assert.Equal(t, 15*time.Minute, s.Extension())
+	assert.Equal(t, s.Meta.FirstLoginAt.Add(2*time.Hour), s.AbsoluteExpiresAt())
+
+	extendedAt := time.Now().Add(time.Minute)
+	s.Meta.ExtendedAt = &extendedAt
+	assert.Equal(t, extendedAt, s.LastActiveAt())
+}
+
+func TestSession_SetCookie(t *testing.T) {
+	s := NewUserSession(&User{}, time.Hour)
+	s.ID = uuid.New()
+
+	recorder := httptest.NewRecorder()
+	s.SetCookie(recorder)
+	cookie := recorder.Result().Cookies()[0]
+	assert.Equal(t, SessionCookieName, cookie.Name)
+	assert.Equal(t, s.ID.String(), cookie.Value)
+	assert.Equal(t, s.ExpiresAt.Add(48*time.Hour).UTC().Truncate(time.Second), cookie.Expires.UTC().Truncate(time.Second))
+
+	s.Meta.RememberMe = true
+	s.Meta.AbsoluteTimeout = 30 * 24 * time.Hour
+	recorder = httptest.NewRecorder()
+	s.SetCookie(recorder)
+	cookie = recorder.Result().Cookies()[0]
+	assert.Equal(t, s.AbsoluteExpiresAt().UTC().Truncate(time.Second), cookie.Expires.UTC().Truncate(time.Second))
+}
+
+func TestDeviceName(t *testing.T) {
+	tests := map[string]string{
+		"Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0":                                                    "Firefox on Linux",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0": "Edge on Windows",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15":     "Safari on macOS",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/604.1": "Safari on iOS",
+		"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36":              "Chrome on Android",
+		"curl/8.4.0": "",
+		"":           "",
+	}
+
+	for userAgent, expected := range tests {
+		assert.Equal(t, expected, DeviceName(userAgent), userAgent)
+	}
+}
+
+func TestPGUserSessionRepository_FindByUser_DeleteByUser(t *testing.T) {
+	registerCleanupUserSessionTable(t)
+
+	first := fooUserSession()
+	require.NoError(t, sessionStore.Write(ctx, first.ID, first))
+	second := fooUserSession()
+	second.Payload = first.Payload
+	second.CreatedAt = time.Now().Add(time.Minute)
+	require.NoError(t, sessionStore.Write(ctx, second.ID, second))
+	other := fooUserSession()
+	require.NoError(t, sessionStore.Write(ctx, other.ID, other))
+
+	sessions, err := sessionStore.FindByUser(ctx, first.Payload.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, second.ID, sessions[0].ID, "newest session first")
+	assert.Equal(t, first.ID, sessions[1].ID)
+
+	require.NoError(t, sessionStore.DeleteByUser(ctx, first.Payload.ID, second.ID))
+	sessions, err = sessionStore.FindByUser(ctx, first.Payload.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, second.ID, sessions[0].ID)
+
+	require.NoError(t, sessionStore.DeleteByUser(ctx, first.Payload.ID))
+	sessions, err = sessionStore.FindByUser(ctx, first.Payload.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	_, err = sessionStore.Read(ctx, other.ID)
+	assert.NoError(t, err, "sessions of other users are kept")
+}
+
 func fooUserSession() *Session {
 	return &Session{
 		Session: persistence.Session[User, SessionMeta]{
@@ -2,12 +2,16 @@ package user
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/auth"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestMain(m *testing.M) {
@@ -93,6 +97,66 @@ func TestPGUSerRepository_FindBy(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPGUserRepository_Tenant(t *testing.T) {
+	registerCleanupUserTable(t)
+	tenantCtx := tenant.WithTenant(ctx, &tenant.Tenant{ID: "uni-a"})
+
+	defaultUser, err := userRepo.Create(ctx, fooUserToCreate())
+	require.NoError(t, err)
+	tenantUser, err := userRepo.Create(tenantCtx, fooUserToCreate())
+	require.NoError(t, err, "the same email may be used in different tenants")
+
+	user, err := userRepo.FindByEmail(tenantCtx, defaultUser.Email)
+	require.NoError(t, err)
+	assert.Equal(t, tenantUser.ID, user.ID)
+
+	_, err = userRepo.FindByID(tenantCtx, defaultUser.ID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+
+	require.NoError(t, userRepo.Delete(tenantCtx, defaultUser.ID))
+	_, err = userRepo.FindByID(ctx, defaultUser.ID)
+	assert.NoError(t, err, "users of other tenants are not deleted")
+}
+
+func TestLogin(t *testing.T) {
+	registerCleanupUserAndSessionTables(t)
+	user, previous := setupMockUserAndSession(t)
+
+	session, err := Login(ctx, user, sessionStore, ReplacesSession(previous.ID), WithDeviceName("Firefox on Linux"))
+	require.NoError(t, err)
+	assert.NotEqual(t, previous.ID, session.ID, "each login creates a new session id")
+	assert.Equal(t, auth.DefaultIdleTimeout, session.Meta.IdleTimeout)
+	assert.Equal(t, auth.DefaultAbsoluteTimeout, session.Meta.AbsoluteTimeout)
+	assert.Equal(t, "Firefox on Linux", session.Meta.DeviceName)
+	assert.False(t, session.Meta.RememberMe)
+
+	_, err = sessionStore.Read(ctx, previous.ID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound, "the replaced session is deleted")
+
+	cfg := &auth.SessionCfg{IdleTimeout: 30, RememberMe: auth.RememberMeCfg{Enabled: true, AbsoluteTimeout: 43200}}
+	session, err = Login(ctx, user, sessionStore, WithSessionCfg(cfg), RememberMe)
+	require.NoError(t, err)
+	assert.True(t, session.Meta.RememberMe)
+	assert.Equal(t, 30*time.Minute, session.Meta.IdleTimeout)
+	assert.Equal(t, 30*24*time.Hour, session.Meta.AbsoluteTimeout)
+	assert.Equal(t, session.CreatedAt.Add(30*time.Minute), session.ExpiresAt)
+
+	readSession, err := sessionStore.Read(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, session.Meta.AbsoluteTimeout, readSession.Meta.AbsoluteTimeout)
+	assert.True(t, readSession.Meta.RememberMe)
+
+	impersonator := &Impersonator{UserID: uuid.New(), Email: "admin@example.com", SessionID: previous.ID}
+	session, err = Login(ctx, user, sessionStore, WithSessionCfg(cfg), RememberMe, Impersonating(impersonator))
+	require.NoError(t, err)
+	assert.False(t, session.Meta.RememberMe, "impersonation sessions are never long-lived")
+	assert.Equal(t, ImpersonationTimeout, session.Meta.AbsoluteTimeout)
+
+	readSession, err = sessionStore.Read(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, impersonator, readSession.Meta.Impersonator)
+}
+
 func registerCleanupUserTable(t *testing.T) {
 	t.Cleanup(func() {
 		_, err := db.Exec(ctx, "DELETE FROM users")
@@ -8,19 +8,25 @@ import (
 	"github.com/org-harmony/harmony/src/core/auth"
 	"github.com/org-harmony/harmony/src/core/hctx"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/org-harmony/harmony/src/core/util"
 	"github.com/org-harmony/harmony/src/core/web"
 	"golang.org/x/oauth2"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // ErrInvalidProvider is returned when the specified provider is not found or not activated.
 var ErrInvalidProvider = errors.New("user.auth.login.error.invalid-provider")
 
-func oAuthLoginController(appCtx *hctx.AppCtx, webCtx *web.Ctx, providers map[string]*auth.ProviderCfg) http.Handler {
+// RememberMeCookieName is the name of the cookie remembering the user's choice to stay logged in during the OAuth2 flow.
+const RememberMeCookieName = "harmony_remember_me"
+
+func oAuthLoginController(appCtx *hctx.AppCtx, webCtx *web.Ctx, providers map[string]*auth.ProviderCfg, sessionCfg *auth.SessionCfg) http.Handler {
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
 		name := web.URLParam(io.Request(), "provider")
-		redirectURL := oAuthProviderRedirectURL(webCtx, name)
+		redirectURL := oAuthProviderRedirectURL(io.Context(), webCtx, name)
 
 		oAuthCfg, cfg, err := oAuthCfgFromProviderMap(name, providers, redirectURL)
 		if err != nil {
@@ -31,6 +37,8 @@ func oAuthLoginController(appCtx *hctx.AppCtx, webCtx *web.Ctx, providers map[st
 			return io.Error(ErrInvalidProvider, fmt.Errorf("the provider %s is not enabled", name))
 		}
 
+		setRememberMe(io.Response(), sessionCfg.RememberMe.Enabled && io.Request().FormValue("remember_me") != "")
+
 		url := oAuthCfg.AuthCodeURL("state") // TODO dynamize state through method in auth.go
 
 		return io.Redirect(url, http.StatusTemporaryRedirect)
@@ -42,6 +50,7 @@ func oAuthLoginSuccessController(
 	webCtx *web.Ctx,
 	providers map[string]*auth.ProviderCfg,
 	adapters map[string]user.OAuthUserAdapter,
+	sessionCfg *auth.SessionCfg,
 ) http.Handler {
 	userRepository := util.UnwrapType[user.Repository](appCtx.Repository(user.RepositoryName))
 	sessionStore := user.SessionStore(appCtx)
@@ -58,9 +67,12 @@ func oAuthLoginSuccessController(
 			return io.Error(ErrInvalidProvider, fmt.Errorf("the provider %s is not enabled", name))
 		}
 
-		redirectURL := oAuthProviderRedirectURL(webCtx, name)
+		redirectURL := oAuthProviderRedirectURL(request.Context(), webCtx, name)
+		loginOptions := loginOptions(request, sessionCfg)
+		setRememberMe(io.Response(), false)
 
-		session, err := auth.OAuthLogin(
+		var userSession *user.Session
+		_, err := auth.OAuthLogin(
 			request.Context(),
 			request.FormValue("state"),
 			request.FormValue("code"),
@@ -76,7 +88,8 @@ func oAuthLoginSuccessController(
 					return nil, fmt.Errorf("oauth user adapter for provider %s not found", provider.Name)
 				}
 
-				userSession, err := user.LoginWithAdapter(ctx, token, provider, userAdapter, userRepository, sessionStore)
+				var err error
+				userSession, err = user.LoginWithAdapter(ctx, token, provider, userAdapter, userRepository, sessionStore, loginOptions...)
 				if err != nil {
 					return nil, err
 				}
@@ -90,17 +103,90 @@ func oAuthLoginSuccessController(
 			return io.Error(errors.New("user.auth.login.error.oauth"))
 		}
 
-		auth.SetSession(io.Response(), user.SessionCookieName, session)
+		userSession.SetCookie(io.Response())
 
 		return io.Redirect("/", http.StatusTemporaryRedirect)
 	})
 }
 
+// oAuthRevokeController logs the user of the form value email out on all devices (see user.RevokeSessions) after the
+// user's access was revoked at the provider, e.g. by the provider's or an identity management's webhook. The notification
+// is authenticated by the provider's revocation secret as bearer token (see auth.ProviderCfg.IsRevocationSecret).
+// Unknown users are answered like known users.
+func oAuthRevokeController(appCtx *hctx.AppCtx, webCtx *web.Ctx, providers map[string]*auth.ProviderCfg) http.Handler {
+	userRepository := util.UnwrapType[user.Repository](appCtx.Repository(user.RepositoryName))
+	sessionStore := user.SessionStore(appCtx)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		request := io.Request()
+		provider, ok := providers[web.URLParam(request, "provider")]
+		if !ok || !provider.Enabled {
+			return io.Error(web.NotFound(ErrInvalidProvider))
+		}
+
+		secret, _ := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+		if !provider.IsRevocationSecret(secret) {
+			return io.Error(web.Forbidden(nil))
+		}
+
+		email := strings.TrimSpace(request.FormValue("email"))
+		err := user.RevokeSessions(request.Context(), email, userRepository, sessionStore)
+		if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+			return io.Error(web.ErrInternal, err)
+		}
+		if err == nil {
+			appCtx.Info(Pkg, "sessions revoked", "provider", provider.Name, "user", email)
+		}
+
+		io.Response().WriteHeader(http.StatusNoContent)
+
+		return nil
+	})
+}
+
+// loginOptions returns the options for logging in the user of the request. The session the request was made with
+// is replaced by the new session (session rotation) and the user's choice to stay logged in is read from the RememberMeCookieName.
+func loginOptions(request *http.Request, sessionCfg *auth.SessionCfg) []user.LoginOption {
+	opts := []user.LoginOption{user.WithSessionCfg(sessionCfg), user.WithDeviceName(user.DeviceName(request.UserAgent()))}
+
+	if sessionID, err := user.SessionIDFromRequest(request); err == nil {
+		opts = append(opts, user.ReplacesSession(sessionID))
+	}
+
+	if cookie, err := request.Cookie(RememberMeCookieName); err == nil && cookie.Value != "" {
+		opts = append(opts, user.RememberMe)
+	}
+
+	return opts
+}
+
+// setRememberMe sets the cookie remembering the user's choice to stay logged in until the OAuth2 flow finished.
+// If rememberMe is false the cookie is cleared.
+func setRememberMe(w http.ResponseWriter, rememberMe bool) {
+	cookie := &http.Cookie{
+		Name:     RememberMeCookieName,
+		Value:    "1",
+		Expires:  time.Now().Add(10 * time.Minute),
+		SameSite: http.SameSiteLaxMode, // the cookie has to be sent on the redirect back from the OAuth2 provider
+		Path:     "/auth/login",
+		Secure:   true,
+		HttpOnly: true,
+	}
+
+	if !rememberMe {
+		cookie.Value = ""
+		cookie.Expires = time.Now()
+	}
+
+	http.SetCookie(w, cookie)
+}
+
 // oAuthProviderRedirectURL returns the redirect URL for a specified provider.
-func oAuthProviderRedirectURL(webCtx *web.Ctx, providerName string) string {
+// The base url of the context's tenant is used if it is set, otherwise the base url of the web server.
+func oAuthProviderRedirectURL(ctx context.Context, webCtx *web.Ctx, providerName string) string {
 	return fmt.Sprintf(
 		"%s%s",
-		webCtx.Config.Server.BaseURL,
+		tenant.BaseURL(ctx, webCtx.Config.Server.BaseURL),
 		fmt.Sprintf("/auth/login/%s/success", providerName),
 	)
 }
@@ -2,6 +2,7 @@ package web
 
 import (
 	"errors"
+	"github.com/google/uuid"
 	"github.com/org-harmony/harmony/src/app/user"
 	"github.com/org-harmony/harmony/src/core/auth"
 	"github.com/org-harmony/harmony/src/core/config"
@@ -15,20 +16,35 @@ import (
 
 const Pkg = "app.user.web"
 
+// LoginCacheTTL is the duration the login page is cached for anonymous users.
+const LoginCacheTTL = 5 * time.Minute
+
+// SessionListData is the data of the list of the user's sessions.
+type SessionListData struct {
+	Sessions []*user.Session
+	// CurrentID is the id of the session the request was made with.
+	CurrentID uuid.UUID
+}
+
 // ErrUpdateUser is returned when the user could not be updated. It is the error message for the user.edit.form template.
 var ErrUpdateUser = errors.New("user.settings.update-error")
 
 // RegisterController registers the web controllers for the user module.
 // It registers the following routes:
 //   - GET /user/me/language/{locale} For updating the user language.
-//   - GET /auth/login For displaying various OAuth2 login buttons.
+//   - GET /user/me/theme/{theme} For updating the user's color theme (light, dark or system).
+//   - GET /auth/login For displaying various OAuth2 login buttons. The page is cached for anonymous users.
 //   - GET /auth/logout For logging out the user.
 //   - GET /user/me For displaying the user profile.
 //   - POST /user/me For updating the user profile.
+//   - GET /user/me/sessions For listing the user's sessions (devices the user is logged in on).
+//   - DELETE /user/me/sessions For logging the user out on all other devices.
+//   - DELETE /user/me/sessions/{id} For logging the user out on a single device.
 //
 // If OAuth2 is enabled in the configuration, it also registers the following routes:
 //   - GET /auth/login/{provider} For redirecting the user to the OAuth2 provider with the necessary parameters.
 //   - GET /auth/login/{provider}/success For handling the OAuth2 callback and logging the user in.
+//   - POST /auth/revoke/{provider} For logging a user out on all devices after the user's access was revoked at the provider.
 func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 	registerNavigation(appCtx, webCtx)
 	registerTemplateDataExtensions(appCtx, webCtx)
@@ -39,12 +55,17 @@ func RegisterController(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 	util.Ok(config.C(authCfg, config.From("auth"), config.Validate(appCtx.Validator)))
 
 	router.Get("/user/me/language/{locale}", userLanguageController(appCtx, webCtx).ServeHTTP)
-	router.Get("/auth/login", loginController(appCtx, webCtx, authCfg).ServeHTTP)
+	router.Get("/user/me/theme/{theme}", userThemeController(appCtx, webCtx).ServeHTTP)
+	router.With(web.Cache(webCtx.Cache, web.PageCachePolicy(LoginCacheTTL, user.IsLoggedIn))).
+		Get("/auth/login", loginController(appCtx, webCtx, authCfg).ServeHTTP)
 	router.Get("/auth/logout", logoutController(appCtx, webCtx).ServeHTTP)
 
 	userRouter := router.With(user.LoggedInMiddleware(appCtx))
 	userRouter.Get("/user/me", userProfileController(appCtx, webCtx).ServeHTTP)
 	userRouter.Post("/user/me", userProfileEditController(appCtx, webCtx).ServeHTTP)
+	userRouter.Get("/user/me/sessions", sessionListController(appCtx, webCtx).ServeHTTP)
+	userRouter.Delete("/user/me/sessions", sessionLogoutOthersController(appCtx, webCtx).ServeHTTP)
+	userRouter.Delete("/user/me/sessions/{id}", sessionLogoutController(appCtx, webCtx).ServeHTTP)
 
 	if authCfg.EnableOAuth2 {
 		registerOAuth2Controller(appCtx, webCtx, authCfg)
@@ -83,9 +104,15 @@ func registerNavigation(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 		Position: 1200,
 	})
 
+	webCtx.Navigation.Add("user.language", web.NavItem{
+		Name:     "harmony.menu.language.label",
+		Position: 1100,
+	})
+
 	webCtx.Navigation.Add("user.language.de", web.NavItem{
-		URL:  "/user/me/language/de",
-		Name: "harmony.menu.language.de",
+		URL:    "/user/me/language/de",
+		Name:   "harmony.menu.language.de",
+		Parent: "user.language",
 		Display: func(io web.IO) (bool, error) {
 			locale, err := io.Request().Cookie(trans.LocaleSessionKey)
 			if err != nil {
@@ -98,8 +125,9 @@ func registerNavigation(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 	})
 
 	webCtx.Navigation.Add("user.language.en", web.NavItem{
-		URL:  "/user/me/language/en",
-		Name: "harmony.menu.language.en",
+		URL:    "/user/me/language/en",
+		Name:   "harmony.menu.language.en",
+		Parent: "user.language",
 		Display: func(io web.IO) (bool, error) {
 			locale, err := io.Request().Cookie(trans.LocaleSessionKey)
 			if err != nil {
@@ -110,6 +138,25 @@ func registerNavigation(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 		},
 		Position: 1100,
 	})
+
+	webCtx.Navigation.Add("user.theme", web.NavItem{
+		Name:     "harmony.menu.theme.label",
+		Position: 1150,
+	})
+
+	for i, theme := range []web.Theme{web.ThemeLight, web.ThemeDark, web.ThemeSystem} {
+		theme := theme
+		webCtx.Navigation.Add("user.theme."+string(theme), web.NavItem{
+			Redirect: true, // the theme is set on the html element which is not swapped by boosted links
+			URL:      "/user/me/theme/" + string(theme),
+			Name:     "harmony.menu.theme." + string(theme),
+			Parent:   "user.theme",
+			Display: func(io web.IO) (bool, error) {
+				return web.ThemeFromRequest(io.Request()) != theme, nil
+			},
+			Position: i,
+		})
+	}
 }
 
 func registerTemplateDataExtensions(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
@@ -122,6 +169,19 @@ func registerTemplateDataExtensions(appCtx *hctx.AppCtx, webCtx *web.Ctx) {
 		data.Extra["User"] = u
 		return nil
 	})
+
+	webCtx.Extensions.Add("impersonator", func(io web.IO, data *web.BaseTemplateData) error {
+		if impersonator, ok := user.CtxImpersonator(io.Context()); ok {
+			data.Extra["Impersonator"] = impersonator
+		}
+
+		return nil
+	})
+
+	webCtx.Extensions.Add("theme", func(io web.IO, data *web.BaseTemplateData) error {
+		data.Extra["Theme"] = web.ThemeFromRequest(io.Request())
+		return nil
+	})
 }
 
 func loginController(appCtx *hctx.AppCtx, webCtx *web.Ctx, authCfg *auth.Cfg) http.Handler {
@@ -173,6 +233,28 @@ func userLanguageController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	})
 }
 
+// TODO persist the theme per user once user profiles support preferences
+func userThemeController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		theme := web.Theme(web.URLParam(io.Request(), "theme"))
+		if !theme.Valid() {
+			return io.Redirect("/", http.StatusTemporaryRedirect)
+		}
+
+		cookie := http.Cookie{
+			Name:     web.ThemeCookieName,
+			Value:    string(theme),
+			Expires:  time.Now().Add(365 * 24 * time.Hour),
+			SameSite: http.SameSiteLaxMode,
+			Path:     "/",
+		}
+
+		http.SetCookie(io.Response(), &cookie)
+
+		return io.Redirect("/", http.StatusTemporaryRedirect)
+	})
+}
+
 func userProfileController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
 	return web.NewController(appCtx, webCtx, func(io web.IO) error {
 		return io.Render(
@@ -213,6 +295,75 @@ func userProfileEditController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handle
 	})
 }
 
+func sessionListController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	sessionStore := user.SessionStore(appCtx)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		return renderSessionList(io, sessionStore, nil)
+	})
+}
+
+func sessionLogoutOthersController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	sessionStore := user.SessionStore(appCtx)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		sessionID, err := user.SessionIDFromRequest(io.Request())
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		err = sessionStore.DeleteByUser(io.Context(), user.MustFromIO(io).ID, sessionID)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderSessionList(io, sessionStore, []string{"user.sessions.logged-out-others"})
+	})
+}
+
+func sessionLogoutController(appCtx *hctx.AppCtx, webCtx *web.Ctx) http.Handler {
+	sessionStore := user.SessionStore(appCtx)
+
+	return web.NewController(appCtx, webCtx, func(io web.IO) error {
+		id, err := uuid.Parse(web.URLParam(io.Request(), "id"))
+		if err != nil {
+			return io.InlineError(web.NotFound(err))
+		}
+
+		session, err := sessionStore.Read(io.Context(), id)
+		if err != nil {
+			return io.InlineError(err)
+		}
+
+		if session.Payload.ID != user.MustFromIO(io).ID {
+			return io.InlineError(web.Forbidden(nil))
+		}
+
+		err = sessionStore.Delete(io.Context(), id)
+		if err != nil {
+			return io.InlineError(web.ErrInternal, err)
+		}
+
+		return renderSessionList(io, sessionStore, []string{"user.sessions.logged-out"})
+	})
+}
+
+// renderSessionList renders the list of the logged-in user's sessions with the passed in success messages.
+func renderSessionList(io web.IO, sessionStore user.SessionRepository, successes []string) error {
+	sessions, err := sessionStore.FindByUser(io.Context(), user.MustFromIO(io).ID)
+	if err != nil {
+		return io.InlineError(web.ErrInternal, err)
+	}
+
+	currentID, _ := user.SessionIDFromRequest(io.Request())
+
+	return io.Render(
+		web.NewFormData(&SessionListData{Sessions: sessions, CurrentID: currentID}, successes),
+		"user.sessions",
+		"user/_sessions.go.html",
+	)
+}
+
 func renderUserEditForm(io web.IO, data any) error {
 	return io.Render(data, "user.edit.form", "user/_form-edit.go.html")
 }
@@ -221,6 +372,7 @@ func registerOAuth2Controller(appCtx *hctx.AppCtx, webCtx *web.Ctx, authCfg *aut
 	providers := authCfg.Providers
 	router := webCtx.Router
 
-	router.Get("/auth/login/{provider}", oAuthLoginController(appCtx, webCtx, providers).ServeHTTP)
-	router.Get("/auth/login/{provider}/success", oAuthLoginSuccessController(appCtx, webCtx, providers, user.Adapters()).ServeHTTP)
+	router.Get("/auth/login/{provider}", oAuthLoginController(appCtx, webCtx, providers, &authCfg.Session).ServeHTTP)
+	router.Get("/auth/login/{provider}/success", oAuthLoginSuccessController(appCtx, webCtx, providers, user.Adapters(), &authCfg.Session).ServeHTTP)
+	router.Post("/auth/revoke/{provider}", oAuthRevokeController(appCtx, webCtx, providers).ServeHTTP)
 }
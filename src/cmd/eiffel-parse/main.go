@@ -0,0 +1,311 @@
+// Command eiffel-parse parses requirements in batch using an EIFFEL basic template without the web UI.
+//
+// Usage:
+//
+//	eiffel-parse -template template.json -variant name [-json] [-locale en] [-translations translations] [-config config] [-first-error] [segments.csv]
+//
+// The segments are read as CSV from the passed in file or from stdin if no file (or "-") is passed in.
+// The first CSV row is the header and contains the technical rule names (the keys of the template's rules) as columns.
+// An optional column named "id" is used to identify the requirement in the report, otherwise the row number is used.
+// Each following row is parsed as one requirement using the template's variant. Templates extending other templates
+// are resolved using the templates located in the same directory as the template. With -first-error each requirement is
+// only parsed up to the first rule with an error, which speeds up large batches only interested in whether requirements are valid.
+// Plugin rule types are registered from the EIFFEL config (see eiffel.LoadCfg) in the -config directory if it exists.
+//
+// The command exits with status code 1 if the template could not be loaded or a row could not be parsed
+// and with status code 2 on usage errors. Parsing errors of requirements are part of the report and do not
+// change the exit code.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/eiffel"
+	"github.com/org-harmony/harmony/src/app/template/parser"
+	"github.com/org-harmony/harmony/src/core/config"
+	"github.com/org-harmony/harmony/src/core/herr"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// idColumn is the name of the optional CSV column identifying a requirement.
+const idColumn = "id"
+
+// Report is the machine-readable result of the eiffel-parse command.
+type Report struct {
+	Template     string      `json:"template"`
+	Version      string      `json:"version"`
+	Variant      string      `json:"variant"`
+	Total        int         `json:"total"`
+	Ok           int         `json:"ok"`
+	Flawless     int         `json:"flawless"`
+	Requirements []RowReport `json:"requirements"`
+}
+
+// RowReport is the parsing result of a single CSV row.
+type RowReport struct {
+	ID          string      `json:"id"`
+	Requirement string      `json:"requirement"`
+	Ok          bool        `json:"ok"`
+	Flawless    bool        `json:"flawless"`
+	Errors      []LogReport `json:"errors,omitempty"`
+	Warnings    []LogReport `json:"warnings,omitempty"`
+	Notices     []LogReport `json:"notices,omitempty"`
+}
+
+// LogReport is a single parsing log. Key is the untranslated message key, Message the translated message.
+type LogReport struct {
+	Rule    string `json:"rule,omitempty"`
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+func main() {
+	templatePath := flag.String("template", "", "path to the EIFFEL basic template JSON file")
+	variantName := flag.String("variant", "", "key of the template's variant used for parsing")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	locale := flag.String("locale", "en", "locale used to translate messages and localized rule values")
+	translationsDir := flag.String("translations", "translations", "directory containing the translation files")
+	configDir := flag.String("config", config.Dir, "directory containing the config files, plugins are registered from eiffel.toml")
+	firstError := flag.Bool("first-error", false, "stop parsing a requirement at the first rule with an error")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: eiffel-parse -template file -variant name [-json] [-locale en] [-translations dir] [-config dir] [-first-error] [segments.csv]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *templatePath == "" || *variantName == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// without a config file only the built-in rule types are known, like without translations the messages are untranslated
+	if _, err := eiffel.LoadCfg(validation.New(), config.FromDir(*configDir)); err != nil && !errors.Is(err, herr.ErrReadFile) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	logger := trace.NewWriterLogger(os.Stderr)
+	translator := trans.FromLocaleOrEmpty(*locale, *translationsDir, logger)
+
+	bt, err := loadTemplate(*templatePath)
+	if err != nil {
+		fail(err, translator)
+	}
+
+	ruleParsers := eiffel.RuleParsers()
+	if errs := bt.Validate(validation.New(), ruleParsers); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, trans.TranslateErr(err, translator))
+		}
+		os.Exit(1)
+	}
+
+	input := os.Stdin
+	if flag.NArg() == 1 && flag.Arg(0) != "-" {
+		input, err = os.Open(flag.Arg(0))
+		if err != nil {
+			fail(err, translator)
+		}
+		defer input.Close()
+	}
+
+	ctx := context.WithValue(context.Background(), trans.TranslatorContextKey, translator)
+	var opts []eiffel.ParseOption
+	if *firstError {
+		opts = append(opts, eiffel.StopAtFirstError())
+	}
+
+	report, err := parseRows(ctx, csv.NewReader(input), bt, ruleParsers, *variantName, translator, opts...)
+	if err != nil {
+		fail(err, translator)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fail(err, translator)
+		}
+		return
+	}
+
+	printReport(report)
+}
+
+// loadTemplate reads the template from the file and resolves the templates it extends
+// using the other templates located in the same directory.
+func loadTemplate(path string) (*eiffel.BasicTemplate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bt := &eiffel.BasicTemplate{}
+	if err := json.Unmarshal(content, bt); err != nil {
+		return nil, fmt.Errorf("invalid template JSON: %w", err)
+	}
+
+	if bt.Extends == "" {
+		return bt, nil
+	}
+
+	siblings, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]*eiffel.BasicTemplate, len(siblings))
+	for _, sibling := range siblings {
+		content, err := os.ReadFile(sibling)
+		if err != nil {
+			continue
+		}
+
+		other := &eiffel.BasicTemplate{}
+		if json.Unmarshal(content, other) != nil || other.ID == "" {
+			continue
+		}
+
+		set[other.ID] = other
+	}
+
+	return eiffel.ResolveExtends(bt, set)
+}
+
+// parseRows parses each CSV row as one requirement. The first row is expected to be the header containing the rule names.
+// The options are passed on to eiffel.BasicTemplate.ParseStream.
+func parseRows(
+	ctx context.Context,
+	reader *csv.Reader,
+	bt *eiffel.BasicTemplate,
+	ruleParsers *eiffel.RuleParserProvider,
+	variantName string,
+	translator trans.Translator,
+	opts ...eiffel.ParseOption,
+) (Report, error) {
+	report := Report{Template: bt.Name, Version: bt.Version, Variant: variantName}
+
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+
+		return report, err
+	}
+
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+
+		id := strconv.Itoa(row)
+		segments := make([]parser.ParsingSegment, 0, len(record))
+		for i, value := range record {
+			if i >= len(header) {
+				break
+			}
+
+			if header[i] == idColumn {
+				id = value
+				continue
+			}
+
+			segments = append(segments, parser.ParsingSegment{Name: header[i], Value: strings.TrimSpace(value)})
+		}
+
+		result, err := bt.ParseStream(ctx, ruleParsers, variantName, segments, func(eiffel.ParsedRule) error {
+			return nil
+		}, opts...)
+		if err != nil {
+			return report, fmt.Errorf("row %s: %s", id, trans.TranslateErr(err, translator))
+		}
+
+		rowReport := RowReport{
+			ID:          id,
+			Requirement: result.Requirement,
+			Ok:          result.Ok(),
+			Flawless:    result.Flawless(),
+			Errors:      logReports(result.Errors, translator),
+			Warnings:    logReports(result.Warnings, translator),
+			Notices:     logReports(result.Notices, translator),
+		}
+
+		report.Total++
+		if rowReport.Ok {
+			report.Ok++
+		}
+		if rowReport.Flawless {
+			report.Flawless++
+		}
+		report.Requirements = append(report.Requirements, rowReport)
+	}
+
+	return report, nil
+}
+
+func logReports(logs []parser.ParsingLog, translator trans.Translator) []LogReport {
+	reports := make([]LogReport, 0, len(logs))
+	for _, log := range logs {
+		rule := ""
+		if log.Segment != nil {
+			rule = log.Segment.Name
+		}
+
+		reports = append(reports, LogReport{Rule: rule, Key: log.Message, Message: log.Translate(translator)})
+	}
+
+	return reports
+}
+
+func fail(err error, translator trans.Translator) {
+	fmt.Fprintln(os.Stderr, trans.TranslateErr(err, translator))
+	os.Exit(1)
+}
+
+func printReport(report Report) {
+	fmt.Printf("%s %s, variant %s\n\n", report.Template, report.Version, report.Variant)
+
+	for _, row := range report.Requirements {
+		status := "OK  "
+		switch {
+		case !row.Ok:
+			status = "FAIL"
+		case !row.Flawless:
+			status = "WARN"
+		}
+
+		fmt.Printf("%s [%s] %s\n", status, row.ID, row.Requirement)
+		printLogs("error", row.Errors)
+		printLogs("warning", row.Warnings)
+		printLogs("notice", row.Notices)
+	}
+
+	fmt.Printf("\n%d requirement(s) parsed, %d ok, %d flawless\n", report.Total, report.Ok, report.Flawless)
+}
+
+func printLogs(level string, logs []LogReport) {
+	for _, log := range logs {
+		fmt.Printf("     - %s: %s\n", level, log.Message)
+	}
+}
@@ -0,0 +1,298 @@
+// Command seed fills the configured database with demo data for local development and demo environments.
+//
+// Usage:
+//
+//	seed [-email demo@harmony.local] [-firstname Demo] [-lastname User] [-templates docs/templates] [-tenant default]
+//
+// It creates a demo user and imports the template sets shipped in the templates directory (PARIS, agile and the
+// example template) for that user. The command uses the repository layer and can therefore be run against any
+// configured database after migrating it. Running the command multiple times is safe: existing users and
+// template sets (same name and version) are reused and not imported again.
+// Using multi-tenancy, the data is seeded for the tenant with the ID passed in through the tenant flag.
+//
+// Note: The demo user can log in through the configured OAuth provider using the same email address.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/app/eiffel"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/config"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// seedSet is a template set seeded from a directory relative to the templates directory.
+// Only the directory's *.json files are imported, subdirectories are not searched.
+type seedSet struct {
+	Name        string
+	Version     string
+	Description string
+	Dir         string
+}
+
+// templateConfig is a template config read from a file.
+type templateConfig struct {
+	path   string
+	config string
+	info   struct {
+		ID      string `json:"id"`
+		Extends string `json:"extends"`
+	}
+}
+
+// seeder holds the dependencies needed to seed the database.
+type seeder struct {
+	users        user.Repository
+	templates    template.Repository
+	templateSets template.SetRepository
+	validator    validation.V
+	em           event.Manager
+	logger       trace.Logger
+}
+
+// seedSets are the template sets imported by the seed command.
+var seedSets = []seedSet{
+	{
+		Name:        "PARIS",
+		Version:     "0.6.2",
+		Description: "PARIS templates for requirements engineering (EIFFEL basic templates).",
+		Dir:         filepath.Join("paris", "v0.6.2"),
+	},
+	{
+		Name:        "Agile",
+		Version:     "0.1.0",
+		Description: "Templates for agile requirements like user stories.",
+		Dir:         "agile",
+	},
+	{
+		Name:        "Examples",
+		Version:     "1.0.0",
+		Description: "Example templates showing the features of EIFFEL basic templates.",
+		Dir:         ".",
+	},
+}
+
+func main() {
+	email := flag.String("email", "demo@harmony.local", "email address of the demo user")
+	firstname := flag.String("firstname", "Demo", "firstname of the demo user")
+	lastname := flag.String("lastname", "User", "lastname of the demo user")
+	templatesDir := flag.String("templates", filepath.Join("docs", "templates"), "directory containing the template sets to import")
+	tenantID := flag.String("tenant", tenant.DefaultID, "id of the tenant the demo data is seeded for")
+	flag.Parse()
+
+	toCreate := &user.ToCreate{Email: *email, Firstname: *firstname, Lastname: *lastname}
+	if err := run(toCreate, *templatesDir, *tenantID); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run seeds the database for the tenant. Deferred clean-ups (e.g. closing the database) run before main exits.
+func run(toCreate *user.ToCreate, templatesDir string, tenantID string) error {
+	v := validation.New()
+	logger := trace.NewLogger()
+
+	dbCfg := &persistence.Cfg{}
+	if err := config.C(dbCfg, config.From("persistence"), config.Validate(v)); err != nil {
+		return err
+	}
+	db, err := persistence.NewDB(dbCfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	s := newSeeder(db, v, logger)
+	ctx := tenant.WithTenant(context.Background(), &tenant.Tenant{ID: tenantID})
+
+	fmt.Printf("seeding database for tenant %s...\n", tenantID)
+
+	usr, err := s.seedUser(ctx, toCreate)
+	if err != nil {
+		return err
+	}
+
+	for _, set := range seedSets {
+		if err := s.seedTemplateSet(ctx, set, templatesDir, usr); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("database seeded successfully")
+
+	return nil
+}
+
+func newSeeder(db *pgxpool.Pool, v validation.V, logger trace.Logger) *seeder {
+	s := &seeder{
+		users:        user.NewUserRepository(db),
+		templates:    template.NewRepository(db),
+		templateSets: template.NewSetRepository(db),
+		validator:    v,
+		em:           event.NewManager(logger),
+		logger:       logger,
+	}
+
+	eiffel.SubscribeTemplateValidation(s.em, v, eiffel.LimitsCfg{}, func(templateSetID uuid.UUID) (map[string]*eiffel.BasicTemplate, error) {
+		return eiffel.BasicTemplatesOfSet(context.Background(), s.templates, templateSetID)
+	})
+
+	return s
+}
+
+// seedUser returns the user with the email address. The user is created if it does not exist yet.
+func (s *seeder) seedUser(ctx context.Context, toCreate *user.ToCreate) (*user.User, error) {
+	usr, err := s.users.FindByEmail(ctx, toCreate.Email)
+	if err == nil {
+		fmt.Printf("user %s already exists\n", usr.Email)
+		return usr, nil
+	}
+	if !errors.Is(err, persistence.ErrNotFound) {
+		return nil, err
+	}
+
+	err, validationErrs := s.validator.ValidateStruct(toCreate)
+	if err != nil {
+		return nil, err
+	}
+	if len(validationErrs) > 0 {
+		return nil, fmt.Errorf("invalid demo user: %w", errors.Join(validationErrs...))
+	}
+
+	usr, err = s.users.Create(ctx, toCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("created user %s\n", usr.Email)
+
+	return usr, nil
+}
+
+// seedTemplateSet creates the template set for the user and imports its templates. Templates are imported after
+// the templates they extend. The set is skipped if the user already has a set with the same name and version.
+func (s *seeder) seedTemplateSet(ctx context.Context, toSeed seedSet, templatesDir string, usr *user.User) error {
+	existing, err := s.templateSets.FindByCreatedBy(ctx, usr.ID)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return err
+	}
+
+	for _, set := range existing {
+		if set.Name == toSeed.Name && set.Version == toSeed.Version {
+			fmt.Printf("template set %s %s already exists\n", set.Name, set.Version)
+			return nil
+		}
+	}
+
+	configs, err := readConfigs(filepath.Join(templatesDir, toSeed.Dir))
+	if err != nil {
+		return err
+	}
+
+	set, err := s.templateSets.Create(ctx, &template.SetToCreate{
+		Name:        toSeed.Name,
+		Version:     toSeed.Version,
+		Description: toSeed.Description,
+		CreatedBy:   usr.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created template set %s %s\n", set.Name, set.Version)
+
+	for _, cfg := range configs {
+		if err := s.seedTemplate(ctx, cfg, set, usr); err != nil {
+			return fmt.Errorf("importing %s: %w", cfg.path, err)
+		}
+	}
+
+	return nil
+}
+
+// seedTemplate validates the template config the same way the web application does and creates the template.
+func (s *seeder) seedTemplate(ctx context.Context, cfg templateConfig, set *template.Set, usr *user.User) error {
+	toCreate, err := template.ToCreateFromConfig(cfg.config)
+	if err != nil {
+		return err
+	}
+
+	toCreate.TemplateSet = set.ID
+	toCreate.CreatedBy = usr.ID
+
+	validationErrs, err := template.ValidateTemplateToCreate(toCreate, s.validator, s.em, s.logger)
+	if err != nil {
+		return err
+	}
+	if len(validationErrs) > 0 {
+		return fmt.Errorf("invalid template: %w", errors.Join(validationErrs...))
+	}
+
+	t, err := s.templates.Create(ctx, toCreate)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  imported template %s %s\n", t.Name, t.Version)
+
+	return nil
+}
+
+// readConfigs reads all *.json files in the directory. The configs are sorted so that templates
+// are always imported after the templates they extend.
+func readConfigs(dir string) ([]templateConfig, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]templateConfig, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := templateConfig{path: path, config: string(content)}
+		_ = json.Unmarshal(content, &cfg.info) // invalid configs are reported during validation
+		configs = append(configs, cfg)
+	}
+
+	extends := make(map[string]string, len(configs))
+	for _, cfg := range configs {
+		extends[cfg.info.ID] = cfg.info.Extends
+	}
+
+	sort.SliceStable(configs, func(i, j int) bool {
+		return extendsDepth(configs[i].info.ID, extends) < extendsDepth(configs[j].info.ID, extends)
+	})
+
+	return configs, nil
+}
+
+// extendsDepth returns the number of templates the template extends transitively. Cycles are stopped
+// after visiting each template once, they are reported during validation.
+func extendsDepth(id string, extends map[string]string) int {
+	depth := 0
+	visited := map[string]bool{id: true}
+	for parent := extends[id]; parent != "" && !visited[parent]; parent = extends[parent] {
+		visited[parent] = true
+		depth++
+	}
+
+	return depth
+}
@@ -0,0 +1,356 @@
+// Command templatecheck validates template JSON files offline without a database.
+//
+// Usage:
+//
+//	templatecheck [-json] [-locale en] [-translations translations] [-config config] [-workers n] [-suppress checks] [-severity check=severity,...] <file|directory>...
+//
+// Directories are searched recursively for *.json files. Each file is validated through the template.config.validate
+// event pipeline, the same way templates are validated when they are created in the web application.
+// The files are validated concurrently by a pool of workers (see template.ValidationPipeline).
+// Templates extending other templates are resolved using all passed in files.
+// Valid templates are linted afterward (see template.LintTemplateConfig). Lint checks can be suppressed by a comma-separated
+// list of their names and their severity can be overridden, e.g. -suppress rule-hint -severity unused-rule=error.
+// Plugin rule types are registered from the EIFFEL config (see eiffel.LoadCfg) in the -config directory if it exists.
+// Templates with lint findings of severity error are invalid. The default size and complexity limits apply (see eiffel.LimitsCfg).
+// The command exits with status code 1 if any template is invalid and with status code 2 on usage errors.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/app/checklist"
+	"github.com/org-harmony/harmony/src/app/eiffel"
+	"github.com/org-harmony/harmony/src/app/story"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/core/config"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/herr"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+// Report is the machine-readable result of the templatecheck command.
+type Report struct {
+	Valid bool         `json:"valid"`
+	Files []FileReport `json:"files"`
+}
+
+// FileReport is the result of validating a single template file.
+type FileReport struct {
+	Path     string        `json:"path"`
+	Template string        `json:"template,omitempty"`
+	Version  string        `json:"version,omitempty"`
+	Valid    bool          `json:"valid"`
+	Errors   []ErrorReport `json:"errors,omitempty"`
+	Lint     []LintReport  `json:"lint,omitempty"`
+}
+
+// ErrorReport is a single validation error. Key is the untranslated error key, Message the translated message.
+type ErrorReport struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// LintReport is a single lint finding. Check is the name of the lint check, Message the translated message.
+type LintReport struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// templateFile is a template file read from disk.
+type templateFile struct {
+	path   string
+	config string
+	info   struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	readErr error
+}
+
+func main() {
+	jsonOutput := flag.Bool("json", false, "print the results as JSON")
+	locale := flag.String("locale", "en", "locale used to translate error messages")
+	translationsDir := flag.String("translations", "translations", "directory containing the translation files")
+	configDir := flag.String("config", config.Dir, "directory containing the config files, plugins are registered from eiffel.toml")
+	workers := flag.Int("workers", 0, "number of templates validated concurrently (default: number of CPUs)")
+	suppress := flag.String("suppress", "", "comma-separated lint checks that are not reported")
+	severity := flag.String("severity", "", "comma-separated severity overrides of lint checks, e.g. unused-rule=error")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: templatecheck [-json] [-locale en] [-translations dir] [-config dir] [-workers n] [-suppress checks] [-severity check=severity,...] <file|directory>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	lintCfg, err := parseLintCfg(*suppress, *severity)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	paths, err := collectFiles(flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// without a config file only the built-in rule types are known, like without translations the messages are untranslated
+	if _, err := eiffel.LoadCfg(validation.New(), config.FromDir(*configDir)); err != nil && !errors.Is(err, herr.ErrReadFile) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	logger := trace.NewWriterLogger(os.Stderr)
+	translator := trans.FromLocaleOrEmpty(*locale, *translationsDir, logger)
+	report := check(ctx, readFiles(paths), *workers, lintCfg, logger, translator)
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "validation canceled")
+		os.Exit(2)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	} else {
+		printReport(report)
+	}
+
+	if !report.Valid {
+		os.Exit(1)
+	}
+}
+
+// check validates all template files using the template.config.validate event pipeline and lints the valid ones.
+// Files are validated concurrently by at most workers goroutines, a number smaller than 1 uses the number of CPUs.
+func check(ctx context.Context, files []*templateFile, workers int, lintCfg eiffel.LintCfg, logger trace.Logger, translator trans.Translator) Report {
+	validator := validation.New()
+	em := event.NewManager(logger)
+
+	set := make(map[string]*eiffel.BasicTemplate)
+	for _, file := range files {
+		bt := &eiffel.BasicTemplate{}
+		if file.readErr == nil && json.Unmarshal([]byte(file.config), bt) == nil && bt.ID != "" {
+			set[bt.ID] = bt
+		}
+	}
+
+	templateSets := func(templateSetID uuid.UUID) (map[string]*eiffel.BasicTemplate, error) {
+		copied := make(map[string]*eiffel.BasicTemplate, len(set))
+		for id, bt := range set {
+			copied[id] = bt
+		}
+
+		return copied, nil
+	}
+	eiffel.SubscribeTemplateValidation(em, validator, eiffel.LimitsCfg{}, templateSets)
+	eiffel.SubscribeTemplateLint(em, lintCfg, templateSets)
+	checklist.SubscribeTemplateValidation(em, validator)
+	story.SubscribeTemplateValidation(em, validator)
+
+	fileErrs := validateFiles(ctx, files, template.NewValidationPipeline(em, logger, template.WithWorkers(workers)))
+
+	report := Report{Valid: true}
+	for i, file := range files {
+		fileReport := FileReport{Path: file.path, Template: file.info.Name, Version: file.info.Version}
+		for _, err := range fileErrs[i] {
+			fileReport.Errors = append(fileReport.Errors, ErrorReport{Key: err.Error(), Message: trans.TranslateErr(err, translator)})
+		}
+
+		if len(fileReport.Errors) == 0 && file.readErr == nil {
+			fileReport.Lint = lint(file, em, logger, translator)
+		}
+
+		fileReport.Valid = len(fileReport.Errors) == 0 && !hasLintErrors(fileReport.Lint)
+		report.Valid = report.Valid && fileReport.Valid
+		report.Files = append(report.Files, fileReport)
+	}
+
+	return report
+}
+
+// lint returns the lint findings of the valid template file. Failures of the linting are logged and reported as no findings.
+func lint(file *templateFile, em event.Manager, logger trace.Logger, translator trans.Translator) []LintReport {
+	findings, _ := template.LintTemplateConfig(file.config, strings.ToLower(file.info.Type), uuid.Nil, em, logger)
+
+	reports := make([]LintReport, 0, len(findings))
+	for _, finding := range findings {
+		reports = append(reports, LintReport{Check: finding.Check, Severity: string(finding.Severity), Message: finding.Translate(translator)})
+	}
+
+	return reports
+}
+
+func hasLintErrors(reports []LintReport) bool {
+	for _, report := range reports {
+		if report.Severity == string(template.LintSeverityError) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseLintCfg parses the -suppress and -severity flags. Both are comma-separated lists, the severity overrides are check=severity pairs.
+func parseLintCfg(suppress, severity string) (eiffel.LintCfg, error) {
+	cfg := eiffel.LintCfg{Severity: map[string]string{}}
+	for _, check := range strings.Split(suppress, ",") {
+		if check = strings.TrimSpace(check); check != "" {
+			cfg.Suppress = append(cfg.Suppress, check)
+		}
+	}
+
+	for _, override := range strings.Split(severity, ",") {
+		if strings.TrimSpace(override) == "" {
+			continue
+		}
+
+		check, level, ok := strings.Cut(override, "=")
+		if _, valid := template.ParseLintSeverity(strings.TrimSpace(level)); !ok || !valid {
+			return cfg, fmt.Errorf("invalid severity override %q, expected check=error|warning|info", override)
+		}
+
+		cfg.Severity[strings.TrimSpace(check)] = strings.TrimSpace(level)
+	}
+
+	return cfg, nil
+}
+
+// validateFiles returns the errors of each file in the order of the files. Files that could not be read
+// or are missing their type are not validated, all other files are validated by the pipeline.
+func validateFiles(ctx context.Context, files []*templateFile, pipeline *template.ValidationPipeline) [][]error {
+	fileErrs := make([][]error, len(files))
+	jobs := make([]template.ValidationJob, 0, len(files))
+	jobFiles := make([]int, 0, len(files))
+	for i, file := range files {
+		switch {
+		case file.readErr != nil:
+			fileErrs[i] = []error{file.readErr}
+		case file.info.Type == "":
+			fileErrs[i] = []error{template.ErrTemplateConfigMissingInfo}
+		default:
+			jobs = append(jobs, template.ValidationJob{Config: file.config, TemplateType: strings.ToLower(file.info.Type)})
+			jobFiles = append(jobFiles, i)
+		}
+	}
+
+	for j, result := range pipeline.Validate(ctx, jobs) {
+		if result.Err != nil {
+			fileErrs[jobFiles[j]] = []error{result.Err}
+			continue
+		}
+
+		fileErrs[jobFiles[j]] = result.Errs
+	}
+
+	return fileErrs
+}
+
+// collectFiles returns all files passed in and all *.json files inside the passed in directories (recursively).
+func collectFiles(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+				paths = append(paths, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
+
+func readFiles(paths []string) []*templateFile {
+	files := make([]*templateFile, 0, len(paths))
+	for _, path := range paths {
+		file := &templateFile{path: path}
+		files = append(files, file)
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			file.readErr = err
+			continue
+		}
+
+		file.config = string(content)
+		if err := json.Unmarshal(content, &file.info); err != nil {
+			file.readErr = fmt.Errorf("invalid JSON: %w", err)
+		}
+	}
+
+	return files
+}
+
+func printReport(report Report) {
+	for _, file := range report.Files {
+		name := ""
+		if file.Template != "" {
+			name = fmt.Sprintf(" (%s %s)", file.Template, file.Version)
+		}
+
+		if file.Valid {
+			fmt.Printf("OK   %s%s\n", file.Path, name)
+		} else {
+			fmt.Printf("FAIL %s%s\n", file.Path, name)
+		}
+
+		for _, err := range file.Errors {
+			fmt.Printf("     - %s\n", err.Message)
+		}
+		for _, finding := range file.Lint {
+			fmt.Printf("     - [%s] %s (%s)\n", finding.Severity, finding.Message, finding.Check)
+		}
+	}
+
+	invalid := 0
+	for _, file := range report.Files {
+		if !file.Valid {
+			invalid++
+		}
+	}
+
+	fmt.Printf("\n%d template(s) checked, %d invalid\n", len(report.Files), invalid)
+}
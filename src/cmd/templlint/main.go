@@ -0,0 +1,467 @@
+// Command templlint checks the references between Go sources, HTML templates and translations offline.
+//
+// Usage:
+//
+//	templlint [-json] [-templates templates] [-base templates/base] [-src src] [-translations translations] [-locale de] [-ignore names]
+//
+// All *.go.html files in the templates directory are parsed and the following is reported:
+//   - undefined-template: a {{ template "x" }} whose target is not defined by any template file
+//   - missing-translation: a translation key used with t, tf, tn or ts (or piped to t) that does not exist in the locale
+//
+// The Go sources are searched for calls of io.Render, io.RespondNegotiated and io.RenderBlock with literal arguments:
+//   - missing-template-file: a template path that does not exist in the templates directory
+//   - unknown-render-template: a template or block name that is not defined by the passed in paths or the base templates
+//
+// Names, paths and keys that are not string literals can not be checked and are skipped. Test files are skipped.
+// Template names and translation keys that are deliberately undefined can be ignored by a comma-separated list,
+// e.g. -ignore content-empty,content-partial for the content placeholders that pages must override.
+// The locale should be the default locale (see trans.toml), all other locales fall back to it.
+// The command exits with status code 1 if anything is reported and with status code 2 on usage errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// Report is the machine-readable result of the templlint command.
+type Report struct {
+	Valid    bool      `json:"valid"`
+	Findings []Finding `json:"findings"`
+}
+
+// Finding is a single broken reference. Check is the name of the check reporting it.
+type Finding struct {
+	Check   string `json:"check"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// templateFile is a parsed template file. Defines are the names of the templates and blocks it defines.
+type templateFile struct {
+	path    string
+	content string
+	defines map[string]bool
+	trees   map[string]*parse.Tree
+}
+
+// renderCall is a call of a render method with literal arguments found in the Go sources.
+type renderCall struct {
+	file  string
+	line  int
+	name  string
+	paths []string
+}
+
+// translationFuncs are the template functions translating their first argument.
+var translationFuncs = map[string]bool{"t": true, "tf": true, "tn": true, "ts": true}
+
+func main() {
+	jsonOutput := flag.Bool("json", false, "print the results as JSON")
+	templatesDir := flag.String("templates", "templates", "directory containing the templates")
+	baseDir := flag.String("base", "templates/base", "directory containing the base templates")
+	srcDir := flag.String("src", "src", "directory containing the Go sources")
+	translationsDir := flag.String("translations", "translations", "directory containing the translation files")
+	locale := flag.String("locale", "de", "default locale the translation keys are checked against")
+	ignore := flag.String("ignore", "", "comma-separated template names and translation keys that are not reported")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: templlint [-json] [-templates dir] [-base dir] [-src dir] [-translations dir] [-locale de] [-ignore names]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	translations, err := trans.LoadTranslations(*translationsDir, *locale)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	files, findings, err := parseTemplates(*templatesDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	calls, err := findRenderCalls(*srcDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ignored := make(map[string]bool)
+	for _, name := range strings.Split(*ignore, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ignored[name] = true
+		}
+	}
+
+	findings = append(findings, checkTemplates(files, translationKeys(translations), ignored)...)
+	findings = append(findings, checkRenderCalls(calls, files, *templatesDir, *baseDir)...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+
+		return findings[i].Line < findings[j].Line
+	})
+
+	report := Report{Valid: len(findings) == 0, Findings: findings}
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	} else {
+		printReport(report, len(files), len(calls))
+	}
+
+	if !report.Valid {
+		os.Exit(1)
+	}
+}
+
+// parseTemplates parses all *.go.html files in the directory (recursively) by their path relative to the directory.
+// Files that can not be parsed are reported as parse-error findings.
+func parseTemplates(dir string) (map[string]*templateFile, []Finding, error) {
+	files := make(map[string]*templateFile)
+	var findings []Finding
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go.html") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		file := &templateFile{path: rel, content: string(content), defines: make(map[string]bool), trees: make(map[string]*parse.Tree)}
+		tree := parse.New(rel)
+		tree.Mode = parse.SkipFuncCheck
+		if _, err := tree.Parse(file.content, "", "", file.trees); err != nil {
+			findings = append(findings, Finding{Check: "parse-error", File: rel, Line: 1, Message: err.Error()})
+			return nil
+		}
+
+		for name := range file.trees {
+			if name != rel {
+				file.defines[name] = true
+			}
+		}
+		files[rel] = file
+
+		return nil
+	})
+
+	return files, findings, err
+}
+
+// translationKeys returns all keys of the flattened translations including the keys of the variant objects,
+// e.g. "items" of "items.one" and "items.other" as they are used by tn and ts.
+func translationKeys(translations map[string]string) map[string]bool {
+	keys := make(map[string]bool, len(translations))
+	for key := range translations {
+		keys[key] = true
+		for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+			keys[key[:i]] = true
+		}
+	}
+
+	return keys
+}
+
+// checkTemplates reports undefined template targets and missing translation keys of all template files
+// unless the name or key is ignored.
+func checkTemplates(files map[string]*templateFile, keys map[string]bool, ignored map[string]bool) []Finding {
+	defined := make(map[string]bool)
+	for _, file := range files {
+		for name := range file.defines {
+			defined[name] = true
+		}
+	}
+
+	var findings []Finding
+	for _, file := range files {
+		for _, tree := range file.trees {
+			walk(tree.Root, func(node parse.Node) {
+				switch n := node.(type) {
+				case *parse.TemplateNode:
+					if !defined[n.Name] && !ignored[n.Name] {
+						findings = append(findings, file.finding("undefined-template", n.Pos, fmt.Sprintf("template %q is not defined", n.Name)))
+					}
+				case *parse.CommandNode:
+					if key, ok := calledTranslationKey(n); ok && !keys[key] && !ignored[key] {
+						findings = append(findings, file.finding("missing-translation", n.Pos, fmt.Sprintf("translation key %q does not exist", key)))
+					}
+				case *parse.PipeNode:
+					for _, key := range pipedTranslationKeys(n) {
+						if !keys[key] && !ignored[key] {
+							findings = append(findings, file.finding("missing-translation", n.Pos, fmt.Sprintf("translation key %q does not exist", key)))
+						}
+					}
+				}
+			})
+		}
+	}
+
+	return findings
+}
+
+// calledTranslationKey returns the key of a translation function called with a string literal, e.g. {{ t "key" }}.
+func calledTranslationKey(cmd *parse.CommandNode) (string, bool) {
+	if len(cmd.Args) < 2 {
+		return "", false
+	}
+
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok || !translationFuncs[ident.Ident] {
+		return "", false
+	}
+
+	key, ok := cmd.Args[1].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+
+	return key.Text, true
+}
+
+// pipedTranslationKeys returns the keys of string literals piped to t, e.g. {{ "key" | t }}.
+func pipedTranslationKeys(pipe *parse.PipeNode) []string {
+	var keys []string
+	for i := 1; i < len(pipe.Cmds); i++ {
+		cmd, previous := pipe.Cmds[i], pipe.Cmds[i-1]
+		if len(cmd.Args) != 1 || len(previous.Args) != 1 {
+			continue
+		}
+
+		ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+		if !ok || ident.Ident != "t" {
+			continue
+		}
+
+		if key, ok := previous.Args[0].(*parse.StringNode); ok {
+			keys = append(keys, key.Text)
+		}
+	}
+
+	return keys
+}
+
+// walk calls the function for the node and all of its descendants.
+func walk(node parse.Node, f func(parse.Node)) {
+	if node == nil {
+		return
+	}
+
+	f(node)
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walk(child, f)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, f)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walk(cmd, f)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walk(arg, f)
+		}
+	case *parse.TemplateNode:
+		walk(n.Pipe, f)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, f)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, f)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, f)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, f func(parse.Node)) {
+	walk(n.Pipe, f)
+	walk(n.List, f)
+	walk(n.ElseList, f)
+}
+
+// finding returns a finding of the check at the byte position of the file.
+func (file *templateFile) finding(check string, pos parse.Pos, message string) Finding {
+	line := 1 + strings.Count(file.content[:min(int(pos), len(file.content))], "\n")
+	return Finding{Check: check, File: file.path, Line: line, Message: message}
+}
+
+// findRenderCalls returns the calls of Render, RespondNegotiated and RenderBlock in the Go sources of the directory
+// whose name and paths are string literals and whose paths are templates (*.go.html). Test files are skipped.
+func findRenderCalls(dir string) ([]renderCall, error) {
+	var calls []renderCall
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			if c, ok := renderCallOf(call); ok {
+				c.file = path
+				c.line = fset.Position(call.Pos()).Line
+				calls = append(calls, c)
+			}
+
+			return true
+		})
+
+		return nil
+	})
+
+	return calls, err
+}
+
+// renderCallOf returns the render call of the call expression. The name is the template name of Render and
+// RespondNegotiated (data, name, paths...) or the block name of RenderBlock (data, templater, block, paths...).
+func renderCallOf(call *ast.CallExpr) (renderCall, bool) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return renderCall{}, false
+	}
+
+	var nameIndex int
+	switch selector.Sel.Name {
+	case "Render", "RespondNegotiated":
+		nameIndex = 1
+	case "RenderBlock":
+		nameIndex = 2
+	default:
+		return renderCall{}, false
+	}
+
+	if len(call.Args) <= nameIndex+1 || call.Ellipsis.IsValid() {
+		return renderCall{}, false
+	}
+
+	name, ok := stringLiteral(call.Args[nameIndex])
+	if !ok {
+		return renderCall{}, false
+	}
+
+	c := renderCall{name: name}
+	for _, arg := range call.Args[nameIndex+1:] {
+		path, ok := stringLiteral(arg)
+		if !ok || !strings.HasSuffix(path, ".go.html") {
+			return renderCall{}, false
+		}
+
+		c.paths = append(c.paths, path)
+	}
+
+	return c, true
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// checkRenderCalls reports render calls with missing template files and names that are neither defined by the
+// call's template files nor by the base templates (which every Templater derives from).
+func checkRenderCalls(calls []renderCall, files map[string]*templateFile, templatesDir string, baseDir string) []Finding {
+	base := make(map[string]bool)
+	if rel, err := filepath.Rel(templatesDir, baseDir); err == nil {
+		prefix := filepath.ToSlash(rel) + "/"
+		for path, file := range files {
+			if !strings.HasPrefix(path, prefix) {
+				continue
+			}
+			for name := range file.defines {
+				base[name] = true
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, call := range calls {
+		defined := base[call.name]
+		for _, path := range call.paths {
+			file, ok := files[path]
+			if !ok {
+				findings = append(findings, Finding{Check: "missing-template-file", File: call.file, Line: call.line, Message: fmt.Sprintf("template file %q does not exist", path)})
+				continue
+			}
+
+			defined = defined || file.defines[call.name]
+		}
+
+		if !defined {
+			findings = append(findings, Finding{Check: "unknown-render-template", File: call.file, Line: call.line,
+				Message: fmt.Sprintf("template %q is not defined in %s", call.name, strings.Join(call.paths, ", "))})
+		}
+	}
+
+	return findings
+}
+
+func printReport(report Report, templates int, calls int) {
+	for _, finding := range report.Findings {
+		fmt.Printf("%s:%d: %s (%s)\n", finding.File, finding.Line, finding.Message, finding.Check)
+	}
+
+	fmt.Printf("\n%d template(s) and %d render call(s) checked, %d problem(s) found\n", templates, calls, len(report.Findings))
+}
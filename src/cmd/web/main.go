@@ -1,25 +1,51 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/app/admin"
+	"github.com/org-harmony/harmony/src/app/attachment"
+	attachmentWeb "github.com/org-harmony/harmony/src/app/attachment/web"
+	"github.com/org-harmony/harmony/src/app/audit"
+	checklistWeb "github.com/org-harmony/harmony/src/app/checklist/web"
+	"github.com/org-harmony/harmony/src/app/comment"
+	commentWeb "github.com/org-harmony/harmony/src/app/comment/web"
 	"github.com/org-harmony/harmony/src/app/eiffel"
+	"github.com/org-harmony/harmony/src/app/gallery"
+	galleryWeb "github.com/org-harmony/harmony/src/app/gallery/web"
 	homeWeb "github.com/org-harmony/harmony/src/app/home"
+	"github.com/org-harmony/harmony/src/app/integration"
+	"github.com/org-harmony/harmony/src/app/notification"
+	notificationWeb "github.com/org-harmony/harmony/src/app/notification/web"
+	"github.com/org-harmony/harmony/src/app/project"
+	projectWeb "github.com/org-harmony/harmony/src/app/project/web"
+	"github.com/org-harmony/harmony/src/app/requirement"
+	requirementWeb "github.com/org-harmony/harmony/src/app/requirement/web"
+	storyWeb "github.com/org-harmony/harmony/src/app/story/web"
 	"github.com/org-harmony/harmony/src/app/template"
 	templateWeb "github.com/org-harmony/harmony/src/app/template/web"
 	"github.com/org-harmony/harmony/src/app/user"
 	userWeb "github.com/org-harmony/harmony/src/app/user/web"
 	"github.com/org-harmony/harmony/src/core/config"
+	"github.com/org-harmony/harmony/src/core/crypto"
 	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/feature"
 	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/outbox"
 	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"github.com/org-harmony/harmony/src/core/trace"
 	"github.com/org-harmony/harmony/src/core/trans"
 	"github.com/org-harmony/harmony/src/core/util"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"github.com/org-harmony/harmony/src/core/web"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
-// TODO add larger integration/e2e tests for the web layer. Each controller and they're functions should be tested.
+// TODO add e2e tests (see src/e2e) for the remaining controllers of the web layer. Each controller and their functions should be tested.
 // TODO add module management to automatically register controllers and subscribe to events
 // TODO evaluate events using code generation for type safety and performance
 // TODO add extensive use of events for module management and all major application parts
@@ -32,56 +58,200 @@ import (
 // TODO add cleanup task for expired sessions
 // TODO add info for esfa about prozessbeschreibung being potentially long
 // TODO add info for esfa about potentially complex <System> definition
+// TODO add a read-only GraphQL endpoint (/api/graphql) over templates, template sets and requirements with cursor pagination
+// and field-level authorization. This requires an API with token authentication and a GraphQL library, neither exists yet.
 
 func main() {
-	logger := trace.NewLogger()
 	validator := initValidator()
-	eventManager := event.NewManager(logger)
+	logger, reporter := initTrace(validator)
+	metrics := trace.NewMemoryMetrics()
 
-	provider, db := initDB(validator)
-	defer db.Close()
+	cipher := initCrypto(validator, logger)
+	provider, db, stopPoolStats := initDB(validator, logger, metrics, cipher)
+
+	outboxCfg := initOutbox(validator)
+	outboxRepository := util.UnwrapType[outbox.Repository](provider.Repository(outbox.RepositoryName))
+	eventManager := event.NewManager(
+		logger,
+		event.WithReporter(reporter),
+		event.WithMetrics(metrics),
+		event.WithFailureHandler(outbox.DeadLetter(outboxRepository, logger, outboxCfg.RetryPolicy())),
+	)
 
 	appCtx := hctx.NewAppCtx(logger, validator, provider, eventManager)
+	appCtx.Metrics = metrics
+	appCtx.Reporter = reporter
+	appCtx.OnShutdown("persistence", func(ctx context.Context) error {
+		stopPoolStats()
+		db.Close()
+		return nil
+	})
+	registerKeyRotation(appCtx, cipher)
+	tenants := initTenancy(validator)
+	registerOutboxRelay(appCtx, outboxRepository, outboxCfg, tenants)
 	translatorProvider := initTrans(validator, logger)
-	webCtx, r := initWeb(appCtx, validator, translatorProvider)
+	webCtx, r := initWeb(appCtx, validator, translatorProvider, tenants)
 
 	homeWeb.RegisterController(appCtx, webCtx)
 	userWeb.RegisterController(appCtx, webCtx)
 	templateWeb.RegisterController(appCtx, webCtx)
+	attachmentWeb.RegisterController(appCtx, webCtx)
+	notificationWeb.RegisterController(appCtx, webCtx)
 	eiffel.RegisterController(appCtx, webCtx)
+	checklistWeb.RegisterController(appCtx, webCtx)
+	storyWeb.RegisterController(appCtx, webCtx)
+	requirementWeb.RegisterController(appCtx, webCtx)
+	projectWeb.RegisterController(appCtx, webCtx)
+	commentWeb.RegisterController(appCtx, webCtx)
+	galleryWeb.RegisterController(appCtx, webCtx)
+	admin.RegisterController(appCtx, webCtx, translatorProvider)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	util.Ok(web.Serve(r, webCtx.Config.Server))
+	util.Ok(appCtx.Init(ctx))
+	if err := web.Serve(ctx, r, webCtx.Config.Server); err != nil {
+		logger.Error(web.Pkg, "server stopped unexpectedly", err)
+	}
+	stop()
+
+	// the shutdown hooks release the resources after the server stopped serving requests, e.g. the outbox relay and the database
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), web.ShutdownTimeout)
+	defer cancel()
+	if err := appCtx.Shutdown(shutdownCtx); err != nil {
+		logger.Error(hctx.Pkg, "failed to shut down the application", err)
+	}
 }
 
 func initValidator() validation.V {
 	return validation.New()
 }
 
-func initWeb(appCtx *hctx.AppCtx, v validation.V, tp trans.TranslatorProvider) (*web.Ctx, web.Router) {
+func initTrace(v validation.V) (trace.Logger, trace.Reporter) {
+	traceCfg := &trace.Cfg{}
+	util.Ok(config.C(traceCfg, config.From("trace"), config.Validate(v)))
+	logger := util.Unwrap(trace.FromCfg(traceCfg))
+
+	return logger, util.Unwrap(trace.NewReporter(traceCfg.Reporting, logger))
+}
+
+func initWeb(appCtx *hctx.AppCtx, v validation.V, tp trans.TranslatorProvider, tenants *tenant.Resolver) (*web.Ctx, web.Router) {
 	webCfg := &web.Cfg{}
 	util.Ok(config.C(webCfg, config.From("web"), config.Validate(v)))
-	store := util.Unwrap(web.SetupTemplaterStore(webCfg.UI))
+	manifest := initAssetManifest(webCfg.Server.AssetFsCfg)
+	store := util.Unwrap(web.SetupTemplaterStore(webCfg.UI, web.WithAssetManifest(manifest)))
 
 	r := web.NewRouter()
-	registerMiddlewares(appCtx, r, tp)
+	registerMiddlewares(appCtx, r, webCfg.Limits, tp, tenants, initFeatures(v))
 
-	web.MountFileServer(r, webCfg.Server.AssetFsCfg)
+	web.MountFileServer(r, webCfg.Server.AssetFsCfg, web.WithFingerprints(manifest))
 
 	webCtx := web.NewContext(r, webCfg, store)
 
 	return webCtx, r
 }
 
-func initDB(v validation.V) (persistence.RepositoryProvider, *pgxpool.Pool) {
+func initAssetManifest(cfg *web.FileServerCfg) *web.AssetManifest {
+	if !cfg.Fingerprint {
+		return nil
+	}
+
+	return util.Unwrap(web.NewAssetManifest(cfg.Root))
+}
+
+func initTenancy(v validation.V) *tenant.Resolver {
+	tenantCfg := &tenant.Cfg{}
+	util.Ok(config.C(tenantCfg, config.From("tenant"), config.Validate(v)))
+
+	return util.Unwrap(tenant.NewResolver(tenantCfg))
+}
+
+func initFeatures(v validation.V) *feature.Flags {
+	featureCfg := &feature.Cfg{}
+	util.Ok(config.C(featureCfg, config.From("feature"), config.Validate(v)))
+
+	return util.Unwrap(feature.New(featureCfg))
+}
+
+// initCrypto returns the cipher encrypting sensitive values. It is nil if no key is configured.
+func initCrypto(v validation.V, logger trace.Logger) *crypto.Cipher {
+	cryptoCfg := &crypto.Cfg{}
+	util.Ok(config.C(cryptoCfg, config.From("crypto"), config.Validate(v)))
+
+	cipher, err := crypto.New(cryptoCfg)
+	if errors.Is(err, crypto.ErrNoKey) {
+		logger.Warn(crypto.Pkg, "no encryption key configured, integration credentials can not be stored")
+		return nil
+	}
+
+	return util.Unwrap(cipher, err)
+}
+
+// registerKeyRotation re-encrypts the integration credentials encrypted with a previous key on startup.
+func registerKeyRotation(appCtx *hctx.AppCtx, cipher *crypto.Cipher) {
+	if cipher == nil {
+		return
+	}
+
+	appCtx.OnInit(integration.Pkg, func(ctx context.Context) error {
+		repository := util.UnwrapType[integration.Repository](appCtx.Repository(integration.RepositoryName))
+
+		rotated, err := repository.RotateKeys(ctx)
+		if err != nil {
+			return err
+		}
+
+		if rotated > 0 {
+			appCtx.Info(integration.Pkg, "re-encrypted integration credentials with the primary key", "count", rotated)
+		}
+
+		return nil
+	})
+}
+
+func initOutbox(v validation.V) *outbox.Cfg {
+	outboxCfg := &outbox.Cfg{}
+	util.Ok(config.C(outboxCfg, config.From("outbox"), config.Validate(v)))
+
+	return outboxCfg
+}
+
+// registerOutboxRelay publishes the events stored in the outbox from application init until shutdown.
+// Failed asynchronously published events are stored in the outbox as well, see outbox.DeadLetter.
+func registerOutboxRelay(appCtx *hctx.AppCtx, repository outbox.Repository, outboxCfg *outbox.Cfg, tenants *tenant.Resolver) {
+	relay := outbox.NewRelay(
+		repository,
+		appCtx.EventManager,
+		appCtx.Logger,
+		outboxCfg,
+		outbox.WithMetrics(appCtx.Metrics),
+		outbox.WithTenants(tenants.Tenant),
+	)
+
+	appCtx.OnInit(outbox.Pkg, func(ctx context.Context) error {
+		relay.Start()
+		return nil
+	})
+	appCtx.OnShutdown(outbox.Pkg, func(ctx context.Context) error {
+		relay.Stop()
+		return nil
+	})
+}
+
+// initDB returns the repository provider, the database connection pool and the function stopping the pool's statistics.
+func initDB(v validation.V, logger trace.Logger, metrics trace.Metrics, cipher *crypto.Cipher) (persistence.RepositoryProvider, *pgxpool.Pool, func()) {
 	dbCfg := &persistence.Cfg{}
 	util.Ok(config.C(dbCfg, config.From("persistence"), config.Validate(v)))
-	db := util.Unwrap(persistence.NewDB(dbCfg.DB))
+	util.Ok(dbCfg.Pool.Validate(dbCfg.DB))
+	tracer := persistence.NewQueryTracer(dbCfg.Tracing, logger, metrics)
+	db := util.Unwrap(persistence.NewDB(dbCfg.DB, persistence.WithQueryTracer(tracer), persistence.WithPool(dbCfg.Pool)))
+	stopPoolStats := persistence.WatchPoolStats(db, dbCfg.Pool, logger, metrics)
 
-	return initRepositoryProvider(db), db
+	return initRepositoryProvider(db, dbCfg.Timeouts, cipher), db, stopPoolStats
 }
 
-func initRepositoryProvider(db *pgxpool.Pool) persistence.RepositoryProvider {
-	p := persistence.NewPGRepositoryProvider(db)
+func initRepositoryProvider(db *pgxpool.Pool, timeouts *persistence.TimeoutCfg, cipher *crypto.Cipher) persistence.RepositoryProvider {
+	p := persistence.NewPGRepositoryProvider(db, persistence.WithTimeouts(timeouts))
 
 	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
 		return user.NewUserRepository(db.(*pgxpool.Pool)), nil
@@ -89,12 +259,63 @@ func initRepositoryProvider(db *pgxpool.Pool) persistence.RepositoryProvider {
 	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
 		return user.NewPGUserSessionRepository(db.(*pgxpool.Pool)), nil
 	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return user.NewPreferenceRepository(db.(*pgxpool.Pool)), nil
+	}))
 	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
 		return template.NewRepository(db.(*pgxpool.Pool)), nil
 	}))
 	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
 		return template.NewSetRepository(db.(*pgxpool.Pool)), nil
 	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return template.NewLabelRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return template.NewSourceRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return template.NewHealthRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return template.NewFavoriteRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return attachment.NewRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return web.NewPGWizardRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return notification.NewRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return integration.NewRepository(db.(*pgxpool.Pool), cipher), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return requirement.NewRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return requirement.NewTagRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return requirement.NewReviewRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return project.NewRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return comment.NewRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return gallery.NewRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return outbox.NewRepository(db.(*pgxpool.Pool)), nil
+	}))
+	util.Ok(p.RegisterRepository(func(db any) (persistence.Repository, error) {
+		return audit.NewRepository(db.(*pgxpool.Pool)), nil
+	}))
 
 	return p
 }
@@ -107,12 +328,23 @@ func initTrans(v validation.V, logger trace.Logger) trans.TranslatorProvider {
 	return provider
 }
 
-func registerMiddlewares(appCtx *hctx.AppCtx, r web.Router, translatorProvider trans.TranslatorProvider) {
+func registerMiddlewares(
+	appCtx *hctx.AppCtx,
+	r web.Router,
+	limits *web.LimitCfg,
+	translatorProvider trans.TranslatorProvider,
+	tenants *tenant.Resolver,
+	flags *feature.Flags,
+) {
 	r.Use(
-		web.Recoverer,
+		web.RequestID,
+		web.Recoverer(appCtx),
 		web.Heartbeat("/ping"),
 		web.CleanPath,
-		user.LoggedInMiddleware(appCtx, user.AllowAnonymous),
+		web.BodyLimit(limits),
+		tenant.Middleware(tenants),
+		user.LoggedInMiddleware(appCtx, user.AllowAnonymous, user.RotateOnPrivilegeChange(admin.IsAdmin(flags))),
+		feature.Middleware(flags, user.FeatureSubject),
 		trans.Middleware(translatorProvider),
 	)
 }
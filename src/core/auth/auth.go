@@ -2,6 +2,7 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"github.com/org-harmony/harmony/src/core/persistence"
@@ -24,10 +25,36 @@ var (
 	ErrCodeExchangeFailed = errors.New("code exchange failed")
 )
 
+const (
+	// DefaultIdleTimeout is the idle timeout of sessions if none is configured, see SessionCfg.IdleTimeout.
+	DefaultIdleTimeout = 3 * time.Hour
+	// DefaultAbsoluteTimeout is the absolute timeout of sessions if none is configured, see SessionCfg.AbsoluteTimeout.
+	DefaultAbsoluteTimeout = 24 * time.Hour
+)
+
 // Cfg is the config for the auth package. It contains necessary information about the OAuth2 providers.
 type Cfg struct {
 	Providers    map[string]*ProviderCfg `toml:"provider"` // Providers contains a list of OAuth2 providers.
 	EnableOAuth2 bool                    `toml:"enable_oauth2"`
+	Session      SessionCfg              `toml:"session"` // Session configures the lifetime of sessions.
+}
+
+// SessionCfg configures the lifetime of sessions. Durations are in minutes, zero values fall back to the defaults.
+// A session expires after it was not used for the idle timeout or after the absolute timeout since the login, whichever comes first.
+type SessionCfg struct {
+	// IdleTimeout is the duration a session may be unused before it expires. Defaults to DefaultIdleTimeout.
+	IdleTimeout int `toml:"idle_timeout"`
+	// AbsoluteTimeout is the duration after the login a session expires regardless of its use. Defaults to DefaultAbsoluteTimeout.
+	AbsoluteTimeout int `toml:"absolute_timeout"`
+	// RememberMe configures long-lived sessions users can opt in to when logging in.
+	RememberMe RememberMeCfg `toml:"remember_me"`
+}
+
+// RememberMeCfg configures long-lived sessions ("remember me"). Durations are in minutes, zero values fall back to the SessionCfg's timeouts.
+type RememberMeCfg struct {
+	Enabled         bool `toml:"enabled"`
+	IdleTimeout     int  `toml:"idle_timeout"`
+	AbsoluteTimeout int  `toml:"absolute_timeout"`
 }
 
 // ProviderCfg is the config for an OAuth2 provider.
@@ -42,6 +69,9 @@ type ProviderCfg struct {
 	ClientID       string   `toml:"client_id" hvalidate:"required"`
 	ClientSecret   string   `toml:"client_secret" hvalidate:"required"`
 	Scopes         []string `toml:"scopes" hvalidate:"required"`
+	// RevocationSecret authenticates notifications that a user's access was revoked at the provider, see IsRevocationSecret.
+	// Notifications are rejected if it is empty.
+	RevocationSecret string `toml:"revocation_secret"`
 }
 
 // LoginFunc is the callback function for the OAuthLogin function it is responsible for creating the user session.
@@ -94,16 +124,35 @@ func OAuthCfgFromProviderCfg(p *ProviderCfg, redirectURL string) *oauth2.Config
 	}
 }
 
+// Timeouts returns the idle and absolute timeout of a session. If rememberMe is true and remember me is enabled,
+// the timeouts of long-lived sessions are returned.
+func (c *SessionCfg) Timeouts(rememberMe bool) (time.Duration, time.Duration) {
+	idle := minutesOr(c.IdleTimeout, DefaultIdleTimeout)
+	absolute := minutesOr(c.AbsoluteTimeout, DefaultAbsoluteTimeout)
+
+	if !rememberMe || !c.RememberMe.Enabled {
+		return idle, absolute
+	}
+
+	return minutesOr(c.RememberMe.IdleTimeout, idle), minutesOr(c.RememberMe.AbsoluteTimeout, absolute)
+}
+
 // SetSession sets the session cookie on the response.
 // The session id is used as the cookie value.
 // The cookie expires at the same time as the session + 48 hours.
 // This allows for db cleanup when using an expired session that is still sent to the backend.
 func SetSession[P, M any](w http.ResponseWriter, name string, session *persistence.Session[P, M]) {
+	SetSessionUntil(w, name, session, session.ExpiresAt.Add(48*time.Hour)) // will be validated by the backend but allows for db cleanup
+}
+
+// SetSessionUntil sets the session cookie on the response, the cookie expires at the passed in time.
+// It is used for long-lived sessions whose cookie has to outlive the session's current expiry.
+func SetSessionUntil[P, M any](w http.ResponseWriter, name string, session *persistence.Session[P, M], expires time.Time) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     name,
 		Value:    session.ID.String(),
-		Expires:  session.ExpiresAt.Add(48 * time.Hour), // will be validated by the backend but allows for db cleanup
-		SameSite: http.SameSiteLaxMode,                  // must be lax for OAuth2, otherwise redirect will lead to weird states
+		Expires:  expires,
+		SameSite: http.SameSiteLaxMode, // must be lax for OAuth2, otherwise redirect will lead to weird states
 		Path:     "/",
 		Secure:   true,
 		HttpOnly: true,
@@ -122,3 +171,18 @@ func ClearSession(w http.ResponseWriter, name string) {
 		HttpOnly: true,
 	})
 }
+
+// IsRevocationSecret returns true if the secret equals the provider's RevocationSecret. The secrets are compared in
+// constant time. It always returns false if the provider has no RevocationSecret.
+func (p *ProviderCfg) IsRevocationSecret(secret string) bool {
+	return p.RevocationSecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(p.RevocationSecret)) == 1
+}
+
+// minutesOr converts the minutes to a duration. It returns the fallback if minutes is not positive.
+func minutesOr(minutes int, fallback time.Duration) time.Duration {
+	if minutes <= 0 {
+		return fallback
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
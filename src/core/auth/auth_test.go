@@ -99,6 +99,46 @@ func TestSetSession(t *testing.T) {
 	assert.Equal(t, session.ExpiresAt.UTC().Add(48*time.Hour).Truncate(time.Second), cookie.Expires.UTC().Truncate(time.Second))
 }
 
+func TestSetSessionUntil(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	session := &persistence.Session[MockUser, MockMeta]{
+		ID:        uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	expires := time.Now().Add(30 * 24 * time.Hour)
+	SetSessionUntil(w, "test", session, expires)
+
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, session.ID.String(), cookies[0].Value)
+	assert.Equal(t, expires.UTC().Truncate(time.Second), cookies[0].Expires.UTC().Truncate(time.Second))
+}
+
+func TestSessionCfg_Timeouts(t *testing.T) {
+	cfg := &SessionCfg{}
+	idle, absolute := cfg.Timeouts(false)
+	assert.Equal(t, DefaultIdleTimeout, idle)
+	assert.Equal(t, DefaultAbsoluteTimeout, absolute)
+
+	idle, absolute = cfg.Timeouts(true)
+	assert.Equal(t, DefaultIdleTimeout, idle, "remember me is disabled")
+	assert.Equal(t, DefaultAbsoluteTimeout, absolute)
+
+	cfg = &SessionCfg{
+		IdleTimeout:     30,
+		AbsoluteTimeout: 480,
+		RememberMe:      RememberMeCfg{Enabled: true, AbsoluteTimeout: 43200},
+	}
+	idle, absolute = cfg.Timeouts(false)
+	assert.Equal(t, 30*time.Minute, idle)
+	assert.Equal(t, 8*time.Hour, absolute)
+
+	idle, absolute = cfg.Timeouts(true)
+	assert.Equal(t, 30*time.Minute, idle, "falls back to the idle timeout of sessions")
+	assert.Equal(t, 30*24*time.Hour, absolute)
+}
+
 func TestClearSession(t *testing.T) {
 	w := httptest.NewRecorder()
 
@@ -136,3 +176,10 @@ func mockOAuthServer(t *testing.T) *httptest.Server {
 		assert.NoError(t, err)
 	}))
 }
+
+func TestProviderCfg_IsRevocationSecret(t *testing.T) {
+	assert.False(t, (&ProviderCfg{}).IsRevocationSecret(""), "notifications are rejected without a secret")
+	assert.True(t, (&ProviderCfg{RevocationSecret: "secret"}).IsRevocationSecret("secret"))
+	assert.False(t, (&ProviderCfg{RevocationSecret: "secret"}).IsRevocationSecret("secret2"))
+	assert.False(t, (&ProviderCfg{RevocationSecret: "secret"}).IsRevocationSecret(""))
+}
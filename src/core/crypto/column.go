@@ -0,0 +1,63 @@
+package crypto
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// EncryptedColumn is a text column of a repository storing an encrypted string (see Cipher.Column).
+// It implements sql.Scanner and driver.Valuer: the value is encrypted when it is written and decrypted when it is scanned.
+// Therefore, repositories can pass it to queries and scans in place of the plaintext string.
+type EncryptedColumn struct {
+	cipher         *Cipher
+	value          *string
+	additionalData fmt.Stringer
+	stale          bool
+}
+
+// Column returns an EncryptedColumn encrypting and decrypting the value with the additional data (see Cipher.Encrypt).
+// The additional data should identify the owner of the value, e.g. the id of the row the value is stored in.
+// It is read when the value is encrypted or decrypted, therefore it may point to a column scanned before the encrypted column.
+// Example:
+//
+//	row.Scan(&c.ID, cipher.Column(&c.Secret, &c.ID))
+func (c *Cipher) Column(value *string, additionalData fmt.Stringer) *EncryptedColumn {
+	return &EncryptedColumn{cipher: c, value: value, additionalData: additionalData}
+}
+
+// Scan implements the sql.Scanner interface by decrypting the column's value into the plaintext string.
+// NULL is scanned as an empty string.
+func (e *EncryptedColumn) Scan(src any) error {
+	var encrypted string
+	switch v := src.(type) {
+	case nil:
+		*e.value = ""
+		return nil
+	case string:
+		encrypted = v
+	case []byte:
+		encrypted = string(v)
+	default:
+		return fmt.Errorf("%w: unsupported column type %T", ErrDecrypt, src)
+	}
+
+	plaintext, err := e.cipher.Decrypt(encrypted, []byte(e.additionalData.String()))
+	if err != nil {
+		return err
+	}
+
+	*e.value = string(plaintext)
+	e.stale = e.cipher.Stale(encrypted)
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface by encrypting the plaintext string with the cipher's primary key.
+func (e *EncryptedColumn) Value() (driver.Value, error) {
+	return e.cipher.Encrypt([]byte(*e.value), []byte(e.additionalData.String()))
+}
+
+// Stale returns true if the scanned value was not encrypted with the primary key and should therefore be written again (see Cipher.Stale).
+func (e *EncryptedColumn) Stale() bool {
+	return e.stale
+}
@@ -0,0 +1,184 @@
+// Package crypto encrypts sensitive values, e.g. API tokens of integrations, before they are persisted.
+// Values are encrypted with AES-256-GCM using the primary key of the configuration. Previous keys remain usable for decryption,
+// this allows rotating the key: values encrypted with a previous key are re-encrypted with the primary key (see Cipher.Stale).
+//
+// TODO support keys managed by a KMS (e.g. HashiCorp Vault or AWS KMS) in addition to keys from the configuration.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Pkg is the package name for logging.
+const Pkg = "sys.crypto"
+
+// version is the prefix of values encrypted by the Cipher. It allows changing the format of encrypted values later on.
+const version = "v1"
+
+var (
+	// ErrNoKey is returned if no key is configured. Values can neither be encrypted nor decrypted then.
+	ErrNoKey = errors.New("no encryption key configured")
+	// ErrInvalidKey is returned if a configured key is not a base64 encoded 256 bit key.
+	ErrInvalidKey = errors.New("invalid encryption key, expected a base64 encoded 256 bit key")
+	// ErrUnknownKey is returned if a value was encrypted with a key that is not configured (anymore).
+	ErrUnknownKey = errors.New("value encrypted with an unknown key")
+	// ErrDecrypt is returned if a value could not be decrypted, e.g. because it was tampered with
+	// or the additional data does not match the additional data the value was encrypted with.
+	ErrDecrypt = errors.New("could not decrypt value")
+)
+
+// Cfg is the configuration of the Cipher. Keys are base64 encoded 256 bit keys, e.g. generated with `openssl rand -base64 32`.
+type Cfg struct {
+	// Key is the primary key. All values are encrypted with the primary key.
+	Key string `toml:"key" env:"CRYPTO_KEY"`
+	// PreviousKeys is a comma separated list of previous primary keys. They are only used to decrypt values
+	// encrypted before the key was rotated. Keys can be removed once all values were re-encrypted.
+	PreviousKeys string `toml:"previous_keys" env:"CRYPTO_PREVIOUS_KEYS"`
+}
+
+// Cipher encrypts and decrypts values using AES-256-GCM. Encrypted values contain the id of the key they were encrypted with.
+// A nil Cipher is valid, all its methods return ErrNoKey. Cipher is safe for concurrent use by multiple goroutines.
+type Cipher struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// New constructs a new Cipher from the config. It returns ErrNoKey if no primary key is configured
+// and ErrInvalidKey if any key is invalid.
+func New(cfg *Cfg) (*Cipher, error) {
+	if cfg == nil || strings.TrimSpace(cfg.Key) == "" {
+		return nil, ErrNoKey
+	}
+
+	c := &Cipher{keys: make(map[string]cipher.AEAD)}
+
+	primary, err := c.addKey(cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+	c.primary = primary
+
+	for _, key := range strings.Split(cfg.PreviousKeys, ",") {
+		if strings.TrimSpace(key) == "" {
+			continue
+		}
+
+		if _, err := c.addKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Encrypt encrypts the plaintext with the primary key. The additional data is authenticated but not encrypted,
+// the value can only be decrypted with the same additional data. Binding a value to its owner through the additional data,
+// e.g. the id of the row it is stored in, prevents values from being swapped between owners.
+func (c *Cipher) Encrypt(plaintext []byte, additionalData []byte) (string, error) {
+	if c == nil {
+		return "", ErrNoKey
+	}
+
+	aead := c.keys[c.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+
+	return strings.Join([]string{version, c.primary, base64.RawStdEncoding.EncodeToString(sealed)}, ":"), nil
+}
+
+// Decrypt decrypts a value encrypted by Encrypt with the same additional data.
+// It returns ErrUnknownKey if the key the value was encrypted with is not configured and ErrDecrypt if the value could not be decrypted.
+func (c *Cipher) Decrypt(encrypted string, additionalData []byte) ([]byte, error) {
+	if c == nil {
+		return nil, ErrNoKey
+	}
+
+	keyID, sealed, err := split(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, ok := c.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, errors.Join(ErrDecrypt, err)
+	}
+
+	return plaintext, nil
+}
+
+// Stale returns true if the value was not encrypted with the primary key. Stale values should be re-encrypted
+// (decrypted and encrypted again) to allow removing the previous keys from the configuration.
+func (c *Cipher) Stale(encrypted string) bool {
+	if c == nil {
+		return false
+	}
+
+	keyID, _, err := split(encrypted)
+
+	return err == nil && keyID != c.primary
+}
+
+// addKey decodes the base64 encoded key and adds it to the cipher's keys. It returns the id of the key.
+func (c *Cipher) addKey(encoded string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return "", ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", errors.Join(ErrInvalidKey, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", errors.Join(ErrInvalidKey, err)
+	}
+
+	id := keyID(key)
+	c.keys[id] = aead
+
+	return id, nil
+}
+
+// keyID derives the id of a key. The id identifies the key an encrypted value was encrypted with without revealing the key.
+func keyID(key []byte) string {
+	hash := sha256.Sum256(key)
+
+	return hex.EncodeToString(hash[:4])
+}
+
+// split splits an encrypted value into the id of its key and the sealed nonce and ciphertext.
+func split(encrypted string) (string, []byte, error) {
+	parts := strings.Split(encrypted, ":")
+	if len(parts) != 3 || parts[0] != version {
+		return "", nil, ErrDecrypt
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, errors.Join(ErrDecrypt, err)
+	}
+
+	return parts[1], sealed, nil
+}
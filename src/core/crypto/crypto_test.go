@@ -0,0 +1,144 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(&Cfg{})
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	_, err = New(&Cfg{Key: "not-base64"})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = New(&Cfg{Key: base64.StdEncoding.EncodeToString([]byte("too short"))})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = New(&Cfg{Key: newKey(t), PreviousKeys: "invalid"})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	c, err := New(&Cfg{Key: newKey(t), PreviousKeys: newKey(t) + ", " + newKey(t) + ","})
+	require.NoError(t, err)
+	assert.Len(t, c.keys, 3)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	c, err := New(&Cfg{Key: newKey(t)})
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt([]byte("token"), []byte("owner"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, version+":"+c.primary+":"))
+	assert.NotContains(t, encrypted, "token")
+
+	again, err := c.Encrypt([]byte("token"), []byte("owner"))
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again, "each encryption must use a new nonce")
+
+	plaintext, err := c.Decrypt(encrypted, []byte("owner"))
+	require.NoError(t, err)
+	assert.Equal(t, "token", string(plaintext))
+
+	_, err = c.Decrypt(encrypted, []byte("other owner"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	tampered := []byte(encrypted)
+	if tampered[len(tampered)-5] == 'A' {
+		tampered[len(tampered)-5] = 'B'
+	} else {
+		tampered[len(tampered)-5] = 'A'
+	}
+	_, err = c.Decrypt(string(tampered), []byte("owner"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = c.Decrypt("plaintext", nil)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	other, err := New(&Cfg{Key: newKey(t)})
+	require.NoError(t, err)
+	_, err = other.Decrypt(encrypted, []byte("owner"))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	var disabled *Cipher
+	_, err = disabled.Encrypt([]byte("token"), nil)
+	assert.ErrorIs(t, err, ErrNoKey)
+	_, err = disabled.Decrypt(encrypted, nil)
+	assert.ErrorIs(t, err, ErrNoKey)
+	assert.False(t, disabled.Stale(encrypted))
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newPrimary := newKey(t), newKey(t)
+
+	old, err := New(&Cfg{Key: oldKey})
+	require.NoError(t, err)
+	encrypted, err := old.Encrypt([]byte("token"), nil)
+	require.NoError(t, err)
+
+	rotated, err := New(&Cfg{Key: newPrimary, PreviousKeys: oldKey})
+	require.NoError(t, err)
+	assert.True(t, rotated.Stale(encrypted))
+	assert.False(t, old.Stale(encrypted))
+
+	plaintext, err := rotated.Decrypt(encrypted, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "token", string(plaintext))
+
+	reencrypted, err := rotated.Encrypt(plaintext, nil)
+	require.NoError(t, err)
+	assert.False(t, rotated.Stale(reencrypted))
+
+	_, err = old.Decrypt(reencrypted, nil)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestEncryptedColumn(t *testing.T) {
+	oldKey := newKey(t)
+	old, err := New(&Cfg{Key: oldKey})
+	require.NoError(t, err)
+	c, err := New(&Cfg{Key: newKey(t), PreviousKeys: oldKey})
+	require.NoError(t, err)
+
+	owner := uuid.New()
+	secret := "token"
+	value, err := c.Column(&secret, &owner).Value()
+	require.NoError(t, err)
+
+	var scannedOwner uuid.UUID
+	var scanned string
+	column := c.Column(&scanned, &scannedOwner)
+	scannedOwner = owner
+	require.NoError(t, column.Scan(value))
+	assert.Equal(t, "token", scanned)
+	assert.False(t, column.Stale())
+
+	require.NoError(t, column.Scan([]byte(value.(string))))
+	assert.Equal(t, "token", scanned)
+
+	otherOwner := uuid.New()
+	assert.ErrorIs(t, c.Column(&scanned, &otherOwner).Scan(value), ErrDecrypt)
+	assert.ErrorIs(t, column.Scan(42), ErrDecrypt)
+
+	require.NoError(t, column.Scan(nil))
+	assert.Empty(t, scanned)
+
+	oldValue, err := old.Column(&secret, &owner).Value()
+	require.NoError(t, err)
+	require.NoError(t, column.Scan(oldValue))
+	assert.Equal(t, "token", scanned)
+	assert.True(t, column.Stale())
+}
+
+func newKey(t *testing.T) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	return base64.StdEncoding.EncodeToString(key)
+}
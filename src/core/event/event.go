@@ -2,8 +2,11 @@
 package event
 
 import (
+	"context"
 	"fmt"
 	"github.com/org-harmony/harmony/src/core/trace"
+	"runtime/debug"
+	"slices"
 	"sort"
 	"sync"
 )
@@ -16,6 +19,14 @@ const Pkg = "sys.event"
 // If you do not care about the order in which subscribers are called, use this constant.
 const DefaultPriority = 0
 
+const (
+	// MetricHandled is the counter of asynchronously published events handled by their subscribers labeled by "event_id".
+	MetricHandled = "events_handled_total"
+	// MetricFailed is the counter of asynchronously published events at least one subscriber failed on labeled by "event_id".
+	// Together with MetricHandled it is the failure rate per event.
+	MetricFailed = "events_failed_total"
+)
+
 // BufferSize is the size of the buffer for event channels.
 // The buffer size is used when creating channels for events.
 // If the buffer size is too small publishing an event will block until the event is handled.
@@ -48,6 +59,8 @@ type Manager interface {
 	Subscribe(eventID string, publish func(Event, *PublishArgs) error, priority int)
 	// Publish publishes an event and allows for errors to be returned through the done channel.
 	Publish(event Event, doneChan chan []error)
+	// PublishSync publishes an event and calls the subscribers in the calling goroutine. It returns the errors of the subscribers.
+	PublishSync(event Event) []error
 }
 
 // subscriber is a struct that holds information about a subscriber.
@@ -97,15 +110,59 @@ type HManager struct {
 	// The subscribers are called when an event is published.
 	subscriber map[string][]subscriber
 	logger     trace.Logger
+	reporter   trace.Reporter
+	metrics    trace.Metrics
+	// onFailure is called with asynchronously published events at least one subscriber failed on, see WithFailureHandler.
+	onFailure func(Event, []error)
+}
+
+// ManagerOption configures the HManager on creation through NewManager.
+type ManagerOption func(*HManager)
+
+// WithReporter sets the reporter panics of subscribers are reported to. By default, panics are only returned as errors.
+// A nil reporter is ignored.
+func WithReporter(r trace.Reporter) ManagerOption {
+	return func(em *HManager) {
+		if r != nil {
+			em.reporter = r
+		}
+	}
+}
+
+// WithMetrics records the number of asynchronously published events and the number of failed ones per event
+// in the metrics, see MetricHandled and MetricFailed. A nil metrics is ignored.
+func WithMetrics(m trace.Metrics) ManagerOption {
+	return func(em *HManager) {
+		if m != nil {
+			em.metrics = m
+		}
+	}
+}
+
+// WithFailureHandler sets the handler called after subscribers of an asynchronously published event (see HManager.Publish)
+// failed, e.g. to store the event as a dead letter for a later retry. It is called with the errors of the subscribers
+// in the event's goroutine after the done channel was signaled. Events published through PublishSync are not passed to the handler,
+// their caller handles the errors.
+func WithFailureHandler(handler func(e Event, errs []error)) ManagerOption {
+	return func(em *HManager) {
+		em.onFailure = handler
+	}
 }
 
 // NewManager creates a new event manager.
-func NewManager(l trace.Logger) *HManager {
-	return &HManager{
+func NewManager(l trace.Logger, opts ...ManagerOption) *HManager {
+	em := &HManager{
 		events:     make(map[string]chan pc),
 		subscriber: make(map[string][]subscriber),
 		logger:     l,
+		reporter:   trace.NopReporter{},
 	}
+
+	for _, opt := range opts {
+		opt(em)
+	}
+
+	return em
 }
 
 // Subscribe subscribes to an event with the given event ID.
@@ -167,6 +224,25 @@ func (em *HManager) Publish(event Event, doneChan chan []error) {
 	em.logger.Debug(Pkg, "published event", "eventID", event.ID())
 }
 
+// PublishSync publishes an event by calling its subscribers in the calling goroutine and returns the errors of the subscribers.
+// In contrast to Publish, events of the same ID are not handled one after another. PublishSync therefore allows handling events
+// concurrently, e.g. from a pool of workers. Subscribers of events published through PublishSync must be safe for concurrent use.
+//
+// If a nil event is passed to the PublishSync function, the function will return nil immediately.
+func (em *HManager) PublishSync(event Event) []error {
+	if event == nil {
+		return nil
+	}
+
+	em.mu.Lock()
+	subscribers := slices.Clone(em.subscriber[event.ID()])
+	em.mu.Unlock()
+
+	em.logger.Debug(Pkg, "publishing event synchronously", "eventID", event.ID())
+
+	return publishToSubscribers(event, subscribers, em.logger, em.reporter)
+}
+
 // register registers an event with the event manager and creates a channel for the event.
 // Also, register boots up a goroutine to handle published events for the event ID.
 //
@@ -180,7 +256,7 @@ func (em *HManager) register(e Event) {
 	em.events[e.ID()] = make(chan pc, BufferSize)
 
 	// start a goroutine to handle published events for a given event ID through the channel
-	go handle(em.events[e.ID()], em.logger)
+	go em.handle(em.events[e.ID()])
 
 	em.logger.Debug(Pkg, "registered event and created channel", "eventID", e.ID())
 }
@@ -189,55 +265,80 @@ func (em *HManager) register(e Event) {
 // Through the channel the handle function receives a [pc] and publishes the event to the subscribers.
 // If the done channel is not nil, the handle function will signal that the event has been handled through the done channel.
 // After the event has been handled, the done channel is closed.
-func handle(e chan pc, l trace.Logger) {
+// Panics of subscribers are reported through the reporter. Failed events are recorded and passed to the failure handler.
+func (em *HManager) handle(e chan pc) {
 	for {
 		pc := <-e
 
-		l.Debug(Pkg, "handling event", "eventID", pc.e.ID())
-
-		var errs []error
-		args := &PublishArgs{}
+		em.logger.Debug(Pkg, "handling event", "eventID", pc.e.ID())
 
-		// publish event to subscribers
-		for _, subscriber := range pc.s {
-			if args.StopPropagation {
-				l.Debug(Pkg, "stopping propagation of event", "eventID", pc.e.ID())
-				break
-			}
+		errs := publishToSubscribers(pc.e, pc.s, em.logger, em.reporter)
 
-			err := safePublish(subscriber, pc.e, args)
-			if err != nil {
-				errs = append(errs, err)
+		if em.metrics != nil {
+			em.metrics.Add(MetricHandled, 1, "event_id", pc.e.ID())
+			if len(errs) > 0 {
+				em.metrics.Add(MetricFailed, 1, "event_id", pc.e.ID())
 			}
 		}
 
-		if len(errs) > 0 {
-			l.Info(Pkg, fmt.Sprintf("handled event with %d error(s)", len(errs)), "eventID", pc.e.ID(), "errors", errs)
+		if dc := pc.dc; dc != nil {
+			// signal that the event has been handled
+			dc <- errs
+			close(dc)
 		} else {
-			l.Debug(Pkg, "handled event without errors", "eventID", pc.e.ID())
+			em.logger.Debug(Pkg, "no done channel for event", "eventID", pc.e.ID())
+		}
+
+		if len(errs) > 0 && em.onFailure != nil {
+			em.onFailure(pc.e, errs)
 		}
+	}
+}
 
-		dc := pc.dc
-		if dc == nil {
-			l.Debug(Pkg, "no done channel for event", "eventID", pc.e.ID())
-			return
+// publishToSubscribers calls the subscribers in order until one stops the propagation of the event.
+// It returns the errors of the subscribers, nil if no subscriber failed.
+func publishToSubscribers(e Event, subscribers []subscriber, l trace.Logger, r trace.Reporter) []error {
+	var errs []error
+	args := &PublishArgs{}
+
+	// publish event to subscribers
+	for _, subscriber := range subscribers {
+		if args.StopPropagation {
+			l.Debug(Pkg, "stopping propagation of event", "eventID", e.ID())
+			break
 		}
 
-		// signal that the event has been handled
-		dc <- errs
-		close(dc)
+		err := safePublish(subscriber, e, args, r)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		l.Info(Pkg, fmt.Sprintf("handled event with %d error(s)", len(errs)), "eventID", e.ID(), "errors", errs)
+	} else {
+		l.Debug(Pkg, "handled event without errors", "eventID", e.ID())
 	}
+
+	return errs
 }
 
 // safePublish is a wrapper around the publish function of a subscriber.
 // It recovers from panics in the subscriber and returns an error if a panic occurred.
-func safePublish(s subscriber, e Event, args *PublishArgs) (err error) {
+// The panic is reported with its stack trace and the event ID through the reporter.
+func safePublish(s subscriber, e Event, args *PublishArgs, reporter trace.Reporter) (err error) {
 	// recover from panics in subscribers
 	// the named return value err is necessary to return the error from the deferred function,
 	// as the return value from the deferred function is discarded
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("subscriber panicked: %v", r)
+			reporter.Report(context.Background(), &trace.Report{
+				Mod:   Pkg,
+				Err:   err,
+				Stack: debug.Stack(),
+				Tags:  map[string]string{"event_id": e.ID()},
+			})
 		}
 	}()
 	return s.publish(e, args)
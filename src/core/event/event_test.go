@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"fmt"
 	"github.com/org-harmony/harmony/src/core/trace"
 	"github.com/org-harmony/harmony/src/core/util"
@@ -44,6 +45,14 @@ func (e *mockEvent) DoneChan() chan []error {
 	return e.d
 }
 
+type mockReporter struct {
+	reports chan *trace.Report
+}
+
+func (r *mockReporter) Report(ctx context.Context, report *trace.Report) {
+	r.reports <- report
+}
+
 func TestMain(m *testing.M) {
 	util.Ok(os.Setenv("TEST_LOG_SILENCE_DEBUG", "true"))
 
@@ -199,6 +208,28 @@ func TestBasicPublishing(t *testing.T) {
 		}
 	})
 
+	t.Run("without done channel", func(t *testing.T) {
+		em := NewManager(logger)
+
+		var received atomic.Int32
+		em.Subscribe("test.event.nodone", func(e Event, args *PublishArgs) error {
+			received.Add(1)
+			return nil
+		}, DefaultPriority)
+
+		// events without a done channel must not stop the handling of subsequent events
+		em.Publish(newMockEvent("test.event.nodone"), nil)
+		em.Publish(newMockEvent("test.event.nodone"), nil)
+
+		dc := make(chan []error)
+		em.Publish(newMockEvent("test.event.nodone"), dc)
+		<-dc
+
+		if received.Load() != 3 {
+			t.Errorf("Expected 3 events to be handled but got %d", received.Load())
+		}
+	})
+
 	t.Run("payload modification", func(t *testing.T) {
 		em := NewManager(logger)
 
@@ -333,6 +364,67 @@ func TestErrorHandling(t *testing.T) {
 		}
 	})
 
+	t.Run("panic is reported", func(t *testing.T) {
+		reporter := &mockReporter{reports: make(chan *trace.Report, 1)}
+		em := NewManager(logger, WithReporter(reporter))
+
+		em.Subscribe("test.event.panic", func(e Event, args *PublishArgs) error {
+			panic("test panic")
+		}, DefaultPriority)
+
+		dc := make(chan []error)
+		em.Publish(newMockEvent("test.event.panic"), dc)
+		<-dc
+
+		report := <-reporter.reports
+		if report.Tags["event_id"] != "test.event.panic" {
+			t.Errorf("Expected event_id tag test.event.panic but got %s", report.Tags["event_id"])
+		}
+		if len(report.Stack) == 0 {
+			t.Error("Expected the report to contain a stack trace")
+		}
+	})
+
+	t.Run("failure handler and metrics", func(t *testing.T) {
+		metrics := trace.NewMemoryMetrics()
+		failed := make(chan []error, 1)
+		em := NewManager(logger, WithMetrics(metrics), WithFailureHandler(func(e Event, errs []error) {
+			failed <- errs
+		}))
+
+		em.Subscribe("test.event.error", func(e Event, args *PublishArgs) error {
+			return fmt.Errorf("test error")
+		}, DefaultPriority)
+
+		dc := make(chan []error)
+		em.Publish(newMockEvent("test.event.ok"), dc)
+		<-dc
+		em.Publish(newMockEvent("test.event.error"), nil)
+
+		errs := <-failed
+		if len(errs) != 1 {
+			t.Errorf("Expected 1 error passed to the failure handler but got %d", len(errs))
+		}
+
+		if errs := em.PublishSync(newMockEvent("test.event.error")); len(errs) != 1 {
+			t.Errorf("Expected 1 error but got %d", len(errs))
+		}
+		if len(failed) != 0 {
+			t.Error("Expected synchronously published events not to be passed to the failure handler")
+		}
+
+		snapshot := metrics.Snapshot()
+		if handled := snapshot.Counter(MetricHandled, "event_id", "test.event.ok"); handled != 1 {
+			t.Errorf("Expected 1 handled test.event.ok but got %v", handled)
+		}
+		if failures := snapshot.Counter(MetricFailed, "event_id", "test.event.ok"); failures != 0 {
+			t.Errorf("Expected no failed test.event.ok but got %v", failures)
+		}
+		if failures := snapshot.Counter(MetricFailed, "event_id", "test.event.error"); failures != 1 {
+			t.Errorf("Expected 1 failed test.event.error but got %v", failures)
+		}
+	})
+
 	t.Run("panic and further processing", func(t *testing.T) {
 		em := NewManager(logger)
 
@@ -430,6 +522,51 @@ func TestConcurrentOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("concurrent synchronous publishing", func(t *testing.T) {
+		em := NewManager(logger)
+
+		var running, maxRunning int32
+		var arrived sync.WaitGroup
+		arrived.Add(2)
+
+		em.Subscribe("test.event.concurrent.sync", func(e Event, args *PublishArgs) error {
+			current := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			for {
+				peak := atomic.LoadInt32(&maxRunning)
+				if current <= peak || atomic.CompareAndSwapInt32(&maxRunning, peak, current) {
+					break
+				}
+			}
+
+			// wait until both publishers are inside the subscriber
+			arrived.Done()
+			arrived.Wait()
+
+			return fmt.Errorf("failed")
+		}, DefaultPriority)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				errs := em.PublishSync(newMockEvent("test.event.concurrent.sync"))
+				if len(errs) != 1 {
+					t.Errorf("Expected 1 error but got %d", len(errs))
+				}
+			}()
+		}
+		wg.Wait()
+
+		if atomic.LoadInt32(&maxRunning) != 2 {
+			t.Errorf("Expected events to be handled concurrently but at most %d were handled at once", maxRunning)
+		}
+	})
+
 	t.Run("mixed operations of concurrent publishing and subscribing", func(t *testing.T) {
 		em := NewManager(logger)
 
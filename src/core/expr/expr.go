@@ -0,0 +1,927 @@
+// Package expr provides a small expression language for user-defined checks, e.g. in templates.
+// Expressions are evaluated against variables and return a value, usually a boolean:
+//
+//	lower(value) != lower(segments.system) && len(value) <= 40
+//
+// The language is sandboxed by design: it has no loops, no assignments and no access to anything but the variables
+// and the built-in functions, which are free of side effects. The length and nesting depth of expressions are limited,
+// therefore the evaluation of a compiled Program always terminates in time linear to the size of its input.
+//
+// Supported are string ("..." or '...'), number, boolean and list ([...]) literals, variables and their fields (a.b or a["b"]),
+// list indices (a[0]), the operators ||, &&, !, ==, !=, <, <=, >, >=, in, +, -, *, / and % as well as the built-in functions:
+// lower, upper, trim, len, contains, startsWith, endsWith, matches (regular expression, RE2 syntax), split and number.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength is the maximum length of an expression in bytes.
+	MaxLength = 1024
+	// MaxDepth is the maximum nesting depth of an expression.
+	MaxDepth = 32
+)
+
+var (
+	// ErrSyntax is returned by Compile if the expression is syntactically invalid.
+	ErrSyntax = errors.New("expression syntax error")
+	// ErrTooComplex is returned by Compile if the expression exceeds MaxLength or MaxDepth.
+	ErrTooComplex = errors.New("expression too complex")
+	// ErrEval is returned by Program.Eval if the expression could not be evaluated, e.g. because of mismatching types or unknown variables.
+	ErrEval = errors.New("expression evaluation error")
+	// ErrNotABool is returned by Program.EvalBool if the expression does not evaluate to a boolean.
+	ErrNotABool = errors.New("expression is not a boolean")
+)
+
+// Program is a compiled expression. It is safe for concurrent use by multiple goroutines.
+type Program struct {
+	source string
+	root   node
+	// regexps are the regular expressions passed as string literals to matches keyed by their pattern.
+	// They are compiled once by Compile and only read afterward.
+	regexps map[string]*regexp.Regexp
+}
+
+// node is a node of the abstract syntax tree of an expression.
+type node interface {
+	eval(p *Program, vars map[string]any) (any, error)
+}
+
+type (
+	literal  struct{ value any }
+	variable struct{ name string }
+	list     struct{ items []node }
+	unary    struct {
+		op      string
+		operand node
+	}
+	binary struct {
+		op          string
+		left, right node
+	}
+	index struct {
+		target, key node
+	}
+	call struct {
+		name string
+		args []node
+	}
+)
+
+// token is a lexical token of an expression. Kind is one of "number", "string", "ident", "op" or "eof".
+type token struct {
+	kind  string
+	text  string
+	value any
+	pos   int
+}
+
+// compiler is a recursive descent parser of an expression's tokens.
+type compiler struct {
+	tokens  []token
+	pos     int
+	depth   int
+	regexps map[string]*regexp.Regexp
+}
+
+// functions are the built-in functions callable from expressions keyed by their name.
+var functions = map[string]func(p *Program, args []any) (any, error){
+	"lower":      stringFunc(strings.ToLower),
+	"upper":      stringFunc(strings.ToUpper),
+	"trim":       stringFunc(strings.TrimSpace),
+	"contains":   stringPredicate(strings.Contains),
+	"startsWith": stringPredicate(strings.HasPrefix),
+	"endsWith":   stringPredicate(strings.HasSuffix),
+	"len": func(p *Program, args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: len expects 1 argument", ErrEval)
+		}
+
+		switch v := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), nil
+		case []any:
+			return float64(len(v)), nil
+		case map[string]any:
+			return float64(len(v)), nil
+		}
+
+		return nil, fmt.Errorf("%w: len expects a string, list or map", ErrEval)
+	},
+	"matches": func(p *Program, args []any) (any, error) {
+		s, pattern, err := twoStrings("matches", args)
+		if err != nil {
+			return nil, err
+		}
+
+		re, err := p.regexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return re.MatchString(s), nil
+	},
+	"split": func(p *Program, args []any) (any, error) {
+		s, separator, err := twoStrings("split", args)
+		if err != nil {
+			return nil, err
+		}
+
+		var parts []string
+		if separator == "" {
+			parts = strings.Fields(s)
+		} else {
+			parts = strings.Split(s, separator)
+		}
+
+		result := make([]any, 0, len(parts))
+		for _, part := range parts {
+			result = append(result, part)
+		}
+
+		return result, nil
+	},
+	"number": func(p *Program, args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: number expects 1 argument", ErrEval)
+		}
+
+		s, ok := args[0].(string)
+		if !ok {
+			return toNumber(args[0])
+		}
+
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a number", ErrEval, s)
+		}
+
+		return n, nil
+	},
+}
+
+// Compile parses the expression into a Program. It returns ErrSyntax if the expression is invalid
+// and ErrTooComplex if it exceeds MaxLength or MaxDepth. Unknown functions and invalid regular expressions
+// passed as string literals to matches are syntax errors.
+func Compile(source string) (*Program, error) {
+	if len(source) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d bytes", ErrTooComplex, MaxLength)
+	}
+
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &compiler{tokens: tokens, regexps: make(map[string]*regexp.Regexp)}
+	root, err := c.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := c.peek(); t.kind != "eof" {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.text, t.pos)
+	}
+
+	return &Program{source: source, root: root, regexps: c.regexps}, nil
+}
+
+// String returns the source of the program.
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval evaluates the program with the variables. Variables may be strings, numbers, booleans, lists ([]any)
+// and maps (map[string]any). Integers are converted to float64. It returns ErrEval if the program could not be evaluated.
+func (p *Program) Eval(vars map[string]any) (any, error) {
+	return p.root.eval(p, vars)
+}
+
+// EvalBool evaluates the program with the variables and returns its boolean result.
+// It returns ErrNotABool if the program does not evaluate to a boolean.
+func (p *Program) EvalBool(vars map[string]any) (bool, error) {
+	result, err := p.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrNotABool, typeName(result))
+	}
+
+	return b, nil
+}
+
+// regexp returns the compiled regular expression. Patterns passed as string literals are compiled once by Compile.
+// Patterns computed at evaluation time, e.g. from variables, are compiled on each call and not cached
+// as their number is unbounded.
+func (p *Program) regexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := p.regexps[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := compileRegexp(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrEval, err.Error())
+	}
+
+	return re, nil
+}
+
+// compileRegexp compiles the regular expression. Patterns longer than MaxLength are rejected.
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > MaxLength {
+		return nil, fmt.Errorf("regular expression longer than %d bytes", MaxLength)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %s", err.Error())
+	}
+
+	return re, nil
+}
+
+// tokenize splits the source into tokens. The last token is always of the kind "eof".
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(source); {
+		r, size := utf8.DecodeRuneInString(source[i:])
+
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.') {
+				i++
+			}
+
+			n, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid number %q at %d", ErrSyntax, source[start:i], start)
+			}
+			tokens = append(tokens, token{kind: "number", text: source[start:i], value: n, pos: start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(source) {
+				r, size := utf8.DecodeRuneInString(source[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{kind: "ident", text: source[start:i], pos: start})
+		case r == '"' || r == '\'':
+			s, end, err := readString(source, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: "string", text: source[i:end], value: s, pos: i})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ",", "."} {
+				if strings.HasPrefix(source[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("%w: unexpected character %q at %d", ErrSyntax, r, i)
+			}
+
+			tokens = append(tokens, token{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: "eof", pos: len(source)}), nil
+}
+
+// readString reads the string literal starting at the quote at start. Backslashes escape the following character.
+// It returns the unquoted string and the position after the closing quote.
+func readString(source string, start int) (string, int, error) {
+	quote := source[start]
+	var b strings.Builder
+
+	for i := start + 1; i < len(source); i++ {
+		switch source[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(source) {
+				break
+			}
+			i++
+			switch source[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(source[i])
+			}
+		default:
+			b.WriteByte(source[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+}
+
+func (c *compiler) peek() token {
+	return c.tokens[c.pos]
+}
+
+func (c *compiler) next() token {
+	t := c.tokens[c.pos]
+	if t.kind != "eof" {
+		c.pos++
+	}
+
+	return t
+}
+
+// accept consumes the next token if it is one of the operators or keywords and returns it.
+func (c *compiler) accept(ops ...string) (string, bool) {
+	t := c.peek()
+	if t.kind != "op" && t.kind != "ident" {
+		return "", false
+	}
+
+	for _, op := range ops {
+		if t.text == op {
+			c.next()
+			return op, true
+		}
+	}
+
+	return "", false
+}
+
+func (c *compiler) expect(op string) error {
+	if _, ok := c.accept(op); !ok {
+		t := c.peek()
+		return fmt.Errorf("%w: expected %q at %d", ErrSyntax, op, t.pos)
+	}
+
+	return nil
+}
+
+// expression parses an expression of the lowest precedence (||). Operators bind from the lowest to the highest precedence:
+// ||, &&, comparisons (==, !=, <, <=, >, >=, in), + and -, *, / and %, unary ! and -, fields, indices and calls.
+func (c *compiler) expression() (node, error) {
+	c.depth++
+	defer func() { c.depth-- }()
+	if c.depth > MaxDepth {
+		return nil, fmt.Errorf("%w: nested deeper than %d", ErrTooComplex, MaxDepth)
+	}
+
+	return c.binary(0)
+}
+
+// precedence are the binary operators per precedence level starting with the lowest.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<=", ">=", "<", ">", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (c *compiler) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return c.unary()
+	}
+
+	left, err := c.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := c.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+
+		right, err := c.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{op: op, left: left, right: right}
+	}
+}
+
+func (c *compiler) unary() (node, error) {
+	if op, ok := c.accept("!", "-"); ok {
+		c.depth++
+		defer func() { c.depth-- }()
+		if c.depth > MaxDepth {
+			return nil, fmt.Errorf("%w: nested deeper than %d", ErrTooComplex, MaxDepth)
+		}
+
+		operand, err := c.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return unary{op: op, operand: operand}, nil
+	}
+
+	return c.postfix()
+}
+
+func (c *compiler) postfix() (node, error) {
+	n, err := c.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := c.accept("."); ok {
+			t := c.next()
+			if t.kind != "ident" {
+				return nil, fmt.Errorf("%w: expected a field name at %d", ErrSyntax, t.pos)
+			}
+			n = index{target: n, key: literal{value: t.text}}
+			continue
+		}
+
+		if _, ok := c.accept("["); ok {
+			key, err := c.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err := c.expect("]"); err != nil {
+				return nil, err
+			}
+			n = index{target: n, key: key}
+			continue
+		}
+
+		return n, nil
+	}
+}
+
+func (c *compiler) primary() (node, error) {
+	t := c.next()
+
+	switch t.kind {
+	case "number", "string":
+		return literal{value: t.value}, nil
+	case "ident":
+		switch t.text {
+		case "true":
+			return literal{value: true}, nil
+		case "false":
+			return literal{value: false}, nil
+		}
+
+		if _, ok := c.accept("("); !ok {
+			return variable{name: t.text}, nil
+		}
+
+		if _, ok := functions[t.text]; !ok {
+			return nil, fmt.Errorf("%w: unknown function %q at %d", ErrSyntax, t.text, t.pos)
+		}
+
+		args, err := c.items(")")
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.compileRegexp(t, args); err != nil {
+			return nil, err
+		}
+
+		return call{name: t.text, args: args}, nil
+	case "op":
+		switch t.text {
+		case "(":
+			n, err := c.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			return n, c.expect(")")
+		case "[":
+			items, err := c.items("]")
+			if err != nil {
+				return nil, err
+			}
+
+			return list{items: items}, nil
+		}
+	case "eof":
+		return nil, fmt.Errorf("%w: unexpected end of expression", ErrSyntax)
+	}
+
+	return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.text, t.pos)
+}
+
+// compileRegexp compiles the pattern of a call of matches if it is a string literal, see Program.regexp.
+func (c *compiler) compileRegexp(t token, args []node) error {
+	if t.text != "matches" || len(args) != 2 {
+		return nil
+	}
+
+	pattern, ok := args[1].(literal)
+	if !ok {
+		return nil
+	}
+
+	s, ok := pattern.value.(string)
+	if !ok {
+		return nil
+	}
+
+	re, err := compileRegexp(s)
+	if err != nil {
+		return fmt.Errorf("%w: %s at %d", ErrSyntax, err.Error(), t.pos)
+	}
+	c.regexps[s] = re
+
+	return nil
+}
+
+// items parses comma separated expressions until the closing operator, e.g. the arguments of a call.
+func (c *compiler) items(closing string) ([]node, error) {
+	var items []node
+	if _, ok := c.accept(closing); ok {
+		return items, nil
+	}
+
+	for {
+		item, err := c.expression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if _, ok := c.accept(closing); ok {
+			return items, nil
+		}
+		if err := c.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (n literal) eval(p *Program, vars map[string]any) (any, error) {
+	return n.value, nil
+}
+
+func (n variable) eval(p *Program, vars map[string]any) (any, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown variable %q", ErrEval, n.name)
+	}
+
+	return normalize(value), nil
+}
+
+func (n list) eval(p *Program, vars map[string]any) (any, error) {
+	items := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		value, err := item.eval(p, vars)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+
+	return items, nil
+}
+
+func (n unary) eval(p *Program, vars map[string]any) (any, error) {
+	operand, err := n.operand.eval(p, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		b, ok := operand.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: ! expects a boolean, got %s", ErrEval, typeName(operand))
+		}
+
+		return !b, nil
+	}
+
+	number, err := toNumber(operand)
+	if err != nil {
+		return nil, err
+	}
+
+	return -number, nil
+}
+
+func (n binary) eval(p *Program, vars map[string]any) (any, error) {
+	left, err := n.left.eval(p, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s expects booleans, got %s", ErrEval, n.op, typeName(left))
+		}
+		if l == (n.op == "||") {
+			return l, nil
+		}
+
+		right, err := n.right.eval(p, vars)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s expects booleans, got %s", ErrEval, n.op, typeName(right))
+		}
+
+		return r, nil
+	}
+
+	right, err := n.right.eval(p, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	case "in":
+		return in(left, right)
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	case "+":
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		}
+	}
+
+	l, err := toNumber(left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := toNumber(right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("%w: division by zero", ErrEval)
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("%w: division by zero", ErrEval)
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+func (n index) eval(p *Program, vars map[string]any) (any, error) {
+	target, err := n.target.eval(p, vars)
+	if err != nil {
+		return nil, err
+	}
+	key, err := n.key.eval(p, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case map[string]any:
+		k, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: map keys are strings, got %s", ErrEval, typeName(key))
+		}
+
+		value, ok := t[k]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrEval, k)
+		}
+
+		return normalize(value), nil
+	case []any:
+		i, err := toNumber(key)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(t) || i != math.Trunc(i) {
+			return nil, fmt.Errorf("%w: index %v out of range", ErrEval, i)
+		}
+
+		return t[int(i)], nil
+	}
+
+	return nil, fmt.Errorf("%w: %s can not be indexed", ErrEval, typeName(target))
+}
+
+func (n call) eval(p *Program, vars map[string]any) (any, error) {
+	args := make([]any, 0, len(n.args))
+	for _, arg := range n.args {
+		value, err := arg.eval(p, vars)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	return functions[n.name](p, args)
+}
+
+// in returns true if the left string is contained in the right string, the left value is an item of the right list
+// or the left string is a key of the right map.
+func in(left any, right any) (any, error) {
+	switch r := right.(type) {
+	case string:
+		l, ok := left.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: in expects a string on the left of a string, got %s", ErrEval, typeName(left))
+		}
+
+		return strings.Contains(r, l), nil
+	case []any:
+		for _, item := range r {
+			if reflect.DeepEqual(left, item) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	case map[string]any:
+		l, ok := left.(string)
+		if !ok {
+			return false, nil
+		}
+		_, ok = r[l]
+
+		return ok, nil
+	}
+
+	return nil, fmt.Errorf("%w: in expects a string, list or map on the right, got %s", ErrEval, typeName(right))
+}
+
+// compare compares two numbers or two strings.
+func compare(op string, left any, right any) (any, error) {
+	var result int
+
+	ls, lOk := left.(string)
+	rs, rOk := right.(string)
+	if lOk && rOk {
+		result = strings.Compare(ls, rs)
+	} else {
+		l, err := toNumber(left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := toNumber(right)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case l < r:
+			result = -1
+		case l > r:
+			result = 1
+		}
+	}
+
+	switch op {
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	default:
+		return result >= 0, nil
+	}
+}
+
+// normalize converts integers to float64 and string slices and maps to []any and map[string]any.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			items = append(items, item)
+		}
+		return items
+	case map[string]string:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[key] = item
+		}
+		return m
+	}
+
+	return value
+}
+
+func toNumber(value any) (float64, error) {
+	n, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%w: expected a number, got %s", ErrEval, typeName(value))
+	}
+
+	return n, nil
+}
+
+// typeName returns the name of the value's type in the expression language.
+func typeName(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	case nil:
+		return "nothing"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+// stringFunc wraps a function transforming a string into a built-in function.
+func stringFunc(f func(string) string) func(p *Program, args []any) (any, error) {
+	return func(p *Program, args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: expected 1 argument", ErrEval)
+		}
+
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: expected a string, got %s", ErrEval, typeName(args[0]))
+		}
+
+		return f(s), nil
+	}
+}
+
+// stringPredicate wraps a predicate on two strings into a built-in function.
+func stringPredicate(f func(string, string) bool) func(p *Program, args []any) (any, error) {
+	return func(p *Program, args []any) (any, error) {
+		a, b, err := twoStrings("function", args)
+		if err != nil {
+			return nil, err
+		}
+
+		return f(a, b), nil
+	}
+}
+
+func twoStrings(name string, args []any) (string, string, error) {
+	if len(args) != 2 {
+		return "", "", fmt.Errorf("%w: %s expects 2 arguments", ErrEval, name)
+	}
+
+	a, aOk := args[0].(string)
+	b, bOk := args[1].(string)
+	if !aOk || !bOk {
+		return "", "", fmt.Errorf("%w: %s expects strings, got %s and %s", ErrEval, name, typeName(args[0]), typeName(args[1]))
+	}
+
+	return a, b, nil
+}
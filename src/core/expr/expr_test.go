@@ -0,0 +1,133 @@
+package expr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]any{
+		"value":    "The System",
+		"count":    3,
+		"segments": map[string]string{"actor": "user", "system": "the system"},
+		"words":    []string{"must", "shall"},
+	}
+
+	tests := []struct {
+		expression string
+		expected   any
+	}{
+		{`1 + 2 * 3`, 7.0},
+		{`(1 + 2) * 3`, 9.0},
+		{`-count + 10 % 4`, -1.0},
+		{`"a" + 'b'`, "ab"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`count == 3 && !false`, true},
+		{`count > 3 || count <= 3`, true},
+		{`"abc" < "abd"`, true},
+		{`lower(value) == segments.system`, true},
+		{`segments["actor"] != segments.system`, true},
+		{`"shall" in words`, true},
+		{`"should" in words`, false},
+		{`"Sys" in value`, true},
+		{`"actor" in segments`, true},
+		{`words[1]`, "shall"},
+		{`len(value)`, 10.0},
+		{`len(split(value, ""))`, 2.0},
+		{`upper(trim("  a "))`, "A"},
+		{`startsWith(value, "The") && endsWith(value, "System") && contains(value, " ")`, true},
+		{`matches(value, "^[A-Z]")`, true},
+		{`number("4.5") > 4`, true},
+		{`[1, "a", true] == [1, "a", true]`, true},
+		{`1 == "1"`, false},
+		{`false && unknown`, false},
+		{`true || unknown`, true},
+	}
+
+	for _, test := range tests {
+		p, err := Compile(test.expression)
+		require.NoError(t, err, test.expression)
+
+		result, err := p.Eval(vars)
+		require.NoError(t, err, test.expression)
+		assert.Equal(t, test.expected, result, test.expression)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	vars := map[string]any{"value": "text", "segments": map[string]any{}}
+
+	for _, expression := range []string{
+		`unknown`,
+		`segments.unknown`,
+		`!value`,
+		`value && true`,
+		`value - 1`,
+		`1 / 0`,
+		`value[0]`,
+		`[1][1]`,
+		`len(1)`,
+		`lower(1)`,
+		`matches(value, value + "(")`,
+		`number("abc")`,
+		`1 in 1`,
+	} {
+		p, err := Compile(expression)
+		require.NoError(t, err, expression)
+
+		_, err = p.Eval(vars)
+		assert.ErrorIs(t, err, ErrEval, expression)
+	}
+
+	p, err := Compile(`value`)
+	require.NoError(t, err)
+	_, err = p.EvalBool(vars)
+	assert.ErrorIs(t, err, ErrNotABool)
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`1 +`,
+		`(1`,
+		`[1, 2`,
+		`"unterminated`,
+		`a.1`,
+		`1 2`,
+		`value = 1`,
+		`exec("rm")`,
+		`1..2`,
+		`matches(value, "(")`,
+	} {
+		_, err := Compile(expression)
+		assert.ErrorIs(t, err, ErrSyntax, expression)
+	}
+
+	_, err := Compile(strings.Repeat("a", MaxLength+1))
+	assert.ErrorIs(t, err, ErrTooComplex)
+
+	_, err = Compile(strings.Repeat("(", MaxDepth+1) + "1" + strings.Repeat(")", MaxDepth+1))
+	assert.ErrorIs(t, err, ErrTooComplex)
+
+	_, err = Compile(strings.Repeat("!", MaxDepth+1) + "true")
+	assert.ErrorIs(t, err, ErrTooComplex)
+}
+
+func TestProgram_Regexp(t *testing.T) {
+	p, err := Compile(`matches(value, "^[A-Z]") && !matches(value, pattern)`)
+	require.NoError(t, err)
+	assert.Len(t, p.regexps, 1, "only literal patterns are compiled")
+	assert.Contains(t, p.regexps, "^[A-Z]")
+
+	for _, pattern := range []string{"a", "b", "c"} {
+		ok, err := p.EvalBool(map[string]any{"value": "Text", "pattern": pattern})
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Len(t, p.regexps, 1, "dynamic patterns are not cached")
+
+	_, err = p.EvalBool(map[string]any{"value": "Text", "pattern": strings.Repeat("a", MaxLength+1)})
+	assert.ErrorIs(t, err, ErrEval)
+}
@@ -0,0 +1,313 @@
+// Package feature provides feature flags allowing to ship experimental features dark and enable them gradually.
+// Flags are defined in the configuration and evaluated per environment, per role and for a percentage of users.
+// Administrators can toggle flags at runtime for their tenant, overriding the configuration until the flag is reset or the application restarts.
+//
+// The Middleware stores the Flags and the Subject of a request in the request context. Code can then check flags
+// through Enabled and templates through the "feature" template function.
+package feature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/util"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Pkg is the package name used for logging.
+	Pkg = "sys.feature"
+	// ContextKey is the key the Flags and the Subject of a request are stored under in the request context.
+	ContextKey = "harmony-app-feature"
+	// RoleAdmin is the role of users allowed to toggle flags at runtime.
+	RoleAdmin = "admin"
+)
+
+var (
+	// ErrUnknownFlag is returned if a flag is not defined in the configuration.
+	ErrUnknownFlag = errors.New("unknown feature flag")
+	// ErrInvalidPercentage is returned if the percentage of a flag is not between 0 and 100.
+	ErrInvalidPercentage = errors.New("feature flag percentage must be between 0 and 100")
+)
+
+// Cfg is the feature package's configuration. The flags are keyed by their name.
+type Cfg struct {
+	// Environment is the environment the application runs in, e.g. development, staging or production.
+	Environment string `toml:"environment" env:"HARMONY_ENVIRONMENT"`
+	// Roles are the email addresses of the users with the role in every tenant, keyed by the role's name.
+	// They are meant for the operators of the instance, tenants' users are assigned roles through TenantRoles.
+	Roles map[string][]string `toml:"roles"`
+	// TenantRoles are the email addresses of the users with the role in a single tenant, keyed by the tenant's ID
+	// (see tenant.ID) and the role's name. Without tenancy all users belong to the tenant.DefaultID tenant.
+	TenantRoles map[string]map[string][]string `toml:"tenant_roles"`
+	Flags       map[string]*Flag               `toml:"flag"`
+}
+
+// Flag is the configuration of a feature flag. A flag is active for a subject if it is available in the environment
+// and it is either enabled, the subject has one of its roles or the subject is part of its percentage of users.
+type Flag struct {
+	Name string `toml:"-"` // Name is set to the key of the flag in Cfg.Flags.
+	// Description explains the feature to administrators.
+	Description string `toml:"description"`
+	// Enabled enables the flag for everyone.
+	Enabled bool `toml:"enabled"`
+	// Environments restricts the flag to these environments. The flag is available in all environments if it is empty.
+	Environments []string `toml:"environments"`
+	// Roles enables the flag for users with one of the roles, see Cfg.Roles.
+	Roles []string `toml:"roles"`
+	// Percentage enables the flag for this share (0-100) of logged-in users. A user keeps their assignment to a flag.
+	Percentage int `toml:"percentage"`
+}
+
+// Subject is whom a flag is evaluated for, usually the logged-in user. Anonymous subjects have an empty ID and email.
+// Tenant is the ID of the tenant of the subject's request (see tenant.ID), an empty Tenant is the tenant.DefaultID tenant.
+type Subject struct {
+	ID     string
+	Email  string
+	Tenant string
+}
+
+// State is the state of a flag for a subject, e.g. to display it to administrators.
+type State struct {
+	*Flag
+	// Available is true if the flag is available in the current environment.
+	Available bool
+	// Override is the state the flag was toggled to at runtime, it is nil if the flag was not toggled.
+	Override *bool
+	// Active is true if the flag is active for the subject.
+	Active bool
+}
+
+// Flags evaluates the configured feature flags and holds the flags toggled at runtime.
+// Runtime toggles are kept per tenant, toggling a flag in one tenant does not affect other tenants.
+// They are kept in memory, they are therefore lost on restart and not shared between instances.
+// Flags is safe for concurrent use by multiple goroutines.
+type Flags struct {
+	environment string
+	roles       map[string][]string
+	tenantRoles map[string]map[string][]string
+	flags       map[string]*Flag
+	overrides   map[string]map[string]bool // overrides are keyed by the tenant's ID and the flag's name
+	mu          sync.RWMutex
+}
+
+// New creates new Flags from the config. The flags' names are set to their keys in Cfg.Flags.
+// It returns ErrInvalidPercentage if a flag's percentage is not between 0 and 100.
+func New(cfg *Cfg) (*Flags, error) {
+	f := &Flags{
+		environment: strings.ToLower(cfg.Environment),
+		roles:       lowerEmails(cfg.Roles),
+		tenantRoles: make(map[string]map[string][]string, len(cfg.TenantRoles)),
+		flags:       make(map[string]*Flag, len(cfg.Flags)),
+		overrides:   make(map[string]map[string]bool),
+	}
+
+	for tenantID, roles := range cfg.TenantRoles {
+		f.tenantRoles[tenantID] = lowerEmails(roles)
+	}
+
+	for name, flag := range cfg.Flags {
+		if flag.Percentage < 0 || flag.Percentage > 100 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPercentage, name)
+		}
+
+		flag.Name = name
+		f.flags[name] = flag
+	}
+
+	return f, nil
+}
+
+// Enabled returns true if the flag is active for the subject. Unknown flags are never active.
+// A flag toggled at runtime is active for every subject of the tenant or none, unless it is not available in the environment.
+func (f *Flags) Enabled(name string, subject Subject) bool {
+	flag, ok := f.flags[name]
+	if !ok || !f.available(flag) {
+		return false
+	}
+
+	f.mu.RLock()
+	override, overridden := f.overrides[orDefaultTenant(subject.Tenant)][name]
+	f.mu.RUnlock()
+	if overridden {
+		return override
+	}
+
+	if flag.Enabled {
+		return true
+	}
+
+	for _, role := range flag.Roles {
+		if f.HasRole(subject, role) {
+			return true
+		}
+	}
+
+	return subject.ID != "" && bucket(name, subject.ID) < flag.Percentage
+}
+
+// HasRole returns true if the subject's email is one of the role's email addresses in every tenant (see Cfg.Roles)
+// or in the subject's tenant (see Cfg.TenantRoles).
+func (f *Flags) HasRole(subject Subject, role string) bool {
+	if subject.Email == "" {
+		return false
+	}
+
+	email := strings.ToLower(subject.Email)
+
+	return slices.Contains(f.roles[role], email) || slices.Contains(f.tenantRoles[orDefaultTenant(subject.Tenant)][role], email)
+}
+
+// Set toggles the flag at runtime for the tenant, overriding its configuration for the tenant's subjects.
+// It returns ErrUnknownFlag if the flag is not configured.
+func (f *Flags) Set(tenantID string, name string, enabled bool) error {
+	if _, ok := f.flags[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenantID = orDefaultTenant(tenantID)
+	if f.overrides[tenantID] == nil {
+		f.overrides[tenantID] = make(map[string]bool)
+	}
+	f.overrides[tenantID][name] = enabled
+
+	return nil
+}
+
+// Reset removes the runtime toggle of the flag for the tenant, its configuration applies again.
+// It returns ErrUnknownFlag if the flag is not configured.
+func (f *Flags) Reset(tenantID string, name string) error {
+	if _, ok := f.flags[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.overrides[orDefaultTenant(tenantID)], name)
+
+	return nil
+}
+
+// States returns the states of all flags for the subject sorted by the flags' names.
+// Overrides are the runtime toggles of the subject's tenant.
+func (f *Flags) States(subject Subject) []State {
+	states := make([]State, 0, len(f.flags))
+	for name, flag := range f.flags {
+		state := State{Flag: flag, Available: f.available(flag), Active: f.Enabled(name, subject)}
+
+		f.mu.RLock()
+		if override, ok := f.overrides[orDefaultTenant(subject.Tenant)][name]; ok {
+			state.Override = &override
+		}
+		f.mu.RUnlock()
+
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+
+	return states
+}
+
+// available returns true if the flag is available in the environment of the Flags.
+func (f *Flags) available(flag *Flag) bool {
+	if len(flag.Environments) == 0 {
+		return true
+	}
+
+	for _, environment := range flag.Environments {
+		if strings.EqualFold(environment, f.environment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orDefaultTenant returns the tenant ID or tenant.DefaultID if the ID is empty.
+func orDefaultTenant(tenantID string) string {
+	if tenantID == "" {
+		return tenant.DefaultID
+	}
+
+	return tenantID
+}
+
+// lowerEmails returns a copy of the roles with lower-cased email addresses as emails are compared case-insensitively.
+func lowerEmails(roles map[string][]string) map[string][]string {
+	lowered := make(map[string][]string, len(roles))
+	for role, emails := range roles {
+		for _, email := range emails {
+			lowered[role] = append(lowered[role], strings.ToLower(email))
+		}
+	}
+
+	return lowered
+}
+
+// bucket assigns the subject to one of 100 buckets per flag. The assignment is stable, a subject keeps its bucket
+// as long as the flag's name does not change. Buckets of different flags are independent of each other.
+func bucket(name string, subjectID string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name + ":" + subjectID))
+
+	return int(hash.Sum32() % 100)
+}
+
+// Middleware stores the flags and the subject of each request in the request context (see WithFlags).
+// The subject is determined by the passed in function, e.g. from the logged-in user.
+func Middleware(flags *Flags, subject func(r *http.Request) Subject) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithFlags(r.Context(), flags, subject(r))))
+		})
+	}
+}
+
+// requestFlags are the flags and the subject of a request stored in the request context.
+type requestFlags struct {
+	flags   *Flags
+	subject Subject
+}
+
+// WithFlags returns a copy of the context containing the flags and the subject they are evaluated for.
+func WithFlags(ctx context.Context, flags *Flags, subject Subject) context.Context {
+	return context.WithValue(ctx, ContextKey, &requestFlags{flags: flags, subject: subject})
+}
+
+// FromContext returns the flags and the subject of the context. It returns false if the context contains no flags.
+func FromContext(ctx context.Context) (*Flags, Subject, bool) {
+	rf, ok := util.CtxValue[*requestFlags](ctx, ContextKey)
+	if !ok || rf.flags == nil {
+		return nil, Subject{}, false
+	}
+
+	return rf.flags, rf.subject, true
+}
+
+// Enabled returns true if the flag is active for the subject of the context.
+// Without flags in the context (see Middleware) no flag is active.
+//
+// Example:
+//
+//	if feature.Enabled(io.Context(), "guided_elicitation") { ... }
+func Enabled(ctx context.Context, name string) bool {
+	flags, subject, ok := FromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	return flags.Enabled(name, subject)
+}
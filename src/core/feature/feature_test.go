@@ -0,0 +1,173 @@
+package feature
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(&Cfg{Flags: map[string]*Flag{"a": {Percentage: 101}}})
+	assert.ErrorIs(t, err, ErrInvalidPercentage)
+
+	f, err := New(&Cfg{Flags: map[string]*Flag{"a": {}, "b": {}}})
+	require.NoError(t, err)
+	assert.Equal(t, "a", f.flags["a"].Name)
+	assert.Len(t, f.States(Subject{}), 2)
+}
+
+func TestFlags_Enabled(t *testing.T) {
+	f, err := New(&Cfg{
+		Environment: "Staging",
+		Roles:       map[string][]string{"beta": {"Beta@Example.com"}},
+		Flags: map[string]*Flag{
+			"enabled":     {Enabled: true},
+			"disabled":    {},
+			"staging":     {Enabled: true, Environments: []string{"staging"}},
+			"production":  {Enabled: true, Environments: []string{"production"}},
+			"beta":        {Roles: []string{"beta"}},
+			"everyone":    {Percentage: 100},
+			"half":        {Percentage: 50},
+			"nobody":      {Percentage: 0},
+			"environment": {Roles: []string{"beta"}, Environments: []string{"production"}},
+		},
+	})
+	require.NoError(t, err)
+
+	anonymous := Subject{}
+	beta := Subject{ID: "1", Email: "beta@example.com"}
+
+	assert.True(t, f.Enabled("enabled", anonymous))
+	assert.False(t, f.Enabled("disabled", beta))
+	assert.False(t, f.Enabled("unknown", beta))
+	assert.True(t, f.Enabled("staging", anonymous), "environments are compared case-insensitively")
+	assert.False(t, f.Enabled("production", anonymous))
+	assert.True(t, f.Enabled("beta", beta), "emails are compared case-insensitively")
+	assert.False(t, f.Enabled("beta", Subject{ID: "2", Email: "other@example.com"}))
+	assert.False(t, f.Enabled("beta", anonymous))
+	assert.True(t, f.Enabled("everyone", beta))
+	assert.False(t, f.Enabled("everyone", anonymous), "percentages apply to logged-in users only")
+	assert.False(t, f.Enabled("nobody", beta))
+	assert.False(t, f.Enabled("environment", beta), "roles do not apply outside of the flag's environments")
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		subject := Subject{ID: strconv.Itoa(i)}
+		if f.Enabled("half", subject) {
+			enabled++
+		}
+		assert.Equal(t, f.Enabled("half", subject), f.Enabled("half", subject), "the assignment is stable")
+	}
+	assert.InDelta(t, 500, enabled, 100)
+}
+
+func TestFlags_SetReset(t *testing.T) {
+	f, err := New(&Cfg{
+		Environment: "production",
+		Flags: map[string]*Flag{
+			"dark":    {},
+			"staging": {Environments: []string{"staging"}},
+		},
+	})
+	require.NoError(t, err)
+	subject := Subject{ID: "1"}
+
+	assert.ErrorIs(t, f.Set("", "unknown", true), ErrUnknownFlag)
+	assert.ErrorIs(t, f.Reset("", "unknown"), ErrUnknownFlag)
+
+	require.NoError(t, f.Set("", "dark", true))
+	assert.True(t, f.Enabled("dark", subject))
+	states := f.States(subject)
+	require.Len(t, states, 2)
+	assert.Equal(t, "dark", states[0].Name)
+	assert.True(t, states[0].Active)
+	require.NotNil(t, states[0].Override)
+	assert.True(t, *states[0].Override)
+
+	require.NoError(t, f.Set("", "dark", false))
+	assert.False(t, f.Enabled("dark", subject))
+
+	require.NoError(t, f.Reset("", "dark"))
+	assert.False(t, f.Enabled("dark", subject))
+	assert.Nil(t, f.States(subject)[0].Override)
+
+	require.NoError(t, f.Set("", "staging", true))
+	assert.False(t, f.Enabled("staging", subject), "toggles do not make flags available in other environments")
+	assert.False(t, f.States(subject)[1].Available)
+}
+
+func TestFlags_SetPerTenant(t *testing.T) {
+	f, err := New(&Cfg{Flags: map[string]*Flag{"dark": {}}})
+	require.NoError(t, err)
+	a := Subject{ID: "1", Tenant: "a"}
+	b := Subject{ID: "2", Tenant: "b"}
+
+	require.NoError(t, f.Set("a", "dark", true))
+	assert.True(t, f.Enabled("dark", a))
+	assert.False(t, f.Enabled("dark", b), "toggles only apply to the tenant they were set for")
+	assert.Nil(t, f.States(b)[0].Override)
+
+	require.NoError(t, f.Set("", "dark", true))
+	assert.True(t, f.Enabled("dark", Subject{ID: "3", Tenant: tenant.DefaultID}), "an empty tenant is the default tenant")
+	assert.False(t, f.Enabled("dark", b))
+
+	require.NoError(t, f.Reset("b", "dark"))
+	assert.True(t, f.Enabled("dark", a), "resetting a flag in another tenant keeps the toggle")
+}
+
+func TestFlags_HasRole(t *testing.T) {
+	f, err := New(&Cfg{Roles: map[string][]string{RoleAdmin: {"admin@example.com"}}})
+	require.NoError(t, err)
+
+	assert.True(t, f.HasRole(Subject{Email: "ADMIN@example.com"}, RoleAdmin))
+	assert.False(t, f.HasRole(Subject{Email: "user@example.com"}, RoleAdmin))
+	assert.False(t, f.HasRole(Subject{}, RoleAdmin))
+	assert.False(t, f.HasRole(Subject{Email: "admin@example.com"}, "unknown"))
+	assert.True(t, f.HasRole(Subject{Email: "admin@example.com", Tenant: "a"}, RoleAdmin), "roles apply in every tenant")
+
+	f, err = New(&Cfg{TenantRoles: map[string]map[string][]string{
+		"a":              {RoleAdmin: {"Tenant-Admin@example.com"}},
+		tenant.DefaultID: {RoleAdmin: {"default-admin@example.com"}},
+	}})
+	require.NoError(t, err)
+
+	assert.True(t, f.HasRole(Subject{Email: "tenant-admin@example.com", Tenant: "a"}, RoleAdmin))
+	assert.False(t, f.HasRole(Subject{Email: "tenant-admin@example.com", Tenant: "b"}, RoleAdmin), "tenant roles only apply in their tenant")
+	assert.False(t, f.HasRole(Subject{Email: "tenant-admin@example.com"}, RoleAdmin))
+	assert.True(t, f.HasRole(Subject{Email: "default-admin@example.com"}, RoleAdmin))
+}
+
+func TestMiddleware(t *testing.T) {
+	f, err := New(&Cfg{Roles: map[string][]string{"beta": {"beta@example.com"}}, Flags: map[string]*Flag{"beta": {Roles: []string{"beta"}}}})
+	require.NoError(t, err)
+
+	assert.False(t, Enabled(context.Background(), "beta"), "no flags are active without flags in the context")
+
+	subject := func(r *http.Request) Subject {
+		return Subject{ID: "1", Email: r.Header.Get("X-Email")}
+	}
+	handler := Middleware(f, subject)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flags, _, ok := FromContext(r.Context())
+		assert.True(t, ok)
+		assert.Same(t, f, flags)
+
+		if Enabled(r.Context(), "beta") {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Email", "beta@example.com")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
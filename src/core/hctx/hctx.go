@@ -1,20 +1,56 @@
 package hctx
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/org-harmony/harmony/src/core/event"
 	"github.com/org-harmony/harmony/src/core/persistence"
 	"github.com/org-harmony/harmony/src/core/trace"
 	"github.com/org-harmony/harmony/src/core/validation"
+	"sync"
+)
+
+// Pkg is the package name used for logging.
+const Pkg = "sys.hctx"
+
+var (
+	// ErrInit is returned by AppCtx.Init if an init hook failed. It is joined with the hook's error.
+	ErrInit = errors.New("application init failed")
+	// ErrShutdown is returned by AppCtx.Shutdown if at least one shutdown hook failed. It is joined with the hooks' errors.
+	ErrShutdown = errors.New("application shutdown failed")
 )
 
 // AppCtx is the application context.
 // It contains parts that are common to all parts of the application.
 // It implements the trace.Logger and persistence.RepositoryProvider interfaces.
+// The application context also manages the lifecycle of the application, see AppCtx.OnInit and AppCtx.OnShutdown.
 type AppCtx struct {
 	Logger       trace.Logger
 	Validator    validation.V
 	Repositories persistence.RepositoryProvider
 	EventManager event.Manager
+	// Metrics records the application's metrics. It is optional and might be nil, e.g. in tests.
+	Metrics trace.Metrics
+	// Reporter reports errors such as recovered panics to an error tracking service. It is optional and might be nil.
+	Reporter  trace.Reporter
+	lifecycle lifecycle
+}
+
+// Hook is a lifecycle hook that is run on application init or shutdown.
+type Hook func(ctx context.Context) error
+
+// lifecycle holds the registered lifecycle hooks. It is safe for concurrent use.
+type lifecycle struct {
+	mu            sync.Mutex
+	initHooks     []namedHook
+	shutdownHooks []namedHook
+}
+
+// namedHook is a lifecycle hook with a name to identify it in logs and errors.
+type namedHook struct {
+	name string
+	hook Hook
 }
 
 // NewAppCtx constructs a new application context.
@@ -56,3 +92,64 @@ func (c *AppCtx) Repository(name string) (persistence.Repository, error) {
 func (c *AppCtx) RegisterRepository(init func(db any) (persistence.Repository, error)) error {
 	return c.Repositories.RegisterRepository(init)
 }
+
+// OnInit registers a hook that is run by AppCtx.Init. Hooks are run in the order they were registered.
+// The name identifies the hook in logs and errors, usually it is the name of the module registering the hook.
+func (c *AppCtx) OnInit(name string, hook Hook) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+
+	c.lifecycle.initHooks = append(c.lifecycle.initHooks, namedHook{name: name, hook: hook})
+}
+
+// OnShutdown registers a hook that is run by AppCtx.Shutdown. Hooks are run in the reverse order they were registered.
+// Therefore, resources registered first (e.g. the database) are released last.
+// The name identifies the hook in logs and errors, usually it is the name of the module registering the hook.
+func (c *AppCtx) OnShutdown(name string, hook Hook) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+
+	c.lifecycle.shutdownHooks = append(c.lifecycle.shutdownHooks, namedHook{name: name, hook: hook})
+}
+
+// Init runs all init hooks in the order they were registered. It stops at the first failing hook
+// and returns ErrInit joined with the hook's error. Init should be called once after all modules are registered.
+func (c *AppCtx) Init(ctx context.Context) error {
+	c.lifecycle.mu.Lock()
+	hooks := append([]namedHook(nil), c.lifecycle.initHooks...)
+	c.lifecycle.mu.Unlock()
+
+	for _, h := range hooks {
+		c.Debug(Pkg, "running init hook", "hook", h.name)
+
+		if err := h.hook(ctx); err != nil {
+			return errors.Join(ErrInit, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	return nil
+}
+
+// Shutdown runs all shutdown hooks in the reverse order they were registered. Failing hooks do not stop the shutdown,
+// instead all errors are collected and returned joined with ErrShutdown. Shutdown should be called once before the application exits.
+func (c *AppCtx) Shutdown(ctx context.Context) error {
+	c.lifecycle.mu.Lock()
+	hooks := append([]namedHook(nil), c.lifecycle.shutdownHooks...)
+	c.lifecycle.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		c.Debug(Pkg, "running shutdown hook", "hook", h.name)
+
+		if err := h.hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(append([]error{ErrShutdown}, errs...)...)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package hctx
+
+import (
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAppCtxLifecycle(t *testing.T) {
+	appCtx := NewAppCtx(trace.NewTestLogger(t), nil, nil, nil)
+
+	var calls []string
+	hook := func(name string, err error) Hook {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	appCtx.OnInit("first", hook("init first", nil))
+	appCtx.OnInit("second", hook("init second", nil))
+	appCtx.OnShutdown("first", hook("shutdown first", nil))
+	appCtx.OnShutdown("second", hook("shutdown second", nil))
+
+	require.NoError(t, appCtx.Init(context.Background()))
+	require.NoError(t, appCtx.Shutdown(context.Background()))
+	assert.Equal(t, []string{"init first", "init second", "shutdown second", "shutdown first"}, calls)
+}
+
+func TestAppCtxInitStopsOnError(t *testing.T) {
+	appCtx := NewAppCtx(trace.NewTestLogger(t), nil, nil, nil)
+	hookErr := errors.New("hook failed")
+
+	var calls []string
+	appCtx.OnInit("failing", func(ctx context.Context) error {
+		calls = append(calls, "failing")
+		return hookErr
+	})
+	appCtx.OnInit("skipped", func(ctx context.Context) error {
+		calls = append(calls, "skipped")
+		return nil
+	})
+
+	err := appCtx.Init(context.Background())
+	assert.ErrorIs(t, err, ErrInit)
+	assert.ErrorIs(t, err, hookErr)
+	assert.Equal(t, []string{"failing"}, calls)
+}
+
+func TestAppCtxShutdownRunsAllHooks(t *testing.T) {
+	appCtx := NewAppCtx(trace.NewTestLogger(t), nil, nil, nil)
+	firstErr := errors.New("first failed")
+	secondErr := errors.New("second failed")
+
+	var calls []string
+	appCtx.OnShutdown("first", func(ctx context.Context) error {
+		calls = append(calls, "first")
+		return firstErr
+	})
+	appCtx.OnShutdown("second", func(ctx context.Context) error {
+		calls = append(calls, "second")
+		return secondErr
+	})
+
+	err := appCtx.Shutdown(context.Background())
+	assert.ErrorIs(t, err, ErrShutdown)
+	assert.ErrorIs(t, err, firstErr)
+	assert.ErrorIs(t, err, secondErr)
+	assert.Equal(t, []string{"second", "first"}, calls)
+}
@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of failed attempts after which a message is dead-lettered.
+	DefaultMaxAttempts = 5
+	// DefaultBackoff is the default delay before the first retry of a failed message.
+	DefaultBackoff = 10 * time.Second
+	// MaxBackoff is the maximum delay between retries of a failed message.
+	MaxBackoff = time.Hour
+)
+
+// RetryPolicy decides when failed messages are retried and when they are dead-lettered.
+// The delay between retries starts at Backoff and doubles with each failed attempt up to MaxBackoff.
+type RetryPolicy struct {
+	// MaxAttempts is the number of failed attempts after which a message is dead-lettered.
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// eventCtx is implemented by events carrying the context they were published in, e.g. their tenant.
+type eventCtx interface {
+	Ctx() context.Context
+}
+
+// RetryPolicy returns the retry policy of the config. Zero values (or a nil config) use the defaults.
+func (c *Cfg) RetryPolicy() RetryPolicy {
+	policy := RetryPolicy{MaxAttempts: DefaultMaxAttempts, Backoff: DefaultBackoff}
+	if c == nil {
+		return policy
+	}
+
+	if c.MaxAttempts > 0 {
+		policy.MaxAttempts = c.MaxAttempts
+	}
+	if c.Backoff > 0 {
+		policy.Backoff = time.Duration(c.Backoff) * time.Second
+	}
+
+	return policy
+}
+
+// Next returns the time of the next attempt after the number of failed attempts and true if the message is dead-lettered instead.
+func (p RetryPolicy) Next(attempts int, now time.Time) (time.Time, bool) {
+	if attempts >= p.MaxAttempts {
+		return now, true
+	}
+
+	delay := p.Backoff
+	for i := 1; i < attempts && delay < MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return now.Add(min(delay, MaxBackoff)), false
+}
+
+// DeadLetter returns a failure handler for the event manager (see event.WithFailureHandler) storing asynchronously published
+// events that subscribers failed on in the outbox. The Relay publishes them again according to the retry policy,
+// the failed publishing counts as their first attempt. Events without a registered Decoder can not be published again,
+// they are stored as dead letters right away to be inspected. Events are stored for the tenant of their context
+// if they carry one (Ctx() context.Context). Events that can not be stored are logged.
+func DeadLetter(repository Repository, logger trace.Logger, retry RetryPolicy) func(event.Event, []error) {
+	return func(e event.Event, errs []error) {
+		ctx := context.Background()
+		if c, ok := e.(eventCtx); ok {
+			ctx = c.Ctx()
+		}
+
+		m, err := NewMessage(ctx, "", e)
+		if err != nil {
+			logger.Error(Pkg, "failed to encode failed event as dead letter", err, "eventID", e.ID())
+			return
+		}
+
+		now := time.Now()
+		next, dead := retry.Next(1, now)
+		m.Attempts = 1
+		m.LastError = errors.Join(errs...).Error()
+		m.NextAttemptAt = next
+		if dead || !Registered(e.ID()) {
+			m.DeadAt = &now
+		}
+
+		if err := repository.Create(context.Background(), m); err != nil {
+			logger.Error(Pkg, "failed to store failed event as dead letter", err, "eventID", e.ID())
+			return
+		}
+
+		logger.Info(Pkg, "stored failed event for retry", "eventID", e.ID(), "messageID", m.ID, "dead", m.DeadAt != nil)
+	}
+}
@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"errors"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Next(t *testing.T) {
+	assert.Equal(t, RetryPolicy{MaxAttempts: DefaultMaxAttempts, Backoff: DefaultBackoff}, (*Cfg)(nil).RetryPolicy())
+	assert.Equal(t, RetryPolicy{MaxAttempts: 3, Backoff: time.Minute}, (&Cfg{MaxAttempts: 3, Backoff: 60}).RetryPolicy())
+
+	policy := RetryPolicy{MaxAttempts: 20, Backoff: time.Minute}
+	now := time.Now()
+
+	next, dead := policy.Next(1, now)
+	assert.False(t, dead)
+	assert.Equal(t, now.Add(time.Minute), next)
+
+	next, _ = policy.Next(3, now)
+	assert.Equal(t, now.Add(4*time.Minute), next)
+
+	next, _ = policy.Next(19, now)
+	assert.Equal(t, now.Add(MaxBackoff), next)
+
+	_, dead = policy.Next(20, now)
+	assert.True(t, dead)
+}
+
+func TestDeadLetter(t *testing.T) {
+	Register(testEventID, decodeTestEvent)
+
+	repository := &repositoryMock{}
+	em := event.NewManager(trace.NewTestLogger(t), event.WithFailureHandler(
+		DeadLetter(repository, trace.NewTestLogger(t), RetryPolicy{MaxAttempts: 3, Backoff: time.Minute}),
+	))
+	em.Subscribe(testEventID, func(e event.Event, args *event.PublishArgs) error {
+		return errors.New("subscriber failed")
+	}, event.DefaultPriority)
+
+	done := make(chan []error)
+	em.Publish(&testEvent{Name: "failed"}, done)
+	<-done
+
+	require.Eventually(t, func() bool {
+		repository.mu.Lock()
+		defer repository.mu.Unlock()
+
+		return len(repository.messages) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	m := repository.messages[0]
+	assert.Equal(t, testEventID, m.EventID)
+	assert.JSONEq(t, `{"Name": "failed"}`, string(m.Payload))
+	assert.Equal(t, 1, m.Attempts)
+	assert.Equal(t, "subscriber failed", m.LastError)
+	assert.True(t, m.NextAttemptAt.After(time.Now()))
+	assert.Nil(t, m.DeadAt)
+
+	DeadLetter(repository, trace.NewTestLogger(t), RetryPolicy{MaxAttempts: 3})(&unknownEvent{}, []error{errors.New("failed")})
+	assert.NotNil(t, repository.messages[1].DeadAt, "events without a decoder are dead-lettered right away")
+}
+
+type unknownEvent struct{}
+
+func (e *unknownEvent) ID() string {
+	return "sys.outbox.test.unknown"
+}
+
+func (e *unknownEvent) Payload() any {
+	return e
+}
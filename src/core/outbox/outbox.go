@@ -0,0 +1,357 @@
+// Package outbox provides a transactional outbox for events. Events describing a database change are stored in the
+// same transaction as the change (see Add) and published to the event manager by the Relay after the transaction was committed.
+// Therefore, events are neither lost if the application stops after committing nor published for changes that were rolled back.
+//
+// Events are delivered at least once: a relay stopping after publishing an event but before marking it as published
+// publishes it again. Subscribers of events stored in the outbox should therefore be idempotent.
+// Each event is stored with a deduplication key, adding an event with a key already in the outbox is ignored.
+// Events can be scheduled for a later publication as well (see Schedule), e.g. to remind a user after a few days.
+//
+// Messages failing to publish are retried with an increasing delay and dead-lettered after the retry policy's attempts (see RetryPolicy).
+// Asynchronously published events that subscribers failed on can be stored in the outbox for a retry as well (see DeadLetter).
+// Dead letters are kept until they are re-delivered, e.g. through the administration.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"sync"
+	"time"
+)
+
+const (
+	// Pkg is the package name for logging.
+	Pkg = "sys.outbox"
+	// RepositoryName is the name of the outbox repository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	RepositoryName = "OutboxRepository"
+	// messageColumns are the columns of a message in the order scanned by scanMessage.
+	messageColumns = "id, event_id, payload, dedup_key, tenant_id, attempts, COALESCE(last_error, ''), next_attempt_at, created_at, published_at, dead_at"
+)
+
+// ErrNoDecoder is returned by Decode if no Decoder is registered for the message's event.
+var ErrNoDecoder = errors.New("no outbox decoder registered")
+
+// decoders are the registered decoders by the id of the event they decode, see Register.
+var decoders = struct {
+	mu sync.RWMutex
+	m  map[string]Decoder
+}{m: make(map[string]Decoder)}
+
+// Decoder restores an event from its JSON encoded payload stored in the outbox (see Add).
+// The context contains the tenant the event was added in, if the application is multi-tenant.
+type Decoder func(ctx context.Context, payload []byte) (event.Event, error)
+
+// Message is an event stored in the outbox. Payload is the JSON encoded payload of the event.
+type Message struct {
+	ID       uuid.UUID
+	EventID  string
+	Payload  []byte
+	DedupKey string
+	// TenantID is the tenant the event was added in, see tenant.ID.
+	TenantID string
+	// Attempts is the number of failed attempts to publish the message and LastError the error of the last failed attempt.
+	Attempts  int
+	LastError string
+	// NextAttemptAt is the time the message is published (again) at the earliest, see Schedule and RetryPolicy.
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	// PublishedAt is nil until the message was published.
+	PublishedAt *time.Time
+	// DeadAt is the time the message was dead-lettered, nil unless it exceeded the retry policy's attempts.
+	// Dead letters are not published until they are re-delivered, see Repository.Redeliver.
+	DeadAt *time.Time
+}
+
+// executor executes a statement, e.g. on a transaction (pgx.Tx) or the connection pool.
+type executor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// Repository is the outbox repository. Messages are added through Add as part of another repository's transaction.
+// Repository is safe for concurrent use by multiple goroutines.
+type Repository interface {
+	persistence.Repository
+
+	// Create stores the message outside a transaction, e.g. a failed event (see DeadLetter). A message with a deduplication key
+	// already in the outbox is ignored. It returns persistence.ErrInsert for any error.
+	Create(ctx context.Context, m *Message) error
+	// Relay claims up to limit messages due for publishing, the oldest first, and passes them to publish one after another.
+	// Messages claimed by a concurrent call are skipped. Messages published without error are marked as published,
+	// for the others the attempt and its error are recorded and they are retried or dead-lettered according to the retry policy.
+	// It returns the number of published messages and persistence.ErrUpdate if the messages could not be claimed or updated.
+	Relay(ctx context.Context, limit int, retry RetryPolicy, publish func(*Message) error) (int, error)
+	// FindDead finds up to limit dead letters of all tenants, the most recently dead-lettered first.
+	// It returns an empty slice if there are none and persistence.ErrReadRow for any other error.
+	FindDead(ctx context.Context, limit int) ([]*Message, error)
+	// Redeliver resets the attempts of the dead letter for the Relay to publish it again.
+	// It returns persistence.ErrNotFound if the message is not a dead letter and persistence.ErrUpdate for any other error.
+	Redeliver(ctx context.Context, id uuid.UUID) error
+	// DeletePublished deletes the messages published before the time. Their deduplication keys can be added again afterward.
+	// It returns the number of deleted messages and persistence.ErrDelete for any error.
+	DeletePublished(ctx context.Context, before time.Time) (int, error)
+}
+
+// PGRepository is the outbox repository for PostgreSQL. It holds a reference to the database connection pool.
+type PGRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewRepository constructs a new PGRepository with the passed in database connection pool.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &PGRepository{db: db}
+}
+
+// Register registers the decoder restoring events of the event id from the outbox. Events can only be relayed if their decoder
+// is registered, it should be registered with the subscription of the event. Registering a decoder for an event id again replaces it.
+func Register(eventID string, decoder Decoder) {
+	decoders.mu.Lock()
+	defer decoders.mu.Unlock()
+
+	decoders.m[eventID] = decoder
+}
+
+// Decode restores the event of the message using the decoder registered for its event id. The context passed to the decoder
+// contains the tenant, if it is not nil. It returns ErrNoDecoder if no decoder is registered for the event.
+func Decode(ctx context.Context, m *Message, t *tenant.Tenant) (event.Event, error) {
+	decoders.mu.RLock()
+	decoder, ok := decoders.m[m.EventID]
+	decoders.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoDecoder, m.EventID)
+	}
+
+	if t != nil {
+		ctx = tenant.WithTenant(ctx, t)
+	}
+
+	return decoder(ctx, m.Payload)
+}
+
+// Registered returns true if a decoder is registered for the event id. Only events with a decoder can be relayed.
+func Registered(eventID string) bool {
+	decoders.mu.RLock()
+	defer decoders.mu.RUnlock()
+
+	_, ok := decoders.m[eventID]
+	return ok
+}
+
+// NewMessage creates a message of the event due for publishing right away. The payload of the event is encoded as JSON,
+// the event's decoder must be able to restore the event from it (see Register). The message is for the tenant of the context.
+// If the deduplication key is empty, a random key is used.
+func NewMessage(ctx context.Context, dedupKey string, e event.Event) (*Message, error) {
+	payload, err := json.Marshal(e.Payload())
+	if err != nil {
+		return nil, err
+	}
+
+	if dedupKey == "" {
+		dedupKey = uuid.NewString()
+	}
+
+	now := time.Now()
+	return &Message{
+		ID:            uuid.New(),
+		EventID:       e.ID(),
+		Payload:       payload,
+		DedupKey:      dedupKey,
+		TenantID:      tenant.ID(ctx),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// Add stores the event in the outbox as part of the transaction, see NewMessage.
+// Adding an event with a deduplication key already in the outbox is ignored.
+// Errors are returned as is for the caller to roll back the transaction.
+func Add(ctx context.Context, tx pgx.Tx, dedupKey string, e event.Event) error {
+	return Schedule(ctx, tx, dedupKey, e, time.Now())
+}
+
+// Schedule stores the event in the outbox as part of the transaction to be published at the time, e.g. a reminder.
+// The Relay publishes the event in its first interval after the time. A scheduled event can be canceled until it was published,
+// see Cancel. Scheduling an event with a deduplication key already in the outbox is ignored, the key has to be canceled first
+// to schedule the event again. Errors are returned as is for the caller to roll back the transaction.
+func Schedule(ctx context.Context, tx pgx.Tx, dedupKey string, e event.Event, at time.Time) error {
+	m, err := NewMessage(ctx, dedupKey, e)
+	if err != nil {
+		return err
+	}
+
+	m.NextAttemptAt = at
+
+	return insert(ctx, tx, m)
+}
+
+// Cancel removes the event with the deduplication key from the outbox as part of the transaction. A scheduled event
+// is not published, the deduplication key of an already published event can be used again. Canceling an unknown key is ignored.
+// Errors are returned as is for the caller to roll back the transaction.
+func Cancel(ctx context.Context, tx pgx.Tx, dedupKey string) error {
+	_, err := tx.Exec(ctx, "DELETE FROM event_outbox WHERE dedup_key = $1", dedupKey)
+
+	return err
+}
+
+// RepositoryName returns the name of the repository. This name is used to identify the repository in the persistence.RepositoryProvider.
+func (r *PGRepository) RepositoryName() string {
+	return RepositoryName
+}
+
+// Create stores the message outside a transaction. A message with a deduplication key already in the outbox is ignored.
+// It returns persistence.ErrInsert for any error.
+func (r *PGRepository) Create(ctx context.Context, m *Message) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	if err := insert(ctx, r.db, m); err != nil {
+		return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// Relay claims up to limit messages due for publishing, the oldest first, and passes them to publish one after another.
+// The messages are locked (FOR UPDATE SKIP LOCKED) until all of them were published and their results recorded.
+// It returns the number of published messages and persistence.ErrUpdate if the messages could not be claimed or updated.
+func (r *PGRepository) Relay(ctx context.Context, limit int, retry RetryPolicy, publish func(*Message) error) (int, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	published := 0
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(
+			ctx,
+			"SELECT "+messageColumns+` FROM event_outbox
+			WHERE published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= now()
+			ORDER BY created_at LIMIT $1 FOR UPDATE SKIP LOCKED`,
+			limit,
+		)
+		messages, err := persistence.PGCollectRows(rows, err, scanMessage)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range messages {
+			if publishErr := publish(m); publishErr != nil {
+				now := time.Now()
+				next, dead := retry.Next(m.Attempts+1, now)
+				var deadAt *time.Time
+				if dead {
+					deadAt = &now
+				}
+
+				_, err = tx.Exec(
+					ctx,
+					"UPDATE event_outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2, dead_at = $3 WHERE id = $4",
+					publishErr.Error(), next, deadAt, m.ID,
+				)
+			} else {
+				published++
+				_, err = tx.Exec(ctx, "UPDATE event_outbox SET published_at = now() WHERE id = $1", m.ID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+
+	return published, nil
+}
+
+// FindDead finds up to limit dead letters of all tenants, the most recently dead-lettered first.
+// It returns an empty slice if there are none and persistence.ErrReadRow for any other error.
+func (r *PGRepository) FindDead(ctx context.Context, limit int) ([]*Message, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(
+		ctx,
+		"SELECT "+messageColumns+" FROM event_outbox WHERE dead_at IS NOT NULL ORDER BY dead_at DESC LIMIT $1",
+		limit,
+	)
+
+	return persistence.PGCollectRows(rows, err, scanMessage)
+}
+
+// Redeliver resets the attempts of the dead letter for the Relay to publish it again.
+// It returns persistence.ErrNotFound if the message is not a dead letter and persistence.ErrUpdate for any other error.
+func (r *PGRepository) Redeliver(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.Exec(
+		ctx,
+		"UPDATE event_outbox SET attempts = 0, next_attempt_at = now(), dead_at = NULL WHERE id = $1 AND dead_at IS NOT NULL",
+		id,
+	)
+	if err != nil {
+		return errors.Join(persistence.ErrUpdate, persistence.TimeoutErr(err))
+	}
+	if result.RowsAffected() == 0 {
+		return persistence.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeletePublished deletes the messages published before the time. Their deduplication keys can be added again afterward.
+// It returns the number of deleted messages and persistence.ErrDelete for any error.
+func (r *PGRepository) DeletePublished(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.Exec(ctx, "DELETE FROM event_outbox WHERE published_at < $1", before)
+	if err != nil {
+		return 0, errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+// insert inserts the message ignoring a conflicting deduplication key.
+func insert(ctx context.Context, db executor, m *Message) error {
+	_, err := db.Exec(
+		ctx,
+		`INSERT INTO event_outbox (id, event_id, payload, dedup_key, tenant_id, attempts, last_error, next_attempt_at, created_at, dead_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10) ON CONFLICT (dedup_key) DO NOTHING`,
+		m.ID, m.EventID, m.Payload, m.DedupKey, m.TenantID, m.Attempts, m.LastError, m.NextAttemptAt, m.CreatedAt, m.DeadAt,
+	)
+
+	return err
+}
+
+// scanMessage scans a message in the order of messageColumns.
+func scanMessage(row pgx.Row) (*Message, error) {
+	m := &Message{}
+	err := row.Scan(
+		&m.ID,
+		&m.EventID,
+		&m.Payload,
+		&m.DedupKey,
+		&m.TenantID,
+		&m.Attempts,
+		&m.LastError,
+		&m.NextAttemptAt,
+		&m.CreatedAt,
+		&m.PublishedAt,
+		&m.DeadAt,
+	)
+
+	return m, err
+}
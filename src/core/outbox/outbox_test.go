@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testEventID = "sys.outbox.test.happened"
+
+type testEvent struct {
+	Name   string
+	tenant *tenant.Tenant
+}
+
+func TestDecode(t *testing.T) {
+	Register(testEventID, decodeTestEvent)
+	ctx := context.Background()
+
+	payload, err := json.Marshal((&testEvent{Name: "first"}).Payload())
+	require.NoError(t, err)
+
+	e, err := Decode(ctx, &Message{EventID: testEventID, Payload: payload}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &testEvent{Name: "first"}, e)
+
+	uniA := &tenant.Tenant{ID: "uni-a"}
+	e, err = Decode(ctx, &Message{EventID: testEventID, Payload: payload, TenantID: "uni-a"}, uniA)
+	require.NoError(t, err)
+	assert.Equal(t, uniA, e.(*testEvent).tenant)
+
+	_, err = Decode(ctx, &Message{EventID: testEventID, Payload: []byte("{")}, nil)
+	assert.Error(t, err)
+
+	_, err = Decode(ctx, &Message{EventID: "sys.outbox.test.unknown", Payload: payload}, nil)
+	assert.ErrorIs(t, err, ErrNoDecoder)
+}
+
+func decodeTestEvent(ctx context.Context, payload []byte) (event.Event, error) {
+	e := &testEvent{}
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+
+	e.tenant, _ = tenant.FromCtx(ctx)
+
+	return e, nil
+}
+
+func (e *testEvent) ID() string {
+	return testEventID
+}
+
+func (e *testEvent) Payload() any {
+	return e
+}
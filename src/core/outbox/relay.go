@@ -0,0 +1,217 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultInterval is the default interval in which the Relay publishes pending messages.
+	DefaultInterval = time.Second
+	// DefaultBatchSize is the default maximum number of messages the Relay publishes per interval.
+	DefaultBatchSize = 100
+	// MetricRelayed is the counter of messages relayed labeled by "event_id" and "result" (published, failed or dead).
+	MetricRelayed = "outbox_relayed_total"
+)
+
+// Cfg configures the Relay. Zero values use the defaults.
+type Cfg struct {
+	// Interval is the interval in milliseconds in which pending messages are published.
+	Interval int `toml:"interval" env:"OUTBOX_INTERVAL"`
+	// BatchSize is the maximum number of messages published per interval.
+	BatchSize int `toml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	// Retention is the number of hours published messages are kept to deduplicate events. 0 keeps them forever.
+	Retention int `toml:"retention" env:"OUTBOX_RETENTION"`
+	// MaxAttempts is the number of failed attempts after which a message is dead-lettered, see RetryPolicy.
+	MaxAttempts int `toml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS"`
+	// Backoff is the delay in seconds before the first retry of a failed message, see RetryPolicy.
+	Backoff int `toml:"backoff" env:"OUTBOX_BACKOFF"`
+}
+
+// Relay publishes the pending messages of the outbox to the event manager in an interval (see Relay.Start).
+// Messages are decoded by their registered Decoder and published through event.Manager.PublishSync.
+// A message is only marked as published if it could be decoded and none of its event's subscribers failed,
+// otherwise it is published again or dead-lettered according to the retry policy (see Cfg.RetryPolicy). Relay is safe for concurrent use by multiple goroutines,
+// multiple relays (e.g. of multiple instances of the application) can publish messages of the same outbox.
+//
+// TODO deliver the relayed events to webhooks as well. This needs webhook subscriptions (URL and events) managed by the users,
+// signed with their webhook secrets stored in app/integration and sent only to public addresses. Until then, events are only
+// published to the event manager.
+type Relay struct {
+	repository Repository
+	em         event.Manager
+	logger     trace.Logger
+	metrics    trace.Metrics
+	// tenants returns the tenant by its id to restore the tenant of decoded events, see WithTenants.
+	tenants   func(id string) (*tenant.Tenant, bool)
+	interval  time.Duration
+	batchSize int
+	retention time.Duration
+	retry     RetryPolicy
+	done      chan struct{}
+	once      sync.Once
+	// running is done once the goroutine started by Start returned.
+	running sync.WaitGroup
+}
+
+// RelayOption configures the Relay on creation through NewRelay.
+type RelayOption func(*Relay)
+
+// WithMetrics records the number of published and failed messages in the metrics, see MetricRelayed.
+func WithMetrics(metrics trace.Metrics) RelayOption {
+	return func(r *Relay) {
+		r.metrics = metrics
+	}
+}
+
+// WithTenants restores the tenant of messages by their tenant id (e.g. tenant.Resolver.Tenant).
+// Without it, events are decoded without a tenant.
+func WithTenants(tenants func(id string) (*tenant.Tenant, bool)) RelayOption {
+	return func(r *Relay) {
+		r.tenants = tenants
+	}
+}
+
+// NewRelay creates a new Relay publishing the messages of the repository to the event manager. The config might be nil.
+func NewRelay(repository Repository, em event.Manager, logger trace.Logger, cfg *Cfg, opts ...RelayOption) *Relay {
+	r := &Relay{
+		repository: repository,
+		em:         em,
+		logger:     logger,
+		interval:   DefaultInterval,
+		batchSize:  DefaultBatchSize,
+		retry:      cfg.RetryPolicy(),
+		done:       make(chan struct{}),
+	}
+
+	if cfg != nil {
+		if cfg.Interval > 0 {
+			r.interval = time.Duration(cfg.Interval) * time.Millisecond
+		}
+		if cfg.BatchSize > 0 {
+			r.batchSize = cfg.BatchSize
+		}
+		r.retention = time.Duration(cfg.Retention) * time.Hour
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Start publishes pending messages in the configured interval until the Relay is stopped.
+// If a full batch was published, the next batch is published right away.
+func (r *Relay) Start() {
+	ticker := time.NewTicker(r.interval)
+	r.running.Add(1)
+
+	go func() {
+		defer r.running.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.tick()
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops publishing pending messages. It waits for a batch that is currently published to finish.
+func (r *Relay) Stop() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+
+	r.running.Wait()
+}
+
+// RelayPending publishes up to one batch of pending messages and returns the number of published messages.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	return r.repository.Relay(ctx, r.batchSize, r.retry, func(m *Message) error {
+		err := r.publish(ctx, m)
+		if err == nil {
+			r.record(m.EventID, "published")
+			return nil
+		}
+
+		if _, dead := r.retry.Next(m.Attempts+1, time.Now()); dead {
+			r.logger.Error(Pkg, "failed to relay event, dead-lettered it", err, "eventID", m.EventID, "messageID", m.ID, "attempts", m.Attempts+1)
+			r.record(m.EventID, "dead")
+		} else {
+			r.logger.Warn(Pkg, "failed to relay event", "eventID", m.EventID, "messageID", m.ID, "attempts", m.Attempts+1, "error", err)
+			r.record(m.EventID, "failed")
+		}
+
+		return err
+	})
+}
+
+// tick publishes batches of pending messages until a batch is not full and deletes the messages exceeding the retention.
+func (r *Relay) tick() {
+	ctx := context.Background()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+
+		published, err := r.RelayPending(ctx)
+		if err != nil {
+			r.logger.Error(Pkg, "failed to relay pending events", err)
+			break
+		}
+		if published < r.batchSize {
+			break
+		}
+	}
+
+	if r.retention <= 0 {
+		return
+	}
+
+	if _, err := r.repository.DeletePublished(ctx, time.Now().Add(-r.retention)); err != nil {
+		r.logger.Error(Pkg, "failed to delete published events", err)
+	}
+}
+
+// publish decodes the message and publishes its event. The errors of the event's subscribers are joined.
+func (r *Relay) publish(ctx context.Context, m *Message) error {
+	var t *tenant.Tenant
+	if r.tenants != nil {
+		if found, ok := r.tenants(m.TenantID); ok {
+			t = found
+		}
+	}
+
+	e, err := Decode(ctx, m, t)
+	if err != nil {
+		return err
+	}
+
+	if errs := r.em.PublishSync(e); len(errs) > 0 {
+		return fmt.Errorf("%d subscriber(s) failed: %w", len(errs), errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// record adds the relayed message to the metrics by its event and result.
+func (r *Relay) record(eventID string, result string) {
+	if r.metrics != nil {
+		r.metrics.Add(MetricRelayed, 1, "event_id", eventID, "result", result)
+	}
+}
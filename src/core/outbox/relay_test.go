@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// repositoryMock is an in-memory Repository relaying its messages in the order they were added.
+type repositoryMock struct {
+	Repository
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func TestRelay_RelayPending(t *testing.T) {
+	Register(testEventID, decodeTestEvent)
+
+	repository := &repositoryMock{}
+	repository.add(t, testEventID, "default", &testEvent{Name: "ok"})
+	repository.add(t, testEventID, "uni-a", &testEvent{Name: "fails"})
+	repository.add(t, "sys.outbox.test.unknown", "default", &testEvent{Name: "unknown"})
+
+	uniA := &tenant.Tenant{ID: "uni-a"}
+	em := event.NewManager(trace.NewTestLogger(t))
+	var received []*testEvent
+	em.Subscribe(testEventID, func(e event.Event, args *event.PublishArgs) error {
+		received = append(received, e.(*testEvent))
+		if e.(*testEvent).Name == "fails" {
+			return errors.New("subscriber failed")
+		}
+		return nil
+	}, event.DefaultPriority)
+
+	metrics := trace.NewMemoryMetrics()
+	relay := NewRelay(repository, em, trace.NewLogger(), &Cfg{BatchSize: 10, MaxAttempts: 2},
+		WithMetrics(metrics),
+		WithTenants(func(id string) (*tenant.Tenant, bool) {
+			return uniA, id == "uni-a"
+		}),
+	)
+
+	published, err := relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	require.Len(t, received, 2)
+	assert.Nil(t, received[0].tenant)
+	assert.Equal(t, uniA, received[1].tenant)
+
+	assert.NotNil(t, repository.messages[0].PublishedAt)
+	assert.Nil(t, repository.messages[1].PublishedAt)
+	assert.Equal(t, 1, repository.messages[1].Attempts)
+	assert.Contains(t, repository.messages[1].LastError, "subscriber failed")
+	assert.Nil(t, repository.messages[2].PublishedAt)
+	assert.Contains(t, repository.messages[2].LastError, ErrNoDecoder.Error())
+
+	snapshot := metrics.Snapshot()
+	assert.Equal(t, float64(1), snapshot.Counter(MetricRelayed, "event_id", testEventID, "result", "published"))
+	assert.Equal(t, float64(1), snapshot.Counter(MetricRelayed, "event_id", testEventID, "result", "failed"))
+	assert.Equal(t, float64(1), snapshot.Counter(MetricRelayed, "event_id", "sys.outbox.test.unknown", "result", "failed"))
+
+	published, err = relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, published)
+	assert.Len(t, received, 2, "failed messages are retried after the backoff")
+
+	repository.messages[1].NextAttemptAt = time.Now()
+	published, err = relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, published)
+	assert.Len(t, received, 3, "failed messages are published again")
+	assert.Equal(t, 2, repository.messages[1].Attempts)
+	assert.NotNil(t, repository.messages[1].DeadAt, "messages exceeding the attempts are dead-lettered")
+	assert.Equal(t, float64(1), metrics.Snapshot().Counter(MetricRelayed, "event_id", testEventID, "result", "dead"))
+}
+
+func TestRelay_StartStop(t *testing.T) {
+	Register(testEventID, decodeTestEvent)
+
+	repository := &repositoryMock{}
+	repository.add(t, testEventID, "default", &testEvent{Name: "started"})
+
+	em := event.NewManager(trace.NewTestLogger(t))
+	received := make(chan string, 1)
+	em.Subscribe(testEventID, func(e event.Event, args *event.PublishArgs) error {
+		received <- e.(*testEvent).Name
+		return nil
+	}, event.DefaultPriority)
+
+	relay := NewRelay(repository, em, trace.NewTestLogger(t), &Cfg{Interval: 10})
+	relay.Start()
+
+	select {
+	case name := <-received:
+		assert.Equal(t, "started", name)
+	case <-time.After(time.Second):
+		t.Fatal("pending message was not relayed")
+	}
+
+	relay.Stop()
+	relay.Stop()
+}
+
+func (r *repositoryMock) add(t *testing.T, eventID string, tenantID string, e event.Event) {
+	payload, err := json.Marshal(e.Payload())
+	require.NoError(t, err)
+
+	r.messages = append(r.messages, &Message{
+		ID:            uuid.New(),
+		EventID:       eventID,
+		Payload:       payload,
+		TenantID:      tenantID,
+		NextAttemptAt: time.Now(),
+		CreatedAt:     time.Now(),
+	})
+}
+
+func (r *repositoryMock) Create(ctx context.Context, m *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = append(r.messages, m)
+	return nil
+}
+
+func (r *repositoryMock) Relay(ctx context.Context, limit int, retry RetryPolicy, publish func(*Message) error) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	published := 0
+	for _, m := range r.messages {
+		if m.PublishedAt != nil || m.DeadAt != nil || m.NextAttemptAt.After(time.Now()) || limit == 0 {
+			continue
+		}
+		limit--
+
+		if err := publish(m); err != nil {
+			now := time.Now()
+			next, dead := retry.Next(m.Attempts+1, now)
+			m.Attempts++
+			m.LastError = err.Error()
+			m.NextAttemptAt = next
+			if dead {
+				m.DeadAt = &now
+			}
+			continue
+		}
+
+		now := time.Now()
+		m.PublishedAt = &now
+		published++
+	}
+
+	return published, nil
+}
+
+func (r *repositoryMock) DeletePublished(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
@@ -34,9 +34,16 @@ type PostgresDBCfg struct {
 type PGRepositoryProvider struct {
 	db           *pgxpool.Pool
 	repositories map[string]Repository
+	timeouts     *TimeoutCfg
 	mu           sync.RWMutex
 }
 
+// DBOption is an option for the database connection pool created by NewDB.
+type DBOption func(*pgxpool.Config)
+
+// PGRepositoryProviderOption is an option for the PGRepositoryProvider.
+type PGRepositoryProviderOption func(*PGRepositoryProvider)
+
 // Repository interface should be safe for concurrent use by multiple goroutines.
 // It defines the most basic functionality of a repository. By now this is only returning the repository's name.
 type Repository interface {
@@ -53,15 +60,26 @@ type RepositoryProvider interface {
 // The PostgresDBCfg is usually read from a config file.
 // The returned database connection pool should be used for instantiating the repositories.
 // Direct access to the database connection pool should be avoided.
-func NewDB(cfg *PostgresDBCfg) (*pgxpool.Pool, error) {
+func NewDB(cfg *PostgresDBCfg, opts ...DBOption) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(cfg.String())
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", DBConfigError, err)
 	}
 
+	for _, opt := range opts {
+		opt(config)
+	}
+
 	return newDBWithConfig(config)
 }
 
+// WithQueryTracer sets the tracer for all queries of the database connection pool, e.g. the QueryTracer.
+func WithQueryTracer(tracer pgx.QueryTracer) DBOption {
+	return func(config *pgxpool.Config) {
+		config.ConnConfig.Tracer = tracer
+	}
+}
+
 // NewDBWithString creates a new database connection pool from a Postgres connection string.
 func NewDBWithString(cfg string) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(cfg)
@@ -96,11 +114,24 @@ func (cfg *PostgresDBCfg) StringWoDBName() string {
 }
 
 // NewPGRepositoryProvider creates a new PGRepositoryProvider with the given database connection pool.
-func NewPGRepositoryProvider(db *pgxpool.Pool) RepositoryProvider {
-	return &PGRepositoryProvider{
+func NewPGRepositoryProvider(db *pgxpool.Pool, opts ...PGRepositoryProviderOption) RepositoryProvider {
+	rp := &PGRepositoryProvider{
 		db:           db,
 		repositories: make(map[string]Repository),
 	}
+
+	for _, opt := range opts {
+		opt(rp)
+	}
+
+	return rp
+}
+
+// WithTimeouts sets the timeouts applied to repositories implementing the TimeoutRepository interface upon registration.
+func WithTimeouts(cfg *TimeoutCfg) PGRepositoryProviderOption {
+	return func(rp *PGRepositoryProvider) {
+		rp.timeouts = cfg
+	}
 }
 
 // Repository returns the repository with the given name. If the repository does not exist, an error is returned.
@@ -119,6 +150,7 @@ func (rp *PGRepositoryProvider) Repository(name string) (Repository, error) {
 // The init function is called with the database connection as an argument.
 // This abstraction was made to avoid direct access to the database connection and keep the repositories database agnostic.
 // Only the repository itself should access the database.
+// If the repository implements the TimeoutRepository interface, the configured timeout is set for the repository.
 func (rp *PGRepositoryProvider) RegisterRepository(init func(db any) (Repository, error)) error {
 	rp.mu.Lock()
 	defer rp.mu.Unlock()
@@ -128,12 +160,17 @@ func (rp *PGRepositoryProvider) RegisterRepository(init func(db any) (Repository
 		return err
 	}
 
+	if timeoutRepo, ok := repo.(TimeoutRepository); ok {
+		timeoutRepo.SetTimeout(rp.timeouts.For(repo.RepositoryName()))
+	}
+
 	rp.repositories[repo.RepositoryName()] = repo
 
 	return nil
 }
 
 // PGReadErr returns a ErrNotFound if the passed in error is a pgx.ErrNoRows. Otherwise, it returns a ErrReadRow.
+// If the read did not finish in time, the ErrReadRow is also joined with ErrTimeout.
 // This is a utility function for wrapping the pgx error inside a persistence-package error.
 func PGReadErr(err error) error {
 	if err == nil {
@@ -144,5 +181,5 @@ func PGReadErr(err error) error {
 		return errors.Join(ErrNotFound, err)
 	}
 
-	return errors.Join(ErrReadRow, err)
+	return errors.Join(ErrReadRow, TimeoutErr(err))
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -78,6 +80,7 @@ func Migrate(ctx context.Context, direction MigrateDirection, migrationsDir stri
 }
 
 // migrateMigrations migrates a list of migrations up/down depending on the direction and the status of the migration.
+// The migrations are executed in the order of their timestamps, see sortMigrations.
 func migrateMigrations(
 	ctx context.Context,
 	direction MigrateDirection,
@@ -86,7 +89,8 @@ func migrateMigrations(
 	migrationsDir string,
 	db *pgxpool.Pool,
 ) error {
-	for name, migration := range migrations {
+	for _, name := range sortMigrations(migrations, direction) {
+		migration := migrations[name]
 		_, isMigrationExecuted := executedMigrations[name]
 		if direction == MigrateUp && isMigrationExecuted {
 			fmt.Printf("skipping migration %s on %s: already executed\n", name, MigrateUp)
@@ -159,6 +163,41 @@ func ensureMigrationsTablesPresence(ctx context.Context, db *pgxpool.Pool) error
 	return nil
 }
 
+// sortMigrations returns the names of the migrations ordered by the timestamp at the end of their names.
+// Schema: <name><unix timestamp>, e.g. Init1697574747. Up migrations are ordered ascending, down migrations descending.
+func sortMigrations(migrations map[string]string, direction MigrateDirection) []string {
+	names := make([]string, 0, len(migrations))
+	for name := range migrations {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		ti, tj := migrationTimestamp(names[i]), migrationTimestamp(names[j])
+		if ti == tj {
+			return names[i] < names[j]
+		}
+		if direction == MigrateDown {
+			return ti > tj
+		}
+
+		return ti < tj
+	})
+
+	return names
+}
+
+// migrationTimestamp returns the unix timestamp at the end of the migration name or 0 if there is none.
+func migrationTimestamp(name string) int64 {
+	i := len(name)
+	for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+		i--
+	}
+
+	timestamp, _ := strconv.ParseInt(name[i:], 10, 64)
+
+	return timestamp
+}
+
 // trimMigrationSuffix trims the migration suffix from the migration name.
 // Schema: <name>_<direction>.sql => <name>
 func trimMigrationSuffix(name string) string {
@@ -0,0 +1,29 @@
+package persistence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSortMigrations(t *testing.T) {
+	migrations := map[string]string{
+		"Tenancy1792097761":  "",
+		"Init1697574747":     "",
+		"Sessions1701354221": "",
+		"Alpha1701354221":    "",
+	}
+
+	assert.Equal(t,
+		[]string{"Init1697574747", "Alpha1701354221", "Sessions1701354221", "Tenancy1792097761"},
+		sortMigrations(migrations, MigrateUp),
+	)
+	assert.Equal(t,
+		[]string{"Tenancy1792097761", "Alpha1701354221", "Sessions1701354221", "Init1697574747"},
+		sortMigrations(migrations, MigrateDown),
+	)
+}
+
+func TestMigrationTimestamp(t *testing.T) {
+	assert.Equal(t, int64(1697574747), migrationTimestamp("Init1697574747"))
+	assert.Equal(t, int64(0), migrationTimestamp("Init"))
+}
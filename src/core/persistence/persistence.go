@@ -2,7 +2,21 @@
 // Part of this package is the database implementation and the session storage.
 package persistence
 
+// TODO add a SQLite backend for small/demo deployments selected through Cfg (e.g. a driver = "sqlite" setting). This is blocked:
+//  - no SQLite driver is a dependency yet (modernc.org/sqlite would avoid cgo), it has to be added and vendored
+//  - all repositories take a *pgxpool.Pool and scan pgx.Row (see PGScanFunc), they need a database/sql based abstraction
+//  - queries use Postgres specifics (RETURNING with uuid columns, jsonb, ILIKE, ON CONFLICT, now()) that differ per dialect
+//  - migrations are Postgres SQL and have to be split per dialect (e.g. migrations/postgres and migrations/sqlite)
+//  - the web application registers all repositories (not only user, session and template) with the PGRepositoryProvider,
+//    so SQLite support for a subset of repositories would still require Postgres
+
 // Cfg is the configuration for the persistence package.
 type Cfg struct {
 	DB *PostgresDBCfg `toml:"db"`
+	// Pool configures the database connection pool, see PoolCfg.
+	Pool *PoolCfg `toml:"pool"`
+	// Timeouts configures the timeouts of repository operations. Without timeouts, operations only end with the request's context.
+	Timeouts *TimeoutCfg `toml:"timeouts"`
+	// Tracing configures the query tracing, see QueryTracer.
+	Tracing *TracingCfg `toml:"tracing"`
 }
@@ -0,0 +1,195 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// MetricPoolAcquires is the counter of connections acquired from the pool.
+	MetricPoolAcquires = "db_pool_acquires_total"
+	// MetricPoolEmptyAcquires is the counter of acquires that had to wait for a connection because the pool was empty.
+	MetricPoolEmptyAcquires = "db_pool_empty_acquires_total"
+	// MetricPoolCanceledAcquires is the counter of acquires canceled by their context.
+	MetricPoolCanceledAcquires = "db_pool_canceled_acquires_total"
+	// MetricPoolAcquireDuration is the counter of the total duration in seconds spent acquiring connections.
+	MetricPoolAcquireDuration = "db_pool_acquire_duration_seconds_total"
+	// MetricPoolNewConns is the counter of newly opened connections.
+	MetricPoolNewConns = "db_pool_new_conns_total"
+	// MetricPoolClosedConns is the counter of connections closed by the pool labeled by "reason" (lifetime or idle).
+	MetricPoolClosedConns = "db_pool_closed_conns_total"
+)
+
+// ErrInvalidPoolCfg is returned by PoolCfg.Validate if the pool configuration is invalid.
+var ErrInvalidPoolCfg = errors.New("invalid database pool configuration")
+
+// PoolCfg configures the database connection pool. The maximum number of connections is configured by PostgresDBCfg.MaxConns.
+// Durations are in seconds, 0 uses the default of pgxpool (lifetime 1h, idle time 30m, health check 1m).
+type PoolCfg struct {
+	// MinConns is the minimum number of connections kept open, even if they are idle.
+	MinConns int `toml:"min_conns" env:"DB_MIN_CONNS"`
+	// MaxConnLifetime is the duration after which a connection is closed.
+	MaxConnLifetime int `toml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	// MaxConnIdleTime is the duration after which an idle connection is closed.
+	MaxConnIdleTime int `toml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+	// HealthCheckPeriod is the interval in which idle connections are checked.
+	HealthCheckPeriod int `toml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD"`
+	// StatsInterval is the interval in which the pool's statistics are logged and recorded, see WatchPoolStats. 0 disables the statistics.
+	StatsInterval int `toml:"stats_interval" env:"DB_POOL_STATS_INTERVAL"`
+}
+
+// PoolStats are the statistics of a connection pool, see pgxpool.Stat.
+// Counts and durations prefixed with "Total" are cumulative since the pool was created.
+type PoolStats struct {
+	TotalConns           int32
+	IdleConns            int32
+	AcquiredConns        int32
+	MaxConns             int32
+	TotalAcquires        int64
+	TotalEmptyAcquires   int64
+	TotalCanceled        int64
+	TotalAcquireDuration time.Duration
+	TotalNewConns        int64
+	TotalLifetimeClosed  int64
+	TotalIdleClosed      int64
+}
+
+// poolStatsReporter logs the pool's statistics and records the difference to the previously reported statistics in the metrics.
+type poolStatsReporter struct {
+	logger   trace.Logger
+	metrics  trace.Metrics
+	previous PoolStats
+}
+
+// Validate validates the pool configuration against the database configuration. Negative values are invalid
+// and the minimum number of connections must not exceed the maximum number of connections.
+func (c *PoolCfg) Validate(db *PostgresDBCfg) error {
+	if c == nil {
+		return nil
+	}
+
+	if c.MinConns < 0 || c.MaxConnLifetime < 0 || c.MaxConnIdleTime < 0 || c.HealthCheckPeriod < 0 || c.StatsInterval < 0 {
+		return fmt.Errorf("%w: values must not be negative", ErrInvalidPoolCfg)
+	}
+
+	if db == nil || db.MaxConns == "" {
+		return nil
+	}
+
+	maxConns, err := strconv.Atoi(db.MaxConns)
+	if err != nil || maxConns < 1 {
+		return fmt.Errorf("%w: max_conns %q must be a positive number", ErrInvalidPoolCfg, db.MaxConns)
+	}
+
+	if c.MinConns > maxConns {
+		return fmt.Errorf("%w: min_conns %d exceeds max_conns %d", ErrInvalidPoolCfg, c.MinConns, maxConns)
+	}
+
+	return nil
+}
+
+// WithPool applies the pool configuration to the database connection pool. Zero values keep the defaults of pgxpool.
+func WithPool(cfg *PoolCfg) DBOption {
+	return func(config *pgxpool.Config) {
+		if cfg == nil {
+			return
+		}
+
+		if cfg.MinConns > 0 {
+			config.MinConns = int32(cfg.MinConns)
+		}
+		if cfg.MaxConnLifetime > 0 {
+			config.MaxConnLifetime = time.Duration(cfg.MaxConnLifetime) * time.Second
+		}
+		if cfg.MaxConnIdleTime > 0 {
+			config.MaxConnIdleTime = time.Duration(cfg.MaxConnIdleTime) * time.Second
+		}
+		if cfg.HealthCheckPeriod > 0 {
+			config.HealthCheckPeriod = time.Duration(cfg.HealthCheckPeriod) * time.Second
+		}
+	}
+}
+
+// WatchPoolStats logs the statistics of the pool in the configured interval (see PoolCfg.StatsInterval) and records
+// the acquires and opened and closed connections in the metrics. If metrics is nil, no metrics are recorded.
+// The returned function stops watching the pool, it should be called before the pool is closed.
+// If the interval is 0 (or the config nil), the pool is not watched.
+func WatchPoolStats(db *pgxpool.Pool, cfg *PoolCfg, logger trace.Logger, metrics trace.Metrics) func() {
+	if cfg == nil || cfg.StatsInterval <= 0 {
+		return func() {}
+	}
+
+	reporter := &poolStatsReporter{logger: logger, metrics: metrics}
+	ticker := time.NewTicker(time.Duration(cfg.StatsInterval) * time.Second)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				reporter.report(PoolStatsOf(db))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// PoolStatsOf returns the current statistics of the pool.
+func PoolStatsOf(db *pgxpool.Pool) PoolStats {
+	stat := db.Stat()
+
+	return PoolStats{
+		TotalConns:           stat.TotalConns(),
+		IdleConns:            stat.IdleConns(),
+		AcquiredConns:        stat.AcquiredConns(),
+		MaxConns:             stat.MaxConns(),
+		TotalAcquires:        stat.AcquireCount(),
+		TotalEmptyAcquires:   stat.EmptyAcquireCount(),
+		TotalCanceled:        stat.CanceledAcquireCount(),
+		TotalAcquireDuration: stat.AcquireDuration(),
+		TotalNewConns:        stat.NewConnsCount(),
+		TotalLifetimeClosed:  stat.MaxLifetimeDestroyCount(),
+		TotalIdleClosed:      stat.MaxIdleDestroyCount(),
+	}
+}
+
+// report logs the statistics and records the difference to the previous statistics in the metrics.
+func (r *poolStatsReporter) report(stats PoolStats) {
+	r.logger.Debug(Pkg, "database pool stats",
+		"total", stats.TotalConns,
+		"idle", stats.IdleConns,
+		"acquired", stats.AcquiredConns,
+		"max", stats.MaxConns,
+		"emptyAcquires", stats.TotalEmptyAcquires-r.previous.TotalEmptyAcquires,
+	)
+
+	if stats.AcquiredConns == stats.MaxConns && stats.TotalEmptyAcquires > r.previous.TotalEmptyAcquires {
+		r.logger.Warn(Pkg, "database pool exhausted, requests are waiting for connections", "max", stats.MaxConns)
+	}
+
+	if r.metrics != nil {
+		r.metrics.Add(MetricPoolAcquires, float64(stats.TotalAcquires-r.previous.TotalAcquires))
+		r.metrics.Add(MetricPoolEmptyAcquires, float64(stats.TotalEmptyAcquires-r.previous.TotalEmptyAcquires))
+		r.metrics.Add(MetricPoolCanceledAcquires, float64(stats.TotalCanceled-r.previous.TotalCanceled))
+		r.metrics.Add(MetricPoolAcquireDuration, (stats.TotalAcquireDuration - r.previous.TotalAcquireDuration).Seconds())
+		r.metrics.Add(MetricPoolNewConns, float64(stats.TotalNewConns-r.previous.TotalNewConns))
+		r.metrics.Add(MetricPoolClosedConns, float64(stats.TotalLifetimeClosed-r.previous.TotalLifetimeClosed), "reason", "lifetime")
+		r.metrics.Add(MetricPoolClosedConns, float64(stats.TotalIdleClosed-r.previous.TotalIdleClosed), "reason", "idle")
+	}
+
+	r.previous = stats
+}
@@ -0,0 +1,57 @@
+package persistence
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPoolCfg_Validate(t *testing.T) {
+	db := &PostgresDBCfg{MaxConns: "10"}
+
+	assert.NoError(t, (*PoolCfg)(nil).Validate(db))
+	assert.NoError(t, (&PoolCfg{MinConns: 10, MaxConnLifetime: 3600}).Validate(db))
+	assert.ErrorIs(t, (&PoolCfg{MinConns: 11}).Validate(db), ErrInvalidPoolCfg)
+	assert.ErrorIs(t, (&PoolCfg{HealthCheckPeriod: -1}).Validate(db), ErrInvalidPoolCfg)
+	assert.ErrorIs(t, (&PoolCfg{}).Validate(&PostgresDBCfg{MaxConns: "many"}), ErrInvalidPoolCfg)
+}
+
+func TestWithPool(t *testing.T) {
+	config, err := pgxpool.ParseConfig((&PostgresDBCfg{Host: "localhost", Port: "5432", MaxConns: "10"}).String())
+	require.NoError(t, err)
+	defaultIdleTime := config.MaxConnIdleTime
+
+	WithPool(&PoolCfg{MinConns: 2, MaxConnLifetime: 60, HealthCheckPeriod: 5})(config)
+
+	assert.Equal(t, int32(2), config.MinConns)
+	assert.Equal(t, int32(10), config.MaxConns)
+	assert.Equal(t, time.Minute, config.MaxConnLifetime)
+	assert.Equal(t, defaultIdleTime, config.MaxConnIdleTime)
+	assert.Equal(t, 5*time.Second, config.HealthCheckPeriod)
+}
+
+func TestPoolStatsReporter(t *testing.T) {
+	metrics := trace.NewMemoryMetrics()
+	reporter := &poolStatsReporter{logger: trace.NewTestLogger(t), metrics: metrics}
+
+	reporter.report(PoolStats{TotalConns: 2, MaxConns: 10, TotalAcquires: 5, TotalAcquireDuration: time.Second, TotalNewConns: 2})
+	reporter.report(PoolStats{TotalConns: 10, AcquiredConns: 10, MaxConns: 10, TotalAcquires: 12, TotalEmptyAcquires: 3, TotalNewConns: 10,
+		TotalAcquireDuration: 3 * time.Second, TotalIdleClosed: 1})
+
+	snapshot := metrics.Snapshot()
+	assert.Equal(t, float64(12), snapshot.Counter(MetricPoolAcquires))
+	assert.Equal(t, float64(3), snapshot.Counter(MetricPoolEmptyAcquires))
+	assert.Equal(t, float64(3), snapshot.Counter(MetricPoolAcquireDuration))
+	assert.Equal(t, float64(10), snapshot.Counter(MetricPoolNewConns))
+	assert.Equal(t, float64(1), snapshot.Counter(MetricPoolClosedConns, "reason", "idle"))
+	assert.Equal(t, float64(0), snapshot.Counter(MetricPoolClosedConns, "reason", "lifetime"))
+}
+
+func TestWatchPoolStats(t *testing.T) {
+	stop := WatchPoolStats(nil, &PoolCfg{}, trace.NewTestLogger(t), nil)
+	stop()
+	stop()
+}
@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"github.com/jackc/pgx/v5"
+	"strings"
+)
+
+// PGScanFunc scans a single row into a new value. Repositories usually define one scan function per entity
+// next to a constant containing the entity's column list. The order of the scanned fields has to match the column list.
+// Both pgx.Row (QueryRow) and pgx.Rows (Query) can be scanned.
+type PGScanFunc[T any] func(row pgx.Row) (T, error)
+
+// PGScanRow scans a single row using the scan function. The error is wrapped using PGReadErr.
+// Therefore, it returns ErrNotFound if the row does not exist and ErrReadRow for any other error.
+// Write operations (e.g. INSERT ... RETURNING) should call the scan function directly and wrap the error themselves.
+func PGScanRow[T any](row pgx.Row, scan PGScanFunc[T]) (T, error) {
+	item, err := scan(row)
+	if err != nil {
+		var zero T
+		return zero, PGReadErr(err)
+	}
+
+	return item, nil
+}
+
+// PGCollectRows scans all rows using the scan function and closes the rows afterward. The query's error is passed in
+// as well, so the result of db.Query can be passed on without checking the error first. Errors are wrapped using PGReadErr.
+// An empty slice is returned if the query did not return any rows.
+func PGCollectRows[T any](rows pgx.Rows, err error, scan PGScanFunc[T]) ([]T, error) {
+	if err != nil {
+		return nil, PGReadErr(err)
+	}
+
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (T, error) {
+		return scan(row)
+	})
+	if err != nil {
+		return nil, PGReadErr(err)
+	}
+
+	return items, nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, see EscapeLike.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes the wildcards (% and _) of a LIKE pattern, the backslash is PostgreSQL's default escape character.
+// E.g. a search query is escaped before it is wrapped in wildcards to match the query literally.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// QualifyColumns prefixes each column of a comma separated column list with the table name.
+// E.g. QualifyColumns("users", "id, email") returns "users.id, users.email".
+// This is useful for reusing column list constants in queries joining multiple tables.
+func QualifyColumns(table string, columns string) string {
+	split := strings.Split(columns, ",")
+	for i, column := range split {
+		split[i] = table + "." + strings.TrimSpace(column)
+	}
+
+	return strings.Join(split, ", ")
+}
@@ -0,0 +1,53 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type mockRow struct {
+	values []any
+	err    error
+}
+
+func (r mockRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for i, d := range dest {
+		*(d.(*string)) = r.values[i].(string)
+	}
+
+	return nil
+}
+
+func TestQualifyColumns(t *testing.T) {
+	assert.Equal(t, "users.id, users.email", QualifyColumns("users", "id, email"))
+	assert.Equal(t, "users.id", QualifyColumns("users", "id"))
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\% \_done\\`, EscapeLike(`100% _done\`))
+	assert.Equal(t, "plain", EscapeLike("plain"))
+}
+
+func TestPGScanRow(t *testing.T) {
+	scan := func(row pgx.Row) (string, error) {
+		var s string
+		err := row.Scan(&s)
+		return s, err
+	}
+
+	value, err := PGScanRow[string](mockRow{values: []any{"foo"}}, scan)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", value)
+
+	_, err = PGScanRow[string](mockRow{err: pgx.ErrNoRows}, scan)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = PGScanRow[string](mockRow{err: errors.New("foo")}, scan)
+	assert.ErrorIs(t, err, ErrReadRow)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/tenant"
 	"time"
 )
 
@@ -61,18 +62,24 @@ func PGReadValidSession[P any, M any](ctx context.Context, db *pgxpool.Pool, key
 }
 
 // PGReadSession reads a session from the database without checking its validity (expiration).
+// Only sessions of the context's tenant are read (see tenant.ID).
 func PGReadSession[P any, M any](ctx context.Context, db *pgxpool.Pool, key uuid.UUID, session *Session[P, M]) error {
-	return db.QueryRow(ctx, "SELECT id, type, payload, meta, created_at, expires_at, updated_at FROM sessions WHERE id = $1", key).
+	return db.QueryRow(
+		ctx,
+		"SELECT id, type, payload, meta, created_at, expires_at, updated_at FROM sessions WHERE id = $1 AND tenant_id = $2",
+		key, tenant.ID(ctx),
+	).
 		Scan(&session.ID, &session.Type, &session.Payload, &session.Meta, &session.CreatedAt, &session.ExpiresAt, &session.UpdatedAt)
 }
 
 // PGWriteSession inserts a session into the database if it does not exist and updates it if it does.
 // Upon update, it will also set the updated_at field to the current time, modifying the session.
+// The session belongs to the context's tenant, sessions of other tenants are not updated.
 func PGWriteSession[P any, M any](ctx context.Context, db *pgxpool.Pool, session *Session[P, M]) error {
 	return db.QueryRow(
 		ctx,
-		`INSERT INTO sessions (id, type, payload, meta, created_at, expires_at) 
-         VALUES ($1, $2, $3, $4, $5, $6)
+		`INSERT INTO sessions (id, type, payload, meta, created_at, expires_at, tenant_id) 
+         VALUES ($1, $2, $3, $4, $5, $6, $7)
          ON CONFLICT (id) 
          DO UPDATE SET 
             type = excluded.type, 
@@ -81,15 +88,16 @@ func PGWriteSession[P any, M any](ctx context.Context, db *pgxpool.Pool, session
             created_at = excluded.created_at, 
             expires_at = excluded.expires_at, 
             updated_at = NOW()
+         WHERE sessions.tenant_id = excluded.tenant_id
          RETURNING updated_at`,
-		session.ID, session.Type, session.Payload, session.Meta, session.CreatedAt, session.ExpiresAt,
+		session.ID, session.Type, session.Payload, session.Meta, session.CreatedAt, session.ExpiresAt, tenant.ID(ctx),
 	).Scan(&session.UpdatedAt)
 }
 
 // PGDeleteSession deletes a session from the database by the key (id). Returns an error transparently if the session could not be deleted.
 // If no session with the key exists, it will return nil.
 func PGDeleteSession(ctx context.Context, db *pgxpool.Pool, key uuid.UUID) error {
-	_, err := db.Exec(ctx, "DELETE FROM sessions WHERE id = $1", key)
+	_, err := db.Exec(ctx, "DELETE FROM sessions WHERE id = $1 AND tenant_id = $2", key, tenant.ID(ctx))
 
 	return err
 }
@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+// ErrTimeout is returned when a repository operation did not finish in time. It is joined with the operation's error
+// (e.g. ErrReadRow or ErrInsert) and the underlying (database) error. The timeout is configured through TimeoutCfg.
+var ErrTimeout = errors.New("operation timed out")
+
+// TimeoutCfg configures the timeouts of repository operations. Timeouts are in milliseconds, 0 disables the timeout.
+// A timeout for a single repository overrides the default timeout. Repositories are identified by their name (Repository.RepositoryName).
+type TimeoutCfg struct {
+	// Default is the timeout applied to each repository without an explicit timeout.
+	Default int `toml:"default" env:"DB_TIMEOUT"`
+	// Repositories maps repository names to their timeout.
+	Repositories map[string]int `toml:"repositories"`
+}
+
+// TimeoutRepository is a repository supporting timeouts for its operations.
+// The PGRepositoryProvider sets the configured timeout when the repository is registered.
+type TimeoutRepository interface {
+	Repository
+	SetTimeout(timeout time.Duration)
+}
+
+// Timeout can be embedded into a repository to implement the TimeoutRepository interface.
+// The repository then calls WithTimeout at the beginning of each operation and defers the returned cancel function.
+type Timeout struct {
+	timeout time.Duration
+}
+
+// For returns the timeout configured for the repository. If the config is nil, no timeout (0) is returned.
+func (c *TimeoutCfg) For(repositoryName string) time.Duration {
+	if c == nil {
+		return 0
+	}
+
+	if timeout, ok := c.Repositories[repositoryName]; ok {
+		return time.Duration(timeout) * time.Millisecond
+	}
+
+	return time.Duration(c.Default) * time.Millisecond
+}
+
+// SetTimeout sets the timeout for each operation of the repository. A timeout of 0 disables the timeout.
+// It should only be called during setup before the repository is used.
+func (t *Timeout) SetTimeout(timeout time.Duration) {
+	t.timeout = timeout
+}
+
+// WithTimeout returns a context that is canceled after the repository's timeout.
+// If no timeout is set, the context is returned unchanged. The returned cancel function should always be deferred.
+func (t *Timeout) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, t.timeout)
+}
+
+// TimeoutErr joins ErrTimeout with the error if the error was caused by an exceeded deadline. Otherwise, the error is returned unchanged.
+// It is used by PGReadErr and should be used to wrap errors of write operations, e.g. errors.Join(ErrInsert, TimeoutErr(err)).
+func TimeoutErr(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return errors.Join(ErrTimeout, err)
+	}
+
+	return err
+}
@@ -0,0 +1,69 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTimeoutCfgFor(t *testing.T) {
+	cfg := &TimeoutCfg{Default: 100, Repositories: map[string]int{"SlowRepository": 500}}
+
+	assert.Equal(t, 100*time.Millisecond, cfg.For("Repository"))
+	assert.Equal(t, 500*time.Millisecond, cfg.For("SlowRepository"))
+
+	var nilCfg *TimeoutCfg
+	assert.Equal(t, time.Duration(0), nilCfg.For("Repository"))
+}
+
+func TestTimeoutWithTimeout(t *testing.T) {
+	timeout := &Timeout{}
+	timeoutCtx, cancel := timeout.WithTimeout(context.Background())
+	_, ok := timeoutCtx.Deadline()
+	assert.False(t, ok)
+	cancel()
+
+	timeout.SetTimeout(time.Millisecond)
+	timeoutCtx, cancel = timeout.WithTimeout(context.Background())
+	defer cancel()
+	_, ok = timeoutCtx.Deadline()
+	assert.True(t, ok)
+
+	<-timeoutCtx.Done()
+	assert.ErrorIs(t, TimeoutErr(timeoutCtx.Err()), ErrTimeout)
+}
+
+func TestTimeoutErr(t *testing.T) {
+	assert.Nil(t, TimeoutErr(nil))
+	assert.NotErrorIs(t, TimeoutErr(errors.New("foo")), ErrTimeout)
+	assert.ErrorIs(t, TimeoutErr(context.DeadlineExceeded), ErrTimeout)
+
+	err := PGReadErr(context.DeadlineExceeded)
+	assert.ErrorIs(t, err, ErrReadRow)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.NotErrorIs(t, PGReadErr(pgx.ErrNoRows), ErrTimeout)
+}
+
+func TestRegisterRepositorySetsTimeout(t *testing.T) {
+	provider := NewPGRepositoryProvider(nil, WithTimeouts(&TimeoutCfg{Default: 100, Repositories: map[string]int{"MockRepository": 200}}))
+
+	err := provider.RegisterRepository(func(db any) (Repository, error) {
+		return &mockTimeoutRepository{}, nil
+	})
+	assert.NoError(t, err)
+
+	repo, err := provider.Repository("MockRepository")
+	assert.NoError(t, err)
+	assert.Equal(t, 200*time.Millisecond, repo.(*mockTimeoutRepository).timeout)
+}
+
+type mockTimeoutRepository struct {
+	Timeout
+}
+
+func (r *mockTimeoutRepository) RepositoryName() string {
+	return "MockRepository"
+}
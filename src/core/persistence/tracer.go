@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Pkg is the package name used for logging.
+const Pkg = "sys.persistence"
+
+const (
+	// MetricQueries is the counter of executed queries labeled by "query" and "status" (ok or error).
+	MetricQueries = "db_queries_total"
+	// MetricSlowQueries is the counter of queries exceeding the slow query threshold labeled by "query".
+	MetricSlowQueries = "db_slow_queries_total"
+	// MetricQueryDuration is the histogram of query durations in seconds labeled by "query".
+	MetricQueryDuration = "db_query_duration_seconds"
+)
+
+// persistencePkgPath is the prefix of all functions of the persistence package on the call stack.
+const persistencePkgPath = "github.com/org-harmony/harmony/src/core/persistence."
+
+// queryTraceContextKey is the context key under which the queryTrace is stored between the start and the end of a query.
+const queryTraceContextKey = "persistence.queryTrace"
+
+// TracingCfg configures the query tracing.
+type TracingCfg struct {
+	// SlowQuery is the duration in milliseconds after which a query is logged as slow. 0 disables slow query logging.
+	SlowQuery int `toml:"slow_query" env:"DB_SLOW_QUERY"`
+}
+
+// QueryTracer implements the pgx.QueryTracer interface. It logs queries exceeding the slow query threshold
+// and records the number and duration of queries in the metrics.
+//
+// Queries are named after the repository method executing them, e.g. "app/template.(*PGRepository).FindByID".
+// The name is resolved from the call stack, so no changes to the repositories are necessary.
+type QueryTracer struct {
+	logger    trace.Logger
+	metrics   trace.Metrics
+	slowQuery time.Duration
+}
+
+// queryTrace holds the information of a running query.
+type queryTrace struct {
+	start time.Time
+	args  int
+}
+
+// NewQueryTracer creates a new QueryTracer. If the config is nil, slow queries are not logged.
+// If metrics is nil, no metrics are recorded.
+func NewQueryTracer(cfg *TracingCfg, logger trace.Logger, metrics trace.Metrics) *QueryTracer {
+	tracer := &QueryTracer{logger: logger, metrics: metrics}
+	if cfg != nil {
+		tracer.slowQuery = time.Duration(cfg.SlowQuery) * time.Millisecond
+	}
+
+	return tracer
+}
+
+// TraceQueryStart implements the pgx.QueryTracer interface. It is called by pgx at the beginning of a query.
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryTraceContextKey, &queryTrace{start: time.Now(), args: len(data.Args)})
+}
+
+// TraceQueryEnd implements the pgx.QueryTracer interface. It is called by pgx after a query finished.
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	qt, ok := ctx.Value(queryTraceContextKey).(*queryTrace)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(qt.start)
+	name := queryName()
+
+	if t.metrics != nil {
+		status := "ok"
+		if data.Err != nil {
+			status = "error"
+		}
+
+		t.metrics.Add(MetricQueries, 1, "query", name, "status", status)
+		t.metrics.Observe(MetricQueryDuration, duration.Seconds(), "query", name)
+	}
+
+	if t.slowQuery <= 0 || duration < t.slowQuery {
+		return
+	}
+
+	if t.metrics != nil {
+		t.metrics.Add(MetricSlowQueries, 1, "query", name)
+	}
+
+	t.logger.Warn(Pkg, "slow query", "query", name, "duration", duration, "args", qt.args)
+}
+
+// queryName returns the name of the first function on the call stack outside of pgx and the persistence package.
+// This usually is the repository method executing the query. The module path is trimmed from the name.
+func queryName() string {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(3, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/jackc/") && !strings.HasPrefix(frame.Function, persistencePkgPath) {
+			return strings.TrimPrefix(frame.Function, "github.com/org-harmony/harmony/src/")
+		}
+
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestQueryTracer(t *testing.T) {
+	metrics := trace.NewMemoryMetrics()
+	tracer := NewQueryTracer(&TracingCfg{SlowQuery: 1}, trace.NewTestLogger(t), metrics)
+
+	traceCtx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1", Args: []any{1, 2}})
+	tracer.TraceQueryEnd(traceCtx, nil, pgx.TraceQueryEndData{})
+
+	traceCtx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	time.Sleep(2 * time.Millisecond)
+	tracer.TraceQueryEnd(traceCtx, nil, pgx.TraceQueryEndData{Err: errors.New("foo")})
+
+	snapshot := metrics.Snapshot()
+	require.NotEmpty(t, snapshot.Counters)
+	// functions of the persistence package are skipped, the test's caller (testing package) is used as the query's name
+	name := snapshot.Counters[0].Labels[1]
+	assert.Equal(t, "testing.tRunner", name)
+	assert.Equal(t, float64(1), snapshot.Counter(MetricQueries, "query", name, "status", "ok"))
+	assert.Equal(t, float64(1), snapshot.Counter(MetricQueries, "query", name, "status", "error"))
+	assert.GreaterOrEqual(t, snapshot.Counter(MetricSlowQueries, "query", name), float64(1))
+
+	histogram, ok := snapshot.Histogram(MetricQueryDuration, "query", name)
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), histogram.Count)
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3UnsignedPayload is used as the payload hash of requests, the content is not hashed before uploading it.
+// This allows streaming the content and is supported by S3 and MinIO over HTTPS.
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Cfg configures the S3Store.
+type S3Cfg struct {
+	// Endpoint is the URL of the S3-compatible service, e.g. "https://s3.eu-central-1.amazonaws.com".
+	Endpoint  string `toml:"endpoint" env:"STORAGE_S3_ENDPOINT"`
+	Region    string `toml:"region" env:"STORAGE_S3_REGION"`
+	Bucket    string `toml:"bucket" env:"STORAGE_S3_BUCKET"`
+	AccessKey string `toml:"access_key" env:"STORAGE_S3_ACCESS_KEY"`
+	SecretKey string `toml:"secret_key" env:"STORAGE_S3_SECRET_KEY"`
+	// PathStyle addresses the bucket through the path (<endpoint>/<bucket>/<key>) instead of the hostname
+	// (<bucket>.<endpoint>/<key>). Most self-hosted services like MinIO require path style addressing.
+	PathStyle bool `toml:"path_style" env:"STORAGE_S3_PATH_STYLE"`
+	// Timeout of a single request in seconds. Defaults to 30 seconds.
+	Timeout int `toml:"timeout"`
+}
+
+// S3Store stores files as objects in a bucket of an S3-compatible object storage.
+// Requests are signed using AWS Signature Version 4.
+type S3Store struct {
+	cfg      *S3Cfg
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Store creates a new S3Store. It returns ErrInvalidBackend if the configuration is incomplete.
+func NewS3Store(cfg *S3Cfg) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Region == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("%w: incomplete s3 config", ErrInvalidBackend)
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBackend, err)
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &S3Store{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+		now:      time.Now,
+	}, nil
+}
+
+// Put uploads the content of the reader as the object of the key.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	request, err := s.request(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+
+	request.ContentLength = size
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := s.do(request)
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// Get downloads the object of the key. It returns ErrNotFound if the object does not exist.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	request, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Body, nil
+}
+
+// Delete deletes the object of the key.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	request, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.do(request)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// request creates a new request for the object of the key.
+func (s *S3Store) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, key)
+	}
+
+	u := *s.endpoint
+	if s.cfg.PathStyle {
+		u.Path = u.Path + "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = u.Path + "/" + key
+	}
+
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// do signs and sends the request. Responses with an error status are closed and returned as an error.
+func (s *S3Store) do(request *http.Request) (*http.Response, error) {
+	request.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	signV4(request, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, s.now())
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 300 {
+		return response, nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	_ = response.Body.Close()
+
+	err = fmt.Errorf("s3 %s %s: status %d: %s", request.Method, request.URL.Path, response.StatusCode, msg)
+	if response.StatusCode == http.StatusNotFound {
+		return nil, errors.Join(ErrNotFound, err)
+	}
+
+	return nil, err
+}
+
+// signV4 signs the request for the S3 service using AWS Signature Version 4 by setting the Authorization header.
+// The X-Amz-Content-Sha256 header is expected to be set already. All headers of the request are signed.
+func signV4(request *http.Request, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	request.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		request.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature,
+	))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data using the key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
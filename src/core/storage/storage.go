@@ -0,0 +1,97 @@
+// Package storage provides a file storage abstraction with a local disk and an S3-compatible backend.
+// Files are stored under keys, a key is a slash separated path like "default/template/<id>/<id>".
+// Files may be served to users through signed, expiring URLs (see Signer).
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// Pkg is the package name used for logging.
+	Pkg = "sys.storage"
+	// BackendLocal stores files on the local disk, see LocalStore.
+	BackendLocal = "local"
+	// BackendS3 stores files in an S3-compatible object storage (AWS S3, MinIO), see S3Store.
+	BackendS3 = "s3"
+)
+
+var (
+	// ErrNotFound is returned if no file is stored under the key.
+	ErrNotFound = errors.New("file not found")
+	// ErrInvalidKey is returned if a key is empty, absolute or escapes the storage (e.g. "../foo").
+	ErrInvalidKey = errors.New("invalid storage key")
+	// ErrInvalidBackend is returned if the configured backend is neither BackendLocal nor BackendS3.
+	ErrInvalidBackend = errors.New("invalid storage backend")
+)
+
+// Cfg is the storage package's configuration.
+type Cfg struct {
+	// Backend is either BackendLocal (default) or BackendS3.
+	Backend string    `toml:"backend" env:"STORAGE_BACKEND"`
+	Local   *LocalCfg `toml:"local"`
+	S3      *S3Cfg    `toml:"s3"`
+}
+
+// LocalCfg configures the LocalStore.
+type LocalCfg struct {
+	// Dir is the directory files are stored in. It is created if it does not exist.
+	Dir string `toml:"dir" env:"STORAGE_LOCAL_DIR"`
+}
+
+// Store stores files under keys. Implementations must be safe for concurrent use by multiple goroutines.
+type Store interface {
+	// Put stores the content of the reader under the key, an existing file is overwritten.
+	// The size of the content has to be known in advance, S3-compatible backends require it.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns a reader of the file stored under the key. The caller has to close the reader.
+	// It returns ErrNotFound if no file is stored under the key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete deletes the file stored under the key. Deleting a non-existing file is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore creates a new Store for the configured backend.
+// It returns ErrInvalidBackend if the backend is unknown or its configuration is missing.
+func NewStore(cfg *Cfg) (Store, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendLocal:
+		if cfg.Local == nil {
+			return nil, fmt.Errorf("%w: missing local config", ErrInvalidBackend)
+		}
+
+		return NewLocalStore(cfg.Local.Dir)
+	case BackendS3:
+		if cfg.S3 == nil {
+			return nil, fmt.Errorf("%w: missing s3 config", ErrInvalidBackend)
+		}
+
+		return NewS3Store(cfg.S3)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrInvalidBackend, cfg.Backend)
+}
+
+// Key joins the parts to a storage key and validates it. It returns ErrInvalidKey if the key is invalid (see ValidKey).
+func Key(parts ...string) (string, error) {
+	key := strings.Join(parts, "/")
+	if !ValidKey(key) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidKey, key)
+	}
+
+	return key, nil
+}
+
+// ValidKey returns true if the key is a clean, relative, slash separated path not escaping the storage.
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+
+	return path.Clean(key) == key && key != "." && key != ".." && !strings.HasPrefix(key, "../")
+}
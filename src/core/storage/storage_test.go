@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidKey(t *testing.T) {
+	assert.True(t, ValidKey("default/template/foo"))
+	assert.True(t, ValidKey("foo.pdf"))
+	assert.False(t, ValidKey(""))
+	assert.False(t, ValidKey("/foo"))
+	assert.False(t, ValidKey("../foo"))
+	assert.False(t, ValidKey("foo/../../bar"))
+	assert.False(t, ValidKey("foo//bar"))
+	assert.False(t, ValidKey("foo\\bar"))
+	assert.False(t, ValidKey(".."))
+
+	_, err := Key("default", "..", "..", "etc")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "default/template/foo.txt", strings.NewReader("foo"), 3, "text/plain"))
+
+	r, err := store.Get(ctx, "default/template/foo.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "foo", string(content))
+
+	require.NoError(t, store.Delete(ctx, "default/template/foo.txt"))
+	require.NoError(t, store.Delete(ctx, "default/template/foo.txt"))
+
+	_, err = store.Get(ctx, "default/template/foo.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.ErrorIs(t, store.Put(ctx, "../foo.txt", strings.NewReader("foo"), 3, ""), ErrInvalidKey)
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore(&Cfg{Local: &LocalCfg{Dir: t.TempDir()}})
+	require.NoError(t, err)
+	assert.IsType(t, &LocalStore{}, store)
+
+	_, err = NewStore(&Cfg{Backend: "ftp"})
+	assert.ErrorIs(t, err, ErrInvalidBackend)
+
+	_, err = NewStore(&Cfg{Backend: BackendS3, S3: &S3Cfg{Endpoint: "http://localhost:9000"}})
+	assert.ErrorIs(t, err, ErrInvalidBackend)
+}
+
+func TestSigner(t *testing.T) {
+	signer, err := NewSigner("secret")
+	require.NoError(t, err)
+
+	signed, err := url.Parse(signer.Sign("/attachment/foo/download", time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "/attachment/foo/download", signed.Path)
+	assert.NoError(t, signer.Verify(signed.Path, signed.Query()))
+
+	assert.ErrorIs(t, signer.Verify("/attachment/bar/download", signed.Query()), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify(signed.Path, url.Values{}), ErrInvalidSignature)
+
+	tampered := signed.Query()
+	tampered.Set(ExpiresParam, "99999999999")
+	assert.ErrorIs(t, signer.Verify(signed.Path, tampered), ErrInvalidSignature)
+
+	other, err := NewSigner("")
+	require.NoError(t, err)
+	assert.ErrorIs(t, other.Verify(signed.Path, signed.Query()), ErrInvalidSignature)
+
+	signer.now = func() time.Time { return time.Now().Add(time.Hour) }
+	assert.ErrorIs(t, signer.Verify(signed.Path, signed.Query()), ErrURLExpired)
+}
@@ -0,0 +1,261 @@
+// Package tenant provides the optional multi-tenancy of HARMONY allowing to host multiple institutions on one instance.
+// The tenant of a request is resolved by the request's hostname or a path prefix through the Middleware
+// and stored in the request context. Repositories scope their data by the tenant ID of the context (see ID).
+//
+// If the tenancy is disabled, no tenant is resolved and all data belongs to the DefaultID tenant.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Pkg is the package name used for logging.
+	Pkg = "sys.tenant"
+	// DefaultID is the ID of the tenant all data belongs to if the tenancy is disabled or no tenant is in the context.
+	// A tenant with this ID is used as the fallback tenant if no other tenant matches the request.
+	DefaultID = "default"
+	// ContextKey is the key the resolved tenant is stored under in the request context.
+	ContextKey = "harmony-app-tenant"
+	// CookieName is the name of the cookie storing the tenant ID resolved by a path prefix (ResolveByPath).
+	CookieName = "harmony-app-tenant"
+)
+
+const (
+	// ResolveByHost resolves the tenant by the hostname of the request, see Tenant.Hosts.
+	ResolveByHost = "host"
+	// ResolveByPath resolves the tenant by a path prefix, see Tenant.PathPrefix. Visiting the path prefix stores the tenant
+	// in the CookieName cookie and redirects to the path without the prefix. Further requests are resolved by the cookie.
+	ResolveByPath = "path"
+)
+
+var (
+	// ErrUnknownTenant is returned if no tenant could be resolved for a request and no DefaultID tenant is configured.
+	ErrUnknownTenant = errors.New("unknown tenant")
+	// ErrInvalidResolution is returned if the configured resolution is neither ResolveByHost nor ResolveByPath.
+	ErrInvalidResolution = errors.New("invalid tenant resolution")
+	// ErrDuplicateTenant is returned if two tenants share a hostname or path prefix.
+	ErrDuplicateTenant = errors.New("duplicate tenant host or path prefix")
+)
+
+// Cfg is the tenant package's configuration. The tenants are keyed by their ID.
+type Cfg struct {
+	// Enabled enables the tenancy. Without tenancy all data belongs to the DefaultID tenant.
+	Enabled bool `toml:"enabled" env:"TENANCY_ENABLED"`
+	// Resolution is either ResolveByHost (default) or ResolveByPath.
+	Resolution string             `toml:"resolution" env:"TENANCY_RESOLUTION"`
+	Tenants    map[string]*Tenant `toml:"tenants"`
+}
+
+// Tenant is an institution using the instance. Its data is isolated from the data of other tenants.
+type Tenant struct {
+	ID string `toml:"-"` // ID is set to the key of the tenant in Cfg.Tenants.
+	// Hosts are the hostnames (without port) the tenant is resolved by using ResolveByHost.
+	Hosts []string `toml:"hosts"`
+	// PathPrefix is the first path segment the tenant is resolved by using ResolveByPath, e.g. "uni-a" for /uni-a/.
+	PathPrefix string `toml:"path_prefix"`
+	// BaseURL overrides the base url of the web server, e.g. for OAuth redirects to the tenant's hostname.
+	BaseURL string `toml:"base_url"`
+	// DefaultTemplatesDir is the directory of the default templates offered to the tenant's users for import.
+	DefaultTemplatesDir string `toml:"default_templates_dir"`
+	// Branding is displayed instead of the default HARMONY branding.
+	Branding *Branding `toml:"branding"`
+}
+
+// Branding is the tenant specific branding of the UI.
+type Branding struct {
+	// Name of the institution displayed next to the logo.
+	Name string `toml:"name"`
+	// Logo is the URL of the logo replacing the HARMONY logo, e.g. "/assets/img/uni-a.png".
+	Logo string `toml:"logo"`
+}
+
+// Resolver resolves the tenant of a request according to the Cfg.
+// Resolver is safe for concurrent use as it is not modified after creation.
+type Resolver struct {
+	enabled    bool
+	resolution string
+	tenants    map[string]*Tenant
+	hosts      map[string]*Tenant
+	prefixes   map[string]*Tenant
+}
+
+// NewResolver creates a new Resolver from the config. The tenants' IDs are set to their keys in Cfg.Tenants.
+// It returns ErrInvalidResolution for an unknown resolution and ErrDuplicateTenant if tenants share a host or path prefix.
+func NewResolver(cfg *Cfg) (*Resolver, error) {
+	r := &Resolver{
+		enabled:    cfg.Enabled,
+		resolution: strings.ToLower(cfg.Resolution),
+		tenants:    make(map[string]*Tenant, len(cfg.Tenants)),
+		hosts:      make(map[string]*Tenant),
+		prefixes:   make(map[string]*Tenant),
+	}
+
+	if r.resolution == "" {
+		r.resolution = ResolveByHost
+	}
+	if r.resolution != ResolveByHost && r.resolution != ResolveByPath {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResolution, cfg.Resolution)
+	}
+
+	for id, t := range cfg.Tenants {
+		t.ID = id
+		r.tenants[id] = t
+
+		for _, host := range t.Hosts {
+			host = strings.ToLower(host)
+			if _, exists := r.hosts[host]; exists {
+				return nil, fmt.Errorf("%w: %s", ErrDuplicateTenant, host)
+			}
+			r.hosts[host] = t
+		}
+
+		if prefix := strings.Trim(t.PathPrefix, "/"); prefix != "" {
+			if _, exists := r.prefixes[prefix]; exists {
+				return nil, fmt.Errorf("%w: %s", ErrDuplicateTenant, prefix)
+			}
+			r.prefixes[prefix] = t
+		}
+	}
+
+	return r, nil
+}
+
+// Enabled returns true if the tenancy is enabled.
+func (r *Resolver) Enabled() bool {
+	return r.enabled
+}
+
+// Tenant returns the tenant by its ID.
+func (r *Resolver) Tenant(id string) (*Tenant, bool) {
+	t, ok := r.tenants[id]
+	return t, ok
+}
+
+// Resolve resolves the tenant of the request by its hostname (ResolveByHost) or the CookieName cookie (ResolveByPath).
+// If no tenant matches, the DefaultID tenant is returned if it is configured. Otherwise, ErrUnknownTenant is returned.
+func (r *Resolver) Resolve(request *http.Request) (*Tenant, error) {
+	var t *Tenant
+	switch r.resolution {
+	case ResolveByHost:
+		t = r.hosts[hostname(request.Host)]
+	case ResolveByPath:
+		if cookie, err := request.Cookie(CookieName); err == nil {
+			t = r.tenants[cookie.Value]
+		}
+	}
+
+	if t != nil {
+		return t, nil
+	}
+
+	if t, ok := r.tenants[DefaultID]; ok {
+		return t, nil
+	}
+
+	return nil, ErrUnknownTenant
+}
+
+// prefixTenant returns the tenant whose path prefix is the first segment of the path and the path without the prefix.
+func (r *Resolver) prefixTenant(path string) (*Tenant, string, bool) {
+	segment, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	t, ok := r.prefixes[segment]
+	if !ok {
+		return nil, "", false
+	}
+
+	return t, "/" + rest, true
+}
+
+// Middleware resolves the tenant of each request and stores it in the request context (see WithTenant).
+// Requests for which no tenant can be resolved are answered with 404.
+// If the tenancy is disabled, the middleware passes all requests on without resolving a tenant.
+//
+// Using ResolveByPath, requests starting with a tenant's path prefix store the tenant in the CookieName cookie
+// and are redirected to the path without the prefix. This keeps all links within the application unchanged.
+func Middleware(resolver *Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if resolver == nil || !resolver.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if resolver.resolution == ResolveByPath {
+				if t, path, ok := resolver.prefixTenant(r.URL.Path); ok {
+					setCookie(w, t)
+
+					target := *r.URL
+					target.Path = path
+					http.Redirect(w, r, target.RequestURI(), http.StatusFound)
+					return
+				}
+			}
+
+			t, err := resolver.Resolve(r)
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), t)))
+		})
+	}
+}
+
+// WithTenant returns a copy of the context containing the tenant.
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, ContextKey, t)
+}
+
+// FromCtx returns the tenant of the context and whether the context contains a tenant.
+func FromCtx(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(ContextKey).(*Tenant)
+	return t, ok && t != nil
+}
+
+// ID returns the ID of the context's tenant. If the context does not contain a tenant, DefaultID is returned.
+// Repositories use ID to scope their queries to the tenant of the request.
+func ID(ctx context.Context) string {
+	t, ok := FromCtx(ctx)
+	if !ok || t.ID == "" {
+		return DefaultID
+	}
+
+	return t.ID
+}
+
+// BaseURL returns the base url of the context's tenant. If the tenant has no base url, the fallback is returned.
+func BaseURL(ctx context.Context, fallback string) string {
+	if t, ok := FromCtx(ctx); ok && t.BaseURL != "" {
+		return t.BaseURL
+	}
+
+	return fallback
+}
+
+// setCookie stores the tenant's ID in the CookieName cookie.
+func setCookie(w http.ResponseWriter, t *Tenant) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    t.ID,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+		Secure:   true,
+		HttpOnly: true,
+	})
+}
+
+// hostname returns the lowercase hostname of a host with an optional port.
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(host)
+}
@@ -0,0 +1,128 @@
+package tenant
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewResolver(t *testing.T) {
+	resolver, err := NewResolver(&Cfg{Tenants: map[string]*Tenant{"uni-a": {Hosts: []string{"a.example.com"}}}})
+	require.NoError(t, err)
+
+	tenantA, ok := resolver.Tenant("uni-a")
+	require.True(t, ok)
+	assert.Equal(t, "uni-a", tenantA.ID)
+
+	_, err = NewResolver(&Cfg{Resolution: "header"})
+	assert.ErrorIs(t, err, ErrInvalidResolution)
+
+	_, err = NewResolver(&Cfg{Tenants: map[string]*Tenant{
+		"uni-a": {Hosts: []string{"a.example.com"}},
+		"uni-b": {Hosts: []string{"A.example.com"}},
+	}})
+	assert.ErrorIs(t, err, ErrDuplicateTenant)
+
+	_, err = NewResolver(&Cfg{Resolution: ResolveByPath, Tenants: map[string]*Tenant{
+		"uni-a": {PathPrefix: "uni"},
+		"uni-b": {PathPrefix: "/uni/"},
+	}})
+	assert.ErrorIs(t, err, ErrDuplicateTenant)
+}
+
+func TestMiddlewareByHost(t *testing.T) {
+	resolver, err := NewResolver(&Cfg{
+		Enabled: true,
+		Tenants: map[string]*Tenant{
+			"uni-a": {Hosts: []string{"a.example.com"}},
+			"uni-b": {Hosts: []string{"b.example.com"}},
+		},
+	})
+	require.NoError(t, err)
+
+	handler := Middleware(resolver)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(ID(r.Context())))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "http://A.example.com:8080/", nil))
+	assert.Equal(t, "uni-a", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "http://b.example.com/template-set/list", nil))
+	assert.Equal(t, "uni-b", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "http://c.example.com/", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	resolver.tenants[DefaultID] = &Tenant{ID: DefaultID}
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "http://c.example.com/", nil))
+	assert.Equal(t, DefaultID, recorder.Body.String(), "unknown hosts fall back to the default tenant")
+}
+
+func TestMiddlewareByPath(t *testing.T) {
+	resolver, err := NewResolver(&Cfg{
+		Enabled:    true,
+		Resolution: ResolveByPath,
+		Tenants: map[string]*Tenant{
+			"uni-a": {PathPrefix: "uni-a"},
+		},
+	})
+	require.NoError(t, err)
+
+	handler := Middleware(resolver)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(ID(r.Context())))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/uni-a/template-set/list?foo=bar", nil))
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	assert.Equal(t, "/template-set/list?foo=bar", recorder.Header().Get("Location"))
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, CookieName, cookies[0].Name)
+	assert.Equal(t, "uni-a", cookies[0].Value)
+
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/template-set/list", nil)
+	request.AddCookie(cookies[0])
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, "uni-a", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/template-set/list", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+	resolver, err := NewResolver(&Cfg{Tenants: map[string]*Tenant{"uni-a": {Hosts: []string{"a.example.com"}}}})
+	require.NoError(t, err)
+
+	handler := Middleware(resolver)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := FromCtx(r.Context())
+		assert.False(t, ok)
+		_, _ = w.Write([]byte(ID(r.Context())))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "http://a.example.com/", nil))
+	assert.Equal(t, DefaultID, recorder.Body.String())
+}
+
+func TestBaseURL(t *testing.T) {
+	assert.Equal(t, "http://localhost:8080", BaseURL(context.Background(), "http://localhost:8080"))
+	assert.Equal(t, "http://localhost:8080", BaseURL(WithTenant(context.Background(), &Tenant{ID: "uni-a"}), "http://localhost:8080"))
+	assert.Equal(t, "https://a.example.com", BaseURL(WithTenant(context.Background(), &Tenant{ID: "uni-a", BaseURL: "https://a.example.com"}), "http://localhost:8080"))
+}
+
+func TestID(t *testing.T) {
+	assert.Equal(t, DefaultID, ID(context.Background()))
+	assert.Equal(t, "uni-a", ID(WithTenant(context.Background(), &Tenant{ID: "uni-a"})))
+	assert.Equal(t, DefaultID, ID(WithTenant(context.Background(), nil)))
+}
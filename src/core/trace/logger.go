@@ -2,30 +2,62 @@ package trace
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 )
 
 // LogPkgKey is the key to log the package name under in the structured logger.
 const LogPkgKey = "module"
 
+const (
+	// FormatText logs human-readable key=value lines. This is the default format.
+	FormatText = "text"
+	// FormatJSON logs one JSON object per line. It is intended for production deployments with log aggregation.
+	FormatJSON = "json"
+)
+
+var (
+	// ErrInvalidLevel is returned if a configured log level is unknown.
+	ErrInvalidLevel = errors.New("invalid log level")
+	// ErrInvalidFormat is returned if the configured log format is unknown.
+	ErrInvalidFormat = errors.New("invalid log format")
+)
+
+// Cfg is the logger's configuration. Levels are one of "debug", "info", "warn" or "error" (case-insensitive).
+type Cfg struct {
+	// Level is the minimum level of logs to be written. Defaults to "info".
+	Level string `toml:"level" env:"LOG_LEVEL"`
+	// Format is the output format of the logger, either FormatText or FormatJSON. Defaults to FormatText.
+	Format string `toml:"format" env:"LOG_FORMAT"`
+	// Packages overrides the level for single packages by their package name constant, e.g. "sys.web" = "debug".
+	// An override also applies to all sub-packages, e.g. "sys" applies to "sys.web" unless "sys.web" is overridden as well.
+	Packages map[string]string `toml:"packages"`
+	// Reporting configures the reporting of errors to an error tracking service, see NewReporter.
+	Reporting *ReportingCfg `toml:"reporting"`
+}
+
 // HLogger is the system's default logger using the log/slog package as the underlying logger.
 //
-// Message format:
-//
-//	<level> <module>: <message> | <args>
+// The output format (text or JSON), the level and per-package level overrides are configured through Cfg.
+// The module is logged under the LogPkgKey. Modules should always be passed as the package's Pkg constant.
 //
 // Example:
 //
 //	logger.Info("sys.web", "template rendered", "template", "home.html")
 //
-//	INFO sys.web: template rendered | template=home.html
+//	time=... level=INFO msg="template rendered" module=sys.web template=home.html
 //
 // TODO Add contextualized logger that can be instantiated With() some args upfront. This should be used in web.IO to always log important request information.
 // TODO Further nesting of contextualized With() loggers could generally help to improve log traceability.
 type HLogger struct {
-	slog *slog.Logger
+	slog     *slog.Logger
+	level    slog.Level
+	packages map[string]slog.Level
 }
 
 // TestLogger is a logger that writes to the test's log.
@@ -57,15 +89,110 @@ type Logger interface {
 }
 
 // NewLogger creates a new trace.HLogger using the log/slog package as the underlying logger.
-// The logger writes to stdout.
+// The logger writes to stdout in the text format and logs from info level upwards.
 func NewLogger() Logger {
+	return NewWriterLogger(os.Stdout)
+}
+
+// NewWriterLogger creates a new trace.HLogger writing to the passed in writer.
+// This can be used by commands whose stdout is reserved for their output, e.g. to log to stderr instead.
+func NewWriterLogger(w io.Writer) Logger {
 	return &HLogger{
-		slog: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		slog:  slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		level: slog.LevelInfo,
 	}
 }
 
+// FromCfg creates a new trace.HLogger writing to stdout as configured by the passed in config.
+// An error is returned if the config contains an unknown level or format.
+func FromCfg(cfg *Cfg) (Logger, error) {
+	return FromCfgWriter(cfg, os.Stdout)
+}
+
+// FromCfgWriter creates a new trace.HLogger writing to the passed in writer as configured by the passed in config.
+func FromCfgWriter(cfg *Cfg, w io.Writer) (Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make(map[string]slog.Level, len(cfg.Packages))
+	for pkg, l := range cfg.Packages {
+		pkgLevel, err := ParseLevel(l)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg, err)
+		}
+		packages[pkg] = pkgLevel
+	}
+
+	// level filtering is done by the HLogger itself, therefore the handler has to let all levels through
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, cfg.Format)
+	}
+
+	return &HLogger{
+		slog:     slog.New(handler),
+		level:    level,
+		packages: packages,
+	}, nil
+}
+
+// ParseLevel parses a level ("debug", "info", "warn" or "error") case-insensitively.
+// An empty string is parsed as info level. Unknown levels result in an ErrInvalidLevel.
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+
+	return slog.LevelInfo, fmt.Errorf("%w: %s", ErrInvalidLevel, level)
+}
+
+// Enabled reports whether logs of the given level are written for the module.
+// The level override of the longest matching package is used, falling back to the logger's level.
+func (l *HLogger) Enabled(level slog.Level, mod string) bool {
+	return level >= l.levelFor(mod)
+}
+
+// levelFor returns the minimum level for the module. A package override matches the module itself
+// and all of its sub-packages separated by a dot, e.g. "sys" matches "sys" and "sys.web".
+func (l *HLogger) levelFor(mod string) slog.Level {
+	for pkg := mod; pkg != ""; {
+		if level, ok := l.packages[pkg]; ok {
+			return level
+		}
+
+		i := strings.LastIndex(pkg, ".")
+		if i < 0 {
+			break
+		}
+		pkg = pkg[:i]
+	}
+
+	return l.level
+}
+
 // Log logs a message with the given level, module and arguments.
+// The message is dropped if the level is below the configured level of the module.
 func (l *HLogger) Log(level slog.Level, mod string, msg string, args ...any) {
+	if !l.Enabled(level, mod) {
+		return
+	}
+
 	a := append([]any{slog.String(LogPkgKey, mod)}, args...)
 	l.slog.Log(context.Background(), level, msg, a...)
 }
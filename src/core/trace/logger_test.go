@@ -0,0 +1,84 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestFromCfgJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, err := FromCfgWriter(&Cfg{Format: "JSON"}, buf)
+	require.NoError(t, err)
+
+	logger.Info("sys.web", "template rendered", "template", "home.html")
+	logger.Error("sys.web", "rendering failed", errors.New("foo"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	entry := make(map[string]any)
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "template rendered", entry["msg"])
+	assert.Equal(t, "sys.web", entry[LogPkgKey])
+	assert.Equal(t, "home.html", entry["template"])
+
+	entry = make(map[string]any)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "foo", entry["error"])
+}
+
+func TestFromCfgLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, err := FromCfgWriter(&Cfg{
+		Level: "warn",
+		Packages: map[string]string{
+			"sys":         "debug",
+			"sys.event":   "error",
+			"app.user.we": "debug",
+		},
+	}, buf)
+	require.NoError(t, err)
+
+	logger.Info("app.template", "dropped")
+	logger.Warn("app.template", "written app.template")
+	logger.Debug("sys.web", "written sys.web")
+	logger.Debug("sys.web.cache", "written sys.web.cache")
+	logger.Warn("sys.event", "dropped")
+	logger.Info("app.user.web", "dropped")
+	logger.Debug("system", "dropped")
+
+	out := buf.String()
+	assert.NotContains(t, out, "dropped")
+	assert.Contains(t, out, "written app.template")
+	assert.Contains(t, out, "written sys.web")
+	assert.Contains(t, out, "written sys.web.cache")
+}
+
+func TestFromCfgInvalid(t *testing.T) {
+	_, err := FromCfgWriter(&Cfg{Level: "verbose"}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrInvalidLevel)
+
+	_, err = FromCfgWriter(&Cfg{Packages: map[string]string{"sys.web": "trace"}}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrInvalidLevel)
+
+	_, err = FromCfgWriter(&Cfg{Format: "xml"}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestWriterLoggerDefaultLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewWriterLogger(buf)
+
+	logger.Debug("sys.web", "dropped")
+	logger.Info("sys.web", "written")
+
+	assert.NotContains(t, buf.String(), "dropped")
+	assert.Contains(t, buf.String(), "written")
+}
@@ -0,0 +1,182 @@
+package trace
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the default upper bounds of histogram buckets. They are suited for durations in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics is the interface for recording metrics. Metrics are expected to be safe for concurrent use.
+// Each metric is identified by its name and labels. Labels are supplied as pairs like the args of the Logger:
+// key1, value1, key2, value2, ... The order of the label pairs is not relevant.
+type Metrics interface {
+	Add(name string, delta float64, labels ...string)     // Add adds the delta to the counter.
+	Observe(name string, value float64, labels ...string) // Observe records the value in the histogram.
+}
+
+// MemoryMetrics records metrics in memory. The recorded metrics can be read through MemoryMetrics.Snapshot.
+// It is the system's default implementation of the Metrics interface.
+type MemoryMetrics struct {
+	buckets    []float64
+	counters   map[string]*CounterValue
+	histograms map[string]*HistogramValue
+	mu         sync.Mutex
+}
+
+// CounterValue is the value of a counter at the time of the snapshot.
+type CounterValue struct {
+	Name   string
+	Labels []string
+	Value  float64
+}
+
+// HistogramValue is the value of a histogram at the time of the snapshot.
+// Counts contains the number of observations per bucket (not cumulative), the last count is for values exceeding all buckets.
+type HistogramValue struct {
+	Name    string
+	Labels  []string
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+// MetricsSnapshot is a copy of all metrics recorded by MemoryMetrics sorted by name and labels.
+type MetricsSnapshot struct {
+	Counters   []CounterValue
+	Histograms []HistogramValue
+}
+
+// NewMemoryMetrics creates a new MemoryMetrics. The buckets are the upper bounds of the histograms' buckets
+// in ascending order. If no buckets are passed in, DefaultBuckets are used.
+func NewMemoryMetrics(buckets ...float64) *MemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	return &MemoryMetrics{
+		buckets:    append([]float64(nil), buckets...),
+		counters:   make(map[string]*CounterValue),
+		histograms: make(map[string]*HistogramValue),
+	}
+}
+
+// Add adds the delta to the counter identified by the name and labels. The counter is created if it does not exist yet.
+func (m *MemoryMetrics) Add(name string, delta float64, labels ...string) {
+	labels = sortedLabels(labels)
+	key := metricKey(name, labels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counter, ok := m.counters[key]
+	if !ok {
+		counter = &CounterValue{Name: name, Labels: labels}
+		m.counters[key] = counter
+	}
+
+	counter.Value += delta
+}
+
+// Observe records the value in the histogram identified by the name and labels. The histogram is created if it does not exist yet.
+func (m *MemoryMetrics) Observe(name string, value float64, labels ...string) {
+	labels = sortedLabels(labels)
+	key := metricKey(name, labels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	histogram, ok := m.histograms[key]
+	if !ok {
+		histogram = &HistogramValue{Name: name, Labels: labels, Buckets: m.buckets, Counts: make([]uint64, len(m.buckets)+1)}
+		m.histograms[key] = histogram
+	}
+
+	histogram.Counts[sort.SearchFloat64s(m.buckets, value)]++
+	histogram.Count++
+	histogram.Sum += value
+}
+
+// Snapshot returns a copy of all recorded metrics.
+func (m *MemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := MetricsSnapshot{
+		Counters:   make([]CounterValue, 0, len(m.counters)),
+		Histograms: make([]HistogramValue, 0, len(m.histograms)),
+	}
+
+	for _, key := range sortedKeys(m.counters) {
+		snapshot.Counters = append(snapshot.Counters, *m.counters[key])
+	}
+
+	for _, key := range sortedKeys(m.histograms) {
+		histogram := *m.histograms[key]
+		histogram.Counts = append([]uint64(nil), histogram.Counts...)
+		snapshot.Histograms = append(snapshot.Histograms, histogram)
+	}
+
+	return snapshot
+}
+
+// Counter returns the value of the counter identified by the name and labels. If the counter does not exist, 0 is returned.
+func (s MetricsSnapshot) Counter(name string, labels ...string) float64 {
+	key := metricKey(name, sortedLabels(labels))
+	for _, counter := range s.Counters {
+		if metricKey(counter.Name, counter.Labels) == key {
+			return counter.Value
+		}
+	}
+
+	return 0
+}
+
+// Histogram returns the histogram identified by the name and labels. The second return value is false if the histogram does not exist.
+func (s MetricsSnapshot) Histogram(name string, labels ...string) (HistogramValue, bool) {
+	key := metricKey(name, sortedLabels(labels))
+	for _, histogram := range s.Histograms {
+		if metricKey(histogram.Name, histogram.Labels) == key {
+			return histogram, true
+		}
+	}
+
+	return HistogramValue{}, false
+}
+
+// sortedLabels returns a copy of the label pairs sorted by their keys. A trailing key without a value is dropped.
+func sortedLabels(labels []string) []string {
+	pairs := make([][2]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, [2]string{labels[i], labels[i+1]})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+
+	sorted := make([]string, 0, len(pairs)*2)
+	for _, pair := range pairs {
+		sorted = append(sorted, pair[0], pair[1])
+	}
+
+	return sorted
+}
+
+func metricKey(name string, labels []string) string {
+	return name + "{" + strings.Join(labels, ",") + "}"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
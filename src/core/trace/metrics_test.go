@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMemoryMetricsCounter(t *testing.T) {
+	metrics := NewMemoryMetrics()
+
+	metrics.Add("requests", 1, "path", "/", "status", "ok")
+	metrics.Add("requests", 2, "status", "ok", "path", "/")
+	metrics.Add("requests", 1, "path", "/", "status", "error")
+
+	snapshot := metrics.Snapshot()
+	assert.Equal(t, float64(3), snapshot.Counter("requests", "path", "/", "status", "ok"))
+	assert.Equal(t, float64(1), snapshot.Counter("requests", "status", "error", "path", "/"))
+	assert.Equal(t, float64(0), snapshot.Counter("requests", "path", "/foo"))
+	assert.Len(t, snapshot.Counters, 2)
+}
+
+func TestMemoryMetricsHistogram(t *testing.T) {
+	metrics := NewMemoryMetrics(1, 5)
+
+	metrics.Observe("duration", 0.5, "query", "foo")
+	metrics.Observe("duration", 1, "query", "foo")
+	metrics.Observe("duration", 3, "query", "foo")
+	metrics.Observe("duration", 10, "query", "foo")
+
+	snapshot := metrics.Snapshot()
+	histogram, ok := snapshot.Histogram("duration", "query", "foo")
+	require.True(t, ok)
+	assert.Equal(t, []uint64{2, 1, 1}, histogram.Counts)
+	assert.Equal(t, uint64(4), histogram.Count)
+	assert.Equal(t, 14.5, histogram.Sum)
+
+	metrics.Observe("duration", 0.1, "query", "foo")
+	assert.Equal(t, []uint64{2, 1, 1}, histogram.Counts, "snapshot should not change after recording new values")
+
+	_, ok = snapshot.Histogram("duration", "query", "bar")
+	assert.False(t, ok)
+}
@@ -0,0 +1,278 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reportScopeContextKey is the context key under which the ReportScope is stored.
+const reportScopeContextKey = "trace.reportScope"
+
+// sentryClient identifies HARMONY as the client sending events to Sentry.
+const sentryClient = "harmony/1.0"
+
+// ErrInvalidDSN is returned if the configured DSN of the error reporter can not be parsed.
+var ErrInvalidDSN = errors.New("invalid error reporting dsn")
+
+// ReportingCfg configures the error reporting. Without a DSN errors are not reported.
+type ReportingCfg struct {
+	// DSN is the Sentry-compatible DSN (e.g. Sentry or GlitchTip) errors are reported to,
+	// e.g. https://<public key>@sentry.example.com/<project id>. An empty DSN disables the reporting.
+	DSN string `toml:"dsn" env:"ERROR_REPORTING_DSN"`
+	// Environment is sent along with each report, e.g. "production".
+	Environment string `toml:"environment" env:"ERROR_REPORTING_ENVIRONMENT"`
+	// SampleRate is the share of errors to report between 0 and 1. A value of 0 or less is treated as 1.
+	SampleRate float64 `toml:"sample_rate"`
+	// Timeout of sending a single report in milliseconds. Defaults to 5000.
+	Timeout int `toml:"timeout"`
+}
+
+// Report is an error reported to an error tracking service. Tags carry additional information
+// such as the request ID, the user ID or the route of the request the error occurred in.
+type Report struct {
+	Mod   string
+	Err   error
+	Stack []byte
+	Tags  map[string]string
+}
+
+// Reporter reports errors, most notably recovered panics, to an error tracking service.
+// Reporting must not block the caller for long and a Reporter is expected to be safe for concurrent use.
+type Reporter interface {
+	Report(ctx context.Context, report *Report)
+}
+
+// NopReporter is the default Reporter, it discards all reports.
+type NopReporter struct{}
+
+// SentryReporter reports errors to a Sentry-compatible error tracking service using its HTTP store endpoint.
+// Reports are sampled according to the configured sample rate and sent asynchronously.
+type SentryReporter struct {
+	endpoint    string
+	auth        string
+	environment string
+	sampleRate  float64
+	client      *http.Client
+	logger      Logger
+}
+
+// ReportScope collects tags of the current request to be added to reports, e.g. the user ID.
+// It is mutable as the information becomes available deeper in the middleware chain than the report scope is created.
+// ReportScope is safe for concurrent use.
+type ReportScope struct {
+	mu   sync.Mutex
+	tags map[string]string
+}
+
+// sentryEvent is the event payload expected by the Sentry store endpoint.
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+}
+
+// sentryExceptions is the exception interface of a sentryEvent.
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+// sentryException is a single exception of a sentryEvent.
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// NewReporter creates a Reporter from the config. If the config is nil or has no DSN a NopReporter is returned.
+// Otherwise, a SentryReporter is created, failures to send reports are logged as warnings through the logger.
+// An ErrInvalidDSN is returned if the DSN can not be parsed.
+func NewReporter(cfg *ReportingCfg, logger Logger) (Reporter, error) {
+	if cfg == nil || cfg.DSN == "" {
+		return NopReporter{}, nil
+	}
+
+	endpoint, key, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	sampleRate := cfg.SampleRate
+	if sampleRate <= 0 || sampleRate > 1 {
+		sampleRate = 1
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &SentryReporter{
+		endpoint:    endpoint,
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", sentryClient, key),
+		environment: cfg.Environment,
+		sampleRate:  sampleRate,
+		client:      &http.Client{Timeout: timeout},
+		logger:      logger,
+	}, nil
+}
+
+// parseDSN returns the store endpoint and public key of a DSN in the format scheme://key@host[/path]/project.
+func parseDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrInvalidDSN, err)
+	}
+
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, project := "", path
+	if i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+
+	if u.User == nil || u.User.Username() == "" || u.Host == "" || project == "" {
+		return "", "", ErrInvalidDSN
+	}
+
+	return fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project), u.User.Username(), nil
+}
+
+// Report discards the report.
+func (NopReporter) Report(context.Context, *Report) {}
+
+// Report sends the report asynchronously if it is sampled. The tags of the ReportScope in the context are added to the report.
+func (r *SentryReporter) Report(ctx context.Context, report *Report) {
+	if report == nil || rand.Float64() >= r.sampleRate {
+		return
+	}
+
+	event := r.event(report, ReportTags(ctx))
+	go r.send(event)
+}
+
+// event converts a report into a sentryEvent. The tags of the report take precedence over the scope's tags.
+func (r *SentryReporter) event(report *Report, tags map[string]string) *sentryEvent {
+	if tags == nil {
+		tags = make(map[string]string, len(report.Tags))
+	}
+	for k, v := range report.Tags {
+		tags[k] = v
+	}
+
+	exception := sentryException{Type: "error", Value: "unknown error"}
+	if report.Err != nil {
+		exception = sentryException{Type: fmt.Sprintf("%T", report.Err), Value: report.Err.Error()}
+	}
+
+	event := &sentryEvent{
+		EventID:     strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Logger:      report.Mod,
+		Environment: r.environment,
+		Exception:   sentryExceptions{Values: []sentryException{exception}},
+		Tags:        tags,
+	}
+
+	if len(report.Stack) > 0 {
+		event.Extra = map[string]string{"stacktrace": string(report.Stack)}
+	}
+
+	return event
+}
+
+// send posts the event to the store endpoint. Errors are logged, the event is not retried.
+func (r *SentryReporter) send(event *sentryEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		r.logger.Warn(Pkg, "failed to marshal error report", "error", err)
+		return
+	}
+
+	request, err := http.NewRequest(http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		r.logger.Warn(Pkg, "failed to create error report request", "error", err)
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Sentry-Auth", r.auth)
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		r.logger.Warn(Pkg, "failed to send error report", "error", err, "eventID", event.EventID)
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		r.logger.Warn(Pkg, "error report was rejected", "status", response.StatusCode, "eventID", event.EventID)
+	}
+}
+
+// WithReportScope returns a copy of the context containing a new ReportScope and the ReportScope itself.
+func WithReportScope(ctx context.Context) (context.Context, *ReportScope) {
+	scope := &ReportScope{tags: make(map[string]string)}
+
+	return context.WithValue(ctx, reportScopeContextKey, scope), scope
+}
+
+// SetReportTag sets a tag on the ReportScope of the context. It is a no-op if the context has no ReportScope.
+func SetReportTag(ctx context.Context, key, value string) {
+	scope, ok := ctx.Value(reportScopeContextKey).(*ReportScope)
+	if !ok {
+		return
+	}
+
+	scope.Set(key, value)
+}
+
+// ReportTags returns a copy of the tags of the ReportScope in the context. It returns nil if the context has no ReportScope.
+func ReportTags(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+
+	scope, ok := ctx.Value(reportScopeContextKey).(*ReportScope)
+	if !ok {
+		return nil
+	}
+
+	return scope.Tags()
+}
+
+// Set sets the tag to the value.
+func (s *ReportScope) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tags[key] = value
+}
+
+// Tags returns a copy of the scope's tags.
+func (s *ReportScope) Tags() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags := make(map[string]string, len(s.tags))
+	for k, v := range s.tags {
+		tags[k] = v
+	}
+
+	return tags
+}
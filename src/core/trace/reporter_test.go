@@ -0,0 +1,91 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDSN(t *testing.T) {
+	endpoint, key, err := parseDSN("https://public@sentry.example.com/42")
+	require.NoError(t, err)
+	assert.Equal(t, "https://sentry.example.com/api/42/store/", endpoint)
+	assert.Equal(t, "public", key)
+
+	endpoint, _, err = parseDSN("http://public@localhost:8000/glitchtip/7")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8000/glitchtip/api/7/store/", endpoint)
+
+	_, _, err = parseDSN("https://sentry.example.com/42")
+	assert.ErrorIs(t, err, ErrInvalidDSN)
+	_, _, err = parseDSN("https://public@sentry.example.com")
+	assert.ErrorIs(t, err, ErrInvalidDSN)
+}
+
+func TestNewReporter(t *testing.T) {
+	reporter, err := NewReporter(nil, NewTestLogger(t))
+	require.NoError(t, err)
+	assert.IsType(t, NopReporter{}, reporter)
+
+	reporter, err = NewReporter(&ReportingCfg{}, NewTestLogger(t))
+	require.NoError(t, err)
+	assert.IsType(t, NopReporter{}, reporter)
+
+	_, err = NewReporter(&ReportingCfg{DSN: "://invalid"}, NewTestLogger(t))
+	assert.ErrorIs(t, err, ErrInvalidDSN)
+}
+
+func TestSentryReporter(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/1/store/", r.URL.Path)
+		assert.Contains(t, r.Header.Get("X-Sentry-Auth"), "sentry_key=public")
+
+		event := make(map[string]any)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/1"
+	reporter, err := NewReporter(&ReportingCfg{DSN: dsn, Environment: "test"}, NewTestLogger(t))
+	require.NoError(t, err)
+
+	ctx, scope := WithReportScope(context.Background())
+	scope.Set("user_id", "user")
+	SetReportTag(ctx, "route", "/scope")
+
+	reporter.Report(ctx, &Report{Mod: "sys.web", Err: errors.New("boom"), Stack: []byte("stack"), Tags: map[string]string{"route": "/report"}})
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "test", event["environment"])
+		assert.Equal(t, "sys.web", event["logger"])
+		assert.Len(t, event["event_id"], 32)
+		assert.Equal(t, map[string]any{"user_id": "user", "route": "/report"}, event["tags"])
+		assert.Equal(t, map[string]any{"stacktrace": "stack"}, event["extra"])
+		assert.Contains(t, event["exception"].(map[string]any)["values"].([]any)[0], "value")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no report received")
+	}
+}
+
+func TestReportScope(t *testing.T) {
+	assert.Nil(t, ReportTags(context.Background()))
+	SetReportTag(context.Background(), "foo", "bar") // must not panic without a scope
+
+	ctx, _ := WithReportScope(context.Background())
+	SetReportTag(ctx, "foo", "bar")
+	tags := ReportTags(ctx)
+	assert.Equal(t, map[string]string{"foo": "bar"}, tags)
+
+	tags["foo"] = "baz"
+	assert.Equal(t, "bar", ReportTags(ctx)["foo"], "returned tags must be a copy")
+}
@@ -1,2 +1,5 @@
-// Package trace contains tracing utilities such as the logger.
+// Package trace contains tracing utilities such as the logger, metrics and the error reporter.
 package trace
+
+// Pkg is the package name used for logging.
+const Pkg = "sys.trace"
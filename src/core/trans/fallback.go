@@ -0,0 +1,148 @@
+package trans
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MaxMissingKeys is the maximum number of missing keys recorded per translator to bound its memory usage.
+const MaxMissingKeys = 1000
+
+// MissingKey is a translation key missing in a translator's locale. Fallback is the path of the locale
+// the key was translated with instead, it is empty if the key is missing in the whole fallback chain.
+type MissingKey struct {
+	Key      string
+	Fallback string
+}
+
+// Report is the report of a translator's missing keys ordered by key and its fallback chain of locale paths.
+type Report struct {
+	Locale    *Locale
+	Fallbacks []string
+	Missing   []MissingKey
+}
+
+// WithFallbacks sets the translators used in order for keys missing in the translator, see HTranslator.T.
+// Fallbacks must be HTranslators with a locale, other translators are ignored. Without this option
+// the fallbacks are linked by NewTranslatorProvider according to the locale's Fallback chain.
+func WithFallbacks(fallbacks ...Translator) HTranslatorOption {
+	return func(t *HTranslator) {
+		t.fallbacks = make([]*HTranslator, 0, len(fallbacks))
+		for _, fallback := range fallbacks {
+			if h, ok := fallback.(*HTranslator); ok && h != nil && h.locale != nil {
+				t.fallbacks = append(t.fallbacks, h)
+			}
+		}
+	}
+}
+
+// Report returns the report of the translator's missing keys and fallbacks.
+func (t *HTranslator) Report() Report {
+	report := Report{Locale: t.locale}
+	for _, fallback := range t.fallbacks {
+		report.Fallbacks = append(report.Fallbacks, fallback.locale.Path)
+	}
+
+	t.mMu.Lock()
+	for key, fallback := range t.missing {
+		report.Missing = append(report.Missing, MissingKey{Key: key, Fallback: fallback})
+	}
+	t.mMu.Unlock()
+
+	sort.Slice(report.Missing, func(i, j int) bool {
+		return report.Missing[i].Key < report.Missing[j].Key
+	})
+
+	return report
+}
+
+// Reports returns the reports of all translators of the provider supporting reports (see HTranslator.Report)
+// ordered by their locale's path.
+func Reports(provider TranslatorProvider) []Report {
+	var reports []Report
+	for _, translator := range provider.Translators() {
+		if reporter, ok := translator.(interface{ Report() Report }); ok {
+			reports = append(reports, reporter.Report())
+		}
+	}
+
+	return reports
+}
+
+// fallback returns the translation of the key from the first fallback translating it and the fallback's locale path.
+func (t *HTranslator) fallback(key string) (string, string, bool) {
+	for _, fallback := range t.fallbacks {
+		if s, ok := fallback.translations[key]; ok {
+			return s, fallback.locale.Path, true
+		}
+	}
+
+	return "", "", false
+}
+
+// has returns true if the key is translated by the translator or one of its fallbacks.
+func (t *HTranslator) has(key string) bool {
+	if _, ok := t.translations[key]; ok {
+		return true
+	}
+
+	_, _, ok := t.fallback(key)
+	return ok
+}
+
+// recordMissing records the key as missing with the locale path of the fallback it was translated with.
+// Only strings looking like keys (see isKey) are recorded, as T is also called with literal messages.
+func (t *HTranslator) recordMissing(key string, fallback string) {
+	if !isKey(key) {
+		return
+	}
+
+	t.mMu.Lock()
+	defer t.mMu.Unlock()
+
+	if t.missing == nil {
+		t.missing = make(map[string]string)
+	}
+	if _, ok := t.missing[key]; !ok && len(t.missing) >= MaxMissingKeys {
+		return
+	}
+
+	t.missing[key] = fallback
+}
+
+// isKey returns true if the string looks like a translation key, e.g. harmony.menu.admin: dotted and without whitespace.
+func isKey(s string) bool {
+	return strings.Contains(s, ".") && strings.IndexFunc(s, unicode.IsSpace) < 0
+}
+
+// linkFallbacks sets the fallbacks of the HTranslators without fallbacks. The chain of a locale is its configured
+// Fallback chain or else its parent language (de-AT => de), followed by the default locale. Unknown paths are skipped.
+func linkFallbacks(translators map[string]Translator, defaultTrans Translator) {
+	for path, translator := range translators {
+		h, ok := translator.(*HTranslator)
+		if !ok || h.fallbacks != nil {
+			continue
+		}
+
+		chain := h.locale.Fallback
+		if len(chain) == 0 {
+			chain = []string{h.locale.language()}
+		}
+		if defaultTrans != nil {
+			chain = append(chain[:len(chain):len(chain)], defaultTrans.Locale().Path)
+		}
+
+		seen := map[string]bool{path: true}
+		h.fallbacks = []*HTranslator{}
+		for _, fallbackPath := range chain {
+			fallback, ok := translators[fallbackPath].(*HTranslator)
+			if !ok || seen[fallbackPath] {
+				continue
+			}
+
+			seen[fallbackPath] = true
+			h.fallbacks = append(h.fallbacks, fallback)
+		}
+	}
+}
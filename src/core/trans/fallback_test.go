@@ -0,0 +1,77 @@
+package trans
+
+import (
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFallbackChains(t *testing.T) {
+	logger := trace.NewTestLogger(t)
+	de := NewTranslator(ForLocale(&Locale{Path: "de", Default: true}), WithLogger(logger), WithTranslations(map[string]string{
+		"menu.home":    "Startseite",
+		"menu.profile": "Profil",
+		"menu.logout":  "Abmelden",
+	}))
+	deAT := NewTranslator(ForLocale(&Locale{Path: "de-AT"}), WithLogger(logger), WithTranslations(map[string]string{
+		"menu.home": "Hauptseite",
+	}))
+	en := NewTranslator(ForLocale(&Locale{Path: "en"}), WithLogger(logger), WithTranslations(map[string]string{
+		"menu.home":    "Home",
+		"menu.profile": "Profile",
+	}))
+	enGB := NewTranslator(ForLocale(&Locale{Path: "en-GB", Fallback: []string{"en", "unknown"}}), WithLogger(logger))
+	provider := NewTranslatorProvider(de, deAT, en, enGB)
+
+	t.Run("parent language and default", func(t *testing.T) {
+		assert.Equal(t, "Hauptseite", deAT.T("menu.home"))
+		assert.Equal(t, "Profil", deAT.T("menu.profile"))
+		assert.Equal(t, "Abmelden", en.T("menu.logout"))
+		assert.Equal(t, "menu.settings", en.T("menu.settings"))
+	})
+
+	t.Run("configured chain", func(t *testing.T) {
+		assert.Equal(t, "Profile", enGB.T("menu.profile"))
+		assert.Equal(t, "Abmelden", enGB.T("menu.logout"))
+	})
+
+	t.Run("plural variants use fallbacks", func(t *testing.T) {
+		deWithPlural := NewTranslator(ForLocale(&Locale{Path: "de"}), WithTranslations(map[string]string{
+			"items.one":   "{{ .count }} Eintrag",
+			"items.other": "{{ .count }} Einträge",
+		}))
+		deCH := NewTranslator(ForLocale(&Locale{Path: "de-CH"}), WithFallbacks(deWithPlural))
+
+		assert.Equal(t, "1 Eintrag", deCH.Tn("items", 1))
+	})
+
+	t.Run("reports", func(t *testing.T) {
+		deAT.T("a message with spaces.")
+
+		reports := Reports(provider)
+		require.Len(t, reports, 4)
+
+		assert.Equal(t, "de", reports[0].Locale.Path)
+		assert.Empty(t, reports[0].Fallbacks)
+		assert.Empty(t, reports[0].Missing)
+
+		assert.Equal(t, "de-AT", reports[1].Locale.Path)
+		assert.Equal(t, []string{"de"}, reports[1].Fallbacks)
+		assert.Equal(t, []MissingKey{{Key: "menu.profile", Fallback: "de"}}, reports[1].Missing)
+
+		assert.Equal(t, "en", reports[2].Locale.Path)
+		assert.Equal(t, []string{"de"}, reports[2].Fallbacks)
+		assert.Equal(t, []MissingKey{{Key: "menu.logout", Fallback: "de"}, {Key: "menu.settings"}}, reports[2].Missing)
+
+		assert.Equal(t, "en-GB", reports[3].Locale.Path)
+		assert.Equal(t, []string{"en", "de"}, reports[3].Fallbacks)
+		assert.Equal(t, []MissingKey{{Key: "menu.logout", Fallback: "de"}, {Key: "menu.profile", Fallback: "en"}}, reports[3].Missing)
+	})
+}
+
+func TestIsKey(t *testing.T) {
+	assert.True(t, isKey("harmony.menu.admin"))
+	assert.False(t, isKey("admin"))
+	assert.False(t, isKey("Invalid input. Try again."))
+}
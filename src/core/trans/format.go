@@ -0,0 +1,97 @@
+package trans
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DirectionLTR is the left-to-right writing direction, it is the default direction of locales.
+	DirectionLTR = "ltr"
+	// DirectionRTL is the right-to-left writing direction, e.g. of Arabic or Hebrew.
+	DirectionRTL = "rtl"
+	// DefaultDateFormat is the date layout of locales without a Locale.DateFormat.
+	DefaultDateFormat = "02.01.2006"
+	// DefaultDateTimeFormat is the date and time layout of locales without a Locale.DateTimeFormat.
+	DefaultDateTimeFormat = "02.01.2006 15:04"
+)
+
+// Dir returns the writing direction of the locale, DirectionRTL or DirectionLTR. It can be used as the HTML dir attribute.
+// Dir is safe to be called on a nil locale.
+func (l *Locale) Dir() string {
+	if l != nil && strings.EqualFold(l.Direction, DirectionRTL) {
+		return DirectionRTL
+	}
+
+	return DirectionLTR
+}
+
+// FormatDate formats the date by the locale's DateFormat or the DefaultDateFormat. Zero times are formatted as an empty string.
+// FormatDate is safe to be called on a nil locale.
+func (l *Locale) FormatDate(t time.Time) string {
+	if l == nil || l.DateFormat == "" {
+		return formatTime(t, DefaultDateFormat)
+	}
+
+	return formatTime(t, l.DateFormat)
+}
+
+// FormatDateTime formats the date and time by the locale's DateTimeFormat or the DefaultDateTimeFormat.
+// Zero times are formatted as an empty string. FormatDateTime is safe to be called on a nil locale.
+func (l *Locale) FormatDateTime(t time.Time) string {
+	if l == nil || l.DateTimeFormat == "" {
+		return formatTime(t, DefaultDateTimeFormat)
+	}
+
+	return formatTime(t, l.DateTimeFormat)
+}
+
+// FormatNumber formats the number with the decimals using the locale's DecimalSeparator (default ".")
+// and GroupSeparator (default none) for groups of thousands. FormatNumber is safe to be called on a nil locale.
+func (l *Locale) FormatNumber(n float64, decimals int) string {
+	decimalSeparator, groupSeparator := ".", ""
+	if l != nil && l.DecimalSeparator != "" {
+		decimalSeparator = l.DecimalSeparator
+	}
+	if l != nil {
+		groupSeparator = l.GroupSeparator
+	}
+
+	if decimals < 0 {
+		decimals = 0
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+
+	formatted := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(formatted, ".")
+
+	var b strings.Builder
+	if n < 0 && strings.Trim(formatted, "0.") != "" {
+		b.WriteString("-")
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(groupSeparator)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(decimalSeparator)
+		b.WriteString(fraction)
+	}
+
+	return b.String()
+}
+
+// formatTime formats the time by the layout. Zero times are formatted as an empty string.
+func formatTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(layout)
+}
@@ -0,0 +1,35 @@
+package trans
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLocale_Dir(t *testing.T) {
+	assert.Equal(t, DirectionRTL, (&Locale{Direction: "RTL"}).Dir())
+	assert.Equal(t, DirectionLTR, (&Locale{}).Dir())
+	assert.Equal(t, DirectionLTR, (*Locale)(nil).Dir())
+}
+
+func TestLocale_FormatDate(t *testing.T) {
+	date := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+	en := &Locale{DateFormat: "01/02/2006", DateTimeFormat: "01/02/2006 3:04 PM"}
+
+	assert.Equal(t, "03/05/2024", en.FormatDate(date))
+	assert.Equal(t, "03/05/2024 2:30 PM", en.FormatDateTime(date))
+	assert.Equal(t, "05.03.2024", (*Locale)(nil).FormatDate(date))
+	assert.Equal(t, "05.03.2024 14:30", (&Locale{}).FormatDateTime(date))
+	assert.Empty(t, en.FormatDate(time.Time{}))
+}
+
+func TestLocale_FormatNumber(t *testing.T) {
+	de := &Locale{DecimalSeparator: ",", GroupSeparator: "."}
+
+	assert.Equal(t, "1.234.567,89", de.FormatNumber(1234567.891, 2))
+	assert.Equal(t, "-1.000", de.FormatNumber(-1000, 0))
+	assert.Equal(t, "999", de.FormatNumber(999, 0))
+	assert.Equal(t, "0", de.FormatNumber(-0.2, 0), "negative numbers rounded to zero have no sign")
+	assert.Equal(t, "1234.5", (*Locale)(nil).FormatNumber(1234.5, 1))
+	assert.Equal(t, "12", (&Locale{}).FormatNumber(12.4, -1))
+}
@@ -0,0 +1,390 @@
+package trans
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidMessage is returned for ICU messages with an invalid syntax, see CompileMessage.
+var ErrInvalidMessage = errors.New("invalid icu message")
+
+// Message is a compiled ICU MessageFormat message. It supports simple arguments ({name}), numbers ({n, number}
+// or {n, number, integer}), select ({gender, select, female {...} other {...}}), plural and selectordinal
+// ({n, plural, offset:1 =0 {...} one {# item} other {# items}}) with '#' as the formatted number and apostrophe quoting.
+// Translations are detected as ICU messages if they contain a single but no double curly brace, see IsMessage.
+type Message struct {
+	nodes []messageNode
+}
+
+// messageNode is a part of a message formatted into the builder.
+type messageNode interface {
+	format(b *strings.Builder, ctx *messageContext)
+}
+
+// messageContext contains the arguments and locale a message is formatted with.
+// The number is the number formatted by '#' in plural and selectordinal cases.
+type messageContext struct {
+	args    map[string]string
+	numbers map[string]float64
+	locale  *Locale
+	number  *float64
+}
+
+type textNode string
+
+type argNode struct {
+	name  string
+	kind  string
+	style string
+}
+
+type poundNode struct{}
+
+type selectNode struct {
+	name   string
+	kind   string
+	offset float64
+	cases  map[string]*Message
+}
+
+// messageParser is a recursive descent parser of ICU messages.
+type messageParser struct {
+	s   []rune
+	pos int
+}
+
+// IsMessage returns true if the translation uses the ICU MessageFormat syntax instead of the template syntax.
+// ICU messages contain single curly braces ({name}) while templates contain double curly braces ({{ .name }}).
+func IsMessage(s string) bool {
+	return strings.Contains(s, "{") && !strings.Contains(s, "{{")
+}
+
+// CompileMessage compiles the ICU message. It returns ErrInvalidMessage for invalid syntax, e.g. unmatched braces,
+// unsupported argument types or select, plural and selectordinal arguments without an other case.
+func CompileMessage(s string) (*Message, error) {
+	p := &messageParser{s: []rune(s)}
+	message, err := p.message(false, false)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidMessage, fmt.Errorf("%w at position %d in %q", err, p.pos, s))
+	}
+
+	return message, nil
+}
+
+// Format formats the message with the arguments by the locale. Arguments are passed as key value pairs (see ArgsAsMap),
+// numbers are parsed from their Go representation, e.g. strconv.Itoa. Missing arguments are formatted as {name}.
+func (m *Message) Format(locale *Locale, args ...string) string {
+	return m.format(&messageContext{args: ArgsAsMap(args...), locale: locale})
+}
+
+func (m *Message) format(ctx *messageContext) string {
+	b := &strings.Builder{}
+	for _, node := range m.nodes {
+		node.format(b, ctx)
+	}
+
+	return b.String()
+}
+
+// value returns the number of the argument, preferring numbers passed without formatting (see HTranslator.Tn),
+// and the number of decimals of the argument.
+func (ctx *messageContext) value(name string) (float64, int, bool) {
+	if n, ok := ctx.numbers[name]; ok {
+		return n, decimalsOf(strconv.FormatFloat(n, 'f', -1, 64)), true
+	}
+
+	s, ok := ctx.args[name]
+	if !ok {
+		return 0, 0, false
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return n, decimalsOf(strings.TrimSpace(s)), true
+}
+
+func (n textNode) format(b *strings.Builder, _ *messageContext) {
+	b.WriteString(string(n))
+}
+
+func (n argNode) format(b *strings.Builder, ctx *messageContext) {
+	if n.kind == "number" {
+		if value, decimals, ok := ctx.value(n.name); ok {
+			if n.style == "integer" {
+				decimals = 0
+			}
+
+			b.WriteString(ctx.locale.FormatNumber(value, decimals))
+			return
+		}
+	}
+
+	if s, ok := ctx.args[n.name]; ok {
+		b.WriteString(s)
+		return
+	}
+	if value, decimals, ok := ctx.value(n.name); ok {
+		b.WriteString(ctx.locale.FormatNumber(value, decimals))
+		return
+	}
+
+	b.WriteString("{" + n.name + "}")
+}
+
+func (poundNode) format(b *strings.Builder, ctx *messageContext) {
+	if ctx.number == nil {
+		b.WriteString("#")
+		return
+	}
+
+	b.WriteString(ctx.locale.FormatNumber(*ctx.number, decimalsOf(strconv.FormatFloat(*ctx.number, 'f', -1, 64))))
+}
+
+func (n selectNode) format(b *strings.Builder, ctx *messageContext) {
+	if n.kind == "select" {
+		selected, ok := n.cases[ctx.args[n.name]]
+		if !ok {
+			selected = n.cases[PluralOther]
+		}
+
+		b.WriteString(selected.format(ctx))
+		return
+	}
+
+	value, _, ok := ctx.value(n.name)
+	if !ok {
+		b.WriteString(n.cases[PluralOther].format(ctx))
+		return
+	}
+
+	selected, ok := n.cases["="+strconv.FormatFloat(value, 'f', -1, 64)]
+	value -= n.offset
+	if !ok {
+		selected, ok = n.cases[n.category(ctx.locale, value)]
+	}
+	if !ok {
+		selected = n.cases[PluralOther]
+	}
+
+	nested := *ctx
+	nested.number = &value
+	b.WriteString(selected.format(&nested))
+}
+
+// category returns the plural or ordinal category of the value in the locale. Fractions are always PluralOther.
+func (n selectNode) category(locale *Locale, value float64) string {
+	if value != math.Trunc(value) || math.Abs(value) > math.MaxInt32 {
+		return PluralOther
+	}
+
+	if n.kind == "selectordinal" {
+		return locale.OrdinalCategory(int(value))
+	}
+
+	return locale.PluralCategory(int(value))
+}
+
+// message parses a message until its end or, if nested, until the closing brace of a case.
+// A '#' is parsed as the number of the enclosing plural or selectordinal argument if inPlural is true.
+func (p *messageParser) message(nested bool, inPlural bool) (*Message, error) {
+	message := &Message{}
+	text := &strings.Builder{}
+	flush := func() {
+		if text.Len() > 0 {
+			message.nodes = append(message.nodes, textNode(text.String()))
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.s) {
+		r := p.s[p.pos]
+		switch {
+		case r == '\'':
+			p.quoted(text)
+		case r == '{':
+			flush()
+			p.pos++
+			node, err := p.argument(inPlural)
+			if err != nil {
+				return nil, err
+			}
+			message.nodes = append(message.nodes, node)
+		case r == '}':
+			if !nested {
+				return nil, errors.New("unmatched closing brace")
+			}
+			flush()
+			return message, nil
+		case r == '#' && inPlural:
+			flush()
+			message.nodes = append(message.nodes, poundNode{})
+			p.pos++
+		default:
+			text.WriteRune(r)
+			p.pos++
+		}
+	}
+
+	if nested {
+		return nil, errors.New("missing closing brace")
+	}
+	flush()
+
+	return message, nil
+}
+
+// quoted parses an apostrophe: a doubled apostrophe is a literal one and an apostrophe followed by a special character
+// quotes the text up to the next apostrophe. Other apostrophes are literal, e.g. in "don't".
+func (p *messageParser) quoted(text *strings.Builder) {
+	p.pos++
+	if p.pos < len(p.s) && p.s[p.pos] == '\'' {
+		text.WriteRune('\'')
+		p.pos++
+		return
+	}
+	if p.pos >= len(p.s) || !strings.ContainsRune("{}#|", p.s[p.pos]) {
+		text.WriteRune('\'')
+		return
+	}
+
+	for p.pos < len(p.s) {
+		if p.s[p.pos] == '\'' {
+			if p.pos+1 < len(p.s) && p.s[p.pos+1] == '\'' {
+				text.WriteRune('\'')
+				p.pos += 2
+				continue
+			}
+
+			p.pos++
+			return
+		}
+
+		text.WriteRune(p.s[p.pos])
+		p.pos++
+	}
+}
+
+// argument parses an argument after its opening brace up to and including its closing brace.
+func (p *messageParser) argument(inPlural bool) (messageNode, error) {
+	name := p.identifier()
+	if name == "" {
+		return nil, errors.New("missing argument name")
+	}
+	if p.consume('}') {
+		return argNode{name: name}, nil
+	}
+	if !p.consume(',') {
+		return nil, fmt.Errorf("unexpected character in argument %s", name)
+	}
+
+	kind := p.identifier()
+	switch kind {
+	case "number":
+		style := ""
+		if p.consume(',') {
+			style = p.identifier()
+		}
+		if !p.consume('}') {
+			return nil, fmt.Errorf("missing closing brace of argument %s", name)
+		}
+
+		return argNode{name: name, kind: kind, style: style}, nil
+	case "select", "plural", "selectordinal":
+		if !p.consume(',') {
+			return nil, fmt.Errorf("missing cases of argument %s", name)
+		}
+
+		return p.cases(name, kind, inPlural || kind != "select")
+	default:
+		return nil, fmt.Errorf("unsupported argument type %q", kind)
+	}
+}
+
+// cases parses the cases of a select, plural or selectordinal argument up to and including its closing brace.
+func (p *messageParser) cases(name string, kind string, inPlural bool) (messageNode, error) {
+	node := selectNode{name: name, kind: kind, cases: make(map[string]*Message)}
+
+	p.skipSpace()
+	if kind != "select" && strings.HasPrefix(string(p.s[p.pos:]), "offset:") {
+		p.pos += len("offset:")
+		offset, err := strconv.ParseFloat(p.identifier(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset of argument %s", name)
+		}
+		node.offset = offset
+	}
+
+	for {
+		if p.consume('}') {
+			break
+		}
+
+		selector := p.identifier()
+		if selector == "" {
+			return nil, fmt.Errorf("missing case of argument %s", name)
+		}
+		if !p.consume('{') {
+			return nil, fmt.Errorf("missing message of case %s", selector)
+		}
+
+		message, err := p.message(true, inPlural)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++ // closing brace of the case
+
+		node.cases[selector] = message
+	}
+
+	if _, ok := node.cases[PluralOther]; !ok {
+		return nil, fmt.Errorf("missing other case of argument %s", name)
+	}
+
+	return node, nil
+}
+
+// identifier skips whitespace and returns the following name, keyword or selector, e.g. =0.
+func (p *messageParser) identifier() string {
+	p.skipSpace()
+
+	start := p.pos
+	for p.pos < len(p.s) {
+		r := p.s[p.pos]
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-=.", r) {
+			break
+		}
+		p.pos++
+	}
+
+	return string(p.s[start:p.pos])
+}
+
+// consume skips whitespace and consumes the rune if it is next.
+func (p *messageParser) consume(r rune) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == r {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *messageParser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+// decimalsOf returns the number of decimals of a number in its Go representation.
+func decimalsOf(s string) int {
+	_, fraction, _ := strings.Cut(s, ".")
+	return len(fraction)
+}
@@ -0,0 +1,83 @@
+package trans
+
+import (
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIsMessage(t *testing.T) {
+	assert.True(t, IsMessage("Hello {name}"))
+	assert.False(t, IsMessage("Hello {{ .name }}"))
+	assert.False(t, IsMessage("Hello"))
+}
+
+func TestCompileMessage(t *testing.T) {
+	en := &Locale{Path: "en", GroupSeparator: ","}
+	de := &Locale{Path: "de", DecimalSeparator: ",", GroupSeparator: "."}
+
+	tests := []struct {
+		message  string
+		locale   *Locale
+		args     []string
+		expected string
+	}{
+		{"Hello {name}!", en, []string{"name", "Ada"}, "Hello Ada!"},
+		{"Hello {name}!", en, nil, "Hello {name}!"},
+		{"{n, number} rows", de, []string{"n", "1234.5"}, "1.234,5 rows"},
+		{"{n, number, integer} rows", en, []string{"n", "1234.6"}, "1,235 rows"},
+		{"{count, plural, =0 {no requirements} one {# requirement} other {# requirements}}", en, []string{"count", "0"}, "no requirements"},
+		{"{count, plural, =0 {no requirements} one {# requirement} other {# requirements}}", en, []string{"count", "1"}, "1 requirement"},
+		{"{count, plural, =0 {no requirements} one {# requirement} other {# requirements}}", en, []string{"count", "1200"}, "1,200 requirements"},
+		{"{count, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}", &Locale{Path: "pl"}, []string{"count", "22"}, "22 pliki"},
+		{"{count, plural, offset:1 =0 {nobody} =1 {{name}} one {{name} and # other} other {{name} and # others}}", en, []string{"count", "3", "name", "Ada"}, "Ada and 2 others"},
+		{"{count, plural, offset:1 =0 {nobody} =1 {{name}} one {{name} and # other} other {{name} and # others}}", en, []string{"count", "2", "name", "Ada"}, "Ada and 1 other"},
+		{"{gender, select, female {{name} invited her team} male {{name} invited his team} other {{name} invited the team}}", en, []string{"gender", "female", "name", "Ada"}, "Ada invited her team"},
+		{"{gender, select, female {{name} invited her team} male {{name} invited his team} other {{name} invited the team}}", en, []string{"name", "Kim"}, "Kim invited the team"},
+		{"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}} place", en, []string{"n", "22"}, "22nd place"},
+		{"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}} place", en, []string{"n", "13"}, "13th place"},
+		{"{n, selectordinal, other {#.}} Platz", de, []string{"n", "3"}, "3. Platz"},
+		{"{count, plural, other {# items}}", en, []string{"count", "many"}, "# items"},
+		{"Don't escape '{name}' or '#' but '{'' and ''}' and {name}''s", en, []string{"name", "Ada"}, "Don't escape {name} or # but {' and '} and Ada's"},
+		{"{count, plural, one {{gender, select, female {# Autorin} other {# Autor}}} other {# Autoren}}", de, []string{"count", "1", "gender", "female"}, "1 Autorin"},
+	}
+
+	for _, test := range tests {
+		message, err := CompileMessage(test.message)
+		require.NoError(t, err, test.message)
+		assert.Equal(t, test.expected, message.Format(test.locale, test.args...), test.message)
+	}
+
+	for _, invalid := range []string{
+		"Hello {name",
+		"Hello name}",
+		"{}",
+		"{n, date}",
+		"{count, plural, one {# item}}",
+		"{gender, select, female {her}",
+		"{count, plural, offset:x other {#}}",
+	} {
+		_, err := CompileMessage(invalid)
+		assert.ErrorIs(t, err, ErrInvalidMessage, invalid)
+	}
+}
+
+func TestHTranslator_Message(t *testing.T) {
+	translator := NewTranslator(
+		ForLocale(&Locale{Path: "de", DecimalSeparator: ",", GroupSeparator: "."}),
+		WithLogger(trace.NewLogger()), // the invalid message is logged as an error
+		WithTranslations(map[string]string{
+			"requirements": "{count, plural, =0 {Keine Anforderungen} one {# Anforderung} other {# Anforderungen}}",
+			"greeting":     "Hallo {name}",
+			"invalid":      "Hallo {name",
+		}),
+	)
+
+	assert.Equal(t, "Hallo Ada", translator.Tf("greeting", "name", "Ada"))
+	assert.Equal(t, "Keine Anforderungen", translator.Tn("requirements", 0))
+	assert.Equal(t, "1.234 Anforderungen", translator.Tn("requirements", 1234))
+	assert.Equal(t, "1 Anforderung", translator.Tf("requirements", "count", "1"))
+	assert.Equal(t, "Hallo {name", translator.Tf("invalid", "name", "Ada"))
+	assert.Equal(t, "Hi Ada", translator.Tf("Hi {name}", "name", "Ada"))
+}
@@ -0,0 +1,206 @@
+package trans
+
+import "strings"
+
+// Plural categories as defined by the CLDR plural rules. Translations of Translator.Tn are selected
+// by the category of the count, e.g. {"items": {"one": "{{ .count }} item", "other": "{{ .count }} items"}}.
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+// Variants of messages selected by grammatical gender using Translator.Ts.
+// Messages without a variant for a gender fall back to the VariantOther.
+const (
+	GenderFemale = "female"
+	GenderMale   = "male"
+	GenderNeuter = "neuter"
+	VariantOther = "other"
+)
+
+// PluralRule returns the plural category of a non-negative integer count.
+type PluralRule func(n int) string
+
+// pluralRules are the CLDR cardinal plural rules of integers by language.
+// Languages without a rule use pluralOneOther, as they are the most common case.
+var pluralRules = map[string]PluralRule{
+	"ar": pluralArabic,
+	"be": pluralEastSlavic,
+	"cs": pluralWestSlavic,
+	"fr": pluralZeroOne,
+	"he": pluralHebrew,
+	"ja": pluralNone,
+	"ko": pluralNone,
+	"pl": pluralPolish,
+	"pt": pluralZeroOne,
+	"ru": pluralEastSlavic,
+	"sk": pluralWestSlavic,
+	"th": pluralNone,
+	"uk": pluralEastSlavic,
+	"vi": pluralNone,
+	"zh": pluralNone,
+}
+
+// ordinalRules are the CLDR ordinal rules of integers by language, e.g. for 1st, 2nd, 3rd, 4th in English.
+// Languages without a rule only use PluralOther, e.g. German (1., 2., 3.).
+var ordinalRules = map[string]PluralRule{
+	"en": ordinalEnglish,
+	"fr": ordinalOne,
+}
+
+// PluralCategory returns the plural category of the count in the locale's language, e.g. PluralOne for 1 in English.
+// The language is the first segment of the locale's path (de-AT => de). PluralCategory is safe to be called on a nil locale.
+func (l *Locale) PluralCategory(n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	rule, ok := pluralRules[l.language()]
+	if !ok {
+		return pluralOneOther(n)
+	}
+
+	return rule(n)
+}
+
+// OrdinalCategory returns the ordinal category of the number in the locale's language, e.g. PluralTwo for 22 (22nd) in English.
+// It is used by selectordinal arguments of ICU messages (see Message). OrdinalCategory is safe to be called on a nil locale.
+func (l *Locale) OrdinalCategory(n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	rule, ok := ordinalRules[l.language()]
+	if !ok {
+		return PluralOther
+	}
+
+	return rule(n)
+}
+
+// language returns the lower-cased language of the locale's path. It is empty for a nil locale.
+func (l *Locale) language() string {
+	if l == nil {
+		return ""
+	}
+
+	language, _, _ := strings.Cut(strings.ReplaceAll(l.Path, "_", "-"), "-")
+
+	return strings.ToLower(language)
+}
+
+// pluralOneOther is the rule of e.g. English and German: 1 is singular, everything else plural.
+func pluralOneOther(n int) string {
+	if n == 1 {
+		return PluralOne
+	}
+
+	return PluralOther
+}
+
+// pluralZeroOne is the rule of e.g. French: 0 and 1 are singular.
+func pluralZeroOne(n int) string {
+	if n == 0 || n == 1 {
+		return PluralOne
+	}
+
+	return PluralOther
+}
+
+// pluralNone is the rule of languages without grammatical number, e.g. Japanese or Chinese.
+func pluralNone(int) string {
+	return PluralOther
+}
+
+// pluralEastSlavic is the rule of Russian, Ukrainian and Belarusian.
+func pluralEastSlavic(n int) string {
+	switch mod10, mod100 := n%10, n%100; {
+	case mod10 == 1 && mod100 != 11:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
+
+// pluralPolish is the rule of Polish, which only uses PluralOne for exactly 1.
+func pluralPolish(n int) string {
+	switch mod10, mod100 := n%10, n%100; {
+	case n == 1:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
+
+// pluralWestSlavic is the rule of Czech and Slovak.
+func pluralWestSlavic(n int) string {
+	switch {
+	case n == 1:
+		return PluralOne
+	case n >= 2 && n <= 4:
+		return PluralFew
+	default:
+		return PluralOther
+	}
+}
+
+// pluralHebrew is the rule of Hebrew.
+func pluralHebrew(n int) string {
+	switch n {
+	case 1:
+		return PluralOne
+	case 2:
+		return PluralTwo
+	default:
+		return PluralOther
+	}
+}
+
+// ordinalEnglish is the ordinal rule of English: 1st, 2nd, 3rd, 4th, 11th, 12th, 13th, 21st.
+func ordinalEnglish(n int) string {
+	switch mod10, mod100 := n%10, n%100; {
+	case mod10 == 1 && mod100 != 11:
+		return PluralOne
+	case mod10 == 2 && mod100 != 12:
+		return PluralTwo
+	case mod10 == 3 && mod100 != 13:
+		return PluralFew
+	default:
+		return PluralOther
+	}
+}
+
+// ordinalOne is the ordinal rule of e.g. French: 1er, 2e.
+func ordinalOne(n int) string {
+	if n == 1 {
+		return PluralOne
+	}
+
+	return PluralOther
+}
+
+// pluralArabic is the rule of Arabic.
+func pluralArabic(n int) string {
+	switch mod100 := n % 100; {
+	case n == 0:
+		return PluralZero
+	case n == 1:
+		return PluralOne
+	case n == 2:
+		return PluralTwo
+	case mod100 >= 3 && mod100 <= 10:
+		return PluralFew
+	case mod100 >= 11:
+		return PluralMany
+	default:
+		return PluralOther
+	}
+}
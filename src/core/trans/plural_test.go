@@ -0,0 +1,47 @@
+package trans
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLocale_PluralCategory(t *testing.T) {
+	tests := []struct {
+		path     string
+		n        int
+		expected string
+	}{
+		{"en", 0, PluralOther},
+		{"en", 1, PluralOne},
+		{"en", 2, PluralOther},
+		{"de-AT", 1, PluralOne},
+		{"de_DE", 21, PluralOther},
+		{"fr", 0, PluralOne},
+		{"fr", 2, PluralOther},
+		{"ru", 1, PluralOne},
+		{"ru", 11, PluralMany},
+		{"ru", 22, PluralFew},
+		{"ru", 14, PluralMany},
+		{"ru", 25, PluralMany},
+		{"pl", 21, PluralMany},
+		{"pl", 23, PluralFew},
+		{"cs", 3, PluralFew},
+		{"cs", 5, PluralOther},
+		{"ar", 0, PluralZero},
+		{"ar", 2, PluralTwo},
+		{"ar", 103, PluralFew},
+		{"ar", 111, PluralMany},
+		{"ar", 100, PluralOther},
+		{"ja", 1, PluralOther},
+		{"en", -1, PluralOne},
+	}
+
+	for _, test := range tests {
+		locale := &Locale{Path: test.path}
+		assert.Equal(t, test.expected, locale.PluralCategory(test.n), "%s %d", test.path, test.n)
+	}
+
+	var locale *Locale
+	assert.Equal(t, PluralOne, locale.PluralCategory(1))
+	assert.Equal(t, PluralOther, locale.PluralCategory(2))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/org-harmony/harmony/src/core/trace"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"text/template"
@@ -44,11 +45,24 @@ type Locale struct {
 	Path    string `toml:"path" hvalidate:"required"` // Path of the locale. E.g. de/de-DE/en/en-US.
 	Name    string `toml:"name" hvalidate:"required"` // Name of the locale. E.g. Deutsch/Deutsch (Deutschland)/English/English (United States).
 	Default bool   `toml:"default"`                   // Default declares the locale as default.
+	// Direction is the writing direction of the locale: ltr (default) or rtl, e.g. for Arabic or Hebrew. See Locale.Dir.
+	Direction string `toml:"direction"`
+	// DateFormat and DateTimeFormat are the Go time layouts of dates and times, see Locale.FormatDate.
+	DateFormat     string `toml:"date_format"`
+	DateTimeFormat string `toml:"date_time_format"`
+	// DecimalSeparator and GroupSeparator are used to format numbers, see Locale.FormatNumber.
+	DecimalSeparator string `toml:"decimal_separator"`
+	GroupSeparator   string `toml:"group_separator"`
+	// Fallback is the chain of locale paths used in order for missing translations, e.g. ["de"] for de-AT.
+	// Without a chain the parent language is used. The default locale always ends the chain, see NewTranslatorProvider.
+	Fallback []string `toml:"fallback"`
 }
 
-// HTranslator is a thread-safe translator using templates ({{.argName}}) for user-facing strings.
-// Translations are cached with the md5 hash of the string as the key.
-// It acts as a lookup table; if a translation is not found, the original string is returned.
+// HTranslator is a thread-safe translator using templates ({{.argName}}) or ICU messages ({argName}, see Message)
+// for user-facing strings. Templates are cached with the md5 hash of the string as the key,
+// ICU messages are compiled when the translator is created and cached by the message.
+// It acts as a lookup table; if a translation is not found, the fallbacks are asked in order
+// and the original string is returned if none translates it. Missing keys are recorded, see HTranslator.Report.
 // The translations map should not be modified concurrently to maintain thread safety.
 type HTranslator struct {
 	translations map[string]string
@@ -56,6 +70,10 @@ type HTranslator struct {
 	tMu          sync.RWMutex
 	logger       trace.Logger
 	locale       *Locale
+	messages     map[string]*Message
+	fallbacks    []*HTranslator
+	missing      map[string]string
+	mMu          sync.Mutex
 }
 
 // HTranslatorProvider provides translators for various locales in a thread-safe manner,
@@ -83,6 +101,14 @@ type Translator interface {
 	// Example:
 	// 	Tf("Hello {{.name}}", "name", "John") => "Hello John"
 	Tf(s string, args ...string) string
+	// Tn translates the plural variant of a key by the count's plural category (see Locale.PluralCategory).
+	// The count is passed as the "count" argument formatted by the locale. Example:
+	// 	Tn("items", 2) with {"items": {"one": "{{ .count }} item", "other": "{{ .count }} items"}} => "2 items"
+	Tn(key string, count int, args ...string) string
+	// Ts translates the variant of a key selected by the variant, e.g. a grammatical gender (see GenderFemale).
+	// Example:
+	// 	Ts("invited", GenderFemale, "name", "Ada") with {"invited": {"female": "{{ .name }} invited her team", "other": ...}}
+	Ts(key string, variant string, args ...string) string
 	Locale() *Locale // Locale returns the locale the translator translates to.
 }
 
@@ -91,6 +117,7 @@ type Translator interface {
 type TranslatorProvider interface {
 	Translator(locale string) (Translator, error) // Translator returns a translator for a locale.
 	Default() (Translator, error)                 // Default returns the default translator as a fallback.
+	Translators() []Translator                    // Translators returns all translators ordered by their locale's path.
 }
 
 // WithLogger sets the logger for the translator. This should be set to the default application logger.
@@ -133,20 +160,33 @@ func NewTranslator(opts ...HTranslatorOption) Translator {
 		translator.template = template.New("translator-base")
 	}
 
+	translator.compileMessages()
+
 	return translator
 }
 
-// T translates a string.
+// T translates a string. Strings missing in the translator's locale are translated by the first fallback translating them.
+// Fallbacks are logged at debug level and recorded as missing keys.
 func (t *HTranslator) T(s string) string {
 	if t == nil {
 		return s
 	}
 
-	transS, ok := t.translations[s]
+	if transS, ok := t.translations[s]; ok {
+		return transS
+	}
+
+	transS, fallback, ok := t.fallback(s)
 	if !ok {
+		t.recordMissing(s, "")
 		return s
 	}
 
+	if t.locale != nil {
+		t.logger.Debug(Pkg, "translation missing, using fallback", "key", s, "locale", t.locale.Path, "fallback", fallback)
+	}
+	t.recordMissing(s, fallback)
+
 	return transS
 }
 
@@ -154,15 +194,25 @@ func (t *HTranslator) T(s string) string {
 // Example:
 //
 //	Tf("Hello {{.name}}", "name", "John") => "Hello John"
+//	Tf("Hello {name}", "name", "John") => "Hello John"
 //
-// This parsing of args is done by the ArgsAsMap function.
+// This parsing of args is done by the ArgsAsMap function. Translations using the ICU syntax are formatted as Message.
 func (t *HTranslator) Tf(s string, args ...string) string {
+	return t.tf(s, nil, args...)
+}
+
+// tf translates a string with arguments and numbers used by ICU messages, see Tf. Numbers are ignored by templates.
+func (t *HTranslator) tf(s string, numbers map[string]float64, args ...string) string {
 	if t == nil {
 		return s
 	}
 
 	var err error
 	s = t.T(s)
+	if IsMessage(s) {
+		return t.formatMessage(s, numbers, args...)
+	}
+
 	hash := md5.New()
 	hash.Write([]byte(s))
 	sh := string(hash.Sum(nil))
@@ -193,6 +243,31 @@ func (t *HTranslator) Tf(s string, args ...string) string {
 	return wr.String()
 }
 
+// Tn translates the plural variant of a key by the count's plural category with arguments.
+// The variant "zero" is preferred for a count of 0 if it exists, regardless of the locale's plural rules.
+// Missing categories fall back to "other" and then to the key itself. The count is passed as the "count" argument
+// formatted by the locale's FormatNumber, it can be overridden by the args. ICU messages without variants,
+// e.g. {"items": "{count, plural, one {# item} other {# items}}"}, select their case by the count instead.
+func (t *HTranslator) Tn(key string, count int, args ...string) string {
+	categories := []string{t.Locale().PluralCategory(count), PluralOther}
+	if count == 0 {
+		categories = append([]string{PluralZero}, categories...)
+	}
+
+	numbers := map[string]float64{"count": float64(count)}
+	for i := 0; i+1 < len(args); i += 2 {
+		delete(numbers, args[i])
+	}
+	args = append([]string{"count", t.Locale().FormatNumber(float64(count), 0)}, args...)
+
+	return t.tf(t.variant(key, categories...), numbers, args...)
+}
+
+// Ts translates the variant of a key with arguments. Missing variants fall back to "other" and then to the key itself.
+func (t *HTranslator) Ts(key string, variant string, args ...string) string {
+	return t.Tf(t.variant(key, variant, VariantOther), args...)
+}
+
 // Locale returns the locale the translator translates to.
 func (t *HTranslator) Locale() *Locale {
 	if t == nil {
@@ -202,6 +277,66 @@ func (t *HTranslator) Locale() *Locale {
 	return t.locale
 }
 
+// compileMessages compiles the translator's ICU messages. Invalid messages are logged and translated as they are.
+func (t *HTranslator) compileMessages() {
+	t.messages = make(map[string]*Message)
+	for key, s := range t.translations {
+		if !IsMessage(s) {
+			continue
+		}
+
+		message, err := CompileMessage(s)
+		if err != nil {
+			t.logger.Error(Pkg, "error compiling icu message", err, "key", key)
+		}
+
+		t.messages[s] = message
+	}
+}
+
+// formatMessage formats the ICU message. Messages of fallbacks are compiled and cached on their first use.
+func (t *HTranslator) formatMessage(s string, numbers map[string]float64, args ...string) string {
+	t.tMu.RLock()
+	message, ok := t.messages[s]
+	t.tMu.RUnlock()
+	if !ok {
+		var err error
+		message, err = CompileMessage(s)
+		if err != nil {
+			t.logger.Error(Pkg, "error compiling icu message", err, "message", s)
+		}
+
+		t.tMu.Lock()
+		if t.messages == nil {
+			t.messages = make(map[string]*Message)
+		}
+		t.messages[s] = message
+		t.tMu.Unlock()
+	}
+
+	if message == nil {
+		return s
+	}
+
+	return message.format(&messageContext{args: ArgsAsMap(args...), numbers: numbers, locale: t.locale})
+}
+
+// variant returns the key of the first variant of the key with a translation or the key itself.
+func (t *HTranslator) variant(key string, variants ...string) string {
+	if t == nil {
+		return key
+	}
+
+	for _, variant := range variants {
+		variantKey := fmt.Sprintf("%s.%s", key, variant)
+		if t.has(variantKey) {
+			return variantKey
+		}
+	}
+
+	return key
+}
+
 // ArgsAsMap converts a list of arguments to a map.
 // Scheme: key1, value1, key2, value2, ...
 func ArgsAsMap(args ...string) map[string]string {
@@ -240,6 +375,28 @@ func FromLocale(locale *Locale, translationsDir string, logger trace.Logger) (Tr
 	return NewTranslator(WithTranslations(translations), ForLocale(locale), WithLogger(logger)), nil
 }
 
+// FromLocaleOrEmpty returns a translator for the locale whose path and name are the same, e.g. passed in as flag of a command.
+// If the translations could not be loaded, a translator without translations is returned and keys are printed instead of messages.
+func FromLocaleOrEmpty(locale string, translationsDir string, logger trace.Logger) Translator {
+	translator, err := FromLocale(&Locale{Path: locale, Name: locale}, translationsDir, logger)
+	if err != nil {
+		return NewTranslator(WithLogger(logger))
+	}
+
+	return translator
+}
+
+// TranslateErr translates the error if it is (or wraps) a Translatable error, e.g. a validation error.
+// Otherwise, the error's message is translated as key.
+func TranslateErr(err error, translator Translator) string {
+	var translatable Translatable
+	if errors.As(err, &translatable) {
+		return translatable.Translate(translator)
+	}
+
+	return translator.T(err.Error())
+}
+
 // LoadTranslations loads the translations from a file.
 // The file content will be flattened to a map of strings keeping case-sensitivity, where the key is the path of the translation.
 // Example:
@@ -263,7 +420,8 @@ func LoadTranslations(translationsDir string, locale string) (map[string]string,
 
 // NewTranslatorProvider returns a new translator provider using a list of translators.
 // The first translator's locale is used as default locale as long as no other translator's locale is marked as default.
-// It ignores translators with a nil locale.
+// It ignores translators with a nil locale. The fallbacks of HTranslators without fallbacks (see WithFallbacks)
+// are linked by their locale's Fallback chain or parent language, followed by the default locale.
 func NewTranslatorProvider(lt ...Translator) TranslatorProvider {
 	p := &HTranslatorProvider{
 		translators: make(map[string]Translator),
@@ -282,6 +440,8 @@ func NewTranslatorProvider(lt ...Translator) TranslatorProvider {
 		}
 	}
 
+	linkFallbacks(p.translators, p.defaultTrans)
+
 	return p
 }
 
@@ -303,6 +463,20 @@ func (t *HTranslatorProvider) Default() (Translator, error) {
 	return t.defaultTrans, nil
 }
 
+// Translators returns all translators ordered by their locale's path.
+func (t *HTranslatorProvider) Translators() []Translator {
+	translators := make([]Translator, 0, len(t.translators))
+	for _, translator := range t.translators {
+		translators = append(translators, translator)
+	}
+
+	sort.Slice(translators, func(i, j int) bool {
+		return translators[i].Locale().Path < translators[j].Locale().Path
+	})
+
+	return translators
+}
+
 // DefaultLocale returns the default locale.
 func (cfg *Cfg) DefaultLocale() (*Locale, error) {
 	for _, locale := range cfg.Locales {
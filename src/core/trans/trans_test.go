@@ -1,6 +1,8 @@
 package trans
 
 import (
+	"errors"
+	"fmt"
 	"github.com/org-harmony/harmony/src/core/trace"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -66,6 +68,37 @@ func TestHTranslator_T(t *testing.T) {
 	})
 }
 
+func TestHTranslator_Tn(t *testing.T) {
+	translator := mockTranslator(t)
+
+	t.Run("plural categories", func(t *testing.T) {
+		assert.Equal(t, "1 Anforderung", translator.Tn("requirements", 1))
+		assert.Equal(t, "1.234 Anforderungen", translator.Tn("requirements", 1234))
+		assert.Equal(t, "keine Anforderungen", translator.Tn("requirements", 0))
+	})
+
+	t.Run("missing category falls back to other", func(t *testing.T) {
+		assert.Equal(t, "1 Dateien", translator.Tn("files", 1))
+	})
+
+	t.Run("without translation", func(t *testing.T) {
+		assert.Equal(t, "3 items of Ada", translator.Tn("{{.count}} items of {{.name}}", 3, "name", "Ada"))
+	})
+
+	t.Run("args override count", func(t *testing.T) {
+		assert.Equal(t, "viele Anforderungen", translator.Tn("requirements", 2, "count", "viele"))
+	})
+}
+
+func TestHTranslator_Ts(t *testing.T) {
+	translator := mockTranslator(t)
+
+	assert.Equal(t, "Ada hat ihr Team eingeladen", translator.Ts("invited", GenderFemale, "name", "Ada"))
+	assert.Equal(t, "Alan hat sein Team eingeladen", translator.Ts("invited", GenderMale, "name", "Alan"))
+	assert.Equal(t, "Kim hat das Team eingeladen", translator.Ts("invited", GenderNeuter, "name", "Kim"))
+	assert.Equal(t, "unknown", translator.Ts("unknown", GenderFemale))
+}
+
 func TestParams(t *testing.T) {
 	t.Run("correct params", func(t *testing.T) {
 		params := ArgsAsMap("foo", "bar")
@@ -93,15 +126,45 @@ func TestParams(t *testing.T) {
 	})
 }
 
+func TestTranslateErr(t *testing.T) {
+	translator := mockTranslator(t)
+
+	assert.Equal(t, "füü", TranslateErr(errors.New("foo"), translator))
+	assert.Equal(t, "qux ist ein fuchs", TranslateErr(fmt.Errorf("wrapped: %w", translatableErr{}), translator))
+}
+
+func TestFromLocaleOrEmpty(t *testing.T) {
+	translator := FromLocaleOrEmpty("xx", t.TempDir(), trace.NewTestLogger(t))
+	assert.Equal(t, "foo", translator.T("foo"), "keys are printed if the translations are missing")
+}
+
+type translatableErr struct{}
+
+func (translatableErr) Error() string {
+	return "translatable"
+}
+
+func (translatableErr) Translate(t Translator) string {
+	return t.T("qux is a fux")
+}
+
 func mockTranslator(t *testing.T) Translator {
 	return &HTranslator{
 		translations: map[string]string{
 			"foo":                    "füü",
 			"{{.foo}} is like a bar": "{{.foo}} ist wie ein bar",
 			"qux is like a {{.foo}} with a {{.crux}}": "qux ist wie ein {{.foo}} mit einem {{.crux}}",
-			"qux is a fux": "qux ist ein fuchs",
+			"qux is a fux":       "qux ist ein fuchs",
+			"requirements.zero":  "keine Anforderungen",
+			"requirements.one":   "{{.count}} Anforderung",
+			"requirements.other": "{{.count}} Anforderungen",
+			"files.other":        "{{.count}} Dateien",
+			"invited.female":     "{{.name}} hat ihr Team eingeladen",
+			"invited.male":       "{{.name}} hat sein Team eingeladen",
+			"invited.other":      "{{.name}} hat das Team eingeladen",
 		},
 		template: template.New("translator-base"),
 		logger:   trace.NewTestLogger(t),
+		locale:   &Locale{Path: "de", GroupSeparator: "."},
 	}
 }
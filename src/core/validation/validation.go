@@ -3,14 +3,22 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"github.com/org-harmony/harmony/src/core/trans"
 	"reflect"
 	"strings"
 	"sync"
 )
 
-// StructTag is the default struct tag used for validation.
-// Using this struct tag will allow the validator to validate struct fields in V.ValidateStruct.
-const StructTag = "hvalidate"
+const (
+	// StructTag is the default struct tag used for validation.
+	// Using this struct tag will allow the validator to validate struct fields in V.ValidateStruct.
+	StructTag = "hvalidate"
+	// MessageTag is the struct tag overriding the messages of a field's validation errors with custom translation keys.
+	// The tag is either a key used for all validators of the field, e.g. `hmsg:"template.set.validation.name-required"`,
+	// or a comma-separated list of keys per validator, e.g. `hmsg:"required=project.validation.name,maxLength=project.validation.name-length"`.
+	// Parameterized validators are referenced by their name without parameter. Validators without a key keep their message.
+	MessageTag = "hmsg"
+)
 
 var (
 	// ErrUnexpected is returned when an unexpected error occurs, e.g. if the reflection fails for an unknown reason.
@@ -19,6 +27,8 @@ var (
 	ErrNotStruct = errors.New("not a struct")
 	// ErrUnknownValidator is returned when an unknown validator is used.
 	ErrUnknownValidator = errors.New("unknown validator")
+	// ErrInvalidParam is returned when a parameterized validator is used with an invalid parameter, e.g. min=abc.
+	ErrInvalidParam = errors.New("invalid validator parameter")
 )
 
 // Validator is a concurrency-safe structure that holds the validation rules (Func or Validators)
@@ -28,6 +38,7 @@ var (
 type Validator struct {
 	structTags  []string
 	funcs       map[string]Func
+	paramFuncs  map[string]ParamFunc
 	fmu         sync.RWMutex
 	schemaCache map[reflect.Type][]Func
 	scmu        sync.RWMutex
@@ -36,9 +47,20 @@ type Validator struct {
 // Error struct holds detailed information about a validation error.
 type Error struct {
 	Msg    string
-	Struct string // namespace and name of the struct, e.g. "config/SomeCfg"
-	Field  string // name of the field, e.g. "SomeField"
-	Path   string // path to the field, e.g. "config/SomeCfg.SomeField(string)"
+	Struct string   // namespace and name of the struct, e.g. "config/SomeCfg"
+	Field  string   // name of the field, e.g. "SomeField"
+	Path   string   // path to the field, e.g. "config/SomeCfg.SomeField(string)"
+	Args   []string // Args are the arguments of the message as key value pairs, e.g. "min", "3" (see ArgsError).
+	// Custom is true if Msg is a custom translation key of the field (see MessageTag).
+	// Custom keys are used as they are by GenericErrorKey and FieldErrorKey.
+	Custom bool
+}
+
+// ArgsError is an error of a validation Func with arguments for the translation of its message, e.g. the minimum of Min.
+// It is a TransparentError unwrapping to an Error with the arguments set.
+type ArgsError struct {
+	Msg  string
+	Args []string
 }
 
 // Func is a function that validates a value and returns an error if the value is invalid.
@@ -47,6 +69,10 @@ type Error struct {
 // Instead, it should return early if the string is empty and another Func should be used to validate that the string is not-empty.
 type Func func(any) error
 
+// ParamFunc returns a Func for the parameter of a parameterized validator, e.g. "3" for the struct tag min=3.
+// It returns an error if the parameter is invalid. Parameters can not contain commas as they separate the validators in struct tags.
+type ParamFunc func(param string) (Func, error)
+
 // ValidatorOption is a function that configures a Validator. It can be used to override the default validator Func map or struct tags.
 type ValidatorOption func(*Validator)
 
@@ -86,7 +112,10 @@ type V interface {
 	ValidateStruct(any, ...string) (error, []error)
 	// AddFunc adds a new validation function to the validator.
 	AddFunc(string, Func)
+	// AddParamFunc adds a new parameterized validation function to the validator. It is used by struct tags like name=param.
+	AddParamFunc(string, ParamFunc)
 	// Lookup returns the validation function for the given name and a bool indicating if the function was found.
+	// Names of parameterized validation functions include the parameter, e.g. min=3.
 	Lookup(string) (Func, bool)
 }
 
@@ -98,6 +127,13 @@ func WithValidators(funcs map[string]Func) ValidatorOption {
 	}
 }
 
+// WithParamValidators allow overriding the validation.DefaultParamValidators.
+func WithParamValidators(funcs map[string]ParamFunc) ValidatorOption {
+	return func(opts *Validator) {
+		opts.paramFuncs = funcs
+	}
+}
+
 // WithStructTags allows overriding the default validation.StructTag used for validation.
 func WithStructTags(tags ...string) ValidatorOption {
 	return func(opts *Validator) {
@@ -182,6 +218,8 @@ func (v *Validator) ValidateStruct(s any, path ...string) (hardErr error, valida
 			errs = append(errs, v...)
 		}
 
+		messages := parseMessageTag(typeOfField.Tag.Get(MessageTag))
+
 		validatorNames := make([]string, 0)
 		for _, tag := range v.structTags {
 			validatorName := typeOfField.Tag.Get(tag)
@@ -198,27 +236,30 @@ func (v *Validator) ValidateStruct(s any, path ...string) (hardErr error, valida
 				continue
 			}
 
-			v.fmu.RLock()
-			validatorFunc, ok := v.funcs[validatorName]
-			v.fmu.RUnlock()
-			if !ok {
-				return fmt.Errorf("%w: %s on %s", ErrUnknownValidator, validatorName, fieldPath), nil
+			validatorFunc, err := v.resolve(validatorName)
+			if err != nil {
+				return fmt.Errorf("%w on %s", err, fieldPath), nil
 			}
 
 			if !valueOfField.CanInterface() {
 				continue
 			}
 
-			err := validatorFunc(valueOfField.Interface())
+			err = validatorFunc(valueOfField.Interface())
 			if err == nil {
 				continue
 			}
 
+			fieldErr := Error{Msg: err.Error(), Struct: typeOfS.Name(), Field: typeOfField.Name, Path: fieldPath}
+			if msg, ok := messages.lookup(validatorName); ok {
+				fieldErr.Msg, fieldErr.Custom = msg, true
+			}
+
 			var validationErr error
-			validationErr = Error{Msg: err.Error(), Struct: typeOfS.Name(), Field: typeOfField.Name, Path: fieldPath}
+			validationErr = fieldErr
 
 			if terr, ok := err.(TransparentError); ok {
-				validationErr = terr.UnwrapTransparent(validationErr.(Error))
+				validationErr = terr.UnwrapTransparent(fieldErr)
 			}
 
 			errs = append(errs, validationErr)
@@ -235,13 +276,51 @@ func (v *Validator) AddFunc(name string, f Func) {
 	v.fmu.Unlock()
 }
 
+// AddParamFunc implements the AddParamFunc method of the V interface. It locks the mutex before adding the function to the map.
+func (v *Validator) AddParamFunc(name string, f ParamFunc) {
+	v.fmu.Lock()
+	v.paramFuncs[name] = f
+	v.fmu.Unlock()
+}
+
 // Lookup implements the Lookup method of the V interface. It locks the mutex before looking up the function in the map.
 func (v *Validator) Lookup(name string) (Func, bool) {
+	f, err := v.resolve(name)
+
+	return f, err == nil
+}
+
+// resolve returns the validation function of the name. Names containing an equal sign are resolved as parameterized
+// validation function (name=param), the resulting function is cached by the name. It returns ErrUnknownValidator
+// for unknown names and ErrInvalidParam for invalid parameters.
+func (v *Validator) resolve(name string) (Func, error) {
 	v.fmu.RLock()
 	f, ok := v.funcs[name]
 	v.fmu.RUnlock()
+	if ok {
+		return f, nil
+	}
+
+	funcName, param, ok := strings.Cut(name, "=")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownValidator, name)
+	}
+
+	v.fmu.RLock()
+	paramFunc, ok := v.paramFuncs[strings.TrimSpace(funcName)]
+	v.fmu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownValidator, name)
+	}
 
-	return f, ok
+	f, err := paramFunc(strings.TrimSpace(param))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidParam, name, err)
+	}
+
+	v.AddFunc(name, f)
+
+	return f, nil
 }
 
 // Validate validates a single value using the given validation funcs.
@@ -278,31 +357,86 @@ func Validate(fieldName string, structName string, value any, funcs ...Func) []e
 }
 
 // GenericErrorKey returns a generic error key for the validation error.
-// The key is constructed as follows: "<msg>.generic". This can be used for i18n.
+// The key is constructed as follows: "<msg>.generic". This can be used for i18n. Custom messages are returned as they are.
 func (e Error) GenericErrorKey() string {
+	if e.Custom {
+		return e.Msg
+	}
+
 	return fmt.Sprintf("%s.generic", e.Msg)
 }
 
 // FieldErrorKey returns a field error key for the validation error.
-// The key is constructed as follows: "<msg>.field.<field>". This can be used for i18n.
+// The key is constructed as follows: "<msg>.field.<field>". This can be used for i18n. Custom messages are returned as they are.
 func (e Error) FieldErrorKey() string {
-	if e.Field != "" {
+	if e.Field != "" && !e.Custom {
 		return fmt.Sprintf("%s.field.%s", e.Msg, e.Field)
 	}
 
 	return e.Msg
 }
 
+// Translate translates the generic error key of the validation error with its arguments, implementing trans.Translatable.
+func (e Error) Translate(t trans.Translator) string {
+	return t.Tf(e.GenericErrorKey(), e.Args...)
+}
+
 // Error implements the Error method of the error interface by returning a string representation of the validation error using the following format:
 // "<path to field>: <msg> (on struct: <struct>, field: <field>)"
 func (e Error) Error() string {
 	return fmt.Sprintf("%s: %s (on struct: %s, field: %s)", e.Path, e.Msg, e.Struct, e.Field)
 }
 
+// Error returns the message of the error.
+func (e ArgsError) Error() string {
+	return e.Msg
+}
+
+// UnwrapTransparent on ArgsError returns the validation error with the arguments, implementing the TransparentError interface.
+func (e ArgsError) UnwrapTransparent(err Error) error {
+	err.Args = e.Args
+	return err
+}
+
+// messageOverrides are the custom translation keys of a field's validators parsed from the MessageTag.
+// The key of all validators is stored under the empty name.
+type messageOverrides map[string]string
+
+// parseMessageTag parses the value of the MessageTag, see MessageTag for the syntax.
+func parseMessageTag(tag string) messageOverrides {
+	if strings.TrimSpace(tag) == "" {
+		return nil
+	}
+
+	messages := make(messageOverrides)
+	for _, entry := range strings.Split(tag, ",") {
+		name, msg, ok := strings.Cut(entry, "=")
+		if !ok {
+			name, msg = "", name
+		}
+
+		messages[strings.TrimSpace(name)] = strings.TrimSpace(msg)
+	}
+
+	return messages
+}
+
+// lookup returns the custom translation key of the validator. Parameterized validators are looked up by their name.
+func (m messageOverrides) lookup(validatorName string) (string, bool) {
+	name, _, _ := strings.Cut(validatorName, "=")
+	if msg, ok := m[strings.TrimSpace(name)]; ok && msg != "" {
+		return msg, true
+	}
+
+	msg, ok := m[""]
+	return msg, ok && msg != ""
+}
+
 // defaultValidator returns a new Validator with the default validation funcs and struct tag.
 func defaultValidator() *Validator {
 	return &Validator{
 		funcs:       DefaultValidators(),
+		paramFuncs:  DefaultParamValidators(),
 		structTags:  []string{StructTag},
 		schemaCache: make(map[reflect.Type][]Func),
 	}
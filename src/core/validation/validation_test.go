@@ -3,6 +3,7 @@ package validation_test
 import (
 	"errors"
 	"fmt"
+	"github.com/org-harmony/harmony/src/core/trans"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -119,6 +120,103 @@ func TestValidator_AddFunc(t *testing.T) {
 	assert.Equal(t, 0, len(validationErrs))
 }
 
+func TestValidator_ParamFuncs(t *testing.T) {
+	type Form struct {
+		Title    string `hvalidate:"required,minLength=3,maxLength=10"`
+		Priority int    `hvalidate:"min=1,max=5"`
+		State    string `hvalidate:"oneOf=draft review done"`
+		Due      string `hvalidate:"datetime=2006-01-02"`
+	}
+
+	v := validation.New()
+
+	err, errs := v.ValidateStruct(Form{Title: "Fix", Priority: 3, State: "done", Due: "2024-01-31"})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	err, errs = v.ValidateStruct(Form{Title: "Ab", Priority: 6, State: "open", Due: "31.01.2024"})
+	require.NoError(t, err)
+	require.Len(t, errs, 4)
+
+	var validationErr validation.Error
+	require.ErrorAs(t, errs[0], &validationErr)
+	assert.Equal(t, "harmony.error.validation.min-length", validationErr.Msg)
+	assert.Equal(t, "Title", validationErr.Field)
+	assert.Equal(t, []string{"min", "3"}, validationErr.Args)
+
+	require.ErrorAs(t, errs[2], &validationErr)
+	assert.Equal(t, []string{"values", "draft, review, done"}, validationErr.Args)
+
+	f, ok := v.Lookup("max=5")
+	require.True(t, ok)
+	assert.Error(t, f(6))
+
+	_, ok = v.Lookup("max=five")
+	assert.False(t, ok)
+
+	type Invalid struct {
+		Priority int `hvalidate:"min=one"`
+	}
+	err, _ = v.ValidateStruct(Invalid{})
+	assert.ErrorIs(t, err, validation.ErrInvalidParam)
+
+	type Unknown struct {
+		Priority int `hvalidate:"between=1"`
+	}
+	err, _ = v.ValidateStruct(Unknown{})
+	assert.ErrorIs(t, err, validation.ErrUnknownValidator)
+
+	v.AddParamFunc("prefix", func(param string) (validation.Func, error) {
+		return func(value any) error {
+			if !strings.HasPrefix(value.(string), param) {
+				return errors.New("prefix")
+			}
+			return nil
+		}, nil
+	})
+	type Prefixed struct {
+		Key string `hvalidate:"prefix=REQ-"`
+	}
+	_, errs = v.ValidateStruct(Prefixed{Key: "TASK-1"})
+	assert.Len(t, errs, 1)
+}
+
+func TestValidator_MessageTag(t *testing.T) {
+	type Form struct {
+		Name    string `hvalidate:"required" hmsg:"project.validation.name-required"`
+		Key     string `hvalidate:"required,minLength=3" hmsg:"minLength=project.validation.key-length"`
+		Version string `hvalidate:"required,semVer"`
+	}
+
+	err, errs := validation.New().ValidateStruct(Form{Key: "AB", Version: "x"})
+	require.NoError(t, err)
+	require.Len(t, errs, 3)
+
+	var nameErr, keyErr, versionErr validation.Error
+	require.ErrorAs(t, errs[0], &nameErr)
+	require.ErrorAs(t, errs[1], &keyErr)
+	require.ErrorAs(t, errs[2], &versionErr)
+
+	assert.True(t, nameErr.Custom)
+	assert.Equal(t, "project.validation.name-required", nameErr.GenericErrorKey())
+	assert.Equal(t, "project.validation.name-required", nameErr.FieldErrorKey())
+
+	assert.Equal(t, "project.validation.key-length", keyErr.GenericErrorKey())
+	assert.Equal(t, []string{"min", "3"}, keyErr.Args)
+
+	assert.False(t, versionErr.Custom)
+	assert.Equal(t, "harmony.error.validation.semantic-version.generic", versionErr.GenericErrorKey())
+}
+
+func TestError_Translate(t *testing.T) {
+	translator := trans.NewTranslator(trans.WithTranslations(map[string]string{
+		"harmony.error.validation.min-length.generic": "At least {{ .min }} characters.",
+	}))
+
+	err := validation.Error{Msg: "harmony.error.validation.min-length", Args: []string{"min", "3"}}
+	assert.Equal(t, "At least 3 characters.", err.Translate(translator))
+}
+
 func TestValidate(t *testing.T) {
 	tests := []struct {
 		input    any
@@ -1,22 +1,74 @@
 package validation
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // DefaultValidators returns a map of default validators (validation.Func).
 // These validators can be used to validate values.
 func DefaultValidators() map[string]Func {
 	return map[string]Func{
-		"required": Required(),
-		"notNil":   NotNil(),
-		"positive": Positive(),
-		"negative": Negative(),
-		"email":    Email(),
-		"semVer":   SemanticVersion(),
+		"required":     Required(),
+		"notNil":       NotNil(),
+		"positive":     Positive(),
+		"negative":     Negative(),
+		"email":        Email(),
+		"semVer":       SemanticVersion(),
+		"url":          URL(),
+		"uuid":         UUID(),
+		"alpha":        Alpha(),
+		"alphanumeric": Alphanumeric(),
+		"json":         JSON(),
+	}
+}
+
+// DefaultParamValidators returns a map of default parameterized validators (validation.ParamFunc).
+// They are used in struct tags with their parameter, e.g. `hvalidate:"min=1,max=10"`, `hvalidate:"maxLength=255"`,
+// `hvalidate:"oneOf=draft review done"` (values separated by spaces) or `hvalidate:"datetime=2006-01-02"`.
+func DefaultParamValidators() map[string]ParamFunc {
+	return map[string]ParamFunc{
+		"min": func(param string) (Func, error) {
+			limit, err := strconv.ParseFloat(param, 64)
+			return Min(limit), err
+		},
+		"max": func(param string) (Func, error) {
+			limit, err := strconv.ParseFloat(param, 64)
+			return Max(limit), err
+		},
+		"minLength": func(param string) (Func, error) {
+			limit, err := strconv.Atoi(param)
+			return MinLength(limit), err
+		},
+		"maxLength": func(param string) (Func, error) {
+			limit, err := strconv.Atoi(param)
+			return MaxLength(limit), err
+		},
+		"oneOf": func(param string) (Func, error) {
+			values := strings.Fields(param)
+			if len(values) == 0 {
+				return nil, errors.New("no values")
+			}
+
+			return OneOf(values), nil
+		},
+		"datetime": func(param string) (Func, error) {
+			if param == "" {
+				return nil, errors.New("no layout")
+			}
+
+			return DateTime(param), nil
+		},
 	}
 }
 
@@ -160,3 +212,297 @@ func SemanticVersion(msgs ...string) Func {
 		return nil
 	}
 }
+
+// URL validates that the value is an absolute http or https URL with a host. Empty values are ignored, non-string values return a validation error.
+func URL(msgs ...string) Func {
+	msg := "harmony.error.validation.url"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return func(value any) error {
+		str, ok := value.(string)
+		if !ok {
+			return errors.New(msg)
+		}
+
+		if str == "" {
+			return nil
+		}
+
+		u, err := url.Parse(str)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New(msg)
+		}
+
+		return nil
+	}
+}
+
+// UUID validates that the value is a UUID. Empty values are ignored, values other than strings and uuid.UUID return a validation error.
+func UUID(msgs ...string) Func {
+	msg := "harmony.error.validation.uuid"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return func(value any) error {
+		if _, ok := value.(uuid.UUID); ok {
+			return nil
+		}
+
+		str, ok := value.(string)
+		if !ok {
+			return errors.New(msg)
+		}
+
+		if str == "" {
+			return nil
+		}
+
+		if _, err := uuid.Parse(str); err != nil {
+			return errors.New(msg)
+		}
+
+		return nil
+	}
+}
+
+// Min validates that the number is at least the minimum. Non-numeric values return a validation error.
+// The error is an ArgsError with the argument min.
+func Min(min float64, msgs ...string) Func {
+	msg := "harmony.error.validation.min"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return func(value any) error {
+		num, ok := number(value)
+		if !ok || num < min {
+			return ArgsError{Msg: msg, Args: []string{"min", strconv.FormatFloat(min, 'f', -1, 64)}}
+		}
+
+		return nil
+	}
+}
+
+// Max validates that the number is at most the maximum. Non-numeric values return a validation error.
+// The error is an ArgsError with the argument max.
+func Max(max float64, msgs ...string) Func {
+	msg := "harmony.error.validation.max"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return func(value any) error {
+		num, ok := number(value)
+		if !ok || num > max {
+			return ArgsError{Msg: msg, Args: []string{"max", strconv.FormatFloat(max, 'f', -1, 64)}}
+		}
+
+		return nil
+	}
+}
+
+// MinLength validates that the length of a string (in characters), slice, array or map is at least the minimum.
+// Empty values are ignored, use Required for them. Values without a length return a validation error.
+// The error is an ArgsError with the argument min.
+func MinLength(min int, msgs ...string) Func {
+	msg := "harmony.error.validation.min-length"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return func(value any) error {
+		l, ok := length(value)
+		if ok && l == 0 {
+			return nil
+		}
+
+		if !ok || l < min {
+			return ArgsError{Msg: msg, Args: []string{"min", strconv.Itoa(min)}}
+		}
+
+		return nil
+	}
+}
+
+// MaxLength validates that the length of a string (in characters), slice, array or map is at most the maximum.
+// Values without a length return a validation error. The error is an ArgsError with the argument max.
+func MaxLength(max int, msgs ...string) Func {
+	msg := "harmony.error.validation.max-length"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return func(value any) error {
+		l, ok := length(value)
+		if !ok || l > max {
+			return ArgsError{Msg: msg, Args: []string{"max", strconv.Itoa(max)}}
+		}
+
+		return nil
+	}
+}
+
+// OneOf validates that the value is one of the values. Values of other types than string are compared by their string
+// representation (fmt.Sprint). Empty strings are ignored. The error is an ArgsError with the argument values (comma-separated).
+func OneOf(values []string, msgs ...string) Func {
+	msg := "harmony.error.validation.one-of"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	allowed := make(map[string]bool, len(values))
+	for _, v := range values {
+		allowed[v] = true
+	}
+
+	return func(value any) error {
+		str, ok := value.(string)
+		if !ok {
+			str = fmt.Sprint(value)
+		}
+
+		if ok && str == "" {
+			return nil
+		}
+
+		if !allowed[str] {
+			return ArgsError{Msg: msg, Args: []string{"values", strings.Join(values, ", ")}}
+		}
+
+		return nil
+	}
+}
+
+// Alpha validates that the value only contains letters, including non-ASCII letters like umlauts.
+// Empty values are ignored, non-string values return a validation error.
+func Alpha(msgs ...string) Func {
+	msg := "harmony.error.validation.alpha"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return runeValidator(msg, unicode.IsLetter)
+}
+
+// Alphanumeric validates that the value only contains letters and digits, including non-ASCII letters like umlauts.
+// Empty values are ignored, non-string values return a validation error.
+func Alphanumeric(msgs ...string) Func {
+	msg := "harmony.error.validation.alphanumeric"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return runeValidator(msg, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	})
+}
+
+// DateTime validates that the value is a date or time of the Go time layout, e.g. 2006-01-02.
+// Empty values are ignored, non-string values return a validation error. The error is an ArgsError with the argument layout.
+func DateTime(layout string, msgs ...string) Func {
+	msg := "harmony.error.validation.datetime"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return func(value any) error {
+		str, ok := value.(string)
+		if ok && str == "" {
+			return nil
+		}
+
+		if !ok {
+			return ArgsError{Msg: msg, Args: []string{"layout", layout}}
+		}
+
+		if _, err := time.Parse(layout, str); err != nil {
+			return ArgsError{Msg: msg, Args: []string{"layout", layout}}
+		}
+
+		return nil
+	}
+}
+
+// JSON validates that the value is valid JSON. Empty values are ignored, values other than strings,
+// byte slices and json.RawMessage return a validation error.
+func JSON(msgs ...string) Func {
+	msg := "harmony.error.validation.json"
+	if len(msgs) > 0 && msgs[0] != "" {
+		msg = msgs[0]
+	}
+
+	return func(value any) error {
+		var data []byte
+		switch v := value.(type) {
+		case string:
+			data = []byte(v)
+		case []byte:
+			data = v
+		case json.RawMessage:
+			data = v
+		default:
+			return errors.New(msg)
+		}
+
+		if len(data) == 0 {
+			return nil
+		}
+
+		if !json.Valid(data) {
+			return errors.New(msg)
+		}
+
+		return nil
+	}
+}
+
+// runeValidator returns a Func validating that all runes of a string are valid. Empty values are ignored, non-string values return a validation error.
+func runeValidator(msg string, valid func(rune) bool) Func {
+	return func(value any) error {
+		str, ok := value.(string)
+		if !ok {
+			return errors.New(msg)
+		}
+
+		for _, r := range str {
+			if !valid(r) {
+				return errors.New(msg)
+			}
+		}
+
+		return nil
+	}
+}
+
+// number returns the value of integer, unsigned integer and float values as float64.
+func number(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// length returns the length of strings in characters and of slices, arrays and maps.
+func length(value any) (int, bool) {
+	if str, ok := value.(string); ok {
+		return utf8.RuneCountInString(str), true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), true
+	default:
+		return 0, false
+	}
+}
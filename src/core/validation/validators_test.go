@@ -1,10 +1,13 @@
 package validation_test
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRequired(t *testing.T) {
@@ -160,3 +163,73 @@ func TestSemanticVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestURL(t *testing.T) {
+	assertValid(t, validation.URL(), map[any]bool{
+		"https://example.com/path?q=1": true,
+		"http://localhost:8080":        true,
+		"":                             true,
+		"ftp://example.com":            false,
+		"example.com":                  false,
+		"https://":                     false,
+		42:                             false,
+	})
+}
+
+func TestUUID(t *testing.T) {
+	assertValid(t, validation.UUID(), map[any]bool{
+		"0b6a2c39-6b5e-4c6a-9a3f-0c3c5c3b1a11": true,
+		uuid.New():                             true,
+		"":                                     true,
+		"0b6a2c39":                             false,
+		42:                                     false,
+	})
+}
+
+func TestMinMax(t *testing.T) {
+	assertValid(t, validation.Min(1), map[any]bool{1: true, 2.5: true, uint8(3): true, 0: false, -1.5: false, "2": false})
+	assertValid(t, validation.Max(10), map[any]bool{10: true, -3: true, 10.5: false, int64(11): false, "2": false})
+
+	var argsErr validation.ArgsError
+	require.ErrorAs(t, validation.Min(1.5)(0), &argsErr)
+	assert.Equal(t, []string{"min", "1.5"}, argsErr.Args)
+}
+
+func TestMinMaxLength(t *testing.T) {
+	assertValid(t, validation.MinLength(3), map[any]bool{"abc": true, "äöü": true, "": true, "ab": false, 3: false})
+	assertValid(t, validation.MaxLength(3), map[any]bool{"abc": true, "äöü": true, "": true, "abcd": false, 3: false})
+
+	assert.NoError(t, validation.MinLength(2)([]string{"a", "b"}))
+	assert.Error(t, validation.MinLength(2)([]string{"a"}))
+	assert.Error(t, validation.MaxLength(1)(map[string]int{"a": 1, "b": 2}))
+}
+
+func TestOneOf(t *testing.T) {
+	assertValid(t, validation.OneOf([]string{"draft", "done", "3"}), map[any]bool{"draft": true, "": true, 3: true, "review": false, 4: false})
+}
+
+func TestAlpha(t *testing.T) {
+	assertValid(t, validation.Alpha(), map[any]bool{"Anforderung": true, "Größe": true, "": true, "abc1": false, "a b": false, 1: false})
+	assertValid(t, validation.Alphanumeric(), map[any]bool{"Anforderung1": true, "Größe2": true, "": true, "a-1": false, 1: false})
+}
+
+func TestDateTime(t *testing.T) {
+	assertValid(t, validation.DateTime("2006-01-02"), map[any]bool{"2024-02-29": true, "": true, "2023-02-29": false, "29.02.2024": false, 1: false})
+}
+
+func TestJSON(t *testing.T) {
+	assertValid(t, validation.JSON(), map[any]bool{`{"a": [1, 2]}`: true, "": true, `{"a": }`: false, 1: false})
+	assert.NoError(t, validation.JSON()([]byte(`[]`)))
+	assert.Error(t, validation.JSON()(json.RawMessage(`{`)))
+}
+
+func assertValid(t *testing.T, validator validation.Func, tests map[any]bool) {
+	for value, valid := range tests {
+		err := validator(value)
+		if valid {
+			assert.NoError(t, err, value)
+		} else {
+			assert.Error(t, err, value)
+		}
+	}
+}
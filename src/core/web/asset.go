@@ -0,0 +1,113 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// fingerprintLen is the number of hex characters of the content hash inserted into fingerprinted file names.
+const fingerprintLen = 10
+
+// ImmutableCacheControl is the Cache-Control header of fingerprinted assets. Their content never changes
+// as any change results in a new file name, therefore they can be cached by clients for a year.
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// AssetManifest maps logical asset names (e.g. "js/eiffel.js") to fingerprinted names containing
+// a hash of the file's content (e.g. "js/eiffel.3f2a1b9c0d.js"). Fingerprinted names change whenever the
+// content changes, which allows clients to cache the assets indefinitely without serving stale files after a deployment.
+// The fingerprinted files do not exist on disk, the file server resolves them to the logical file through the manifest.
+//
+// AssetManifest is safe for concurrent use by multiple goroutines as it is not modified after its creation.
+type AssetManifest struct {
+	assets       map[string]string
+	fingerprints map[string]string
+}
+
+// NewAssetManifest creates an AssetManifest of all files in the root directory and its subdirectories.
+func NewAssetManifest(root string) (*AssetManifest, error) {
+	m := &AssetManifest{
+		assets:       make(map[string]string),
+		fingerprints: make(map[string]string),
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		hash, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+
+		m.add(filepath.ToSlash(name), hash)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Path returns the fingerprinted name of the asset. Names of assets unknown to the manifest are returned unchanged.
+// The manifest may be nil in which case the name is always returned unchanged.
+func (m *AssetManifest) Path(name string) string {
+	if m == nil {
+		return name
+	}
+
+	if fingerprinted, ok := m.assets[name]; ok {
+		return fingerprinted
+	}
+
+	return name
+}
+
+// Resolve returns the logical name of a fingerprinted name and true.
+// If the name is not a fingerprinted name of the manifest, it returns an empty string and false.
+func (m *AssetManifest) Resolve(fingerprinted string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+
+	name, ok := m.fingerprints[fingerprinted]
+
+	return name, ok
+}
+
+// add adds the asset to the manifest inserting the hash before the file extension: "js/app.js" => "js/app.<hash>.js".
+func (m *AssetManifest) add(name, hash string) {
+	ext := path.Ext(name)
+	fingerprinted := name[:len(name)-len(ext)] + "." + hash + ext
+
+	m.assets[name] = fingerprinted
+	m.fingerprints[fingerprinted] = name
+}
+
+// hashFile returns the first fingerprintLen hex characters of the SHA-256 hash of the file's content.
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:fingerprintLen], nil
+}
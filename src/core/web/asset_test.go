@@ -0,0 +1,73 @@
+package web
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestAssetManifest(t *testing.T) {
+	assetsDir := setupAssetsDirectory(t)
+	require.NoError(t, os.Mkdir(filepath.Join(assetsDir, "js"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(assetsDir, "js", "app.min.js"), []byte("app"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(assetsDir, "LICENSE"), []byte("license"), 0644))
+
+	manifest, err := NewAssetManifest(assetsDir)
+	require.NoError(t, err)
+
+	fingerprinted := manifest.Path("js/app.min.js")
+	assert.Regexp(t, regexp.MustCompile(`^js/app\.min\.[0-9a-f]{10}\.js$`), fingerprinted)
+	assert.Regexp(t, regexp.MustCompile(`^LICENSE\.[0-9a-f]{10}$`), manifest.Path("LICENSE"))
+	assert.Equal(t, "unknown.js", manifest.Path("unknown.js"))
+
+	name, ok := manifest.Resolve(fingerprinted)
+	assert.True(t, ok)
+	assert.Equal(t, "js/app.min.js", name)
+
+	_, ok = manifest.Resolve("js/app.min.js")
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(filepath.Join(assetsDir, "js", "app.min.js"), []byte("changed"), 0644))
+	changed, err := NewAssetManifest(assetsDir)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprinted, changed.Path("js/app.min.js"))
+	assert.Equal(t, manifest.Path("test.js"), changed.Path("test.js"))
+
+	var nilManifest *AssetManifest
+	assert.Equal(t, "test.js", nilManifest.Path("test.js"))
+	_, ok = nilManifest.Resolve("test.js")
+	assert.False(t, ok)
+
+	asset := templateFuncs(&UICfg{AssetsUri: "/assets"}, WithAssetManifest(manifest))["asset"].(func(string) string)
+	assert.Equal(t, "/assets/"+fingerprinted, asset("js/app.min.js"))
+}
+
+func TestMountFileServerFingerprints(t *testing.T) {
+	assetsDir := setupAssetsDirectory(t)
+	manifest, err := NewAssetManifest(assetsDir)
+	require.NoError(t, err)
+
+	r := NewRouter()
+	MountFileServer(r, &FileServerCfg{Root: assetsDir, Route: "/static"}, WithFingerprints(manifest))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest("GET", "/static/"+manifest.Path("test.js"), nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "console.log('test');")
+	assert.Equal(t, ImmutableCacheControl, recorder.Header().Get("Cache-Control"))
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest("GET", "/static/test.js", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "console.log('test');")
+	assert.Empty(t, recorder.Header().Get("Cache-Control"))
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest("GET", "/static/test.0123456789.js", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,309 @@
+package web
+
+import (
+	"bytes"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheMaxEntries is the default maximum number of responses held by the MemoryCacheStore.
+const DefaultCacheMaxEntries = 1000
+
+// MaxCachedBodySize is the maximum size in bytes of a response body to be cached. Larger responses are not cached.
+const MaxCachedBodySize = 1 << 20
+
+// CacheHeader is the response header indicating whether the response was served from the cache (HIT) or not (MISS).
+const CacheHeader = "X-Cache"
+
+// CacheCfg is the config for the response cache.
+type CacheCfg struct {
+	// Disabled disables the response cache entirely, e.g. during development of templates.
+	Disabled bool `toml:"disabled" env:"CACHE_DISABLED"`
+	// MaxEntries is the maximum number of cached responses. Defaults to DefaultCacheMaxEntries.
+	MaxEntries int `toml:"max_entries"`
+}
+
+// CachePolicy defines how the responses of a route are cached by the Cache middleware.
+// Responses are cached per method and URL. VaryHeaders and VaryCookies add the values of the request headers
+// and cookies to the cache key, e.g. to cache a page per locale or separately for HTMX requests (fragments).
+// Skip can be used to bypass the cache for certain requests, e.g. requests of logged-in users.
+type CachePolicy struct {
+	TTL         time.Duration
+	VaryHeaders []string
+	VaryCookies []string
+	Skip        func(r *http.Request) bool
+}
+
+// CachedResponse is a response stored in the CacheStore.
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// CacheStore stores cached responses by key. Expired responses must not be returned by Get.
+// A CacheStore is expected to be safe for concurrent use by multiple goroutines.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)                      // Get returns the response of the key if it exists and has not expired yet.
+	Set(key string, response *CachedResponse, ttl time.Duration) // Set stores the response under the key for the duration of the ttl.
+}
+
+// MemoryCacheStore is an in-memory CacheStore holding at most a maximum number of responses.
+// If the store is full, expired responses are evicted first, then the responses expiring next.
+type MemoryCacheStore struct {
+	entries    map[string]memoryCacheEntry
+	maxEntries int
+	mu         sync.Mutex
+	now        func() time.Time
+}
+
+type memoryCacheEntry struct {
+	response *CachedResponse
+	expires  time.Time
+}
+
+// cacheRecorder passes the response through to the client while recording it for the cache.
+type cacheRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+// NewCacheStore returns the CacheStore for the config. Without a config, a MemoryCacheStore with
+// DefaultCacheMaxEntries is returned. If the cache is disabled, nil is returned which disables the Cache middleware.
+func NewCacheStore(cfg *CacheCfg) CacheStore {
+	if cfg == nil {
+		return NewMemoryCacheStore(DefaultCacheMaxEntries)
+	}
+
+	if cfg.Disabled {
+		return nil
+	}
+
+	return NewMemoryCacheStore(cfg.MaxEntries)
+}
+
+// NewMemoryCacheStore creates a new MemoryCacheStore. If maxEntries is <= 0, DefaultCacheMaxEntries is used.
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+
+	return &MemoryCacheStore{
+		entries:    make(map[string]memoryCacheEntry),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Get returns the response of the key if it exists and has not expired yet. Expired responses are removed.
+func (s *MemoryCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !s.now().Before(entry.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+
+	return entry.response, true
+}
+
+// Set stores the response under the key for the duration of the ttl. A response with a ttl <= 0 is not stored.
+func (s *MemoryCacheStore) Set(key string, response *CachedResponse, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= s.maxEntries {
+		s.evict()
+	}
+
+	s.entries[key] = memoryCacheEntry{response: response, expires: s.now().Add(ttl)}
+}
+
+// Len returns the number of responses in the store including expired responses that have not been evicted yet.
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// evict removes all expired responses. If none expired, the response expiring next is removed.
+// evict expects the caller to hold the lock.
+func (s *MemoryCacheStore) evict() {
+	now := s.now()
+	next := ""
+	var nextExpires time.Time
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, key)
+			continue
+		}
+
+		if next == "" || entry.expires.Before(nextExpires) {
+			next = key
+			nextExpires = entry.expires
+		}
+	}
+
+	if len(s.entries) >= s.maxEntries && next != "" {
+		delete(s.entries, next)
+	}
+}
+
+// PageCachePolicy returns a CachePolicy for pages caching full pages and HTMX fragments separately.
+// The pages are cached per locale and theme as both are part of the rendered page.
+// The Accept header is considered as well, as pages may be served as JSON (see IO.RespondNegotiated).
+// Skip should bypass the cache for requests rendering user specific content, e.g. if the user is logged in.
+func PageCachePolicy(ttl time.Duration, skip func(r *http.Request) bool) CachePolicy {
+	return CachePolicy{
+		TTL:         ttl,
+		VaryHeaders: []string{HXRequestHeader, "Accept"},
+		VaryCookies: []string{trans.LocaleSessionKey, ThemeCookieName},
+		Skip:        skip,
+	}
+}
+
+// Cache is a middleware caching the responses of GET requests in the store according to the policy.
+// Only successful responses (200) without cookies and without a Cache-Control header forbidding caching
+// (no-store, private) up to MaxCachedBodySize are cached. The CacheHeader indicates if the response was served from the cache.
+// If the store is nil, caching is disabled and the middleware passes all requests on to the next handler.
+//
+// Cache is meant for pages that do not depend on the user, it should therefore be applied to the routes directly:
+//
+//	router.With(web.Cache(webCtx.Cache, web.PageCachePolicy(5*time.Minute, isLoggedIn))).Get("/", ...)
+func Cache(store CacheStore, policy CachePolicy) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || (policy.Skip != nil && policy.Skip(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := CacheKey(r, policy)
+			if cached, ok := store.Get(key); ok {
+				writeCachedResponse(w, cached)
+				return
+			}
+
+			w.Header().Set(CacheHeader, "MISS")
+			recorder := &cacheRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			if !recorder.cacheable() {
+				return
+			}
+
+			store.Set(key, &CachedResponse{
+				Status: recorder.status,
+				Header: w.Header().Clone(),
+				Body:   bytes.Clone(recorder.body.Bytes()),
+			}, policy.TTL)
+		})
+	}
+}
+
+// CacheKey returns the key of the request in the CacheStore according to the policy.
+// It consists of the method, the URL (path and query), the tenant of the request (see tenant.ID)
+// and the values of the policy's vary headers and cookies.
+func CacheKey(r *http.Request, policy CachePolicy) string {
+	var b strings.Builder
+
+	b.WriteString(r.Method + " " + r.URL.RequestURI())
+	b.WriteString("|t:" + tenant.ID(r.Context()))
+
+	for _, header := range policy.VaryHeaders {
+		b.WriteString("|h:" + header + "=" + r.Header.Get(header))
+	}
+
+	for _, name := range policy.VaryCookies {
+		value := ""
+		if cookie, err := r.Cookie(name); err == nil {
+			value = cookie.Value
+		}
+
+		b.WriteString("|c:" + name + "=" + value)
+	}
+
+	return b.String()
+}
+
+// WriteHeader records the status code and passes it on.
+func (r *cacheRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the body up to MaxCachedBodySize and passes it on.
+func (r *cacheRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	if !r.overflow {
+		if r.body.Len()+len(b) > MaxCachedBodySize {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, it is used by the http.ResponseController.
+func (r *cacheRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// cacheable returns true if the recorded response may be cached.
+func (r *cacheRecorder) cacheable() bool {
+	if r.status != http.StatusOK || r.overflow {
+		return false
+	}
+
+	header := r.Header()
+	if len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+
+	cacheControl := strings.ToLower(header.Get("Cache-Control"))
+
+	return !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private")
+}
+
+// writeCachedResponse writes the cached response to the client.
+func writeCachedResponse(w http.ResponseWriter, cached *CachedResponse) {
+	header := w.Header()
+	for name, values := range cached.Header {
+		header[name] = append([]string(nil), values...)
+	}
+
+	header.Set(CacheHeader, "HIT")
+	w.WriteHeader(cached.Status)
+	_, _ = w.Write(cached.Body)
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/tenant"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheMiddleware(t *testing.T) {
+	calls := 0
+	handler := Cache(NewMemoryCacheStore(10), PageCachePolicy(time.Minute, func(r *http.Request) bool {
+		return r.Header.Get("X-User") != ""
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprintf(w, "page %d", calls)
+	}))
+
+	serve := func(modify func(r *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if modify != nil {
+			modify(req)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(nil)
+	assert.Equal(t, "page 1", rec.Body.String())
+	assert.Equal(t, "MISS", rec.Header().Get(CacheHeader))
+
+	rec = serve(nil)
+	assert.Equal(t, "page 1", rec.Body.String())
+	assert.Equal(t, "HIT", rec.Header().Get(CacheHeader))
+	assert.Equal(t, "text/html", rec.Header().Get("Content-Type"))
+
+	rec = serve(func(r *http.Request) { r.Header.Set("HX-Request", "true") })
+	assert.Equal(t, "page 2", rec.Body.String(), "HTMX fragments are cached separately")
+
+	rec = serve(func(r *http.Request) { r.AddCookie(&http.Cookie{Name: trans.LocaleSessionKey, Value: "en"}) })
+	assert.Equal(t, "page 3", rec.Body.String(), "pages are cached per locale")
+
+	rec = serve(func(r *http.Request) { r.AddCookie(&http.Cookie{Name: ThemeCookieName, Value: "dark"}) })
+	assert.Equal(t, "page 4", rec.Body.String(), "pages are cached per theme")
+
+	rec = serve(func(r *http.Request) { r.Header.Set("X-User", "logged-in") })
+	assert.Equal(t, "page 5", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(CacheHeader), "skipped requests bypass the cache")
+
+	rec = serve(func(r *http.Request) { r.Method = http.MethodPost })
+	assert.Equal(t, "page 6", rec.Body.String())
+
+	rec = serve(nil)
+	assert.Equal(t, "page 1", rec.Body.String())
+}
+
+func TestCacheMiddlewareUncacheableResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"error status", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "error", http.StatusInternalServerError)
+		}},
+		{"cookie", func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "123"})
+			_, _ = w.Write([]byte("ok"))
+		}},
+		{"no-store", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte("ok"))
+		}},
+		{"too large", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(make([]byte, MaxCachedBodySize))
+			_, _ = w.Write([]byte("ok"))
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewMemoryCacheStore(10)
+			handler := Cache(store, CachePolicy{TTL: time.Minute})(test.handler)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, 0, store.Len())
+		})
+	}
+}
+
+func TestCacheMiddlewareDisabled(t *testing.T) {
+	assert.Nil(t, NewCacheStore(&CacheCfg{Disabled: true}))
+
+	calls := 0
+	handler := Cache(nil, CachePolicy{TTL: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryCacheStore(2)
+	store.now = func() time.Time { return now }
+
+	store.Set("short", &CachedResponse{Body: []byte("short")}, time.Second)
+	store.Set("long", &CachedResponse{Body: []byte("long")}, time.Minute)
+	store.Set("ignored", &CachedResponse{}, 0)
+
+	cached, ok := store.Get("short")
+	require.True(t, ok)
+	assert.Equal(t, "short", string(cached.Body))
+	assert.Equal(t, 2, store.Len())
+
+	store.Set("new", &CachedResponse{Body: []byte("new")}, time.Minute)
+	assert.Equal(t, 2, store.Len())
+	_, ok = store.Get("short")
+	assert.False(t, ok, "the response expiring next is evicted if the store is full")
+
+	now = now.Add(2 * time.Minute)
+	_, ok = store.Get("long")
+	assert.False(t, ok, "expired responses are not returned")
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestCacheKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/page?q=1", nil)
+	req.Header.Set("HX-Request", "true")
+	req.AddCookie(&http.Cookie{Name: "locale", Value: "de"})
+
+	key := CacheKey(req, CachePolicy{VaryHeaders: []string{"HX-Request"}, VaryCookies: []string{"locale", "missing"}})
+	assert.Equal(t, "GET /page?q=1|t:default|h:HX-Request=true|c:locale=de|c:missing=", key)
+
+	req = req.WithContext(tenant.WithTenant(req.Context(), &tenant.Tenant{ID: "uni-a"}))
+	key = CacheKey(req, CachePolicy{})
+	assert.Equal(t, "GET /page?q=1|t:uni-a", key)
+}
@@ -0,0 +1,221 @@
+package web
+
+import (
+	"errors"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// CommandsRoute is the route of the command palette's endpoint (see CommandsController).
+	CommandsRoute = "/commands"
+	// CommandQueryParam is the query parameter the commands are filtered by.
+	CommandQueryParam = "q"
+	// MaxCommands is the maximum number of commands returned by the CommandsController.
+	MaxCommands = 50
+	// NavigationCommandGroup is the group of the commands built from the navigation.
+	NavigationCommandGroup = "harmony.command.group.navigation"
+)
+
+// Command is an action of the command palette, e.g. opening a page or switching the template in use.
+// The Title and Group are translation keys, TitleArgs are passed to the translation of the title as key value pairs.
+// Commands are sorted by their Position, a command with a lower Position is listed first.
+// Keywords are matched against the query in addition to the translated title and group.
+type Command struct {
+	Title     string
+	TitleArgs []string
+	Group     string
+	URL       string
+	// Redirect is true if the client should navigate to the URL with a full page load instead of an HTMX boosted request.
+	Redirect bool
+	// Shortcut is the keyboard shortcut of the command displayed in the palette, e.g. Alt + F.
+	Shortcut string
+	Keywords []string
+	Position int
+}
+
+// CommandProvider returns the commands a module contributes to the command palette for the current request.
+// The query is passed to allow providers to search for commands, e.g. templates by their name.
+// The returned commands are filtered by the query afterward, providers are therefore not required to filter their commands.
+type CommandProvider func(io IO, query string) ([]Command, error)
+
+// Commands is a collection of CommandProviders. Together with the Navigation it makes up the commands of the command palette.
+// Modules add their providers by name through Commands.Add.
+//
+// Commands is safe for concurrent use by multiple goroutines.
+type Commands struct {
+	providers map[string]CommandProvider
+	mu        sync.RWMutex
+}
+
+// CommandResult is a translated command as returned by the CommandsController.
+type CommandResult struct {
+	Title    string `json:"title"`
+	Group    string `json:"group"`
+	URL      string `json:"url"`
+	Redirect bool   `json:"redirect"`
+	Shortcut string `json:"shortcut,omitempty"`
+}
+
+// CommandsResponse is the JSON response of the CommandsController.
+type CommandsResponse struct {
+	Query    string          `json:"query"`
+	Commands []CommandResult `json:"commands"`
+}
+
+// NewCommands returns a new Commands collection with an empty but allocated map of CommandProviders.
+func NewCommands() *Commands {
+	return &Commands{
+		providers: make(map[string]CommandProvider),
+	}
+}
+
+// Add adds a CommandProvider by name. A provider with the same name is replaced.
+func (c *Commands) Add(name string, provider CommandProvider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.providers[name] = provider
+}
+
+// Remove removes the CommandProvider by name.
+func (c *Commands) Remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.providers, name)
+}
+
+// Build returns the commands of all providers for the current request sorted by their Position.
+// The providers are called in alphabetical order of their names. If a provider fails, Build returns its error
+// together with the commands of the other providers. This allows the command palette to work despite a failing module.
+func (c *Commands) Build(io IO, query string) ([]Command, error) {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.providers))
+	providers := make(map[string]CommandProvider, len(c.providers))
+	for name, provider := range c.providers {
+		names = append(names, name)
+		providers[name] = provider
+	}
+	c.mu.RUnlock()
+
+	sort.Strings(names)
+
+	var commands []Command
+	var errs []error
+	for _, name := range names {
+		provided, err := providers[name](io, query)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		commands = append(commands, provided...)
+	}
+
+	sortCommands(commands)
+
+	return commands, errors.Join(errs...)
+}
+
+// NavigationCommands returns a command for each item of the built navigation that has a URL.
+// Sub items are included, their group is the name of their parent item. Groups without a URL are skipped.
+func NavigationCommands(items []NavItem) []Command {
+	return navigationCommands(items, NavigationCommandGroup)
+}
+
+// CommandsController returns the commands of the command palette as JSON. The commands are made up of the navigation
+// items displayed to the current user (see NavigationCommands) followed by the commands of the web.Ctx's Commands.
+// The commands are filtered by the query parameter CommandQueryParam: each whitespace separated term of the query has to be
+// contained in the translated title, group or keywords of the command (case-insensitive). At most MaxCommands are returned.
+// Failing command providers are logged, the commands of the remaining providers are returned anyway.
+func CommandsController(appCtx *hctx.AppCtx, webCtx *Ctx) http.Handler {
+	return NewController(appCtx, webCtx, func(io IO) error {
+		query := strings.TrimSpace(io.Request().URL.Query().Get(CommandQueryParam))
+
+		navigation, err := webCtx.Navigation.Build(io)
+		if err != nil {
+			return err
+		}
+
+		provided, err := webCtx.Commands.Build(io, query)
+		if err != nil {
+			appCtx.Error(Pkg, "failed to build commands of a command provider", err, "query", query)
+		}
+
+		commands := append(NavigationCommands(navigation), provided...)
+		results := FilterCommands(io, commands, query, MaxCommands)
+
+		return io.JSON(CommandsResponse{Query: query, Commands: results})
+	})
+}
+
+// FilterCommands translates the commands and returns at most limit commands matching the query.
+// A command matches if each whitespace separated term of the query is contained in the command's translated title,
+// translated group or keywords. The comparison is case-insensitive. An empty query matches all commands.
+func FilterCommands(io IO, commands []Command, query string, limit int) []CommandResult {
+	translator := io.Translator()
+	terms := strings.Fields(strings.ToLower(query))
+
+	results := make([]CommandResult, 0, min(len(commands), limit))
+	for _, command := range commands {
+		if len(results) >= limit {
+			break
+		}
+
+		result := CommandResult{
+			Title:    translator.Tf(command.Title, command.TitleArgs...),
+			Group:    translator.T(command.Group),
+			URL:      command.URL,
+			Redirect: command.Redirect,
+			Shortcut: command.Shortcut,
+		}
+
+		searchable := strings.ToLower(strings.Join(append([]string{result.Title, result.Group}, command.Keywords...), " "))
+		if !containsAll(searchable, terms) {
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func navigationCommands(items []NavItem, group string) []Command {
+	var commands []Command
+	for _, item := range items {
+		if item.URL != "" {
+			commands = append(commands, Command{
+				Title:    item.Name,
+				Group:    group,
+				URL:      item.URL,
+				Redirect: item.Redirect,
+				Position: item.Position,
+			})
+		}
+
+		commands = append(commands, navigationCommands(item.Items, item.Name)...)
+	}
+
+	return commands
+}
+
+func containsAll(s string, terms []string) bool {
+	for _, term := range terms {
+		if !strings.Contains(s, term) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortCommands(commands []Command) {
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].Position < commands[j].Position
+	})
+}
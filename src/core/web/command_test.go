@@ -0,0 +1,104 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCommandsBuild(t *testing.T) {
+	commands := NewCommands()
+	commands.Add("b", func(io IO, query string) ([]Command, error) {
+		return []Command{{Title: "b.second", Position: 2}, {Title: "b.first", Position: 1}}, nil
+	})
+	commands.Add("a", func(io IO, query string) ([]Command, error) {
+		return []Command{{Title: "a.query", Keywords: []string{query}, Position: 2}}, nil
+	})
+	commands.Add("failing", func(io IO, query string) ([]Command, error) {
+		return nil, errors.New("failed")
+	})
+
+	built, err := commands.Build(newMockIO("/"), "foo")
+	assert.Error(t, err)
+	require.Len(t, built, 3)
+	assert.Equal(t, "b.first", built[0].Title)
+	assert.Equal(t, "a.query", built[1].Title)
+	assert.Equal(t, []string{"foo"}, built[1].Keywords)
+	assert.Equal(t, "b.second", built[2].Title)
+
+	commands.Remove("failing")
+	_, err = commands.Build(newMockIO("/"), "")
+	assert.NoError(t, err)
+}
+
+func TestNavigationCommands(t *testing.T) {
+	commands := NavigationCommands([]NavItem{
+		{Name: "home", URL: "/", Position: 0},
+		{Name: "group", Position: 10, Items: []NavItem{
+			{Name: "group.logout", URL: "/logout", Redirect: true, Position: 20},
+		}},
+	})
+
+	assert.Equal(t, []Command{
+		{Title: "home", Group: NavigationCommandGroup, URL: "/", Position: 0},
+		{Title: "group.logout", Group: "group", URL: "/logout", Redirect: true, Position: 20},
+	}, commands)
+}
+
+func TestFilterCommands(t *testing.T) {
+	commands := []Command{
+		{Title: "Open Template Set", Group: "sets", URL: "/set"},
+		{Title: "New Requirement", Group: "eiffel", URL: "/eiffel", Keywords: []string{"elicitation"}},
+		{Title: "Switch Template", Group: "eiffel", URL: "/eiffel/1"},
+	}
+	io := newMockIO("/")
+
+	assert.Len(t, FilterCommands(io, commands, "", 10), 3)
+	assert.Len(t, FilterCommands(io, commands, "", 2), 2)
+	assert.Len(t, FilterCommands(io, commands, "TEMPLATE", 10), 2)
+	assert.Len(t, FilterCommands(io, commands, "eiffel template", 10), 1)
+	assert.Len(t, FilterCommands(io, commands, "elicitation", 10), 1)
+	assert.Empty(t, FilterCommands(io, commands, "nothing", 10))
+}
+
+func TestCommandsController(t *testing.T) {
+	appCtx, webCtx := setupMockCtxs(t)
+
+	webCtx.Navigation.Add("home", NavItem{Name: "harmony.menu.home", URL: "/", Position: 0})
+	webCtx.Navigation.Add("hidden", NavItem{Name: "harmony.menu.hidden", URL: "/hidden", Display: func(io IO) (bool, error) {
+		return false, nil
+	}})
+	webCtx.Commands.Add("test", func(io IO, query string) ([]Command, error) {
+		return []Command{{Title: "test.command", Group: "test", URL: "/test", Shortcut: "Alt + T"}}, nil
+	})
+	webCtx.Commands.Add("failing", func(io IO, query string) ([]Command, error) {
+		return nil, errors.New("failed")
+	})
+
+	serve := func(target string) CommandsResponse {
+		recorder := httptest.NewRecorder()
+		CommandsController(appCtx, webCtx).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+		var response CommandsResponse
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+
+		return response
+	}
+
+	response := serve(CommandsRoute)
+	assert.Equal(t, []CommandResult{
+		{Title: "harmony.menu.home", Group: NavigationCommandGroup, URL: "/"},
+		{Title: "test.command", Group: "test", URL: "/test", Shortcut: "Alt + T"},
+	}, response.Commands)
+
+	response = serve(CommandsRoute + "?q=test")
+	assert.Equal(t, "test", response.Query)
+	require.Len(t, response.Commands, 1)
+	assert.Equal(t, "/test", response.Commands[0].URL)
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/util"
+)
+
+var (
+	// ErrDependencyNotFound is returned if the request has no dependency of the requested name.
+	ErrDependencyNotFound = errors.New("dependency not found")
+	// ErrDependencyType is returned if a dependency does not have the requested type.
+	ErrDependencyType = errors.New("dependency has unexpected type")
+)
+
+// dependenciesKey is the context key of the request-scoped dependencies, see WithDependency.
+type dependenciesKey struct{}
+
+// WithDependency returns a copy of the context providing the dependency under the name for the request.
+// Request-scoped dependencies take precedence over the application's repositories of the same name (see IO.Repository).
+// Tests can therefore inject fakes per request and middlewares can provide request-bound services.
+// The dependencies of the parent context are not modified.
+func WithDependency(ctx context.Context, name string, dependency any) context.Context {
+	parent, _ := ctx.Value(dependenciesKey{}).(map[string]any)
+
+	deps := make(map[string]any, len(parent)+1)
+	for n, d := range parent {
+		deps[n] = d
+	}
+	deps[name] = dependency
+
+	return context.WithValue(ctx, dependenciesKey{}, deps)
+}
+
+// Dependency returns the request-scoped dependency of the name typed as T, see WithDependency.
+// It returns ErrDependencyNotFound if the request has no dependency of the name and ErrDependencyType if it is not a T.
+func Dependency[T any](io IO, name string) (T, error) {
+	var zero T
+
+	deps, _ := io.Context().Value(dependenciesKey{}).(map[string]any)
+	dependency, ok := deps[name]
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrDependencyNotFound, name)
+	}
+
+	typed, ok := dependency.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is %T", ErrDependencyType, name, dependency)
+	}
+
+	return typed, nil
+}
+
+// Repository returns the repository of the name typed as T through IO.Repository.
+// It returns ErrDependencyType if the repository is not a T.
+//
+// Example:
+//
+//	templateRepository, err := web.Repository[template.Repository](io, template.RepositoryName)
+func Repository[T any](io IO, name string) (T, error) {
+	var zero T
+
+	repository, err := io.Repository(name)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := repository.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is %T", ErrDependencyType, name, repository)
+	}
+
+	return typed, nil
+}
+
+// MustRepository is like Repository but panics if the repository can not be resolved.
+// Repositories are registered on startup, a missing repository is a misconfiguration and not a runtime error.
+func MustRepository[T any](io IO, name string) T {
+	return util.Unwrap(Repository[T](io, name))
+}
+
+// Repository returns the repository of the name. A request-scoped dependency of the name (see WithDependency)
+// takes precedence over the repository registered on the application context.
+func (io *HIO) Repository(name string) (persistence.Repository, error) {
+	repository, err := Dependency[persistence.Repository](io, name)
+	if err == nil {
+		return repository, nil
+	}
+	if errors.Is(err, ErrDependencyType) {
+		return nil, err
+	}
+
+	return io.appCtx.Repository(name)
+}
+
+// Translator returns the translator of the request set by the trans.Middleware.
+// Without a translator in the request's context a translator returning the keys untranslated is returned.
+func (io *HIO) Translator() trans.Translator {
+	translator, ok := util.CtxValue[trans.Translator](io.Context(), trans.TranslatorContextKey)
+	if !ok || translator == nil {
+		return trans.NewTranslator()
+	}
+
+	return translator
+}
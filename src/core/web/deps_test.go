@@ -0,0 +1,115 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+var errRepositoryNotFound = errors.New("repository not found")
+
+type fakeRepository struct {
+	name string
+}
+
+type otherRepository struct{}
+
+type fakeRepositoryProvider struct {
+	repositories map[string]persistence.Repository
+}
+
+func (r *fakeRepository) RepositoryName() string {
+	return "FakeRepository"
+}
+
+func (r *otherRepository) RepositoryName() string {
+	return "FakeRepository"
+}
+
+func (p *fakeRepositoryProvider) Repository(name string) (persistence.Repository, error) {
+	repository, ok := p.repositories[name]
+	if !ok {
+		return nil, errRepositoryNotFound
+	}
+
+	return repository, nil
+}
+
+func (p *fakeRepositoryProvider) RegisterRepository(init func(db any) (persistence.Repository, error)) error {
+	return nil
+}
+
+func TestIORepository(t *testing.T) {
+	appCtx, webCtx := setupMockCtxs(t)
+	appCtx.Repositories = &fakeRepositoryProvider{repositories: map[string]persistence.Repository{
+		"FakeRepository": &fakeRepository{name: "app"},
+	}}
+
+	serve := func(ctx context.Context, handler func(io IO) error) {
+		request := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+		NewController(appCtx, webCtx, handler).ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	serve(context.Background(), func(io IO) error {
+		repository, err := Repository[*fakeRepository](io, "FakeRepository")
+		require.NoError(t, err)
+		assert.Equal(t, "app", repository.name)
+
+		_, err = Repository[*otherRepository](io, "FakeRepository")
+		assert.ErrorIs(t, err, ErrDependencyType)
+
+		_, err = Repository[*fakeRepository](io, "MissingRepository")
+		assert.ErrorIs(t, err, errRepositoryNotFound)
+		assert.Panics(t, func() { MustRepository[*fakeRepository](io, "MissingRepository") })
+
+		_, err = Dependency[string](io, "tx")
+		assert.ErrorIs(t, err, ErrDependencyNotFound)
+
+		return nil
+	})
+
+	ctx := WithDependency(context.Background(), "FakeRepository", &fakeRepository{name: "request"})
+	ctx = WithDependency(ctx, "tx", "transaction")
+	serve(ctx, func(io IO) error {
+		assert.Equal(t, "request", MustRepository[*fakeRepository](io, "FakeRepository").name)
+
+		tx, err := Dependency[string](io, "tx")
+		require.NoError(t, err)
+		assert.Equal(t, "transaction", tx)
+
+		_, err = Dependency[int](io, "tx")
+		assert.ErrorIs(t, err, ErrDependencyType)
+
+		return nil
+	})
+
+	serve(context.Background(), func(io IO) error {
+		assert.Equal(t, "app", MustRepository[*fakeRepository](io, "FakeRepository").name)
+		return nil
+	})
+}
+
+func TestIOTranslator(t *testing.T) {
+	appCtx, webCtx := setupMockCtxs(t)
+	translator := trans.NewTranslator(trans.WithTranslations(map[string]string{"hello": "Hallo"}))
+
+	serve := func(ctx context.Context, handler func(io IO) error) {
+		request := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+		NewController(appCtx, webCtx, handler).ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	serve(context.WithValue(context.Background(), trans.TranslatorContextKey, translator), func(io IO) error {
+		assert.Equal(t, "Hallo", io.Translator().T("hello"))
+		return nil
+	})
+
+	serve(context.Background(), func(io IO) error {
+		assert.Equal(t, "hello", io.Translator().T("hello"))
+		return nil
+	})
+}
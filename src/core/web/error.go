@@ -0,0 +1,163 @@
+package web
+
+import (
+	"errors"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"net/http"
+	"sync"
+)
+
+// ErrorResponseHeader is set on responses rendered by IO.Error, IO.InlineError and IO.InlineErrorTo.
+// HTMX does not swap responses with an error status code by default, the client swaps responses carrying this header anyway.
+const ErrorResponseHeader = "X-Harmony-Error"
+
+var (
+	// ErrNotFound is displayed to the user if the requested resource does not exist.
+	ErrNotFound = errors.New("harmony.error.not-found")
+	// ErrForbidden is displayed to the user if they are not permitted to access the requested resource.
+	ErrForbidden = errors.New("harmony.error.forbidden")
+)
+
+// HTTPError is an error carrying the HTTP status code of the response, the user facing message and the internal cause.
+// The message is expected to be a translation key, it is rendered on the error page. The cause is only logged.
+// Passing an HTTPError to IO.Error or IO.InlineError responds with its status code and message.
+type HTTPError struct {
+	Status int
+	Msg    error
+	Cause  error
+}
+
+// ErrorMapping maps errors, e.g. domain errors like a not found error, to an HTTP status code and a user facing message.
+// IO.Error and IO.InlineError resolve the status code and message of a response through the mapping of the web.Ctx.
+// This allows to map errors centrally instead of wrapping them in an HTTPError in every controller.
+//
+// ErrorMapping is safe for concurrent use by multiple goroutines.
+type ErrorMapping struct {
+	entries []errorMappingEntry
+	mu      sync.RWMutex
+}
+
+// errorMappingEntry is a single mapping of an error to a status code and an optional user facing message.
+type errorMappingEntry struct {
+	err    error
+	status int
+	msg    error
+}
+
+// NewHTTPError creates a new HTTPError with the passed in status code, user facing message and internal cause.
+// The message and cause may be nil. Without a message, the status text is displayed to the user.
+func NewHTTPError(status int, msg error, cause error) *HTTPError {
+	return &HTTPError{Status: status, Msg: msg, Cause: cause}
+}
+
+// NotFound creates a new HTTPError with status code 404 and ErrNotFound as the user facing message.
+func NotFound(cause error) *HTTPError {
+	return NewHTTPError(http.StatusNotFound, ErrNotFound, cause)
+}
+
+// Forbidden creates a new HTTPError with status code 403 and ErrForbidden as the user facing message.
+func Forbidden(cause error) *HTTPError {
+	return NewHTTPError(http.StatusForbidden, ErrForbidden, cause)
+}
+
+// Error returns the user facing message of the HTTPError.
+func (e *HTTPError) Error() string {
+	if e.Msg == nil {
+		return http.StatusText(e.Status)
+	}
+
+	return e.Msg.Error()
+}
+
+// Unwrap returns the message and the cause of the HTTPError allowing to check for both using errors.Is and errors.As.
+func (e *HTTPError) Unwrap() []error {
+	var errs []error
+	if e.Msg != nil {
+		errs = append(errs, e.Msg)
+	}
+	if e.Cause != nil {
+		errs = append(errs, e.Cause)
+	}
+
+	return errs
+}
+
+// NewErrorMapping creates a new ErrorMapping with the default mappings of the core packages:
+// persistence.ErrNotFound is mapped to 404 (ErrNotFound), persistence.ErrTimeout to 503 (ErrTimeout)
+// and ErrPreconditionFailed to 412.
+func NewErrorMapping() *ErrorMapping {
+	m := &ErrorMapping{}
+	m.Map(persistence.ErrNotFound, http.StatusNotFound, ErrNotFound)
+	m.Map(persistence.ErrTimeout, http.StatusServiceUnavailable, ErrTimeout)
+	m.Map(ErrPreconditionFailed, http.StatusPreconditionFailed, nil)
+
+	return m
+}
+
+// Map maps the error to the status code and user facing message. If msg is nil, the error's own message is displayed.
+// Errors are matched using errors.Is. Mappings added later take precedence over earlier ones.
+func (m *ErrorMapping) Map(err error, status int, msg error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = append(m.entries, errorMappingEntry{err: err, status: status, msg: msg})
+}
+
+// Resolve returns an HTTPError with the status code and user facing message for the passed in errors.
+// The errors are checked in order, the first one being an HTTPError or matching a mapping determines the status code.
+// The first error stays the user facing message, unless it is the matching error itself or the generic ErrInternal.
+// In that case the message of the HTTPError or mapping is used if it has one.
+// If no error matches, the status code is 500. The first error is always returned as the cause.
+//
+// Resolve may be called on a nil ErrorMapping, only HTTPErrors are considered then.
+func (m *ErrorMapping) Resolve(errs ...error) *HTTPError {
+	if len(errs) == 0 {
+		return NewHTTPError(http.StatusInternalServerError, ErrInternal, nil)
+	}
+
+	first := errs[0]
+	replaceable := errors.Is(first, ErrInternal)
+
+	for i, err := range errs {
+		var httpErr *HTTPError
+		status, msg, ok := m.match(err)
+		if errors.As(err, &httpErr) {
+			status, msg, ok = httpErr.Status, httpErr.Msg, true
+		}
+
+		if !ok {
+			continue
+		}
+
+		if msg == nil || (i > 0 && !replaceable) {
+			msg = first
+		}
+
+		return NewHTTPError(status, msg, first)
+	}
+
+	return NewHTTPError(http.StatusInternalServerError, first, first)
+}
+
+// match returns the status code and message of the latest mapping matching the error.
+// Without a matching mapping, errors caused by a too large request body (see IsBodyTooLarge) match 413 and ErrBodyTooLarge.
+func (m *ErrorMapping) match(err error) (int, error, bool) {
+	if m == nil {
+		return 0, nil, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if errors.Is(err, m.entries[i].err) {
+			return m.entries[i].status, m.entries[i].msg, true
+		}
+	}
+
+	if IsBodyTooLarge(err) {
+		return http.StatusRequestEntityTooLarge, ErrBodyTooLarge, true
+	}
+
+	return 0, nil, false
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"errors"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestErrorMappingResolve(t *testing.T) {
+	errDomainNotFound := errors.New("domain not found")
+	errDomainMessage := errors.New("domain.error.message")
+	errPermission := errors.New("permission")
+
+	m := NewErrorMapping()
+	m.Map(errDomainNotFound, http.StatusNotFound, nil)
+	m.Map(errPermission, http.StatusForbidden, ErrForbidden)
+
+	resolved := m.Resolve()
+	assert.Equal(t, http.StatusInternalServerError, resolved.Status)
+	assert.ErrorIs(t, resolved, ErrInternal)
+
+	resolved = m.Resolve(errDomainMessage, errors.New("unmapped"))
+	assert.Equal(t, http.StatusInternalServerError, resolved.Status)
+	assert.Equal(t, errDomainMessage.Error(), resolved.Error())
+
+	resolved = m.Resolve(errors.Join(errDomainNotFound, persistence.ErrNotFound))
+	assert.Equal(t, http.StatusNotFound, resolved.Status)
+	assert.Equal(t, errDomainNotFound.Error()+"\n"+persistence.ErrNotFound.Error(), resolved.Error(), "later mappings take precedence")
+
+	resolved = m.Resolve(errDomainMessage, errPermission)
+	assert.Equal(t, http.StatusForbidden, resolved.Status)
+	assert.Equal(t, errDomainMessage.Error(), resolved.Error(), "an explicit user facing message is kept")
+
+	resolved = m.Resolve(ErrInternal, errPermission)
+	assert.Equal(t, http.StatusForbidden, resolved.Status)
+	assert.Equal(t, ErrForbidden.Error(), resolved.Error(), "the generic message is replaced")
+
+	resolved = m.Resolve(ErrInternal, NewHTTPError(http.StatusConflict, nil, errors.New("cause")))
+	assert.Equal(t, http.StatusConflict, resolved.Status)
+	assert.Equal(t, ErrInternal.Error(), resolved.Error())
+
+	resolved = m.Resolve(NewHTTPError(http.StatusTeapot, nil, nil))
+	assert.Equal(t, http.StatusTeapot, resolved.Status)
+	assert.Equal(t, http.StatusText(http.StatusTeapot), resolved.Error())
+
+	var nilMapping *ErrorMapping
+	resolved = nilMapping.Resolve(ErrInternal, NotFound(persistence.ErrNotFound))
+	assert.Equal(t, http.StatusNotFound, resolved.Status)
+	assert.Equal(t, ErrNotFound.Error(), resolved.Error())
+	assert.Equal(t, http.StatusInternalServerError, nilMapping.Resolve(persistence.ErrNotFound).Status)
+}
+
+func TestHTTPErrorUnwrap(t *testing.T) {
+	cause := errors.New("cause")
+	err := NotFound(cause)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, ErrNotFound.Error(), err.Error())
+
+	var httpErr *HTTPError
+	assert.True(t, errors.As(errors.Join(errors.New("wrapped"), err), &httpErr))
+	assert.Equal(t, http.StatusNotFound, httpErr.Status)
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrPreconditionFailed is displayed to the user if the resource was changed since the client retrieved it (see CheckIfMatch).
+var ErrPreconditionFailed = errors.New("harmony.error.precondition-failed")
+
+// ETag returns a strong entity tag derived from the parts, e.g. a resource's id and version.
+// The same parts always result in the same entity tag. The entity tag is quoted as required by the ETag header.
+func ETag(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// VersionETag returns the entity tag of a resource identified by id in the version of its last modification.
+// Resources that were never updated should pass their creation time as modification time.
+func VersionETag(id string, modified time.Time) string {
+	return ETag(id, modified.UTC().Format(time.RFC3339Nano))
+}
+
+// NotModified sets the ETag header of the response and checks the If-None-Match header of GET and HEAD requests.
+// If the client already has the current version of the resource, the status code 304 is written and true is returned.
+// Nothing else should be written to the response then. Entity tags are compared weakly as required for If-None-Match.
+func NotModified(io IO, etag string) bool {
+	io.Response().Header().Set("ETag", etag)
+
+	r := io.Request()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if !etagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		return false
+	}
+
+	io.Response().WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// CheckIfMatch checks the If-Match header of the request against the entity tag of the current version of the resource.
+// It returns ErrPreconditionFailed (mapped to 412) if the client sent an If-Match header not matching the current version,
+// e.g. because the resource was changed concurrently. Requests without an If-Match header pass the check.
+// Entity tags are compared strongly as required for If-Match.
+func CheckIfMatch(io IO, etag string) error {
+	header := io.Request().Header.Get("If-Match")
+	if header == "" || etagMatches(header, etag, false) {
+		return nil
+	}
+
+	return ErrPreconditionFailed
+}
+
+// etagMatches returns true if the comma separated list of entity tags of a conditional header contains the entity tag.
+// The wildcard * matches any entity tag. Weak entity tags (W/ prefix) only match if weak is true.
+func etagMatches(header string, etag string, weak bool) bool {
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVersionETag(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	etag := VersionETag("id", modified)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, etag, VersionETag("id", modified.In(time.FixedZone("CET", 3600))))
+	assert.NotEqual(t, etag, VersionETag("id", modified.Add(time.Microsecond)))
+	assert.NotEqual(t, etag, VersionETag("other", modified))
+}
+
+func TestNotModified(t *testing.T) {
+	etag := ETag("resource", "1")
+
+	tests := []struct {
+		method      string
+		ifNoneMatch string
+		want        bool
+	}{
+		{method: http.MethodGet, ifNoneMatch: "", want: false},
+		{method: http.MethodGet, ifNoneMatch: etag, want: true},
+		{method: http.MethodGet, ifNoneMatch: `"other", W/` + etag, want: true},
+		{method: http.MethodGet, ifNoneMatch: "*", want: true},
+		{method: http.MethodGet, ifNoneMatch: `"other"`, want: false},
+		{method: http.MethodHead, ifNoneMatch: etag, want: true},
+		{method: http.MethodPut, ifNoneMatch: etag, want: false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/", nil)
+		req.Header.Set("If-None-Match", test.ifNoneMatch)
+		recorder := httptest.NewRecorder()
+		io := &HIO{request: req, writer: recorder}
+
+		assert.Equal(t, test.want, NotModified(io, etag), "%s with If-None-Match %s", test.method, test.ifNoneMatch)
+		assert.Equal(t, etag, recorder.Header().Get("ETag"))
+		if test.want {
+			assert.Equal(t, http.StatusNotModified, recorder.Code)
+		}
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	etag := ETag("resource", "1")
+
+	tests := []struct {
+		ifMatch string
+		want    error
+	}{
+		{ifMatch: "", want: nil},
+		{ifMatch: etag, want: nil},
+		{ifMatch: `"other", ` + etag, want: nil},
+		{ifMatch: "*", want: nil},
+		{ifMatch: `"other"`, want: ErrPreconditionFailed},
+		{ifMatch: "W/" + etag, want: ErrPreconditionFailed},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.Header.Set("If-Match", test.ifMatch)
+
+		assert.Equal(t, test.want, CheckIfMatch(&HIO{request: req}, etag), "If-Match %s", test.ifMatch)
+	}
+
+	resolved := NewErrorMapping().Resolve(ErrPreconditionFailed)
+	assert.Equal(t, http.StatusPreconditionFailed, resolved.Status)
+}
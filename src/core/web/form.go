@@ -0,0 +1,326 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/org-harmony/harmony/src/core/util"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"html"
+	"html/template"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FormTag is the struct tag declaring a field of a form, see Form.Fields.
+// The tag is a comma-separated list of key=value pairs, e.g.:
+//
+//	Name        string `hform:"label=template.set.name,cols=6"`
+//	Description string `hform:"label=template.set.description,widget=textarea"`
+//
+// The supported keys are label (translation key), placeholder (translation key, defaults to the label),
+// widget (one of the Widget constants, defaults to WidgetText) and cols (Bootstrap grid columns, defaults to 12).
+// Fields without the tag are not rendered.
+const FormTag = "hform"
+
+// FormTemplateName is the name of the template rendering a Form. It is defined in the base templates.
+// Example:
+//
+//	{{ template "harmony.form" .Data }}
+const FormTemplateName = "harmony.form"
+
+// ValidationTemplateName is the name of the template rendering the violations of a field validated by a ValidationController.
+// It is defined in the base templates next to the FormTemplateName template.
+const ValidationTemplateName = "harmony.form.violations"
+
+// ValidationFieldParam is the query parameter naming the field validated by a ValidationController.
+// HTMX requests name the field through the HX-Trigger-Name header instead.
+const ValidationFieldParam = "field"
+
+// Widgets of a form field. The widget is declared through the FormTag.
+const (
+	WidgetText     = "text"
+	WidgetTextarea = "textarea"
+	WidgetNumber   = "number"
+	WidgetEmail    = "email"
+	WidgetPassword = "password"
+	WidgetHidden   = "hidden"
+	WidgetCheckbox = "checkbox"
+)
+
+// Form is a form declared from the struct T through the FormTag. It embeds the FormData of the form
+// and therefore contains the form's values, violations and success messages.
+// A Form is rendered by the FormTemplateName template and handled by HandleForm.
+type Form[T any] struct {
+	*FormData[T]
+	// ID is the id of the form element. It is used as a prefix for the ids of the fields.
+	ID string
+	// Action is the URL the form is submitted to.
+	Action string
+	// Method is the HTTP method the form is submitted with, defaults to POST.
+	Method string
+	// HTMX submits the form through HTMX and replaces the form with the response.
+	// Otherwise, the form is submitted by the browser which is only possible for GET and POST.
+	HTMX bool
+	// Submit is the translation key of the submit button's label. No submit button is rendered if it is empty.
+	Submit string
+	// Validate is the URL of the form's ValidationController. If it is set, fields are validated inline when they change.
+	Validate string
+}
+
+// ValidationResult is the response of a ValidationController. Violations are the translated messages of the violations
+// by field name, violations without a field are listed under the WildcardViolation.
+type ValidationResult struct {
+	Valid      bool                `json:"valid"`
+	Field      string              `json:"field,omitempty"` // Field is the validated field, all fields are validated if it is empty.
+	ID         string              `json:"-"`               // ID is the id of the validated field's element (HX-Trigger header).
+	Violations map[string][]string `json:"violations"`
+}
+
+// FormField is a field of a Form declared through the FormTag.
+type FormField struct {
+	// Name is the name of the struct field. It is the name of the form value read by ReadForm.
+	Name        string
+	ID          string
+	Label       string
+	Placeholder string
+	Widget      string
+	Cols        int
+	// Required is true if the field is validated as required (hvalidate:"required").
+	Required bool
+	// Value is the formatted value of the struct field.
+	Value string
+	// Checked is true if the widget is a WidgetCheckbox and the value of the struct field is true.
+	Checked bool
+}
+
+// NewForm constructs a Form of the form spec with the passed in form values, success messages and violations (see NewFormData).
+// The spec declares the form element (ID, Action, Method, HTMX and Submit), its FormData is ignored.
+func NewForm[T any](spec Form[T], form T, success []string, errs ...error) *Form[T] {
+	spec.FormData = NewFormData(form, success, errs...)
+	if spec.Method == "" {
+		spec.Method = http.MethodPost
+	}
+
+	return &spec
+}
+
+// HandleForm handles the submission of the form: the request's form values are read into the form's values and validated (see ReadForm).
+// If the form is invalid, it is re-rendered with the violations through render. Otherwise, submit is called with the valid form.
+// Submit writes the response, e.g. by redirecting or by rendering the form with a success message.
+// Errors reading the form and errors returned by submit are rendered through IO.InlineError for HTMX forms and IO.Error otherwise.
+func HandleForm[T any](io IO, validator validation.V, form *Form[T], render func(*Form[T]) error, submit func(*Form[T]) error) error {
+	err, validationErrs := ReadForm(io.Request(), form.Form, validator)
+	if err != nil {
+		return form.error(io, ErrInternal, err)
+	}
+
+	if len(validationErrs) > 0 {
+		form.ViolationsFromErrors(validationErrs...)
+		return render(form)
+	}
+
+	err = submit(form)
+	if err != nil {
+		return form.error(io, ErrInternal, err)
+	}
+
+	return nil
+}
+
+// Fields returns the fields of the form declared through the FormTag in the order of the struct fields.
+// The values of the fields are read from the form's values.
+func (f *Form[T]) Fields() []FormField {
+	value := reflect.ValueOf(f.Form)
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value = reflect.New(value.Type().Elem())
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []FormField
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		tag, ok := structField.Tag.Lookup(FormTag)
+		if !ok || !structField.IsExported() {
+			continue
+		}
+
+		field := parseFormTag(tag)
+		field.Name = structField.Name
+		field.ID = strings.ToLower(structField.Name)
+		if f.ID != "" {
+			field.ID = f.ID + "-" + field.ID
+		}
+		field.Required = slices.Contains(validatorNames(structField), "required")
+		field.Value, field.Checked = formatFieldValue(value.Field(i))
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// Attributes returns the attributes of the form element submitting the form to its action with its method,
+// e.g. `hx-put="/template-set/<id>" hx-swap="outerHTML"` for an HTMX form or `method="post" action="/template-set/new"` otherwise.
+func (f *Form[T]) Attributes() template.HTMLAttr {
+	action := html.EscapeString(f.Action)
+	if !f.HTMX {
+		return template.HTMLAttr(fmt.Sprintf(`method="%s" action="%s"`, strings.ToLower(f.Method), action))
+	}
+
+	return template.HTMLAttr(fmt.Sprintf(`hx-%s="%s" hx-swap="outerHTML"`, strings.ToLower(f.Method), action))
+}
+
+// ValidateAttributes returns the HTMX attributes validating the field through the form's Validate URL when it changes.
+// The field's violations are swapped into the element with the id "<field id>-violations" (see ValidationTemplateName).
+// It returns no attributes if the form has no Validate URL.
+func (f *Form[T]) ValidateAttributes(field FormField) template.HTMLAttr {
+	if f.Validate == "" {
+		return ""
+	}
+
+	return template.HTMLAttr(fmt.Sprintf(
+		`hx-post="%s" hx-trigger="change" hx-include="closest form" hx-target="#%s-violations" hx-swap="outerHTML" hx-disabled-elt="unset"`,
+		html.EscapeString(f.Validate),
+		html.EscapeString(field.ID),
+	))
+}
+
+// ValidationController returns a controller validating the submitted form values of the struct T without submitting them.
+// It allows inline validation of any form, e.g. for a Form with the Validate URL:
+//
+//	router.Post("/template-set/validate", web.ValidationController[template.SetToCreate](appCtx, webCtx).ServeHTTP)
+//
+// The form values are read and validated by ReadForm with the application's validator. If a field is named through the
+// HX-Trigger-Name header or the ValidationFieldParam, only its violations are returned. The ValidationResult is written
+// as JSON to clients requesting JSON (see WantsJSON) and rendered by the ValidationTemplateName template otherwise.
+// T must be a struct type.
+func ValidationController[T any](appCtx *hctx.AppCtx, webCtx *Ctx) http.Handler {
+	return NewController(appCtx, webCtx, func(io IO) error {
+		form := new(T)
+		err, validationErrs := ReadForm(io.Request(), form, appCtx.Validator)
+		if err != nil {
+			return io.InlineError(ErrInternal, err)
+		}
+
+		field := io.Request().Header.Get("HX-Trigger-Name")
+		if field == "" {
+			field = io.Request().URL.Query().Get(ValidationFieldParam)
+		}
+
+		result := newValidationResult(io.Context(), field, validationErrs)
+		result.ID = io.Request().Header.Get("HX-Trigger")
+
+		if io.WantsJSON() {
+			return io.JSON(result)
+		}
+
+		return io.Render(result, ValidationTemplateName, "base/form.go.html")
+	})
+}
+
+// newValidationResult returns the ValidationResult of the validation errors translated by the context's translator.
+// If the field is not empty, only its violations are contained.
+func newValidationResult(ctx context.Context, field string, validationErrs []error) *ValidationResult {
+	translator, ok := util.CtxValue[trans.Translator](ctx, trans.TranslatorContextKey)
+	if !ok {
+		translator = trans.NewTranslator()
+	}
+
+	data := NewFormData[any](nil, nil, validationErrs...)
+	result := &ValidationResult{Field: field, Violations: make(map[string][]string)}
+	for name, violations := range data.Violations {
+		if field != "" && name != field {
+			continue
+		}
+
+		for _, violation := range violations {
+			result.Violations[name] = append(result.Violations[name], translateViolation(translator, violation))
+		}
+	}
+	result.Valid = len(result.Violations) == 0
+
+	return result
+}
+
+// translateViolation translates a violation like the tryTranslate template function.
+func translateViolation(translator trans.Translator, err error) string {
+	if t, ok := err.(trans.Translatable); ok {
+		return t.Translate(translator)
+	}
+
+	return translator.T(err.Error())
+}
+
+// error renders the errors through IO.InlineError for HTMX forms and IO.Error otherwise.
+func (f *Form[T]) error(io IO, errs ...error) error {
+	if f.HTMX {
+		return io.InlineError(errs...)
+	}
+
+	return io.Error(errs...)
+}
+
+// parseFormTag parses the key=value pairs of the FormTag into a FormField. Unknown keys are ignored.
+func parseFormTag(tag string) FormField {
+	field := FormField{Widget: WidgetText, Cols: 12}
+
+	for _, pair := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		switch key {
+		case "label":
+			field.Label = value
+		case "placeholder":
+			field.Placeholder = value
+		case "widget":
+			field.Widget = value
+		case "cols":
+			if cols, err := strconv.Atoi(value); err == nil && cols > 0 && cols <= 12 {
+				field.Cols = cols
+			}
+		}
+	}
+
+	if field.Placeholder == "" {
+		field.Placeholder = field.Label
+	}
+
+	return field
+}
+
+// validatorNames returns the names of the validators of the struct field's validation.StructTag.
+func validatorNames(field reflect.StructField) []string {
+	var names []string
+	for _, name := range strings.Split(field.Tag.Get(validation.StructTag), ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+
+	return names
+}
+
+// formatFieldValue formats the value of a struct field for a form field.
+// The second return value is true if the value is a bool and true.
+func formatFieldValue(value reflect.Value) (string, bool) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "", false
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Bool {
+		return strconv.FormatBool(value.Bool()), value.Bool()
+	}
+
+	return fmt.Sprintf("%v", value.Interface()), false
+}
@@ -0,0 +1,203 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type formTestStruct struct {
+	Name        string `hvalidate:"required" hform:"label=test.name,cols=6"`
+	Age         int    `hform:"label=test.age,placeholder=test.age.placeholder,widget=number,cols=20"`
+	Description string `hform:"label=test.description,widget=textarea"`
+	Subscribed  bool   `hform:"label=test.subscribed,widget=checkbox"`
+	Token       string `hform:"widget=hidden"`
+	CreatedBy   string `hvalidate:"required"`
+}
+
+func TestFormFields(t *testing.T) {
+	form := NewForm(Form[*formTestStruct]{ID: "test-form"}, &formTestStruct{
+		Name:       "foo",
+		Age:        42,
+		Subscribed: true,
+	}, nil)
+
+	assert.Equal(t, http.MethodPost, form.Method)
+
+	fields := form.Fields()
+	require.Len(t, fields, 5)
+
+	assert.Equal(t, FormField{
+		Name:        "Name",
+		ID:          "test-form-name",
+		Label:       "test.name",
+		Placeholder: "test.name",
+		Widget:      WidgetText,
+		Cols:        6,
+		Required:    true,
+		Value:       "foo",
+	}, fields[0])
+
+	assert.Equal(t, "test.age.placeholder", fields[1].Placeholder)
+	assert.Equal(t, WidgetNumber, fields[1].Widget)
+	assert.Equal(t, 12, fields[1].Cols)
+	assert.Equal(t, "42", fields[1].Value)
+	assert.False(t, fields[1].Required)
+
+	assert.Equal(t, WidgetTextarea, fields[2].Widget)
+	assert.True(t, fields[3].Checked)
+	assert.Equal(t, WidgetHidden, fields[4].Widget)
+
+	var nilForm *formTestStruct
+	assert.Len(t, NewForm(Form[*formTestStruct]{}, nilForm, nil).Fields(), 5)
+}
+
+func TestFormAttributes(t *testing.T) {
+	form := NewForm(Form[*formTestStruct]{Action: "/test?a=1&b=2"}, &formTestStruct{}, nil)
+	assert.Equal(t, template.HTMLAttr(`method="post" action="/test?a=1&amp;b=2"`), form.Attributes())
+
+	form = NewForm(Form[*formTestStruct]{Action: "/test/1", Method: http.MethodPut, HTMX: true}, &formTestStruct{}, nil)
+	assert.Equal(t, template.HTMLAttr(`hx-put="/test/1" hx-swap="outerHTML"`), form.Attributes())
+}
+
+func TestFormTemplate(t *testing.T) {
+	tmpl, err := template.New("test").
+		Funcs(templateFuncs(&UICfg{})).
+		ParseFiles(filepath.Join("..", "..", "..", "templates", "base", "form.go.html"))
+	require.NoError(t, err)
+
+	form := NewForm(Form[*formTestStruct]{ID: "test-form", Action: "/test", Submit: "test.submit"}, &formTestStruct{
+		Name:        "<b>foo</b>",
+		Description: "bar",
+	}, []string{"test.saved"}, validation.Error{Field: "Age", Msg: "test.age.invalid"}, errors.New("test.error"))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, tmpl.ExecuteTemplate(buf, FormTemplateName, form))
+	out := buf.String()
+
+	assert.Contains(t, out, `<form id="test-form" method="post" action="/test"`)
+	assert.Contains(t, out, `value="&lt;b&gt;foo&lt;/b&gt;"`)
+	assert.Contains(t, out, `>bar</textarea>`)
+	assert.Contains(t, out, `type="number"`)
+	assert.Contains(t, out, `test.name *</label>`)
+	assert.Contains(t, out, `test.age.invalid.generic`)
+	assert.Contains(t, out, `test.saved`)
+	assert.Contains(t, out, `test.error`)
+	assert.Contains(t, out, `<button type="submit" class="btn btn-primary">test.submit</button>`)
+	assert.Equal(t, 1, strings.Count(out, "is-invalid"))
+	assert.NotContains(t, out, `name="CreatedBy"`)
+}
+
+func TestHandleForm(t *testing.T) {
+	appCtx, webCtx := setupMockCtxs(t)
+
+	serve := func(values url.Values, form *Form[*formTestStruct], render func(*Form[*formTestStruct]) error, submit func(*Form[*formTestStruct]) error) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(values.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+
+		NewController(appCtx, webCtx, func(io IO) error {
+			return HandleForm(io, appCtx.Validator, form, render, submit)
+		}).ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	t.Run("invalid form is re-rendered", func(t *testing.T) {
+		var rendered *Form[*formTestStruct]
+		form := NewForm(Form[*formTestStruct]{}, &formTestStruct{CreatedBy: "user"}, nil)
+
+		serve(url.Values{"Name": {""}}, form, func(form *Form[*formTestStruct]) error {
+			rendered = form
+			return nil
+		}, func(form *Form[*formTestStruct]) error {
+			t.Fatal("submit must not be called for an invalid form")
+			return nil
+		})
+
+		require.NotNil(t, rendered)
+		assert.True(t, rendered.FieldHasViolations("Name"))
+	})
+
+	t.Run("valid form is submitted", func(t *testing.T) {
+		var submitted *formTestStruct
+		form := NewForm(Form[*formTestStruct]{}, &formTestStruct{CreatedBy: "user"}, nil)
+
+		recorder := serve(url.Values{"Name": {"foo"}, "Age": {"42"}, "Subscribed": {"true", "false"}}, form, func(form *Form[*formTestStruct]) error {
+			t.Fatal("render must not be called for a valid form")
+			return nil
+		}, func(form *Form[*formTestStruct]) error {
+			submitted = form.Form
+			return nil
+		})
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		require.NotNil(t, submitted)
+		assert.Equal(t, "foo", submitted.Name)
+		assert.Equal(t, 42, submitted.Age)
+		assert.True(t, submitted.Subscribed)
+		assert.Equal(t, "user", submitted.CreatedBy)
+	})
+
+	t.Run("submit errors are rendered", func(t *testing.T) {
+		form := NewForm(Form[*formTestStruct]{HTMX: true}, &formTestStruct{CreatedBy: "user"}, nil)
+
+		recorder := serve(url.Values{"Name": {"foo"}}, form, func(form *Form[*formTestStruct]) error {
+			return nil
+		}, func(form *Form[*formTestStruct]) error {
+			return errors.New("submit failed")
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func TestFormValidateAttributes(t *testing.T) {
+	form := NewForm(Form[*formTestStruct]{ID: "test-form"}, &formTestStruct{}, nil)
+	assert.Empty(t, form.ValidateAttributes(form.Fields()[0]))
+
+	form = NewForm(Form[*formTestStruct]{ID: "test-form", Validate: "/test/validate"}, &formTestStruct{}, nil)
+	attributes := form.ValidateAttributes(form.Fields()[0])
+	assert.Contains(t, attributes, `hx-post="/test/validate"`)
+	assert.Contains(t, attributes, `hx-target="#test-form-name-violations"`)
+}
+
+func TestValidationController(t *testing.T) {
+	appCtx, webCtx := setupMockCtxs(t)
+	controller := ValidationController[formTestStruct](appCtx, webCtx)
+
+	validate := func(query string, values url.Values) *ValidationResult {
+		request := httptest.NewRequest(http.MethodPost, "/validate?"+query, strings.NewReader(values.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Accept", "application/json")
+		recorder := httptest.NewRecorder()
+
+		controller.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		result := &ValidationResult{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), result))
+
+		return result
+	}
+
+	result := validate("", url.Values{"Name": {""}})
+	assert.False(t, result.Valid)
+	assert.Contains(t, result.Violations, "Name")
+	assert.Contains(t, result.Violations, "CreatedBy")
+
+	result = validate(ValidationFieldParam+"=Name", url.Values{"Name": {"foo"}})
+	assert.True(t, result.Valid)
+	assert.Equal(t, "Name", result.Field)
+	assert.Empty(t, result.Violations)
+}
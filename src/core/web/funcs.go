@@ -0,0 +1,163 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"html/template"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidDict is returned by the dict template function if it is not called with pairs of string keys and values.
+var ErrInvalidDict = errors.New("dict requires pairs of string keys and values")
+
+// helperFuncs returns the template functions helping to prepare data inside templates:
+//   - dict creates a map from pairs of string keys and values, e.g. to pass multiple values to a partial
+//   - list creates a slice from its arguments
+//   - truncate shortens a string to a maximum number of characters (runes) appending "…", e.g. {{ .Description | truncate 80 }}
+//   - markdown renders a sanitized subset of Markdown as HTML, see Markdown
+//   - default returns the default if the value is empty (nil, zero or of length 0), e.g. {{ .Name | default "-" }}
+//   - json encodes the value as JSON, e.g. for hx-vals
+func helperFuncs() template.FuncMap {
+	return template.FuncMap{
+		"dict":     dict,
+		"list":     func(items ...any) []any { return items },
+		"truncate": truncate,
+		"markdown": Markdown,
+		"default":  defaultValue,
+		"json":     jsonString,
+	}
+}
+
+// dict creates a map from pairs of string keys and values. It returns ErrInvalidDict for an odd number of arguments or non-string keys.
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, ErrInvalidDict
+	}
+
+	d := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: key %v is no string", ErrInvalidDict, pairs[i])
+		}
+
+		d[key] = pairs[i+1]
+	}
+
+	return d, nil
+}
+
+// truncate shortens the string to at most length characters (runes) including the appended ellipsis.
+// Strings not exceeding the length are returned unchanged.
+func truncate(length int, s string) string {
+	if length < 1 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+
+	runes := []rune(s)
+	return strings.TrimRight(string(runes[:length-1]), " ") + "…"
+}
+
+// defaultValue returns def if the value is empty: nil, a nil pointer, a zero value or of length 0.
+func defaultValue(def any, v any) any {
+	if v == nil {
+		return def
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array, reflect.Chan:
+		if rv.Len() == 0 {
+			return def
+		}
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return def
+		}
+	default:
+		if rv.IsZero() {
+			return def
+		}
+	}
+
+	return v
+}
+
+// jsonString encodes the value as JSON.
+func jsonString(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// formatDate formats a time.Time or *time.Time by the style: "date" and "datetime" use the locale's formats
+// (see trans.Locale.FormatDate), any other style is used as time layout, e.g. "2006-01-02". Zero times are formatted as an empty string.
+func formatDate(locale *trans.Locale, style string, v any) string {
+	t := templateTime(v)
+	if t.IsZero() {
+		return ""
+	}
+
+	switch style {
+	case "date":
+		return locale.FormatDate(t)
+	case "datetime":
+		return locale.FormatDateTime(t)
+	}
+
+	return t.Format(style)
+}
+
+// pluralize returns the singular if the count is singular in the locale's language (see trans.Locale.PluralCategory), the plural otherwise.
+func pluralize(locale *trans.Locale, count int, singular string, plural string) string {
+	if locale.PluralCategory(count) == trans.PluralOne {
+		return singular
+	}
+
+	return plural
+}
+
+// timeAgo describes the time relative to now, e.g. "3 days ago" or "in 2 hours", by the translations
+// harmony.time.ago.<unit> and harmony.time.in.<unit> with the unit minutes, hours, days, months or years.
+// Times less than a minute apart are described as harmony.time.just-now. Zero times are described as an empty string.
+func timeAgo(translator trans.Translator, t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	direction := "ago"
+	d := now.Sub(t)
+	if d < 0 {
+		direction, d = "in", -d
+	}
+
+	var unit string
+	var count int
+	switch {
+	case d < time.Minute:
+		return translator.T("harmony.time.just-now")
+	case d < time.Hour:
+		unit, count = "minutes", int(d/time.Minute)
+	case d < 24*time.Hour:
+		unit, count = "hours", int(d/time.Hour)
+	case d < 30*24*time.Hour:
+		unit, count = "days", int(d/(24*time.Hour))
+	case d < 365*24*time.Hour:
+		unit, count = "months", int(d/(30*24*time.Hour))
+	default:
+		unit, count = "years", int(d/(365*24*time.Hour))
+	}
+
+	return translator.Tn("harmony.time."+direction+"."+unit, count)
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"bytes"
+	"github.com/org-harmony/harmony/src/core/trans"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"html/template"
+	"testing"
+	"time"
+)
+
+func TestDict(t *testing.T) {
+	d, err := dict("name", "harmony", "count", 2)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "harmony", "count": 2}, d)
+
+	_, err = dict("name")
+	assert.ErrorIs(t, err, ErrInvalidDict)
+	_, err = dict(1, "harmony")
+	assert.ErrorIs(t, err, ErrInvalidDict)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate(10, "short"))
+	assert.Equal(t, "exactly", truncate(7, "exactly"))
+	assert.Equal(t, "a long…", truncate(8, "a long description"))
+	assert.Equal(t, "Grüß…", truncate(5, "Grüße aus Berlin"))
+	assert.Equal(t, "", truncate(0, "anything"))
+}
+
+func TestDefaultValue(t *testing.T) {
+	var nilTime *time.Time
+	now := time.Now()
+
+	assert.Equal(t, "-", defaultValue("-", nil))
+	assert.Equal(t, "-", defaultValue("-", ""))
+	assert.Equal(t, "-", defaultValue("-", 0))
+	assert.Equal(t, "-", defaultValue("-", []string{}))
+	assert.Equal(t, "-", defaultValue("-", nilTime))
+	assert.Equal(t, "name", defaultValue("-", "name"))
+	assert.Equal(t, 3, defaultValue("-", 3))
+	assert.Equal(t, &now, defaultValue("-", &now))
+}
+
+func TestFormatDateAndPluralize(t *testing.T) {
+	date := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+	locale := &trans.Locale{Path: "de", DateFormat: "02.01.2006", DateTimeFormat: "02.01.2006 15:04"}
+
+	assert.Equal(t, "05.03.2024", formatDate(locale, "date", date))
+	assert.Equal(t, "05.03.2024 14:30", formatDate(locale, "datetime", &date))
+	assert.Equal(t, "2024-03-05", formatDate(locale, "2006-01-02", date))
+	assert.Equal(t, "", formatDate(locale, "date", (*time.Time)(nil)))
+
+	assert.Equal(t, "entry", pluralize(locale, 1, "entry", "entries"))
+	assert.Equal(t, "entries", pluralize(locale, 0, "entry", "entries"))
+	assert.Equal(t, "entries", pluralize(nil, 2, "entry", "entries"))
+}
+
+func TestTimeAgo(t *testing.T) {
+	translator := trans.NewTranslator(trans.WithTranslations(map[string]string{
+		"harmony.time.just-now":          "just now",
+		"harmony.time.ago.minutes.one":   "{{ .count }} minute ago",
+		"harmony.time.ago.minutes.other": "{{ .count }} minutes ago",
+		"harmony.time.ago.days.other":    "{{ .count }} days ago",
+		"harmony.time.in.hours.other":    "in {{ .count }} hours",
+		"harmony.time.ago.years.one":     "{{ .count }} year ago",
+	}))
+	now := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "just now", timeAgo(translator, now.Add(-30*time.Second), now))
+	assert.Equal(t, "1 minute ago", timeAgo(translator, now.Add(-time.Minute), now))
+	assert.Equal(t, "5 minutes ago", timeAgo(translator, now.Add(-5*time.Minute), now))
+	assert.Equal(t, "3 days ago", timeAgo(translator, now.Add(-3*24*time.Hour), now))
+	assert.Equal(t, "in 2 hours", timeAgo(translator, now.Add(2*time.Hour+time.Minute), now))
+	assert.Equal(t, "1 year ago", timeAgo(translator, now.AddDate(-1, 0, -1), now))
+	assert.Equal(t, "", timeAgo(translator, time.Time{}, now))
+}
+
+func TestHelperFuncs(t *testing.T) {
+	tmpl := template.Must(template.New("test").Funcs(helperFuncs()).Parse(
+		`{{ define "partial" }}{{ .Name }}:{{ .Count }}{{ end }}` +
+			`{{ template "partial" dict "Name" "harmony" "Count" 2 }}|{{ range list 1 2 }}{{ . }}{{ end }}|` +
+			`{{ .Missing | default "-" }}|<div data-vals='{{ json .Vals }}'></div>|{{ markdown "**bold**" }}`,
+	))
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, map[string]any{"Missing": "", "Vals": map[string]string{"state": "on"}}))
+	assert.Equal(t, `harmony:2|12|-|<div data-vals='{&#34;state&#34;:&#34;on&#34;}'></div>|<p><strong>bold</strong></p>`, buf.String())
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// HXRequestHeader is the header HTMX sets on all of its requests, see IsHTMX.
+	HXRequestHeader = "HX-Request"
+	// HXRedirectHeader is the HTMX response header making the client load the url as a full page, see Redirect.
+	HXRedirectHeader = "HX-Redirect"
+	// HXRetargetHeader is the HTMX response header replacing the request's target by the element of a CSS selector.
+	HXRetargetHeader = "HX-Retarget"
+	// HXReswapHeader is the HTMX response header replacing the request's swap strategy, e.g. innerHTML or none.
+	HXReswapHeader = "HX-Reswap"
+	// HXTriggerHeader is the HTMX response header triggering client-side events with a JSON encoded detail per event.
+	HXTriggerHeader = "HX-Trigger"
+	// ToastEvent is the client-side event displaying a Toast, it is triggered through the HXTriggerHeader.
+	ToastEvent = "harmony:toast"
+	// ToastLevelDanger is the level of toasts displaying errors. The level is a Bootstrap contextual color.
+	ToastLevelDanger = "danger"
+)
+
+// ErrorTarget configures how HTMX swaps an error rendered by IO.InlineErrorTo into the page.
+// The zero value swaps the error into the request's target like IO.InlineError.
+type ErrorTarget struct {
+	// Selector is the CSS selector of the element the error is swapped into instead of the request's target (HX-Retarget).
+	Selector string
+	// Swap is the swap strategy replacing the request's swap strategy (HX-Reswap), e.g. innerHTML.
+	Swap string
+	// Toast displays the translated error as a toast (see ToastEvent) instead of swapping it into the page.
+	// Selector and Swap are ignored.
+	Toast bool
+}
+
+// Toast is the detail of the ToastEvent.
+type Toast struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// ErrorToast displays the error as a toast instead of swapping it into the page, see ErrorTarget.Toast.
+var ErrorToast = ErrorTarget{Toast: true}
+
+// ErrorInto swaps the error into the inner HTML of the element of the CSS selector, e.g. a dedicated error container.
+func ErrorInto(selector string) ErrorTarget {
+	return ErrorTarget{Selector: selector, Swap: "innerHTML"}
+}
+
+// IsHTMX returns true if the request is an HTMX request.
+func IsHTMX(r *http.Request) bool {
+	return r.Header.Get(HXRequestHeader) != ""
+}
+
+// Redirect redirects the client to the url with the status code. HTMX follows HTTP redirects transparently
+// and would swap the page redirected to into its target. Therefore, HTMX requests are answered with 204 and
+// the HXRedirectHeader instead, letting the client load the url as a full page.
+// HX-Location is not used as it loads the url as an HTMX request which only renders the partial template.
+func Redirect(w http.ResponseWriter, r *http.Request, url string, code int) {
+	if !IsHTMX(r) {
+		http.Redirect(w, r, url, code)
+		return
+	}
+
+	w.Header().Set(HXRedirectHeader, url)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InlineErrorTo implements the web.IO interface on HIO by setting the HTMX response headers of the ErrorTarget
+// before rendering the error like InlineError. For more information on the behaviour of InlineErrorTo see the web.IO interface.
+// Toasts are sent as ToastEvent through the HXTriggerHeader with the resolved status code and without a body to swap.
+func (io *HIO) InlineErrorTo(target ErrorTarget, errs ...error) error {
+	if !io.IsHTMX() {
+		return io.InlineError(errs...)
+	}
+
+	if target.Toast {
+		return io.errsToast(errs...)
+	}
+
+	header := io.writer.Header()
+	if target.Selector != "" {
+		header.Set(HXRetargetHeader, target.Selector)
+	}
+	if target.Swap != "" {
+		header.Set(HXReswapHeader, target.Swap)
+	}
+
+	return io.InlineError(errs...)
+}
+
+// errsToast resolves and logs the errors like errs and triggers the ToastEvent with the translated user facing message.
+func (io *HIO) errsToast(errs ...error) error {
+	resolved := io.resolveErrs(errs...)
+
+	trigger, err := json.Marshal(map[string]Toast{
+		ToastEvent: {Level: ToastLevelDanger, Message: io.Translator().T(resolved.Error())},
+	})
+	if err != nil {
+		return err
+	}
+
+	header := io.writer.Header()
+	header.Set(HXTriggerHeader, string(trigger))
+	header.Set(HXReswapHeader, "none")
+	header.Set(ErrorResponseHeader, "true")
+	io.writer.WriteHeader(resolved.Status)
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInlineErrorTo(t *testing.T) {
+	app, ctx := setupMockCtxs(t)
+
+	retarget := NewController(app, ctx, func(io IO) error {
+		return io.InlineErrorTo(ErrorInto("#errors"), errors.Join(persistence.ErrReadRow, persistence.ErrNotFound))
+	})
+	toast := NewController(app, ctx, func(io IO) error {
+		return io.InlineErrorTo(ErrorToast, Forbidden(errors.New("not the owner")))
+	})
+
+	router := ctx.Router
+	router.Get("/retarget", retarget.ServeHTTP)
+	router.Get("/toast", toast.ServeHTTP)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/retarget", nil)
+	req.Header.Set("HX-Request", "true")
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "#errors", recorder.Header().Get(HXRetargetHeader))
+	assert.Equal(t, "innerHTML", recorder.Header().Get(HXReswapHeader))
+	assert.Equal(t, "true", recorder.Header().Get(ErrorResponseHeader))
+	assert.Contains(t, recorder.Body.String(), "harmony.error.not-found")
+	assert.NotContains(t, recorder.Body.String(), "before content;")
+
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/toast", nil)
+	req.Header.Set("HX-Request", "true")
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, "none", recorder.Header().Get(HXReswapHeader))
+	assert.Empty(t, recorder.Body.String())
+
+	var trigger map[string]Toast
+	require.NoError(t, json.Unmarshal([]byte(recorder.Header().Get(HXTriggerHeader)), &trigger))
+	assert.Equal(t, Toast{Level: ToastLevelDanger, Message: "harmony.error.forbidden"}, trigger[ToastEvent])
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/toast", nil))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Empty(t, recorder.Header().Get(HXTriggerHeader))
+	assert.Contains(t, recorder.Body.String(), "harmony.error.forbidden")
+}
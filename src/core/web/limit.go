@@ -0,0 +1,94 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+const (
+	// DefaultMaxBodySize is the default maximum size in bytes of a request body (2 MB).
+	DefaultMaxBodySize = 2 << 20
+	// DefaultMaxUploadSize is the default maximum size in bytes of a request body of routes accepting file uploads (32 MB), see UploadLimit.
+	DefaultMaxUploadSize = 32 << 20
+)
+
+// ErrBodyTooLarge is displayed to the user if the request body exceeds its limit (see BodyLimit).
+var ErrBodyTooLarge = errors.New("harmony.error.body-too-large")
+
+// LimitCfg is the config for the size limits of request bodies enforced by the BodyLimit and UploadLimit middlewares.
+type LimitCfg struct {
+	// MaxBodySize is the maximum size of a request body in bytes. Defaults to DefaultMaxBodySize.
+	MaxBodySize int64 `toml:"max_body_size" env:"MAX_BODY_SIZE"`
+	// MaxUploadSize is the maximum size of a request body of routes accepting file uploads in bytes (see UploadLimit).
+	// Defaults to DefaultMaxUploadSize.
+	MaxUploadSize int64 `toml:"max_upload_size" env:"MAX_UPLOAD_SIZE"`
+}
+
+// limitedBody is a request body limited through http.MaxBytesReader. It keeps the original body
+// allowing LimitBody to replace the global limit of a route instead of nesting another limit.
+type limitedBody struct {
+	io.ReadCloser
+	original io.ReadCloser
+}
+
+// BodyLimit middleware limits the size of all request bodies to the config's MaxBodySize through http.MaxBytesReader.
+// Without a config the default is used. Routes accepting file uploads raise the limit through the UploadLimit or LimitBody
+// middleware. The limit is not chosen by the request's content type as the client controls it.
+//
+// Reading a body beyond its limit fails with an *http.MaxBytesError. Passing such an error to IO.Error or IO.InlineError,
+// e.g. the error returned by ReadForm, responds with the status code 413 and ErrBodyTooLarge (see ErrorMapping.Resolve).
+func BodyLimit(cfg *LimitCfg) func(http.Handler) http.Handler {
+	maxBodySize := int64(DefaultMaxBodySize)
+	if cfg != nil && cfg.MaxBodySize > 0 {
+		maxBodySize = cfg.MaxBodySize
+	}
+
+	return LimitBody(maxBodySize)
+}
+
+// UploadLimit middleware raises the limit of the BodyLimit middleware to the config's MaxUploadSize for routes accepting
+// file uploads. Without a config the default is used. Routes with a limit of their own use LimitBody instead, e.g.:
+//
+//	router.With(web.UploadLimit(webCtx.Config.Limits)).Post("/import", importController(appCtx, webCtx).ServeHTTP)
+func UploadLimit(cfg *LimitCfg) func(http.Handler) http.Handler {
+	maxUploadSize := int64(DefaultMaxUploadSize)
+	if cfg != nil && cfg.MaxUploadSize > 0 {
+		maxUploadSize = cfg.MaxUploadSize
+	}
+
+	return LimitBody(maxUploadSize)
+}
+
+// LimitBody middleware limits the size of the request body to n bytes. It replaces the limit of the BodyLimit middleware
+// and can therefore raise or lower it for a route, e.g.:
+//
+//	router.With(web.LimitBody(100 << 20)).Post("/upload", uploadController(appCtx, webCtx).ServeHTTP)
+func LimitBody(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limitBody(w, r, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsBodyTooLarge returns true if the error is caused by a request body exceeding its limit.
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// limitBody limits the request's body to n bytes. An already limited body is limited anew.
+func limitBody(w http.ResponseWriter, r *http.Request, n int64) {
+	if r.Body == nil {
+		return
+	}
+
+	original := r.Body
+	if limited, ok := r.Body.(*limitedBody); ok {
+		original = limited.original
+	}
+
+	r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, original, n), original: original}
+}
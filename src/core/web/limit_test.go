@@ -0,0 +1,65 @@
+package web
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	appCtx, webCtx := setupMockCtxs(t)
+
+	router := webCtx.Router
+	router.Use(BodyLimit(&LimitCfg{MaxBodySize: 16, MaxUploadSize: 64}))
+	read := func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		if IsBodyTooLarge(err) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}
+	router.Post("/body", read)
+	router.With(LimitBody(32)).Post("/raised", read)
+	router.With(UploadLimit(&LimitCfg{MaxBodySize: 16, MaxUploadSize: 64})).Post("/upload", read)
+	router.Post("/form", NewController(appCtx, webCtx, func(io IO) error {
+		err, _ := ReadForm(io.Request(), &formTestStruct{}, nil)
+		if err != nil {
+			return io.InlineError(ErrInternal, err)
+		}
+
+		return nil
+	}).ServeHTTP)
+
+	post := func(path string, contentType string, body string) int {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post("/body", "text/plain", strings.Repeat("a", 16)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/body", "text/plain", strings.Repeat("a", 17)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/body", "multipart/form-data; boundary=x", strings.Repeat("a", 17)), "the content type does not raise the limit")
+	assert.Equal(t, http.StatusOK, post("/upload", "multipart/form-data; boundary=x", strings.Repeat("a", 64)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/upload", "multipart/form-data; boundary=x", strings.Repeat("a", 65)))
+	assert.Equal(t, http.StatusOK, post("/raised", "text/plain", strings.Repeat("a", 32)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/raised", "text/plain", strings.Repeat("a", 33)))
+
+	values := url.Values{"Name": {strings.Repeat("a", 32)}}.Encode()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/form", "application/x-www-form-urlencoded", values))
+}
+
+func TestErrorMappingResolveBodyTooLarge(t *testing.T) {
+	err := errors.Join(ErrInternalReadForm, &http.MaxBytesError{Limit: 16})
+
+	resolved := NewErrorMapping().Resolve(ErrInternal, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resolved.Status)
+	require.ErrorIs(t, resolved, ErrBodyTooLarge)
+}
@@ -0,0 +1,130 @@
+package web
+
+import (
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
+	markdownBullet  = regexp.MustCompile(`^[-*]\s+(.+)$`)
+	markdownOrdered = regexp.MustCompile(`^\d+\.\s+(.+)$`)
+	markdownLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s*]+)\)`)
+	markdownBold    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownItalic  = regexp.MustCompile(`\*(.+?)\*`)
+)
+
+// Markdown renders a subset of Markdown as HTML: paragraphs, headings (#), unordered (- or *) and ordered (1.) lists,
+// **bold**, *italic*, `code` and [links](https://example.com). The output is sanitized by escaping all HTML
+// of the input before it is rendered, links are only rendered for http(s), mailto and relative URLs.
+// Markdown is meant for user content like descriptions, it is no complete Markdown implementation.
+func Markdown(s string) template.HTML {
+	var b strings.Builder
+
+	for _, block := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n\n") {
+		lines := nonEmptyLines(block)
+		if len(lines) == 0 {
+			continue
+		}
+
+		switch {
+		case len(lines) == 1 && markdownHeading.MatchString(lines[0]):
+			match := markdownHeading.FindStringSubmatch(lines[0])
+			level := string(rune('0' + len(match[1])))
+			b.WriteString("<h" + level + ">" + markdownInline(match[2]) + "</h" + level + ">")
+		case allMatch(lines, markdownBullet):
+			markdownList(&b, "ul", lines, markdownBullet)
+		case allMatch(lines, markdownOrdered):
+			markdownList(&b, "ol", lines, markdownOrdered)
+		default:
+			inline := make([]string, len(lines))
+			for i, line := range lines {
+				inline[i] = markdownInline(line)
+			}
+			b.WriteString("<p>" + strings.Join(inline, "<br>") + "</p>")
+		}
+	}
+
+	return template.HTML(b.String())
+}
+
+// markdownList writes the lines as list items of the list element (ul or ol). The item is the pattern's first group.
+func markdownList(b *strings.Builder, element string, lines []string, pattern *regexp.Regexp) {
+	b.WriteString("<" + element + ">")
+	for _, line := range lines {
+		b.WriteString("<li>" + markdownInline(pattern.FindStringSubmatch(line)[1]) + "</li>")
+	}
+	b.WriteString("</" + element + ">")
+}
+
+// markdownInline escapes the text and renders the inline elements. Code spans are not formatted any further.
+func markdownInline(text string) string {
+	segments := strings.Split(template.HTMLEscapeString(text), "`")
+
+	var b strings.Builder
+	for i, segment := range segments {
+		// an even number of segments means the last backtick is unclosed, it is kept as it is
+		unclosed := len(segments)%2 == 0 && i == len(segments)-1
+		switch {
+		case i%2 == 1 && !unclosed:
+			b.WriteString("<code>" + segment + "</code>")
+		case i%2 == 1:
+			b.WriteString("`" + markdownFormat(segment))
+		default:
+			b.WriteString(markdownFormat(segment))
+		}
+	}
+
+	return b.String()
+}
+
+// markdownFormat renders links, bold and italic text of the escaped text.
+func markdownFormat(escaped string) string {
+	escaped = markdownLink.ReplaceAllStringFunc(escaped, func(link string) string {
+		match := markdownLink.FindStringSubmatch(link)
+		if !isSafeMarkdownURL(match[2]) {
+			return match[1]
+		}
+
+		return `<a href="` + match[2] + `" rel="noopener noreferrer nofollow">` + match[1] + `</a>`
+	})
+	escaped = markdownBold.ReplaceAllString(escaped, "<strong>$1</strong>")
+
+	return markdownItalic.ReplaceAllString(escaped, "<em>$1</em>")
+}
+
+// isSafeMarkdownURL returns true for http(s) and mailto URLs and relative URLs. Other schemes (e.g. javascript:) are unsafe.
+func isSafeMarkdownURL(url string) bool {
+	lower := strings.ToLower(url)
+	for _, prefix := range []string{"http://", "https://", "mailto:", "/", "#"} {
+		if strings.HasPrefix(lower, prefix) {
+			return !strings.HasPrefix(lower, "//")
+		}
+	}
+
+	return false
+}
+
+// nonEmptyLines returns the trimmed lines of the block that are not empty.
+func nonEmptyLines(block string) []string {
+	var lines []string
+	for _, line := range strings.Split(block, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// allMatch returns true if all lines match the pattern.
+func allMatch(lines []string, pattern *regexp.Regexp) bool {
+	for _, line := range lines {
+		if !pattern.MatchString(line) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,32 @@
+package web
+
+import (
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		expected template.HTML
+	}{
+		{"paragraphs", "first line\nsecond line\n\nnext paragraph", "<p>first line<br>second line</p><p>next paragraph</p>"},
+		{"heading", "## Requirements", "<h2>Requirements</h2>"},
+		{"lists", "- one\n- *two*\n\n1. first\n2. second", "<ul><li>one</li><li><em>two</em></li></ul><ol><li>first</li><li>second</li></ol>"},
+		{"inline", "**bold**, *italic* and `**code**`", "<p><strong>bold</strong>, <em>italic</em> and <code>**code**</code></p>"},
+		{"unclosed code", "a ` b", "<p>a ` b</p>"},
+		{"link", "[docs](https://example.com/a?b=1&c=2)", `<p><a href="https://example.com/a?b=1&amp;c=2" rel="noopener noreferrer nofollow">docs</a></p>`},
+		{"unsafe link", "[click](javascript:alert)", "<p>click</p>"},
+		{"protocol relative link", "[click](//evil.example)", "<p>click</p>"},
+		{"html is escaped", "<script>alert('x')</script> <b onclick=\"x\">b</b>", "<p>&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt; &lt;b onclick=&#34;x&#34;&gt;b&lt;/b&gt;</p>"},
+		{"empty", "\n\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Markdown(test.markdown))
+		})
+	}
+}
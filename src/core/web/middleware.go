@@ -1,7 +1,13 @@
 package web
 
 import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"net/http"
+	"runtime/debug"
 )
 
 var (
@@ -9,6 +15,69 @@ var (
 	CleanPath = middleware.CleanPath
 	// Heartbeat creates a heartbeat endpoint. It is a wrapper for middleware.Heartbeat.
 	Heartbeat = middleware.Heartbeat
-	// Recoverer middleware recovers from panics and writes a 500 status if there was one. It is a wrapper for middleware.Recoverer.
-	Recoverer = middleware.Recoverer
+	// RequestID middleware sets a request ID on the context of each request. It is a wrapper for middleware.RequestID.
+	// The request ID is added to error reports by the Recoverer if the RequestID middleware runs before it.
+	RequestID = middleware.RequestID
 )
+
+// Recoverer middleware recovers from panics, logs them and writes a 500 status if there was one.
+// The panic is reported with its stack trace through the reporter of the application context if one is set.
+// Reports are tagged with the request ID, method, url and route as well as the tags added to the request's
+// trace.ReportScope further down the middleware chain, e.g. the user ID.
+//
+// As http.ErrAbortHandler is used to abort a response deliberately, it is not recovered.
+func Recoverer(appCtx *hctx.AppCtx) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, _ := trace.WithReportScope(r.Context())
+			r = r.WithContext(ctx)
+
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+
+				err := fmt.Errorf("panic: %v", rvr)
+				stack := debug.Stack()
+				tags := requestReportTags(r)
+
+				appCtx.Error(Pkg, "recovered from panic", err, "requestID", tags["request_id"], "route", tags["route"], "stack", string(stack))
+				if appCtx.Reporter != nil {
+					appCtx.Reporter.Report(r.Context(), &trace.Report{Mod: Pkg, Err: err, Stack: stack, Tags: tags})
+				}
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestReportTags returns the tags describing the request for error reports.
+// The route is the matched route pattern, e.g. /template/{id}/edit, and is empty if no route matched (yet).
+func requestReportTags(r *http.Request) map[string]string {
+	tags := map[string]string{
+		"method": r.Method,
+		"url":    r.URL.String(),
+	}
+
+	if requestID := middleware.GetReqID(r.Context()); requestID != "" {
+		tags["request_id"] = requestID
+	}
+
+	if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+		if route := routeCtx.RoutePattern(); route != "" {
+			tags["route"] = route
+		}
+	}
+
+	return tags
+}
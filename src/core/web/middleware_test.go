@@ -0,0 +1,82 @@
+package web
+
+import (
+	"context"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type recordingReporter struct {
+	mu      sync.Mutex
+	reports []*trace.Report
+	tags    []map[string]string
+}
+
+func (r *recordingReporter) Report(ctx context.Context, report *trace.Report) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reports = append(r.reports, report)
+	r.tags = append(r.tags, trace.ReportTags(ctx))
+}
+
+func TestRecoverer(t *testing.T) {
+	app, ctx := setupMockCtxs(t)
+	reporter := &recordingReporter{}
+	app.Reporter = reporter
+
+	router := ctx.Router
+	router.Use(RequestID, Recoverer(app))
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace.SetReportTag(r.Context(), "user_id", "user")
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Get("/panic/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	router.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/ok", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, reporter.reports)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/panic/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	require.Len(t, reporter.reports, 1)
+	report := reporter.reports[0]
+	assert.Equal(t, Pkg, report.Mod)
+	assert.EqualError(t, report.Err, "panic: boom")
+	assert.NotEmpty(t, report.Stack)
+	assert.Equal(t, "/panic/{id}", report.Tags["route"])
+	assert.Equal(t, "GET", report.Tags["method"])
+	assert.NotEmpty(t, report.Tags["request_id"])
+	assert.Equal(t, "user", reporter.tags[0]["user_id"])
+}
+
+func TestRecovererAbortHandler(t *testing.T) {
+	app, ctx := setupMockCtxs(t)
+	reporter := &recordingReporter{}
+	app.Reporter = reporter
+
+	handler := Recoverer(app)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	ctx.Router.Get("/abort", handler.ServeHTTP)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		ctx.Router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+	})
+	assert.Empty(t, reporter.reports)
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -9,23 +10,38 @@ import (
 // The navbar is built by calling Build. Build will call the Display function of each NavItem to determine if it should be displayed.
 // The Display function is called with the current IO. The IO can be used to determine if the user is logged in or not.
 //
-// Navigation also sorts the NavItems by their Position.
+// Navigation also sorts the NavItems by their Position, this includes the sub items of each NavItem.
 // If two items have the same Position the order is undefined. The Navigation will cache the sorted items.
+// NavItems can be nested into groups (displayed as dropdowns) by setting the NavItem.Parent to the name of the group.
+// This allows modules to add items to groups registered by other modules.
+//
+// NavItems requiring a permission (NavItem.Permission) are only displayed if the PermissionChecker allows it.
+// Without a PermissionChecker these items are never displayed.
 //
 // Navigation is safe for concurrent use by multiple goroutines.
 type Navigation struct {
-	items    map[string]NavItem
-	mu       sync.RWMutex
-	sorted   []NavItem
-	sortedMu sync.Mutex
+	items             map[string]NavItem
+	mu                sync.RWMutex
+	sorted            []NavItem
+	sortedMu          sync.Mutex
+	permissionChecker PermissionChecker
 }
 
+// PermissionChecker checks if the current user (determined through the web.IO) has the permission.
+// It is used by the Navigation to determine if a NavItem requiring a permission should be displayed.
+type PermissionChecker func(io IO, permission string) (bool, error)
+
 // NavItem is a single item in the navigation bar. It can either be part of the Navigation or a sub item of another NavItem.
 // NavItem.active will be determined by the Navigation.Build depending on the current URL.
 // Set NavItem.Redirect to true if the item should redirect to NavItem.URL (e.g. for logout) otherwise the item might be an HTMX boosted link.
 // In that case the item will be loaded via HTMX and the URL will be changed to NavItem.URL. However, the URL will not be reloaded.
 // During Navigation.Built the Display function will be called to determine if the item should be displayed.
 // Also, the NavItem.Position will be used to sort the items. Items with a lower Position will be displayed first.
+//
+// An item with sub items is displayed as a group (dropdown). A group without a URL is only displayed if any of its sub items is displayed.
+// Sub items can be set directly through NavItem.Items or by adding items to the Navigation with NavItem.Parent set to the group's name.
+// By default, an item is active if its URL equals the current URL path. NavItem.ActivePrefixes allow to mark an item
+// as active for further paths, e.g. the detail pages of a list. A group is active if any of its sub items is active.
 type NavItem struct {
 	active   bool
 	Redirect bool
@@ -34,6 +50,12 @@ type NavItem struct {
 	Items    []NavItem
 	Display  func(io IO) (bool, error)
 	Position int
+	// Parent is the name of the NavItem this item is nested into. If the parent does not exist, the item is displayed at the top level.
+	Parent string
+	// Permission is the permission required to display the item. It is checked using the Navigation's PermissionChecker.
+	Permission string
+	// ActivePrefixes are URL path prefixes for which the item is active in addition to its URL.
+	ActivePrefixes []string
 }
 
 // Active returns true if the item is active. An item is active if the current URL matches the item URL.
@@ -41,6 +63,26 @@ func (i *NavItem) Active() bool {
 	return i.active
 }
 
+// IsGroup returns true if the item has sub items and should therefore be displayed as a group (dropdown).
+func (i *NavItem) IsGroup() bool {
+	return len(i.Items) > 0
+}
+
+// matches returns true if the item's URL equals the path or the path starts with any of the item's ActivePrefixes.
+func (i *NavItem) matches(path string) bool {
+	if i.URL != "" && i.URL == path {
+		return true
+	}
+
+	for _, prefix := range i.ActivePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // display is a nil-safe wrapper around the NavItem.Display function. It returns true if the Display function is nil.
 // Otherwise, it calls the Display function with the given IO and returns its result.
 func (i *NavItem) display(io IO) (bool, error) {
@@ -81,6 +123,14 @@ func (n *Navigation) Remove(name string) {
 	n.sortedMu.Unlock()
 }
 
+// SetPermissionChecker sets the PermissionChecker used to determine if NavItems requiring a permission are displayed.
+func (n *Navigation) SetPermissionChecker(checker PermissionChecker) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.permissionChecker = checker
+}
+
 // Item returns the NavItem with the given name and a boolean indicating if the item was found.
 // It can be used as a Lookup function.
 func (n *Navigation) Item(name string) (NavItem, bool) {
@@ -91,7 +141,8 @@ func (n *Navigation) Item(name string) (NavItem, bool) {
 	return item, ok
 }
 
-// Items returns a slice of all NavItems in the Navigation sorted by their Position.
+// Items returns a slice of all top level NavItems in the Navigation sorted by their Position.
+// Items with a NavItem.Parent are nested into the Items of their parent, the sub items are sorted by their Position as well.
 // The slice is cached and will not be recalculated until the Navigation is modified.
 func (n *Navigation) Items() []NavItem {
 	n.sortedMu.Lock()
@@ -101,27 +152,48 @@ func (n *Navigation) Items() []NavItem {
 		return n.sorted
 	}
 
-	n.sorted = make([]NavItem, 0, len(n.items))
-
 	n.mu.RLock()
-	items := n.items
+	items := make(map[string]NavItem, len(n.items))
+	for name, item := range n.items {
+		items[name] = item
+	}
 	n.mu.RUnlock()
-	for _, item := range items {
-		n.sorted = append(n.sorted, item)
+
+	children := make(map[string][]string)
+	for name, item := range items {
+		if _, ok := items[item.Parent]; ok && item.Parent != name {
+			children[item.Parent] = append(children[item.Parent], name)
+		}
 	}
 
-	sort.Slice(n.sorted, func(i, j int) bool {
-		return n.sorted[i].Position < n.sorted[j].Position
-	})
+	n.sorted = make([]NavItem, 0, len(items))
+	for name, item := range items {
+		if _, ok := items[item.Parent]; ok && item.Parent != name {
+			continue
+		}
+
+		n.sorted = append(n.sorted, nestItems(name, items, children, map[string]bool{}))
+	}
+
+	sortItems(n.sorted)
 
 	return n.sorted
 }
 
 // Build builds a slice of NavItems that should be displayed based on the web.IO as the current context.
-// Build internally calls BuildNavigation on the Navigation items.
+// Build internally calls BuildNavigation on the Navigation items the current user is permitted to see.
 // Therefore, the NavItems will be sorted by their Position and then evaluated based on the BuildNavigation function. (see BuildNavigation for more details)
 func (n *Navigation) Build(io IO) ([]NavItem, error) {
-	return BuildNavigation(n.Items(), io)
+	n.mu.RLock()
+	checker := n.permissionChecker
+	n.mu.RUnlock()
+
+	items, err := permittedItems(n.Items(), io, checker)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildNavigation(items, io)
 }
 
 // BuildNavigation builds a slice of NavItems that should be displayed based on the web.IO as the current context.
@@ -133,7 +205,8 @@ func (n *Navigation) Build(io IO) ([]NavItem, error) {
 //
 // BuildNavigation will call the Display function of each NavItem to determine if it should be displayed.
 // Also, NavItems with a non-empty Items slice will be recursively evaluated and the current item will be set to active if any of its children is active.
-// An Item will also be set to active if its URL matches the current URL.Path.
+// An Item will also be set to active if its URL matches the current URL.Path or the path starts with one of its NavItem.ActivePrefixes.
+// Groups without a URL whose sub items are all hidden are not displayed.
 func BuildNavigation(navigation []NavItem, io IO, parent ...*NavItem) ([]NavItem, error) {
 	// TODO show profile/login/logout links on the right (maybe this is a separate navigation bar?)
 
@@ -159,12 +232,8 @@ func BuildNavigation(navigation []NavItem, io IO, parent ...*NavItem) ([]NavItem
 			continue
 		}
 
-		if item.URL == io.Request().URL.Path {
+		if item.matches(io.Request().URL.Path) {
 			item.active = true
-
-			if singleParent != nil {
-				singleParent.active = true
-			}
 		}
 
 		if len(item.Items) > 0 {
@@ -174,6 +243,14 @@ func BuildNavigation(navigation []NavItem, io IO, parent ...*NavItem) ([]NavItem
 			}
 
 			item.Items = subNavigation
+
+			if len(subNavigation) == 0 && item.URL == "" {
+				continue // empty group
+			}
+		}
+
+		if item.active && singleParent != nil {
+			singleParent.active = true
 		}
 
 		nav = append(nav, item)
@@ -181,3 +258,67 @@ func BuildNavigation(navigation []NavItem, io IO, parent ...*NavItem) ([]NavItem
 
 	return nav, nil
 }
+
+// nestItems returns the item with the given name and appends the items registered with the item as their NavItem.Parent
+// to its sub items. It is called recursively for the sub items. visited protects against cyclic parent relations.
+func nestItems(name string, items map[string]NavItem, children map[string][]string, visited map[string]bool) NavItem {
+	item := items[name]
+	if visited[name] {
+		return item
+	}
+	visited[name] = true
+
+	nested := append([]NavItem(nil), item.Items...)
+	for _, child := range children[name] {
+		nested = append(nested, nestItems(child, items, children, visited))
+	}
+
+	sortItems(nested)
+	item.Items = nested
+
+	return item
+}
+
+// permittedItems returns a copy of the items (including sub items) without the items the current user has no permission for.
+// Groups without a URL are dropped if none of their sub items are permitted.
+func permittedItems(items []NavItem, io IO, checker PermissionChecker) ([]NavItem, error) {
+	permitted := make([]NavItem, 0, len(items))
+	for _, item := range items {
+		if item.Permission != "" {
+			if checker == nil {
+				continue
+			}
+
+			ok, err := checker(io, item.Permission)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if len(item.Items) > 0 {
+			subItems, err := permittedItems(item.Items, io, checker)
+			if err != nil {
+				return nil, err
+			}
+
+			item.Items = subItems
+
+			if len(subItems) == 0 && item.URL == "" {
+				continue // empty group
+			}
+		}
+
+		permitted = append(permitted, item)
+	}
+
+	return permitted, nil
+}
+
+func sortItems(items []NavItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Position < items[j].Position
+	})
+}
@@ -180,3 +180,100 @@ func TestBuildNavigation_NotOrdered(t *testing.T) {
 	assert.Equal(t, "/first", builtItems[0].URL) // items are NOT sorted by BuildNavigation, only by Navigation.Items
 	assert.Equal(t, "/second", builtItems[1].URL)
 }
+
+func TestNavigationItems_Nested(t *testing.T) {
+	navigation := NewNavigation()
+
+	navigation.Add("group", NavItem{Name: "Group", Position: 2})
+	navigation.Add("group.second", NavItem{Name: "Second", Parent: "group", Position: 2})
+	navigation.Add("group.first", NavItem{Name: "First", Parent: "group", Position: 1})
+	navigation.Add("orphan", NavItem{Name: "Orphan", Parent: "missing", Position: 1})
+
+	items := navigation.Items()
+	require.Len(t, items, 2)
+	assert.Equal(t, "Orphan", items[0].Name, "items with an unknown parent are displayed at the top level")
+	assert.Equal(t, "Group", items[1].Name)
+	assert.True(t, items[1].IsGroup())
+	require.Len(t, items[1].Items, 2)
+	assert.Equal(t, "First", items[1].Items[0].Name)
+	assert.Equal(t, "Second", items[1].Items[1].Name)
+}
+
+func TestBuildNavigation_ActivePrefixes(t *testing.T) {
+	io := newMockIO("/template-set/123/edit")
+
+	items := []NavItem{
+		{URL: "/template-set/list", ActivePrefixes: []string{"/template-set/"}},
+		{URL: "/eiffel", ActivePrefixes: []string{"/eiffel/"}},
+	}
+	builtItems, err := BuildNavigation(items, io)
+
+	require.NoError(t, err)
+	assert.True(t, builtItems[0].Active())
+	assert.False(t, builtItems[1].Active())
+}
+
+func TestBuildNavigation_GrandparentActive(t *testing.T) {
+	io := newMockIO("/deep")
+
+	items := []NavItem{{
+		Name: "Top",
+		Items: []NavItem{{
+			Name:  "Middle",
+			Items: []NavItem{{URL: "/deep"}},
+		}},
+	}}
+	builtItems, err := BuildNavigation(items, io)
+
+	require.NoError(t, err)
+	assert.True(t, builtItems[0].Items[0].Active())
+	assert.True(t, builtItems[0].Active())
+}
+
+func TestBuildNavigation_HidesEmptyGroups(t *testing.T) {
+	io := newMockIO("/")
+	hidden := func(io IO) (bool, error) { return false, nil }
+
+	items := []NavItem{
+		{Name: "Empty", Items: []NavItem{{URL: "/hidden", Display: hidden}}},
+		{Name: "WithURL", URL: "/group", Items: []NavItem{{URL: "/hidden", Display: hidden}}},
+	}
+	builtItems, err := BuildNavigation(items, io)
+
+	require.NoError(t, err)
+	require.Len(t, builtItems, 1)
+	assert.Equal(t, "WithURL", builtItems[0].Name)
+}
+
+func TestNavigationBuild_Permissions(t *testing.T) {
+	io := newMockIO("/")
+	navigation := NewNavigation()
+
+	navigation.Add("public", NavItem{Name: "Public", URL: "/public", Position: 1})
+	navigation.Add("admin", NavItem{Name: "Admin", URL: "/admin", Permission: "admin", Position: 2})
+	navigation.Add("group", NavItem{Name: "Group", Position: 3})
+	navigation.Add("group.admin", NavItem{Name: "GroupAdmin", URL: "/group/admin", Parent: "group", Permission: "admin"})
+
+	builtItems, err := navigation.Build(io)
+	require.NoError(t, err)
+	require.Len(t, builtItems, 1, "items requiring a permission are hidden without a checker")
+	assert.Equal(t, "Public", builtItems[0].Name)
+
+	navigation.SetPermissionChecker(func(io IO, permission string) (bool, error) {
+		return permission == "admin", nil
+	})
+
+	builtItems, err = navigation.Build(io)
+	require.NoError(t, err)
+	require.Len(t, builtItems, 3)
+	assert.Equal(t, "Admin", builtItems[1].Name)
+	assert.Equal(t, "GroupAdmin", builtItems[2].Items[0].Name)
+
+	expectedError := errors.New("permission error")
+	navigation.SetPermissionChecker(func(io IO, permission string) (bool, error) {
+		return false, expectedError
+	})
+
+	_, err = navigation.Build(io)
+	assert.ErrorIs(t, err, expectedError)
+}
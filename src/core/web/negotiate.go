@@ -0,0 +1,112 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FormatQueryParam is the query parameter overriding the Accept header in the content negotiation, see WantsJSON.
+	FormatQueryParam = "format"
+	// FormatJSON is the value of the FormatQueryParam requesting a JSON response.
+	FormatJSON = "json"
+	// FormatHTML is the value of the FormatQueryParam requesting an HTML response.
+	FormatHTML = "html"
+	// ContentTypeJSON is the content type of JSON responses.
+	ContentTypeJSON = "application/json"
+)
+
+// JSONError is the response body of errors rendered for clients requesting JSON, see IO.Error.
+type JSONError struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+// WantsJSON returns true if the client prefers a JSON response over an HTML response. The FormatQueryParam takes precedence,
+// otherwise JSON is preferred if the Accept header rates application/json higher than text/html.
+// Wildcards do not count, a client accepting any media type receives HTML. HTMX requests always receive HTML.
+func WantsJSON(r *http.Request) bool {
+	if IsHTMX(r) {
+		return false
+	}
+
+	switch r.URL.Query().Get(FormatQueryParam) {
+	case FormatJSON:
+		return true
+	case FormatHTML:
+		return false
+	}
+
+	jsonQuality, htmlQuality := 0.0, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case ContentTypeJSON:
+			jsonQuality = max(jsonQuality, quality)
+		case "text/html", "application/xhtml+xml":
+			htmlQuality = max(htmlQuality, quality)
+		}
+	}
+
+	return jsonQuality > 0 && jsonQuality > htmlQuality
+}
+
+// WantsJSON implements the web.IO interface on HIO, see WantsJSON.
+func (io *HIO) WantsJSON() bool {
+	return WantsJSON(io.request)
+}
+
+// JSON implements the web.IO interface on HIO by writing the data as JSON with the status code 200.
+func (io *HIO) JSON(data any) error {
+	return writeJSON(io.writer, http.StatusOK, data)
+}
+
+// RespondNegotiated implements the web.IO interface on HIO by writing the data as JSON if the client prefers JSON (see WantsJSON)
+// and rendering the template (see Render) otherwise. The response varies by the Accept header, caches must therefore consider it.
+func (io *HIO) RespondNegotiated(data any, name string, paths ...string) error {
+	io.writer.Header().Add("Vary", "Accept")
+
+	if io.WantsJSON() {
+		return io.JSON(data)
+	}
+
+	return io.Render(data, name, paths...)
+}
+
+// errsJSON writes the resolved error as JSONError for clients requesting JSON, see errs.
+func (io *HIO) errsJSON(resolved *HTTPError) error {
+	return writeJSON(io.writer, resolved.Status, JSONError{
+		Status: resolved.Status,
+		Error:  io.Translator().T(resolved.Error()),
+	})
+}
+
+// writeJSON encodes the data as JSON and writes it with the status code to the client.
+// Like executeTemplate, nothing is written if the data could not be encoded.
+func writeJSON(w http.ResponseWriter, status int, data any) error {
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(status)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
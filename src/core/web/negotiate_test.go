@@ -0,0 +1,81 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWantsJSON(t *testing.T) {
+	tests := []struct {
+		target string
+		accept string
+		htmx   bool
+		want   bool
+	}{
+		{target: "/", accept: "", want: false},
+		{target: "/", accept: "text/html,application/xhtml+xml,*/*;q=0.8", want: false},
+		{target: "/", accept: "*/*", want: false},
+		{target: "/", accept: "application/json", want: true},
+		{target: "/", accept: "application/json, text/plain, */*", want: true},
+		{target: "/", accept: "text/html;q=0.5, application/json;q=0.9", want: true},
+		{target: "/", accept: "text/html, application/json", want: false},
+		{target: "/", accept: "application/json;q=0", want: false},
+		{target: "/?format=json", accept: "text/html", want: true},
+		{target: "/?format=html", accept: "application/json", want: false},
+		{target: "/?format=json", htmx: true, want: false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.target, nil)
+		req.Header.Set("Accept", test.accept)
+		if test.htmx {
+			req.Header.Set("HX-Request", "true")
+		}
+
+		assert.Equal(t, test.want, WantsJSON(req), "target %s, accept %q", test.target, test.accept)
+	}
+}
+
+func TestRespondNegotiated(t *testing.T) {
+	app, ctx := setupMockCtxs(t)
+
+	negotiated := NewController(app, ctx, func(io IO) error {
+		return io.RespondNegotiated("content-string", "printer", "partial.go.html", "printer.go.html")
+	})
+	negotiatedError := NewController(app, ctx, func(io IO) error {
+		return io.Error(errors.Join(persistence.ErrReadRow, persistence.ErrNotFound))
+	})
+
+	router := ctx.Router
+	router.Get("/negotiated", negotiated.ServeHTTP)
+	router.Get("/negotiated-error", negotiatedError.ServeHTTP)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/negotiated", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+	assert.Contains(t, recorder.Body.String(), "partial-appendix")
+
+	recorder = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/negotiated", nil)
+	req.Header.Set("Accept", ContentTypeJSON)
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, ContentTypeJSON, recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `"content-string"`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/negotiated-error?format=json", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, ContentTypeJSON, recorder.Header().Get("Content-Type"))
+
+	var jsonErr JSONError
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&jsonErr))
+	assert.Equal(t, JSONError{Status: http.StatusNotFound, Error: "harmony.error.not-found"}, jsonErr)
+}
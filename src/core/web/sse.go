@@ -0,0 +1,290 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"github.com/org-harmony/harmony/src/core/event"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeartbeatInterval is the interval in which an EventStream sends a comment to the client to keep the connection alive.
+// Proxies tend to close connections without any traffic.
+const HeartbeatInterval = 15 * time.Second
+
+// BrokerBufferSize is the number of StreamEvents buffered per subscriber of a Broker.
+// If a subscriber's buffer is full, further events are dropped for that subscriber.
+const BrokerBufferSize = 16
+
+var (
+	// ErrStreamingUnsupported is returned by IO.EventStream if the http.ResponseWriter does not support flushing.
+	ErrStreamingUnsupported = errors.New("streaming unsupported")
+	// ErrStreamClosed is returned by EventStream.Send if the stream is closed, e.g. because the client disconnected.
+	ErrStreamClosed = errors.New("event stream closed")
+)
+
+// StreamEvent is a single server-sent event. Only Data is required.
+// If Event is empty, the client receives the event as a "message" event.
+// Data may contain multiple lines, each line is sent as a separate data field.
+type StreamEvent struct {
+	ID    string
+	Event string
+	Data  string
+	// Retry is the reconnection time in milliseconds the client should wait before reconnecting. 0 omits the field.
+	Retry int
+}
+
+// EventStream is a stream of server-sent events (SSE) to a single client. It is created by IO.EventStream.
+// The EventStream sends a heartbeat in the HeartbeatInterval and is closed when the client disconnects.
+// EventStream is safe for concurrent use by multiple goroutines.
+type EventStream struct {
+	writer     http.ResponseWriter
+	controller *http.ResponseController
+	mu         sync.Mutex
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// Broker distributes StreamEvents to the subscribers of a topic. Modules publish to the broker
+// and controllers forward the events of a topic to an EventStream:
+//
+//	stream, err := io.EventStream()
+//	...
+//	events, unsubscribe := webCtx.Broker.Subscribe("template.123")
+//	defer unsubscribe()
+//	return stream.Forward(events)
+//
+// Publishing never blocks, events are dropped for subscribers that do not keep up (see BrokerBufferSize).
+// Broker is safe for concurrent use by multiple goroutines.
+type Broker struct {
+	subscribers map[string]map[chan StreamEvent]struct{}
+	mu          sync.RWMutex
+}
+
+// String returns the event in the wire format of server-sent events including the terminating blank line.
+func (e StreamEvent) String() string {
+	var b strings.Builder
+
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + e.Event + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString(fmt.Sprintf("retry: %d\n", e.Retry))
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// NewEventStream upgrades the response to a stream of server-sent events. It writes the headers, flushes them
+// and starts sending heartbeats in the passed in interval until the request's context is done or the stream is closed.
+// An interval <= 0 disables heartbeats. Any write deadline of the server is removed for the response.
+// ErrStreamingUnsupported is returned if the http.ResponseWriter can not be flushed.
+//
+// Usually, IO.EventStream should be used instead of calling NewEventStream directly.
+func NewEventStream(w http.ResponseWriter, r *http.Request, heartbeat time.Duration) (*EventStream, error) {
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := controller.Flush(); err != nil {
+		return nil, errors.Join(ErrStreamingUnsupported, err)
+	}
+
+	stream := &EventStream{
+		writer:     w,
+		controller: controller,
+		done:       make(chan struct{}),
+	}
+
+	go stream.keepAlive(r, heartbeat)
+
+	return stream, nil
+}
+
+// Send writes the event to the client and flushes it. ErrStreamClosed is returned if the stream is closed.
+// If writing fails, the stream is closed and the error is returned.
+func (s *EventStream) Send(e StreamEvent) error {
+	return s.write(e.String())
+}
+
+// Forward sends all events received through the channel to the client. It blocks until the channel is closed
+// or the stream is closed, e.g. because the client disconnected. A disconnect is not considered an error.
+func (s *EventStream) Forward(events <-chan StreamEvent) error {
+	for {
+		select {
+		case <-s.done:
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			err := s.Send(e)
+			if errors.Is(err, ErrStreamClosed) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Done returns a channel that is closed when the stream is closed.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close closes the stream and stops the heartbeat. It is safe to call Close multiple times.
+// The connection itself is closed after the controller returned.
+func (s *EventStream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// write writes the raw message to the client and flushes it. The stream is closed if writing fails.
+func (s *EventStream) write(message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return ErrStreamClosed
+	default:
+	}
+
+	_, err := s.writer.Write([]byte(message))
+	if err == nil {
+		err = s.controller.Flush()
+	}
+	if err != nil {
+		s.Close()
+		return err
+	}
+
+	return nil
+}
+
+// keepAlive sends heartbeats in the given interval and closes the stream once the request's context is done.
+func (s *EventStream) keepAlive(r *http.Request, interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-r.Context().Done():
+			s.Close()
+			return
+		case <-tick:
+			if err := s.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// NewBroker creates a new Broker without any subscribers.
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[chan StreamEvent]struct{}),
+	}
+}
+
+// Subscribe subscribes to the topic. The returned channel receives all events published to the topic.
+// The returned function unsubscribes from the topic and closes the channel, it has to be called once the subscriber is done.
+func (b *Broker) Subscribe(topic string) (<-chan StreamEvent, func()) {
+	events := make(chan StreamEvent, BrokerBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan StreamEvent]struct{})
+	}
+	b.subscribers[topic][events] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subscribers[topic], events)
+			if len(b.subscribers[topic]) == 0 {
+				delete(b.subscribers, topic)
+			}
+
+			close(events)
+		})
+	}
+
+	return events, unsubscribe
+}
+
+// Publish publishes the event to all subscribers of the topic without blocking.
+// It returns the number of subscribers the event was delivered to.
+func (b *Broker) Publish(topic string, e StreamEvent) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	delivered := 0
+	for subscriber := range b.subscribers[topic] {
+		select {
+		case subscriber <- e:
+			delivered++
+		default: // the subscriber does not keep up, drop the event
+		}
+	}
+
+	return delivered
+}
+
+// Subscribers returns the number of subscribers of the topic.
+func (b *Broker) Subscribers(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subscribers[topic])
+}
+
+// ForwardEvents subscribes to the event of the event manager and publishes it to the Broker.
+// The convert function maps the event to a topic and StreamEvent. If the returned topic is empty, the event is skipped.
+// This allows modules to notify clients about events published through the event manager without depending on the web package.
+func (b *Broker) ForwardEvents(em event.Manager, eventID string, convert func(event.Event) (string, StreamEvent, error)) {
+	em.Subscribe(eventID, func(e event.Event, args *event.PublishArgs) error {
+		topic, streamEvent, err := convert(e)
+		if err != nil {
+			return err
+		}
+		if topic == "" {
+			return nil
+		}
+
+		b.Publish(topic, streamEvent)
+
+		return nil
+	}, event.DefaultPriority)
+}
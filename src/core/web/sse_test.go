@@ -0,0 +1,169 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"github.com/org-harmony/harmony/src/core/event"
+	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type mockEvent struct {
+	id      string
+	payload string
+}
+
+func (e *mockEvent) ID() string {
+	return e.id
+}
+
+func (e *mockEvent) Payload() any {
+	return e.payload
+}
+
+func TestStreamEventString(t *testing.T) {
+	assert.Equal(t, "data: hello\n\n", StreamEvent{Data: "hello"}.String())
+	assert.Equal(
+		t,
+		"id: 1\nevent: update\nretry: 3000\ndata: first\ndata: second\n\n",
+		StreamEvent{ID: "1", Event: "update", Data: "first\r\nsecond", Retry: 3000}.String(),
+	)
+}
+
+func TestEventStream(t *testing.T) {
+	closed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := NewEventStream(w, r, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		require.NoError(t, stream.Send(StreamEvent{Event: "greeting", Data: "hello"}))
+
+		<-stream.Done()
+		assert.ErrorIs(t, stream.Send(StreamEvent{Data: "too late"}), ErrStreamClosed)
+		close(closed)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal(t, "event: greeting\n", readLine(t, reader))
+	assert.Equal(t, "data: hello\n", readLine(t, reader))
+	assert.Equal(t, "\n", readLine(t, reader))
+	assert.Equal(t, ": heartbeat\n", readLine(t, reader))
+
+	cancel()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("stream was not closed after the client disconnected")
+	}
+}
+
+func TestEventStreamForward(t *testing.T) {
+	events := make(chan StreamEvent, 2)
+	events <- StreamEvent{Data: "first"}
+	events <- StreamEvent{Data: "second"}
+	close(events)
+
+	rec := httptest.NewRecorder()
+	stream, err := NewEventStream(rec, httptest.NewRequest(http.MethodGet, "/", nil), 0)
+	require.NoError(t, err)
+
+	require.NoError(t, stream.Forward(events))
+	stream.Close()
+	stream.Close()
+
+	assert.Equal(t, "data: first\n\ndata: second\n\n", rec.Body.String())
+}
+
+func TestEventStreamUnsupported(t *testing.T) {
+	_, err := NewEventStream(&unflushableWriter{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil), 0)
+	assert.ErrorIs(t, err, ErrStreamingUnsupported)
+}
+
+func TestBroker(t *testing.T) {
+	broker := NewBroker()
+
+	first, unsubscribeFirst := broker.Subscribe("topic")
+	second, unsubscribeSecond := broker.Subscribe("topic")
+	assert.Equal(t, 2, broker.Subscribers("topic"))
+
+	assert.Equal(t, 2, broker.Publish("topic", StreamEvent{Data: "hello"}))
+	assert.Equal(t, 0, broker.Publish("other", StreamEvent{Data: "hello"}))
+	assert.Equal(t, "hello", (<-first).Data)
+	assert.Equal(t, "hello", (<-second).Data)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	_, ok := <-first
+	assert.False(t, ok, "channel is closed after unsubscribing")
+	assert.Equal(t, 1, broker.Subscribers("topic"))
+
+	for i := 0; i < BrokerBufferSize; i++ {
+		broker.Publish("topic", StreamEvent{Data: "fill"})
+	}
+	assert.Equal(t, 0, broker.Publish("topic", StreamEvent{Data: "dropped"}), "events are dropped for full subscribers")
+
+	unsubscribeSecond()
+	assert.Equal(t, 0, broker.Subscribers("topic"))
+}
+
+func TestBrokerForwardEvents(t *testing.T) {
+	em := event.NewManager(trace.NewTestLogger(t))
+	broker := NewBroker()
+
+	broker.ForwardEvents(em, "test.event.forward", func(e event.Event) (string, StreamEvent, error) {
+		payload := e.Payload().(string)
+		if payload == "skip" {
+			return "", StreamEvent{}, nil
+		}
+
+		return "topic", StreamEvent{Event: "forwarded", Data: payload}, nil
+	})
+
+	events, unsubscribe := broker.Subscribe("topic")
+	defer unsubscribe()
+
+	for _, payload := range []string{"skip", "hello"} {
+		dc := make(chan []error)
+		em.Publish(&mockEvent{id: "test.event.forward", payload: payload}, dc)
+		assert.Empty(t, <-dc)
+	}
+
+	select {
+	case e := <-events:
+		assert.Equal(t, StreamEvent{Event: "forwarded", Data: "hello"}, e)
+	default:
+		t.Fatal("event was not forwarded")
+	}
+
+	assert.Len(t, events, 0)
+}
+
+// unflushableWriter hides the http.Flusher implementation of the wrapped http.ResponseWriter.
+type unflushableWriter struct {
+	http.ResponseWriter
+}
+
+func readLine(t *testing.T, reader *bufio.Reader) string {
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+
+	return line
+}
@@ -0,0 +1,40 @@
+package web
+
+import "net/http"
+
+// ThemeCookieName is the name of the cookie storing the user's preferred Theme.
+const ThemeCookieName = "harmony-app-theme"
+
+// Theme is the color theme of the UI. It is used as the value of the data-bs-theme attribute.
+// ThemeSystem is resolved to ThemeLight or ThemeDark on the client according to the prefers-color-scheme media query.
+type Theme string
+
+const (
+	// ThemeLight is the light theme.
+	ThemeLight Theme = "light"
+	// ThemeDark is the dark theme.
+	ThemeDark Theme = "dark"
+	// ThemeSystem follows the theme of the user's operating system. It is the default theme.
+	ThemeSystem Theme = "system"
+)
+
+// Valid returns true if the theme is one of ThemeLight, ThemeDark or ThemeSystem.
+func (t Theme) Valid() bool {
+	return t == ThemeLight || t == ThemeDark || t == ThemeSystem
+}
+
+// ThemeFromRequest returns the theme stored in the ThemeCookieName cookie of the request.
+// If the cookie is not set or contains an invalid theme, ThemeSystem is returned.
+func ThemeFromRequest(request *http.Request) Theme {
+	cookie, err := request.Cookie(ThemeCookieName)
+	if err != nil {
+		return ThemeSystem
+	}
+
+	theme := Theme(cookie.Value)
+	if !theme.Valid() {
+		return ThemeSystem
+	}
+
+	return theme
+}
@@ -0,0 +1,28 @@
+package web
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestThemeFromRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, ThemeSystem, ThemeFromRequest(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: ThemeCookieName, Value: "dark"})
+	assert.Equal(t, ThemeDark, ThemeFromRequest(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: ThemeCookieName, Value: "neon"})
+	assert.Equal(t, ThemeSystem, ThemeFromRequest(req))
+}
+
+func TestThemeValid(t *testing.T) {
+	assert.True(t, ThemeLight.Valid())
+	assert.True(t, ThemeDark.Valid())
+	assert.True(t, ThemeSystem.Valid())
+	assert.False(t, Theme("").Valid())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/org-harmony/harmony/src/core/feature"
 	"github.com/org-harmony/harmony/src/core/hctx"
 	"github.com/org-harmony/harmony/src/core/trans"
 	"github.com/org-harmony/harmony/src/core/util"
@@ -11,6 +12,7 @@ import (
 	"html/template"
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 const (
@@ -42,6 +44,8 @@ var (
 	// Each time Templater.Template is called a new template is created from the Templater's base template through cloning `base.New(name)`.
 	// If, for whatever reason, the template can not be cloned this error is returned.
 	ErrCanNotClone = fmt.Errorf("template not cloned")
+	// ErrBlockNotFound is returned by IO.RenderBlock if none of the templates defines the block.
+	ErrBlockNotFound = fmt.Errorf("template block not found")
 )
 
 // UICfg is the config for the UI. It contains the URI to the assets and the TemplatesCfg.
@@ -63,7 +67,10 @@ type BaseTemplateData struct {
 	Data       any
 	HTMX       bool
 	Navigation []NavItem
-	Extra      map[string]any // Extra might be the user session or other data that is not part of the template data.
+	// Locale is the locale of the request's translator. It is never nil, an empty locale is used without translator.
+	// It provides the writing direction (Locale.Dir) and the language (Locale.Path) of the page.
+	Locale *trans.Locale
+	Extra  map[string]any // Extra might be the user session or other data that is not part of the template data.
 }
 
 // FormData is the generic template data for forms. It contains any form object, a slice of success messages and a map of violations.
@@ -132,12 +139,17 @@ type Templater interface {
 // NewBaseTemplateData returns an instance of BaseTemplateData with the passed in data.
 // It will set the HTMX field based on if the request contains an HX-Request header.
 // The extra data is initialized by executing the extension functions from web.Ctx.Extensions (TemplateDataExtensions).
-// The navigation is built from the passed in web.Ctx.Navigation with web.IO.
+// The navigation is built from the passed in web.Ctx.Navigation with web.IO. The locale is read from the translator in the context.
 func NewBaseTemplateData(appCtx *hctx.AppCtx, webCtx *Ctx, io IO, data any) (*BaseTemplateData, error) {
 	baseData := &BaseTemplateData{
-		Data:  data,
-		HTMX:  io.Request().Header.Get("HX-Request") != "",
-		Extra: make(map[string]any),
+		Data:   data,
+		HTMX:   IsHTMX(io.Request()),
+		Locale: &trans.Locale{},
+		Extra:  make(map[string]any),
+	}
+
+	if translator, ok := util.CtxValue[trans.Translator](io.Context(), trans.TranslatorContextKey); ok && translator.Locale() != nil {
+		baseData.Locale = translator.Locale()
 	}
 
 	navigation, err := webCtx.Navigation.Build(io)
@@ -491,19 +503,35 @@ func (d *FormData[T]) Valid() bool {
 	return len(d.Violations) < 1
 }
 
+// TemplateOption is an option for the templates created by SetupTemplaterStore, BaseTemplate, PartialTemplate and EmptyTemplate.
+type TemplateOption func(*templateOptions)
+
+// templateOptions are the options applied through TemplateOption.
+type templateOptions struct {
+	manifest *AssetManifest
+}
+
+// WithAssetManifest resolves the asset names passed to the asset template function through the AssetManifest.
+// The asset function then returns the fingerprinted name of known assets.
+func WithAssetManifest(manifest *AssetManifest) TemplateOption {
+	return func(o *templateOptions) {
+		o.manifest = manifest
+	}
+}
+
 // SetupTemplaterStore sets up a TemplaterStore with the base, partial and empty templates.
-func SetupTemplaterStore(ui *UICfg) (TemplaterStore, error) {
-	base, err := BaseTemplate(ui)
+func SetupTemplaterStore(ui *UICfg, opts ...TemplateOption) (TemplaterStore, error) {
+	base, err := BaseTemplate(ui, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	partialPage, err := PartialTemplate(ui)
+	partialPage, err := PartialTemplate(ui, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	emptyPage, err := EmptyTemplate(ui)
+	emptyPage, err := EmptyTemplate(ui, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -516,35 +544,35 @@ func SetupTemplaterStore(ui *UICfg) (TemplaterStore, error) {
 }
 
 // BaseTemplate returns the base template from the passed in UICfg.
-func BaseTemplate(ui *UICfg) (*template.Template, error) {
+func BaseTemplate(ui *UICfg, opts ...TemplateOption) (*template.Template, error) {
 	return template.
 		New(BaseTemplateName).
-		Funcs(templateFuncs(ui)).
+		Funcs(templateFuncs(ui, opts...)).
 		ParseGlob(filepath.Join(ui.Templates.BaseDir, "*.go.html"))
 }
 
 // PartialTemplate returns the partial template from the passed in UICfg.
 // It extends the base template and makes it partial to be used with HTMX.
-func PartialTemplate(ui *UICfg) (*template.Template, error) {
-	base, err := BaseTemplate(ui)
+func PartialTemplate(ui *UICfg, opts ...TemplateOption) (*template.Template, error) {
+	base, err := BaseTemplate(ui, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	return base.New(PartialTemplateName).
-		Funcs(templateFuncs(ui)).
+		Funcs(templateFuncs(ui, opts...)).
 		ParseFiles(filepath.Join(ui.Templates.Dir, "partial.go.html"))
 }
 
 // EmptyTemplate returns the empty template from the passed in UICfg.
 // It contains only the most essential template blocks to be used as an empty template without any surrounding HTML.
-func EmptyTemplate(ui *UICfg) (*template.Template, error) {
+func EmptyTemplate(ui *UICfg, opts ...TemplateOption) (*template.Template, error) {
 	return template.New(EmptyTemplateName).
-		Funcs(templateFuncs(ui)).
+		Funcs(templateFuncs(ui, opts...)).
 		ParseFiles(filepath.Join(ui.Templates.Dir, "empty.go.html"))
 }
 
-// makeTemplateTranslatable overrides the translation functions t/tf on the template using the translator from the context.
+// makeTemplateTranslatable overrides the translation functions t/tf/tn/ts/tryTranslate/timeago on the template using the translator from the context.
 // This function is intended to be used with the trans.Middleware.
 func makeTemplateTranslatable(ctx context.Context, t *template.Template) error {
 	translator, ok := util.CtxValue[trans.Translator](ctx, trans.TranslatorContextKey)
@@ -559,6 +587,12 @@ func makeTemplateTranslatable(ctx context.Context, t *template.Template) error {
 		"tf": func(s string, args ...string) string {
 			return translator.Tf(s, args...)
 		},
+		"tn": func(s string, count int, args ...string) string {
+			return translator.Tn(s, count, args...)
+		},
+		"ts": func(s string, variant string, args ...string) string {
+			return translator.Ts(s, variant, args...)
+		},
 		"tryTranslate": func(t any) string {
 			if s, ok := t.(string); ok {
 				return translator.T(s)
@@ -574,19 +608,111 @@ func makeTemplateTranslatable(ctx context.Context, t *template.Template) error {
 
 			return translator.T(fmt.Sprintf("%s", t))
 		},
+		"timeago": func(v any) string {
+			return timeAgo(translator, templateTime(v), time.Now())
+		},
 	})
+	t.Funcs(localeFuncs(translator.Locale()))
 
 	return nil
 }
 
-// templateFuncs returns a template.FuncMap containing basic template functions.
-func templateFuncs(ui *UICfg) template.FuncMap {
+// makeTemplateFeatureAware overrides the feature function on the template to check the feature flags of the context (see feature.Enabled).
+// Without the feature.Middleware the function reports all flags as inactive.
+func makeTemplateFeatureAware(ctx context.Context, t *template.Template) {
+	t.Funcs(template.FuncMap{
+		"feature": func(name string) bool {
+			return feature.Enabled(ctx, name)
+		},
+	})
+}
+
+// localeFuncs returns the template functions formatting values for the locale, see trans.Locale.
+// The locale may be nil, then the default formats are used:
+//   - localDate formats a time.Time or *time.Time as date, nil and zero times are formatted as an empty string
+//   - localDateTime formats a time.Time or *time.Time as date and time
+//   - localNumber formats an integer or float with optional decimals (default 0)
+//   - formatDate formats a time.Time or *time.Time by a style ("date", "datetime" or a time layout), see formatDate
+//   - pluralize returns the singular or plural of a word by the count, e.g. {{ pluralize .Count "entry" "entries" }}
+func localeFuncs(locale *trans.Locale) template.FuncMap {
 	return template.FuncMap{
+		"formatDate": func(style string, v any) string {
+			return formatDate(locale, style, v)
+		},
+		"localDate": func(v any) string {
+			return locale.FormatDate(templateTime(v))
+		},
+		"localDateTime": func(v any) string {
+			return locale.FormatDateTime(templateTime(v))
+		},
+		"localNumber": func(v any, decimals ...int) string {
+			d := 0
+			if len(decimals) > 0 {
+				d = decimals[0]
+			}
+
+			return locale.FormatNumber(templateNumber(v), d)
+		},
+		"pluralize": func(count int, singular string, plural string) string {
+			return pluralize(locale, count, singular, plural)
+		},
+	}
+}
+
+// templateTime returns the time of a time.Time or *time.Time passed to a template function. It returns the zero time for any other value.
+func templateTime(v any) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case *time.Time:
+		if t != nil {
+			return *t
+		}
+	}
+
+	return time.Time{}
+}
+
+// templateNumber returns the number passed to a template function as float64. It returns 0 for non-numeric values.
+func templateNumber(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	}
+
+	return 0
+}
+
+// templateFuncs returns a template.FuncMap containing basic template functions, the localeFuncs and the helperFuncs.
+// timeago describes a time.Time or *time.Time relative to now (e.g. "3 days ago"), see timeAgo.
+func templateFuncs(ui *UICfg, opts ...TemplateOption) template.FuncMap {
+	o := &templateOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	identity := trans.NewTranslator() // translates translatable values to their keys until the translator is set
+	funcs := template.FuncMap{
 		"add": func(a, b int) int {
 			return a + b
 		},
 		"asset": func(filename string) string {
-			return filepath.Join(ui.AssetsUri, filename)
+			return filepath.Join(ui.AssetsUri, o.manifest.Path(filename))
+		},
+		"feature": func(name string) bool {
+			return false
 		},
 		"safeHTML": func(s string) template.HTML {
 			return template.HTML(s)
@@ -597,12 +723,33 @@ func templateFuncs(ui *UICfg) template.FuncMap {
 		"tf": func(s string, args ...string) string {
 			return s
 		},
+		"tn": func(s string, count int, args ...string) string {
+			return s
+		},
+		"ts": func(s string, variant string, args ...string) string {
+			return s
+		},
 		"tryTranslate": func(t any) string {
 			if s, ok := t.(string); ok {
 				return s
 			}
 
+			if t, ok := t.(trans.Translatable); ok {
+				return t.Translate(identity)
+			}
+
 			return fmt.Sprintf("%s", t)
 		},
+		"timeago": func(v any) string {
+			return timeAgo(identity, templateTime(v), time.Now())
+		},
 	}
+	for name, f := range localeFuncs(nil) {
+		funcs[name] = f
+	}
+	for name, f := range helperFuncs() {
+		funcs[name] = f
+	}
+
+	return funcs
 }
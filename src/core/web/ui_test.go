@@ -1,12 +1,17 @@
 package web
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/trans"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"html/template"
 	"testing"
+	"time"
 )
 
 func TestTemplaterStoreOperations(t *testing.T) {
@@ -102,6 +107,34 @@ func TestNewBaseTemplateDataHTMXHeader(t *testing.T) {
 	assert.True(t, baseData.HTMX)
 }
 
+func TestNewBaseTemplateDataLocale(t *testing.T) {
+	webCtx := &Ctx{Navigation: NewNavigation(), Extensions: NewExtensions()}
+
+	baseData, err := NewBaseTemplateData(nil, webCtx, newMockIO("/"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, &trans.Locale{}, baseData.Locale, "the locale is empty without translator")
+
+	locale := &trans.Locale{Path: "ar", Direction: trans.DirectionRTL}
+	translator := trans.NewTranslator(trans.ForLocale(locale))
+	io := &HIO{request: newMockIO("/").Request().WithContext(context.WithValue(context.Background(), trans.TranslatorContextKey, translator))}
+
+	baseData, err = NewBaseTemplateData(nil, webCtx, io, nil)
+	require.NoError(t, err)
+	assert.Equal(t, locale, baseData.Locale)
+	assert.Equal(t, trans.DirectionRTL, baseData.Locale.Dir())
+}
+
+func TestLocaleFuncs(t *testing.T) {
+	date := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+	tmpl := template.Must(template.New("test").Funcs(localeFuncs(&trans.Locale{DateFormat: "2006-01-02", DecimalSeparator: ",", GroupSeparator: "."})).Parse(
+		`{{ localDate .Date }}|{{ localDateTime .Missing }}|{{ localNumber .Count }}|{{ localNumber .Ratio 2 }}`,
+	))
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, map[string]any{"Date": &date, "Missing": (*time.Time)(nil), "Count": 12345, "Ratio": 0.5}))
+	assert.Equal(t, "2024-03-05||12.345|0,50", buf.String())
+}
+
 func TestFormDataWithSuccessAndErrors(t *testing.T) {
 	form := struct{}{}
 	successMessages := []string{"Operation successful"}
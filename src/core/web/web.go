@@ -4,23 +4,32 @@
 package web
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/trans"
 	"github.com/org-harmony/harmony/src/core/util"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"html/template"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Pkg is the package name used for logging.
 const Pkg = "sys.web"
 
+// ShutdownTimeout is the time Serve waits for active requests to finish when the server is shut down.
+const ShutdownTimeout = 10 * time.Second
+
 var (
 	// ErrNotPointerToStruct is returned when the input is not a pointer to a struct.
 	ErrNotPointerToStruct = errors.New("input is not a pointer to a struct")
@@ -31,13 +40,28 @@ var (
 	// ErrInternal can be used to wrap unexpected internal errors whose message should not be displayed to the user.
 	// In most cases instead of using ErrInternal, a more specific error should be used.
 	ErrInternal = errors.New("harmony.error.generic-reload")
+	// ErrTimeout is displayed to the user instead of the actual error if an operation timed out (persistence.ErrTimeout).
+	ErrTimeout = errors.New("harmony.error.timeout")
 )
 
+// maxPooledBufferSize is the maximum capacity of a buffer to be returned to the bufferPool.
+// Larger buffers are dropped to not hold on to the memory of exceptionally large pages.
+const maxPooledBufferSize = 1 << 20
+
+// bufferPool holds the buffers templates are rendered into before they are written to the client, see executeTemplate.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
 // Cfg is the config for the web package.
-// It contains the config for the web server and the config for the UI.
+// It contains the config for the web server, the config for the UI and the optional config for the response cache.
 type Cfg struct {
 	Server *ServerCfg `toml:"server" hvalidate:"required"`
 	UI     *UICfg     `toml:"ui" hvalidate:"required"`
+	Cache  *CacheCfg  `toml:"cache"`
+	Limits *LimitCfg  `toml:"limits"`
 }
 
 // ServerCfg is the config for the web server. It contains the address and port to listen on and the base url.
@@ -50,19 +74,34 @@ type ServerCfg struct {
 }
 
 // FileServerCfg is the config for a file server. It contains the root directory to assets and the route to serve them on.
+// If Fingerprint is enabled, assets are referenced by fingerprinted names and cached by clients indefinitely (see AssetManifest).
 type FileServerCfg struct {
-	Root  string `toml:"root" hvalidate:"required"`
-	Route string `toml:"route" hvalidate:"required"`
+	Root        string `toml:"root" hvalidate:"required"`
+	Route       string `toml:"route" hvalidate:"required"`
+	Fingerprint bool   `toml:"fingerprint" env:"ASSET_FINGERPRINT"`
+}
+
+// FileServerOption is an option for the file server mounted by MountFileServer.
+type FileServerOption func(*fileServerOptions)
+
+// fileServerOptions are the options applied through FileServerOption.
+type fileServerOptions struct {
+	manifest *AssetManifest
 }
 
 // Ctx is the web context. It is passed to the controller's handler function.
-// It contains the router, config, templater store, navigation and template data extensions.
+// It contains the router, config, templater store, navigation, template data extensions, the server-sent events broker,
+// the response cache store, the error mapping and the command palette's commands. Cache is nil if the response cache is disabled.
 type Ctx struct {
 	Router         Router
 	Config         *Cfg
 	TemplaterStore TemplaterStore
 	Navigation     *Navigation
 	Extensions     *TemplateDataExtensions
+	Broker         *Broker
+	Cache          CacheStore
+	Errors         *ErrorMapping
+	Commands       *Commands
 }
 
 // Controller is convenience struct for handling web requests.
@@ -94,6 +133,12 @@ type IO interface {
 	Request() *http.Request
 	// Context returns the context.Context of the request. It is the same context as in the http.Request.
 	Context() context.Context
+	// Translator returns the trans.Translator of the request's locale.
+	// A translator returning the keys untranslated is returned if the request has no translator.
+	Translator() trans.Translator
+	// Repository returns the repository of the name. Request-scoped dependencies (see WithDependency) take precedence
+	// over the repositories of the application context. Use the generic Repository function for typed access.
+	Repository(name string) (persistence.Repository, error)
 	// RenderTemplate renders a template with the passed in data and writes it to the http.ResponseWriter.
 	RenderTemplate(*template.Template, any) error
 	// Render renders a template with the passed in data and writes it to the http.ResponseWriter.
@@ -106,31 +151,72 @@ type IO interface {
 	// If the request is an HTMX request, Render renders the template from the partial Templater (PartialTemplateName).
 	// Otherwise, it will render the template from the base Templater (BaseTemplateName).
 	Render(data any, name string, paths ...string) error
+	// RenderBlock renders only the named block (a template defined with define or block) of the templates joined
+	// from the Templater of the name (e.g. PartialTemplateName) instead of the whole page.
+	// This allows HTMX requests to swap a single block of an existing page template without a separate partial file.
+	// Example:
+	//  	io.RenderBlock(formData, web.PartialTemplateName, "template.set.list", "template/set-list-page.go.html")
+	RenderBlock(data any, templaterName string, blockName string, paths ...string) error
 	// Error renders an error page with the first passed in error as the user facing error message.
 	// All errors will be logged. At least one error should always be provided as this will be the user facing error message.
 	// Error handles HTMX requests by rendering the error template from the partial template.
+	// The status code and user facing message are resolved through the web.Ctx's ErrorMapping:
+	// an HTTPError or a mapped error sets the status code (and possibly the message), otherwise the status code is 500.
 	//
 	// Also adding more errors to improve the meaning of the log entry is highly recommended.
 	// If no errors are provided a generic error message is rendered and the error is logged.
 	Error(...error) error
 	// InlineError is similar to Error, but it renders the error template from the empty template.
 	// This allows for rendering the error inline in the page e.g. upon form submission.
+	// The status code is resolved the same way as for Error.
 	InlineError(...error) error
+	// InlineErrorTo is similar to InlineError, but HTMX swaps the error as configured by the ErrorTarget
+	// instead of into the request's target, e.g. into a dedicated error container or as a toast.
+	// Example:
+	//  	io.InlineErrorTo(web.ErrorInto("#template-errors"), err)
+	//
+	// Requests that are no HTMX requests ignore the ErrorTarget and are handled like InlineError.
+	InlineErrorTo(ErrorTarget, ...error) error
 	// Redirect will send a redirect to the client with the specified status code.
+	// HTMX requests are redirected by the HX-Redirect header instead, see Redirect.
 	Redirect(string, int) error
 	// IsHTMX returns true if the request is an HTMX request.
 	IsHTMX() bool
+	// EventStream upgrades the response to a stream of server-sent events. The stream sends heartbeats
+	// and is closed once the client disconnects. Nothing else should be written to the response afterward.
+	// Errors must therefore not be rendered using Error or InlineError once the stream has been created.
+	EventStream() (*EventStream, error)
+	// WantsJSON returns true if the client prefers a JSON response over an HTML response (see WantsJSON).
+	WantsJSON() bool
+	// JSON writes the data encoded as JSON to the client.
+	JSON(data any) error
+	// RespondNegotiated serves the data as JSON if the client prefers JSON (see WantsJSON) and renders the template otherwise (see Render).
+	// This allows controllers to serve the UI and programmatic clients without duplication. The data must therefore be encodable as JSON.
+	// Example:
+	//  	io.RespondNegotiated(listData, "template.set.list", "template/set-list.go.html")
+	RespondNegotiated(data any, name string, paths ...string) error
 }
 
 // NewContext creates a new web context using the passed in router, config and templater store.
-// The Navigation and TemplateDataExtensions are initialized with NewNavigation and NewExtensions respectively.
+// The Navigation, TemplateDataExtensions, Broker, ErrorMapping and Commands are initialized with NewNavigation, NewExtensions,
+// NewBroker, NewErrorMapping and NewCommands respectively.
+// The Cache is initialized with NewCacheStore from the config's cache config.
 func NewContext(router Router, cfg *Cfg, ts TemplaterStore) *Ctx {
+	var cacheCfg *CacheCfg
+	if cfg != nil {
+		cacheCfg = cfg.Cache
+	}
+
 	return &Ctx{
 		Router:         router,
 		Config:         cfg,
 		TemplaterStore: ts,
 		Navigation:     NewNavigation(),
 		Extensions:     NewExtensions(),
+		Broker:         NewBroker(),
+		Cache:          NewCacheStore(cacheCfg),
+		Errors:         NewErrorMapping(),
+		Commands:       NewCommands(),
 	}
 }
 
@@ -212,6 +298,28 @@ func (io *HIO) Render(data any, name string, paths ...string) error {
 	return io.RenderTemplate(t, data)
 }
 
+// RenderBlock implements the web.IO interface on HIO by rendering the named block of the joined templates with RenderTemplate.
+// The joined templates are cached per Templater by their paths, see Templater.JoinedTemplate.
+// If the block is not defined, ErrBlockNotFound is returned.
+func (io *HIO) RenderBlock(data any, templaterName string, blockName string, paths ...string) error {
+	templater, err := io.webCtx.TemplaterStore.Templater(templaterName)
+	if err != nil {
+		return err
+	}
+
+	t, err := templater.JoinedTemplate("block:"+strings.Join(paths, ","), paths...)
+	if err != nil {
+		return err
+	}
+
+	block := t.Lookup(blockName)
+	if block == nil {
+		return fmt.Errorf("%w: %s in %s", ErrBlockNotFound, blockName, strings.Join(paths, ", "))
+	}
+
+	return io.RenderTemplate(block, data)
+}
+
 // RenderTemplate implements the web.IO interface on HIO by rendering a template with the passed in data.
 // RenderTemplate then writes the executed template to the http.ResponseWriter.
 // For more information on the behaviour of RenderTemplate see the web.IO interface.
@@ -220,14 +328,19 @@ func (io *HIO) Render(data any, name string, paths ...string) error {
 // This will add a translation function to the template's function map with a reference to the trans.Translator in the context.
 // If makeTemplateTranslatable returns an error, it is logged and the rendering continues. An error is not returned and will not lead to a failed request.
 // That is because the template should always be provided with a translation function that just returns the passed in string as-is (fallback).
+// Likewise, the template's feature function checks the feature flags of the request, see makeTemplateFeatureAware.
+//
+// The template is rendered completely before anything is written to the client (see executeTemplate).
+// Therefore, a failing template does not result in a broken page but in an error returned to the Controller.
 func (io *HIO) RenderTemplate(t *template.Template, data any) error {
 	if err := makeTemplateTranslatable(io.request.Context(), t); err != nil {
 		io.appCtx.Warn(Pkg, "failed to make template translatable, likely context does not contain translator", "error", err)
 	}
+	makeTemplateFeatureAware(io.request.Context(), t)
 
 	io.baseData.Data = data
 
-	return util.Wrap(t.Execute(io.writer, io.baseData), "failed to render template")
+	return util.Wrap(executeTemplate(io.writer, 0, t, io.baseData), "failed to render template")
 }
 
 // Error implements the web.IO interface on HIO by rendering an error page with the first passed in error as the user facing error message.
@@ -261,9 +374,9 @@ func (io *HIO) InlineError(errs ...error) error {
 	return io.errs(templater, errs...)
 }
 
-// Redirect will send a redirect to the client.
+// Redirect implements the web.IO interface on HIO by redirecting the client through Redirect.
 func (io *HIO) Redirect(url string, code int) error {
-	http.Redirect(io.writer, io.request, url, code)
+	Redirect(io.writer, io.request, url, code)
 	return nil
 }
 
@@ -272,21 +385,25 @@ func (io *HIO) IsHTMX() bool {
 	return io.baseData.HTMX
 }
 
+// EventStream upgrades the response to a stream of server-sent events using NewEventStream with the HeartbeatInterval.
+func (io *HIO) EventStream() (*EventStream, error) {
+	return NewEventStream(io.writer, io.request, HeartbeatInterval)
+}
+
 // errs is a helper function for Error and InlineError.
-// It renders the error template from the passed in templater with the first passed in error as the user facing error message.
+// It renders the error template from the passed in templater with the status code and user facing message
+// resolved through the web.Ctx's ErrorMapping (see ErrorMapping.Resolve).
 // It also adds the request's url, method and header to the log entry of all errors.
 // errs also makes the template translatable by calling makeTemplateTranslatable.
+// The ErrorResponseHeader is set to allow the client to swap the error response into the page despite the status code.
+// Clients preferring JSON (see WantsJSON) receive the translated user facing message as JSONError instead.
 func (io *HIO) errs(templater Templater, errs ...error) error {
-	if len(errs) == 0 {
-		errs = append(errs, fmt.Errorf("harmony.error.generic-reload"))
-	}
+	resolved := io.resolveErrs(errs...)
 
-	for _, err := range errs {
-		io.appCtx.Error(Pkg, "error in controller", err, "url", io.request.URL.String(), "method", io.request.Method)
+	if io.WantsJSON() {
+		return io.errsJSON(resolved)
 	}
 
-	e := errs[0]
-
 	errTemplate, err := templater.Template("error", "error.go.html")
 	if err != nil {
 		return err
@@ -296,9 +413,50 @@ func (io *HIO) errs(templater Templater, errs ...error) error {
 		io.appCtx.Warn(Pkg, "failed to make template translatable, likely context does not contain translator", "error", err)
 	}
 
-	io.baseData.Data = e.Error()
+	io.baseData.Data = resolved.Error()
+	io.writer.Header().Set(ErrorResponseHeader, "true")
 
-	return errTemplate.Execute(io.writer, io.baseData)
+	return executeTemplate(io.writer, resolved.Status, errTemplate, io.baseData)
+}
+
+// resolveErrs resolves the status code and user facing message of the errors through the web.Ctx's ErrorMapping
+// and logs all errors with the request's url and method. Without errors, ErrInternal is resolved.
+func (io *HIO) resolveErrs(errs ...error) *HTTPError {
+	if len(errs) == 0 {
+		errs = append(errs, ErrInternal)
+	}
+
+	resolved := io.webCtx.Errors.Resolve(errs...)
+
+	for _, err := range errs {
+		io.appCtx.Error(Pkg, "error in controller", err, "url", io.request.URL.String(), "method", io.request.Method, "status", resolved.Status)
+	}
+
+	return resolved
+}
+
+// executeTemplate executes the template with the data into a pooled buffer and writes the buffer to the client
+// only if the template was executed successfully. This prevents partially written pages if the execution fails midway.
+// The status code is written before the buffer, a status code of 0 leaves the status code to the http.ResponseWriter.
+func executeTemplate(w http.ResponseWriter, status int, t *template.Template, data any) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := t.Execute(buf, data); err != nil {
+		return err
+	}
+
+	if status != 0 {
+		w.WriteHeader(status)
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
 }
 
 // getBaseTemplater returns the base Templater based on the request (HTMX or not).
@@ -318,7 +476,13 @@ func (io *HIO) getBaseInlineTemplater() (Templater, error) {
 }
 
 // MountFileServer registers a file server with a config on a router.
-func MountFileServer(r Router, cfg *FileServerCfg) {
+// Fingerprinted names of an AssetManifest (see WithFingerprints) are served with the ImmutableCacheControl header.
+func MountFileServer(r Router, cfg *FileServerCfg, opts ...FileServerOption) {
+	o := &fileServerOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	route := cfg.Route
 
 	// Path Validation
@@ -339,13 +503,55 @@ func MountFileServer(r Router, cfg *FileServerCfg) {
 	r.Get(routeWithWildcard, func(w http.ResponseWriter, r *http.Request) {
 		pathPrefix := strings.TrimSuffix(route, "/*")
 		fs := http.StripPrefix(pathPrefix, http.FileServer(http.Dir(cfg.Root)))
+
+		if name, ok := o.manifest.Resolve(strings.TrimPrefix(r.URL.Path, route)); ok {
+			r.URL.Path = route + name
+			r.URL.RawPath = ""
+			w.Header().Set("Cache-Control", ImmutableCacheControl)
+		}
+
 		fs.ServeHTTP(w, r)
 	})
 }
 
-// Serve starts a web server on a router using the address and port specified in the config.
-func Serve(r Router, cfg *ServerCfg) error {
-	return http.ListenAndServe(fmt.Sprintf("%s:%s", cfg.Addr, cfg.Port), r)
+// WithFingerprints serves the fingerprinted names of the AssetManifest by resolving them to their files.
+func WithFingerprints(manifest *AssetManifest) FileServerOption {
+	return func(o *fileServerOptions) {
+		o.manifest = manifest
+	}
+}
+
+// Serve starts a web server on a router using the address and port specified in the config. It serves until the context
+// is done, e.g. on SIGTERM through signal.NotifyContext, and then shuts the server down gracefully: it stops accepting
+// connections and waits up to ShutdownTimeout for active requests to finish. The contexts of active requests are cancelled
+// when the shutdown starts, so long-lived requests like event streams (see IO.EventStream) end as well.
+// Serve returns nil after a graceful shutdown and the error of the server otherwise, e.g. if the address is already in use.
+func Serve(ctx context.Context, r Router, cfg *ServerCfg) error {
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	server := &http.Server{
+		Addr:        fmt.Sprintf("%s:%s", cfg.Addr, cfg.Port),
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return requestCtx },
+	}
+	server.RegisterOnShutdown(cancelRequests)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	return server.Shutdown(shutdownCtx)
 }
 
 // ReadForm reads the form values from a request and populates the fields of a struct pointed to by 'data'.
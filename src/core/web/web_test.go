@@ -1,18 +1,25 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"github.com/org-harmony/harmony/src/core/event"
 	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
 	"github.com/org-harmony/harmony/src/core/trace"
+	"github.com/org-harmony/harmony/src/core/trans"
 	"github.com/org-harmony/harmony/src/core/validation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 )
 
 type SimpleTestStruct struct {
@@ -75,6 +82,26 @@ func TestController(t *testing.T) {
 	renderJoined := NewController(app, ctx, func(io IO) error {
 		return io.Render("content-string", "printer", "partial.go.html", "printer.go.html")
 	})
+	renderBlock := NewController(app, ctx, func(io IO) error {
+		return io.RenderBlock("content-string", BaseTemplateName, "content", "printer.go.html")
+	})
+	missingBlock := NewController(app, ctx, func(io IO) error {
+		err := io.RenderBlock("content-string", BaseTemplateName, "missing", "printer.go.html")
+		assert.ErrorIs(t, err, ErrBlockNotFound)
+		return err
+	})
+	broken := NewController(app, ctx, func(io IO) error {
+		return io.Render("content-string", "broken", "broken.go.html")
+	})
+	timeoutError := NewController(app, ctx, func(io IO) error {
+		return io.Error(errors.Join(persistence.ErrReadRow, persistence.ErrTimeout))
+	})
+	notFoundError := NewController(app, ctx, func(io IO) error {
+		return io.Error(ErrInternal, errors.Join(persistence.ErrReadRow, persistence.ErrNotFound))
+	})
+	forbiddenError := NewController(app, ctx, func(io IO) error {
+		return io.InlineError(Forbidden(errors.New("not the owner")))
+	})
 
 	router := ctx.Router
 	router.Get("/test", partial.ServeHTTP)
@@ -83,6 +110,12 @@ func TestController(t *testing.T) {
 	router.Get("/redirect", redirect.ServeHTTP)
 	router.Get("/htmx-only", htmxOnly.ServeHTTP)
 	router.Get("/render-joined", renderJoined.ServeHTTP)
+	router.Get("/timeout-error", timeoutError.ServeHTTP)
+	router.Get("/not-found-error", notFoundError.ServeHTTP)
+	router.Get("/forbidden-error", forbiddenError.ServeHTTP)
+	router.Get("/broken", broken.ServeHTTP)
+	router.Get("/render-block", renderBlock.ServeHTTP)
+	router.Get("/missing-block", missingBlock.ServeHTTP)
 
 	recorder := httptest.NewRecorder()
 	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/test", nil))
@@ -92,19 +125,20 @@ func TestController(t *testing.T) {
 	recorder = httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/error", nil)
 	router.ServeHTTP(recorder, req)
-	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get(ErrorResponseHeader))
 	assert.Contains(t, recorder.Body.String(), "before content; harmony.error.generic-reload; after")
 	assert.NotContains(t, recorder.Body.String(), "appendix")
 
 	recorder = httptest.NewRecorder()
 	req.Header.Set("HX-Request", "true")
 	router.ServeHTTP(recorder, req)
-	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 	assert.Contains(t, recorder.Body.String(), "appendix")
 
 	recorder = httptest.NewRecorder()
 	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/inline-error", nil))
-	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 	assert.Contains(t, recorder.Body.String(), "harmony.error.generic-reload")
 	assert.NotContains(t, recorder.Body.String(), "before content;")
 	assert.NotContains(t, recorder.Body.String(), "after;")
@@ -114,6 +148,14 @@ func TestController(t *testing.T) {
 	assert.Equal(t, http.StatusFound, recorder.Code)
 	assert.Equal(t, "/", recorder.Header().Get("Location"))
 
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/redirect", nil)
+	req.Header.Set(HXRequestHeader, "true")
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "/", recorder.Header().Get(HXRedirectHeader))
+	assert.Empty(t, recorder.Header().Get("Location"))
+
 	recorder = httptest.NewRecorder()
 	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/not-found", nil))
 	assert.Equal(t, http.StatusNotFound, recorder.Code)
@@ -129,6 +171,72 @@ func TestController(t *testing.T) {
 	assert.Equal(t, http.StatusOK, recorder.Code)
 	assert.Contains(t, recorder.Body.String(), "content-string")
 	assert.Contains(t, recorder.Body.String(), "partial-appendix")
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/timeout-error", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "before content; harmony.error.timeout; after")
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/not-found-error", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "before content; harmony.error.not-found; after")
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/forbidden-error", nil))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "harmony.error.forbidden")
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/render-block", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "content-string", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/missing-block", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/broken", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "before content;", "a failing template must not write a partial page")
+}
+
+func BenchmarkControllerRender(b *testing.B) {
+	app, ctx := setupMockCtxs(b)
+
+	controller := NewController(app, ctx, func(io IO) error {
+		return io.Render("content-string", "printer", "partial.go.html", "printer.go.html")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), trans.TranslatorContextKey, trans.NewTranslator()))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		controller.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkExecuteTemplate(b *testing.B) {
+	tmpl := template.Must(template.New("bench").Parse(`{{ range . }}<li class="item">{{ . }}</li>{{ end }}`))
+	data := make([]string, 500)
+	for i := range data {
+		data[i] = "requirement <b>" + strconv.Itoa(i) + "</b>"
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := executeTemplate(httptest.NewRecorder(), 0, tmpl, data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
 
 func TestValuesIntoStruct(t *testing.T) {
@@ -223,7 +331,7 @@ func TestValuesIntoStructNoPanicForNonPointer(t *testing.T) {
 	assert.Equal(t, TestStruct{}, ts)
 }
 
-func setupMockCtxs(t *testing.T) (*hctx.AppCtx, *Ctx) {
+func setupMockCtxs(t testing.TB) (*hctx.AppCtx, *Ctx) {
 	r, ts := setupMock(t)
 	templatesDir, baseDir := setupDirectories(t)
 	logger := trace.NewLogger()
@@ -240,10 +348,12 @@ func setupMockCtxs(t *testing.T) (*hctx.AppCtx, *Ctx) {
 			TemplaterStore: ts,
 			Navigation:     NewNavigation(),
 			Extensions:     NewExtensions(),
+			Errors:         NewErrorMapping(),
+			Commands:       NewCommands(),
 		}
 }
 
-func setupMock(t *testing.T) (Router, TemplaterStore) {
+func setupMock(t testing.TB) (Router, TemplaterStore) {
 	templateDir, baseDir := setupDirectories(t)
 	cfg := setupConfig(templateDir, baseDir)
 
@@ -263,7 +373,7 @@ func setupAssetsFileServer(t *testing.T, r Router) {
 }
 
 // setupDirectories sets up the directories and writes templates. It returns the paths to the created directories.
-func setupDirectories(t *testing.T) (string, string) {
+func setupDirectories(t testing.TB) (string, string) {
 	tempDir := t.TempDir()
 	templatesDir := filepath.Join(tempDir, "templates")
 	baseDir := filepath.Join(tempDir, "templates", "base")
@@ -293,6 +403,10 @@ func setupDirectories(t *testing.T) (string, string) {
 	err = os.WriteFile(filepath.Join(templatesDir, "error.go.html"), []byte(errorPageContent), 0644)
 	require.NoError(t, err)
 
+	brokenPageContent := "{{define \"broken\"}}{{template \"index\" .}}{{end}}{{define \"content\"}}{{.Data.Missing}}{{end}}"
+	err = os.WriteFile(filepath.Join(templatesDir, "broken.go.html"), []byte(brokenPageContent), 0644)
+	require.NoError(t, err)
+
 	printerPageContent := "{{define \"printer\"}}{{template \"index\" .}}{{end}}{{define \"content\"}}{{.Data}}{{end}}"
 	err = os.WriteFile(filepath.Join(templatesDir, "printer.go.html"), []byte(printerPageContent), 0644)
 	require.NoError(t, err)
@@ -335,3 +449,22 @@ func setupAssetsDirectory(t *testing.T) string {
 
 	return assetsDir
 }
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, NewRouter(), &ServerCfg{Addr: "127.0.0.1", Port: "0"})
+	}()
+
+	cancel()
+	select {
+	case err := <-served:
+		assert.NoError(t, err, "the server is shut down gracefully once the context is done")
+	case <-time.After(ShutdownTimeout):
+		t.Fatal("the server was not shut down")
+	}
+
+	err := Serve(context.Background(), NewRouter(), &ServerCfg{Addr: "127.0.0.1", Port: "invalid"})
+	assert.Error(t, err, "errors of the server are returned")
+}
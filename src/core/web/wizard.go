@@ -0,0 +1,536 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/core/hctx"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"net/http"
+	"time"
+)
+
+const (
+	// WizardRepositoryName is the name of the WizardRepository. It can be used to retrieve the repository from the persistence.RepositoryProvider.
+	WizardRepositoryName = "WizardRepository"
+	// WizardSessionType is the type of the sessions storing the state of wizards.
+	WizardSessionType = "wizard"
+	// WizardActionField is the name of the form value navigating a wizard, it is sent by the wizard's buttons.
+	WizardActionField = "wizard-action"
+	// WizardActionBack navigates to the previous step without submitting the current step.
+	WizardActionBack = "back"
+	// WizardActionNext submits the current step and navigates to the next step or completes the wizard on the last step.
+	WizardActionNext = "next"
+	// WizardActionCheck submits the current step and renders it again without navigating, e.g. to preview the step's result.
+	WizardActionCheck = "check"
+	// DefaultWizardTTL is the duration a wizard can be resumed for if the Wizard does not define a TTL.
+	DefaultWizardTTL = 24 * time.Hour
+	// wizardCookiePrefix is the prefix of the cookie storing the session id of a wizard. The wizard's name is appended.
+	wizardCookiePrefix = "harmony_wizard_"
+)
+
+// ErrWizardNotFound is displayed to the user if a wizard session does not exist, has expired or belongs to another user.
+var ErrWizardNotFound = errors.New("harmony.wizard.error.not-found")
+
+// Wizard is a multistep form. The state of a wizard (its current step and the values of all steps) is stored
+// server-side in a WizardSession, which allows users to navigate back and forth and to resume the wizard later.
+// The session's id is stored in a cookie. Opening the wizard's Route resumes the unfinished session or starts a new one.
+//
+// A Wizard registers the following routes through Register:
+//   - GET <Route> Resumes the user's wizard or starts a new one by redirecting to the current step.
+//   - GET <Route>/{wizardID}/{step} Renders the step if it has been reached.
+//   - POST <Route>/{wizardID}/{step} Navigates back (WizardActionBack), submits the step (WizardActionNext) or checks it (WizardActionCheck).
+//   - POST <Route>/{wizardID}/cancel Deletes the session and redirects to the CancelURL.
+type Wizard struct {
+	// Name identifies the wizard, it is stored in the session and part of the cookie's name.
+	Name string
+	// Route is the base route of the wizard, e.g. "/eiffel/wizard".
+	Route string
+	// Title is the translation key of the wizard's title.
+	Title string
+	Steps []WizardStep
+	// TTL is the duration the wizard can be resumed for after it was last submitted. Defaults to DefaultWizardTTL.
+	TTL time.Duration
+	// Owner returns the identifier of the owner of a wizard session, usually the logged-in user's id.
+	// Sessions can only be resumed by their owner. If Owner is nil, sessions are not bound to an owner.
+	Owner func(io IO) string
+	// Complete is called after the last step was submitted successfully. It returns the URL the user is redirected to.
+	// The session is deleted afterward. If Complete returns an error, the session is kept and the error is displayed
+	// as an internal error unless it is mapped to another status code and message (see ErrorMapping).
+	Complete func(io IO, session *WizardSession) (string, error)
+	// CancelURL is the URL the user is redirected to after canceling the wizard.
+	CancelURL string
+}
+
+// WizardStep is a step of a Wizard.
+type WizardStep struct {
+	// Name identifies the step in the URL and is the key of the step's values in the WizardSession.
+	Name string
+	// Title is the translation key of the step's title.
+	Title string
+	// Render renders the step, usually with the page as the template data. If the page's Form is nil, the step was not submitted
+	// and the form should be filled with the step's values from the session (see WizardValue).
+	Render func(io IO, page *WizardPage) error
+	// Submit reads and validates the step's form. The submitted values should be set as the page's Form.
+	// If the values are invalid, the validation errors are added to the page (FormData.ViolationsFromErrors)
+	// and the step is rendered again. Otherwise, the values should be stored in the session (WizardSession.SetValue).
+	// Returned errors are displayed as internal errors.
+	Submit func(io IO, page *WizardPage) error
+}
+
+// WizardPage is the template data of a wizard step. It embeds the FormData of the step's form.
+type WizardPage struct {
+	*FormData[any]
+	Wizard  *Wizard
+	Session *WizardSession
+	// Index is the index of the rendered step.
+	Index int
+}
+
+// WizardState is the state of a wizard stored as the payload of a WizardSession.
+type WizardState struct {
+	Wizard string `json:"wizard"`
+	Owner  string `json:"owner"`
+	// Step is the index of the current step.
+	Step int `json:"step"`
+	// Reached is the index of the furthest step reached. All steps up to Reached can be navigated to.
+	Reached int `json:"reached"`
+	// Values are the JSON encoded values of the steps by the steps' names.
+	Values map[string]json.RawMessage `json:"values"`
+}
+
+// WizardSession is a persistence.Session with the WizardState as the payload.
+type WizardSession struct {
+	persistence.Session[WizardState, map[string]string]
+}
+
+// WizardRepository stores wizard sessions. It is a persistence.SessionRepository for WizardSession.
+type WizardRepository interface {
+	persistence.SessionRepository[*WizardSession]
+}
+
+// PGWizardRepository is a PostgreSQL implementation of the WizardRepository storing the wizard sessions in the sessions table.
+type PGWizardRepository struct {
+	persistence.Timeout
+	db *pgxpool.Pool
+}
+
+// NewPGWizardRepository creates a new PGWizardRepository with the given database connection pool.
+func NewPGWizardRepository(db *pgxpool.Pool) WizardRepository {
+	return &PGWizardRepository{db: db}
+}
+
+// RepositoryName returns the name of the repository. It is used to register the repository in the application context.
+func (r *PGWizardRepository) RepositoryName() string {
+	return WizardRepositoryName
+}
+
+// Read reads a valid wizard session by id. Expired sessions are deleted and persistence.ErrSessionExpired is returned.
+// It returns persistence.ErrNotFound if the session does not exist.
+func (r *PGWizardRepository) Read(ctx context.Context, id uuid.UUID) (*WizardSession, error) {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	session := &WizardSession{}
+	err := persistence.PGReadValidSession(ctx, r.db, id, &session.Session)
+	if errors.Is(err, persistence.ErrSessionExpired) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, persistence.PGReadErr(err)
+	}
+
+	return session, nil
+}
+
+// Write writes the wizard session by id, the session's id is overwritten by the passed in id.
+// It returns persistence.ErrInsert if the session could not be written.
+func (r *PGWizardRepository) Write(ctx context.Context, id uuid.UUID, session *WizardSession) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	session.ID = id
+
+	err := persistence.PGWriteSession(ctx, r.db, &session.Session)
+	if err != nil {
+		return errors.Join(persistence.ErrInsert, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// Delete deletes a wizard session by id. If the session does not exist it returns nil.
+// It returns persistence.ErrDelete if the session could not be deleted.
+func (r *PGWizardRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.WithTimeout(ctx)
+	defer cancel()
+
+	err := persistence.PGDeleteSession(ctx, r.db, id)
+	if err != nil {
+		return errors.Join(persistence.ErrDelete, persistence.TimeoutErr(err))
+	}
+
+	return nil
+}
+
+// Insert inserts a new wizard session with a new id which is set on the session.
+// It returns persistence.ErrInsert if the session could not be inserted.
+func (r *PGWizardRepository) Insert(ctx context.Context, session *WizardSession) error {
+	return r.Write(ctx, uuid.New(), session)
+}
+
+// Register registers the routes of the wizard on the router (see Wizard).
+// Routes requiring a logged-in user should be protected by passing a router with the corresponding middleware.
+func (w *Wizard) Register(appCtx *hctx.AppCtx, webCtx *Ctx, router Router) {
+	router.Get(w.Route, NewController(appCtx, webCtx, w.resume).ServeHTTP)
+	router.Get(w.Route+"/{wizardID}/{step}", NewController(appCtx, webCtx, w.show).ServeHTTP)
+	router.Post(w.Route+"/{wizardID}/cancel", NewController(appCtx, webCtx, w.cancel).ServeHTTP)
+	router.Post(w.Route+"/{wizardID}/{step}", NewController(appCtx, webCtx, w.submit).ServeHTTP)
+}
+
+// StepURL returns the URL of the step with the index in the session.
+func (w *Wizard) StepURL(session *WizardSession, index int) string {
+	return w.Route + "/" + session.ID.String() + "/" + w.Steps[index].Name
+}
+
+// resume redirects to the current step of the user's unfinished wizard session or starts a new session.
+func (w *Wizard) resume(io IO) error {
+	session, err := w.sessionFromCookie(io)
+	if err != nil {
+		session, err = w.start(io)
+		if err != nil {
+			return io.Error(ErrInternal, err)
+		}
+	}
+
+	return io.Redirect(w.StepURL(session, session.Payload.Step), http.StatusFound)
+}
+
+// show renders a step of the wizard. Steps that have not been reached yet redirect to the current step.
+func (w *Wizard) show(io IO) error {
+	session, err := w.SessionFromParams(io)
+	if err != nil {
+		return io.Error(err)
+	}
+
+	index := w.stepIndex(URLParam(io.Request(), "step"))
+	if index < 0 || index > session.Payload.Reached {
+		return io.Redirect(w.StepURL(session, session.Payload.Step), http.StatusFound)
+	}
+
+	if session.Payload.Step != index {
+		session.Payload.Step = index
+		if err := w.save(io, session); err != nil {
+			return io.Error(ErrInternal, err)
+		}
+	}
+
+	return w.Steps[index].Render(io, w.page(session, index))
+}
+
+// submit navigates back or submits the step. Valid steps advance the wizard unless they are only checked, invalid steps are rendered again.
+func (w *Wizard) submit(io IO) error {
+	session, err := w.SessionFromParams(io)
+	if err != nil {
+		return io.Error(err)
+	}
+
+	index := w.stepIndex(URLParam(io.Request(), "step"))
+	if index < 0 || index > session.Payload.Reached {
+		return io.Redirect(w.StepURL(session, session.Payload.Step), http.StatusFound)
+	}
+
+	action := io.Request().FormValue(WizardActionField)
+	if action == WizardActionBack {
+		return io.Redirect(w.StepURL(session, max(index-1, 0)), http.StatusFound)
+	}
+
+	page := w.page(session, index)
+	err = w.Steps[index].Submit(io, page)
+	if err != nil {
+		return io.Error(ErrInternal, err)
+	}
+
+	if !page.Valid() {
+		return w.Steps[index].Render(io, page)
+	}
+
+	if action == WizardActionCheck {
+		if err := w.save(io, session); err != nil {
+			return io.Error(ErrInternal, err)
+		}
+
+		return w.Steps[index].Render(io, page)
+	}
+
+	if index == len(w.Steps)-1 {
+		return w.complete(io, session)
+	}
+
+	session.Payload.Step = index + 1
+	session.Payload.Reached = max(session.Payload.Reached, index+1)
+	if err := w.save(io, session); err != nil {
+		return io.Error(ErrInternal, err)
+	}
+
+	return io.Redirect(w.StepURL(session, index+1), http.StatusFound)
+}
+
+// cancel deletes the wizard session and redirects to the CancelURL.
+func (w *Wizard) cancel(io IO) error {
+	session, err := w.SessionFromParams(io)
+	if err != nil {
+		return io.Error(err)
+	}
+
+	if err := w.delete(io, session); err != nil {
+		return io.Error(ErrInternal, err)
+	}
+
+	return io.Redirect(w.CancelURL, http.StatusFound)
+}
+
+// complete completes the wizard through Wizard.Complete, deletes the session and redirects to the returned URL.
+func (w *Wizard) complete(io IO, session *WizardSession) error {
+	if err := w.save(io, session); err != nil {
+		return io.Error(ErrInternal, err)
+	}
+
+	redirect, err := w.Complete(io, session)
+	if err != nil {
+		return io.Error(ErrInternal, err)
+	}
+
+	if err := w.delete(io, session); err != nil {
+		return io.Error(ErrInternal, err)
+	}
+
+	return io.Redirect(redirect, http.StatusFound)
+}
+
+// start inserts a new wizard session and sets its id in the wizard's cookie.
+func (w *Wizard) start(io IO) (*WizardSession, error) {
+	session := &WizardSession{Session: persistence.Session[WizardState, map[string]string]{
+		Type: WizardSessionType,
+		Payload: WizardState{
+			Wizard: w.Name,
+			Owner:  w.owner(io),
+			Values: make(map[string]json.RawMessage),
+		},
+		CreatedAt: time.Now(),
+	}}
+	session.ID = uuid.New()
+
+	return session, w.save(io, session)
+}
+
+// save writes the session with a renewed expiry and refreshes the wizard's cookie.
+func (w *Wizard) save(io IO, session *WizardSession) error {
+	session.ExpiresAt = time.Now().Add(w.ttl())
+
+	err := MustRepository[WizardRepository](io, WizardRepositoryName).Write(io.Context(), session.ID, session)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(io.Response(), &http.Cookie{
+		Name:     w.cookieName(),
+		Value:    session.ID.String(),
+		Path:     w.Route,
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// delete deletes the session and the wizard's cookie.
+func (w *Wizard) delete(io IO, session *WizardSession) error {
+	err := MustRepository[WizardRepository](io, WizardRepositoryName).Delete(io.Context(), session.ID)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(io.Response(), &http.Cookie{
+		Name:     w.cookieName(),
+		Value:    "",
+		Path:     w.Route,
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// sessionFromCookie reads the session of the wizard's cookie.
+func (w *Wizard) sessionFromCookie(io IO) (*WizardSession, error) {
+	cookie, err := io.Request().Cookie(w.cookieName())
+	if err != nil {
+		return nil, err
+	}
+
+	return w.session(io, cookie.Value)
+}
+
+// SessionFromParams reads the session of the wizardID URL parameter.
+// It returns a 404 HTTPError if the session does not exist, has expired or belongs to another owner.
+// Routes registered next to the wizard's routes (e.g. <Route>/{wizardID}/report) use it to read the wizard's values.
+func (w *Wizard) SessionFromParams(io IO) (*WizardSession, error) {
+	session, err := w.session(io, URLParam(io.Request(), "wizardID"))
+	if err != nil {
+		return nil, NewHTTPError(http.StatusNotFound, ErrWizardNotFound, err)
+	}
+
+	return session, nil
+}
+
+// session reads the session by id. It returns ErrWizardNotFound if the session belongs to another wizard or owner.
+func (w *Wizard) session(io IO, id string) (*WizardSession, error) {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.Join(ErrWizardNotFound, err)
+	}
+
+	session, err := MustRepository[WizardRepository](io, WizardRepositoryName).Read(io.Context(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Type != WizardSessionType || session.Payload.Wizard != w.Name || session.Payload.Owner != w.owner(io) {
+		return nil, ErrWizardNotFound
+	}
+
+	if session.Payload.Values == nil {
+		session.Payload.Values = make(map[string]json.RawMessage)
+	}
+
+	return session, nil
+}
+
+// page returns the WizardPage of the step with the index without a submitted form.
+func (w *Wizard) page(session *WizardSession, index int) *WizardPage {
+	return &WizardPage{
+		FormData: NewFormData[any](nil, nil),
+		Wizard:   w,
+		Session:  session,
+		Index:    index,
+	}
+}
+
+// stepIndex returns the index of the step by name or -1 if the wizard has no such step.
+func (w *Wizard) stepIndex(name string) int {
+	for i, step := range w.Steps {
+		if step.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// owner returns the owner of the request through Wizard.Owner or an empty string if Owner is nil.
+func (w *Wizard) owner(io IO) string {
+	if w.Owner == nil {
+		return ""
+	}
+
+	return w.Owner(io)
+}
+
+// ttl returns the Wizard's TTL or DefaultWizardTTL.
+func (w *Wizard) ttl() time.Duration {
+	if w.TTL <= 0 {
+		return DefaultWizardTTL
+	}
+
+	return w.TTL
+}
+
+// cookieName returns the name of the cookie storing the wizard's session id.
+func (w *Wizard) cookieName() string {
+	return wizardCookiePrefix + w.Name
+}
+
+// Value decodes the values of the step into v. It returns false if the step has no values or they could not be decoded.
+func (s *WizardSession) Value(step string, v any) bool {
+	raw, ok := s.Payload.Values[step]
+	if !ok {
+		return false
+	}
+
+	return json.Unmarshal(raw, v) == nil
+}
+
+// SetValue stores the JSON encoded value as the values of the step.
+func (s *WizardSession) SetValue(step string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if s.Payload.Values == nil {
+		s.Payload.Values = make(map[string]json.RawMessage)
+	}
+	s.Payload.Values[step] = raw
+
+	return nil
+}
+
+// WizardValue returns the values of the step decoded into a T. If the step has no values, the fallback is returned.
+func WizardValue[T any](session *WizardSession, step string, fallback T) T {
+	var v T
+	if !session.Value(step, &v) {
+		return fallback
+	}
+
+	return v
+}
+
+// Step returns the rendered step.
+func (p *WizardPage) Step() WizardStep {
+	return p.Wizard.Steps[p.Index]
+}
+
+// Number returns the 1-based number of the rendered step.
+func (p *WizardPage) Number() int {
+	return p.Index + 1
+}
+
+// First returns true if the rendered step is the first step.
+func (p *WizardPage) First() bool {
+	return p.Index == 0
+}
+
+// Last returns true if the rendered step is the last step. Submitting the last step completes the wizard.
+func (p *WizardPage) Last() bool {
+	return p.Index == len(p.Wizard.Steps)-1
+}
+
+// Reached returns true if the step with the index has been reached and can be navigated to.
+func (p *WizardPage) Reached(index int) bool {
+	return index <= p.Session.Payload.Reached
+}
+
+// URL returns the URL of the rendered step. The step's form is submitted to this URL.
+func (p *WizardPage) URL() string {
+	return p.Wizard.StepURL(p.Session, p.Index)
+}
+
+// StepURL returns the URL of the step with the index.
+func (p *WizardPage) StepURL(index int) string {
+	return p.Wizard.StepURL(p.Session, index)
+}
+
+// CancelURL returns the URL canceling the wizard.
+func (p *WizardPage) CancelURL() string {
+	return p.Wizard.Route + "/" + p.Session.ID.String() + "/cancel"
+}
+
+// FormID returns the id of the step's form element. The wizard's buttons are associated with the form through this id.
+func (p *WizardPage) FormID() string {
+	return "wizard-" + p.Wizard.Name + "-" + p.Step().Name
+}
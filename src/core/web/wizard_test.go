@@ -0,0 +1,250 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"github.com/org-harmony/harmony/src/core/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memWizardRepository struct {
+	sessions map[uuid.UUID]WizardSession
+}
+
+func (r *memWizardRepository) RepositoryName() string {
+	return WizardRepositoryName
+}
+
+func (r *memWizardRepository) Read(ctx context.Context, id uuid.UUID) (*WizardSession, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+
+	if session.ExpiresAt.Before(time.Now()) {
+		delete(r.sessions, id)
+		return nil, persistence.ErrSessionExpired
+	}
+
+	return &session, nil
+}
+
+func (r *memWizardRepository) Write(ctx context.Context, id uuid.UUID, session *WizardSession) error {
+	session.ID = id
+	r.sessions[id] = *session
+	return nil
+}
+
+func (r *memWizardRepository) Insert(ctx context.Context, session *WizardSession) error {
+	return r.Write(ctx, uuid.New(), session)
+}
+
+func (r *memWizardRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.sessions, id)
+	return nil
+}
+
+func TestWizard(t *testing.T) {
+	appCtx, webCtx := setupMockCtxs(t)
+	repository := &memWizardRepository{sessions: make(map[uuid.UUID]WizardSession)}
+	appCtx.Repositories = &fakeRepositoryProvider{repositories: map[string]persistence.Repository{
+		WizardRepositoryName: repository,
+	}}
+
+	var rendered *WizardPage
+	var completed *WizardSession
+	render := func(io IO, page *WizardPage) error {
+		rendered = page
+		return nil
+	}
+	submit := func(io IO, page *WizardPage) error {
+		value := io.Request().FormValue("value")
+		page.Form = value
+		if value == "" {
+			page.ViolationsFromErrors(validation.Error{Field: "value", Msg: "required"})
+			return nil
+		}
+
+		return page.Session.SetValue(page.Step().Name, value)
+	}
+
+	wizard := &Wizard{
+		Name:  "test",
+		Route: "/wizard",
+		Steps: []WizardStep{
+			{Name: "one", Render: render, Submit: submit},
+			{Name: "two", Render: render, Submit: submit},
+		},
+		Owner: func(io IO) string {
+			return io.Request().Header.Get("X-Owner")
+		},
+		Complete: func(io IO, session *WizardSession) (string, error) {
+			completed = session
+			return "/done", nil
+		},
+		CancelURL: "/canceled",
+	}
+	wizard.Register(appCtx, webCtx, webCtx.Router)
+
+	serve := func(method, target, owner string, values url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(values.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("X-Owner", owner)
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+
+		recorder := httptest.NewRecorder()
+		webCtx.Router.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	recorder := serve(http.MethodGet, "/wizard", "alice", nil)
+	require.Equal(t, http.StatusFound, recorder.Code)
+	require.Len(t, repository.sessions, 1)
+	require.Len(t, recorder.Result().Cookies(), 1)
+
+	cookie := recorder.Result().Cookies()[0]
+	sessionID := cookie.Value
+	stepOne := "/wizard/" + sessionID + "/one"
+	stepTwo := "/wizard/" + sessionID + "/two"
+	assert.Equal(t, "harmony_wizard_test", cookie.Name)
+	assert.Equal(t, stepOne, recorder.Header().Get("Location"))
+
+	t.Run("resume", func(t *testing.T) {
+		recorder := serve(http.MethodGet, "/wizard", "alice", nil, cookie)
+		assert.Equal(t, stepOne, recorder.Header().Get("Location"))
+		assert.Len(t, repository.sessions, 1)
+
+		recorder = serve(http.MethodGet, "/wizard", "bob", nil, cookie)
+		assert.NotEqual(t, stepOne, recorder.Header().Get("Location"))
+		assert.Len(t, repository.sessions, 2)
+	})
+
+	t.Run("foreign session", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, stepOne, "bob", nil).Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/wizard/"+uuid.NewString()+"/one", "alice", nil).Code)
+	})
+
+	t.Run("step not reached", func(t *testing.T) {
+		recorder := serve(http.MethodGet, stepTwo, "alice", nil)
+		assert.Equal(t, http.StatusFound, recorder.Code)
+		assert.Equal(t, stepOne, recorder.Header().Get("Location"))
+	})
+
+	t.Run("invalid step", func(t *testing.T) {
+		rendered = nil
+		recorder := serve(http.MethodPost, stepOne, "alice", url.Values{WizardActionField: {WizardActionNext}})
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		require.NotNil(t, rendered)
+		assert.False(t, rendered.Valid())
+		assert.Equal(t, 0, rendered.Session.Payload.Reached)
+	})
+
+	t.Run("check step", func(t *testing.T) {
+		rendered = nil
+		serve(http.MethodPost, stepOne, "alice", url.Values{WizardActionField: {WizardActionCheck}, "value": {"checked"}})
+		require.NotNil(t, rendered)
+		assert.True(t, rendered.Valid())
+		assert.Equal(t, 0, rendered.Session.Payload.Reached)
+	})
+
+	t.Run("next and back", func(t *testing.T) {
+		recorder := serve(http.MethodPost, stepOne, "alice", url.Values{WizardActionField: {WizardActionNext}, "value": {"first"}})
+		assert.Equal(t, stepTwo, recorder.Header().Get("Location"))
+
+		session, err := repository.Read(context.Background(), uuid.MustParse(sessionID))
+		require.NoError(t, err)
+		assert.Equal(t, 1, session.Payload.Reached)
+		assert.Equal(t, "first", WizardValue(session, "one", ""))
+
+		rendered = nil
+		serve(http.MethodGet, stepTwo, "alice", nil)
+		require.NotNil(t, rendered)
+		assert.Nil(t, rendered.Form)
+		assert.True(t, rendered.Last())
+
+		recorder = serve(http.MethodPost, stepTwo, "alice", url.Values{WizardActionField: {WizardActionBack}})
+		assert.Equal(t, stepOne, recorder.Header().Get("Location"))
+
+		recorder = serve(http.MethodGet, stepTwo, "alice", nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		recorder := serve(http.MethodPost, stepTwo, "alice", url.Values{WizardActionField: {WizardActionNext}, "value": {"second"}})
+		assert.Equal(t, "/done", recorder.Header().Get("Location"))
+		require.NotNil(t, completed)
+		assert.Equal(t, "first", WizardValue(completed, "one", ""))
+		assert.Equal(t, "second", WizardValue(completed, "two", ""))
+		assert.NotContains(t, repository.sessions, uuid.MustParse(sessionID))
+		cookies := recorder.Result().Cookies()
+		assert.Equal(t, -1, cookies[len(cookies)-1].MaxAge)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		recorder := serve(http.MethodGet, "/wizard", "carol", nil)
+		id := recorder.Result().Cookies()[0].Value
+
+		recorder = serve(http.MethodPost, "/wizard/"+id+"/cancel", "carol", nil)
+		assert.Equal(t, "/canceled", recorder.Header().Get("Location"))
+		assert.NotContains(t, repository.sessions, uuid.MustParse(id))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		recorder := serve(http.MethodGet, "/wizard", "dave", nil)
+		id := uuid.MustParse(recorder.Result().Cookies()[0].Value)
+
+		session := repository.sessions[id]
+		session.ExpiresAt = time.Now().Add(-time.Minute)
+		repository.sessions[id] = session
+
+		recorder = serve(http.MethodGet, "/wizard/"+id.String()+"/one", "dave", nil)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestWizardTemplate(t *testing.T) {
+	tmpl, err := template.New("test").
+		Funcs(templateFuncs(&UICfg{})).
+		ParseFiles(filepath.Join("..", "..", "..", "templates", "base", "wizard.go.html"))
+	require.NoError(t, err)
+
+	wizard := &Wizard{Name: "test", Route: "/wizard", Steps: []WizardStep{
+		{Name: "one", Title: "step.one"},
+		{Name: "two", Title: "step.two"},
+		{Name: "three", Title: "step.three"},
+	}}
+	session := &WizardSession{}
+	session.ID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	session.Payload.Reached = 1
+	page := &WizardPage{Wizard: wizard, Session: session, Index: 1}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, tmpl.ExecuteTemplate(buf, "harmony.wizard.steps", page))
+	out := buf.String()
+	assert.Contains(t, out, `<a href="/wizard/00000000-0000-0000-0000-000000000001/one" class="link-body-emphasis">1. step.one</a>`)
+	assert.NotContains(t, out, `/two"`)
+	assert.NotContains(t, out, `/three"`)
+
+	buf.Reset()
+	require.NoError(t, tmpl.ExecuteTemplate(buf, "harmony.wizard.buttons", page))
+	out = buf.String()
+	assert.Contains(t, out, `form="wizard-test-two"`)
+	assert.Contains(t, out, `formaction="/wizard/00000000-0000-0000-0000-000000000001/cancel"`)
+	assert.Contains(t, out, `value="back"`)
+	assert.Contains(t, out, `harmony.wizard.next`)
+	assert.NotContains(t, out, `harmony.wizard.finish`)
+}
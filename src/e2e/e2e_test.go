@@ -0,0 +1,133 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/org-harmony/harmony/src/app/template"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+)
+
+var app *App
+
+func TestMain(m *testing.M) {
+	var err error
+	app, err = Start("./../../")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	result := m.Run()
+	app.Stop()
+	os.Exit(result)
+}
+
+const templateConfig = `{
+	"id": "e2e", "type": "ebt", "name": "E2E", "version": "1.0.0", "authors": ["HARMONY"], "license": "MIT",
+	"rules": {
+		"system": {"name": "System", "type": "placeholder"},
+		"modal": {"name": "Modal", "type": "equalsAny", "value": ["shall", "should"]},
+		"process": {"name": "Process", "type": "placeholder"}
+	},
+	"variants": {"default": {"name": "Default", "rules": ["system", "modal", "process"]}}
+}`
+
+func TestLogin(t *testing.T) {
+	response, err := app.Client().Get("/template-set/list")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, response.StatusCode)
+	assert.Equal(t, "/auth/login", response.Header.Get("Location"))
+
+	client, _, err := app.Login(context.Background(), &user.ToCreate{Email: "login@e2e.test", Firstname: "Login", Lastname: "E2E"})
+	require.NoError(t, err)
+
+	response, err = client.Get("/template-set/list")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestTemplateCRUDAndElicitation(t *testing.T) {
+	ctx := context.Background()
+	client, usr, err := app.Login(ctx, &user.ToCreate{Email: "crud@e2e.test", Firstname: "CRUD", Lastname: "E2E"})
+	require.NoError(t, err)
+
+	response, err := client.PostForm("/template-set/new", url.Values{"Name": {"E2E Set"}, "Version": {"1.0.0"}})
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusFound, response.StatusCode)
+
+	templateSets, err := template.NewSetRepository(app.DB).FindByCreatedBy(ctx, usr.ID)
+	require.NoError(t, err)
+	require.Len(t, templateSets, 1)
+	templateSet := templateSets[0]
+
+	response, err = client.Get("/template-set/list")
+	require.NoError(t, err)
+	body, err := ReadBody(response)
+	require.NoError(t, err)
+	assert.Contains(t, body, "E2E Set")
+
+	response, err = client.PostForm(fmt.Sprintf("/template-set/%s/new", templateSet.ID), url.Values{"Config": {templateConfig}})
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusFound, response.StatusCode)
+
+	templates, err := template.NewRepository(app.DB).FindByTemplateSetID(ctx, templateSet.ID)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	tmpl := templates[0]
+
+	t.Run("read template", func(t *testing.T) {
+		response, err := client.Get(fmt.Sprintf("/template/%s", tmpl.ID), "Accept", "application/json")
+		require.NoError(t, err)
+		body, err := ReadBody(response)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		read := template.Template{}
+		require.NoError(t, json.Unmarshal([]byte(body), &read))
+		assert.Equal(t, tmpl.ID, read.ID)
+		assert.Equal(t, "E2E", read.Name)
+	})
+
+	t.Run("parse requirement", func(t *testing.T) {
+		path := fmt.Sprintf("/eiffel/elicitation/%s/default", tmpl.ID)
+		response, err := client.PostForm(path, url.Values{
+			"segment-system":  {"The system"},
+			"segment-modal":   {"shall"},
+			"segment-process": {"parse requirements"},
+		})
+		require.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.NotEmpty(t, response.Header.Get("ParsingSuccessEvent"))
+
+		response, err = client.PostForm(path, url.Values{"segment-system": {"The system"}, "segment-modal": {"must"}})
+		require.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Empty(t, response.Header.Get("ParsingSuccessEvent"))
+	})
+
+	t.Run("delete template", func(t *testing.T) {
+		response, err := client.Do(http.MethodDelete, fmt.Sprintf("/template/%s", tmpl.ID), nil)
+		require.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		templates, err := template.NewRepository(app.DB).FindByTemplateSetID(ctx, templateSet.ID)
+		require.NoError(t, err)
+		assert.Empty(t, templates)
+	})
+}
@@ -0,0 +1,306 @@
+// Package e2e provides a harness for end-to-end tests of the web application. The harness builds and starts the web
+// application (src/cmd/web) against a disposable database and exercises it through HTTP like a browser would.
+//
+// The end-to-end tests require a running PostgreSQL server configured like the test database (see config/persistence.test.toml
+// and the DB_* environment variables), e.g. started with docker/e2e/docker-compose.yml. They are excluded from go test ./...
+// by the e2e build tag and run with:
+//
+//	go test -tags e2e ./src/e2e/...
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/org-harmony/harmony/src/app/user"
+	"github.com/org-harmony/harmony/src/core/persistence"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// StartTimeout is the maximum duration to wait for the web application to respond after starting it.
+	StartTimeout = 30 * time.Second
+	// StopTimeout is the maximum duration to wait for the web application to shut down before it is killed.
+	StopTimeout = 10 * time.Second
+)
+
+// ErrNotStarted is returned by Start if the web application did not respond in time or exited while starting.
+var ErrNotStarted = errors.New("web application did not start")
+
+// App is the web application started by Start. It serves on BaseURL and uses DB, a disposable database with all migrations
+// applied that is dropped on Stop. The web application's output is captured and returned by Logs.
+type App struct {
+	BaseURL string
+	DB      *pgxpool.Pool
+	cmd     *exec.Cmd
+	// binaryDir is the temporary directory of the built web application removed on Stop.
+	binaryDir string
+	logs      *logBuffer
+	exited    chan error
+}
+
+// Client is an HTTP client for the App keeping the cookies (e.g. the session) between requests.
+// Redirects are not followed, the redirect responses are returned instead.
+type Client struct {
+	app  *App
+	http *http.Client
+}
+
+// logBuffer is a buffer safe for concurrent writes of the web application's output and reads by Logs.
+type logBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+// Start creates a disposable database, builds the web application and starts it on a free port. The baseDir is the root
+// directory of the repository containing the go.mod, config, migrations, templates and translations.
+// The environment is passed on to the web application, the database name, port and base url are overwritten.
+// The App must be stopped after use, see App.Stop.
+func Start(baseDir string) (*App, error) {
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	binaryDir, err := os.MkdirTemp("", "harmony-e2e")
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := build(baseDir, binaryDir)
+	if err != nil {
+		_ = os.RemoveAll(binaryDir)
+		return nil, err
+	}
+
+	port, err := freePort()
+	if err != nil {
+		_ = os.RemoveAll(binaryDir)
+		return nil, err
+	}
+
+	db := persistence.InitTestDB(baseDir)
+	app := &App{
+		BaseURL:   fmt.Sprintf("http://localhost:%d", port),
+		DB:        db,
+		binaryDir: binaryDir,
+		logs:      &logBuffer{},
+		exited:    make(chan error, 1),
+	}
+
+	app.cmd = exec.Command(binary)
+	app.cmd.Dir = baseDir
+	app.cmd.Stdout = app.logs
+	app.cmd.Stderr = app.logs
+	app.cmd.Env = append(
+		os.Environ(),
+		"DB_NAME="+db.Config().ConnConfig.Database,
+		fmt.Sprintf("PORT=%d", port),
+		"BASE_URL="+app.BaseURL,
+	)
+
+	err = app.cmd.Start()
+	if err != nil {
+		app.cleanup()
+		return nil, err
+	}
+
+	go func() {
+		app.exited <- app.cmd.Wait()
+	}()
+
+	err = app.waitUntilReady()
+	if err != nil {
+		app.Stop()
+		return nil, fmt.Errorf("%w: %w\n%s", ErrNotStarted, err, app.Logs())
+	}
+
+	return app, nil
+}
+
+// Stop stops the web application and drops its database.
+func (a *App) Stop() {
+	select {
+	case <-a.exited:
+	default:
+		_ = a.cmd.Process.Signal(os.Interrupt)
+
+		select {
+		case <-a.exited:
+		case <-time.After(StopTimeout):
+			_ = a.cmd.Process.Kill()
+			<-a.exited
+		}
+	}
+
+	a.cleanup()
+}
+
+// Logs returns the output of the web application.
+func (a *App) Logs() string {
+	a.logs.mu.Lock()
+	defer a.logs.mu.Unlock()
+
+	return a.logs.buf.String()
+}
+
+// Client returns a new anonymous client for the App.
+func (a *App) Client() *Client {
+	jar, _ := cookiejar.New(nil)
+
+	return &Client{
+		app: a,
+		http: &http.Client{
+			Jar:     jar,
+			Timeout: StartTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Login creates the user and returns a client logged in as the user. This stubs the login through an OAuth2 provider
+// by storing a session for the user in the database and setting its cookie on the client (see user.Login).
+func (a *App) Login(ctx context.Context, toCreate *user.ToCreate) (*Client, *user.User, error) {
+	usr, err := user.NewUserRepository(a.DB).Create(ctx, toCreate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := user.Login(ctx, usr, user.NewPGUserSessionRepository(a.DB))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := a.Client()
+	baseURL, err := url.Parse(a.BaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	client.http.Jar.SetCookies(baseURL, []*http.Cookie{{Name: user.SessionCookieName, Value: session.ID.String(), Path: "/"}})
+
+	return client, usr, nil
+}
+
+// Get requests the path of the App.
+func (c *Client) Get(path string, header ...string) (*http.Response, error) {
+	return c.Do(http.MethodGet, path, nil, header...)
+}
+
+// PostForm posts the form values to the path of the App.
+func (c *Client) PostForm(path string, values url.Values, header ...string) (*http.Response, error) {
+	header = append(header, "Content-Type", "application/x-www-form-urlencoded")
+
+	return c.Do(http.MethodPost, path, strings.NewReader(values.Encode()), header...)
+}
+
+// Do sends a request with the method to the path of the App. The header is passed in as key-value pairs,
+// e.g. "Accept", "application/json". The response body must be closed by the caller, see ReadBody.
+func (c *Client) Do(method string, path string, body io.Reader, header ...string) (*http.Response, error) {
+	request, err := http.NewRequest(method, c.app.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i+1 < len(header); i += 2 {
+		request.Header.Set(header[i], header[i+1])
+	}
+
+	return c.http.Do(request)
+}
+
+// ReadBody reads and closes the body of the response.
+func ReadBody(response *http.Response) (string, error) {
+	defer response.Body.Close()
+
+	var buf bytes.Buffer
+	_, err := buf.ReadFrom(response.Body)
+
+	return buf.String(), err
+}
+
+// Write implements io.Writer for the logBuffer.
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// waitUntilReady polls the heartbeat (/ping) of the web application until it responds or the StartTimeout is exceeded.
+func (a *App) waitUntilReady() error {
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(StartTimeout)
+
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-a.exited:
+			a.exited <- err
+			return fmt.Errorf("web application exited: %v", err)
+		default:
+		}
+
+		response, err := client.Get(a.BaseURL + "/ping")
+		if err == nil {
+			response.Body.Close()
+			if response.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("no response after %s", StartTimeout)
+}
+
+// cleanup terminates the remaining connections of the web application to the database and drops it by closing the pool.
+// The built web application is removed.
+func (a *App) cleanup() {
+	defer os.RemoveAll(a.binaryDir)
+
+	_, _ = a.DB.Exec(
+		context.Background(),
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()",
+		a.DB.Config().ConnConfig.Database,
+	)
+
+	a.DB.Close()
+}
+
+// build builds the web application into the directory and returns the path of the binary.
+func build(baseDir string, dir string) (string, error) {
+	binary := filepath.Join(dir, "web")
+	cmd := exec.Command("go", "build", "-o", binary, "./src/cmd/web")
+	cmd.Dir = baseDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("building web application failed: %w\n%s", err, output)
+	}
+
+	return binary, nil
+}
+
+// freePort returns a free TCP port on localhost.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}